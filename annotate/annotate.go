@@ -0,0 +1,403 @@
+// Package annotate evaluates games with a UCI engine, classifies each move as
+// an inaccuracy, a mistake or a blunder, and computes the accuracy of both
+// players.
+//
+// Every position of a game's main line is searched with the same limits. A
+// move's loss is how much worse the position is after it than before it, from
+// the point of view of the player who made it. By default, the loss is
+// measured as expected score, using uci.Score.WinProbability, so that a
+// missed win counts for more than the same number of centipawns in a position
+// that is lost anyway.
+package annotate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/uci"
+)
+
+// Class is the classification of a move.
+type Class int
+
+const (
+	Good Class = iota // The move loses less than an inaccuracy.
+	Inaccuracy
+	Mistake
+	Blunder
+)
+
+var classNames = [...]string{
+	Good:       "Good",
+	Inaccuracy: "Inaccuracy",
+	Mistake:    "Mistake",
+	Blunder:    "Blunder",
+}
+
+func (c Class) String() string {
+	if c < 0 || int(c) >= len(classNames) {
+		return fmt.Sprintf("Class(%d)", int(c))
+	}
+	return classNames[c]
+}
+
+// NAG returns the Numeric Annotation Glyph for the class: 6 for "?!", 2 for
+// "?" and 4 for "??". Good moves have none, and return 0.
+func (c Class) NAG() int {
+	switch c {
+	case Inaccuracy:
+		return 6
+	case Mistake:
+		return 2
+	case Blunder:
+		return 4
+	}
+	return 0
+}
+
+// Thresholds are the smallest losses for each class of move.
+type Thresholds struct {
+	// Centipawns measures losses in centipawns instead of expected score.
+	// Mates count as 1000 centipawns.
+	Centipawns bool
+
+	Inaccuracy, Mistake, Blunder float64
+}
+
+var (
+	// DefaultThresholds measure losses in expected score, from 0 to 1.
+	DefaultThresholds = Thresholds{Inaccuracy: 0.1, Mistake: 0.2, Blunder: 0.3}
+
+	// CentipawnThresholds are traditional thresholds in centipawns.
+	CentipawnThresholds = Thresholds{Centipawns: true, Inaccuracy: 50, Mistake: 100, Blunder: 300}
+)
+
+// classify returns the class of a move that loses loss.
+func (t Thresholds) classify(loss float64) Class {
+	switch {
+	case loss >= t.Blunder:
+		return Blunder
+	case loss >= t.Mistake:
+		return Mistake
+	case loss >= t.Inaccuracy:
+		return Inaccuracy
+	}
+	return Good
+}
+
+// Annotator evaluates games with an engine.
+type Annotator struct {
+	// Engine is the engine to use. It must have completed the "uci"
+	// handshake, and its options must already be set.
+	Engine *uci.Client
+
+	// Search limits the search of each position, such as to a fixed depth.
+	// It must end on its own.
+	Search uci.Search
+
+	// Thresholds classify moves. If zero, DefaultThresholds are used.
+	Thresholds Thresholds
+
+	// Fingerprint identifies the engine in reports. If nil, that of Engine
+	// is used, which only covers its "uci" handshake; set it, such as with
+	// uci.Engine's Fingerprint and a bench signature, to identify the exact
+	// build.
+	Fingerprint *uci.Fingerprint
+}
+
+// MoveReport is the evaluation of a move.
+type MoveReport struct {
+	Ply   int         // The index of the move in the main line.
+	Color chess.Color // The side that made the move.
+	Move  chess.Move
+
+	// Before is the evaluation of the position before the move, from the
+	// point of view of the side that made it. After is the evaluation of the
+	// position after the move, from the point of view of the opponent, who is
+	// then to move. If the move checkmates, After is a mate in 0.
+	Before, After uci.Score
+
+	Best     chess.Move   // The engine's best move in the position before the move.
+	BestLine []chess.Move // The engine's principal variation, starting with Best.
+
+	CPLoss   int     // The loss in centipawns, with mates counted as 1000.
+	WinLoss  float64 // The loss in expected score, from 0 to 1.
+	Class    Class
+	Accuracy float64 // From 0 to 100.
+}
+
+// Report is the evaluation of a game.
+type Report struct {
+	Moves []MoveReport // The moves of the main line, in order.
+
+	// Accuracy is the average accuracy of each side's moves, from 0 to 100,
+	// and ACPL their average centipawn loss. Both are indexed by color.
+	Accuracy [2]float64
+	ACPL     [2]float64
+
+	Engine uci.Fingerprint // The engine that evaluated the game.
+}
+
+// Count returns the number of moves of class cl made by c.
+func (r *Report) Count(c chess.Color, cl Class) int {
+	n := 0
+	for _, m := range r.Moves {
+		if m.Color == c && m.Class == cl {
+			n++
+		}
+	}
+	return n
+}
+
+// Analyze evaluates every position of the main line of g, and classifies its
+// moves. g isn't modified; see Report.Annotate.
+func (a *Annotator) Analyze(ctx context.Context, g *pgn.Game) (*Report, error) {
+	if a.Engine == nil {
+		return nil, errors.New("annotate: no engine")
+	}
+	start, err := g.StartingPosition()
+	if err != nil {
+		return nil, fmt.Errorf("annotate: %w", err)
+	}
+	moves := g.MainLine()
+
+	// Find the positions to search. Checkmate and stalemate are scored
+	// without the engine.
+	evals := make([]uci.BatchResult, len(moves)+1)
+	var (
+		params  []uci.PositionParams
+		indexes []int // The ply of each position in params.
+	)
+	pos := start.Clone()
+	for i := 0; i <= len(moves); i++ {
+		switch {
+		case len(pos.LegalMoves()) > 0:
+			p := uci.PositionParams{StartPos: g.Tag("FEN") == "", Moves: moves[:i]}
+			if !p.StartPos {
+				p.FEN = start.String()
+			}
+			params = append(params, p)
+			indexes = append(indexes, i)
+		case pos.InCheck():
+			evals[i].Info.Score.Mate.Found = true
+		}
+		if i < len(moves) {
+			if !pos.IsLegal(moves[i]) {
+				return nil, fmt.Errorf("annotate: illegal move %v in position %v", moves[i], pos)
+			}
+			pos.Apply(moves[i])
+		}
+	}
+
+	if err := a.Engine.UCINewGame(); err != nil {
+		return nil, err
+	}
+	if err := a.Engine.IsReadyContext(ctx); err != nil {
+		return nil, err
+	}
+	_, err = a.Engine.SearchBatch(ctx, params, a.Search, func(r uci.BatchResult) {
+		evals[indexes[r.Index]] = r
+	})
+	if err != nil {
+		return nil, err
+	}
+	rep := a.report(start, moves, evals)
+	if a.Fingerprint != nil {
+		rep.Engine = *a.Fingerprint
+	} else {
+		rep.Engine = a.Engine.Fingerprint()
+	}
+	return rep, nil
+}
+
+// report builds the report of a game from the evaluations of its positions.
+func (a *Annotator) report(start *chess.Position, moves []chess.Move, evals []uci.BatchResult) *Report {
+	t := a.Thresholds
+	if t == (Thresholds{}) {
+		t = DefaultThresholds
+	}
+
+	r := &Report{Moves: make([]MoveReport, len(moves))}
+	var (
+		accuracy, cpLoss [2]float64
+		count            [2]int
+	)
+	pos := start.Clone()
+	for i, m := range moves {
+		before, after := evals[i], evals[i+1]
+		mr := MoveReport{
+			Ply:      i,
+			Color:    pos.SideToMove(),
+			Move:     m,
+			Before:   before.Info.Score,
+			After:    after.Info.Score,
+			Best:     before.BestMove.Move,
+			BestLine: before.Info.PV,
+		}
+		if len(mr.BestLine) == 0 && mr.Best != (chess.Move{}) {
+			mr.BestLine = []chess.Move{mr.Best}
+		}
+
+		mr.WinLoss = math.Max(0, mr.Before.WinProbability()+mr.After.WinProbability()-1)
+		mr.CPLoss = max(0, centipawns(mr.Before)+centipawns(mr.After))
+		if m == mr.Best {
+			// Differences in the evaluations of the best move are noise.
+			mr.WinLoss, mr.CPLoss = 0, 0
+		}
+		loss := mr.WinLoss
+		if t.Centipawns {
+			loss = float64(mr.CPLoss)
+		}
+		mr.Class = t.classify(loss)
+		mr.Accuracy = moveAccuracy(mr.WinLoss)
+
+		c := mr.Color
+		accuracy[c] += mr.Accuracy
+		cpLoss[c] += float64(mr.CPLoss)
+		count[c]++
+		r.Moves[i] = mr
+		pos.Apply(m)
+	}
+	for c := range count {
+		if count[c] > 0 {
+			r.Accuracy[c] = accuracy[c] / float64(count[c])
+			r.ACPL[c] = cpLoss[c] / float64(count[c])
+		}
+	}
+	return r
+}
+
+// Annotate adds the report to the main line of g, which must be the game that
+// was analyzed. Each move gets the evaluation after it as an "%eval" command,
+// such as "[%eval 0.31]" or "[%eval #-3]", from White's point of view. Inaccuracies,
+// mistakes and blunders also get their NAG, and mistakes and blunders get a
+// comment naming the best move and a variation with the engine's best line.
+//
+// The engine is named in the Annotator tag, unless g already has one, and
+// identified by the ID of its fingerprint in the AnnotatorFingerprint tag.
+func (r *Report) Annotate(g *pgn.Game) error {
+	if len(g.Moves) != len(r.Moves) {
+		return fmt.Errorf("annotate: game has %d moves, report has %d", len(g.Moves), len(r.Moves))
+	}
+	pos, err := g.StartingPosition()
+	if err != nil {
+		return fmt.Errorf("annotate: %w", err)
+	}
+	for i, m := range g.Moves {
+		mr := r.Moves[i]
+		if m.Move != mr.Move {
+			return fmt.Errorf("annotate: move %d is %v, report has %v", i, m.Move, mr.Move)
+		}
+		if nag := mr.Class.NAG(); nag != 0 && !hasNAG(m, nag) {
+			m.NAGs = append(m.NAGs, nag)
+		}
+		if mr.Class >= Mistake && mr.Best != (chess.Move{}) && pos.IsLegal(mr.Best) {
+			m.Comments = append(m.Comments, fmt.Sprintf("%v. %s was best.", mr.Class, chess.FormatSAN(pos, mr.Best)))
+			if line := variation(pos, mr.BestLine); len(line) > 0 {
+				m.Variations = append(m.Variations, line)
+			}
+		}
+		if eval, ok := pgnEval(mr.After, mr.Color.Other()); ok {
+			m.Commands.Eval = eval
+		}
+		pos.Apply(m.Move)
+	}
+	if g.Tag("Annotator") == "" && r.Engine.Name != "" {
+		setTag(g, "Annotator", r.Engine.Name)
+	}
+	setTag(g, "AnnotatorFingerprint", r.Engine.ID())
+	return nil
+}
+
+// setTag sets the tag called name to value, adding it if g doesn't have it.
+func setTag(g *pgn.Game, name, value string) {
+	for i := range g.Tags {
+		if g.Tags[i].Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, pgn.Tag{Name: name, Value: value})
+}
+
+// hasNAG reports whether m has the NAG.
+func hasNAG(m *pgn.Move, nag int) bool {
+	for _, n := range m.NAGs {
+		if n == nag {
+			return true
+		}
+	}
+	return false
+}
+
+// variation returns moves played from pos as PGN moves, stopping at the first
+// illegal move.
+func variation(pos *chess.Position, moves []chess.Move) []*pgn.Move {
+	pos = pos.Clone()
+	var res []*pgn.Move
+	for _, m := range moves {
+		if !pos.IsLegal(m) {
+			break
+		}
+		res = append(res, &pgn.Move{Move: m, SAN: chess.FormatSAN(pos, m)})
+		pos.Apply(m)
+	}
+	return res
+}
+
+// pgnEval returns an evaluation of a position with c to move as an "%eval"
+// command, from White's point of view. It reports false for checkmate, which
+// needs no evaluation.
+func pgnEval(s uci.Score, c chess.Color) (*pgn.Eval, bool) {
+	sign := 1
+	if c == chess.Black {
+		sign = -1
+	}
+	if s.Mate.Found {
+		if s.Mate.MovesUntil == 0 {
+			return nil, false
+		}
+		return &pgn.Eval{Mate: sign * s.Mate.MovesUntil}, true
+	}
+	return &pgn.Eval{CP: sign * s.CP}, true
+}
+
+// moveAccuracy returns the accuracy of a move that loses loss in expected
+// score, with the formula used by Lichess.
+func moveAccuracy(loss float64) float64 {
+	a := 103.1668*math.Exp(-0.04354*loss*100) - 3.1669
+	return math.Max(0, math.Min(100, a))
+}
+
+// mateCentipawns is the value of a mate in centipawns.
+const mateCentipawns = 1000
+
+// centipawns returns s in centipawns, limited to plus or minus the value of a
+// mate.
+func centipawns(s uci.Score) int {
+	switch {
+	case s.Mate.Found && s.Mate.MovesUntil > 0:
+		return mateCentipawns
+	case s.Mate.Found:
+		return -mateCentipawns
+	}
+	return max(-mateCentipawns, min(mateCentipawns, s.CP))
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,242 @@
+package annotate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/uci"
+)
+
+// scriptedEngine returns a client connected to a fake engine. When searching a
+// position after n moves, the engine reports the score and principal
+// variation evals[n], such as "cp 30 pv e2e4 e7e5", and plays the first move
+// of the variation.
+func scriptedEngine(t *testing.T, evals []string) *uci.Client {
+	t.Helper()
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	go func() {
+		var n int
+		s := bufio.NewScanner(engineIn)
+		for s.Scan() {
+			cmd := s.Text()
+			switch {
+			case cmd == "uci":
+				io.WriteString(engineOut, "id name Scripted\nuciok\n")
+			case cmd == "isready":
+				io.WriteString(engineOut, "readyok\n")
+			case strings.HasPrefix(cmd, "position"):
+				n = 0
+				if _, after, ok := strings.Cut(cmd, " moves "); ok {
+					n = len(strings.Fields(after))
+				}
+			case strings.HasPrefix(cmd, "go"):
+				_, pv, _ := strings.Cut(evals[n], " pv ")
+				best := strings.Fields(pv)[0]
+				io.WriteString(engineOut, "info depth 1 score "+evals[n]+"\nbestmove "+best+"\n")
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		engineOut.Close()
+		w.Close()
+	})
+	c := uci.NewClient(r, w)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func move(t *testing.T, s string) chess.Move {
+	t.Helper()
+	m, err := chess.ParseMove(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func readGame(t *testing.T, s string) *pgn.Game {
+	t.Helper()
+	g, err := pgn.NewReader(strings.NewReader(s)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// scholarsMate is a game with an inaccuracy by White and a blunder by Black,
+// and the evaluations of its positions.
+var (
+	scholarsMate = `[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+`
+	scholarsMateEvals = []string{
+		"cp 30 pv e2e4",
+		"cp -30 pv e7e5",
+		"cp 80 pv g1f3",
+		"cp 0 pv b8c6",
+		"cp 0 pv f1c4",
+		"cp -20 pv g7g6 h5f3 g8f6",
+		"mate 1 pv h5f7",
+	}
+)
+
+func TestAnnotator_Analyze(t *testing.T) {
+	g := readGame(t, scholarsMate)
+	a := &Annotator{Engine: scriptedEngine(t, scholarsMateEvals), Search: uci.Search{Depth: 1}}
+	r, err := a.Analyze(context.Background(), g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantClasses := []Class{Good, Good, Inaccuracy, Good, Good, Blunder, Good}
+	wantCPLoss := []int{0, 0, 80, 0, 0, 980, 0}
+	if len(r.Moves) != len(wantClasses) {
+		t.Fatalf("want %d moves, got %d", len(wantClasses), len(r.Moves))
+	}
+	for i, m := range r.Moves {
+		if m.Ply != i || m.Move != g.Moves[i].Move {
+			t.Errorf("move %d: got ply %d, move %v", i, m.Ply, m.Move)
+		}
+		if m.Class != wantClasses[i] {
+			t.Errorf("move %d: want class %v, got %v", i, wantClasses[i], m.Class)
+		}
+		if m.CPLoss != wantCPLoss[i] {
+			t.Errorf("move %d: want centipawn loss %d, got %d", i, wantCPLoss[i], m.CPLoss)
+		}
+	}
+
+	nf6 := r.Moves[5]
+	if nf6.Color != chess.Black || nf6.Best != move(t, "g7g6") || len(nf6.BestLine) != 3 {
+		t.Errorf("unexpected report for Nf6: %+v", nf6)
+	}
+	if got := nf6.After; !got.Mate.Found || got.Mate.MovesUntil != 1 {
+		t.Errorf("want Nf6 to allow mate in 1, got %v", got)
+	}
+	if got := r.Moves[6].After; !got.Mate.Found || got.Mate.MovesUntil != 0 {
+		t.Errorf("want Qxf7# to be mate in 0, got %v", got)
+	}
+
+	if got := r.Count(chess.White, Inaccuracy); got != 1 {
+		t.Errorf("want 1 inaccuracy by White, got %d", got)
+	}
+	if got := r.Count(chess.Black, Blunder); got != 1 {
+		t.Errorf("want 1 blunder by Black, got %d", got)
+	}
+	if got, want := r.ACPL, [2]float64{20, 980.0 / 3}; math.Abs(got[0]-want[0]) > 1e-9 || math.Abs(got[1]-want[1]) > 1e-9 {
+		t.Errorf("want ACPL %v, got %v", want, got)
+	}
+	if w, b := r.Accuracy[chess.White], r.Accuracy[chess.Black]; w <= b || w > 100 || b < 0 {
+		t.Errorf("unexpected accuracy: White %.1f, Black %.1f", w, b)
+	}
+}
+
+func TestAnnotator_Analyze_Thresholds(t *testing.T) {
+	g := readGame(t, scholarsMate)
+	a := &Annotator{
+		Engine:     scriptedEngine(t, scholarsMateEvals),
+		Search:     uci.Search{Depth: 1},
+		Thresholds: Thresholds{Centipawns: true, Inaccuracy: 50, Mistake: 80, Blunder: 1000},
+	}
+	r, err := a.Analyze(context.Background(), g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Moves[2].Class; got != Mistake {
+		t.Errorf("Qh5: want Mistake, got %v", got)
+	}
+	if got := r.Moves[5].Class; got != Mistake {
+		t.Errorf("Nf6: want Mistake, got %v", got)
+	}
+}
+
+func TestReport_Annotate(t *testing.T) {
+	g := readGame(t, scholarsMate)
+	a := &Annotator{Engine: scriptedEngine(t, scholarsMateEvals), Search: uci.Search{Depth: 1}}
+	r, err := a.Analyze(context.Background(), g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Annotate(g); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := pgn.NewWriter(&b).Write(g); err != nil {
+		t.Fatal(err)
+	}
+	const want = `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "1-0"]
+[Annotator "Scripted"]
+[AnnotatorFingerprint "c42f41a89634d8b2"]
+
+1. e4 {[%eval 0.30]} 1... e5 {[%eval 0.80]} 2. Qh5 $6 {[%eval 0.00]} 2... Nc6
+{[%eval 0.00]} 3. Bc4 {[%eval 0.20]} 3... Nf6 $4 {Blunder. g6 was best.}
+{[%eval #1]} (3... g6 4. Qf3 Nf6) 4. Qxf7# 1-0
+
+`
+	if got := b.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	r.Moves = r.Moves[:3]
+	if err := r.Annotate(g); err == nil {
+		t.Error("want error for a report of another game")
+	}
+}
+
+func TestClass_NAG(t *testing.T) {
+	cases := []struct {
+		c   Class
+		s   string
+		nag int
+	}{
+		{Good, "Good", 0},
+		{Inaccuracy, "Inaccuracy", 6},
+		{Mistake, "Mistake", 2},
+		{Blunder, "Blunder", 4},
+		{Class(9), "Class(9)", 0},
+	}
+	for _, tc := range cases {
+		if got := tc.c.String(); got != tc.s {
+			t.Errorf("String: want %q, got %q", tc.s, got)
+		}
+		if got := tc.c.NAG(); got != tc.nag {
+			t.Errorf("%v.NAG(): want %d, got %d", tc.c, tc.nag, got)
+		}
+	}
+}
+
+func TestReport_Annotate_Fingerprint(t *testing.T) {
+	g := readGame(t, `[Annotator "Someone"]`+"\n"+scholarsMate)
+	fp := &uci.Fingerprint{Name: "Scripted", Binary: "abc", Bench: 1234}
+	a := &Annotator{Engine: scriptedEngine(t, scholarsMateEvals), Search: uci.Search{Depth: 1}, Fingerprint: fp}
+	r, err := a.Analyze(context.Background(), g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Annotate(g); err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Tag("Annotator"); got != "Someone" {
+		t.Errorf("Annotator: got %q, want the original Someone", got)
+	}
+	if got, want := g.Tag("AnnotatorFingerprint"), fp.ID(); got != want {
+		t.Errorf("AnnotatorFingerprint: got %q, want %q", got, want)
+	}
+}
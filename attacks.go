@@ -0,0 +1,132 @@
+package chess
+
+//go:generate go run magic_gen.go
+
+// Attack tables, indexed by square.
+var (
+	knightAttacks [64]Bitboard
+	kingAttacks   [64]Bitboard
+	pawnAttacks   [2][64]Bitboard // Indexed by the pawn's color.
+
+	between [64][64]Bitboard // Squares strictly between two aligned squares.
+	line    [64][64]Bitboard // The full line through two aligned squares.
+
+	rookMagics   [64]magic
+	bishopMagics [64]magic
+)
+
+// magic holds the data for looking up sliding attacks from one square with
+// magic bitboards. See https://www.chessprogramming.org/Magic_Bitboards.
+type magic struct {
+	mask    Bitboard // Relevant occupancy, excluding the board edges.
+	number  uint64
+	shift   uint
+	attacks []Bitboard
+}
+
+func (m *magic) index(occ Bitboard) uint64 {
+	return (uint64(occ&m.mask) * m.number) >> m.shift
+}
+
+func init() {
+	for sq := A1; sq <= H8; sq++ {
+		knightAttacks[sq] = stepAttacks(sq, knightOffsets)
+		kingAttacks[sq] = stepAttacks(sq, kingOffsets)
+		for _, c := range []Color{White, Black} {
+			for _, df := range [2]int8{-1, 1} {
+				if to, ok := offset(sq, df, pawnDirection(c)); ok {
+					pawnAttacks[c][sq] |= bb(to)
+				}
+			}
+		}
+
+		initMagic(&rookMagics[sq], sq, rookOffsets, rookMagicNumbers[sq])
+		initMagic(&bishopMagics[sq], sq, bishopOffsets, bishopMagicNumbers[sq])
+	}
+
+	for a := A1; a <= H8; a++ {
+		for _, offsets := range [][][2]int8{rookOffsets, bishopOffsets} {
+			for _, o := range offsets {
+				ray := Bitboard(0)
+				for b, ok := offset(a, o[0], o[1]); ok; b, ok = offset(b, o[0], o[1]) {
+					between[a][b] = ray
+					ray |= bb(b)
+				}
+				full := ray | bb(a) | slidingAttacks(a, 0, [][2]int8{{-o[0], -o[1]}})
+				for b := ray; b != 0; b &= b - 1 {
+					line[a][b.first()] = full
+				}
+			}
+		}
+	}
+}
+
+// initMagic fills in m for sliding attacks from sq in the given directions.
+func initMagic(m *magic, sq Square, offsets [][2]int8, number uint64) {
+	m.mask = relevantOccupancy(sq, offsets)
+	m.number = number
+	m.shift = uint(64 - m.mask.Count())
+	m.attacks = make([]Bitboard, 1<<m.mask.Count())
+
+	// Enumerate all subsets of the mask.
+	occ := Bitboard(0)
+	for {
+		m.attacks[m.index(occ)] = slidingAttacks(sq, occ, offsets)
+		occ = (occ - m.mask) & m.mask
+		if occ == 0 {
+			break
+		}
+	}
+}
+
+// stepAttacks returns the squares one step away from sq in each direction.
+func stepAttacks(sq Square, offsets [][2]int8) Bitboard {
+	var b Bitboard
+	for _, o := range offsets {
+		if to, ok := offset(sq, o[0], o[1]); ok {
+			b |= bb(to)
+		}
+	}
+	return b
+}
+
+// slidingAttacks returns the squares attacked from sq by a piece sliding in the
+// given directions, where occ is the set of occupied squares. It is slow, and
+// only used to build the lookup tables.
+func slidingAttacks(sq Square, occ Bitboard, offsets [][2]int8) Bitboard {
+	var b Bitboard
+	for _, o := range offsets {
+		for to, ok := offset(sq, o[0], o[1]); ok; to, ok = offset(to, o[0], o[1]) {
+			b |= bb(to)
+			if occ.Has(to) {
+				break
+			}
+		}
+	}
+	return b
+}
+
+// relevantOccupancy returns the squares whose occupancy affects sliding
+// attacks from sq: the rays, without their final squares.
+func relevantOccupancy(sq Square, offsets [][2]int8) Bitboard {
+	var b Bitboard
+	for _, o := range offsets {
+		for to, ok := offset(sq, o[0], o[1]); ok; to, ok = offset(to, o[0], o[1]) {
+			if _, ok := offset(to, o[0], o[1]); !ok {
+				break
+			}
+			b |= bb(to)
+		}
+	}
+	return b
+}
+
+func rookAttacks(sq Square, occ Bitboard) Bitboard {
+	m := &rookMagics[sq]
+	return m.attacks[m.index(occ)]
+}
+
+func bishopAttacks(sq Square, occ Bitboard) Bitboard {
+	m := &bishopMagics[sq]
+	return m.attacks[m.index(occ)]
+}
@@ -0,0 +1,49 @@
+package chess
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSlidingAttacks_Magic(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		sq := Square(r.Intn(64))
+		occ := Bitboard(r.Uint64() & r.Uint64())
+		if got, want := rookAttacks(sq, occ), slidingAttacks(sq, occ, rookOffsets); got != want {
+			t.Fatalf("rookAttacks(%v, %#x) = %#x, want %#x", sq, uint64(occ), uint64(got), uint64(want))
+		}
+		if got, want := bishopAttacks(sq, occ), slidingAttacks(sq, occ, bishopOffsets); got != want {
+			t.Fatalf("bishopAttacks(%v, %#x) = %#x, want %#x", sq, uint64(occ), uint64(got), uint64(want))
+		}
+	}
+}
+
+func TestBetweenAndLine(t *testing.T) {
+	cases := []struct {
+		a, b    Square
+		between []Square
+		line    int // Number of squares on the line.
+	}{
+		{A1, H8, []Square{B2, C3, D4, E5, F6, G7}, 8},
+		{E1, E4, []Square{E2, E3}, 8},
+		{C3, D3, nil, 8},
+		{B1, A2, nil, 2},
+		{A1, B3, nil, 0},
+	}
+	for _, tc := range cases {
+		var want Bitboard
+		for _, sq := range tc.between {
+			want |= bb(sq)
+		}
+		if got := between[tc.a][tc.b]; got != want {
+			t.Errorf("between[%v][%v]:\n%vwant:\n%v", tc.a, tc.b, got, want)
+		}
+		if got := between[tc.b][tc.a]; got != want {
+			t.Errorf("between[%v][%v] is not symmetric", tc.b, tc.a)
+		}
+		if got := line[tc.a][tc.b].Count(); got != tc.line {
+			t.Errorf("line[%v][%v] has %d squares, want %d", tc.a, tc.b, got, tc.line)
+		}
+	}
+}
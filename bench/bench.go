@@ -0,0 +1,166 @@
+// Package bench measures engines on a suite of positions: each position is
+// searched with the same fixed limits, such as a depth or a node count, and
+// the nodes searched, the time taken, the depth reached and the best move are
+// recorded. Reports compare the speed of several engines, or several builds of
+// one engine, how often they agree on the best move, and how many of the
+// suite's best moves they find.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/epd"
+	"github.com/clfs/chess/uci"
+)
+
+// Position is a position of a suite.
+type Position struct {
+	ID  string `json:"id,omitempty"` // A name for the position, such as an EPD id.
+	FEN string `json:"fen"`
+
+	// Best are the moves that solve the position, if any, such as those of an
+	// EPD "bm" operation.
+	Best []chess.Move `json:"best,omitempty"`
+}
+
+// FromEPD returns the positions of an EPD suite, with the best moves of their
+// "bm" operations.
+func FromEPD(records []*epd.Record) ([]Position, error) {
+	positions := make([]Position, len(records))
+	for i, r := range records {
+		best, err := r.BestMoves()
+		if err != nil {
+			return nil, fmt.Errorf("bench: position %d: %w", i+1, err)
+		}
+		positions[i] = Position{ID: r.ID(), FEN: r.Position.String(), Best: best}
+	}
+	return positions, nil
+}
+
+// Engine is an engine to measure.
+type Engine struct {
+	Name string
+
+	// Client is the engine. It must have completed the "uci" handshake, and
+	// its options must already be set.
+	Client *uci.Client
+}
+
+// Bench runs engines over a suite of positions.
+type Bench struct {
+	// Search limits the search of each position, such as to a fixed depth or
+	// node count. It must end on its own.
+	Search uci.Search
+
+	// NewGame sends "ucinewgame" before each position, so that the results
+	// for a position don't depend on the positions searched before it.
+	NewGame bool
+
+	// Progress, if not nil, is called after each position is searched.
+	Progress func(engine string, r Result)
+}
+
+// Result is the result of searching one position with one engine.
+type Result struct {
+	Position int        `json:"position"` // The index of the position in the suite.
+	Move     chess.Move `json:"move"`     // The engine's best move.
+	Score    uci.Score  `json:"score"`
+	Depth    int        `json:"depth"`
+	SelDepth int        `json:"seldepth,omitempty"`
+	Nodes    int64      `json:"nodes"`
+
+	// Elapsed is the time from sending "go" to receiving the best move, in
+	// nanoseconds in JSON.
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Solved reports whether Move is one of the position's best moves. It is
+	// false for positions without any.
+	Solved bool `json:"solved,omitempty"`
+}
+
+var errInfinite = errors.New("bench: search must end on its own")
+
+// Run searches every position with every engine in turn, one engine at a time
+// so that they don't compete for the CPU, and returns the report. If ctx is
+// done, Run stops the running search and returns the report so far along with
+// ctx.Err().
+func (b *Bench) Run(ctx context.Context, engines []Engine, positions []Position) (*Report, error) {
+	if b.Search.Infinite || b.Search.Ponder {
+		return nil, errInfinite
+	}
+	r := &Report{Search: b.Search.String(), Positions: positions}
+	for _, e := range engines {
+		r.Engines = append(r.Engines, EngineReport{Name: e.Name})
+		er := &r.Engines[len(r.Engines)-1]
+		for i, p := range positions {
+			res, err := b.search(ctx, e.Client, p)
+			if err != nil {
+				return r, fmt.Errorf("bench: %s: position %d: %w", e.Name, i+1, err)
+			}
+			res.Position = i
+			er.add(res)
+			if b.Progress != nil {
+				b.Progress(e.Name, res)
+			}
+		}
+	}
+	return r, nil
+}
+
+// search searches p with c.
+func (b *Bench) search(ctx context.Context, c *uci.Client, p Position) (Result, error) {
+	if b.NewGame {
+		if err := c.UCINewGame(); err != nil {
+			return Result{}, err
+		}
+		if err := c.IsReadyContext(ctx); err != nil {
+			return Result{}, err
+		}
+	}
+	if err := c.Position(uci.PositionParams{FEN: p.FEN}); err != nil {
+		return Result{}, err
+	}
+
+	start := time.Now()
+	infoCh, bestCh, err := c.GoContext(ctx, b.Search)
+	if err != nil {
+		return Result{}, err
+	}
+	var res Result
+	for info := range infoCh {
+		if info.MultiPV > 1 {
+			continue
+		}
+		if len(info.PV) > 0 {
+			res.Score = info.Score
+		}
+		if info.Depth > res.Depth {
+			res.Depth = info.Depth
+		}
+		if info.SelDepth > res.SelDepth {
+			res.SelDepth = info.SelDepth
+		}
+		if info.Nodes > res.Nodes {
+			res.Nodes = info.Nodes
+		}
+	}
+	bm, ok := <-bestCh
+	res.Elapsed = time.Since(start)
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	if !ok {
+		return Result{}, errors.New("engine stopped without a best move")
+	}
+	res.Move = bm.Move
+	for _, m := range p.Best {
+		if m == bm.Move {
+			res.Solved = true
+		}
+	}
+	return res, nil
+}
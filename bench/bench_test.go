@@ -0,0 +1,125 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess/epd"
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/uci"
+)
+
+const suite = `6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8#; id "back rank";
+r1bqkbnr/pppp1ppp/2n5/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - bm Qxf7#; id "scholar";
+rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - id "start";
+`
+
+func testPositions(t *testing.T) []Position {
+	t.Helper()
+	records, err := epd.ReadAll(strings.NewReader(suite))
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions, err := FromEPD(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return positions
+}
+
+func testEngine(t *testing.T, name string) Engine {
+	t.Helper()
+	c, closer, err := refengine.New().Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closer.Close() })
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	return Engine{Name: name, Client: c}
+}
+
+func TestFromEPD(t *testing.T) {
+	positions := testPositions(t)
+	if len(positions) != 3 {
+		t.Fatalf("got %d positions, want 3", len(positions))
+	}
+	p := positions[0]
+	if p.ID != "back rank" || p.FEN != "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1" {
+		t.Errorf("got %+v", p)
+	}
+	if len(p.Best) != 1 || p.Best[0].String() != "a1a8" {
+		t.Errorf("got best moves %v, want [a1a8]", p.Best)
+	}
+	if len(positions[2].Best) != 0 {
+		t.Errorf("got best moves %v, want none", positions[2].Best)
+	}
+}
+
+func TestRun(t *testing.T) {
+	positions := testPositions(t)
+	engines := []Engine{testEngine(t, "a"), testEngine(t, "b")}
+
+	var calls int
+	b := &Bench{
+		Search:   uci.Search{Depth: 2},
+		NewGame:  true,
+		Progress: func(string, Result) { calls++ },
+	}
+	r, err := b.Run(context.Background(), engines, positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 6 {
+		t.Errorf("got %d progress calls, want 6", calls)
+	}
+	if r.Search != "go depth 2" {
+		t.Errorf("got search %q", r.Search)
+	}
+	for _, er := range r.Engines {
+		if len(er.Results) != 3 {
+			t.Fatalf("%s: got %d results, want 3", er.Name, len(er.Results))
+		}
+		if er.Solved != 2 {
+			t.Errorf("%s: solved %d, want 2", er.Name, er.Solved)
+		}
+		for i, res := range er.Results {
+			if res.Position != i {
+				t.Errorf("%s: result %d has position %d", er.Name, i, res.Position)
+			}
+			if res.Nodes <= 0 || res.Depth < 1 || res.Elapsed <= 0 {
+				t.Errorf("%s: result %d: got %+v", er.Name, i, res)
+			}
+		}
+		if !er.Results[0].Score.Mate.Found {
+			t.Errorf("%s: got score %v, want a mate", er.Name, er.Results[0].Score)
+		}
+	}
+	// The reference engine is deterministic.
+	if got := r.Agreement(0, 1); got != 1 {
+		t.Errorf("got agreement %v, want 1", got)
+	}
+}
+
+func TestRunInfinite(t *testing.T) {
+	b := &Bench{Search: uci.Search{Infinite: true}}
+	if _, err := b.Run(context.Background(), nil, nil); !errors.Is(err, errInfinite) {
+		t.Errorf("got %v, want %v", err, errInfinite)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := &Bench{Search: uci.Search{Depth: 1}}
+	r, err := b.Run(ctx, []Engine{testEngine(t, "a")}, testPositions(t))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	if r == nil || len(r.Engines) != 1 || len(r.Engines[0].Results) != 0 {
+		t.Errorf("got report %+v", r)
+	}
+}
@@ -0,0 +1,127 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the result of a run. It encodes to JSON with the field names of
+// its struct tags.
+type Report struct {
+	Search    string         `json:"search"` // The "go" command each position was searched with.
+	Positions []Position     `json:"positions"`
+	Engines   []EngineReport `json:"engines"`
+}
+
+// EngineReport is the result of a run for one engine.
+type EngineReport struct {
+	Name    string   `json:"name"`
+	Results []Result `json:"results"` // One for each position searched, in order.
+
+	Nodes   int64         `json:"nodes"`   // Total nodes searched.
+	Elapsed time.Duration `json:"elapsed"` // Total search time, in nanoseconds in JSON.
+	Solved  int           `json:"solved"`  // Number of positions solved.
+}
+
+func (er *EngineReport) add(r Result) {
+	er.Results = append(er.Results, r)
+	er.Nodes += r.Nodes
+	er.Elapsed += r.Elapsed
+	if r.Solved {
+		er.Solved++
+	}
+}
+
+// NPS returns the nodes searched per second over all positions, or 0 if no
+// time was spent.
+func (er *EngineReport) NPS() int64 {
+	if er.Elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(er.Nodes) / er.Elapsed.Seconds())
+}
+
+// MeanDepth returns the mean depth reached over all positions.
+func (er *EngineReport) MeanDepth() float64 {
+	if len(er.Results) == 0 {
+		return 0
+	}
+	total := 0
+	for _, r := range er.Results {
+		total += r.Depth
+	}
+	return float64(total) / float64(len(er.Results))
+}
+
+// Agreement returns the fraction of positions, from 0 to 1, for which engines
+// i and j chose the same best move. Only positions searched by both count.
+func (r *Report) Agreement(i, j int) float64 {
+	a, b := r.Engines[i].Results, r.Engines[j].Results
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	if n == 0 {
+		return 0
+	}
+	same := 0
+	for k := 0; k < n; k++ {
+		if a[k].Move == b[k].Move {
+			same++
+		}
+	}
+	return float64(same) / float64(n)
+}
+
+// WriteText writes a summary of the report as text: a line for each engine
+// with its totals, followed by the agreement between each pair of engines if
+// there is more than one.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Search: %s\nPositions: %d\n\n", r.Search, len(r.Positions))
+	fmt.Fprintln(tw, "Engine\tNodes\tTime\tNPS\tDepth\tSolved")
+	for i := range r.Engines {
+		er := &r.Engines[i]
+		row := []string{
+			er.Name,
+			strconv.FormatInt(er.Nodes, 10),
+			er.Elapsed.Round(time.Millisecond).String(),
+			strconv.FormatInt(er.NPS(), 10),
+			strconv.FormatFloat(er.MeanDepth(), 'f', 1, 64),
+			fmt.Sprintf("%d/%d", er.Solved, len(er.Results)),
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if len(r.Engines) > 1 {
+		fmt.Fprintln(tw)
+		header := []string{"Agreement"}
+		for _, er := range r.Engines {
+			header = append(header, er.Name)
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for i, er := range r.Engines {
+			row := []string{er.Name}
+			for j := range r.Engines {
+				if i == j {
+					row = append(row, "*")
+					continue
+				}
+				row = append(row, fmt.Sprintf("%.0f%%", 100*r.Agreement(i, j)))
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
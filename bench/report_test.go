@@ -0,0 +1,91 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testReport() *Report {
+	e4, _ := chess.ParseMove("e2e4")
+	d4, _ := chess.ParseMove("d2d4")
+	r := &Report{
+		Search:    "go nodes 1000",
+		Positions: []Position{{FEN: chess.StartingFEN}, {FEN: chess.StartingFEN}},
+		Engines:   []EngineReport{{Name: "old"}, {Name: "new"}},
+	}
+	r.Engines[0].add(Result{Position: 0, Move: e4, Depth: 4, Nodes: 1000, Elapsed: time.Second})
+	r.Engines[0].add(Result{Position: 1, Move: e4, Depth: 6, Nodes: 1000, Elapsed: time.Second, Solved: true})
+	r.Engines[1].add(Result{Position: 0, Move: e4, Depth: 5, Nodes: 1000, Elapsed: 500 * time.Millisecond})
+	r.Engines[1].add(Result{Position: 1, Move: d4, Depth: 5, Nodes: 1000, Elapsed: 500 * time.Millisecond})
+	return r
+}
+
+func TestEngineReport(t *testing.T) {
+	r := testReport()
+	if got := r.Engines[0].NPS(); got != 1000 {
+		t.Errorf("got NPS %d, want 1000", got)
+	}
+	if got := r.Engines[1].NPS(); got != 2000 {
+		t.Errorf("got NPS %d, want 2000", got)
+	}
+	if got := r.Engines[0].MeanDepth(); got != 5 {
+		t.Errorf("got mean depth %v, want 5", got)
+	}
+	if got := r.Engines[0].Solved; got != 1 {
+		t.Errorf("got solved %d, want 1", got)
+	}
+	if got := (&EngineReport{}).NPS(); got != 0 {
+		t.Errorf("got NPS %d for an empty report, want 0", got)
+	}
+}
+
+func TestAgreement(t *testing.T) {
+	r := testReport()
+	if got := r.Agreement(0, 1); got != 0.5 {
+		t.Errorf("got %v, want 0.5", got)
+	}
+	if got := r.Agreement(1, 1); got != 1 {
+		t.Errorf("got %v, want 1", got)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var b bytes.Buffer
+	if err := testReport().WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `Search: go nodes 1000
+Positions: 2
+
+Engine  Nodes  Time  NPS   Depth  Solved
+old     2000   2s    1000  5.0    1/2
+new     2000   1s    2000  5.0    0/2
+
+Agreement  old  new
+old        *    50%
+new        50%  *
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	want := testReport()
+	var b bytes.Buffer
+	if err := want.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	got := new(Report)
+	if err := json.Unmarshal(b.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,55 @@
+package chess
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// Bitboard is a set of squares, with bit n set if Square(n) is in the set.
+type Bitboard uint64
+
+// bb returns the bitboard containing only sq.
+func bb(sq Square) Bitboard {
+	return 1 << uint(sq)
+}
+
+// Has reports whether sq is in b.
+func (b Bitboard) Has(sq Square) bool {
+	return b&bb(sq) != 0
+}
+
+// Count returns the number of squares in b.
+func (b Bitboard) Count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// first returns the lowest square in b, which must not be empty.
+func (b Bitboard) first() Square {
+	return Square(bits.TrailingZeros64(uint64(b)))
+}
+
+// Squares returns the squares in b, in ascending order.
+func (b Bitboard) Squares() []Square {
+	res := make([]Square, 0, b.Count())
+	for ; b != 0; b &= b - 1 {
+		res = append(res, b.first())
+	}
+	return res
+}
+
+// String returns b as an 8x8 grid, with rank 8 first and "x" marking squares in
+// b.
+func (b Bitboard) String() string {
+	var s strings.Builder
+	for r := Rank8; r >= Rank1; r-- {
+		for f := FileA; f <= FileH; f++ {
+			if b.Has(NewSquare(f, r)) {
+				s.WriteByte('x')
+			} else {
+				s.WriteByte('.')
+			}
+		}
+		s.WriteByte('\n')
+	}
+	return s.String()
+}
@@ -0,0 +1,51 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBitboard(t *testing.T) {
+	b := bb(A1) | bb(E4) | bb(H8)
+	if got := b.Count(); got != 3 {
+		t.Errorf("Count() = %d, want 3", got)
+	}
+	if !b.Has(E4) || b.Has(E5) {
+		t.Error("Has is wrong")
+	}
+	if diff := cmp.Diff([]Square{A1, E4, H8}, b.Squares()); diff != "" {
+		t.Errorf("Squares() mismatch (-want +got):\n%s", diff)
+	}
+
+	want := "" +
+		".......x\n" +
+		"........\n" +
+		"........\n" +
+		"........\n" +
+		"....x...\n" +
+		"........\n" +
+		"........\n" +
+		"x.......\n"
+	if got := b.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPosition_Pieces(t *testing.T) {
+	p := StartingPosition()
+	if got, want := p.Pieces(White, Knight), bb(B1)|bb(G1); got != want {
+		t.Errorf("Pieces(White, Knight) =\n%v", got)
+	}
+	if got := p.Pieces(Black, Pawn).Count(); got != 8 {
+		t.Errorf("Pieces(Black, Pawn) has %d squares, want 8", got)
+	}
+	if got := p.Occupied(White).Count(); got != 16 {
+		t.Errorf("Occupied(White) has %d squares, want 16", got)
+	}
+
+	p.Apply(Move{From: G1, To: F3})
+	if got, want := p.Pieces(White, Knight), bb(B1)|bb(F3); got != want {
+		t.Errorf("after Nf3, Pieces(White, Knight) =\n%v", got)
+	}
+}
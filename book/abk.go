@@ -0,0 +1,114 @@
+package book
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/clfs/chess"
+)
+
+// The layout of Arena books: an array of 28-byte entries, where the first move
+// of the starting position is entry 900.
+const (
+	abkEntrySize = 28
+	abkRoot      = 900
+)
+
+// abkEntry is an entry of an Arena book: a move, its statistics, and links to
+// the first reply and to the next alternative in the same position. Links of
+// -1 mean there are none.
+type abkEntry struct {
+	From, To, Promotion, Priority uint8
+
+	Games, Won, Lost, Plies uint32
+
+	FirstChild, NextSibling int32
+}
+
+// abkPromotions are the pieces of the promotion byte of an entry.
+var abkPromotions = [...]chess.PieceType{chess.NoPieceType, chess.Rook, chess.Knight, chess.Bishop, chess.Queen}
+
+// move returns the move of the entry.
+func (e abkEntry) move() (chess.Move, error) {
+	promo := int(int8(e.Promotion))
+	if promo < 0 {
+		promo = -promo
+	}
+	if e.From > 63 || e.To > 63 || promo >= len(abkPromotions) {
+		return chess.Move{}, fmt.Errorf("invalid move %d-%d=%d", e.From, e.To, e.Promotion)
+	}
+	return chess.Move{From: chess.Square(e.From), To: chess.Square(e.To), Promotion: abkPromotions[promo]}, nil
+}
+
+// ReadABK reads a book in the Arena format (.abk). Each move is weighted by the
+// number of games that played it, so Pick never plays moves without games.
+// Moves that aren't legal, and the lines following them, are skipped.
+func ReadABK(r io.Reader) (*Memory, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	n := len(data) / abkEntrySize
+	if n <= abkRoot {
+		return nil, errors.New("book: abk: file too short")
+	}
+	entry := func(i int32) abkEntry {
+		return parseABKEntry(data[int(i)*abkEntrySize:])
+	}
+
+	b := new(Memory)
+	type line struct {
+		first int32 // The first entry of the position.
+		pos   *chess.Position
+	}
+	visited := make(map[int32]bool)
+	stack := []line{{abkRoot, chess.StartingPosition()}}
+	for len(stack) > 0 {
+		l := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for i := l.first; i >= 0; i = entry(i).NextSibling {
+			if int(i) >= n {
+				return nil, fmt.Errorf("book: abk: entry %d out of range", i)
+			}
+			if visited[i] {
+				break
+			}
+			visited[i] = true
+
+			e := entry(i)
+			m, err := e.move()
+			if err != nil {
+				return nil, fmt.Errorf("book: abk: entry %d: %w", i, err)
+			}
+			if !l.pos.IsLegal(m) {
+				continue
+			}
+			b.Add(l.pos, m, int(e.Games))
+			if e.FirstChild >= 0 {
+				next := l.pos.Clone()
+				next.Apply(m)
+				stack = append(stack, line{e.FirstChild, next})
+			}
+		}
+	}
+	return b, nil
+}
+
+// parseABKEntry decodes the entry at the start of b.
+func parseABKEntry(b []byte) abkEntry {
+	u32 := func(off int) uint32 { return binary.LittleEndian.Uint32(b[off:]) }
+	return abkEntry{
+		From:        b[0],
+		To:          b[1],
+		Promotion:   b[2],
+		Priority:    b[3],
+		Games:       u32(4),
+		Won:         u32(8),
+		Lost:        u32(12),
+		Plies:       u32(16),
+		FirstChild:  int32(u32(20)),
+		NextSibling: int32(u32(24)),
+	}
+}
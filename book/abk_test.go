@@ -0,0 +1,73 @@
+package book
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+// abkFile returns an Arena book with the given entries from entry 900 on.
+func abkFile(entries ...abkEntry) []byte {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, abkRoot*abkEntrySize))
+	for _, e := range entries {
+		binary.Write(&buf, binary.LittleEndian, e)
+	}
+	return buf.Bytes()
+}
+
+func TestReadABK(t *testing.T) {
+	data := abkFile(
+		abkEntry{From: uint8(chess.E2), To: uint8(chess.E4), Games: 3, FirstChild: 901, NextSibling: 902},
+		abkEntry{From: uint8(chess.E7), To: uint8(chess.E5), Games: 2, FirstChild: -1, NextSibling: 903},
+		abkEntry{From: uint8(chess.D2), To: uint8(chess.D4), Games: 1, FirstChild: -1, NextSibling: -1},
+		// Illegal, so its reply is skipped too.
+		abkEntry{From: uint8(chess.E7), To: uint8(chess.E4), Games: 9, FirstChild: 904, NextSibling: -1},
+		abkEntry{From: uint8(chess.G1), To: uint8(chess.F3), Games: 9, FirstChild: -1, NextSibling: -1},
+	)
+	b, err := ReadABK(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pos := chess.StartingPosition()
+	want := []WeightedMove{{move("e2e4"), 3}, {move("d2d4"), 1}}
+	if diff := cmp.Diff(want, b.Probe(pos)); diff != "" {
+		t.Errorf("start: mismatch (-want +got):\n%s", diff)
+	}
+	pos.Apply(move("e2e4"))
+	want = []WeightedMove{{move("e7e5"), 2}}
+	if diff := cmp.Diff(want, b.Probe(pos)); diff != "" {
+		t.Errorf("after e4: mismatch (-want +got):\n%s", diff)
+	}
+	if b.Len() != 2 {
+		t.Errorf("Len: want 2, got %d", b.Len())
+	}
+}
+
+func TestReadABK_Promotion(t *testing.T) {
+	e := abkEntry{From: uint8(chess.A7), To: uint8(chess.A8), Promotion: 2}
+	m, err := e.move()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != move("a7a8n") {
+		t.Errorf("want a7a8n, got %v", m)
+	}
+}
+
+func TestReadABK_Error(t *testing.T) {
+	cases := map[string][]byte{
+		"short":     make([]byte, 100),
+		"link":      abkFile(abkEntry{From: uint8(chess.E2), To: uint8(chess.E4), FirstChild: -1, NextSibling: 5000}),
+		"promotion": abkFile(abkEntry{From: uint8(chess.E2), To: uint8(chess.E4), Promotion: 7, FirstChild: -1, NextSibling: -1}),
+	}
+	for name, data := range cases {
+		if _, err := ReadABK(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package book provides opening books: sources of moves to play in known
+// positions, chosen by weight.
+//
+// Books are read from Polyglot (.bin) files with ReadPolyglot, or mapped into
+// memory with OpenPolyglot, and from Arena (.abk) files with ReadABK, or built
+// from games with FromPGN.
+//
+// ChessBase (.ctg) books are not supported yet. The format has only been
+// reverse-engineered: looking up a position depends on a hash table and a move
+// encoding table that must match ChessBase's exactly, and unlike Polyglot keys
+// there are no published test vectors to check them against. A reader with a
+// wrong entry would silently find nothing, so it waits for sample books to test
+// with.
+package book
+
+import (
+	"math/rand"
+
+	"github.com/clfs/chess"
+)
+
+// WeightedMove is a book move and its weight. Moves with higher weights are
+// played more often.
+type WeightedMove struct {
+	Move   chess.Move
+	Weight int
+}
+
+// Book is an opening book.
+type Book interface {
+	// Probe returns the book moves in pos, sorted by decreasing weight, or
+	// nil if pos is not in the book. The moves are legal in pos.
+	Probe(pos *chess.Position) []WeightedMove
+}
+
+// Composite is a book that probes each of its books in order and returns the
+// moves of the first one that has the position. Earlier books take precedence,
+// and later ones serve as fallbacks.
+type Composite []Book
+
+// Probe implements Book.
+func (c Composite) Probe(pos *chess.Position) []WeightedMove {
+	for _, b := range c {
+		if moves := b.Probe(pos); len(moves) > 0 {
+			return moves
+		}
+	}
+	return nil
+}
+
+// Pick chooses a book move in pos at random, with probability proportional to
+// its weight, using r. It reports false if pos is not in the book or all its
+// moves have a weight of zero.
+func Pick(b Book, pos *chess.Position, r *rand.Rand) (chess.Move, bool) {
+	moves := b.Probe(pos)
+	total := 0
+	for _, m := range moves {
+		if m.Weight > 0 {
+			total += m.Weight
+		}
+	}
+	if total == 0 {
+		return chess.Move{}, false
+	}
+	n := r.Intn(total)
+	for _, m := range moves {
+		if m.Weight <= 0 {
+			continue
+		}
+		if n < m.Weight {
+			return m.Move, true
+		}
+		n -= m.Weight
+	}
+	panic("unreachable")
+}
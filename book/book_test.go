@@ -0,0 +1,69 @@
+package book
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+func move(s string) chess.Move {
+	m, err := chess.ParseMove(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestComposite_Probe(t *testing.T) {
+	start := chess.StartingPosition()
+	primary, fallback := new(Memory), new(Memory)
+	primary.Add(start, move("e2e4"), 1)
+	fallback.Add(start, move("d2d4"), 5)
+	after := start.Clone()
+	after.Apply(move("d2d4"))
+	fallback.Add(after, move("g8f6"), 2)
+
+	c := Composite{primary, fallback}
+	if diff := cmp.Diff([]WeightedMove{{move("e2e4"), 1}}, c.Probe(start)); diff != "" {
+		t.Errorf("start: mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]WeightedMove{{move("g8f6"), 2}}, c.Probe(after)); diff != "" {
+		t.Errorf("after d4: mismatch (-want +got):\n%s", diff)
+	}
+	after.Apply(move("g8f6"))
+	if got := c.Probe(after); got != nil {
+		t.Errorf("out of book: got %v", got)
+	}
+}
+
+func TestPick(t *testing.T) {
+	start := chess.StartingPosition()
+	b := new(Memory)
+	b.Add(start, move("e2e4"), 3)
+	b.Add(start, move("d2d4"), 1)
+	b.Add(start, move("g1f3"), 0)
+
+	counts := make(map[chess.Move]int)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 4000; i++ {
+		m, ok := Pick(b, start, r)
+		if !ok {
+			t.Fatal("Pick: not in book")
+		}
+		counts[m]++
+	}
+	if counts[move("g1f3")] != 0 {
+		t.Errorf("picked a move of weight 0 %d times", counts[move("g1f3")])
+	}
+	if n := counts[move("e2e4")]; n < 2800 || n > 3200 {
+		t.Errorf("picked e2e4 %d times of 4000, want about 3000", n)
+	}
+
+	after := start.Clone()
+	after.Apply(move("e2e4"))
+	if _, ok := Pick(b, after, r); ok {
+		t.Error("Pick: want false out of book")
+	}
+}
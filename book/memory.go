@@ -0,0 +1,108 @@
+package book
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/pgn"
+)
+
+// Memory is a book held in memory, keyed by position hash. The zero value is an
+// empty book ready to use.
+type Memory struct {
+	entries map[uint64][]WeightedMove
+}
+
+// Add adds weight to the weight of m in pos, adding the move if it isn't in the
+// book yet. It panics if m is not legal in pos.
+func (b *Memory) Add(pos *chess.Position, m chess.Move, weight int) {
+	if !pos.IsLegal(m) {
+		panic(fmt.Sprintf("book: illegal move %v", m))
+	}
+	if b.entries == nil {
+		b.entries = make(map[uint64][]WeightedMove)
+	}
+	key := pos.Hash()
+	moves := b.entries[key]
+	for i := range moves {
+		if moves[i].Move == m {
+			moves[i].Weight += weight
+			sortMoves(moves)
+			return
+		}
+	}
+	moves = append(moves, WeightedMove{m, weight})
+	sortMoves(moves)
+	b.entries[key] = moves
+}
+
+// Probe implements Book. Moves that are illegal in pos, which only happens
+// when another position has the same hash, are left out.
+func (b *Memory) Probe(pos *chess.Position) []WeightedMove {
+	var res []WeightedMove
+	for _, m := range b.entries[pos.Hash()] {
+		if pos.IsLegal(m.Move) {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// Len returns the number of positions in the book.
+func (b *Memory) Len() int {
+	return len(b.entries)
+}
+
+// sortMoves sorts moves by decreasing weight, breaking ties by move so that
+// the order doesn't depend on the order moves were added.
+func sortMoves(moves []WeightedMove) {
+	sort.Slice(moves, func(i, j int) bool {
+		if moves[i].Weight != moves[j].Weight {
+			return moves[i].Weight > moves[j].Weight
+		}
+		return moves[i].Move.String() < moves[j].Move.String()
+	})
+}
+
+// FromPGN builds a book from the first plies moves of the main line of every
+// game read from r. Each move has a weight of one per game that played it in
+// the position, so the book repeats the choices of the games in proportion.
+// Games that can't be read, or that start from a position other than the
+// standard one, are skipped.
+func FromPGN(r io.Reader, plies int) (*Memory, error) {
+	b := new(Memory)
+	pr := pgn.NewReader(r)
+	for {
+		g, err := pr.Read()
+		if err == io.EOF {
+			return b, nil
+		}
+		var pe *pgn.ParseError
+		if errors.As(err, &pe) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if g.Tag("FEN") != "" {
+			continue
+		}
+		b.AddGame(chess.StartingPosition(), g.MainLine(), plies)
+	}
+}
+
+// AddGame adds the first plies moves of a game starting at start, with a weight
+// of one each. It stops at the first illegal move.
+func (b *Memory) AddGame(start *chess.Position, moves []chess.Move, plies int) {
+	pos := start.Clone()
+	for i, m := range moves {
+		if i == plies || !pos.IsLegal(m) {
+			return
+		}
+		b.Add(pos, m, 1)
+		pos.Apply(m)
+	}
+}
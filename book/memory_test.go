@@ -0,0 +1,83 @@
+package book
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMemory_Add(t *testing.T) {
+	start := chess.StartingPosition()
+	var b Memory
+	b.Add(start, move("d2d4"), 2)
+	b.Add(start, move("e2e4"), 1)
+	b.Add(start, move("c2c4"), 2)
+	b.Add(start, move("e2e4"), 2)
+
+	want := []WeightedMove{{move("e2e4"), 3}, {move("c2c4"), 2}, {move("d2d4"), 2}}
+	if diff := cmp.Diff(want, b.Probe(start)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if b.Len() != 1 {
+		t.Errorf("Len: want 1, got %d", b.Len())
+	}
+}
+
+func TestMemory_Add_Illegal(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic")
+		}
+	}()
+	new(Memory).Add(chess.StartingPosition(), move("e2e5"), 1)
+}
+
+func TestFromPGN(t *testing.T) {
+	const games = `[Event "1"]
+
+1. e4 e5 2. Nf3 Nc6 1-0
+
+[Event "2"]
+
+1. e4 c5 2. Nf3 d6 0-1
+
+[Event "3"]
+
+1. d4 Nf6 1/2-1/2
+
+[Event "bad"]
+
+1. e4 e4 *
+
+[FEN "8/8/8/8/8/8/8/K6k w - - 0 1"]
+
+1. Kb1 *
+`
+	b, err := FromPGN(strings.NewReader(games), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pos := chess.StartingPosition()
+	want := []WeightedMove{{move("e2e4"), 2}, {move("d2d4"), 1}}
+	if diff := cmp.Diff(want, b.Probe(pos)); diff != "" {
+		t.Errorf("start: mismatch (-want +got):\n%s", diff)
+	}
+	pos.Apply(move("e2e4"))
+	want = []WeightedMove{{move("c7c5"), 1}, {move("e7e5"), 1}}
+	if diff := cmp.Diff(want, b.Probe(pos)); diff != "" {
+		t.Errorf("after e4: mismatch (-want +got):\n%s", diff)
+	}
+	pos.Apply(move("e7e5"))
+	want = []WeightedMove{{move("g1f3"), 1}}
+	if diff := cmp.Diff(want, b.Probe(pos)); diff != "" {
+		t.Errorf("after e5: mismatch (-want +got):\n%s", diff)
+	}
+	// The fourth ply is past the limit.
+	pos.Apply(move("g1f3"))
+	if got := b.Probe(pos); got != nil {
+		t.Errorf("after Nf3: got %v", got)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package book
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile isn't supported on this system, so it reads the first size bytes of
+// f into memory instead.
+func mapFile(f *os.File, size int64) (data []byte, unmap func() error, err error) {
+	data = make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
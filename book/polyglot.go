@@ -0,0 +1,211 @@
+package book
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/clfs/chess"
+)
+
+// polyglotEntrySize is the size of an entry of a Polyglot book: a 64-bit key,
+// a 16-bit move, a 16-bit weight and 32 bits of learning data, big-endian.
+const polyglotEntrySize = 16
+
+// polyglotEntry is an entry of a Polyglot book.
+type polyglotEntry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// Polyglot is a book in the Polyglot format (.bin), the most common format of
+// engine books. Positions are looked up by their Polyglot key, as computed by
+// PolyglotKey.
+type Polyglot struct {
+	data  []byte       // The entries, sorted by key.
+	unmap func() error // Releases data, if it is mapped from a file.
+}
+
+// ReadPolyglot reads a book in the Polyglot format into memory. The entries of
+// a book are sorted by key, but ReadPolyglot doesn't rely on it.
+func ReadPolyglot(r io.Reader) (*Polyglot, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%polyglotEntrySize != 0 {
+		return nil, errPolyglotSize
+	}
+	sort.Stable(polyglotEntries(data))
+	return &Polyglot{data: data}, nil
+}
+
+// OpenPolyglot opens the Polyglot book in the named file. Where the system
+// supports it, the file is mapped into memory rather than read, so that only
+// the parts of a large book that are probed are loaded. The entries are
+// searched in place, so they must be sorted by key, as the format requires.
+// The book must be closed with Close.
+func OpenPolyglot(name string) (*Polyglot, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size()%polyglotEntrySize != 0 {
+		return nil, fmt.Errorf("%w: %s", errPolyglotSize, name)
+	}
+	if fi.Size() == 0 {
+		return new(Polyglot), nil
+	}
+	data, unmap, err := mapFile(f, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+	return &Polyglot{data: data, unmap: unmap}, nil
+}
+
+// errPolyglotSize is returned for books that aren't a whole number of entries.
+var errPolyglotSize = errors.New("book: polyglot: file size is not a multiple of 16 bytes")
+
+// Close releases a book opened with OpenPolyglot. Probing the book after Close
+// finds nothing. Close must not be called while the book is being probed.
+func (b *Polyglot) Close() error {
+	b.data = nil
+	if b.unmap == nil {
+		return nil
+	}
+	unmap := b.unmap
+	b.unmap = nil
+	return unmap()
+}
+
+// Probe implements Book. Moves that are illegal in pos, which only happens
+// when another position has the same key, are left out. Polyglot books are for
+// standard chess, so Probe returns nil in other variants.
+func (b *Polyglot) Probe(pos *chess.Position) []WeightedMove {
+	if pos.Variant() != chess.Standard {
+		return nil
+	}
+	entries := polyglotEntries(b.data)
+	key := PolyglotKey(pos)
+	i := sort.Search(entries.Len(), func(i int) bool { return entries.key(i) >= key })
+	var res []WeightedMove
+	for ; i < entries.Len() && entries.key(i) == key; i++ {
+		e := entries.entry(i)
+		m := pos.ConvertCastling(polyglotMove(e.Move), pos.Chess960())
+		if pos.IsLegal(m) {
+			res = append(res, WeightedMove{m, int(e.Weight)})
+		}
+	}
+	sortMoves(res)
+	return res
+}
+
+// Len returns the number of entries in the book.
+func (b *Polyglot) Len() int {
+	return polyglotEntries(b.data).Len()
+}
+
+// polyglotEntries is the encoded entries of a book. It sorts them by key.
+type polyglotEntries []byte
+
+func (e polyglotEntries) Len() int { return len(e) / polyglotEntrySize }
+
+func (e polyglotEntries) Less(i, j int) bool { return e.key(i) < e.key(j) }
+
+func (e polyglotEntries) Swap(i, j int) {
+	var tmp [polyglotEntrySize]byte
+	a, b := e[i*polyglotEntrySize:(i+1)*polyglotEntrySize], e[j*polyglotEntrySize:(j+1)*polyglotEntrySize]
+	copy(tmp[:], a)
+	copy(a, b)
+	copy(b, tmp[:])
+}
+
+// key returns the key of entry i.
+func (e polyglotEntries) key(i int) uint64 {
+	return binary.BigEndian.Uint64(e[i*polyglotEntrySize:])
+}
+
+// entry decodes entry i.
+func (e polyglotEntries) entry(i int) polyglotEntry {
+	b := e[i*polyglotEntrySize:]
+	return polyglotEntry{
+		Key:    binary.BigEndian.Uint64(b),
+		Move:   binary.BigEndian.Uint16(b[8:]),
+		Weight: binary.BigEndian.Uint16(b[10:]),
+		Learn:  binary.BigEndian.Uint32(b[12:]),
+	}
+}
+
+// polyglotPromotions are the pieces of the promotion field of a move.
+var polyglotPromotions = [8]chess.PieceType{chess.NoPieceType, chess.Knight, chess.Bishop, chess.Rook, chess.Queen}
+
+// polyglotMove decodes a move of a Polyglot book. Castling is encoded as the
+// king capturing its own rook, such as e1h1.
+func polyglotMove(v uint16) chess.Move {
+	square := func(v uint16) chess.Square {
+		return chess.NewSquare(chess.File(v&7), chess.Rank(v>>3&7))
+	}
+	return chess.Move{
+		From:      square(v >> 6),
+		To:        square(v),
+		Promotion: polyglotPromotions[v>>12&7],
+	}
+}
+
+// PolyglotKey returns the key of pos in Polyglot books. Unlike chess.Position's
+// Hash, it only counts the en passant square when a pawn of the side to move
+// stands next to the pawn that can be captured, as the format requires.
+func PolyglotKey(pos *chess.Position) uint64 {
+	var key uint64
+	for c := chess.White; c <= chess.Black; c++ {
+		for pt := chess.Pawn; pt <= chess.King; pt++ {
+			// Kinds alternate black and white: black pawn, white pawn,
+			// black knight, and so on.
+			kind := 2 * int(pt-chess.Pawn)
+			if c == chess.White {
+				kind++
+			}
+			for _, sq := range pos.Pieces(c, pt).Squares() {
+				key ^= polyglotRandom[64*kind+int(sq)]
+			}
+		}
+	}
+
+	cr := pos.CastlingRights()
+	for i, r := range []chess.CastlingRights{chess.WhiteKingside, chess.WhiteQueenside, chess.BlackKingside, chess.BlackQueenside} {
+		if cr&r != 0 {
+			key ^= polyglotRandom[768+i]
+		}
+	}
+
+	if ep := pos.EnPassant(); ep != chess.NoSquare {
+		// The pawn that can be captured is on the rank in front of the en
+		// passant square, from the side to move's point of view.
+		us := pos.SideToMove()
+		rank := chess.Rank5
+		if us == chess.Black {
+			rank = chess.Rank4
+		}
+		pawns := pos.Pieces(us, chess.Pawn)
+		f := ep.File()
+		if f > chess.FileA && pawns.Has(chess.NewSquare(f-1, rank)) ||
+			f < chess.FileH && pawns.Has(chess.NewSquare(f+1, rank)) {
+			key ^= polyglotRandom[772+int(f)]
+		}
+	}
+
+	if pos.SideToMove() == chess.White {
+		key ^= polyglotRandom[780]
+	}
+	return key
+}
@@ -0,0 +1,166 @@
+package book
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+// polyglotFile returns a Polyglot book with the given entries, in order.
+func polyglotFile(entries ...polyglotEntry) []byte {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		binary.Write(&buf, binary.BigEndian, e)
+	}
+	return buf.Bytes()
+}
+
+// polyglotEncode encodes m as a move of a Polyglot book.
+func polyglotEncode(m chess.Move) uint16 {
+	v := uint16(m.From.Rank())<<9 | uint16(m.From.File())<<6 | uint16(m.To.Rank())<<3 | uint16(m.To.File())
+	for i, pt := range polyglotPromotions {
+		if pt == m.Promotion && pt != chess.NoPieceType {
+			v |= uint16(i) << 12
+		}
+	}
+	return v
+}
+
+// The test positions of the Polyglot book format specification.
+func TestPolyglotKey(t *testing.T) {
+	cases := []struct {
+		moves string
+		want  uint64
+	}{
+		{"", 0x463b96181691fc9c},
+		{"e2e4", 0x823c9b50fd114196},
+		{"e2e4 d7d5", 0x0756b94461c50fb0},
+		{"e2e4 d7d5 e4e5", 0x662fafb965db29d4},
+		{"e2e4 d7d5 e4e5 f7f5", 0x22a48b5a8e47ff78},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2", 0x652a607ca3f242c1},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2 e8f7", 0x00fdd303c946bdd9},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4", 0x3c8123ea7b067637},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4 b4c3 a1a3", 0x5c3f9b829b279560},
+	}
+	for _, c := range cases {
+		pos := chess.StartingPosition()
+		for _, s := range strings.Fields(c.moves) {
+			pos.Apply(move(s))
+		}
+		if got := PolyglotKey(pos); got != c.want {
+			t.Errorf("%q: want %#016x, got %#016x", c.moves, c.want, got)
+		}
+	}
+}
+
+func TestReadPolyglot(t *testing.T) {
+	start := chess.StartingPosition()
+	afterE4 := start.Clone()
+	afterE4.Apply(move("e2e4"))
+	castling, err := chess.ParseFEN("r3k2r/pppq1ppp/2npbn2/4p3/4P3/2NPBN2/PPPQ1PPP/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	promotion, err := chess.ParseFEN("8/P6k/8/8/8/8/8/K7 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := func(pos *chess.Position, m string, weight uint16) polyglotEntry {
+		return polyglotEntry{Key: PolyglotKey(pos), Move: polyglotEncode(move(m)), Weight: weight}
+	}
+	// Out of order, to check that the entries are sorted.
+	data := polyglotFile(
+		entry(afterE4, "e7e5", 4),
+		entry(start, "d2d4", 3),
+		entry(castling, "e1h1", 2),
+		entry(castling, "e1a1", 1),
+		entry(start, "e2e4", 5),
+		entry(start, "e2e5", 9), // Illegal, as if from another position.
+		entry(promotion, "a7a8q", 7),
+		entry(promotion, "a7a8n", 1),
+	)
+	b, err := ReadPolyglot(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 8 {
+		t.Errorf("Len: want 8, got %d", b.Len())
+	}
+
+	cases := []struct {
+		name string
+		pos  *chess.Position
+		want []WeightedMove
+	}{
+		{"start", start, []WeightedMove{{move("e2e4"), 5}, {move("d2d4"), 3}}},
+		{"after e4", afterE4, []WeightedMove{{move("e7e5"), 4}}},
+		{"castling", castling, []WeightedMove{{move("e1g1"), 2}, {move("e1c1"), 1}}},
+		{"promotion", promotion, []WeightedMove{{move("a7a8q"), 7}, {move("a7a8n"), 1}}},
+	}
+	for _, c := range cases {
+		if diff := cmp.Diff(c.want, b.Probe(c.pos)); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", c.name, diff)
+		}
+	}
+
+	afterE4.Apply(move("e7e5"))
+	if got := b.Probe(afterE4); got != nil {
+		t.Errorf("out of book: got %v", got)
+	}
+}
+
+func TestOpenPolyglot(t *testing.T) {
+	start := chess.StartingPosition()
+	data := polyglotFile(
+		polyglotEntry{Key: PolyglotKey(start), Move: polyglotEncode(move("e2e4")), Weight: 2},
+		polyglotEntry{Key: PolyglotKey(start), Move: polyglotEncode(move("d2d4")), Weight: 1},
+	)
+	sort.Stable(polyglotEntries(data))
+	name := filepath.Join(t.TempDir(), "book.bin")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := OpenPolyglot(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WeightedMove{{move("e2e4"), 2}, {move("d2d4"), 1}}
+	if diff := cmp.Diff(want, b.Probe(start)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Probe(start); got != nil {
+		t.Errorf("after Close: got %v", got)
+	}
+}
+
+func TestOpenPolyglot_Error(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenPolyglot(filepath.Join(dir, "missing.bin")); err == nil {
+		t.Error("missing file: want error")
+	}
+	name := filepath.Join(dir, "short.bin")
+	if err := os.WriteFile(name, make([]byte, 20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenPolyglot(name); err == nil {
+		t.Error("short file: want error")
+	}
+}
+
+func TestReadPolyglot_Error(t *testing.T) {
+	if _, err := ReadPolyglot(bytes.NewReader(make([]byte, 20))); err == nil {
+		t.Error("want error")
+	}
+}
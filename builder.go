@@ -0,0 +1,106 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder composes an arbitrary position, as in a board setup dialog: pieces
+// are placed and removed one square at a time, and the other fields of a FEN
+// are set directly. Nothing is checked until Validate or Position is called,
+// so a Builder may pass through states that aren't positions.
+//
+// Castling rights refer to the rooks in the corners.
+type Builder struct {
+	board          [64]Piece
+	sideToMove     Color
+	castling       CastlingRights
+	enPassant      Square
+	halfmoveClock  int
+	fullmoveNumber int
+}
+
+// NewBuilder returns a Builder with an empty board, White to move, no castling
+// rights and no en passant square.
+func NewBuilder() *Builder {
+	return &Builder{enPassant: NoSquare, fullmoveNumber: 1}
+}
+
+// NewBuilderFromPosition returns a Builder holding p, to be edited.
+func NewBuilderFromPosition(p *Position) *Builder {
+	return &Builder{
+		board:          p.board,
+		sideToMove:     p.sideToMove,
+		castling:       p.castling,
+		enPassant:      p.enPassant,
+		halfmoveClock:  p.halfmoveClock,
+		fullmoveNumber: p.fullmoveNumber,
+	}
+}
+
+// Put places pc on sq, replacing any piece there. Putting NoPiece empties sq.
+func (b *Builder) Put(sq Square, pc Piece) {
+	b.board[sq] = pc
+}
+
+// Remove empties sq.
+func (b *Builder) Remove(sq Square) {
+	b.board[sq] = NoPiece
+}
+
+// Clear empties the board. The other fields are kept.
+func (b *Builder) Clear() {
+	b.board = [64]Piece{}
+}
+
+// PieceAt returns the piece on sq, or NoPiece if sq is empty.
+func (b *Builder) PieceAt(sq Square) Piece {
+	return b.board[sq]
+}
+
+// SetSideToMove sets the color whose turn it is.
+func (b *Builder) SetSideToMove(c Color) {
+	b.sideToMove = c
+}
+
+// SetCastlingRights sets the castling rights.
+func (b *Builder) SetCastlingRights(cr CastlingRights) {
+	b.castling = cr
+}
+
+// SetEnPassant sets the en passant target square. NoSquare clears it.
+func (b *Builder) SetEnPassant(sq Square) {
+	b.enPassant = sq
+}
+
+// SetMoveCounters sets the halfmove clock and the fullmove number.
+func (b *Builder) SetMoveCounters(halfmoveClock, fullmoveNumber int) {
+	b.halfmoveClock, b.fullmoveNumber = halfmoveClock, fullmoveNumber
+}
+
+// FEN returns the position as a FEN, whether or not it is valid.
+func (b *Builder) FEN() string {
+	var s strings.Builder
+	writeBoard(&s, &b.board, 0)
+	side := "w"
+	if b.sideToMove == Black {
+		side = "b"
+	}
+	fmt.Fprintf(&s, " %s %v %v %d %d", side, b.castling, b.enPassant, b.halfmoveClock, b.fullmoveNumber)
+	return s.String()
+}
+
+// Validate returns every problem with the position, as found by ValidateFEN,
+// or nil if there are none.
+func (b *Builder) Validate() []*FENError {
+	return ValidateFEN(b.FEN())
+}
+
+// Position returns the position, or the first problem Validate reports.
+func (b *Builder) Position() (*Position, error) {
+	fen := b.FEN()
+	if errs := ValidateFEN(fen); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return ParseFEN(fen)
+}
@@ -0,0 +1,80 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder()
+	if got, want := b.FEN(), "8/8/8/8/8/8/8/8 w - - 0 1"; got != want {
+		t.Errorf("empty: want %q, got %q", want, got)
+	}
+
+	b.Put(E1, WhiteKing)
+	b.Put(H1, WhiteRook)
+	b.Put(E8, BlackKing)
+	b.Put(D5, BlackPawn)
+	b.Put(E5, WhitePawn)
+	b.Put(A2, WhiteQueen)
+	b.Remove(A2)
+	b.SetSideToMove(White)
+	b.SetCastlingRights(WhiteKingside)
+	b.SetEnPassant(D6)
+	b.SetMoveCounters(0, 30)
+
+	const want = "4k3/8/8/3pP3/8/8/8/4K2R w K d6 0 30"
+	if got := b.FEN(); got != want {
+		t.Errorf("FEN: want %q, got %q", want, got)
+	}
+	if errs := b.Validate(); errs != nil {
+		t.Errorf("Validate: %v", errs)
+	}
+	p, err := b.Position()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.String(); got != want {
+		t.Errorf("Position: want %q, got %q", want, got)
+	}
+	if b.PieceAt(D5) != BlackPawn || b.PieceAt(A2) != NoPiece {
+		t.Errorf("PieceAt: got %v and %v", b.PieceAt(D5), b.PieceAt(A2))
+	}
+}
+
+func TestBuilder_Validate(t *testing.T) {
+	b := NewBuilder()
+	b.Put(E1, WhiteKing)
+	b.Put(A8, WhitePawn)
+	b.SetCastlingRights(WhiteQueenside)
+
+	want := ValidateFEN(b.FEN())
+	if len(want) == 0 {
+		t.Fatal("ValidateFEN: want problems")
+	}
+	if diff := cmp.Diff(want, b.Validate()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := b.Position(); err == nil || err.Error() != want[0].Error() {
+		t.Errorf("Position: want %v, got %v", want[0], err)
+	}
+}
+
+func TestNewBuilderFromPosition(t *testing.T) {
+	b := NewBuilderFromPosition(StartingPosition())
+	if got := b.FEN(); got != StartingFEN {
+		t.Errorf("want %q, got %q", StartingFEN, got)
+	}
+
+	// Editing the builder doesn't change the position.
+	p := StartingPosition()
+	b = NewBuilderFromPosition(p)
+	b.Clear()
+	if p.PieceAt(E1) != WhiteKing {
+		t.Error("Clear changed the position")
+	}
+	if got, want := b.FEN(), "8/8/8/8/8/8/8/8 w KQkq - 0 1"; got != want {
+		t.Errorf("cleared: want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,287 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Castling rights are indexed by their bit position: white kingside, white
+// queenside, black kingside, black queenside.
+func castlingIndex(c Color, kingside bool) int {
+	i := 2 * int(c)
+	if !kingside {
+		i++
+	}
+	return i
+}
+
+// castling describes a castling move.
+type castling struct {
+	king, kingTo Square
+	rook, rookTo Square
+}
+
+// castlingPath returns the castling move for right i, with the king on king.
+func (p *Position) castlingPath(i int, king Square) castling {
+	rank := Rank1
+	if i >= 2 {
+		rank = Rank8
+	}
+	c := castling{king: king, rook: p.castlingRooks[i]}
+	if i%2 == 0 {
+		c.kingTo, c.rookTo = NewSquare(FileG, rank), NewSquare(FileF, rank)
+	} else {
+		c.kingTo, c.rookTo = NewSquare(FileC, rank), NewSquare(FileD, rank)
+	}
+	return c
+}
+
+// span returns the squares from a to b inclusive, which must be aligned.
+func span(a, b Square) Bitboard {
+	return between[a][b] | bb(a) | bb(b)
+}
+
+// castlingMove returns the move that castles with right i, as encoded in p's
+// mode: the king moves two squares in standard chess, and captures its own
+// rook in Chess960.
+func (p *Position) castlingMove(i int) Move {
+	c := p.castlingPath(i, p.kingSquare(Color(i/2)))
+	if p.chess960 {
+		return Move{From: c.king, To: c.rook}
+	}
+	return Move{From: c.king, To: c.kingTo}
+}
+
+// castlingSide returns the castling right that m uses, if m castles in p's
+// mode.
+func (p *Position) castlingSide(m Move) (int, bool) {
+	pc := p.board[m.From]
+	if pc.Type() != King || m.Promotion != NoPieceType {
+		return 0, false
+	}
+	us := pc.Color()
+	for _, kingside := range []bool{true, false} {
+		i := castlingIndex(us, kingside)
+		if p.castling&(1<<i) != 0 && m == p.castlingMove(i) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (p *Position) appendCastlingMoves(moves []Move) []Move {
+	us := p.sideToMove
+	king := p.kingSquare(us)
+	occ := p.colors[White] | p.colors[Black]
+
+	for _, kingside := range []bool{true, false} {
+		i := castlingIndex(us, kingside)
+		if p.castling&(1<<i) == 0 {
+			continue
+		}
+		c := p.castlingPath(i, king)
+		if p.board[c.rook] != NewPiece(us, Rook) {
+			continue
+		}
+
+		// Everything the king and rook pass over must be empty, apart from
+		// the king and rook themselves.
+		if (span(c.king, c.kingTo)|span(c.rook, c.rookTo))&occ&^bb(c.king)&^bb(c.rook) != 0 {
+			continue
+		}
+
+		// The king must not pass through check. In Chess960, the rook can
+		// also uncover an attack on the king's final square, so that is
+		// checked by playing the move.
+		safe := true
+		for b := span(c.king, c.kingTo); b != 0; b &= b - 1 {
+			if p.isAttacked(b.first(), us.Other()) {
+				safe = false
+				break
+			}
+		}
+		if !safe {
+			continue
+		}
+		m := p.castlingMove(i)
+		p.Apply(m)
+		safe = !p.isAttacked(p.kingSquare(us), us.Other())
+		p.Unapply()
+		if safe {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// Chess960 reports whether p is in Chess960 mode. In Chess960 mode, castling
+// moves are encoded as the king capturing its own rook, as in UCI, and FEN
+// castling rights are written in X-FEN style.
+func (p *Position) Chess960() bool {
+	return p.chess960
+}
+
+var errNotStandard = errors.New("chess: castling rooks or king are not on their standard squares")
+
+// SetChess960 turns Chess960 mode on or off. ParseFEN turns it on for
+// positions that need it, such as those with the king off the e-file and
+// castling rights. Such positions can't leave Chess960 mode.
+func (p *Position) SetChess960(on bool) error {
+	if !on && p.needsChess960() {
+		return errNotStandard
+	}
+	p.chess960 = on
+	return nil
+}
+
+// needsChess960 reports whether any castling right involves a king or rook
+// outside its standard square.
+func (p *Position) needsChess960() bool {
+	for i := 0; i < 4; i++ {
+		if p.castling&(1<<i) != 0 && !p.isStandardCastling(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStandardCastling reports whether castling with right i has the king on the
+// e-file and the rook on the a- or h-file.
+func (p *Position) isStandardCastling(i int) bool {
+	rookFile := FileH
+	if i%2 == 1 {
+		rookFile = FileA
+	}
+	return p.kingSquare(Color(i/2)).File() == FileE && p.castlingRooks[i].File() == rookFile
+}
+
+// ConvertCastling returns m with castling re-encoded for Chess960, as the king
+// capturing its own rook, or for standard chess, as the king moving two
+// squares. m may be in either encoding. Other moves are returned unchanged, as
+// are castling moves that have no standard encoding because the king or rook
+// is off its standard square.
+func (p *Position) ConvertCastling(m Move, chess960 bool) Move {
+	pc := p.board[m.From]
+	if pc.Type() != King || pc.Color() != p.sideToMove {
+		return m
+	}
+	for _, kingside := range []bool{true, false} {
+		i := castlingIndex(pc.Color(), kingside)
+		if p.castling&(1<<i) == 0 {
+			continue
+		}
+		c := p.castlingPath(i, m.From)
+		std := p.isStandardCastling(i)
+		if m.To != c.rook && !(std && m.To == c.kingTo) {
+			continue
+		}
+		if chess960 || !std {
+			return Move{From: c.king, To: c.rook}
+		}
+		return Move{From: c.king, To: c.kingTo}
+	}
+	return m
+}
+
+// parseCastlingRights parses the castling field of a FEN. It accepts standard
+// "KQkq" letters, X-FEN, where K and Q refer to the outermost rooks, and
+// Shredder-FEN, where rooks are given by their files.
+func (p *Position) parseCastlingRights(s string) error {
+	p.castlingRooks = [4]Square{H1, A1, H8, A8}
+	if s == "-" {
+		return nil
+	}
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		c := White
+		if ch >= 'a' && ch <= 'z' {
+			c = Black
+			ch -= 'a' - 'A'
+		}
+		rank := Rank1
+		if c == Black {
+			rank = Rank8
+		}
+		kings := p.Pieces(c, King)
+		if kings.Count() != 1 || kings.first().Rank() != rank {
+			return fmt.Errorf("castling rights %q without the king on its back rank", s)
+		}
+		king := kings.first()
+		rook := NewPiece(c, Rook)
+
+		var sq Square
+		switch {
+		case ch == 'K':
+			sq = NoSquare
+			for f := FileH; f > king.File(); f-- {
+				if p.board[NewSquare(f, rank)] == rook {
+					sq = NewSquare(f, rank)
+					break
+				}
+			}
+		case ch == 'Q':
+			sq = NoSquare
+			for f := FileA; f < king.File(); f++ {
+				if p.board[NewSquare(f, rank)] == rook {
+					sq = NewSquare(f, rank)
+					break
+				}
+			}
+		case ch >= 'A' && ch <= 'H':
+			sq = NewSquare(File(ch-'A'), rank)
+			p.chess960 = true
+		default:
+			return fmt.Errorf("invalid castling rights %q", s)
+		}
+		if sq == NoSquare || p.board[sq] != rook || sq.File() == king.File() {
+			return fmt.Errorf("castling rights %q without a rook", s)
+		}
+
+		j := castlingIndex(c, sq.File() > king.File())
+		if p.castling&(1<<j) != 0 {
+			return fmt.Errorf("invalid castling rights %q", s)
+		}
+		p.castling |= 1 << j
+		p.castlingRooks[j] = sq
+	}
+
+	if p.needsChess960() {
+		p.chess960 = true
+	}
+	return nil
+}
+
+// formatCastlingRights formats the castling field of a FEN. In Chess960 mode,
+// rights use X-FEN: K and Q for the outermost rooks, and files otherwise.
+func (p *Position) formatCastlingRights() string {
+	if !p.chess960 || p.castling == NoCastling {
+		return p.castling.String()
+	}
+
+	var b strings.Builder
+	for i := 0; i < 4; i++ {
+		if p.castling&(1<<i) == 0 {
+			continue
+		}
+		c := Color(i / 2)
+		sq := p.castlingRooks[i]
+
+		// Look for other rooks further out.
+		left := span(NewSquare(FileA, sq.Rank()), sq) &^ bb(sq)
+		right := span(sq, NewSquare(FileH, sq.Rank())) &^ bb(sq)
+		outer, letter := p.Pieces(c, Rook)&right, byte('K')
+		if i%2 == 1 {
+			outer, letter = p.Pieces(c, Rook)&left, 'Q'
+		}
+		if outer != 0 {
+			letter = 'A' + byte(sq.File())
+		}
+		if c == Black {
+			letter += 'a' - 'A'
+		}
+		b.WriteByte(letter)
+	}
+	return b.String()
+}
@@ -0,0 +1,184 @@
+package chess
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseFEN_Chess960(t *testing.T) {
+	cases := []struct {
+		fen      string
+		chess960 bool
+		want     string // The FEN as formatted, if different.
+	}{
+		{StartingFEN, false, ""},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1", true, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", true, "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9"},
+		{"1r2k1r1/8/8/8/8/8/8/RR2K3 w Bg - 0 1", true, "1r2k1r1/8/8/8/8/8/8/RR2K3 w Bk - 0 1"},
+		{"1r2k1r1/8/8/8/8/8/8/RR2K3 w Ak - 0 1", true, "1r2k1r1/8/8/8/8/8/8/RR2K3 w Qk - 0 1"},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Errorf("ParseFEN(%q): %v", tc.fen, err)
+			continue
+		}
+		if p.Chess960() != tc.chess960 {
+			t.Errorf("%s: Chess960() = %v, want %v", tc.fen, p.Chess960(), tc.chess960)
+		}
+		want := tc.want
+		if want == "" {
+			want = tc.fen
+		}
+		if got := p.String(); got != want {
+			t.Errorf("ParseFEN(%q).String() = %q, want %q", tc.fen, got, want)
+		}
+	}
+
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN1 w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w Gkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkqK - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQ1BNR w K - 0 1",
+	} {
+		if _, err := ParseFEN(fen); err == nil {
+			t.Errorf("ParseFEN(%q) succeeded, want error", fen)
+		}
+	}
+}
+
+func TestCastling_Chess960(t *testing.T) {
+	// The king and rook swap squares: the king goes to g1 and the rook to f1.
+	p, err := ParseFEN("4k3/8/8/8/8/8/8/5RK1 w F - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := Move{From: G1, To: F1}
+	if !p.IsLegal(m) {
+		t.Fatal("castling is not legal")
+	}
+	if got := FormatSAN(p, m); got != "O-O-O" {
+		t.Errorf("FormatSAN = %q, want O-O-O", got)
+	}
+	if got, err := ParseSAN(p, "O-O-O"); err != nil || got != m {
+		t.Errorf("ParseSAN(O-O-O) = %v, %v, want %v", got, err, m)
+	}
+
+	p.Apply(m)
+	if want, got := "4k3/8/8/8/8/8/8/2KR4 b - - 1 1", p.String(); got != want {
+		t.Errorf("after castling got %q, want %q", got, want)
+	}
+	p.Unapply()
+	if want, got := "4k3/8/8/8/8/8/8/5RK1 w Q - 0 1", p.String(); got != want {
+		t.Errorf("after unapplying got %q, want %q", got, want)
+	}
+}
+
+func TestCastling_UncoveredAttack(t *testing.T) {
+	// Castling queenside leaves the king on b1, where the rook on a1 no
+	// longer shields it from the queen.
+	p, err := ParseFEN("4k3/8/8/8/8/8/8/qRK5 w B - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.IsLegal(Move{From: C1, To: B1}) {
+		t.Error("castling into check is legal")
+	}
+}
+
+func TestConvertCastling(t *testing.T) {
+	p := StartingPosition()
+	p.Apply(Move{From: G1, To: F3})
+	p.Apply(Move{From: G8, To: F6})
+	p.Apply(Move{From: G2, To: G3})
+	p.Apply(Move{From: G7, To: G6})
+	p.Apply(Move{From: F1, To: G2})
+	p.Apply(Move{From: F8, To: G7})
+
+	std, frc := Move{From: E1, To: G1}, Move{From: E1, To: H1}
+	if got := p.ConvertCastling(std, true); got != frc {
+		t.Errorf("to Chess960: got %v, want %v", got, frc)
+	}
+	if got := p.ConvertCastling(frc, false); got != std {
+		t.Errorf("to standard: got %v, want %v", got, std)
+	}
+	if got := p.ConvertCastling(std, false); got != std {
+		t.Errorf("standard to standard: got %v, want %v", got, std)
+	}
+	other := Move{From: E1, To: F1}
+	if got := p.ConvertCastling(other, true); got != other {
+		t.Errorf("non-castling move changed to %v", got)
+	}
+
+	if err := p.SetChess960(true); err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsLegal(frc) || p.IsLegal(std) {
+		t.Error("Chess960 mode doesn't use king-takes-rook castling")
+	}
+	if err := p.SetChess960(false); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := ParseFEN("4k3/8/8/8/8/8/8/5RK1 w F - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetChess960(false); err != errNotStandard {
+		t.Errorf("SetChess960(false): want %v, got %v", errNotStandard, err)
+	}
+}
+
+func TestCastling_Corpus(t *testing.T) {
+	data, err := os.ReadFile("testdata/castling.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		f := strings.Split(line, " | ")
+		if len(f) != 4 {
+			t.Fatalf("invalid line %q", line)
+		}
+		p, err := ParseFEN(f[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		frc, err := ParseMove(f[3])
+		if err != nil {
+			t.Fatal(err)
+		}
+		std := frc
+		if f[2] != "-" {
+			if std, err = ParseMove(f[2]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		own := std
+		if p.Chess960() {
+			own = frc
+		}
+		if !p.IsLegal(own) {
+			t.Errorf("%s: %v is not legal", f[0], own)
+			continue
+		}
+		if got, err := ParseSAN(p, f[1]); err != nil || got != own {
+			t.Errorf("%s: ParseSAN(%s) = %v, %v, want %v", f[0], f[1], got, err, own)
+		}
+		if got := FormatSAN(p, own); got != f[1] {
+			t.Errorf("%s: FormatSAN(%v) = %s, want %s", f[0], own, got, f[1])
+		}
+		for _, m := range []Move{std, frc} {
+			if got := p.ConvertCastling(m, true); got != frc {
+				t.Errorf("%s: ConvertCastling(%v, true) = %v, want %v", f[0], m, got, frc)
+			}
+			if got := p.ConvertCastling(m, false); got != std {
+				t.Errorf("%s: ConvertCastling(%v, false) = %v, want %v", f[0], m, got, std)
+			}
+		}
+	}
+}
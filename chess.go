@@ -0,0 +1,7 @@
+// Package chess implements the rules of chess.
+//
+// A Position holds the state of a game at a single point in time, and can be
+// read from and written to Forsyth-Edwards Notation (FEN). Positions follow
+// standard rules unless they are parsed with ParseVariantFEN, which supports
+// Crazyhouse, Atomic, King of the Hill, Antichess and Horde.
+package chess
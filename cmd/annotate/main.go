@@ -0,0 +1,162 @@
+// Annotate analyzes chess games with a UCI engine and writes them back with
+// evaluations, annotations for inaccuracies, mistakes and blunders, and the
+// engine's suggestions.
+//
+// Usage:
+//
+//	annotate [flags] -engine path [file.pgn ...]
+//
+// Games are read from the files, or from standard input if there are none.
+// The annotated games are written to standard output, with the engine and the
+// fingerprint of its build in the Annotator and AnnotatorFingerprint tags, and
+// a summary of each game, with the accuracy of each side and the moves that
+// lost the most, to standard error.
+//
+// The flags are:
+//
+//	-engine path
+//		The engine to analyze with. Required.
+//	-o name=value
+//		Set an engine option, such as Threads or Hash. It may be repeated.
+//	-depth n
+//		Search each position to depth n. The default is 14, unless
+//		-movetime or -nodes is given.
+//	-movetime duration
+//		Search each position for this long, such as 500ms.
+//	-nodes n
+//		Search each position for n nodes.
+//	-cp
+//		Classify moves by centipawn loss instead of expected score.
+//	-out file
+//		Write the annotated games to file instead of standard output.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/clfs/chess/annotate"
+	"github.com/clfs/chess/uci"
+)
+
+// optionFlags collects -o flags.
+type optionFlags []string
+
+func (o *optionFlags) String() string { return strings.Join(*o, ",") }
+
+func (o *optionFlags) Set(s string) error {
+	if !strings.Contains(s, "=") {
+		return fmt.Errorf("want name=value, got %q", s)
+	}
+	*o = append(*o, s)
+	return nil
+}
+
+var (
+	enginePath = flag.String("engine", "", "analyze with the engine at `path`")
+	depth      = flag.Int("depth", 0, "search each position to depth `n`")
+	moveTime   = flag.Duration("movetime", 0, "search each position for `duration`")
+	nodes      = flag.Int64("nodes", 0, "search each position for `n` nodes")
+	centipawns = flag.Bool("cp", false, "classify moves by centipawn loss")
+	outPath    = flag.String("out", "", "write the annotated games to `file`")
+	options    optionFlags
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("annotate: ")
+	flag.Var(&options, "o", "set engine option `name=value` (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: annotate [flags] -engine path [file.pgn ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *enginePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ok, err := run(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// run annotates the games. It reports whether every game could be analyzed.
+func run(ctx context.Context) (ok bool, err error) {
+	e, err := uci.EngineConfig{Path: *enginePath, Stderr: os.Stderr}.Start()
+	if err != nil {
+		return false, err
+	}
+	defer e.Close()
+	if _, _, _, err := e.UCI(); err != nil {
+		return false, err
+	}
+	for _, o := range options {
+		name, value, _ := strings.Cut(o, "=")
+		if err := e.SetOption(name, value); err != nil {
+			return false, err
+		}
+	}
+
+	fp, err := e.Fingerprint()
+	if err != nil {
+		return false, err
+	}
+	a := &annotate.Annotator{
+		Engine:      e.Client,
+		Search:      uci.Search{Depth: *depth, MoveTime: *moveTime, Nodes: *nodes},
+		Fingerprint: &fp,
+	}
+	if *depth == 0 && *moveTime == 0 && *nodes == 0 {
+		a.Search.Depth = 14
+	}
+	if *centipawns {
+		a.Thresholds = annotate.CentipawnThresholds
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var inputs []io.Reader
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		inputs = append(inputs, f)
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, os.Stdin)
+	}
+
+	p := &processor{a: a, out: out, summary: os.Stderr}
+	ok = true
+	for _, in := range inputs {
+		inputOK, err := p.process(ctx, in)
+		if err != nil {
+			return false, err
+		}
+		ok = ok && inputOK
+	}
+	return ok, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/annotate"
+	"github.com/clfs/chess/pgn"
+)
+
+// processor annotates games and summarizes them.
+type processor struct {
+	a       *annotate.Annotator
+	out     io.Writer // Receives the games.
+	summary io.Writer // Receives the summaries and problems.
+	games   int       // The number of games seen so far.
+}
+
+// process annotates the games read from r. Games that can't be read are
+// skipped, and games that can't be analyzed are written as they are; either
+// way, the problem is reported in the summary, and ok is false. Errors
+// writing the games, and ctx being done, stop processing.
+func (p *processor) process(ctx context.Context, r io.Reader) (ok bool, err error) {
+	rd := pgn.NewReader(r)
+	w := pgn.NewWriter(p.out)
+	ok = true
+	for {
+		g, err := rd.Read()
+		if err == io.EOF {
+			return ok, nil
+		}
+		p.games++
+		var pe *pgn.ParseError
+		if errors.As(err, &pe) {
+			fmt.Fprintf(p.summary, "Game %d: skipped: %v\n", p.games, err)
+			ok = false
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		rep, err := p.a.Analyze(ctx, g)
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if err == nil {
+			err = rep.Annotate(g)
+		}
+		if err != nil {
+			fmt.Fprintf(p.summary, "Game %d: not analyzed: %v\n", p.games, err)
+			ok = false
+		} else {
+			writeSummary(p.summary, p.games, g, rep)
+		}
+		if err := w.Write(g); err != nil {
+			return false, err
+		}
+	}
+}
+
+// sideNames are the names of the sides in summaries.
+var sideNames = [2]string{chess.White: "White", chess.Black: "Black"}
+
+// writeSummary writes a summary of the nth game's report: the accuracy of each
+// side, and its mistakes and blunders.
+func writeSummary(w io.Writer, n int, g *pgn.Game, rep *annotate.Report) {
+	fmt.Fprintf(w, "Game %d: %s - %s (%v)\n", n, tagOr(g, "White", "?"), tagOr(g, "Black", "?"), g.Result)
+	for _, c := range []chess.Color{chess.White, chess.Black} {
+		fmt.Fprintf(w, "  %s: accuracy %.1f, ACPL %.0f, %s, %s, %s\n",
+			sideNames[c], rep.Accuracy[c], rep.ACPL[c],
+			plural(rep.Count(c, annotate.Inaccuracy), "inaccuracy", "inaccuracies"),
+			plural(rep.Count(c, annotate.Mistake), "mistake", "mistakes"),
+			plural(rep.Count(c, annotate.Blunder), "blunder", "blunders"))
+	}
+
+	start, err := g.StartingPosition()
+	if err != nil {
+		return
+	}
+	pos := start.Clone()
+	for i, mr := range rep.Moves {
+		if mr.Class >= annotate.Mistake {
+			suffix := "?"
+			if mr.Class == annotate.Blunder {
+				suffix = "??"
+			}
+			fmt.Fprintf(w, "  %s%s%s", moveNumber(start, i), chess.FormatSAN(pos, mr.Move), suffix)
+			if mr.Best != (chess.Move{}) && pos.IsLegal(mr.Best) {
+				fmt.Fprintf(w, " (%s was best)", chess.FormatSAN(pos, mr.Best))
+			}
+			fmt.Fprintln(w)
+		}
+		pos.Apply(mr.Move)
+	}
+}
+
+// moveNumber returns the number of the move at ply from start, such as "12."
+// or "12...".
+func moveNumber(start *chess.Position, ply int) string {
+	if start.SideToMove() == chess.Black {
+		ply++
+	}
+	n := start.FullmoveNumber() + ply/2
+	if ply%2 == 1 {
+		return fmt.Sprintf("%d... ", n)
+	}
+	return fmt.Sprintf("%d. ", n)
+}
+
+func tagOr(g *pgn.Game, name, def string) string {
+	if v := g.Tag(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// plural formats a count of things, such as "1 mistake" or "2 mistakes".
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/annotate"
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/uci"
+)
+
+const games = `[White "Scholar"]
+[Black "Victim"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+
+[White "Bad"]
+[Black "Game"]
+
+1. e4 e4 *
+
+[White "Short"]
+[Black "Draw"]
+[Result "1/2-1/2"]
+
+1. d4 d5 1/2-1/2
+`
+
+func TestProcessor(t *testing.T) {
+	c, closer, err := refengine.New().Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closer.Close() })
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, summary bytes.Buffer
+	p := &processor{
+		a:       &annotate.Annotator{Engine: c, Search: uci.Search{Depth: 2}},
+		out:     &out,
+		summary: &summary,
+	}
+	ok, err := p.process(context.Background(), strings.NewReader(games))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("want ok false for a bad game")
+	}
+
+	for _, want := range []string{
+		"Game 1: Scholar - Victim (1-0)\n",
+		"  Black: accuracy ",
+		"  3... Nf6?? (",
+		"Game 2: skipped: ",
+		"Game 3: Short - Draw (1/2-1/2)\n",
+	} {
+		if !strings.Contains(summary.String(), want) {
+			t.Errorf("summary doesn't contain %q:\n%s", want, summary.String())
+		}
+	}
+	for _, want := range []string{
+		"Nf6 $4 {Blunder.",
+		"{[%eval ",
+		"[White \"Short\"]",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), `"Bad"`) {
+		t.Errorf("output contains the bad game:\n%s", out.String())
+	}
+}
+
+func TestMoveNumber(t *testing.T) {
+	black, err := chess.ParseFEN("8/8/8/8/8/8/8/K1k5 b - - 0 30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		start *chess.Position
+		ply   int
+		want  string
+	}{
+		{chess.StartingPosition(), 0, "1. "},
+		{chess.StartingPosition(), 1, "1... "},
+		{chess.StartingPosition(), 4, "3. "},
+		{black, 0, "30... "},
+		{black, 1, "31. "},
+	}
+	for _, tc := range cases {
+		if got := moveNumber(tc.start, tc.ply); got != tc.want {
+			t.Errorf("moveNumber(%v, %d): want %q, got %q", tc.start, tc.ply, tc.want, got)
+		}
+	}
+}
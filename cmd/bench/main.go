@@ -0,0 +1,153 @@
+// Bench searches the positions of an EPD suite with one or more UCI engines
+// and compares their speed, depth and best moves, such as to compare two
+// builds of an engine.
+//
+// Usage:
+//
+//	bench [flags] suite.epd [name=]path ...
+//
+// Each engine is named by the name before '=', or else by its path. The
+// flags are:
+//
+//	-depth n
+//		Search each position to depth n.
+//	-nodes n
+//		Search n nodes in each position.
+//	-movetime d
+//		Search each position for duration d, such as 500ms.
+//	-newgame
+//		Send "ucinewgame" before each position.
+//	-json
+//		Write the report as JSON instead of text.
+//	-v
+//		Log the result of each position as it is searched.
+//
+// At least one of -depth, -nodes and -movetime is required. Best moves in
+// the suite's "bm" operations count as solved.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/clfs/chess/bench"
+	"github.com/clfs/chess/epd"
+	"github.com/clfs/chess/uci"
+)
+
+var (
+	depth    = flag.Int("depth", 0, "search each position to depth `n`")
+	nodes    = flag.Int64("nodes", 0, "search `n` nodes in each position")
+	moveTime = flag.Duration("movetime", 0, "search each position for duration `d`")
+	newGame  = flag.Bool("newgame", false, `send "ucinewgame" before each position`)
+	asJSON   = flag.Bool("json", false, "write the report as JSON")
+	verbose  = flag.Bool("v", false, "log the result of each position")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("bench: ")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: bench [flags] suite.epd [name=]path ...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *depth == 0 && *nodes == 0 && *moveTime == 0 {
+		log.Fatal("one of -depth, -nodes and -movetime is required")
+	}
+	b := &bench.Bench{
+		Search:  uci.Search{Depth: *depth, Nodes: *nodes, MoveTime: *moveTime},
+		NewGame: *newGame,
+	}
+	if *verbose {
+		b.Progress = logResult
+	}
+
+	positions, err := readSuite(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var engines []bench.Engine
+	for _, arg := range flag.Args()[1:] {
+		name, e, err := startEngine(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer e.Close()
+		engines = append(engines, bench.Engine{Name: name, Client: e.Client})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, b, engines, positions, os.Stdout, *asJSON); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run benchmarks the engines and writes the report to w. If ctx is done, the
+// report so far is written before the error is returned.
+func run(ctx context.Context, b *bench.Bench, engines []bench.Engine, positions []bench.Position, w io.Writer, asJSON bool) error {
+	r, err := b.Run(ctx, engines, positions)
+	if r == nil {
+		return err
+	}
+	write := r.WriteText
+	if asJSON {
+		write = r.WriteJSON
+	}
+	if werr := write(w); err == nil {
+		err = werr
+	}
+	if errors.Is(err, context.Canceled) {
+		err = errors.New("interrupted")
+	}
+	return err
+}
+
+// readSuite reads the positions of the EPD file at path.
+func readSuite(path string) ([]bench.Position, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := epd.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bench.FromEPD(records)
+}
+
+// startEngine starts the engine described by arg, which is a path optionally
+// preceded by a name and '='.
+func startEngine(arg string) (string, *uci.Engine, error) {
+	name, path, ok := strings.Cut(arg, "=")
+	if !ok {
+		name, path = arg, arg
+	}
+	e, err := uci.StartEngine(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, _, _, err := e.UCI(); err != nil {
+		e.Close()
+		return "", nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return name, e, nil
+}
+
+func logResult(engine string, r bench.Result) {
+	log.Printf("%s: position %d: %v, depth %d, %d nodes in %v", engine, r.Position+1, r.Move, r.Depth, r.Nodes, r.Elapsed.Round(time.Millisecond))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess/bench"
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/uci"
+)
+
+func refEngine(t *testing.T, name string) bench.Engine {
+	t.Helper()
+	c, closer, err := refengine.New().Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closer.Close() })
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	return bench.Engine{Name: name, Client: c}
+}
+
+func writeSuite(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "suite.epd")
+	suite := `6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8#; id "back rank";` + "\n"
+	if err := os.WriteFile(path, []byte(suite), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	positions, err := readSuite(writeSuite(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &bench.Bench{Search: uci.Search{Depth: 2}}
+	engines := []bench.Engine{refEngine(t, "a"), refEngine(t, "b")}
+
+	var out bytes.Buffer
+	if err := run(context.Background(), b, engines, positions, &out, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Search: go depth 2\n", "Solved", "1/1", "Agreement", "100%"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := run(context.Background(), b, engines[:1], positions, &out, true); err != nil {
+		t.Fatal(err)
+	}
+	var r bench.Report
+	if err := json.Unmarshal(out.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Engines) != 1 || r.Engines[0].Solved != 1 {
+		t.Errorf("got report %+v", r)
+	}
+}
+
+func TestRun_Interrupted(t *testing.T) {
+	positions, err := readSuite(writeSuite(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := &bench.Bench{Search: uci.Search{Depth: 2}}
+
+	var out bytes.Buffer
+	err = run(ctx, b, []bench.Engine{refEngine(t, "a")}, positions, &out, false)
+	if err == nil || err.Error() != "interrupted" {
+		t.Errorf("got error %v, want interrupted", err)
+	}
+	if !strings.Contains(out.String(), "0/0") {
+		t.Errorf("want the report so far, got:\n%s", out.String())
+	}
+}
+
+func TestReadSuite_Missing(t *testing.T) {
+	if _, err := readSuite(filepath.Join(t.TempDir(), "missing.epd")); err == nil {
+		t.Error("want an error for a missing file")
+	}
+}
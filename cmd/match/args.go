@@ -0,0 +1,358 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clfs/chess/match"
+	"github.com/clfs/chess/stats"
+	"github.com/clfs/chess/timecontrol"
+	"github.com/clfs/chess/uci"
+)
+
+// errHelp is returned by parseArgs when help is requested.
+var errHelp = errors.New("help requested")
+
+// engineSpec describes an engine from the command line.
+type engineSpec struct {
+	name    string
+	cfg     uci.EngineConfig
+	options map[string]string
+}
+
+// openingSpec describes where the openings come from.
+type openingSpec struct {
+	file   string
+	format string // "epd" or "pgn".
+	plies  int    // The number of moves to play from PGN openings. 0 means all.
+	random bool   // Shuffle the openings.
+	seed   int64  // The seed for shuffling. 0 means a random seed.
+}
+
+// config is a parsed command line.
+type config struct {
+	engines     []engineSpec
+	format      match.Format
+	tc          timecontrol.Control
+	search      uci.Search
+	margin      time.Duration
+	games       int
+	rounds      int
+	concurrency int
+	openings    openingSpec
+	sprt        *stats.SPRT
+	pgnOut      string
+	recover     bool // Restart engines that crash.
+	adjudicate  match.Adjudication
+}
+
+// parseArgs parses the command line, in the style of cutechess-cli: options
+// start with "-" and are followed by their values, which are often key=value
+// pairs.
+func parseArgs(args []string) (*config, error) {
+	cfg := &config{games: 2, rounds: 1, concurrency: 1}
+	var each []string
+	var engines [][]string
+
+	for i := 0; i < len(args); {
+		opt := args[i]
+		i++
+		var values []string
+		for ; i < len(args) && !strings.HasPrefix(args[i], "-"); i++ {
+			values = append(values, args[i])
+		}
+
+		var err error
+		switch opt {
+		case "-h", "-help", "--help":
+			return nil, errHelp
+		case "-engine":
+			engines = append(engines, values)
+		case "-each":
+			each = append(each, values...)
+		case "-tournament":
+			err = cfg.parseFormat(values)
+		case "-games":
+			cfg.games, err = positive(opt, values)
+		case "-rounds":
+			cfg.rounds, err = positive(opt, values)
+		case "-concurrency":
+			cfg.concurrency, err = positive(opt, values)
+		case "-openings":
+			err = cfg.parseOpenings(values)
+		case "-sprt":
+			err = cfg.parseSPRT(values)
+		case "-draw":
+			a := &cfg.adjudicate
+			err = parseInts(opt, values, map[string]*int{"movenumber": &a.DrawMoveNumber, "movecount": &a.DrawMoves, "score": &a.DrawScore}, "movecount")
+		case "-resign":
+			a := &cfg.adjudicate
+			err = parseInts(opt, values, map[string]*int{"movecount": &a.ResignMoves, "score": &a.ResignScore}, "movecount", "score")
+		case "-recover":
+			if len(values) != 0 {
+				err = errors.New("-recover: takes no values")
+			}
+			cfg.recover = true
+		case "-pgnout":
+			if len(values) != 1 {
+				err = fmt.Errorf("-pgnout: want a file name")
+			}
+			cfg.pgnOut = strings.Join(values, " ")
+		default:
+			err = fmt.Errorf("unknown option %q", opt)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Settings given with -each come first, so that each engine can override
+	// them.
+	var eachEngine []string
+	for _, kv := range each {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("-each: want key=value, got %q", kv)
+		}
+		handled, err := cfg.parseGameSetting(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("-each: %w", err)
+		}
+		if !handled {
+			eachEngine = append(eachEngine, kv)
+		}
+	}
+	for _, values := range engines {
+		spec, err := parseEngine(append(append([]string{}, eachEngine...), values...))
+		if err != nil {
+			return nil, fmt.Errorf("-engine: %w", err)
+		}
+		cfg.engines = append(cfg.engines, spec)
+	}
+
+	switch {
+	case len(cfg.engines) < 2:
+		return nil, errors.New("at least two engines are needed")
+	case len(cfg.tc) == 0 && cfg.search.Depth == 0 && cfg.search.Nodes == 0 && cfg.search.MoveTime == 0:
+		return nil, errors.New("no time control: use -each tc=..., st=..., depth=... or nodes=...")
+	case cfg.sprt != nil && len(cfg.engines) != 2:
+		return nil, errors.New("-sprt needs exactly two engines")
+	}
+	return cfg, nil
+}
+
+// parseGameSetting parses a setting that applies to every game, such as the
+// time control. It reports whether key is such a setting.
+func (cfg *config) parseGameSetting(key, value string) (handled bool, err error) {
+	switch key {
+	case "tc":
+		if value == "inf" {
+			cfg.tc = nil
+			break
+		}
+		cfg.tc, err = timecontrol.Parse(value)
+	case "st":
+		var d time.Duration
+		if d, err = seconds(value); err == nil {
+			cfg.search.MoveTime = d
+		}
+	case "depth":
+		cfg.search.Depth, err = strconv.Atoi(value)
+	case "nodes":
+		cfg.search.Nodes, err = strconv.ParseInt(value, 10, 64)
+	case "timemargin":
+		var ms int
+		ms, err = strconv.Atoi(value)
+		cfg.margin = time.Duration(ms) * time.Millisecond
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return true, nil
+}
+
+// parseEngine parses the settings of an engine: name=, cmd=, dir=, arg=
+// (repeatable) and option.NAME=VALUE.
+func parseEngine(values []string) (engineSpec, error) {
+	spec := engineSpec{options: make(map[string]string)}
+	for _, kv := range values {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return spec, fmt.Errorf("want key=value, got %q", kv)
+		}
+		switch {
+		case key == "name":
+			spec.name = value
+		case key == "cmd":
+			spec.cfg.Path = value
+		case key == "dir":
+			spec.cfg.Dir = value
+		case key == "arg":
+			spec.cfg.Args = append(spec.cfg.Args, value)
+		case strings.HasPrefix(key, "option."):
+			spec.options[strings.TrimPrefix(key, "option.")] = value
+		case key == "tc" || key == "st" || key == "depth" || key == "nodes" || key == "timemargin":
+			return spec, fmt.Errorf("%s applies to every game; give it with -each", key)
+		default:
+			return spec, fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	if spec.cfg.Path == "" {
+		return spec, errors.New("missing cmd")
+	}
+	if spec.name == "" {
+		spec.name = filepath.Base(spec.cfg.Path)
+	}
+	return spec, nil
+}
+
+func (cfg *config) parseFormat(values []string) error {
+	switch strings.Join(values, " ") {
+	case "round-robin":
+		cfg.format = match.RoundRobin
+	case "gauntlet":
+		cfg.format = match.Gauntlet
+	default:
+		return fmt.Errorf("-tournament: want round-robin or gauntlet, got %q", strings.Join(values, " "))
+	}
+	return nil
+}
+
+// parseOpenings parses the settings of -openings: file=, format=epd|pgn,
+// plies=N, order=sequential|random and seed=N.
+func (cfg *config) parseOpenings(values []string) error {
+	o := &cfg.openings
+	for _, kv := range values {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("-openings: want key=value, got %q", kv)
+		}
+		var err error
+		switch key {
+		case "file":
+			o.file = value
+		case "format":
+			if value != "epd" && value != "pgn" {
+				return fmt.Errorf("-openings: want format epd or pgn, got %q", value)
+			}
+			o.format = value
+		case "plies":
+			o.plies, err = strconv.Atoi(value)
+		case "order":
+			if value != "sequential" && value != "random" {
+				return fmt.Errorf("-openings: want order sequential or random, got %q", value)
+			}
+			o.random = value == "random"
+		case "seed":
+			o.seed, err = strconv.ParseInt(value, 10, 64)
+		default:
+			return fmt.Errorf("-openings: unknown setting %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("-openings: invalid %s %q", key, value)
+		}
+	}
+	if o.file == "" {
+		return errors.New("-openings: missing file")
+	}
+	if o.format == "" {
+		o.format = "epd"
+		if strings.EqualFold(filepath.Ext(o.file), ".pgn") {
+			o.format = "pgn"
+		}
+	}
+	return nil
+}
+
+// parseSPRT parses the settings of -sprt: elo0=, elo1=, alpha= and beta=.
+// The error rates default to 0.05.
+func (cfg *config) parseSPRT(values []string) error {
+	s := stats.SPRT{Alpha: 0.05, Beta: 0.05}
+	var haveElo0, haveElo1 bool
+	for _, kv := range values {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("-sprt: want key=value, got %q", kv)
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("-sprt: invalid %s %q", key, value)
+		}
+		switch key {
+		case "elo0":
+			s.Elo0, haveElo0 = f, true
+		case "elo1":
+			s.Elo1, haveElo1 = f, true
+		case "alpha":
+			s.Alpha = f
+		case "beta":
+			s.Beta = f
+		default:
+			return fmt.Errorf("-sprt: unknown setting %q", key)
+		}
+	}
+	switch {
+	case !haveElo0 || !haveElo1:
+		return errors.New("-sprt: elo0 and elo1 are required")
+	case s.Elo0 >= s.Elo1:
+		return errors.New("-sprt: elo0 must be less than elo1")
+	case s.Alpha <= 0 || s.Alpha >= 1 || s.Beta <= 0 || s.Beta >= 1:
+		return errors.New("-sprt: alpha and beta must be between 0 and 1")
+	}
+	cfg.sprt = &s
+	return nil
+}
+
+// parseInts parses the key=value settings of opt into the integers of fields,
+// by key. The required keys must be present, and all values non-negative.
+func parseInts(opt string, values []string, fields map[string]*int, required ...string) error {
+	seen := make(map[string]bool)
+	for _, kv := range values {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("%s: want key=value, got %q", opt, kv)
+		}
+		p, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %q", opt, key)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s: invalid %s %q", opt, key, value)
+		}
+		*p, seen[key] = n, true
+	}
+	for _, key := range required {
+		if !seen[key] {
+			return fmt.Errorf("%s: missing %s", opt, key)
+		}
+	}
+	return nil
+}
+
+// positive parses the single value of opt as a positive integer.
+func positive(opt string, values []string) (int, error) {
+	if len(values) != 1 {
+		return 0, fmt.Errorf("%s: want a number", opt)
+	}
+	n, err := strconv.Atoi(values[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s: want a positive number, got %q", opt, values[0])
+	}
+	return n, nil
+}
+
+// seconds parses a number of seconds, such as "0.5".
+func seconds(s string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid number of seconds %q", s)
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
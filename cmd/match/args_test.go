@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/match"
+	"github.com/clfs/chess/stats"
+	"github.com/clfs/chess/timecontrol"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseArgs(t *testing.T) {
+	args := strings.Fields(`-engine cmd=/bin/sf name=SF option.Hash=64 -engine cmd=/opt/lc0 arg=--verbose dir=/opt
+		-each tc=40/60+0.5:30 option.Threads=2 timemargin=100
+		-tournament gauntlet -games 4 -rounds 3 -concurrency 2
+		-openings file=book.PGN plies=8 order=random seed=7
+		-sprt elo0=0 elo1=5 alpha=0.1 -pgnout out.pgn -recover
+		-draw movenumber=40 movecount=8 score=10 -resign movecount=3 score=900`)
+	got, err := parseArgs(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &config{
+		engines: []engineSpec{
+			{name: "SF", cfg: uci.EngineConfig{Path: "/bin/sf"}, options: map[string]string{"Hash": "64", "Threads": "2"}},
+			{name: "lc0", cfg: uci.EngineConfig{Path: "/opt/lc0", Args: []string{"--verbose"}, Dir: "/opt"}, options: map[string]string{"Threads": "2"}},
+		},
+		format:      match.Gauntlet,
+		tc:          timecontrol.Control{{Moves: 40, Time: time.Minute, Increment: 500 * time.Millisecond}, {Time: 30 * time.Second}},
+		margin:      100 * time.Millisecond,
+		games:       4,
+		rounds:      3,
+		concurrency: 2,
+		openings:    openingSpec{file: "book.PGN", format: "pgn", plies: 8, random: true, seed: 7},
+		sprt:        &stats.SPRT{Elo0: 0, Elo1: 5, Alpha: 0.1, Beta: 0.05},
+		pgnOut:      "out.pgn",
+		recover:     true,
+		adjudicate: match.Adjudication{
+			DrawMoveNumber: 40, DrawMoves: 8, DrawScore: 10,
+			ResignMoves: 3, ResignScore: 900,
+		},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(config{}, engineSpec{}, openingSpec{})); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Engines override -each.
+	got, err = parseArgs(strings.Fields("-engine cmd=a option.Hash=1 -engine cmd=b -each option.Hash=2 depth=3 st=0.5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.engines[0].options["Hash"] != "1" || got.engines[1].options["Hash"] != "2" {
+		t.Errorf("options: got %v and %v", got.engines[0].options, got.engines[1].options)
+	}
+	if want := (uci.Search{Depth: 3, MoveTime: 500 * time.Millisecond}); !cmp.Equal(want, got.search) {
+		t.Errorf("search: want %v, got %v", want, got.search)
+	}
+}
+
+func TestParseArgs_Errors(t *testing.T) {
+	for _, args := range []string{
+		"-engine cmd=a -each tc=1",
+		"-engine cmd=a -engine cmd=b",
+		"-engine cmd=a -engine name=b -each tc=1",
+		"-engine cmd=a tc=1 -engine cmd=b -each tc=1",
+		"-engine cmd=a -engine cmd=b -each tc=x",
+		"-engine cmd=a -engine cmd=b -each tc=1 -games 0",
+		"-engine cmd=a -engine cmd=b -each tc=1 -tournament swiss",
+		"-engine cmd=a -engine cmd=b -each tc=1 -openings plies=2",
+		"-engine cmd=a -engine cmd=b -each tc=1 -sprt elo0=5 elo1=0",
+		"-engine cmd=a -engine cmd=b -engine cmd=c -each tc=1 -sprt elo0=0 elo1=5",
+		"-engine cmd=a -engine cmd=b -each tc=1 -recover yes",
+		"-engine cmd=a -engine cmd=b -each tc=1 -draw score=10",
+		"-engine cmd=a -engine cmd=b -each tc=1 -draw movecount=-1",
+		"-engine cmd=a -engine cmd=b -each tc=1 -resign movecount=3",
+		"-engine cmd=a -engine cmd=b -each tc=1 -resign movecount=3 score=900 twosided=true",
+		"-engine cmd=a -engine cmd=b -each tc=1 -bogus",
+	} {
+		if _, err := parseArgs(strings.Fields(args)); err == nil {
+			t.Errorf("parseArgs(%q): want error", args)
+		}
+	}
+	if _, err := parseArgs([]string{"-help"}); err != errHelp {
+		t.Errorf("-help: want errHelp, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+// Match plays matches and tournaments between UCI engines, in the style of
+// cutechess-cli.
+//
+// Usage:
+//
+//	match -engine cmd=path [name=...] ... -engine ... -each tc=... [options]
+//
+// Options take values after them, mostly key=value pairs:
+//
+//	-engine key=value ...
+//		An engine. The keys are cmd (required), name, dir, arg (repeatable,
+//		for command-line arguments) and option.NAME=VALUE for UCI options.
+//	-each key=value ...
+//		Settings for every engine, which -engine can override, and for
+//		every game: tc=time control, in the format of the PGN TimeControl
+//		tag, such as 40/60+0.5 or 40/5400+30:1800+30, st=seconds per move,
+//		depth=n, nodes=n and timemargin=milliseconds.
+//	-tournament round-robin|gauntlet
+//		The pairings. The default is round-robin. In a gauntlet, the first
+//		engine plays the others.
+//	-games n
+//		Games per pairing in each round, alternating colors. The default is 2.
+//	-rounds n
+//		Rounds to play. The default is 1.
+//	-concurrency n
+//		Games to play at once. The default is 1.
+//	-openings file=path [format=epd|pgn] [plies=n] [order=sequential|random] [seed=n]
+//		Openings to start games from. Each opening is played twice, with
+//		colors reversed.
+//	-sprt elo0=x elo1=y [alpha=0.05] [beta=0.05]
+//		Stop a two-engine match as soon as a sequential probability ratio
+//		test reaches a decision.
+//	-pgnout path
+//		Append the games to path as they finish.
+//	-draw movecount=n [movenumber=n] [score=cp]
+//		Adjudicate a draw once both engines have reported scores within
+//		score centipawns of zero (default 0) for n moves each, counting
+//		from move movenumber on.
+//	-resign movecount=n score=cp
+//		Adjudicate a win once both engines have agreed for n moves each
+//		that the same side is ahead by at least score centipawns.
+//	-recover
+//		Restart an engine that crashes in the middle of a game, and resume
+//		the game, instead of scoring it as a loss.
+//
+// The score is reported after every game of a two-engine match, and the
+// standings at the end. Interrupting the program stops the match early and
+// reports the standings so far.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/clfs/chess/match"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/uci"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("match: ")
+	cfg, err := parseArgs(os.Args[1:])
+	if err == errHelp {
+		fmt.Fprintln(os.Stderr, "usage: match -engine cmd=path [name=...] ... -engine ... -each tc=... [options]")
+		fmt.Fprintln(os.Stderr, "Run 'go doc github.com/clfs/chess/cmd/match' for the options.")
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// On Ctrl-C, the games in progress are abandoned, and the standings so
+	// far are written.
+	sh := uci.ShutdownOnSignal(context.Background(), 5*time.Second)
+	err = run(sh.Context(), cfg, os.Stdout)
+	var se *uci.SignalError
+	if errors.As(sh.Stop(), &se) {
+		os.Exit(se.ExitCode())
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, cfg *config, w io.Writer) error {
+	t, err := tournament(cfg)
+	if err != nil {
+		return err
+	}
+
+	rep := &reporter{w: w, sprt: cfg.sprt}
+	for _, p := range t.Players {
+		rep.players = append(rep.players, p.Name)
+	}
+	if cfg.pgnOut != "" {
+		f, err := os.OpenFile(cfg.pgnOut, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rep.pgn = pgn.NewWriter(f)
+	}
+	return play(ctx, t, rep)
+}
+
+// tournament sets up the tournament described by cfg.
+func tournament(cfg *config) (*match.Tournament, error) {
+	t := &match.Tournament{
+		Format:          cfg.format,
+		GamesPerPairing: cfg.games * cfg.rounds,
+		Concurrency:     cfg.concurrency,
+		Restart:         cfg.recover,
+		Match: match.Match{
+			Control:      cfg.tc,
+			Search:       cfg.search,
+			Margin:       cfg.margin,
+			Adjudication: cfg.adjudicate,
+		},
+	}
+	for _, e := range cfg.engines {
+		e.cfg.Stderr = os.Stderr
+		t.Players = append(t.Players, match.EnginePlayer(e.name, e.cfg, e.options))
+	}
+	if cfg.openings.file != "" {
+		var err error
+		if t.Openings, err = loadOpenings(cfg.openings); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// play plays t, reporting each game to rep, and writes the standings. It stops
+// early when the SPRT reaches a decision or ctx is done.
+func play(ctx context.Context, t *match.Tournament, rep *reporter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rep.games = len(t.Players) * (len(t.Players) - 1) / 2 * t.GamesPerPairing
+	if t.Format == match.Gauntlet {
+		rep.games = (len(t.Players) - 1) * t.GamesPerPairing
+	}
+
+	var reportErr error
+	t.OnGame = func(g match.GameResult) {
+		if reportErr != nil {
+			return
+		}
+		done, err := rep.add(g)
+		if err != nil {
+			reportErr = err
+		}
+		if done || err != nil {
+			cancel()
+		}
+	}
+
+	_, err := t.Play(ctx)
+	if reportErr != nil {
+		return reportErr
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return rep.writeResults()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess/match"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/stats"
+	"github.com/clfs/chess/uci"
+)
+
+// refPlayer returns a player that runs the reference engine at depth.
+func refPlayer(name string, depth int) match.Player {
+	return match.Player{
+		Name: name,
+		Start: func() (*uci.Client, io.Closer, error) {
+			c, closer, err := refengine.New().Client()
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, _, _, err := c.UCI(); err != nil {
+				closer.Close()
+				return nil, nil, err
+			}
+			if err := c.SetOption("Depth", fmt.Sprint(depth)); err != nil {
+				closer.Close()
+				return nil, nil, err
+			}
+			return c, closer, nil
+		},
+	}
+}
+
+func TestPlay(t *testing.T) {
+	tour := &match.Tournament{
+		Players:         []match.Player{refPlayer("Deep", 2), refPlayer("Shallow", 1)},
+		GamesPerPairing: 2,
+		Concurrency:     2,
+		Openings:        []match.Opening{{FEN: "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1"}},
+	}
+	var out, games bytes.Buffer
+	rep := &reporter{w: &out, players: []string{"Deep", "Shallow"}, pgn: pgn.NewWriter(&games)}
+	if err := play(context.Background(), tour, rep); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"Finished game 1 (Deep vs Shallow): 1-0 {normal}\n",
+		"Finished game 2 (Shallow vs Deep): 1-0 {normal}\n",
+		"Score of Deep vs Shallow: 1 - 1 - 0  [0.500] 2/2\n",
+		"Elo difference: 0.0 +/- ",
+		"Player",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out.String())
+		}
+	}
+	if n := strings.Count(games.String(), "[Result"); n != 2 {
+		t.Errorf("want 2 games in the PGN, got %d:\n%s", n, games.String())
+	}
+}
+
+func TestPlay_SPRT(t *testing.T) {
+	tour := &match.Tournament{
+		Players:         []match.Player{refPlayer("Deep", 3), refPlayer("Shallow", 1)},
+		GamesPerPairing: 1000,
+		Openings: []match.Opening{
+			{FEN: "4k3/8/8/8/8/8/3QK3/8 w - - 0 1"},
+			{FEN: "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1"},
+		},
+	}
+	var out bytes.Buffer
+	rep := &reporter{w: &out, players: []string{"Deep", "Shallow"}, sprt: &stats.SPRT{Elo0: 0, Elo1: 5, Alpha: 0.05, Beta: 0.05}}
+	if err := play(context.Background(), tour, rep); err != nil {
+		t.Fatal(err)
+	}
+	// The deeper engine wins or draws every pair, so the test is decided
+	// quickly.
+	if !strings.Contains(out.String(), "SPRT: H1 accepted\n") {
+		t.Errorf("no decision:\n%s", out.String())
+	}
+	if len(rep.finished) >= 1000 {
+		t.Errorf("the match didn't stop early")
+	}
+}
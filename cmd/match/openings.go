@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/clfs/chess/epd"
+	"github.com/clfs/chess/match"
+	"github.com/clfs/chess/pgn"
+)
+
+// loadOpenings reads the openings described by spec.
+func loadOpenings(spec openingSpec) ([]match.Opening, error) {
+	f, err := os.Open(spec.file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var openings []match.Opening
+	if spec.format == "pgn" {
+		openings, err = readPGNOpenings(f, spec.plies)
+	} else {
+		openings, err = readEPDOpenings(f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(openings) == 0 {
+		return nil, errors.New("no openings in " + spec.file)
+	}
+
+	if spec.random {
+		seed := spec.seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		r := rand.New(rand.NewSource(seed))
+		r.Shuffle(len(openings), func(i, j int) {
+			openings[i], openings[j] = openings[j], openings[i]
+		})
+	}
+	return openings, nil
+}
+
+// readEPDOpenings reads the positions of EPD records as openings.
+func readEPDOpenings(r io.Reader) ([]match.Opening, error) {
+	records, err := epd.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var openings []match.Opening
+	for _, rec := range records {
+		openings = append(openings, match.Opening{FEN: rec.Position.String()})
+	}
+	return openings, nil
+}
+
+// readPGNOpenings reads the main lines of PGN games as openings, up to plies
+// moves each if plies is positive.
+func readPGNOpenings(r io.Reader, plies int) ([]match.Opening, error) {
+	rd := pgn.NewReader(r)
+	var openings []match.Opening
+	for {
+		g, err := rd.Read()
+		if err == io.EOF {
+			return openings, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		moves := g.MainLine()
+		if plies > 0 && len(moves) > plies {
+			moves = moves[:plies]
+		}
+		openings = append(openings, match.Opening{FEN: g.Tag("FEN"), Moves: moves})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/match"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadOpenings(t *testing.T) {
+	dir := t.TempDir()
+	epdFile := filepath.Join(dir, "book.epd")
+	pgnFile := filepath.Join(dir, "book.pgn")
+	os.WriteFile(epdFile, []byte("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - id \"e4\";\n\n4k3/8/8/8/8/8/8/4K3 w - -\n"), 0o644)
+	os.WriteFile(pgnFile, []byte("1. e4 e5 2. Nf3 *\n\n[FEN \"4k3/8/8/8/8/8/8/4K2R w K - 0 1\"]\n\n1. O-O *\n"), 0o644)
+
+	e2e4 := chess.Move{From: chess.E2, To: chess.E4}
+	e7e5 := chess.Move{From: chess.E7, To: chess.E5}
+	cases := []struct {
+		spec openingSpec
+		want []match.Opening
+	}{
+		{
+			openingSpec{file: epdFile, format: "epd"},
+			[]match.Opening{
+				{FEN: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"},
+				{FEN: "4k3/8/8/8/8/8/8/4K3 w - - 0 1"},
+			},
+		},
+		{
+			openingSpec{file: pgnFile, format: "pgn", plies: 2},
+			[]match.Opening{
+				{Moves: []chess.Move{e2e4, e7e5}},
+				{FEN: "4k3/8/8/8/8/8/8/4K2R w K - 0 1", Moves: []chess.Move{{From: chess.E1, To: chess.G1}}},
+			},
+		},
+	}
+	for _, tc := range cases {
+		got, err := loadOpenings(tc.spec)
+		if err != nil {
+			t.Errorf("loadOpenings(%+v): %v", tc.spec, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("loadOpenings(%+v) mismatch (-want +got):\n%s", tc.spec, diff)
+		}
+	}
+
+	// Shuffling with a seed is repeatable.
+	a, err := loadOpenings(openingSpec{file: epdFile, format: "epd", random: true, seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := loadOpenings(openingSpec{file: epdFile, format: "epd", random: true, seed: 1})
+	if !cmp.Equal(a, b) {
+		t.Errorf("shuffles differ: %v and %v", a, b)
+	}
+
+	if _, err := loadOpenings(openingSpec{file: filepath.Join(dir, "missing.epd"), format: "epd"}); err == nil {
+		t.Error("missing file: want error")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/clfs/chess/match"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/stats"
+)
+
+// reporter reports the progress of a tournament as games finish.
+type reporter struct {
+	w       io.Writer
+	players []string
+	games   int // The number of games scheduled.
+	sprt    *stats.SPRT
+	pgn     *pgn.Writer // Receives the games as they finish, if not nil.
+
+	finished []match.GameResult
+	tally    stats.Tally // The first player's results, in a two-player match.
+	decision stats.Decision
+}
+
+// add reports a finished game, and reports whether the SPRT, if any, has
+// reached a decision.
+func (r *reporter) add(g match.GameResult) (done bool, err error) {
+	r.finished = append(r.finished, g)
+	white, black := r.players[g.White], r.players[g.Black]
+	if g.Err != nil {
+		fmt.Fprintf(r.w, "Game %d (%s vs %s) not played: %v\n", g.Round, white, black, g.Err)
+		return false, nil
+	}
+
+	fmt.Fprintf(r.w, "Finished game %d (%s vs %s): %v {%s}\n", g.Round, white, black, g.Game.Result, g.Game.Tag("Termination"))
+	if r.pgn != nil {
+		if err := r.pgn.Write(g.Game); err != nil {
+			return false, err
+		}
+	}
+
+	if len(r.players) != 2 {
+		return false, nil
+	}
+	if points, ok := g.Points(0); ok {
+		// Pairs of games are played from the same opening.
+		r.tally.Add((g.Round-1)/2, points)
+	}
+	r.writeScore()
+	if r.sprt != nil {
+		r.decision = r.tally.Decide(*r.sprt)
+		return r.decision != stats.Continue, nil
+	}
+	return false, nil
+}
+
+// writeScore writes the score of a two-player match, from the first player's
+// point of view.
+func (r *reporter) writeScore() {
+	t := r.tally.Trinomial
+	n := t.Games()
+	if n == 0 {
+		return
+	}
+	score := (float64(t.Wins) + float64(t.Draws)/2) / float64(n)
+	fmt.Fprintf(r.w, "Score of %s vs %s: %d - %d - %d  [%.3f] %d/%d\n",
+		r.players[0], r.players[1], t.Wins, t.Losses, t.Draws, score, n, r.games)
+
+	e := t.Elo()
+	fmt.Fprintf(r.w, "Elo difference: %s +/- %s, LOS: %.1f %%, DrawRatio: %.1f %%\n",
+		formatElo(e.Elo), formatElo((e.Upper-e.Lower)/2), 100*t.LOS(), 100*float64(t.Draws)/float64(n))
+
+	if r.sprt != nil {
+		llr := r.sprt.PentanomialLLR(r.tally.Pentanomial)
+		lower, upper := r.sprt.Bounds()
+		fmt.Fprintf(r.w, "SPRT: llr %.3g (%.1f%%), lbound %.3g, ubound %.3g", llr, 100*llr/upper, lower, upper)
+		if d := r.sprt.Decide(llr); d != stats.Continue {
+			fmt.Fprintf(r.w, " - %v", d)
+		}
+		fmt.Fprintln(r.w)
+	}
+}
+
+// writeResults writes the final standings.
+func (r *reporter) writeResults() error {
+	fmt.Fprintln(r.w)
+	res := &match.Results{Players: r.players, Games: r.finished}
+	if err := res.WriteCrosstable(r.w); err != nil {
+		return err
+	}
+	if r.sprt != nil && r.decision != stats.Continue {
+		fmt.Fprintf(r.w, "SPRT: %v\n", r.decision)
+	}
+	return nil
+}
+
+// formatElo formats an Elo difference, which may be infinite.
+func formatElo(elo float64) string {
+	switch {
+	case math.IsInf(elo, 1):
+		return "inf"
+	case math.IsInf(elo, -1):
+		return "-inf"
+	case math.IsNaN(elo):
+		return "nan"
+	case elo == 0:
+		elo = 0 // Not -0.
+	}
+	return fmt.Sprintf("%.1f", elo)
+}
@@ -0,0 +1,127 @@
+// Openings builds a suite of opening positions from a PGN database, for the
+// -openings flag of cmd/match.
+//
+// Usage:
+//
+//	openings [flags] games.pgn ...
+//
+// The flags are:
+//
+//	-plies n
+//		Take the positions after the first n moves of each game.
+//		The default is 8.
+//	-min-games n
+//		Keep the positions that at least n games reached. The default is 1.
+//	-limit n
+//		Keep at most the n most popular positions.
+//	-engine path
+//		Evaluate the positions with the engine at path, and keep those
+//		from -min-eval to -max-eval.
+//	-depth n
+//		Evaluate to depth n. The default is 12.
+//	-min-eval cp, -max-eval cp
+//		The range of evaluations to keep, in centipawns from White's
+//		point of view. The defaults are -50 and 50.
+//	-format epd|pgn
+//		Write the suite as EPD or PGN. The default is EPD.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/clfs/chess/openings"
+	"github.com/clfs/chess/uci"
+)
+
+var (
+	plies      = flag.Int("plies", 8, "take the positions after `n` moves")
+	minGames   = flag.Int("min-games", 1, "keep positions that at least `n` games reached")
+	limit      = flag.Int("limit", 0, "keep at most the `n` most popular positions")
+	enginePath = flag.String("engine", "", "evaluate with the engine at `path`")
+	depth      = flag.Int("depth", 12, "evaluate to depth `n`")
+	minEval    = flag.Int("min-eval", -50, "keep evaluations from `cp` centipawns")
+	maxEval    = flag.Int("max-eval", 50, "keep evaluations up to `cp` centipawns")
+	format     = flag.String("format", "epd", "write the suite as `epd` or pgn")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("openings: ")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: openings [flags] games.pgn ...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format != "epd" && *format != "pgn" {
+		log.Fatalf("invalid format %q", *format)
+	}
+
+	suite, err := extract(flag.Args(), *plies)
+	if err != nil {
+		log.Fatal(err)
+	}
+	suite = openings.Popular(suite, *minGames)
+	if *limit > 0 && len(suite) > *limit {
+		suite = suite[:*limit]
+	}
+
+	if *enginePath != "" {
+		e, err := uci.StartEngine(*enginePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer e.Close()
+		if _, _, _, err := e.UCI(); err != nil {
+			log.Fatal(err)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := openings.Evaluate(ctx, e.Client, uci.Search{Depth: *depth}, suite); err != nil {
+			if errors.Is(err, context.Canceled) {
+				err = errors.New("interrupted")
+			}
+			log.Fatal(err)
+		}
+		suite = openings.InEvalRange(suite, *minEval, *maxEval)
+	}
+
+	if err := write(os.Stdout, suite, *format); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%d positions", len(suite))
+}
+
+// extract extracts the openings of the PGN files at paths, as one database.
+func extract(paths []string, plies int) ([]openings.Opening, error) {
+	var readers []io.Reader
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		// Separate the files, in case one doesn't end with a blank line.
+		readers = append(readers, f, strings.NewReader("\n\n"))
+	}
+	return openings.Extract(io.MultiReader(readers...), plies)
+}
+
+// write writes the suite to w in format.
+func write(w io.Writer, suite []openings.Opening, format string) error {
+	if format == "pgn" {
+		return openings.WritePGN(w, suite)
+	}
+	return openings.WriteEPD(w, suite)
+}
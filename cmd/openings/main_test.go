@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtract_Files(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	// The first file doesn't end with a blank line.
+	for i, games := range []string{"1. e4 e5 *", "1. e4 e5 *\n\n1. d4 d5 *\n"} {
+		path := filepath.Join(dir, string(rune('a'+i))+".pgn")
+		if err := os.WriteFile(path, []byte(games), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	suite, err := extract(paths, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := write(&b, suite, "epd"); err != nil {
+		t.Fatal(err)
+	}
+	want := `rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 c0 "1. e4 e5"; c1 "2 games";
+rnbqkbnr/ppp1pppp/8/3p4/3P4/8/PPP1PPPP/RNBQKBNR w KQkq d6 c0 "1. d4 d5"; c1 "1 game";
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExtract_Missing(t *testing.T) {
+	if _, err := extract([]string{filepath.Join(t.TempDir(), "missing.pgn")}, 2); err == nil {
+		t.Error("want an error")
+	}
+}
@@ -0,0 +1,94 @@
+// Perft counts the leaf nodes in the tree of legal moves from a position, to
+// check the move generator against known counts or another implementation.
+//
+// Usage:
+//
+//	perft [flags] depth
+//
+// The flags are:
+//
+//	-fen fen
+//		Count from this position instead of the starting position.
+//	-divide
+//		Show the count under each legal move.
+//	-workers n
+//		Count with n goroutines. The default is the number of CPUs.
+//	-engine path
+//		Compare the counts with the engine's "go perft" command, as
+//		supported by Stockfish. This implies -divide. Perft exits with
+//		status 1 if the counts differ.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+var (
+	fen        = flag.String("fen", "", "count from `fen` instead of the starting position")
+	divide     = flag.Bool("divide", false, "show the count under each move")
+	workers    = flag.Int("workers", runtime.NumCPU(), "count with `n` goroutines")
+	enginePath = flag.String("engine", "", "compare with the engine at `path`")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("perft: ")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: perft [flags] depth\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	depth, err := strconv.Atoi(flag.Arg(0))
+	if err != nil || depth < 1 {
+		log.Fatalf("invalid depth %q", flag.Arg(0))
+	}
+
+	p := chess.StartingPosition()
+	if *fen != "" {
+		if p, err = chess.ParseFEN(*fen); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var engine *uci.PerftResult
+	if *enginePath != "" {
+		if engine, err = enginePerft(*enginePath, p, depth); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	res := count(p, depth, *divide || engine != nil, *workers)
+	if !report(os.Stdout, res, engine) {
+		os.Exit(1)
+	}
+}
+
+// enginePerft runs "go perft" to depth from p with the engine at path.
+func enginePerft(path string, p *chess.Position, depth int) (*uci.PerftResult, error) {
+	e, err := uci.StartEngine(path)
+	if err != nil {
+		return nil, err
+	}
+	defer e.Close()
+
+	if _, _, _, err := e.UCI(); err != nil {
+		return nil, err
+	}
+	if err := e.Position(uci.PositionParams{FEN: p.String()}); err != nil {
+		return nil, err
+	}
+	return e.Perft(context.Background(), depth)
+}
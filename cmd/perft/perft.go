@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// result is a perft count.
+type result struct {
+	moves   map[chess.Move]int // The count under each move, if divided.
+	nodes   int
+	elapsed time.Duration
+}
+
+// count counts the leaf nodes to depth from p with the given number of
+// goroutines, under each move if divide is true.
+func count(p *chess.Position, depth int, divide bool, workers int) result {
+	start := time.Now()
+	var res result
+	if divide {
+		res.moves = chess.ParallelDivide(p, depth, workers)
+		for _, n := range res.moves {
+			res.nodes += n
+		}
+	} else {
+		res.nodes = chess.ParallelPerft(p, depth, workers)
+	}
+	res.elapsed = time.Since(start)
+	return res
+}
+
+// report writes res to w, compared with the engine's counts if engine isn't
+// nil. Moves whose counts differ, including moves only one side generates,
+// are marked with "*". It reports whether the counts agree.
+func report(w io.Writer, res result, engine *uci.PerftResult) (ok bool) {
+	ok = true
+
+	var moves []chess.Move
+	for m := range res.moves {
+		moves = append(moves, m)
+	}
+	if engine != nil {
+		for m := range engine.Moves {
+			if _, found := res.moves[m]; !found {
+				moves = append(moves, m)
+			}
+		}
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		return moves[i].String() < moves[j].String()
+	})
+
+	for _, m := range moves {
+		ours, found := res.moves[m]
+		line := fmt.Sprintf("%-6v %12s", m, optional(ours, found))
+		if engine != nil {
+			theirs, theirsFound := engine.Moves[m]
+			line += fmt.Sprintf(" %12s", optional(theirs, theirsFound))
+			if ours != theirs || found != theirsFound {
+				line += " *"
+				ok = false
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+	if len(moves) > 0 {
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "Nodes: %d\n", res.nodes)
+	if engine != nil {
+		fmt.Fprintf(w, "Engine nodes: %d\n", engine.Nodes)
+		if engine.Nodes != res.nodes {
+			ok = false
+		}
+	}
+	fmt.Fprintf(w, "Time: %v", res.elapsed.Round(time.Millisecond))
+	if s := res.elapsed.Seconds(); s > 0 {
+		fmt.Fprintf(w, " (%.0f nodes/s)", float64(res.nodes)/s)
+	}
+	fmt.Fprintln(w)
+	if !ok {
+		fmt.Fprintln(w, "The counts differ.")
+	}
+	return ok
+}
+
+// optional formats n, or "-" if it isn't present.
+func optional(n int, present bool) string {
+	if !present {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
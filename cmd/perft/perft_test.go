@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+func move(s string) chess.Move {
+	m, err := chess.ParseMove(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestCount(t *testing.T) {
+	p := chess.StartingPosition()
+	if got := count(p, 3, false, 2); got.nodes != 8902 || got.moves != nil {
+		t.Errorf("count without divide: got %d nodes, moves %v", got.nodes, got.moves)
+	}
+	got := count(p, 2, true, 2)
+	if got.nodes != 400 || len(got.moves) != 20 || got.moves[move("e2e4")] != 20 {
+		t.Errorf("count with divide: got %d nodes, moves %v", got.nodes, got.moves)
+	}
+}
+
+func TestReport(t *testing.T) {
+	res := result{
+		moves: map[chess.Move]int{move("e2e4"): 20, move("d2d4"): 20, move("g1f3"): 20},
+		nodes: 60,
+	}
+	cases := []struct {
+		name   string
+		res    result
+		engine *uci.PerftResult
+		want   string
+		ok     bool
+	}{
+		{
+			name: "total",
+			res:  result{nodes: 8902},
+			want: "Nodes: 8902\nTime: 0s\n",
+			ok:   true,
+		},
+		{
+			name: "divide",
+			res:  res,
+			want: "d2d4             20\ne2e4             20\ng1f3             20\n\nNodes: 60\nTime: 0s\n",
+			ok:   true,
+		},
+		{
+			name: "engine agrees",
+			res:  res,
+			engine: &uci.PerftResult{
+				Moves: map[chess.Move]int{move("e2e4"): 20, move("d2d4"): 20, move("g1f3"): 20},
+				Nodes: 60,
+			},
+			want: "d2d4             20           20\ne2e4             20           20\ng1f3             20           20\n\nNodes: 60\nEngine nodes: 60\nTime: 0s\n",
+			ok:   true,
+		},
+		{
+			name: "engine differs",
+			res:  res,
+			engine: &uci.PerftResult{
+				Moves: map[chess.Move]int{move("e2e4"): 21, move("d2d4"): 20, move("e2e5"): 20},
+				Nodes: 61,
+			},
+			want: "d2d4             20           20\ne2e4             20           21 *\ne2e5              -           20 *\ng1f3             20            - *\n\n" +
+				"Nodes: 60\nEngine nodes: 61\nTime: 0s\nThe counts differ.\n",
+			ok: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if ok := report(&b, tc.res, tc.engine); ok != tc.ok {
+				t.Errorf("want ok %t, got %t", tc.ok, ok)
+			}
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Posindex builds an index of the positions of PGN databases, and finds the
+// games that reached a position.
+//
+// Usage:
+//
+//	posindex build index.pidx games.pgn ...
+//	posindex find index.pidx [fen] [move ...]
+//
+// Build indexes the games of the PGN files into the index file. Find prints
+// the games that reached the position after the moves, in long algebraic
+// notation, from the position in FEN, or else from the starting position.
+// Each game is printed with its file, its number in the file from 1, the ply
+// where it reached the position, and its players and event.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/posindex"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: posindex build index.pidx games.pgn ...\n")
+	fmt.Fprintf(os.Stderr, "       posindex find index.pidx [fen] [move ...]\n")
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("posindex: ")
+	if len(os.Args) < 3 {
+		usage()
+	}
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; {
+	case cmd == "build" && len(args) >= 2:
+		err = build(args[0], args[1:])
+	case cmd == "find":
+		err = find(os.Stdout, args[0], args[1:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// build indexes the PGN files at paths into the index file at out.
+func build(out string, paths []string) error {
+	ix := posindex.NewIndexer()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = ix.AddPGN(path, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if _, err := ix.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("indexed %d games", ix.Games())
+	return nil
+}
+
+// find writes the games of the index at path that reached the position
+// described by args to w.
+func find(w io.Writer, path string, args []string) error {
+	pos, err := parsePosition(args)
+	if err != nil {
+		return err
+	}
+	idx, err := posindex.Open(path)
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+	hits, err := idx.Find(pos)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, h := range hits {
+		g := h.Game
+		fmt.Fprintf(bw, "%s #%d ply %d: %s - %s, %s\n", g.File, g.N+1, h.Ply, orUnknown(g.White), orUnknown(g.Black), orUnknown(g.Event))
+	}
+	return bw.Flush()
+}
+
+// parsePosition returns the position after the moves of args, from the FEN at
+// their start, if any, or else from the starting position.
+func parsePosition(args []string) (*chess.Position, error) {
+	pos := chess.StartingPosition()
+	if len(args) > 0 && strings.Contains(args[0], "/") {
+		var err error
+		if pos, err = chess.ParseFEN(args[0]); err != nil {
+			return nil, err
+		}
+		args = args[1:]
+	}
+	for _, s := range args {
+		m, err := chess.ParseMove(s)
+		if err != nil {
+			return nil, err
+		}
+		if !pos.IsLegal(m) {
+			return nil, errors.New("illegal move " + s)
+		}
+		pos.Apply(m)
+	}
+	return pos, nil
+}
+
+// orUnknown returns s, or "?" if it is empty.
+func orUnknown(s string) string {
+	if s == "" {
+		return "?"
+	}
+	return s
+}
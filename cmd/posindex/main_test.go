@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildFind(t *testing.T) {
+	dir := t.TempDir()
+	pgnPath := filepath.Join(dir, "games.pgn")
+	games := `[Event "Open"]
+[White "A"]
+[Black "B"]
+
+1. e4 e5 2. Nf3 Nc6 *
+
+[White "C"]
+
+1. Nf3 Nc6 2. e4 e5 *
+`
+	if err := os.WriteFile(pgnPath, []byte(games), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idxPath := filepath.Join(dir, "games.pidx")
+	if err := build(idxPath, []string{pgnPath}); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := find(&b, idxPath, []string{"e2e4", "e7e5", "g1f3", "b8c6"}); err != nil {
+		t.Fatal(err)
+	}
+	want := pgnPath + " #1 ply 4: A - B, Open\n" + pgnPath + " #2 ply 4: C - ?, ?\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	b.Reset()
+	fen := "rnbqkbnr/pppppppp/8/8/8/5N2/PPPPPPPP/RNBQKB1R b KQkq - 1 1"
+	if err := find(&b, idxPath, []string{fen}); err != nil {
+		t.Fatal(err)
+	}
+	if want := pgnPath + " #2 ply 1: C - ?, ?\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
+
+func TestParsePosition_Errors(t *testing.T) {
+	for _, args := range [][]string{{"8/8 w"}, {"e2e5"}, {"zz"}} {
+		if _, err := parsePosition(args); err == nil {
+			t.Errorf("%q: want an error", args)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// goKeywords are the parameters of the "go" command.
+var goKeywords = []string{
+	"searchmoves", "ponder", "wtime", "btime", "winc", "binc", "movestogo",
+	"depth", "nodes", "mate", "movetime", "infinite",
+}
+
+// complete implements completer. It completes command names, option names and
+// values in "setoption", the parts of "position" including legal moves, and
+// the parameters of "go".
+func (r *repl) complete(line string) (start int, completions []string) {
+	start = strings.LastIndex(line, " ") + 1
+	word := line[start:]
+	fields := strings.Fields(line[:start])
+	if len(fields) == 0 {
+		return start, matching(word, commandNames())
+	}
+
+	switch fields[0] {
+	case "setoption":
+		return r.completeSetOption(line)
+	case "position":
+		switch {
+		case len(fields) == 1:
+			return start, matching(word, []string{"startpos", "fen"})
+		case contains(fields, "moves"):
+			params, err := uci.ParsePosition(line[:start])
+			if err != nil {
+				return start, nil
+			}
+			pos, err := startPosition(params)
+			if err != nil {
+				return start, nil
+			}
+			return start, matching(word, moveNames(pos))
+		case fields[1] == "startpos" || len(fields) >= 8:
+			return start, matching(word, []string{"moves"})
+		}
+	case "go":
+		candidates := goKeywords
+		if contains(fields, "searchmoves") {
+			candidates = append(moveNames(r.position()), candidates...)
+		}
+		return start, matching(word, candidates)
+	}
+	return start, nil
+}
+
+// completeSetOption completes a "setoption" command.
+func (r *repl) completeSetOption(line string) (start int, completions []string) {
+	nameAt := strings.Index(line, " name ")
+	if nameAt < 0 {
+		start = strings.LastIndex(line, " ") + 1
+		return start, matching(line[start:], []string{"name"})
+	}
+	nameAt += len(" name ")
+
+	valueAt := strings.Index(line[nameAt:], " value ")
+	if valueAt < 0 {
+		name := line[nameAt:]
+		var names []string
+		for _, o := range r.c.Options() {
+			names = append(names, o.OptionName())
+		}
+		if completions := matching(name, names); len(completions) > 0 {
+			return nameAt, completions
+		}
+		if strings.HasSuffix(name, " ") && contains(names, strings.TrimSpace(name)) {
+			return len(line), []string{"value"}
+		}
+		return nameAt, nil
+	}
+
+	name := line[nameAt : nameAt+valueAt]
+	start = nameAt + valueAt + len(" value ")
+	for _, o := range r.c.Options() {
+		if !strings.EqualFold(o.OptionName(), name) {
+			continue
+		}
+		switch o := o.(type) {
+		case uci.CheckOption:
+			return start, matching(line[start:], []string{"false", "true"})
+		case uci.ComboOption:
+			return start, matching(line[start:], o.Vars)
+		}
+	}
+	return start, nil
+}
+
+// commandNames returns the commands the prompt understands.
+func commandNames() []string {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	return names
+}
+
+// moveNames returns the legal moves in p in UCI notation.
+func moveNames(p *chess.Position) []string {
+	var names []string
+	for _, m := range p.LegalMoves() {
+		names = append(names, m.String())
+	}
+	return names
+}
+
+// matching returns the candidates that start with prefix, ignoring case, in
+// sorted order.
+func matching(prefix string, candidates []string) []string {
+	var res []string
+	for _, c := range candidates {
+		if len(c) >= len(prefix) && strings.EqualFold(c[:len(prefix)], prefix) {
+			res = append(res, c)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func contains(ss []string, s string) bool {
+	for _, t := range ss {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/clfs/chess/uci"
+	"github.com/clfs/chess/uci/ucitest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestREPL_Complete(t *testing.T) {
+	e := ucitest.New(t, ucitest.Script{
+		Options: []uci.Option{
+			uci.SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 1024},
+			uci.CheckOption{Name: "Ponder"},
+			uci.ComboOption{Name: "Style", Default: "Normal", Vars: []string{"Solid", "Normal", "Risky"}},
+			uci.SpinOption{Name: "Skill Level", Default: 20, Max: 20},
+		},
+	})
+	c := e.Client()
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	r := newREPL(c)
+
+	cases := []struct {
+		line  string
+		start int
+		want  []string
+	}{
+		{"", 0, []string{"d", "go", "help", "isready", "options", "ponderhit", "position", "quit", "setoption", "stop", "ucinewgame"}},
+		{"po", 0, []string{"ponderhit", "position"}},
+		{"setoption ", 10, []string{"name"}},
+		{"setoption name ", 15, []string{"Hash", "Ponder", "Skill Level", "Style"}},
+		{"setoption name s", 15, []string{"Skill Level", "Style"}},
+		{"setoption name Skill L", 15, []string{"Skill Level"}},
+		{"setoption name Hash ", 20, []string{"value"}},
+		{"setoption name Style value ", 27, []string{"Normal", "Risky", "Solid"}},
+		{"setoption name ponder value t", 28, []string{"true"}},
+		{"setoption name Hash value ", 26, nil},
+		{"position ", 9, []string{"fen", "startpos"}},
+		{"position startpos ", 18, []string{"moves"}},
+		{"position startpos moves e2e4 g", 29, []string{"g7g5", "g7g6", "g8f6", "g8h6"}},
+		{"position startpos moves e2e5 ", 29, nil},
+		{"position fen 8/8/8/8/8/8/8/K1k5 w - - 0 1 ", 42, []string{"moves"}},
+		{"go d", 3, []string{"depth"}},
+		{"go searchmoves e2e", 15, []string{"e2e3", "e2e4"}},
+		{"bench ", 6, nil},
+	}
+	for _, tc := range cases {
+		start, got := r.complete(tc.line)
+		if start != tc.start {
+			t.Errorf("complete(%q): want start %d, got %d", tc.line, tc.start, start)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("complete(%q) mismatch (-want +got):\n%s", tc.line, diff)
+		}
+	}
+}
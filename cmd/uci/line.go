@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Control keys understood by the line editor.
+const (
+	keyCtrlD     = 4
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// completer returns the completions for line. The completions replace
+// line[start:].
+type completer func(line string) (start int, completions []string)
+
+// lineReader reads commands from the user.
+//
+// In edit mode, the terminal has been put in raw mode and lineReader echoes
+// input itself, completes words on Tab and recalls history with the arrow
+// keys. Otherwise, it reads plain lines, which is what happens when the input
+// isn't a terminal.
+type lineReader struct {
+	in       *bufio.Reader
+	edit     bool
+	complete completer
+
+	mu      sync.Mutex // Guards the fields below, and serializes writes to out.
+	out     io.Writer
+	reading bool // The prompt is showing.
+	prompt  string
+	buf     []rune
+	history []string
+}
+
+func newLineReader(in io.Reader, out io.Writer, edit bool, complete completer) *lineReader {
+	return &lineReader{in: bufio.NewReader(in), out: out, edit: edit, complete: complete}
+}
+
+// Printf prints a message above the prompt, if it is showing, and draws the
+// prompt again after it. A trailing newline is added if needed.
+func (l *lineReader) Printf(format string, a ...any) {
+	s := fmt.Sprintf(format, a...)
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.edit && l.reading {
+		fmt.Fprint(l.out, "\r\033[K", s)
+		l.redraw()
+		return
+	}
+	fmt.Fprint(l.out, s)
+}
+
+// redraw draws the prompt and the current input.
+func (l *lineReader) redraw() {
+	fmt.Fprintf(l.out, "\r\033[K%s%s", l.prompt, string(l.buf))
+}
+
+// ReadLine shows prompt and reads a line. It returns io.EOF at the end of the
+// input, or when the user presses Ctrl-D on an empty line.
+func (l *lineReader) ReadLine(prompt string) (string, error) {
+	if !l.edit {
+		l.mu.Lock()
+		fmt.Fprint(l.out, prompt)
+		l.mu.Unlock()
+		line, err := l.in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	l.mu.Lock()
+	l.prompt, l.buf, l.reading = prompt, nil, true
+	l.redraw()
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		l.reading = false
+		l.mu.Unlock()
+	}()
+
+	hist := len(l.history) // Index of the history entry shown, if any.
+	for {
+		r, _, err := l.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		l.mu.Lock()
+		switch r {
+		case '\r', '\n':
+			line := string(l.buf)
+			fmt.Fprint(l.out, "\n")
+			if strings.TrimSpace(line) != "" {
+				l.history = append(l.history, line)
+			}
+			l.mu.Unlock()
+			return line, nil
+		case keyCtrlD:
+			if len(l.buf) == 0 {
+				fmt.Fprint(l.out, "\n")
+				l.mu.Unlock()
+				return "", io.EOF
+			}
+		case keyBackspace, keyDelete:
+			if len(l.buf) > 0 {
+				l.buf = l.buf[:len(l.buf)-1]
+			}
+		case keyCtrlU:
+			l.buf = nil
+		case keyTab:
+			l.completeLine()
+		case keyEscape:
+			// Arrow keys send "ESC [ A" and so on. Other sequences are
+			// ignored.
+			seq := make([]byte, 2)
+			if _, err := io.ReadFull(l.in, seq); err != nil {
+				l.mu.Unlock()
+				return "", err
+			}
+			switch {
+			case seq[1] == 'A' && hist > 0:
+				hist--
+				l.buf = []rune(l.history[hist])
+			case seq[1] == 'B' && hist < len(l.history):
+				hist++
+				l.buf = nil
+				if hist < len(l.history) {
+					l.buf = []rune(l.history[hist])
+				}
+			}
+		default:
+			if r >= ' ' {
+				l.buf = append(l.buf, r)
+			}
+		}
+		l.redraw()
+		l.mu.Unlock()
+	}
+}
+
+// completeLine completes the input as far as it is unambiguous. If it can't
+// be completed any further, the choices are listed instead.
+func (l *lineReader) completeLine() {
+	if l.complete == nil {
+		return
+	}
+	line := string(l.buf)
+	start, completions := l.complete(line)
+	switch len(completions) {
+	case 0:
+		return
+	case 1:
+		l.buf = []rune(line[:start] + completions[0] + " ")
+		return
+	}
+
+	prefix := commonPrefix(completions)
+	if len(prefix) > len(line)-start {
+		l.buf = []rune(line[:start] + prefix)
+		return
+	}
+	fmt.Fprint(l.out, "\r\033[K", strings.Join(completions, "  "), "\n")
+}
+
+// commonPrefix returns the longest common prefix of ss.
+func commonPrefix(ss []string) string {
+	prefix := ss[0]
+	for _, s := range ss[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
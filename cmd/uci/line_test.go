@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLineReader_Edit(t *testing.T) {
+	complete := func(line string) (int, []string) {
+		start := strings.LastIndex(line, " ") + 1
+		return start, matching(line[start:], []string{"depth", "movetime", "movestogo"})
+	}
+	cases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"plain", "go depth 5\r", []string{"go depth 5"}},
+		{"backspace", "go deptx\x7fh 5\r", []string{"go depth 5"}},
+		{"kill line", "stop\x15go\r", []string{"go"}},
+		{"complete", "go d\t5\r", []string{"go depth 5"}},
+		{"common prefix", "go m\t\x74ime 5\r", []string{"go movetime 5"}},
+		{"no completion", "go x\t\r", []string{"go x"}},
+		{"history", "isready\rgo\r\x1b[A\x1b[A\r", []string{"isready", "go", "isready"}},
+		{"history down", "isready\r\x1b[A\x1b[B\r", []string{"isready", ""}},
+		{"ctrl-d", "go\r\x04", []string{"go"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			l := newLineReader(strings.NewReader(tc.input), &out, true, complete)
+			var got []string
+			for {
+				line, err := l.ReadLine("> ")
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, line)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLineReader_Printf(t *testing.T) {
+	var out bytes.Buffer
+	l := newLineReader(strings.NewReader(""), &out, false, nil)
+	l.Printf("a")
+	l.Printf("b\n")
+	if got := out.String(); got != "a\nb\n" {
+		t.Errorf("want %q, got %q", "a\nb\n", got)
+	}
+	if _, err := l.ReadLine("> "); err != io.EOF {
+		t.Errorf("want io.EOF, got %v", err)
+	}
+}
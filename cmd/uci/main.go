@@ -0,0 +1,130 @@
+// Uci is an interactive prompt for driving UCI chess engines.
+//
+// It starts an engine, performs the UCI handshake and reads commands. The
+// standard commands are checked before they are sent: options must exist,
+// positions must be valid, and so on. Search progress is printed with the
+// principal variation in SAN. Other commands are sent to the engine as is, and
+// any lines the engine sends that aren't part of the protocol are printed.
+// On a terminal, Tab completes commands, options, moves and search
+// parameters, and Ctrl-C stops the running search.
+//
+// Usage:
+//
+//	uci [flags] engine [args...]
+//
+// The flags are:
+//
+//	-log file
+//		Append the lines sent to and received from the engine to file, as a
+//		transcript that uci.ReadTrace can read back.
+//	-o name=value
+//		Set an option after the handshake. It may be repeated.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/clfs/chess/uci"
+)
+
+// optionFlags collects -o flags.
+type optionFlags []string
+
+func (o *optionFlags) String() string { return strings.Join(*o, ",") }
+
+func (o *optionFlags) Set(s string) error {
+	if !strings.Contains(s, "=") {
+		return fmt.Errorf("want name=value, got %q", s)
+	}
+	*o = append(*o, s)
+	return nil
+}
+
+var (
+	logPath = flag.String("log", "", "append the session to `file`")
+	options optionFlags
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("uci: ")
+	flag.Var(&options, "o", "set option `name=value` (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: uci [flags] engine [args...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), flag.Args()[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(path string, args []string) error {
+	// The log is closed after the engine, so that it records "quit".
+	var logFile *os.File
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		logFile = f
+	}
+
+	e, err := uci.EngineConfig{Path: path, Args: args, Stderr: os.Stderr}.Start()
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+	if logFile != nil {
+		e.TraceTo(logFile)
+	}
+
+	r := newREPL(e.Client)
+	edit := false
+	if restore, err := makeRaw(int(os.Stdin.Fd())); err == nil {
+		defer restore()
+		edit = true
+	}
+	r.out = newLineReader(os.Stdin, os.Stdout, edit, r.complete)
+	// "info string" lines are shown with search results.
+	e.OnLog(func(l uci.LogLine) {
+		if l.Unknown {
+			r.out.Printf("%s", l.Text)
+		}
+	})
+
+	name, author, opts, err := e.UCI()
+	if err != nil {
+		return err
+	}
+	r.out.Printf("%s by %s, %d options. Type help for help.", name, author, len(opts))
+	for _, o := range options {
+		name, value, _ := strings.Cut(o, "=")
+		if err := e.SetOption(name, value); err != nil {
+			return err
+		}
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+	go func() {
+		for range sig {
+			if !r.interrupt() {
+				r.out.Printf("Type quit to quit.")
+			}
+		}
+	}()
+
+	return r.run()
+}
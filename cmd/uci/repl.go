@@ -0,0 +1,327 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+const helpText = `Commands:
+  setoption name <name> [value <value>]   Set an option.
+  position startpos|fen <fen> [moves ...] Set up a position.
+  go [depth <n>] [movetime <ms>] ...      Start a search.
+  stop, ponderhit, ucinewgame, isready    Send the command to the engine.
+  options                                 List the engine's options.
+  d                                       Show the board.
+  help                                    Show this help.
+  quit                                    Quit.
+Anything else is sent to the engine as is. Press Tab to complete.`
+
+// A command is a command understood by the prompt.
+type command func(r *repl, line string) error
+
+var commands = map[string]command{
+	"help": func(r *repl, line string) error {
+		r.out.Printf("%s", helpText)
+		return nil
+	},
+	"options": func(r *repl, line string) error {
+		for _, o := range r.c.Options() {
+			text, err := o.MarshalText()
+			if err != nil {
+				return err
+			}
+			r.out.Printf("%s", strings.TrimPrefix(string(text), "option name "))
+		}
+		return nil
+	},
+	"setoption": func(r *repl, line string) error {
+		name, value, err := uci.ParseSetOption(line)
+		if err != nil {
+			return err
+		}
+		return r.c.SetOption(name, value)
+	},
+	"position": (*repl).setPosition,
+	"go": func(r *repl, line string) error {
+		s, err := uci.ParseSearch(line)
+		if err != nil {
+			return err
+		}
+		return r.startSearch(s)
+	},
+	"stop": func(r *repl, line string) error {
+		return r.c.Stop()
+	},
+	"ponderhit": func(r *repl, line string) error {
+		return r.c.PonderHit()
+	},
+	"ucinewgame": func(r *repl, line string) error {
+		return r.c.UCINewGame()
+	},
+	"isready": func(r *repl, line string) error {
+		if err := r.c.IsReady(); err != nil {
+			return err
+		}
+		r.out.Printf("readyok")
+		return nil
+	},
+	"d": func(r *repl, line string) error {
+		p := r.position()
+		r.out.Printf("%s\n%s", p.Render(chess.RenderOptions{Coordinates: true}), p)
+		return nil
+	},
+	"quit": nil, // Handled by run.
+}
+
+// repl is an interactive session with an engine.
+type repl struct {
+	c   *uci.Client
+	out *lineReader
+
+	mu     sync.Mutex
+	pos    *chess.Position // The position sent to the engine, for SAN and completion.
+	search chan struct{}   // Closed when the running search finishes, or nil.
+}
+
+func newREPL(c *uci.Client) *repl {
+	return &repl{c: c, pos: chess.StartingPosition()}
+}
+
+// run reads and runs commands until the input ends or the user quits. A
+// running search is stopped before returning.
+func (r *repl) run() error {
+	defer func() {
+		if r.interrupt() {
+			r.wait()
+		}
+	}()
+	for {
+		line, err := r.out.ReadLine("uci> ")
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		cmd, ok := commands[fields[0]]
+		if !ok {
+			cmd = func(r *repl, line string) error { return r.c.SendRaw(line) }
+		}
+		if err := cmd(r, line); err != nil {
+			r.out.Printf("error: %v", err)
+		}
+	}
+}
+
+// position returns a copy of the current position.
+func (r *repl) position() *chess.Position {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pos.Clone()
+}
+
+// setPosition handles a "position" command. Unlike the engine, it checks that
+// the moves are legal.
+func (r *repl) setPosition(line string) error {
+	params, err := uci.ParsePosition(line)
+	if err != nil {
+		return err
+	}
+	pos, err := startPosition(params)
+	if err != nil {
+		return err
+	}
+	if err := r.c.Position(params); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pos = pos
+	return nil
+}
+
+// startPosition returns the position described by params.
+func startPosition(params uci.PositionParams) (*chess.Position, error) {
+	pos := chess.StartingPosition()
+	if !params.StartPos {
+		var err error
+		if pos, err = chess.ParseFEN(params.FEN); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range params.Moves {
+		if !pos.IsLegal(m) {
+			return nil, fmt.Errorf("illegal move %v", m)
+		}
+		pos.Apply(m)
+	}
+	return pos, nil
+}
+
+// startSearch starts a search and prints its progress as it arrives. When the
+// input isn't a terminal, finite searches run to completion before the next
+// command is read, so that scripts see the results in order.
+func (r *repl) startSearch(s uci.Search) error {
+	r.mu.Lock()
+	if r.search != nil {
+		r.mu.Unlock()
+		return errors.New("a search is already running; type stop to end it")
+	}
+	infos, bms, err := r.c.Go(s)
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	pos := r.pos.Clone()
+	done := make(chan struct{})
+	r.search = done
+	r.mu.Unlock()
+
+	go func() {
+		defer func() {
+			r.mu.Lock()
+			r.search = nil
+			r.mu.Unlock()
+			close(done)
+		}()
+		for info := range infos {
+			if text := formatInfo(pos, info); text != "" {
+				r.out.Printf("%s", text)
+			}
+		}
+		if bm, ok := <-bms; ok {
+			r.out.Printf("%s", formatBestMove(pos, bm))
+		} else {
+			r.out.Printf("error: the engine stopped responding")
+		}
+	}()
+
+	if !r.out.edit && !s.Infinite && !s.Ponder {
+		<-done
+	}
+	return nil
+}
+
+// interrupt stops the running search, if any, and reports whether there was
+// one.
+func (r *repl) interrupt() bool {
+	r.mu.Lock()
+	running := r.search != nil
+	r.mu.Unlock()
+	if running {
+		r.c.Stop()
+	}
+	return running
+}
+
+// wait waits for the running search, if any, to finish.
+func (r *repl) wait() {
+	r.mu.Lock()
+	done := r.search
+	r.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// formatInfo formats search information for people, with the principal
+// variation in SAN. Information without a principal variation or a string,
+// such as the move being searched, isn't worth showing and is empty.
+func formatInfo(pos *chess.Position, info uci.Info) string {
+	if len(info.PV) == 0 {
+		if info.String != "" {
+			return "info: " + info.String
+		}
+		return ""
+	}
+
+	var b strings.Builder
+	if info.MultiPV > 0 {
+		fmt.Fprintf(&b, "%d. ", info.MultiPV)
+	}
+	fmt.Fprintf(&b, "depth %d", info.Depth)
+	if info.SelDepth > 0 {
+		fmt.Fprintf(&b, "/%d", info.SelDepth)
+	}
+	fmt.Fprintf(&b, "  score %s", info.Score)
+	switch {
+	case info.Score.LowerBound:
+		b.WriteString(" (lower bound)")
+	case info.Score.UpperBound:
+		b.WriteString(" (upper bound)")
+	}
+	if info.Nodes > 0 {
+		fmt.Fprintf(&b, "  nodes %s", count(info.Nodes))
+	}
+	if info.NPS > 0 {
+		fmt.Fprintf(&b, "  nps %s", count(info.NPS))
+	}
+	if info.Time > 0 {
+		fmt.Fprintf(&b, "  time %.2fs", info.Time.Seconds())
+	}
+	if info.TBHits > 0 {
+		fmt.Fprintf(&b, "  tbhits %s", count(info.TBHits))
+	}
+	fmt.Fprintf(&b, "  pv %s", sanLine(pos, info.PV))
+	return b.String()
+}
+
+// formatBestMove formats a best move in both UCI notation and SAN.
+func formatBestMove(pos *chess.Position, bm uci.BestMove) string {
+	if bm.Move == (chess.Move{}) {
+		return "bestmove (none)"
+	}
+	s := fmt.Sprintf("bestmove %v (%s)", bm.Move, sanLine(pos, []chess.Move{bm.Move}))
+	if bm.Ponder != (chess.Move{}) {
+		line := sanLine(pos, []chess.Move{bm.Move, bm.Ponder})
+		s += fmt.Sprintf(" ponder %v (%s)", bm.Ponder, line[strings.LastIndex(line, " ")+1:])
+	}
+	return s
+}
+
+// sanLine formats moves played from pos in SAN. If a move is illegal, it and
+// the moves after it are written in UCI notation instead.
+func sanLine(pos *chess.Position, moves []chess.Move) string {
+	p := pos.Clone()
+	var b strings.Builder
+	for i, m := range moves {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if p == nil || !p.IsLegal(m) {
+			p = nil
+			b.WriteString(m.String())
+			continue
+		}
+		b.WriteString(chess.FormatSAN(p, m))
+		p.Apply(m)
+	}
+	return b.String()
+}
+
+// count formats a count compactly, such as "1234", "56.7k" or "8.9M".
+func count(n int64) string {
+	switch {
+	case n < 10000:
+		return fmt.Sprint(n)
+	case n < 1000000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	case n < 1000000000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	}
+	return fmt.Sprintf("%.1fG", float64(n)/1e9)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/uci"
+)
+
+// runScript runs the commands in script against the reference engine and
+// returns the output.
+func runScript(t *testing.T, script string) string {
+	t.Helper()
+	c, closer, err := refengine.New().Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closer.Close() })
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	r := newREPL(c)
+	r.out = newLineReader(strings.NewReader(script), &out, false, r.complete)
+	if err := r.run(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestREPL(t *testing.T) {
+	out := runScript(t, `isready
+options
+setoption name Depth value 2
+position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1
+go
+d
+position startpos moves e2e5
+setoption name Nope value 1
+go wtime x
+quit
+isready
+`)
+	for _, want := range []string{
+		"uci> readyok\n",
+		"Depth type spin default 3 min 1 max 32\n",
+		"depth 1  score #1  nodes 33  pv Ra8#\n",
+		"bestmove a1a8 (Ra8#)\n",
+		"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1\n",
+		"error: illegal move e2e5\n",
+		"error: ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "readyok"); n != 1 {
+		t.Errorf("commands after quit were run:\n%s", out)
+	}
+	if n := strings.Count(out, "error: "); n != 3 {
+		t.Errorf("want 3 errors, got %d:\n%s", n, out)
+	}
+}
+
+func TestREPL_Infinite(t *testing.T) {
+	// An infinite search keeps running until the input ends.
+	start := time.Now()
+	out := runScript(t, "go infinite\n")
+	if !strings.Contains(out, "bestmove ") {
+		t.Errorf("no best move:\n%s", out)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("took %v", d)
+	}
+}
+
+func TestFormatInfo(t *testing.T) {
+	pos := chess.StartingPosition()
+	cases := []struct {
+		info uci.Info
+		want string
+	}{
+		{
+			uci.Info{Depth: 12, SelDepth: 18, Score: uci.Score{CP: 35}, Nodes: 1234567, NPS: 850000, Time: 1450 * time.Millisecond, PV: moves("e2e4", "e7e5", "g1f3")},
+			"depth 12/18  score +0.35  nodes 1.2M  nps 850.0k  time 1.45s  pv e4 e5 Nf3",
+		},
+		{
+			uci.Info{Depth: 3, MultiPV: 2, Score: uci.Score{CP: -10, UpperBound: true}, PV: moves("d2d4", "e2e4")},
+			"2. depth 3  score -0.10 (upper bound)  pv d4 e2e4",
+		},
+		{uci.Info{String: "hello"}, "info: hello"},
+		{uci.Info{Depth: 5, CurrMove: chess.Move{From: chess.E2, To: chess.E4}}, ""},
+	}
+	for _, tc := range cases {
+		if got := formatInfo(pos, tc.info); got != tc.want {
+			t.Errorf("formatInfo(%+v):\nwant %q\ngot  %q", tc.info, tc.want, got)
+		}
+	}
+}
+
+func TestFormatBestMove(t *testing.T) {
+	pos := chess.StartingPosition()
+	cases := []struct {
+		bm   uci.BestMove
+		want string
+	}{
+		{uci.BestMove{}, "bestmove (none)"},
+		{uci.BestMove{Move: moves("g1f3")[0]}, "bestmove g1f3 (Nf3)"},
+		{uci.BestMove{Move: moves("e2e4")[0], Ponder: moves("c7c5")[0]}, "bestmove e2e4 (e4) ponder c7c5 (c5)"},
+	}
+	for _, tc := range cases {
+		if got := formatBestMove(pos, tc.bm); got != tc.want {
+			t.Errorf("formatBestMove(%v): want %q, got %q", tc.bm, tc.want, got)
+		}
+	}
+}
+
+func moves(s ...string) []chess.Move {
+	var res []chess.Move
+	for _, m := range s {
+		mv, err := chess.ParseMove(m)
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, mv)
+	}
+	return res
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// makeRaw isn't supported on this system, so the prompt falls back to plain
+// line input without completion.
+func makeRaw(fd int) (restore func(), err error) {
+	return nil, errors.New("line editing not supported")
+}
@@ -0,0 +1,229 @@
+// Package consensus analyzes positions with several engines at once and
+// merges their results: which best move most engines agree on, how far apart
+// their evaluations are, and whether the position is disputed. Correspondence
+// players use it to find positions that need a closer look, and fair play
+// reviews to compare a player's moves with more than one engine.
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// Engine is an engine to consult.
+type Engine struct {
+	Name string
+
+	// Client is the engine. It must have completed the "uci" handshake, and
+	// its options must already be set.
+	Client *uci.Client
+}
+
+// Analyzer analyzes positions with several engines.
+type Analyzer struct {
+	// Search limits the search of each engine, such as to a fixed depth or a
+	// time. It must end on its own.
+	Search uci.Search
+
+	// MaxSpread is the largest difference in centipawns between the
+	// evaluations of the engines for a position not to be disputed. If zero,
+	// it is 50.
+	MaxSpread int
+}
+
+// Result is the result of one engine.
+type Result struct {
+	Engine string
+	Move   chess.Move   // The best move.
+	Score  uci.Score    // The evaluation, from the point of view of the side to move.
+	Depth  int          // The depth reached.
+	PV     []chess.Move // The principal variation.
+
+	// Err is the error of the engine, if it failed. The other fields are
+	// then zero, and the result doesn't count toward the consensus.
+	Err error
+}
+
+// Report merges the results of the engines for a position.
+type Report struct {
+	Results []Result // In the order of the engines.
+
+	// Move is the consensus move: the best move of the most engines, with
+	// ties broken by the better average evaluation, and then by the order of
+	// the engines. It is the zero Move if every engine failed.
+	Move chess.Move
+
+	// Votes is the number of engines whose best move is Move.
+	Votes int
+
+	// Agreement is the fraction of the engines that didn't fail whose best
+	// move is Move, from 0 to 1.
+	Agreement float64
+
+	// Min and Max are the lowest and highest evaluations, in centipawns from
+	// the point of view of the side to move, with mates counted as 10000
+	// centipawns, less the number of moves until mate.
+	Min, Max int
+
+	// Dissenters are the engines whose best move isn't Move.
+	Dissenters []string
+
+	// Disputed reports whether the engines disagree on the best move, or
+	// their evaluations are more than MaxSpread apart.
+	Disputed bool
+}
+
+// Spread returns the difference between the highest and lowest evaluations,
+// in centipawns.
+func (r *Report) Spread() int {
+	return r.Max - r.Min
+}
+
+var (
+	errInfinite = errors.New("consensus: search must end on its own")
+	errNoResult = errors.New("engine stopped without a best move")
+)
+
+// Analyze searches the position with every engine at once, and merges their
+// results. The engines shouldn't share a CPU core, or they slow each other
+// down. A failed engine is reported in its result, and Analyze only fails if
+// every engine does, or if ctx is done, in which case the searches are stopped.
+func (a *Analyzer) Analyze(ctx context.Context, engines []Engine, pos uci.PositionParams) (*Report, error) {
+	if a.Search.Infinite || a.Search.Ponder {
+		return nil, errInfinite
+	}
+	if len(engines) == 0 {
+		return nil, errors.New("consensus: no engines")
+	}
+
+	results := make([]Result, len(engines))
+	var wg sync.WaitGroup
+	for i, e := range engines {
+		wg.Add(1)
+		go func(i int, e Engine) {
+			defer wg.Done()
+			res, err := a.search(ctx, e.Client, pos)
+			if err != nil {
+				res = Result{Err: err}
+			}
+			res.Engine = e.Name
+			results[i] = res
+		}(i, e)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r := merge(results, a.MaxSpread)
+	if r.Move == (chess.Move{}) {
+		return nil, fmt.Errorf("consensus: every engine failed: %s: %w", results[0].Engine, results[0].Err)
+	}
+	return r, nil
+}
+
+// search searches pos with c.
+func (a *Analyzer) search(ctx context.Context, c *uci.Client, pos uci.PositionParams) (Result, error) {
+	if err := c.Position(pos); err != nil {
+		return Result{}, err
+	}
+	infoCh, bestCh, err := c.GoContext(ctx, a.Search)
+	if err != nil {
+		return Result{}, err
+	}
+	var res Result
+	for info := range infoCh {
+		if info.MultiPV > 1 {
+			continue
+		}
+		if len(info.PV) > 0 {
+			res.Score, res.PV = info.Score, info.PV
+		}
+		if info.Depth > res.Depth {
+			res.Depth = info.Depth
+		}
+	}
+	bm, ok := <-bestCh
+	if !ok {
+		return Result{}, errNoResult
+	}
+	res.Move = bm.Move
+	return res, nil
+}
+
+// mateCentipawns is the value of a mate in centipawns.
+const mateCentipawns = 10000
+
+// centipawns returns s in centipawns, with mates counted as mateCentipawns less
+// the number of moves until mate, so that nearer mates count for more.
+func centipawns(s uci.Score) int {
+	switch {
+	case s.Mate.Found && s.Mate.MovesUntil > 0:
+		return mateCentipawns - s.Mate.MovesUntil
+	case s.Mate.Found:
+		return -mateCentipawns - s.Mate.MovesUntil
+	}
+	return s.CP
+}
+
+// merge returns the report of the results.
+func merge(results []Result, maxSpread int) *Report {
+	if maxSpread == 0 {
+		maxSpread = 50
+	}
+	r := &Report{Results: results}
+
+	type tally struct {
+		votes, total int // The number of engines, and the sum of their evaluations.
+	}
+	tallies := map[chess.Move]*tally{}
+	var order []chess.Move // The moves, in the order of the engines.
+	ok := 0
+	for _, res := range results {
+		if res.Err != nil {
+			continue
+		}
+		cp := centipawns(res.Score)
+		if ok == 0 || cp < r.Min {
+			r.Min = cp
+		}
+		if ok == 0 || cp > r.Max {
+			r.Max = cp
+		}
+		ok++
+		t := tallies[res.Move]
+		if t == nil {
+			t = &tally{}
+			tallies[res.Move] = t
+			order = append(order, res.Move)
+		}
+		t.votes++
+		t.total += cp
+	}
+	if ok == 0 {
+		return r
+	}
+
+	var best *tally
+	for _, m := range order {
+		t := tallies[m]
+		// Compare averages without dividing: t.total/t.votes > best.total/best.votes.
+		if best == nil || t.votes > best.votes || t.votes == best.votes && t.total*best.votes > best.total*t.votes {
+			best, r.Move = t, m
+		}
+	}
+	r.Votes = best.votes
+	r.Agreement = float64(best.votes) / float64(ok)
+	for _, res := range results {
+		if res.Err == nil && res.Move != r.Move {
+			r.Dissenters = append(r.Dissenters, res.Engine)
+		}
+	}
+	r.Disputed = len(r.Dissenters) > 0 || r.Spread() > maxSpread
+	return r
+}
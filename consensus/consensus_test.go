@@ -0,0 +1,173 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/clfs/chess/uci/ucitest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func mustMove(t *testing.T, s string) chess.Move {
+	t.Helper()
+	m, err := chess.ParseMove(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// fake returns an engine that answers a search with the move and score.
+func fake(t *testing.T, name, move string, score uci.Score) Engine {
+	t.Helper()
+	m := mustMove(t, move)
+	e := ucitest.New(t, ucitest.Script{Searches: []ucitest.Search{{
+		Info:     []uci.Info{{Depth: 12, Score: score, PV: []chess.Move{m}}},
+		BestMove: uci.BestMove{Move: m},
+	}}})
+	return Engine{Name: name, Client: e.Client()}
+}
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	engines := []Engine{
+		fake(t, "a", "e2e4", uci.Score{CP: 30}),
+		fake(t, "b", "d2d4", uci.Score{CP: 25}),
+		fake(t, "c", "e2e4", uci.Score{CP: 40}),
+	}
+	a := &Analyzer{Search: uci.Search{Depth: 12}}
+	r, err := a.Analyze(context.Background(), engines, uci.PositionParams{StartPos: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e4, d4 := mustMove(t, "e2e4"), mustMove(t, "d2d4")
+	want := &Report{
+		Results: []Result{
+			{Engine: "a", Move: e4, Score: uci.Score{CP: 30}, Depth: 12, PV: []chess.Move{e4}},
+			{Engine: "b", Move: d4, Score: uci.Score{CP: 25}, Depth: 12, PV: []chess.Move{d4}},
+			{Engine: "c", Move: e4, Score: uci.Score{CP: 40}, Depth: 12, PV: []chess.Move{e4}},
+		},
+		Move:       e4,
+		Votes:      2,
+		Agreement:  2.0 / 3,
+		Min:        25,
+		Max:        40,
+		Dissenters: []string{"b"},
+		Disputed:   true,
+	}
+	if diff := cmp.Diff(want, r); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if got := r.Spread(); got != 15 {
+		t.Errorf("Spread: got %d, want 15", got)
+	}
+}
+
+func TestAnalyzer_Analyze_Infinite(t *testing.T) {
+	a := &Analyzer{Search: uci.Search{Infinite: true}}
+	if _, err := a.Analyze(context.Background(), nil, uci.PositionParams{StartPos: true}); !errors.Is(err, errInfinite) {
+		t.Errorf("got %v, want %v", err, errInfinite)
+	}
+}
+
+func TestAnalyzer_Analyze_Canceled(t *testing.T) {
+	e := ucitest.New(t, ucitest.Script{Searches: []ucitest.Search{{WaitStop: true}}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	a := &Analyzer{Search: uci.Search{Depth: 30}}
+	_, err := a.Analyze(ctx, []Engine{{Name: "slow", Client: e.Client()}}, uci.PositionParams{StartPos: true})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func mate(n int) uci.Score {
+	var s uci.Score
+	s.Mate.Found, s.Mate.MovesUntil = true, n
+	return s
+}
+
+func TestMerge(t *testing.T) {
+	e4, d4, c4 := mustMove(t, "e2e4"), mustMove(t, "d2d4"), mustMove(t, "c2c4")
+	failed := errors.New("crashed")
+	tests := []struct {
+		name      string
+		results   []Result
+		maxSpread int
+		want      Report
+	}{
+		{
+			name: "unanimous",
+			results: []Result{
+				{Engine: "a", Move: e4, Score: uci.Score{CP: 20}},
+				{Engine: "b", Move: e4, Score: uci.Score{CP: 60}},
+			},
+			want: Report{Move: e4, Votes: 2, Agreement: 1, Min: 20, Max: 60},
+		},
+		{
+			name: "eval spread",
+			results: []Result{
+				{Engine: "a", Move: e4, Score: uci.Score{CP: 20}},
+				{Engine: "b", Move: e4, Score: uci.Score{CP: 80}},
+			},
+			want: Report{Move: e4, Votes: 2, Agreement: 1, Min: 20, Max: 80, Disputed: true},
+		},
+		{
+			name: "custom spread",
+			results: []Result{
+				{Engine: "a", Move: e4, Score: uci.Score{CP: 20}},
+				{Engine: "b", Move: e4, Score: uci.Score{CP: 80}},
+			},
+			maxSpread: 100,
+			want:      Report{Move: e4, Votes: 2, Agreement: 1, Min: 20, Max: 80},
+		},
+		{
+			name: "tie broken by evaluation",
+			results: []Result{
+				{Engine: "a", Move: d4, Score: uci.Score{CP: 10}},
+				{Engine: "b", Move: c4, Score: uci.Score{CP: 35}},
+			},
+			want: Report{Move: c4, Votes: 1, Agreement: 0.5, Min: 10, Max: 35, Dissenters: []string{"a"}, Disputed: true},
+		},
+		{
+			name: "tie broken by order",
+			results: []Result{
+				{Engine: "a", Move: d4, Score: uci.Score{CP: 10}},
+				{Engine: "b", Move: c4, Score: uci.Score{CP: 10}},
+			},
+			want: Report{Move: d4, Votes: 1, Agreement: 0.5, Min: 10, Max: 10, Dissenters: []string{"b"}, Disputed: true},
+		},
+		{
+			name: "mates",
+			results: []Result{
+				{Engine: "a", Move: e4, Score: mate(3)},
+				{Engine: "b", Move: e4, Score: mate(5)},
+			},
+			want: Report{Move: e4, Votes: 2, Agreement: 1, Min: 9995, Max: 9997},
+		},
+		{
+			name: "failed engine",
+			results: []Result{
+				{Engine: "a", Err: failed},
+				{Engine: "b", Move: e4, Score: uci.Score{CP: -20}},
+			},
+			want: Report{Move: e4, Votes: 1, Agreement: 1, Min: -20, Max: -20},
+		},
+		{
+			name:    "all failed",
+			results: []Result{{Engine: "a", Err: failed}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := merge(tt.results, tt.maxSpread)
+			if diff := cmp.Diff(tt.want, *got, cmpopts.IgnoreFields(Report{}, "Results")); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package diagram draws chess positions as SVG and PNG images, with optional
+// square highlights and arrows.
+//
+// The built-in pieces are simple pixel-art figures, so that the package needs
+// no assets; use a PieceSet to draw others.
+package diagram
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/clfs/chess"
+)
+
+// Options control how a position is drawn. The zero value draws a 360x360
+// board from White's side in the usual brown colors.
+type Options struct {
+	SquareSize  int         // The size of a square, in pixels. Defaults to 45.
+	Light, Dark color.Color // The colors of the squares.
+	Flipped     bool        // Draw the board from Black's side.
+
+	Highlights []Highlight
+	Arrows     []Arrow
+
+	// Pieces draws the pieces. If nil, the built-in pieces are used.
+	Pieces *PieceSet
+}
+
+// Highlight colors a square, such as to show the last move.
+type Highlight struct {
+	Square chess.Square
+	Color  color.Color // Defaults to a translucent yellow.
+}
+
+// Arrow is an arrow between the centers of two squares, such as to show a
+// threat or the best move.
+type Arrow struct {
+	From, To chess.Square
+	Color    color.Color // Defaults to a translucent green.
+}
+
+// Default colors.
+var (
+	DefaultLight     color.Color = color.NRGBA{0xf0, 0xd9, 0xb5, 0xff}
+	DefaultDark      color.Color = color.NRGBA{0xb5, 0x88, 0x63, 0xff}
+	DefaultHighlight color.Color = color.NRGBA{0xcd, 0xd2, 0x6a, 0xaa}
+	DefaultArrow     color.Color = color.NRGBA{0x15, 0x78, 0x1b, 0xaa}
+)
+
+// withDefaults returns o with defaults for the zero fields.
+func (o Options) withDefaults() Options {
+	if o.SquareSize <= 0 {
+		o.SquareSize = 45
+	}
+	if o.Light == nil {
+		o.Light = DefaultLight
+	}
+	if o.Dark == nil {
+		o.Dark = DefaultDark
+	}
+	if o.Pieces == nil {
+		o.Pieces = builtinPieces
+	}
+	return o
+}
+
+// origin returns the top-left corner of sq, in pixels.
+func (o Options) origin(sq chess.Square) (x, y int) {
+	col, row := int(sq.File()), 7-int(sq.Rank())
+	if o.Flipped {
+		col, row = 7-col, 7-row
+	}
+	return col * o.SquareSize, row * o.SquareSize
+}
+
+// squareColor returns the color of the empty square sq.
+func (o Options) squareColor(sq chess.Square) color.Color {
+	if (int(sq.File())+int(sq.Rank()))%2 == 0 {
+		return o.Dark
+	}
+	return o.Light
+}
+
+// point is a point in pixels.
+type point struct {
+	x, y float64
+}
+
+// arrowPolygon returns the outline of a, as a shaft and a triangular head.
+func (o Options) arrowPolygon(a Arrow) []point {
+	size := float64(o.SquareSize)
+	center := func(sq chess.Square) point {
+		x, y := o.origin(sq)
+		return point{float64(x) + size/2, float64(y) + size/2}
+	}
+	from, to := center(a.From), center(a.To)
+	dx, dy := to.x-from.x, to.y-from.y
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return nil
+	}
+	// Unit vectors along and across the arrow.
+	ux, uy := dx/length, dy/length
+	nx, ny := -uy, ux
+
+	shaft, head, headLength := size/10, size/4, size*0.45
+	base := math.Max(0, length-headLength)
+	at := func(along, across float64) point {
+		return point{from.x + ux*along + nx*across, from.y + uy*along + ny*across}
+	}
+	return []point{
+		at(0, -shaft), at(base, -shaft), at(base, -head),
+		at(length, 0),
+		at(base, head), at(base, shaft), at(0, shaft),
+	}
+}
+
+// arrowColor returns the color of a.
+func arrowColor(a Arrow) color.Color {
+	if a.Color == nil {
+		return DefaultArrow
+	}
+	return a.Color
+}
+
+// highlightColor returns the color of h.
+func highlightColor(h Highlight) color.Color {
+	if h.Color == nil {
+		return DefaultHighlight
+	}
+	return h.Color
+}
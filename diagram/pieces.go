@@ -0,0 +1,190 @@
+package diagram
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+// PieceSet holds the images of the pieces.
+type PieceSet struct {
+	// Images are drawn in PNG output, scaled to the size of a square.
+	Images map[chess.Piece]image.Image
+
+	// SVG holds SVG elements for each piece, drawn in a 45x45 box like many
+	// freely licensed piece sets.
+	SVG map[chess.Piece]string
+}
+
+// pieceMasks are the built-in pieces, as 16x16 pixel art: "#" is the outline,
+// "o" is the body and "." is transparent.
+var pieceMasks = map[chess.PieceType][16]string{
+	chess.Pawn: {
+		"................",
+		"................",
+		"................",
+		".......##.......",
+		"......#oo#......",
+		".....#oooo#.....",
+		".....#oooo#.....",
+		"......#oo#......",
+		".....#oooo#.....",
+		"......#oo#......",
+		".....#oooo#.....",
+		"....#oooooo#....",
+		"...#oooooooo#...",
+		"...##########...",
+		"................",
+		"................",
+	},
+	chess.Knight: {
+		"................",
+		"................",
+		".......#.##.....",
+		"......#o#oo##...",
+		".....#oooooo#...",
+		"....#ooo#oooo#..",
+		"...#oooooooooo#.",
+		"...#ooooo#oooo#.",
+		"....###ooooooo#.",
+		"......#oooooo#..",
+		".....#oooooo#...",
+		"....#ooooooo#...",
+		"...#oooooooo#...",
+		"...##########...",
+		"................",
+		"................",
+	},
+	chess.Bishop: {
+		"................",
+		".......##.......",
+		"......#oo#......",
+		".....#oo#o#.....",
+		"....#oo#ooo#....",
+		"....#o#oooo#....",
+		"....#oooooo#....",
+		".....#oooo#.....",
+		"......#oo#......",
+		".....#oooo#.....",
+		".....#oooo#.....",
+		"....#oooooo#....",
+		"...#oooooooo#...",
+		"...##########...",
+		"................",
+		"................",
+	},
+	chess.Rook: {
+		"................",
+		"................",
+		"...##.####.##...",
+		"...#o##oo##o#...",
+		"...#oooooooo#...",
+		"....#oooooo#....",
+		".....#oooo#.....",
+		".....#oooo#.....",
+		".....#oooo#.....",
+		".....#oooo#.....",
+		"....#oooooo#....",
+		"...#oooooooo#...",
+		"...#oooooooo#...",
+		"...##########...",
+		"................",
+		"................",
+	},
+	chess.Queen: {
+		"................",
+		"..#....##....#..",
+		".#o#..#oo#..#o#.",
+		"..#o#..##..#o#..",
+		"..#oo#.##.#oo#..",
+		"..#ooo#oo#ooo#..",
+		"..#oooooooooo#..",
+		"...#oooooooo#...",
+		"....#oooooo#....",
+		"....#oooooo#....",
+		".....#oooo#.....",
+		"....#oooooo#....",
+		"...#oooooooo#...",
+		"...##########...",
+		"................",
+		"................",
+	},
+	chess.King: {
+		".......##.......",
+		"......#oo#......",
+		".....##oo##.....",
+		".....#oooo#.....",
+		"..###.#oo#.###..",
+		".#ooo##oo##ooo#.",
+		".#oooooooooooo#.",
+		".#oooooooooooo#.",
+		"..#oooooooooo#..",
+		"...#oooooooo#...",
+		"....#oooooo#....",
+		"....#oooooo#....",
+		"...#oooooooo#...",
+		"...##########...",
+		"................",
+		"................",
+	},
+}
+
+// Colors of the built-in pieces, indexed by chess.Color.
+var (
+	pieceOutline = [2]color.NRGBA{{0x00, 0x00, 0x00, 0xff}, {0x00, 0x00, 0x00, 0xff}}
+	pieceBody    = [2]color.NRGBA{{0xff, 0xff, 0xff, 0xff}, {0x33, 0x33, 0x33, 0xff}}
+)
+
+// builtinPieces is the built-in piece set.
+var builtinPieces = newBuiltinPieces()
+
+func newBuiltinPieces() *PieceSet {
+	set := &PieceSet{
+		Images: make(map[chess.Piece]image.Image),
+		SVG:    make(map[chess.Piece]string),
+	}
+	for pt, mask := range pieceMasks {
+		for _, c := range []chess.Color{chess.White, chess.Black} {
+			p := chess.NewPiece(c, pt)
+			set.Images[p] = maskImage(mask, pieceOutline[c], pieceBody[c])
+			set.SVG[p] = maskSVG(mask, pieceOutline[c], pieceBody[c])
+		}
+	}
+	return set
+}
+
+// maskImage draws a piece mask.
+func maskImage(mask [16]string, outline, body color.NRGBA) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y, row := range mask {
+		for x, c := range row {
+			switch c {
+			case '#':
+				img.SetNRGBA(x, y, outline)
+			case 'o':
+				img.SetNRGBA(x, y, body)
+			}
+		}
+	}
+	return img
+}
+
+// maskSVG draws a piece mask as SVG paths scaled to a 45x45 box.
+func maskSVG(mask [16]string, outline, body color.NRGBA) string {
+	var o, b strings.Builder
+	for y, row := range mask {
+		for x, c := range row {
+			switch c {
+			case '#':
+				fmt.Fprintf(&o, "M%d %dh1v1h-1z", x, y)
+			case 'o':
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	return fmt.Sprintf(`<g transform="scale(2.8125)"><path fill="%s" d="%s"/><path fill="%s" d="%s"/></g>`,
+		hexColor(outline), o.String(), hexColor(body), b.String())
+}
@@ -0,0 +1,90 @@
+package diagram
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/clfs/chess"
+)
+
+// PNG writes a PNG image of pos to w.
+func PNG(w io.Writer, pos *chess.Position, opts Options) error {
+	return png.Encode(w, Image(pos, opts))
+}
+
+// Image draws pos.
+func Image(pos *chess.Position, opts Options) *image.NRGBA {
+	o := opts.withDefaults()
+	img := image.NewNRGBA(image.Rect(0, 0, 8*o.SquareSize, 8*o.SquareSize))
+
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		draw.Draw(img, o.squareRect(sq), image.NewUniform(o.squareColor(sq)), image.Point{}, draw.Src)
+	}
+	for _, h := range o.Highlights {
+		draw.Draw(img, o.squareRect(h.Square), image.NewUniform(highlightColor(h)), image.Point{}, draw.Over)
+	}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		p := pos.PieceAt(sq)
+		if p == chess.NoPiece || o.Pieces.Images[p] == nil {
+			continue
+		}
+		r := o.squareRect(sq)
+		draw.Draw(img, r, scale(o.Pieces.Images[p], o.SquareSize), image.Point{}, draw.Over)
+	}
+	for _, a := range o.Arrows {
+		pts := o.arrowPolygon(a)
+		if pts == nil {
+			continue
+		}
+		draw.DrawMask(img, img.Bounds(), image.NewUniform(arrowColor(a)), image.Point{}, polygonMask(img.Bounds(), pts), image.Point{}, draw.Over)
+	}
+	return img
+}
+
+// squareRect returns the pixels of sq.
+func (o Options) squareRect(sq chess.Square) image.Rectangle {
+	x, y := o.origin(sq)
+	return image.Rect(x, y, x+o.SquareSize, y+o.SquareSize)
+}
+
+// scale resizes src to a size x size image with nearest-neighbor sampling,
+// which keeps pixel art sharp.
+func scale(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*b.Dx()/size, b.Min.Y+y*b.Dy()/size))
+		}
+	}
+	return dst
+}
+
+// polygonMask returns a mask of the pixels in r whose centers are inside the
+// polygon.
+func polygonMask(r image.Rectangle, pts []point) *image.Alpha {
+	mask := image.NewAlpha(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if inside(point{float64(x) + 0.5, float64(y) + 0.5}, pts) {
+				mask.SetAlpha(x, y, color.Alpha{0xff})
+			}
+		}
+	}
+	return mask
+}
+
+// inside reports whether p is inside the polygon, by the even-odd rule.
+func inside(p point, pts []point) bool {
+	in := false
+	for i, j := 0, len(pts)-1; i < len(pts); j, i = i, i+1 {
+		a, b := pts[i], pts[j]
+		if (a.y > p.y) != (b.y > p.y) && p.x < (b.x-a.x)*(p.y-a.y)/(b.y-a.y)+a.x {
+			in = !in
+		}
+	}
+	return in
+}
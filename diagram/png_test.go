@@ -0,0 +1,78 @@
+package diagram
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/clfs/chess"
+)
+
+func nrgba(c color.Color) color.NRGBA {
+	return color.NRGBAModel.Convert(c).(color.NRGBA)
+}
+
+func TestImage(t *testing.T) {
+	pos := chess.StartingPosition()
+	img := Image(pos, Options{
+		SquareSize: 16,
+		Highlights: []Highlight{{Square: chess.E4, Color: color.NRGBA{0xff, 0, 0, 0xff}}},
+		Arrows:     []Arrow{{From: chess.A3, To: chess.H3, Color: color.NRGBA{0, 0, 0xff, 0xff}}},
+	})
+	if got, want := img.Bounds(), image.Rect(0, 0, 128, 128); got != want {
+		t.Fatalf("bounds: want %v, got %v", want, got)
+	}
+
+	cases := []struct {
+		name string
+		x, y int
+		want color.Color
+	}{
+		{"a1 is dark", 0, 127, DefaultDark},
+		{"h1 is light", 127, 127, DefaultLight},
+		{"e4 is highlighted", 4*16 + 1, 4*16 + 1, color.NRGBA{0xff, 0, 0, 0xff}},
+		{"arrow on d3", 3*16 + 8, 5*16 + 8, color.NRGBA{0, 0, 0xff, 0xff}},
+		{"white king body", 4*16 + 8, 7*16 + 8, pieceBody[chess.White]},
+		{"black king body", 4*16 + 8, 8, pieceBody[chess.Black]},
+	}
+	for _, tc := range cases {
+		if got, want := img.NRGBAAt(tc.x, tc.y), nrgba(tc.want); got != want {
+			t.Errorf("%s: pixel (%d, %d) is %v, want %v", tc.name, tc.x, tc.y, got, want)
+		}
+	}
+
+	flipped := Image(pos, Options{SquareSize: 16, Flipped: true})
+	if got, want := flipped.NRGBAAt(3*16+8, 8), nrgba(pieceBody[chess.White]); got != want {
+		t.Errorf("flipped: want the white king on e1 at the top, got %v", got)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	var b bytes.Buffer
+	if err := PNG(&b, chess.StartingPosition(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 360, 360); got != want {
+		t.Errorf("bounds: want %v, got %v", want, got)
+	}
+}
+
+func TestImage_PieceSet(t *testing.T) {
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	square := image.NewUniform(red)
+	set := &PieceSet{Images: map[chess.Piece]image.Image{chess.WhiteKing: square}}
+
+	img := Image(chess.StartingPosition(), Options{SquareSize: 8, Pieces: set})
+	if got := img.NRGBAAt(4*8, 7*8); got != red {
+		t.Errorf("e1: want the custom king, got %v", got)
+	}
+	if got, want := img.NRGBAAt(3*8+4, 7*8+4), nrgba(DefaultLight); got != want {
+		t.Errorf("d1: want an empty square for a missing piece, got %v", got)
+	}
+}
@@ -0,0 +1,73 @@
+package diagram
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+// SVG writes an SVG image of pos to w.
+func SVG(w io.Writer, pos *chess.Position, opts Options) error {
+	o := opts.withDefaults()
+	size := 8 * o.SquareSize
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", size, size, size, size)
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		writeSquare(bw, o, sq, o.squareColor(sq))
+	}
+	for _, h := range o.Highlights {
+		writeSquare(bw, o, h.Square, highlightColor(h))
+	}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		p := pos.PieceAt(sq)
+		if p == chess.NoPiece {
+			continue
+		}
+		x, y := o.origin(sq)
+		fmt.Fprintf(bw, `<g transform="translate(%d %d) scale(%s)">%s</g>`+"\n", x, y, formatFloat(float64(o.SquareSize)/45), o.Pieces.SVG[p])
+	}
+	for _, a := range o.Arrows {
+		pts := o.arrowPolygon(a)
+		if pts == nil {
+			continue
+		}
+		coords := make([]string, len(pts))
+		for i, p := range pts {
+			coords[i] = formatFloat(p.x) + "," + formatFloat(p.y)
+		}
+		fmt.Fprintf(bw, `<polygon points="%s"%s/>`+"\n", strings.Join(coords, " "), fillAttrs(arrowColor(a)))
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// writeSquare writes a square filled with c.
+func writeSquare(w io.Writer, o Options, sq chess.Square, c color.Color) {
+	x, y := o.origin(sq)
+	fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d"%s/>`+"\n", x, y, o.SquareSize, o.SquareSize, fillAttrs(c))
+}
+
+// fillAttrs returns the SVG attributes to fill with c.
+func fillAttrs(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0xff {
+		return fmt.Sprintf(` fill="%s"`, hexColor(n))
+	}
+	return fmt.Sprintf(` fill="%s" fill-opacity="%s"`, hexColor(n), strconv.FormatFloat(float64(n.A)/0xff, 'f', 3, 64))
+}
+
+// hexColor returns c as "#rrggbb", ignoring alpha.
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// formatFloat formats a number as briefly as possible.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,58 @@
+package diagram
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+)
+
+func TestSVG(t *testing.T) {
+	var b bytes.Buffer
+	err := SVG(&b, chess.StartingPosition(), Options{
+		SquareSize: 10,
+		Flipped:    true,
+		Highlights: []Highlight{{Square: chess.E2}},
+		Arrows:     []Arrow{{From: chess.G1, To: chess.F3, Color: color.NRGBA{0x12, 0x34, 0x56, 0xff}}, {From: chess.A1, To: chess.A1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	// The output must be well-formed XML.
+	d := xml.NewDecoder(strings.NewReader(out))
+	for {
+		if _, err := d.Token(); err != nil {
+			if err != io.EOF {
+				t.Fatalf("invalid XML: %v\n%s", err, out)
+			}
+			break
+		}
+	}
+
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="80" height="80" viewBox="0 0 80 80">`,
+		`<rect x="70" y="0" width="10" height="10" fill="#b58863"/>`,                       // a1, flipped
+		`<rect x="30" y="10" width="10" height="10" fill="#cdd26a" fill-opacity="0.667"/>`, // e2
+		`<g transform="translate(30 0) scale(0.2222222222222222)">`,                        // the white king
+		`fill="#123456"/>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't contain %s:\n%s", want, out)
+		}
+	}
+	if got := strings.Count(out, "<rect"); got != 65 {
+		t.Errorf("want 65 rects, got %d", got)
+	}
+	if got := strings.Count(out, "<polygon"); got != 1 {
+		t.Errorf("want 1 arrow, got %d", got)
+	}
+	if got := strings.Count(out, "<g transform=\"translate"); got != 32 {
+		t.Errorf("want 32 pieces, got %d", got)
+	}
+}
@@ -0,0 +1,163 @@
+// Package eco classifies chess openings using an embedded database of
+// Encyclopaedia of Chess Openings (ECO) codes and opening names, covering every
+// code from A00 to E99.
+//
+// Openings are recognized by position, so a game that reaches an opening by
+// another move order is classified the same.
+package eco
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/clfs/chess"
+)
+
+//go:embed openings.tsv
+var openingsTSV []byte
+
+// Opening is a named opening line.
+type Opening struct {
+	ECO   string       // The ECO code, such as "C60".
+	Name  string       // The opening name, such as "Ruy Lopez: Berlin Defense".
+	Moves []chess.Move // The moves that define the opening, from the standard starting position.
+}
+
+var (
+	loadOnce   sync.Once
+	openings   []Opening
+	byPosition map[string]int // Position key to index in openings.
+)
+
+func load() {
+	var err error
+	openings, err = parseTSV(openingsTSV)
+	if err != nil {
+		panic(fmt.Sprintf("eco: invalid embedded database: %v", err))
+	}
+	byPosition = make(map[string]int, len(openings))
+	for i, o := range openings {
+		p := chess.StartingPosition()
+		for _, m := range o.Moves {
+			p.Apply(m)
+		}
+		byPosition[positionKey(p)] = i
+	}
+}
+
+// parseTSV parses a database with a header row and the columns eco, name and
+// pgn, where pgn is the line in SAN with move numbers, such as "1. e4 e5".
+func parseTSV(data []byte) ([]Opening, error) {
+	var res []Opening
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		if n == 1 {
+			continue
+		}
+		fields := strings.Split(s.Text(), "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: want 3 fields, got %d", n, len(fields))
+		}
+		moves, err := parseLine(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		res = append(res, Opening{
+			ECO:   fields[0],
+			Name:  fields[1],
+			Moves: moves,
+		})
+	}
+	return res, s.Err()
+}
+
+// parseLine parses a line of SAN moves from the standard starting position,
+// skipping move numbers.
+func parseLine(s string) ([]chess.Move, error) {
+	var moves []chess.Move
+	p := chess.StartingPosition()
+	for _, tok := range strings.Fields(s) {
+		if strings.HasSuffix(tok, ".") {
+			continue
+		}
+		m, err := chess.ParseSAN(p, tok)
+		if err != nil {
+			return nil, err
+		}
+		p.Apply(m)
+		moves = append(moves, m)
+	}
+	return moves, nil
+}
+
+// positionKey returns the FEN of p without the move counters, and without an
+// en passant square that no pawn can capture on, so that transposed positions
+// have the same key.
+func positionKey(p *chess.Position) string {
+	fields := strings.Fields(p.String())
+	if fields[3] != "-" && !canCaptureEnPassant(p) {
+		fields[3] = "-"
+	}
+	return strings.Join(fields[:4], " ")
+}
+
+// canCaptureEnPassant reports whether the side to move has a legal en passant
+// capture.
+func canCaptureEnPassant(p *chess.Position) bool {
+	for _, m := range p.LegalMoves() {
+		if m.To == p.EnPassant() && p.PieceAt(m.From).Type() == chess.Pawn {
+			return true
+		}
+	}
+	return false
+}
+
+// Openings returns every opening in the database, sorted by ECO code.
+func Openings() []Opening {
+	loadOnce.Do(load)
+	res := make([]Opening, len(openings))
+	copy(res, openings)
+	return res
+}
+
+// Lookup returns the most specific opening that the moves, played from the
+// standard starting position, belong to: that of the last position in the
+// database that they reach. Play stops at the first illegal move. Lookup
+// reports false if no opening matches.
+func Lookup(moves []chess.Move) (Opening, bool) {
+	loadOnce.Do(load)
+	var (
+		res   Opening
+		found bool
+	)
+	p := chess.StartingPosition()
+	for _, m := range moves {
+		if !p.IsLegal(m) {
+			break
+		}
+		p.Apply(m)
+		if i, ok := byPosition[positionKey(p)]; ok {
+			res, found = openings[i], true
+		}
+	}
+	return res, found
+}
+
+// LookupPosition returns the opening whose position is p, regardless of the
+// moves that led to it. It reports false if p isn't in the database.
+func LookupPosition(p *chess.Position) (Opening, bool) {
+	loadOnce.Do(load)
+	if p.Variant() != chess.Standard {
+		return Opening{}, false
+	}
+	i, ok := byPosition[positionKey(p)]
+	if !ok {
+		return Opening{}, false
+	}
+	return openings[i], true
+}
@@ -0,0 +1,125 @@
+package eco
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+)
+
+// parseMoves parses moves in UCI notation, separated by spaces.
+func parseMoves(t *testing.T, s string) []chess.Move {
+	t.Helper()
+	var moves []chess.Move
+	for _, f := range strings.Fields(s) {
+		m, err := chess.ParseMove(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moves = append(moves, m)
+	}
+	return moves
+}
+
+func TestLookup(t *testing.T) {
+	cases := []struct {
+		moves string
+		eco   string
+		name  string
+	}{
+		{"e2e4", "B00", "King's Pawn Game"},
+		{"e2e4 e7e5 g1f3 b8c6 f1b5 g8f6 e1g1", "C65", "Ruy Lopez: Berlin Defense"},
+		{"d2d4 g8f6 c2c4 e7e6 b1c3 f8b4 d1c2", "E32", "Nimzo-Indian Defense: Classical Variation"},
+		{"e2e4 c7c5 g1f3 d7d6 d2d4 c5d4 f3d4 g8f6 b1c3 a7a6 c1e3", "B90", "Sicilian Defense: Najdorf Variation"},
+		// Past the end of the database.
+		{"e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5a4 g8f6 e1g1 f8e7 f1e1 b7b5 a4b3 d7d6 c2c3 e8g8 h2h3 c6a5 b3c2 c7c5 d2d4 d8c7 b1d2 c5d4 c3d4 a5c6 d2b3",
+			"C99", "Ruy Lopez: Closed, Chigorin Defense, Exchange Line"},
+		// Transpositions.
+		{"c2c4 e7e6 d2d4 g8f6", "E00", "Indian Defense: East Indian Defense"},
+		{"g1f3 d7d5 d2d4", "D02", "Queen's Pawn Game: Zukertort Variation"},
+		{"c2c4 c7c6 d2d4 d7d5", "D10", "Slav Defense"},
+		// Play stops at the first illegal move.
+		{"e2e4 e2e4 e7e5", "B00", "King's Pawn Game"},
+	}
+	for i, c := range cases {
+		got, ok := Lookup(parseMoves(t, c.moves))
+		if !ok {
+			t.Errorf("#%d: no match", i)
+			continue
+		}
+		if got.ECO != c.eco || got.Name != c.name {
+			t.Errorf("#%d: want %s %s, got %s %s", i, c.eco, c.name, got.ECO, got.Name)
+		}
+	}
+}
+
+func TestLookup_NoMatch(t *testing.T) {
+	for _, moves := range []string{"", "e2e5"} {
+		if o, ok := Lookup(parseMoves(t, moves)); ok {
+			t.Errorf("Lookup(%q): want no match, got %+v", moves, o)
+		}
+	}
+}
+
+func TestLookupPosition(t *testing.T) {
+	cases := []struct {
+		fen  string
+		eco  string
+		name string
+	}{
+		// 1. d4 Nf6 2. c4 g6 3. Nc3 d5, reached with other move counters.
+		{"rnbqkb1r/ppp1pp1p/5np1/3p4/2PP4/2N5/PP2PPPP/R1BQKBNR w KQkq - 0 9", "D80", "Grünfeld Defense"},
+		// 1. e4 c5, with an en passant square no pawn can capture on.
+		{"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2", "B20", "Sicilian Defense"},
+	}
+	for i, c := range cases {
+		p, err := chess.ParseFEN(c.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := LookupPosition(p)
+		if !ok {
+			t.Errorf("#%d: no match", i)
+			continue
+		}
+		if got.ECO != c.eco || got.Name != c.name {
+			t.Errorf("#%d: want %s %s, got %s %s", i, c.eco, c.name, got.ECO, got.Name)
+		}
+	}
+
+	if o, ok := LookupPosition(chess.StartingPosition()); ok {
+		t.Errorf("LookupPosition(start): want no match, got %+v", o)
+	}
+}
+
+func TestOpenings(t *testing.T) {
+	codes := make(map[string]bool)
+	seen := make(map[string]string)
+	for _, o := range Openings() {
+		if len(o.ECO) != 3 || o.ECO[0] < 'A' || o.ECO[0] > 'E' {
+			t.Errorf("%s: invalid ECO code %q", o.Name, o.ECO)
+		}
+		codes[o.ECO] = true
+		if len(o.Moves) == 0 {
+			t.Errorf("%s: no moves", o.Name)
+			continue
+		}
+		p := chess.StartingPosition()
+		for _, m := range o.Moves {
+			p.Apply(m)
+		}
+		key := positionKey(p)
+		if prev, ok := seen[key]; ok {
+			t.Errorf("%s: same position as %s", o.Name, prev)
+		}
+		seen[key] = o.Name
+	}
+	for _, l := range "ABCDE" {
+		for n := 0; n < 100; n++ {
+			if code := fmt.Sprintf("%c%02d", l, n); !codes[code] {
+				t.Errorf("no opening with code %s", code)
+			}
+		}
+	}
+}
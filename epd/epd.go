@@ -0,0 +1,272 @@
+// Package epd reads and writes positions in Extended Position Description
+// (EPD), the format of test suites such as WAC, STS and Arasan.
+//
+// An EPD record is the first four fields of a FEN followed by operations, each
+// an opcode with operands, terminated by a semicolon:
+//
+//	r1b1k2r/ppppnppp/2n2q2/2b5/3NP3/2P1B3/PP3PPP/RN1QKB1R w KQkq - bm Nf5; id "WAC.014";
+package epd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+// Record is a single EPD record.
+type Record struct {
+	// Position is the position described by the record. Its halfmove clock
+	// and fullmove number come from the hmvc and fmvn opcodes, if present.
+	Position *chess.Position
+
+	Ops []Op // The operations, in order.
+}
+
+// Op is an EPD operation, such as bm Nf3 Qh5.
+type Op struct {
+	Opcode   string
+	Operands []string // Unquoted.
+}
+
+// Parse parses a single EPD record.
+func Parse(line string) (*Record, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("epd: invalid record %q: want at least 4 fields", line)
+	}
+
+	// Skip past the position fields, which contain no quotes.
+	rest := strings.TrimSpace(line)
+	for i := 0; i < 4; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+		rest = rest[strings.IndexAny(rest+" ", " \t"):]
+	}
+
+	r := new(Record)
+	var err error
+	if r.Ops, err = parseOps(rest); err != nil {
+		return nil, fmt.Errorf("epd: invalid record %q: %w", line, err)
+	}
+
+	hmvc, fmvn := "0", "1"
+	if op, ok := r.Op("hmvc"); ok && len(op.Operands) == 1 {
+		hmvc = op.Operands[0]
+	}
+	if op, ok := r.Op("fmvn"); ok && len(op.Operands) == 1 {
+		fmvn = op.Operands[0]
+	}
+	fen := strings.Join(append(fields[:4:4], hmvc, fmvn), " ")
+	if r.Position, err = chess.ParseFEN(fen); err != nil {
+		return nil, fmt.Errorf("epd: invalid record %q: %w", line, err)
+	}
+	return r, nil
+}
+
+// parseOps parses the operations of a record.
+func parseOps(s string) ([]Op, error) {
+	var ops []Op
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return ops, nil
+		}
+
+		i := strings.IndexAny(s, " \t;")
+		if i < 0 {
+			i = len(s)
+		}
+		op := Op{Opcode: s[:i]}
+		if op.Opcode == "" {
+			return nil, errors.New("empty opcode")
+		}
+		s = s[i:]
+
+		for {
+			s = strings.TrimLeft(s, " \t")
+			if s == "" || s[0] == ';' {
+				// Tolerate a missing semicolon at the end of the record.
+				if s != "" {
+					s = s[1:]
+				}
+				break
+			}
+			if s[0] == '"' {
+				end := strings.IndexByte(s[1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated string in %s", op.Opcode)
+				}
+				op.Operands = append(op.Operands, s[1:end+1])
+				s = s[end+2:]
+				continue
+			}
+			end := strings.IndexAny(s, " \t;")
+			if end < 0 {
+				end = len(s)
+			}
+			op.Operands = append(op.Operands, s[:end])
+			s = s[end:]
+		}
+		ops = append(ops, op)
+	}
+}
+
+// String returns the record in EPD.
+func (r *Record) String() string {
+	fields := strings.Fields(r.Position.String())
+	var b strings.Builder
+	b.WriteString(strings.Join(fields[:4], " "))
+	for _, op := range r.Ops {
+		b.WriteByte(' ')
+		b.WriteString(op.Opcode)
+		for _, v := range op.Operands {
+			b.WriteByte(' ')
+			if needsQuotes(op.Opcode, v) {
+				b.WriteString(`"` + v + `"`)
+			} else {
+				b.WriteString(v)
+			}
+		}
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+// needsQuotes reports whether an operand of the given opcode is written as a
+// string.
+func needsQuotes(opcode, v string) bool {
+	switch opcode {
+	case "id", "eco", "nic":
+		return true
+	}
+	if len(opcode) == 2 && (opcode[0] == 'c' || opcode[0] == 'v') && opcode[1] >= '0' && opcode[1] <= '9' {
+		return true
+	}
+	return v == "" || strings.ContainsAny(v, " \t;")
+}
+
+// Op returns the first operation with the given opcode.
+func (r *Record) Op(opcode string) (Op, bool) {
+	for _, op := range r.Ops {
+		if op.Opcode == opcode {
+			return op, true
+		}
+	}
+	return Op{}, false
+}
+
+// SetOp sets the operands of the first operation with the given opcode,
+// adding the operation to the end if there is none.
+func (r *Record) SetOp(opcode string, operands ...string) {
+	for i := range r.Ops {
+		if r.Ops[i].Opcode == opcode {
+			r.Ops[i].Operands = operands
+			return
+		}
+	}
+	r.Ops = append(r.Ops, Op{Opcode: opcode, Operands: operands})
+}
+
+// ID returns the operand of the id opcode, which names the record, or the
+// empty string if there is none.
+func (r *Record) ID() string {
+	if op, ok := r.Op("id"); ok && len(op.Operands) > 0 {
+		return op.Operands[0]
+	}
+	return ""
+}
+
+// BestMoves returns the moves of the bm opcode, which are the best moves in
+// the position, or nil if there is none.
+func (r *Record) BestMoves() ([]chess.Move, error) {
+	return r.moves("bm")
+}
+
+// AvoidMoves returns the moves of the am opcode, which are moves to avoid in
+// the position, or nil if there is none.
+func (r *Record) AvoidMoves() ([]chess.Move, error) {
+	return r.moves("am")
+}
+
+// moves parses the operands of opcode as alternative moves in SAN.
+func (r *Record) moves(opcode string) ([]chess.Move, error) {
+	op, ok := r.Op(opcode)
+	if !ok {
+		return nil, nil
+	}
+	moves := make([]chess.Move, len(op.Operands))
+	for i, s := range op.Operands {
+		m, err := chess.ParseSAN(r.Position, s)
+		if err != nil {
+			return nil, fmt.Errorf("epd: %s: %w", opcode, err)
+		}
+		moves[i] = m
+	}
+	return moves, nil
+}
+
+// PV returns the moves of the pv opcode, a predicted variation played from
+// the position, or nil if there is none.
+func (r *Record) PV() ([]chess.Move, error) {
+	op, ok := r.Op("pv")
+	if !ok {
+		return nil, nil
+	}
+	p := r.Position.Clone()
+	moves := make([]chess.Move, len(op.Operands))
+	for i, s := range op.Operands {
+		m, err := chess.ParseSAN(p, s)
+		if err != nil {
+			return nil, fmt.Errorf("epd: pv: %w", err)
+		}
+		p.Apply(m)
+		moves[i] = m
+	}
+	return moves, nil
+}
+
+// CentipawnEval returns the operand of the ce opcode, an evaluation in
+// centipawns from the point of view of the side to move.
+func (r *Record) CentipawnEval() (int, bool) {
+	return r.int("ce")
+}
+
+// DirectMate returns the operand of the dm opcode, the number of moves in
+// which the side to move mates.
+func (r *Record) DirectMate() (int, bool) {
+	return r.int("dm")
+}
+
+// int returns the single integer operand of opcode.
+func (r *Record) int(opcode string) (int, bool) {
+	op, ok := r.Op(opcode)
+	if !ok || len(op.Operands) != 1 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(op.Operands[0])
+	return n, err == nil
+}
+
+// ReadAll reads records from r, one per line. Blank lines are skipped.
+func ReadAll(r io.Reader) ([]*Record, error) {
+	var res []*Record
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		rec, err := Parse(s.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		res = append(res, rec)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
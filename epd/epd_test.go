@@ -0,0 +1,134 @@
+package epd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+const wac001 = `2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001";`
+
+func TestParse(t *testing.T) {
+	r, err := Parse(wac001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - 0 1", r.Position.String(); got != want {
+		t.Errorf("position: want %q, got %q", want, got)
+	}
+	want := []Op{{"bm", []string{"Qg6"}}, {"id", []string{"WAC.001"}}}
+	if diff := cmp.Diff(want, r.Ops); diff != "" {
+		t.Errorf("ops mismatch (-want +got):\n%s", diff)
+	}
+	if got := r.String(); got != wac001 {
+		t.Errorf("String: want %q, got %q", wac001, got)
+	}
+}
+
+func TestParse_Ops(t *testing.T) {
+	const line = `1k6/8/8/8/8/8/8/K7 b - - c0 "a; b" ;hmvc 12;fmvn 40; pv Kb7 Kb2 ;noop`
+	r, err := Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Op{
+		{"c0", []string{"a; b"}},
+		{"hmvc", []string{"12"}},
+		{"fmvn", []string{"40"}},
+		{"pv", []string{"Kb7", "Kb2"}},
+		{"noop", nil},
+	}
+	if diff := cmp.Diff(want, r.Ops); diff != "" {
+		t.Errorf("ops mismatch (-want +got):\n%s", diff)
+	}
+	if r.Position.HalfmoveClock() != 12 || r.Position.FullmoveNumber() != 40 {
+		t.Errorf("clocks: got %d and %d, want 12 and 40", r.Position.HalfmoveClock(), r.Position.FullmoveNumber())
+	}
+	const formatted = `1k6/8/8/8/8/8/8/K7 b - - c0 "a; b"; hmvc 12; fmvn 40; pv Kb7 Kb2; noop;`
+	if got := r.String(); got != formatted {
+		t.Errorf("String: want %q, got %q", formatted, got)
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"8/8/8/8/8/8/8/8 w - -",
+		"2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w",
+		`2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - id "WAC.001;`,
+	} {
+		if _, err := Parse(line); err == nil {
+			t.Errorf("Parse(%q): want error", line)
+		}
+	}
+}
+
+func TestRecord_Accessors(t *testing.T) {
+	r, err := Parse(`6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - bm Rd8#; am Kf1 h3; ce 32767; dm 1; pv Rd8#; id "mate";`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.ID(); got != "mate" {
+		t.Errorf("ID: want mate, got %q", got)
+	}
+
+	mate := chess.Move{From: chess.D1, To: chess.D8}
+	bm, err := r.BestMoves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]chess.Move{mate}, bm); diff != "" {
+		t.Errorf("BestMoves mismatch (-want +got):\n%s", diff)
+	}
+	am, err := r.AvoidMoves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]chess.Move{{From: chess.G1, To: chess.F1}, {From: chess.H2, To: chess.H3}}, am); diff != "" {
+		t.Errorf("AvoidMoves mismatch (-want +got):\n%s", diff)
+	}
+	pv, err := r.PV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]chess.Move{mate}, pv); diff != "" {
+		t.Errorf("PV mismatch (-want +got):\n%s", diff)
+	}
+	if ce, ok := r.CentipawnEval(); !ok || ce != 32767 {
+		t.Errorf("CentipawnEval: got %d, %v", ce, ok)
+	}
+	if dm, ok := r.DirectMate(); !ok || dm != 1 {
+		t.Errorf("DirectMate: got %d, %v", dm, ok)
+	}
+
+	r.SetOp("bm", "Qd8")
+	if _, err := r.BestMoves(); err == nil {
+		t.Error("BestMoves with illegal move: want error")
+	}
+	r.SetOp("c0", "new comment")
+	if want, got := `id "mate"; c0 "new comment";`, r.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("String: got %q, want suffix %q", got, want)
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	input := wac001 + "\n\n" +
+		`5rk1/1ppb3p/p1pb4/6q1/3P1p1r/2P1R2P/PP1BQ1P1/5RKN w - - bm Rg3; id "WAC.003";` + "\n"
+	records, err := ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range records {
+		ids = append(ids, r.ID())
+	}
+	if diff := cmp.Diff([]string{"WAC.001", "WAC.003"}, ids); diff != "" {
+		t.Errorf("ids mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadAll(strings.NewReader(wac001 + "\nbogus\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("want error on line 2, got %v", err)
+	}
+}
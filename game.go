@@ -0,0 +1,269 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Outcome is the result of a game.
+type Outcome int
+
+// Outcomes.
+const (
+	NoOutcome Outcome = iota // The game is still in progress.
+	WhiteWon
+	BlackWon
+	Draw
+)
+
+// String returns the outcome as in PGN: "*", "1-0", "0-1" or "1/2-1/2".
+func (o Outcome) String() string {
+	switch o {
+	case NoOutcome:
+		return "*"
+	case WhiteWon:
+		return "1-0"
+	case BlackWon:
+		return "0-1"
+	case Draw:
+		return "1/2-1/2"
+	}
+	return fmt.Sprintf("Outcome(%d)", int(o))
+}
+
+// Method is the way a game ended.
+type Method int
+
+// Methods.
+const (
+	NoMethod Method = iota
+	Checkmate
+	Stalemate
+	InsufficientMaterial
+	FivefoldRepetition
+	SeventyFiveMoveRule
+	ThreefoldRepetition // Claimed with Game.ClaimDraw.
+	FiftyMoveRule       // Claimed with Game.ClaimDraw.
+	VariantEnd          // A win by a rule of the variant; see Position.VariantOutcome.
+)
+
+func (m Method) String() string {
+	switch m {
+	case NoMethod:
+		return "none"
+	case Checkmate:
+		return "checkmate"
+	case Stalemate:
+		return "stalemate"
+	case InsufficientMaterial:
+		return "insufficient material"
+	case FivefoldRepetition:
+		return "fivefold repetition"
+	case SeventyFiveMoveRule:
+		return "seventy-five-move rule"
+	case ThreefoldRepetition:
+		return "threefold repetition"
+	case FiftyMoveRule:
+		return "fifty-move rule"
+	case VariantEnd:
+		return "variant end"
+	}
+	return fmt.Sprintf("Method(%d)", int(m))
+}
+
+var (
+	errGameOver   = errors.New("chess: game is over")
+	errCannotDraw = errors.New("chess: no draw to claim")
+)
+
+// Game is a game of chess: a starting position and the moves played from it.
+// Game ends automatically by checkmate, stalemate, insufficient material,
+// fivefold repetition and the seventy-five-move rule. Draws by threefold
+// repetition and the fifty-move rule must be claimed. Games in a variant also
+// end by the variant's own rules, with the method VariantEnd.
+type Game struct {
+	start   *Position
+	pos     *Position
+	moves   []Move
+	keys    []positionKey // Indexed by ply, starting from the start position.
+	outcome Outcome
+	method  Method
+}
+
+// positionKey identifies a position for the purposes of repetition.
+type positionKey struct {
+	board      [64]Piece
+	sideToMove Color
+	castling   CastlingRights
+	enPassant  Square // Only set if en passant is possible.
+	pockets    [2][7]int8
+}
+
+// NewGame returns a game from the standard starting position.
+func NewGame() *Game {
+	return NewGameFromPosition(StartingPosition())
+}
+
+// NewGameFromPosition returns a game starting from p.
+func NewGameFromPosition(p *Position) *Game {
+	g := &Game{start: p.Clone(), pos: p.Clone()}
+	g.keys = append(g.keys, g.pos.key())
+	g.update()
+	return g
+}
+
+// Position returns a copy of the current position.
+func (g *Game) Position() *Position {
+	return g.pos.Clone()
+}
+
+// StartingPosition returns a copy of the position the game started from.
+func (g *Game) StartingPosition() *Position {
+	return g.start.Clone()
+}
+
+// Moves returns the moves played so far.
+func (g *Game) Moves() []Move {
+	return append([]Move(nil), g.moves...)
+}
+
+// Outcome returns the outcome of the game, or NoOutcome if it is in progress.
+func (g *Game) Outcome() Outcome {
+	return g.outcome
+}
+
+// Method returns how the game ended, or NoMethod if it is in progress.
+func (g *Game) Method() Method {
+	return g.method
+}
+
+// Play plays m. It fails if the game is over or m is illegal.
+func (g *Game) Play(m Move) error {
+	if g.outcome != NoOutcome {
+		return errGameOver
+	}
+	if !g.pos.IsLegal(m) {
+		return fmt.Errorf("chess: illegal move %v", m)
+	}
+	g.pos.Apply(m)
+	g.moves = append(g.moves, m)
+	g.keys = append(g.keys, g.pos.key())
+	g.update()
+	return nil
+}
+
+// PlaySAN is like Play, but takes a move in Standard Algebraic Notation.
+func (g *Game) PlaySAN(san string) error {
+	if g.outcome != NoOutcome {
+		return errGameOver
+	}
+	m, err := ParseSAN(g.pos, san)
+	if err != nil {
+		return err
+	}
+	return g.Play(m)
+}
+
+// CanClaimDraw reports whether the side to move can claim a draw by threefold
+// repetition or the fifty-move rule, and by which method.
+func (g *Game) CanClaimDraw() (Method, bool) {
+	if g.outcome != NoOutcome {
+		return NoMethod, false
+	}
+	if g.repetitions() >= 3 {
+		return ThreefoldRepetition, true
+	}
+	if g.pos.halfmoveClock >= 100 {
+		return FiftyMoveRule, true
+	}
+	return NoMethod, false
+}
+
+// ClaimDraw ends the game in a draw by threefold repetition or the fifty-move
+// rule, if the side to move can claim one.
+func (g *Game) ClaimDraw() error {
+	method, ok := g.CanClaimDraw()
+	if !ok {
+		return errCannotDraw
+	}
+	g.outcome, g.method = Draw, method
+	return nil
+}
+
+// update ends the game if it is over by rule.
+func (g *Game) update() {
+	if o := g.pos.VariantOutcome(); o != NoOutcome {
+		g.outcome, g.method = o, VariantEnd
+		return
+	}
+	switch {
+	case len(g.pos.LegalMoves()) == 0:
+		if g.pos.variant == Antichess {
+			g.outcome, g.method = winFor(g.pos.sideToMove), VariantEnd
+		} else if !g.pos.InCheck() {
+			g.outcome, g.method = Draw, Stalemate
+		} else if g.pos.sideToMove == White {
+			g.outcome, g.method = BlackWon, Checkmate
+		} else {
+			g.outcome, g.method = WhiteWon, Checkmate
+		}
+	case g.pos.insufficientMaterial():
+		g.outcome, g.method = Draw, InsufficientMaterial
+	case g.repetitions() >= 5:
+		g.outcome, g.method = Draw, FivefoldRepetition
+	case g.pos.halfmoveClock >= 150:
+		g.outcome, g.method = Draw, SeventyFiveMoveRule
+	}
+}
+
+// repetitions returns the number of times the current position has occurred.
+func (g *Game) repetitions() int {
+	cur := g.keys[len(g.keys)-1]
+	n := 0
+	// Positions can only repeat since the last capture or pawn move.
+	for i := len(g.keys) - 1; i >= 0 && i >= len(g.keys)-1-g.pos.halfmoveClock; i -= 2 {
+		if g.keys[i] == cur {
+			n++
+		}
+	}
+	return n
+}
+
+// key returns the repetition key of p.
+func (p *Position) key() positionKey {
+	k := positionKey{
+		board:      p.board,
+		sideToMove: p.sideToMove,
+		castling:   p.castling,
+		enPassant:  NoSquare,
+		pockets:    p.pockets,
+	}
+	if p.enPassant != NoSquare {
+		for _, m := range p.LegalMoves() {
+			if m.To == p.enPassant && p.board[m.From].Type() == Pawn {
+				k.enPassant = p.enPassant
+				break
+			}
+		}
+	}
+	return k
+}
+
+// insufficientMaterial reports whether neither side can possibly checkmate:
+// only kings remain, plus at most one minor piece, or bishops that all stand on
+// squares of the same color. It only applies to standard chess.
+func (p *Position) insufficientMaterial() bool {
+	if p.variant != Standard || p.pieces[Pawn]|p.pieces[Rook]|p.pieces[Queen] != 0 {
+		return false
+	}
+	minors := p.pieces[Knight] | p.pieces[Bishop]
+	if minors.Count() <= 1 {
+		return true
+	}
+	if p.pieces[Knight] != 0 {
+		return false
+	}
+	const darkSquares Bitboard = 0xAA55AA55AA55AA55
+	bishops := p.pieces[Bishop]
+	return bishops&darkSquares == 0 || bishops&^darkSquares == 0
+}
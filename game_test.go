@@ -0,0 +1,155 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+// playSAN plays the space-separated SAN moves in g.
+func playSAN(t *testing.T, g *Game, moves string) {
+	t.Helper()
+	for _, san := range strings.Fields(moves) {
+		if err := g.PlaySAN(san); err != nil {
+			t.Fatalf("PlaySAN(%q): %v", san, err)
+		}
+	}
+}
+
+func TestGame_Checkmate(t *testing.T) {
+	g := NewGame()
+	playSAN(t, g, "f3 e5 g4 Qh4#")
+	if g.Outcome() != BlackWon || g.Method() != Checkmate {
+		t.Errorf("got %v by %v, want 0-1 by checkmate", g.Outcome(), g.Method())
+	}
+	if err := g.PlaySAN("a3"); err != errGameOver {
+		t.Errorf("playing after mate: want %v, got %v", errGameOver, err)
+	}
+	if got := len(g.Moves()); got != 4 {
+		t.Errorf("got %d moves, want 4", got)
+	}
+}
+
+func TestGame_Stalemate(t *testing.T) {
+	p, err := ParseFEN("7k/8/6K1/8/8/8/8/5Q2 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGameFromPosition(p)
+	playSAN(t, g, "Qf7")
+	if g.Outcome() != Draw || g.Method() != Stalemate {
+		t.Errorf("got %v by %v, want a draw by stalemate", g.Outcome(), g.Method())
+	}
+}
+
+func TestGame_InsufficientMaterial(t *testing.T) {
+	p, err := ParseFEN("7k/6q1/8/8/8/8/8/K5Q1 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGameFromPosition(p)
+	playSAN(t, g, "Qxg7+")
+	if g.Outcome() != NoOutcome {
+		t.Fatalf("game over after Qxg7+: %v by %v", g.Outcome(), g.Method())
+	}
+	playSAN(t, g, "Kxg7")
+	if g.Outcome() != Draw || g.Method() != InsufficientMaterial {
+		t.Errorf("got %v by %v, want a draw by insufficient material", g.Outcome(), g.Method())
+	}
+}
+
+func TestGame_Repetition(t *testing.T) {
+	g := NewGame()
+	shuffle := "Nf3 Nf6 Ng1 Ng8"
+
+	playSAN(t, g, shuffle)
+	if _, ok := g.CanClaimDraw(); ok {
+		t.Error("can claim a draw after one repetition")
+	}
+	playSAN(t, g, shuffle)
+	if method, ok := g.CanClaimDraw(); !ok || method != ThreefoldRepetition {
+		t.Errorf("CanClaimDraw() = %v, %v, want threefold repetition", method, ok)
+	}
+	playSAN(t, g, shuffle)
+	if g.Outcome() != NoOutcome {
+		t.Fatalf("game over after four occurrences: %v by %v", g.Outcome(), g.Method())
+	}
+	playSAN(t, g, shuffle)
+	if g.Outcome() != Draw || g.Method() != FivefoldRepetition {
+		t.Errorf("got %v by %v, want a draw by fivefold repetition", g.Outcome(), g.Method())
+	}
+}
+
+func TestGame_ClaimDraw(t *testing.T) {
+	g := NewGame()
+	if err := g.ClaimDraw(); err != errCannotDraw {
+		t.Errorf("want %v, got %v", errCannotDraw, err)
+	}
+
+	p, err := ParseFEN("4k3/8/8/8/8/8/4P3/R3K3 w - - 99 80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = NewGameFromPosition(p)
+	playSAN(t, g, "Ra2")
+	if err := g.ClaimDraw(); err != nil {
+		t.Fatal(err)
+	}
+	if g.Outcome() != Draw || g.Method() != FiftyMoveRule {
+		t.Errorf("got %v by %v, want a draw by the fifty-move rule", g.Outcome(), g.Method())
+	}
+}
+
+func TestGame_SeventyFiveMoveRule(t *testing.T) {
+	p, err := ParseFEN("4k3/8/8/8/8/8/4P3/R3K3 w - - 149 80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGameFromPosition(p)
+	playSAN(t, g, "Ra2")
+	if g.Outcome() != Draw || g.Method() != SeventyFiveMoveRule {
+		t.Errorf("got %v by %v, want a draw by the seventy-five-move rule", g.Outcome(), g.Method())
+	}
+}
+
+func TestGame_IllegalMove(t *testing.T) {
+	g := NewGame()
+	if err := g.Play(Move{From: E2, To: E5}); err == nil {
+		t.Error("illegal move accepted")
+	}
+	if len(g.Moves()) != 0 {
+		t.Error("illegal move recorded")
+	}
+}
+
+func TestInsufficientMaterial(t *testing.T) {
+	cases := []struct {
+		fen  string
+		want bool
+	}{
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/4KN2 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/4KB2 w - - 0 1", true},
+		{"4kb2/8/8/8/8/8/8/2B1K3 w - - 0 1", true},   // Both bishops on dark squares.
+		{"4k1b1/8/8/8/8/8/8/2B1K3 w - - 0 1", false}, // Opposite-colored bishops.
+		{"4k3/8/8/8/8/8/8/3NKN2 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", false},
+		{StartingFEN, false},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.insufficientMaterial(); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.fen, got, tc.want)
+		}
+	}
+}
+
+func TestOutcome_String(t *testing.T) {
+	for o, want := range map[Outcome]string{NoOutcome: "*", WhiteWon: "1-0", BlackWon: "0-1", Draw: "1/2-1/2"} {
+		if got := o.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+// Package gametree represents games as trees of moves, with variations, for
+// tools that navigate and edit games, such as annotators and GUIs.
+//
+// Each Node is a position reached by a move from its parent. The first child
+// of a node is the main continuation, and the others are variations on it.
+// Trees convert to and from pgn.Game, keeping the annotations of every move:
+//
+//	t, err := gametree.FromPGN(g)
+//	n := t.Root().Next().Next()       // After 1. e4 e5.
+//	v, err := n.Parent().Add(c5)      // Add 1... c5 as a variation.
+//	v.PromoteToMainLine()             // Make it the main line.
+//	g = t.PGN()
+package gametree
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/pgn"
+)
+
+// Tree is a game as a tree of moves.
+type Tree struct {
+	Tags   []pgn.Tag     // The tag pairs, which may set the starting position.
+	Result chess.Outcome // The game termination marker.
+
+	start *chess.Position
+	root  *Node
+}
+
+// Node is a position of a tree, and the move that reached it from its parent,
+// with its annotations.
+type Node struct {
+	Move chess.Move // The move from the parent, or the zero Move for the root.
+
+	// Annotations of the move, as in pgn.Move. The comments of the root are
+	// the comments before the first move.
+	Before   []string
+	NAGs     []int
+	Comments []string
+	Commands pgn.Commands
+
+	tree     *Tree
+	parent   *Node
+	children []*Node
+}
+
+// New returns a tree with no moves, starting from start.
+func New(start *chess.Position) *Tree {
+	t := &Tree{start: start.Clone()}
+	t.root = &Node{tree: t}
+	return t
+}
+
+// FromPGN returns the tree of g, with its variations as variations of the
+// tree.
+func FromPGN(g *pgn.Game) (*Tree, error) {
+	start, err := g.StartingPosition()
+	if err != nil {
+		return nil, fmt.Errorf("gametree: %w", err)
+	}
+	t := New(start)
+	t.Tags = append([]pgn.Tag(nil), g.Tags...)
+	t.Result = g.Result
+	t.root.Comments = append([]string(nil), g.Comments...)
+	if err := t.root.addLine(start, g.Moves); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// addLine adds the line of moves played from n, whose position is pos, and
+// their variations. pos is left as it was.
+func (n *Node) addLine(pos *chess.Position, moves []*pgn.Move) error {
+	applied := 0
+	defer func() {
+		for ; applied > 0; applied-- {
+			pos.Unapply()
+		}
+	}()
+	for _, m := range moves {
+		if !pos.IsLegal(m.Move) {
+			return fmt.Errorf("gametree: illegal move %v in position %v", m.Move, pos)
+		}
+		child := n.newChild(m.Move)
+		child.Before = append([]string(nil), m.Before...)
+		child.NAGs = append([]int(nil), m.NAGs...)
+		child.Comments = append([]string(nil), m.Comments...)
+		child.Commands = m.Commands
+		for _, v := range m.Variations {
+			if err := n.addLine(pos, v); err != nil {
+				return err
+			}
+		}
+		pos.Apply(m.Move)
+		applied++
+		n = child
+	}
+	return nil
+}
+
+// newChild appends a child of n, without checking the move.
+func (n *Node) newChild(m chess.Move) *Node {
+	c := &Node{Move: m, tree: n.tree, parent: n}
+	n.children = append(n.children, c)
+	return c
+}
+
+// PGN returns the tree as a game, with the main line of the tree as its main
+// line.
+func (t *Tree) PGN() *pgn.Game {
+	return &pgn.Game{
+		Tags:     append([]pgn.Tag(nil), t.Tags...),
+		Comments: append([]string(nil), t.root.Comments...),
+		Moves:    t.root.line(),
+		Result:   t.Result,
+	}
+}
+
+// line returns the main line from n as PGN moves, with the other children
+// along it as variations.
+func (n *Node) line() []*pgn.Move {
+	var line []*pgn.Move
+	for ; len(n.children) > 0; n = n.children[0] {
+		main := n.children[0]
+		m := main.pgnMove()
+		for _, v := range n.children[1:] {
+			m.Variations = append(m.Variations, append([]*pgn.Move{v.pgnMove()}, v.line()...))
+		}
+		line = append(line, m)
+	}
+	return line
+}
+
+// pgnMove returns the move of n with its annotations.
+func (n *Node) pgnMove() *pgn.Move {
+	return &pgn.Move{
+		Move:     n.Move,
+		Before:   append([]string(nil), n.Before...),
+		NAGs:     append([]int(nil), n.NAGs...),
+		Comments: append([]string(nil), n.Comments...),
+		Commands: n.Commands,
+	}
+}
+
+// StartingPosition returns a copy of the position the tree starts from.
+func (t *Tree) StartingPosition() *chess.Position {
+	return t.start.Clone()
+}
+
+// Root returns the root of the tree, the starting position.
+func (t *Tree) Root() *Node {
+	return t.root
+}
+
+// MainLine returns the nodes of the main line, after the root.
+func (t *Tree) MainLine() []*Node {
+	return t.root.MainLine()
+}
+
+// Merge adds the lines of other to t, as by Node.Merge. Both trees must start
+// from the same position.
+func (t *Tree) Merge(other *Tree) error {
+	if t.start.Hash() != other.start.Hash() {
+		return errors.New("gametree: trees start from different positions")
+	}
+	t.root.Merge(other.root)
+	return nil
+}
+
+// Parent returns the parent of n, which is the position before its move, or
+// nil for the root.
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// Children returns the children of n: the main continuation, and then the
+// variations on it.
+func (n *Node) Children() []*Node {
+	return append([]*Node(nil), n.children...)
+}
+
+// Next returns the main continuation of n, or nil if there is none.
+func (n *Node) Next() *Node {
+	if len(n.children) == 0 {
+		return nil
+	}
+	return n.children[0]
+}
+
+// NextSibling returns the next variation on the move of n, or nil if there is
+// none.
+func (n *Node) NextSibling() *Node {
+	if i := n.index(); i >= 0 && i+1 < len(n.parent.children) {
+		return n.parent.children[i+1]
+	}
+	return nil
+}
+
+// PrevSibling returns the previous variation on the move of n, which is the
+// main continuation for the first variation, or nil if there is none.
+func (n *Node) PrevSibling() *Node {
+	if i := n.index(); i > 0 {
+		return n.parent.children[i-1]
+	}
+	return nil
+}
+
+// index returns the index of n in the children of its parent, or -1 for the
+// root.
+func (n *Node) index() int {
+	if n.parent == nil {
+		return -1
+	}
+	for i, c := range n.parent.children {
+		if c == n {
+			return i
+		}
+	}
+	panic("gametree: node not among its parent's children")
+}
+
+// MainLine returns the nodes of the main continuation from n, after n.
+func (n *Node) MainLine() []*Node {
+	var line []*Node
+	for n = n.Next(); n != nil; n = n.Next() {
+		line = append(line, n)
+	}
+	return line
+}
+
+// IsMainLine reports whether n is on the main line of its tree.
+func (n *Node) IsMainLine() bool {
+	for ; n.parent != nil; n = n.parent {
+		if n.index() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Ply returns the number of moves from the root to n.
+func (n *Node) Ply() int {
+	ply := 0
+	for ; n.parent != nil; n = n.parent {
+		ply++
+	}
+	return ply
+}
+
+// Path returns the moves from the root to n.
+func (n *Node) Path() []chess.Move {
+	moves := make([]chess.Move, n.Ply())
+	for i := len(moves) - 1; i >= 0; i-- {
+		moves[i] = n.Move
+		n = n.parent
+	}
+	return moves
+}
+
+// Position returns the position of n.
+func (n *Node) Position() *chess.Position {
+	pos := n.tree.StartingPosition()
+	for _, m := range n.Path() {
+		pos.Apply(m)
+	}
+	return pos
+}
+
+// Child returns the child of n reached by m, or nil if there is none.
+func (n *Node) Child(m chess.Move) *Node {
+	for _, c := range n.children {
+		if c.Move == m {
+			return c
+		}
+	}
+	return nil
+}
+
+// Add returns the child of n reached by m, adding it as the last variation if
+// there is none yet, or as the main continuation if n has no children. It
+// fails if m is illegal.
+func (n *Node) Add(m chess.Move) (*Node, error) {
+	if c := n.Child(m); c != nil {
+		return c, nil
+	}
+	if pos := n.Position(); !pos.IsLegal(m) {
+		return nil, fmt.Errorf("gametree: illegal move %v in position %v", m, pos)
+	}
+	return n.newChild(m), nil
+}
+
+// Promote moves n up one place among its siblings, so that the first
+// variation becomes the main continuation. It does nothing if n is the main
+// continuation or the root.
+func (n *Node) Promote() {
+	if i := n.index(); i > 0 {
+		s := n.parent.children
+		s[i-1], s[i] = s[i], s[i-1]
+	}
+}
+
+// Demote moves n down one place among its siblings. It does nothing if n is
+// the last variation or the root.
+func (n *Node) Demote() {
+	if i := n.index(); i >= 0 && i+1 < len(n.parent.children) {
+		s := n.parent.children
+		s[i], s[i+1] = s[i+1], s[i]
+	}
+}
+
+// PromoteToMainLine makes the line to n the main line of the tree, by making n
+// and each of its ancestors the main continuation of its parent. The former
+// main continuations become the first variations.
+func (n *Node) PromoteToMainLine() {
+	for ; n.parent != nil; n = n.parent {
+		i := n.index()
+		s := n.parent.children
+		copy(s[1:i+1], s[:i])
+		s[0] = n
+	}
+}
+
+// Remove removes n and the moves after it from the tree. It does nothing for
+// the root. n must not be used afterwards.
+func (n *Node) Remove() {
+	i := n.index()
+	if i < 0 {
+		return
+	}
+	s := n.parent.children
+	n.parent.children = append(s[:i:i], s[i+1:]...)
+	n.parent = nil
+}
+
+// RemoveChildren removes the moves after n.
+func (n *Node) RemoveChildren() {
+	for _, c := range n.children {
+		c.parent = nil
+	}
+	n.children = nil
+}
+
+// RemoveVariations removes the variations on the main continuation of n, and
+// on the main continuations after it.
+func (n *Node) RemoveVariations() {
+	for ; len(n.children) > 0; n = n.children[0] {
+		for _, c := range n.children[1:] {
+			c.parent = nil
+		}
+		n.children = n.children[:1]
+	}
+}
+
+// Merge adds the moves after other to the moves after n, which must be the
+// same position. Moves that n already has are merged with their annotations,
+// and new moves are added as variations, in order. other is left as it was.
+func (n *Node) Merge(other *Node) {
+	for _, oc := range other.children {
+		c := n.Child(oc.Move)
+		if c == nil {
+			c = n.newChild(oc.Move)
+		}
+		c.mergeAnnotations(oc)
+		c.Merge(oc)
+	}
+}
+
+// mergeAnnotations adds the annotations of other that n doesn't have to n.
+func (n *Node) mergeAnnotations(other *Node) {
+	n.Before = appendMissing(n.Before, other.Before)
+	n.NAGs = appendMissing(n.NAGs, other.NAGs)
+	n.Comments = appendMissing(n.Comments, other.Comments)
+	c, oc := &n.Commands, &other.Commands
+	if !c.HasClock {
+		c.Clock, c.HasClock = oc.Clock, oc.HasClock
+	}
+	if c.Eval == nil {
+		c.Eval = oc.Eval
+	}
+	if len(c.Highlights) == 0 {
+		c.Highlights = append(c.Highlights, oc.Highlights...)
+	}
+	if len(c.Arrows) == 0 {
+		c.Arrows = append(c.Arrows, oc.Arrows...)
+	}
+}
+
+// appendMissing appends the elements of b that aren't in a to a.
+func appendMissing[T comparable](a, b []T) []T {
+outer:
+	for _, x := range b {
+		for _, y := range a {
+			if x == y {
+				continue outer
+			}
+		}
+		a = append(a, x)
+	}
+	return a
+}
+
+// MergeDuplicates merges the children of n, and of every node after it, that
+// have the same move, such as a variation that repeats the main continuation.
+// The first of them keeps its place.
+func (n *Node) MergeDuplicates() {
+	var kept []*Node
+	for _, c := range n.children {
+		if k := findMove(kept, c.Move); k != nil {
+			k.mergeAnnotations(c)
+			k.children = append(k.children, c.children...)
+			for _, gc := range c.children {
+				gc.parent = k
+			}
+			c.parent, c.children = nil, nil
+			continue
+		}
+		kept = append(kept, c)
+	}
+	n.children = kept
+	for _, c := range n.children {
+		c.MergeDuplicates()
+	}
+}
+
+func findMove(nodes []*Node, m chess.Move) *Node {
+	for _, n := range nodes {
+		if n.Move == m {
+			return n
+		}
+	}
+	return nil
+}
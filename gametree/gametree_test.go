@@ -0,0 +1,201 @@
+package gametree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/pgn"
+	"github.com/google/go-cmp/cmp"
+)
+
+func mustRead(t *testing.T, s string) *Tree {
+	t.Helper()
+	g, err := pgn.NewReader(strings.NewReader(s)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := FromPGN(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+// movetext returns the movetext of the tree as written by pgn.Writer, on one
+// line.
+func movetext(t *testing.T, tree *Tree) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := pgn.NewWriter(&b).Write(tree.PGN()); err != nil {
+		t.Fatal(err)
+	}
+	_, text, _ := strings.Cut(b.String(), "\n\n")
+	text = strings.Join(strings.Fields(text), " ")
+	// Undo the space added where a line was wrapped before a ")".
+	return strings.ReplaceAll(text, " )", ")")
+}
+
+func mustMove(t *testing.T, s string) chess.Move {
+	t.Helper()
+	m, err := chess.ParseMove(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+const game = `[Event "Test"]
+
+{Start} 1. e4 $1 {[%clk 0:03:00]} (1. d4 d5 (1... Nf6) 2. c4) 1... e5 (1... c5 {Sicilian}) 2. Nf3 *
+`
+
+func TestFromPGN_RoundTrip(t *testing.T) {
+	tree := mustRead(t, game)
+	want := "{Start} 1. e4 $1 {[%clk 0:03:00]} (1. d4 d5 (1... Nf6) 2. c4) 1... e5 (1... c5 {Sicilian}) 2. Nf3 *"
+	if got := movetext(t, tree); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := tree.PGN().Tag("Event"); got != "Test" {
+		t.Errorf("Event tag: got %q", got)
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	tree := mustRead(t, game)
+	root := tree.Root()
+	e4 := root.Next()
+	if e4.Move != mustMove(t, "e2e4") || e4.Parent() != root {
+		t.Fatalf("first move: got %v", e4.Move)
+	}
+	d4 := e4.NextSibling()
+	if d4 == nil || d4.Move != mustMove(t, "d2d4") || d4.PrevSibling() != e4 || d4.NextSibling() != nil {
+		t.Fatal("wrong siblings of 1. e4")
+	}
+	if root.NextSibling() != nil || root.PrevSibling() != nil || root.Parent() != nil {
+		t.Error("the root has relatives")
+	}
+
+	var main []string
+	for _, n := range tree.MainLine() {
+		main = append(main, n.Move.String())
+	}
+	if diff := cmp.Diff([]string{"e2e4", "e7e5", "g1f3"}, main); diff != "" {
+		t.Errorf("main line mismatch (-want +got):\n%s", diff)
+	}
+
+	nf6 := d4.Next().NextSibling()
+	if nf6.Ply() != 2 || nf6.IsMainLine() || !e4.Next().IsMainLine() {
+		t.Error("wrong ply or main line status of 1... Nf6")
+	}
+	if diff := cmp.Diff([]chess.Move{mustMove(t, "d2d4"), mustMove(t, "g8f6")}, nf6.Path()); diff != "" {
+		t.Errorf("path mismatch (-want +got):\n%s", diff)
+	}
+	want := "rnbqkb1r/pppppppp/5n2/8/3P4/8/PPP1PPPP/RNBQKBNR w KQkq - 1 2"
+	if got := nf6.Position().String(); got != want {
+		t.Errorf("position: got %s, want %s", got, want)
+	}
+	if c := nf6.Children(); len(c) != 0 {
+		t.Errorf("got %d children after 1... Nf6", len(c))
+	}
+}
+
+func TestNode_Add(t *testing.T) {
+	tree := New(chess.StartingPosition())
+	e4, err := tree.Root().Add(mustMove(t, "e2e4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := tree.Root().Add(mustMove(t, "e2e4")); again != e4 {
+		t.Error("adding an existing move made a new node")
+	}
+	if _, err := e4.Add(mustMove(t, "e2e4")); err == nil {
+		t.Error("added an illegal move")
+	}
+	if _, err := tree.Root().Add(mustMove(t, "d2d4")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := movetext(t, tree), "1. e4 (1. d4) *"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNode_Promote(t *testing.T) {
+	tree := mustRead(t, game)
+	nf6 := tree.Root().Next().NextSibling().Next().NextSibling()
+	nf6.PromoteToMainLine()
+	want := "{Start} 1. d4 (1. e4 $1 {[%clk 0:03:00]} 1... e5 (1... c5 {Sicilian}) 2. Nf3) 1... Nf6 (1... d5 2. c4) *"
+	if got := movetext(t, tree); got != want {
+		t.Errorf("after PromoteToMainLine:\ngot  %q\nwant %q", got, want)
+	}
+
+	nf6.Demote()
+	nf6.Demote() // No effect on the last variation.
+	tree.Root().Next().Demote()
+	tree.Root().Promote() // No effect on the root.
+	want = "{Start} 1. e4 $1 {[%clk 0:03:00]} (1. d4 d5 (1... Nf6) 2. c4) 1... e5 (1... c5 {Sicilian}) 2. Nf3 *"
+	if got := movetext(t, tree); got != want {
+		t.Errorf("after Demote:\ngot  %q\nwant %q", got, want)
+	}
+
+	c5 := tree.Root().Next().Next().NextSibling()
+	c5.Promote()
+	want = "{Start} 1. e4 $1 {[%clk 0:03:00]} (1. d4 d5 (1... Nf6) 2. c4) 1... c5 {Sicilian} (1... e5 2. Nf3) *"
+	if got := movetext(t, tree); got != want {
+		t.Errorf("after Promote:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestNode_Remove(t *testing.T) {
+	tree := mustRead(t, game)
+	d4 := tree.Root().Next().NextSibling()
+	d4.Next().NextSibling().Remove()
+	if got, want := movetext(t, tree), "{Start} 1. e4 $1 {[%clk 0:03:00]} (1. d4 d5 2. c4) 1... e5 (1... c5 {Sicilian}) 2. Nf3 *"; got != want {
+		t.Errorf("after Remove:\ngot  %q\nwant %q", got, want)
+	}
+	tree.Root().Remove() // No effect on the root.
+
+	tree.Root().RemoveVariations()
+	if got, want := movetext(t, tree), "{Start} 1. e4 $1 {[%clk 0:03:00]} 1... e5 2. Nf3 *"; got != want {
+		t.Errorf("after RemoveVariations:\ngot  %q\nwant %q", got, want)
+	}
+
+	tree.Root().Next().RemoveChildren()
+	if got, want := movetext(t, tree), "{Start} 1. e4 $1 {[%clk 0:03:00]} *"; got != want {
+		t.Errorf("after RemoveChildren:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestTree_Merge(t *testing.T) {
+	tree := mustRead(t, "1. e4 e5 2. Nf3 *")
+	other := mustRead(t, "1. e4 $1 {Best} c5 2. Nf3 (2. c3) *")
+	if err := tree.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	want := "1. e4 $1 {Best} 1... e5 (1... c5 2. Nf3 (2. c3)) 2. Nf3 *"
+	if got := movetext(t, tree); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if got, want := movetext(t, other), "1. e4 $1 {Best} 1... c5 2. Nf3 (2. c3) *"; got != want {
+		t.Errorf("other changed to %q", got)
+	}
+
+	fromFEN := mustRead(t, "[FEN \"4k3/8/8/8/8/8/8/4K3 w - - 0 1\"]\n\n1. Kd2 *")
+	if err := tree.Merge(fromFEN); err == nil {
+		t.Error("merged trees from different positions")
+	}
+}
+
+func TestNode_MergeDuplicates(t *testing.T) {
+	tree := mustRead(t, "1. e4 {A} (1. d4) (1. e4 {B} c5) 1... e5 (1... e6) *")
+	tree.Root().MergeDuplicates()
+	want := "1. e4 {A} {B} (1. d4) 1... e5 (1... e6) (1... c5) *"
+	if got := movetext(t, tree); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	c5 := tree.Root().Next().Next().NextSibling().NextSibling()
+	if c5.Parent() != tree.Root().Next() {
+		t.Error("merged child has the wrong parent")
+	}
+}
@@ -2,4 +2,7 @@ module github.com/clfs/chess
 
 go 1.18
 
-require github.com/google/go-cmp v0.5.8
+require (
+	github.com/google/go-cmp v0.5.8
+	golang.org/x/crypto v0.9.0
+)
@@ -0,0 +1,92 @@
+// Package relay delivers the progress and result of a running engine search
+// to a consumer. It is shared by the uci and xboard clients, and isn't part of
+// the module's stable API.
+package relay
+
+import (
+	"context"
+	"sync"
+)
+
+// Relay delivers the information of type I reported during a search, and its
+// result of type R. Information is queued so that a slow consumer never blocks
+// the goroutine reading from the engine.
+type Relay[I, R any] struct {
+	Info   chan I        // Receives information in order.
+	Result chan R        // Receives the result, if there is one.
+	Done   chan struct{} // Closed after Info and Result are closed.
+
+	mu       sync.Mutex
+	pending  []I
+	result   *R
+	finished bool
+	wake     chan struct{}
+}
+
+// New returns a relay for a search that has just started.
+func New[I, R any]() *Relay[I, R] {
+	return &Relay[I, R]{
+		Info:   make(chan I),
+		Result: make(chan R, 1),
+		Done:   make(chan struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+func (r *Relay[I, R]) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Queue adds info to the queue of search information.
+func (r *Relay[I, R]) Queue(info I) {
+	r.mu.Lock()
+	r.pending = append(r.pending, info)
+	r.mu.Unlock()
+	r.notify()
+}
+
+// Finish ends the search. If result is nil, the search ended without a
+// result.
+func (r *Relay[I, R]) Finish(result *R) {
+	r.mu.Lock()
+	r.result = result
+	r.finished = true
+	r.mu.Unlock()
+	r.notify()
+}
+
+// Forward delivers queued search information until the search is finished,
+// then sends the result and closes the channels. Information queued after ctx
+// is done is discarded.
+func (r *Relay[I, R]) Forward(ctx context.Context) {
+	defer close(r.Done)
+	defer close(r.Info)
+	defer close(r.Result)
+
+	for {
+		r.mu.Lock()
+		pending, finished, result := r.pending, r.finished, r.result
+		r.pending = nil
+		r.mu.Unlock()
+
+		for _, info := range pending {
+			select {
+			case r.Info <- info:
+			case <-ctx.Done():
+			}
+		}
+		if len(pending) > 0 {
+			continue
+		}
+		if finished {
+			if result != nil {
+				r.Result <- *result
+			}
+			return
+		}
+		<-r.wake
+	}
+}
@@ -0,0 +1,55 @@
+package relay
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRelay(t *testing.T) {
+	r := New[int, string]()
+	go r.Forward(context.Background())
+
+	r.Queue(1)
+	r.Queue(2)
+	result := "done"
+	r.Finish(&result)
+
+	var got []int
+	for n := range r.Info {
+		got = append(got, n)
+	}
+	if diff := cmp.Diff([]int{1, 2}, got); diff != "" {
+		t.Errorf("info mismatch (-want +got):\n%s", diff)
+	}
+	if s, ok := <-r.Result; !ok || s != "done" {
+		t.Errorf("want result %q, got %q", "done", s)
+	}
+	<-r.Done
+}
+
+func TestRelay_NoResult(t *testing.T) {
+	r := New[int, string]()
+	go r.Forward(context.Background())
+	r.Finish(nil)
+	for range r.Info {
+	}
+	if _, ok := <-r.Result; ok {
+		t.Error("got a result from a search without one")
+	}
+	<-r.Done
+}
+
+func TestRelay_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := New[int, string]()
+	go r.Forward(ctx)
+
+	// Nobody reads the information, but the search still finishes.
+	r.Queue(1)
+	r.Queue(2)
+	cancel()
+	r.Finish(nil)
+	<-r.Done
+}
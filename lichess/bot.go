@@ -0,0 +1,424 @@
+package lichess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// Bot plays games on Lichess with a UCI engine.
+type Bot struct {
+	Client *Client
+
+	// Start starts an instance of the engine for a game. The client must have
+	// completed the "uci" handshake, and its options must be set. The closer
+	// is called when the game ends.
+	Start func() (*uci.Client, io.Closer, error)
+
+	// Accept decides whether to accept a challenge, returning the reason for
+	// declining it, or "" to accept it. If nil, challenges in standard chess,
+	// and from a position, are accepted, and others are declined.
+	Accept func(*Challenge) DeclineReason
+
+	// MaxGames is the number of games to play at once. Further challenges
+	// are declined with DeclineLater. Zero means 1.
+	MaxGames int
+
+	// Search holds the limits for each move in games without a clock. If it
+	// is zero, each move is searched for 10 seconds.
+	Search uci.Search
+
+	// Overhead is deducted from the bot's time when passing it to the engine,
+	// to allow for network delays.
+	Overhead time.Duration
+
+	// The bot resigns once the engine's score has been at most -ResignScore
+	// centipawns for ResignMoves of its moves in a row. Zero ResignMoves
+	// means it never resigns.
+	ResignScore, ResignMoves int
+
+	// The bot offers and accepts draws once the engine's score has been
+	// within DrawScore centipawns of zero for DrawMoves of its moves in a
+	// row. Zero DrawMoves means it never does.
+	DrawScore, DrawMoves int
+
+	// OnGame, if non-nil, is called when the bot stops playing a game that
+	// Run started, with the error that stopped it if the game didn't end.
+	// Calls are serialized.
+	OnGame func(id string, err error)
+
+	mu   sync.Mutex
+	self string // The bot's user ID, once known.
+}
+
+// mateScore is the score, in centipawns, of a mate.
+const mateScore = 100000
+
+// Run answers challenges and plays games until ctx is done or the stream of
+// events fails, then waits for the games in progress to stop. Games that are
+// already in progress are resumed. Run returns ctx.Err() if ctx is done, and
+// otherwise the error that stopped it.
+func (b *Bot) Run(ctx context.Context) error {
+	self, err := b.selfID(ctx)
+	if err != nil {
+		return err
+	}
+	events, err := b.Client.StreamEvents(ctx)
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex              // Guards active and playing.
+		active  = make(map[string]bool) // Accepted challenges and games in progress.
+		playing = make(map[string]bool) // Games in progress.
+	)
+	defer wg.Wait()
+	for {
+		ev, err := events.Next()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == io.EOF {
+			return errors.New("lichess: event stream ended")
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case ev.Type == "challenge" && ev.Challenge != nil:
+			ch := ev.Challenge
+			if ch.Challenger.ID == self {
+				continue // The bot's own challenge.
+			}
+			reason := b.accept(ch)
+			mu.Lock()
+			if reason == "" && len(active) >= b.maxGames() {
+				reason = DeclineLater
+			}
+			if reason == "" {
+				active[ch.ID] = true
+			}
+			mu.Unlock()
+
+			// Errors are ignored: the challenge may have been canceled in
+			// the meantime.
+			if reason != "" {
+				b.Client.DeclineChallenge(ctx, ch.ID, reason)
+			} else if b.Client.AcceptChallenge(ctx, ch.ID) != nil {
+				mu.Lock()
+				delete(active, ch.ID)
+				mu.Unlock()
+			}
+
+		case ev.Type == "challengeCanceled" && ev.Challenge != nil:
+			mu.Lock()
+			if !playing[ev.Challenge.ID] {
+				delete(active, ev.Challenge.ID)
+			}
+			mu.Unlock()
+
+		case ev.Type == "gameStart" && ev.Game != nil:
+			id := ev.Game.ID
+			mu.Lock()
+			if playing[id] {
+				mu.Unlock()
+				continue
+			}
+			active[id], playing[id] = true, true
+			mu.Unlock()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := b.Play(ctx, id)
+				mu.Lock()
+				delete(active, id)
+				delete(playing, id)
+				mu.Unlock()
+				if ctx.Err() == nil && b.OnGame != nil {
+					b.mu.Lock()
+					b.OnGame(id, err)
+					b.mu.Unlock()
+				}
+			}()
+		}
+	}
+}
+
+// Play plays the game with the given ID, which the bot must be playing in,
+// with a new instance of the engine. It returns nil when the game ends, and
+// otherwise the error that stopped it.
+func (b *Bot) Play(ctx context.Context, gameID string) error {
+	self, err := b.selfID(ctx)
+	if err != nil {
+		return err
+	}
+	gs, err := b.Client.StreamGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	defer gs.Close()
+
+	ev, err := gs.Next()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	if ev.Full == nil {
+		return fmt.Errorf("lichess: game %s: stream starts with %q", gameID, ev.Type)
+	}
+	g := &game{bot: b, id: gameID, full: ev.Full, thought: -1, answered: -1}
+	switch self {
+	case g.full.White.ID:
+		g.color = chess.White
+	case g.full.Black.ID:
+		g.color = chess.Black
+	default:
+		return fmt.Errorf("lichess: game %s: not playing", gameID)
+	}
+	if g.start, err = g.full.StartingPosition(); err != nil {
+		return err
+	}
+
+	engine, closer, err := b.Start()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	if err := engine.UCINewGame(); err != nil {
+		return err
+	}
+	if err := engine.IsReadyContext(ctx); err != nil {
+		return err
+	}
+	g.engine = engine
+
+	state := &g.full.State
+	for {
+		if state.Status != "created" && state.Status != "started" {
+			return nil
+		}
+		if err := g.update(ctx, state); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		ev, err := gs.Next()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == io.EOF {
+			return fmt.Errorf("lichess: game %s: stream ended during the game", gameID)
+		}
+		if err != nil {
+			return err
+		}
+		if ev.State != nil {
+			state = ev.State
+		}
+	}
+}
+
+// selfID returns the bot's user ID.
+func (b *Bot) selfID(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	self := b.self
+	b.mu.Unlock()
+	if self != "" {
+		return self, nil
+	}
+	acct, err := b.Client.Account(ctx)
+	if err != nil {
+		return "", err
+	}
+	b.mu.Lock()
+	b.self = acct.ID
+	b.mu.Unlock()
+	return acct.ID, nil
+}
+
+func (b *Bot) accept(ch *Challenge) DeclineReason {
+	if b.Accept != nil {
+		return b.Accept(ch)
+	}
+	switch ch.Variant.Key {
+	case "standard", "fromPosition":
+		return ""
+	}
+	return DeclineVariant
+}
+
+func (b *Bot) maxGames() int {
+	if b.MaxGames <= 0 {
+		return 1
+	}
+	return b.MaxGames
+}
+
+// search returns the limits for a move by the given side in state.
+func (b *Bot) search(full *GameFull, state *GameState, side chess.Color) uci.Search {
+	if full.Clock == nil {
+		if reflect.DeepEqual(b.Search, uci.Search{}) {
+			return uci.Search{MoveTime: 10 * time.Second}
+		}
+		return b.Search
+	}
+	s := state.Search()
+	left := &s.WhiteTime
+	if side == chess.Black {
+		left = &s.BlackTime
+	}
+	// Zero would mean no limit.
+	if *left -= b.Overhead; *left < time.Millisecond {
+		*left = time.Millisecond
+	}
+	if s.WhiteTime <= 0 {
+		s.WhiteTime = time.Millisecond
+	}
+	if s.BlackTime <= 0 {
+		s.BlackTime = time.Millisecond
+	}
+	return s
+}
+
+// game is a game the bot is playing.
+type game struct {
+	bot    *Bot
+	id     string
+	full   *GameFull
+	color  chess.Color // The bot's side.
+	start  *chess.Position
+	engine *uci.Client
+
+	scores   []int // The engine's score after each of its moves, for its side.
+	thought  int   // The number of moves in the position the engine last searched.
+	answered int   // The number of moves when the last draw offer was answered.
+}
+
+// update responds to the state of the game: it answers draw offers, and moves
+// when it is the bot's turn.
+func (g *game) update(ctx context.Context, state *GameState) error {
+	moves, err := state.MoveList()
+	if err != nil {
+		return err
+	}
+	pos := g.start.Clone()
+	for _, m := range moves {
+		if !pos.IsLegal(m) {
+			return fmt.Errorf("lichess: game %s: illegal move %v", g.id, m)
+		}
+		pos.Apply(m)
+	}
+
+	offered := state.BDraw
+	if g.color == chess.Black {
+		offered = state.WDraw
+	}
+	if offered && g.answered != len(moves) {
+		g.answered = len(moves)
+		accept := g.drawish()
+		if err := g.bot.Client.HandleDrawOffer(ctx, g.id, accept); err != nil {
+			return err
+		}
+		if accept {
+			return nil
+		}
+	}
+
+	if pos.SideToMove() != g.color || g.thought == len(moves) {
+		return nil
+	}
+	g.thought = len(moves)
+	m, err := g.think(ctx, moves, state)
+	if err != nil {
+		return err
+	}
+
+	if g.hopeless() {
+		return g.bot.Client.Resign(ctx, g.id)
+	}
+	return g.bot.Client.Move(ctx, g.id, m, g.drawish())
+}
+
+// think has the engine search the position after moves, and returns its best
+// move.
+func (g *game) think(ctx context.Context, moves []chess.Move, state *GameState) (chess.Move, error) {
+	params := uci.PositionParams{StartPos: true, Moves: moves}
+	if f := g.full.InitialFEN; f != "" && f != "startpos" {
+		params = uci.PositionParams{FEN: f, Moves: moves}
+	}
+	if err := g.engine.Position(params); err != nil {
+		return chess.Move{}, err
+	}
+	infoCh, bestCh, err := g.engine.GoContext(ctx, g.bot.search(g.full, state, g.color))
+	if err != nil {
+		return chess.Move{}, err
+	}
+	score, scored := 0, false
+	for info := range infoCh {
+		if len(info.PV) > 0 && info.MultiPV <= 1 {
+			score, scored = centipawns(info.Score), true
+		}
+	}
+	bm, ok := <-bestCh
+	if !ok {
+		if ctx.Err() != nil {
+			return chess.Move{}, ctx.Err()
+		}
+		return chess.Move{}, fmt.Errorf("lichess: game %s: no best move", g.id)
+	}
+	if scored {
+		g.scores = append(g.scores, score)
+	}
+	return bm.Move, nil
+}
+
+// hopeless reports whether the bot should resign.
+func (g *game) hopeless() bool {
+	return g.lastScores(g.bot.ResignMoves, func(cp int) bool { return cp <= -g.bot.ResignScore })
+}
+
+// drawish reports whether the bot should agree to a draw.
+func (g *game) drawish() bool {
+	return g.lastScores(g.bot.DrawMoves, func(cp int) bool { return -g.bot.DrawScore <= cp && cp <= g.bot.DrawScore })
+}
+
+// lastScores reports whether there are at least n scores, and the last n all
+// satisfy f. It is false if n is zero.
+func (g *game) lastScores(n int, f func(cp int) bool) bool {
+	if n <= 0 || len(g.scores) < n {
+		return false
+	}
+	for _, cp := range g.scores[len(g.scores)-n:] {
+		if !f(cp) {
+			return false
+		}
+	}
+	return true
+}
+
+// centipawns returns s in centipawns, counting a mate as mateScore.
+func centipawns(s uci.Score) int {
+	switch {
+	case s.Mate.Found && s.Mate.MovesUntil > 0:
+		return mateScore
+	case s.Mate.Found:
+		return -mateScore
+	}
+	return s.CP
+}
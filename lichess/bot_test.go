@@ -0,0 +1,277 @@
+package lichess
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+// refStart starts the reference engine, searching to depth.
+func refStart(depth int) func() (*uci.Client, io.Closer, error) {
+	return func() (*uci.Client, io.Closer, error) {
+		c, closer, err := refengine.New().Client()
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, _, _, err := c.UCI(); err != nil {
+			closer.Close()
+			return nil, nil, err
+		}
+		if err := c.SetOption("Depth", fmt.Sprint(depth)); err != nil {
+			closer.Close()
+			return nil, nil, err
+		}
+		return c, closer, nil
+	}
+}
+
+func gameFull(id, white, black, fen, moves string) string {
+	return fmt.Sprintf(`{"type":"gameFull","id":%q,"variant":{"key":"standard"},"clock":{"initial":60000,"increment":1000},"white":{"id":%q},"black":{"id":%q},"initialFen":%q,"state":%s}`,
+		id, white, black, fen, gameState(moves, "started", ""))
+}
+
+func gameState(moves, status, extra string) string {
+	return fmt.Sprintf(`{"type":"gameState","moves":%q,"wtime":60000,"btime":60000,"winc":1000,"binc":1000,"status":%q%s}`, moves, status, extra)
+}
+
+// movePath parses the path of a move request.
+func movePath(path string) (id string, m chess.Move, ok bool) {
+	rest := strings.TrimPrefix(path, "/api/bot/game/")
+	id, move, ok := strings.Cut(rest, "/move/")
+	if !ok || rest == path {
+		return "", chess.Move{}, false
+	}
+	m, err := chess.ParseMove(move)
+	return id, m, err == nil
+}
+
+func TestBot_Run(t *testing.T) {
+	f := newFakeServer(t)
+	ended := make(chan error, 1)
+	b := &Bot{
+		Client: f.client(),
+		Start:  refStart(1),
+		OnGame: func(id string, err error) {
+			if id != "c2" {
+				t.Errorf("OnGame: got game %s", id)
+			}
+			ended <- err
+		},
+	}
+
+	// The opponent plays its first legal move in reply to the bot's moves,
+	// and resigns after the bot's third.
+	pos := chess.StartingPosition()
+	var moves []string
+	f.onPost = func(r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/challenge/c2/accept":
+			f.events <- `{"type":"gameStart","game":{"gameId":"c2"}}`
+			f.game("c2") <- gameFull("c2", "bot", "alice", "startpos", "")
+		case strings.HasPrefix(r.URL.Path, "/api/bot/game/c2/move/"):
+			_, m, _ := movePath(r.URL.Path)
+			if !pos.IsLegal(m) {
+				t.Errorf("illegal move %v in %v", m, pos)
+				return
+			}
+			pos.Apply(m)
+			moves = append(moves, m.String())
+			if len(moves) == 5 {
+				f.game("c2") <- gameState(strings.Join(moves, " "), "resign", `,"winner":"white"`)
+				close(f.game("c2"))
+				return
+			}
+			reply := pos.LegalMoves()[0]
+			pos.Apply(reply)
+			moves = append(moves, reply.String())
+			f.game("c2") <- gameState(strings.Join(moves, " "), "started", "")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+	f.events <- `{"type":"challenge","challenge":{"id":"c0","challenger":{"id":"bob"},"variant":{"key":"chess960"}}}`
+	f.events <- `{"type":"challenge","challenge":{"id":"c1","challenger":{"id":"bot"},"variant":{"key":"standard"}}}`
+	f.events <- `{"type":"challenge","challenge":{"id":"c2","challenger":{"id":"alice"},"variant":{"key":"standard"}}}`
+
+	select {
+	case err := <-ended:
+		if err != nil {
+			t.Errorf("OnGame: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the game")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run: want context.Canceled, got %v", err)
+	}
+
+	posts := f.posts()
+	want := []string{
+		"POST /api/challenge/c0/decline reason=variant",
+		"POST /api/challenge/c2/accept",
+	}
+	if diff := cmp.Diff(want, posts[:2]); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if n := len(posts) - 2; n != 3 {
+		t.Errorf("want 3 moves, got %d: %v", n, posts)
+	}
+}
+
+func TestBot_Run_Busy(t *testing.T) {
+	f := newFakeServer(t)
+	b := &Bot{Client: f.client(), Start: refStart(1)}
+	accepted := make(chan bool, 1)
+	f.onPost = func(r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/decline") {
+			accepted <- false
+		} else if strings.HasSuffix(r.URL.Path, "/accept") {
+			accepted <- true
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+	f.events <- `{"type":"challenge","challenge":{"id":"c1","challenger":{"id":"alice"},"variant":{"key":"standard"}}}`
+	if !<-accepted {
+		t.Fatal("the first challenge was declined")
+	}
+	f.events <- `{"type":"challenge","challenge":{"id":"c2","challenger":{"id":"alice"},"variant":{"key":"standard"}}}`
+	if <-accepted {
+		t.Fatal("the second challenge was accepted")
+	}
+	if want := "POST /api/challenge/c2/decline reason=later"; f.posts()[1] != want {
+		t.Errorf("want %q, got %q", want, f.posts()[1])
+	}
+
+	// Once the first challenge is canceled, the bot is free.
+	f.events <- `{"type":"challengeCanceled","challenge":{"id":"c1"}}`
+	f.events <- `{"type":"challenge","challenge":{"id":"c3","challenger":{"id":"alice"},"variant":{"key":"standard"}}}`
+	if !<-accepted {
+		t.Fatal("the third challenge was declined")
+	}
+}
+
+func TestBot_Play_Draw(t *testing.T) {
+	f := newFakeServer(t)
+	b := &Bot{Client: f.client(), Start: refStart(1), DrawScore: 1000, DrawMoves: 1}
+	f.onPost = func(r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/move/"):
+			_, m, _ := movePath(r.URL.Path)
+			f.game("g1") <- gameState("e2e4 "+m.String()+" d2d4", "started", `,"wdraw":true`)
+		case strings.HasSuffix(r.URL.Path, "/draw/yes"):
+			f.game("g1") <- gameState("e2e4", "draw", "")
+		}
+	}
+	// White offers a draw before the bot has a score, which it declines.
+	f.game("g1") <- strings.Replace(gameFull("g1", "alice", "bot", "startpos", "e2e4"), `"status":"started"`, `"status":"started","wdraw":true`, 1)
+	if err := b.Play(context.Background(), "g1"); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := f.posts()
+	if len(posts) != 3 {
+		t.Fatalf("want 3 requests, got %v", posts)
+	}
+	if want := "POST /api/bot/game/g1/draw/no"; posts[0] != want {
+		t.Errorf("want %q, got %q", want, posts[0])
+	}
+	if !strings.HasSuffix(posts[1], "?offeringDraw=true") {
+		t.Errorf("the move doesn't offer a draw: %q", posts[1])
+	}
+	if want := "POST /api/bot/game/g1/draw/yes"; posts[2] != want {
+		t.Errorf("want %q, got %q", want, posts[2])
+	}
+}
+
+func TestBot_Play_Resign(t *testing.T) {
+	f := newFakeServer(t)
+	b := &Bot{Client: f.client(), Start: refStart(1), ResignScore: 1000, ResignMoves: 1}
+	f.onPost = func(r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/resign") {
+			f.game("g1") <- gameState("", "resign", `,"winner":"black"`)
+		}
+	}
+	f.game("g1") <- gameFull("g1", "bot", "alice", "qqqqk3/8/8/8/8/8/8/4K3 w - - 0 1", "")
+	if err := b.Play(context.Background(), "g1"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"POST /api/bot/game/g1/resign"}, f.posts()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBot_Play_Errors(t *testing.T) {
+	f := newFakeServer(t)
+	b := &Bot{Client: f.client(), Start: refStart(1)}
+
+	f.game("g1") <- gameFull("g1", "alice", "carol", "startpos", "")
+	if err := b.Play(context.Background(), "g1"); err == nil {
+		t.Error("not playing: want error")
+	}
+
+	f.game("g2") <- gameFull("g2", "bot", "alice", "startpos", "e2e5")
+	if err := b.Play(context.Background(), "g2"); err == nil {
+		t.Error("illegal move: want error")
+	}
+
+	f.game("g3") <- gameFull("g3", "alice", "bot", "startpos", "")
+	close(f.game("g3"))
+	if err := b.Play(context.Background(), "g3"); err == nil {
+		t.Error("stream ended early: want error")
+	}
+}
+
+func TestBot_search(t *testing.T) {
+	clock := &GameFull{Clock: &GameClock{Initial: 60000, Increment: 1000}}
+	state := &GameState{WTime: 30000, BTime: 20, WInc: 1000, BInc: 1000}
+	cases := []struct {
+		bot   *Bot
+		full  *GameFull
+		side  chess.Color
+		state *GameState
+		want  uci.Search
+	}{
+		{
+			&Bot{Overhead: 100 * time.Millisecond}, clock, chess.White, state,
+			uci.Search{WhiteTime: 29900 * time.Millisecond, BlackTime: 20 * time.Millisecond, WhiteIncrement: time.Second, BlackIncrement: time.Second},
+		},
+		{
+			// The bot's time never drops to zero, which would mean no limit.
+			&Bot{Overhead: 100 * time.Millisecond}, clock, chess.Black, state,
+			uci.Search{WhiteTime: 30 * time.Second, BlackTime: time.Millisecond, WhiteIncrement: time.Second, BlackIncrement: time.Second},
+		},
+		{
+			&Bot{}, clock, chess.Black, &GameState{},
+			uci.Search{WhiteTime: time.Millisecond, BlackTime: time.Millisecond},
+		},
+		{
+			&Bot{}, &GameFull{}, chess.White, state,
+			uci.Search{MoveTime: 10 * time.Second},
+		},
+		{
+			&Bot{Search: uci.Search{Depth: 12}}, &GameFull{}, chess.White, state,
+			uci.Search{Depth: 12},
+		},
+	}
+	for i, tc := range cases {
+		got := tc.bot.search(tc.full, tc.state, tc.side)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("case %d mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// ErrNoCloudEval is returned by CloudEval when Lichess has no evaluation of
+// the position.
+var ErrNoCloudEval = errors.New("lichess: no cloud evaluation")
+
+// CloudEval is a cached engine evaluation of a position.
+type CloudEval struct {
+	FEN    string
+	KNodes int // Thousands of nodes searched.
+	Depth  int
+	PVs    []CloudPV // The best lines, best first.
+}
+
+// CloudPV is a line in a cloud evaluation.
+type CloudPV struct {
+	Moves []chess.Move
+
+	// Score is from the point of view of the side to move, like an engine's
+	// score in uci.Info, rather than White's, as Lichess sends it.
+	Score uci.Score
+}
+
+// CloudEval returns the cached evaluation of the position described by fen,
+// with up to multiPV lines; zero means one. It returns ErrNoCloudEval if there
+// is none.
+func (c *Client) CloudEval(ctx context.Context, fen string, multiPV int) (*CloudEval, error) {
+	pos, err := chess.ParseFEN(fen)
+	if err != nil {
+		return nil, fmt.Errorf("lichess: %w", err)
+	}
+	v := url.Values{"fen": {fen}}
+	if multiPV > 1 {
+		v.Set("multiPv", strconv.Itoa(multiPV))
+	}
+	resp, err := c.do(ctx, http.MethodGet, "/api/cloud-eval?"+v.Encode(), nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, ErrNoCloudEval
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var raw struct {
+		FEN    string `json:"fen"`
+		KNodes int    `json:"knodes"`
+		Depth  int    `json:"depth"`
+		PVs    []struct {
+			Moves string `json:"moves"`
+			CP    *int   `json:"cp"`
+			Mate  *int   `json:"mate"`
+		} `json:"pvs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("lichess: cloud eval: %w", err)
+	}
+
+	// Scores are from White's point of view.
+	sign := 1
+	if pos.SideToMove() == chess.Black {
+		sign = -1
+	}
+	e := &CloudEval{FEN: raw.FEN, KNodes: raw.KNodes, Depth: raw.Depth}
+	for _, rp := range raw.PVs {
+		var pv CloudPV
+		switch {
+		case rp.Mate != nil:
+			pv.Score.Mate.Found = true
+			pv.Score.Mate.MovesUntil = sign * *rp.Mate
+		case rp.CP != nil:
+			pv.Score.CP = sign * *rp.CP
+		}
+		p := pos.Clone()
+		for _, f := range strings.Fields(rp.Moves) {
+			m, err := parseMove(p, f)
+			if err != nil {
+				return nil, err
+			}
+			if !p.IsLegal(m) {
+				return nil, fmt.Errorf("lichess: cloud eval: illegal move %v in %v", m, p)
+			}
+			pv.Moves = append(pv.Moves, m)
+			p.Apply(m)
+		}
+		e.PVs = append(e.PVs, pv)
+	}
+	return e, nil
+}
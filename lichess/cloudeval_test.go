@@ -0,0 +1,67 @@
+package lichess
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_CloudEval(t *testing.T) {
+	const fen = "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3"
+	s, uris := jsonServer(t, http.StatusOK, `{"fen":"`+fen+`","knodes":1000,"depth":30,"pvs":[{"moves":"f1b5 g8f6 e1h1","cp":30},{"moves":"f1c4","mate":-12}]}`)
+	c := &Client{BaseURL: s.URL}
+	got, err := c.CloudEval(context.Background(), fen, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mated := uci.Score{}
+	mated.Mate.Found, mated.Mate.MovesUntil = true, -12
+	want := &CloudEval{
+		FEN: fen, KNodes: 1000, Depth: 30,
+		PVs: []CloudPV{
+			{Moves: []chess.Move{{From: chess.F1, To: chess.B5}, {From: chess.G8, To: chess.F6}, {From: chess.E1, To: chess.G1}}, Score: uci.Score{CP: 30}},
+			{Moves: []chess.Move{{From: chess.F1, To: chess.C4}}, Score: mated},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	wantURI := "/api/cloud-eval?fen=r1bqkbnr%2Fpppp1ppp%2F2n5%2F4p3%2F4P3%2F5N2%2FPPPP1PPP%2FRNBQKB1R+w+KQkq+-+2+3&multiPv=2"
+	if diff := cmp.Diff([]string{wantURI}, *uris); diff != "" {
+		t.Errorf("URI mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_CloudEval_Black(t *testing.T) {
+	// Scores are turned around for Black.
+	const fen = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+	s, _ := jsonServer(t, http.StatusOK, `{"fen":"`+fen+`","knodes":1,"depth":1,"pvs":[{"moves":"c7c5","cp":25}]}`)
+	got, err := (&Client{BaseURL: s.URL}).CloudEval(context.Background(), fen, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp := got.PVs[0].Score.CP; cp != -25 {
+		t.Errorf("want -25, got %d", cp)
+	}
+}
+
+func TestClient_CloudEval_Errors(t *testing.T) {
+	s, _ := jsonServer(t, http.StatusNotFound, `{"error":"No cloud evaluation available for that position"}`)
+	c := &Client{BaseURL: s.URL}
+	if _, err := c.CloudEval(context.Background(), chess.StartingPosition().String(), 1); err != ErrNoCloudEval {
+		t.Errorf("want ErrNoCloudEval, got %v", err)
+	}
+	if _, err := c.CloudEval(context.Background(), "bad", 1); err == nil {
+		t.Error("bad FEN: want error")
+	}
+
+	s, _ = jsonServer(t, http.StatusOK, `{"fen":"","pvs":[{"moves":"e2e5","cp":0}]}`)
+	c = &Client{BaseURL: s.URL}
+	if _, err := c.CloudEval(context.Background(), chess.StartingPosition().String(), 1); err == nil {
+		t.Error("illegal move: want error")
+	}
+}
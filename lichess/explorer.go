@@ -0,0 +1,296 @@
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+// ExplorerDB is a database of the opening explorer.
+type ExplorerDB string
+
+// Databases of the opening explorer.
+const (
+	MastersDB ExplorerDB = "masters" // Over-the-board games between masters.
+	LichessDB ExplorerDB = "lichess" // Games played on Lichess.
+	PlayerDB  ExplorerDB = "player"  // Games of one player on Lichess.
+)
+
+// ExplorerQuery selects a position, and the games to count, in the opening
+// explorer.
+type ExplorerQuery struct {
+	FEN  string       // The position before Play. Empty means the standard starting position.
+	Play []chess.Move // Moves played from FEN to reach the position.
+
+	// Moves, TopGames and RecentGames are the maximum numbers of moves and
+	// games to list. Zero means the server's default, and a negative number
+	// means none.
+	Moves, TopGames, RecentGames int
+
+	// Since and Until limit the games to a range of dates: years, such as
+	// "1952", for MastersDB, and months, such as "2020-01", otherwise. Empty
+	// means no limit.
+	Since, Until string
+
+	// The remaining fields are for LichessDB and PlayerDB.
+	Variant string   // Such as "standard". Empty means the server's default.
+	Speeds  []string // Such as "blitz" or "rapid". Empty means all of them.
+	Ratings []int    // For LichessDB, the rating groups, such as 1600 for 1600-1799. Empty means all of them.
+	Modes   []string // For PlayerDB, "rated" and "casual". Empty means both.
+
+	// Player and Color select the games of one side of a player, for
+	// PlayerDB.
+	Player string
+	Color  chess.Color
+}
+
+// Explorer is the result of a query to the opening explorer: the games that
+// reached the position, and the moves played from it.
+type Explorer struct {
+	White, Draws, Black int // The results of the games.
+
+	Moves       []ExplorerMove
+	TopGames    []ExplorerGame // The games of the highest rated players.
+	RecentGames []ExplorerGame
+	Opening     *Opening // The opening the position belongs to, if known.
+}
+
+// ExplorerMove is a move played in the explorer's position.
+type ExplorerMove struct {
+	Move                chess.Move
+	SAN                 string
+	AverageRating       int
+	White, Draws, Black int // The results of the games with the move.
+
+	// Game is the game with the move, if there is just one.
+	Game *ExplorerGame
+}
+
+// Games returns the number of games with the move.
+func (m *ExplorerMove) Games() int {
+	return m.White + m.Draws + m.Black
+}
+
+// ExplorerGame is a game in the opening explorer.
+type ExplorerGame struct {
+	ID           string
+	Move         chess.Move // The move played in the explorer's position, if listed.
+	Winner       string     // "white" or "black", or empty for a draw.
+	Speed        string
+	Mode         string
+	White, Black User
+	Year         int
+	Month        string // Such as "2021-03", if known.
+}
+
+// Opening is a named opening.
+type Opening struct {
+	ECO  string `json:"eco"`
+	Name string `json:"name"`
+}
+
+// Explore looks up a position in a database of the opening explorer. Moves in
+// the result are encoded like moves in the position, with castling as a king
+// move of two squares in standard chess.
+//
+// Results from PlayerDB are computed on demand, which may take a while for a
+// player with many games.
+func (c *Client) Explore(ctx context.Context, db ExplorerDB, q *ExplorerQuery) (*Explorer, error) {
+	pos := chess.StartingPosition()
+	if q.FEN != "" {
+		var err error
+		if pos, err = chess.ParseFEN(q.FEN); err != nil {
+			return nil, fmt.Errorf("lichess: %w", err)
+		}
+	}
+	for _, m := range q.Play {
+		if !pos.IsLegal(m) {
+			return nil, fmt.Errorf("lichess: illegal move %v in %v", m, pos)
+		}
+		pos.Apply(m)
+	}
+	if db == PlayerDB && q.Player == "" {
+		return nil, errors.New("lichess: no player for the player database")
+	}
+
+	rawURL := urlOr(c.ExplorerURL, DefaultExplorerURL) + "/" + url.PathEscape(string(db)) + "?" + q.values(db).Encode()
+	resp, err := c.request(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The player database streams results as they are computed, each one
+	// replacing the last.
+	var raw *rawExplorer
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var next rawExplorer
+		err := dec.Decode(&next)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("lichess: explorer: %w", err)
+		}
+		raw = &next
+	}
+	if raw == nil {
+		return nil, errors.New("lichess: empty explorer response")
+	}
+	return raw.explorer(pos)
+}
+
+// values returns the URL parameters of q for db.
+func (q *ExplorerQuery) values(db ExplorerDB) url.Values {
+	v := url.Values{}
+	if q.FEN != "" {
+		v.Set("fen", q.FEN)
+	}
+	if len(q.Play) > 0 {
+		play := make([]string, len(q.Play))
+		for i, m := range q.Play {
+			play[i] = m.String()
+		}
+		v.Set("play", strings.Join(play, ","))
+	}
+	for name, n := range map[string]int{"moves": q.Moves, "topGames": q.TopGames, "recentGames": q.RecentGames} {
+		switch {
+		case n > 0:
+			v.Set(name, strconv.Itoa(n))
+		case n < 0:
+			v.Set(name, "0")
+		}
+	}
+	if q.Since != "" {
+		v.Set("since", q.Since)
+	}
+	if q.Until != "" {
+		v.Set("until", q.Until)
+	}
+	if db == MastersDB {
+		return v
+	}
+
+	if q.Variant != "" {
+		v.Set("variant", q.Variant)
+	}
+	if len(q.Speeds) > 0 {
+		v.Set("speeds", strings.Join(q.Speeds, ","))
+	}
+	if len(q.Ratings) > 0 {
+		ratings := make([]string, len(q.Ratings))
+		for i, r := range q.Ratings {
+			ratings[i] = strconv.Itoa(r)
+		}
+		v.Set("ratings", strings.Join(ratings, ","))
+	}
+	if db == PlayerDB {
+		v.Set("player", q.Player)
+		v.Set("color", sideKeys[q.Color])
+		if len(q.Modes) > 0 {
+			v.Set("modes", strings.Join(q.Modes, ","))
+		}
+	}
+	return v
+}
+
+// sideKeys are the names of the sides in the API.
+var sideKeys = [2]string{chess.White: "white", chess.Black: "black"}
+
+// rawExplorer is an explorer response as sent.
+type rawExplorer struct {
+	White       int       `json:"white"`
+	Draws       int       `json:"draws"`
+	Black       int       `json:"black"`
+	Moves       []rawMove `json:"moves"`
+	TopGames    []rawGame `json:"topGames"`
+	RecentGames []rawGame `json:"recentGames"`
+	Opening     *Opening  `json:"opening"`
+}
+
+type rawMove struct {
+	UCI           string   `json:"uci"`
+	SAN           string   `json:"san"`
+	AverageRating int      `json:"averageRating"`
+	White         int      `json:"white"`
+	Draws         int      `json:"draws"`
+	Black         int      `json:"black"`
+	Game          *rawGame `json:"game"`
+}
+
+type rawGame struct {
+	ID     string `json:"id"`
+	UCI    string `json:"uci"`
+	Winner string `json:"winner"`
+	Speed  string `json:"speed"`
+	Mode   string `json:"mode"`
+	White  User   `json:"white"`
+	Black  User   `json:"black"`
+	Year   int    `json:"year"`
+	Month  string `json:"month"`
+}
+
+// explorer converts r, the response for pos.
+func (r *rawExplorer) explorer(pos *chess.Position) (*Explorer, error) {
+	e := &Explorer{White: r.White, Draws: r.Draws, Black: r.Black, Opening: r.Opening}
+	for _, rm := range r.Moves {
+		m, err := parseMove(pos, rm.UCI)
+		if err != nil {
+			return nil, err
+		}
+		em := ExplorerMove{Move: m, SAN: rm.SAN, AverageRating: rm.AverageRating, White: rm.White, Draws: rm.Draws, Black: rm.Black}
+		if rm.Game != nil {
+			g, err := rm.Game.game(pos)
+			if err != nil {
+				return nil, err
+			}
+			g.Move = m
+			em.Game = &g
+		}
+		e.Moves = append(e.Moves, em)
+	}
+	for _, list := range []struct {
+		raw []rawGame
+		dst *[]ExplorerGame
+	}{{r.TopGames, &e.TopGames}, {r.RecentGames, &e.RecentGames}} {
+		for _, rg := range list.raw {
+			g, err := rg.game(pos)
+			if err != nil {
+				return nil, err
+			}
+			*list.dst = append(*list.dst, g)
+		}
+	}
+	return e, nil
+}
+
+func (r *rawGame) game(pos *chess.Position) (ExplorerGame, error) {
+	g := ExplorerGame{ID: r.ID, Winner: r.Winner, Speed: r.Speed, Mode: r.Mode, White: r.White, Black: r.Black, Year: r.Year, Month: r.Month}
+	if r.UCI != "" {
+		m, err := parseMove(pos, r.UCI)
+		if err != nil {
+			return g, err
+		}
+		g.Move = m
+	}
+	return g, nil
+}
+
+// parseMove parses a move in pos, which Lichess may send with
+// castling as the king taking its own rook.
+func parseMove(pos *chess.Position, s string) (chess.Move, error) {
+	m, err := chess.ParseMove(s)
+	if err != nil {
+		return chess.Move{}, fmt.Errorf("lichess: %w", err)
+	}
+	return pos.ConvertCastling(m, pos.Chess960()), nil
+}
@@ -0,0 +1,107 @@
+package lichess
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+// jsonServer returns a server that answers every request with body, and
+// records the requested URIs.
+func jsonServer(t *testing.T, status int, body string) (*httptest.Server, *[]string) {
+	var uris []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uris = append(uris, r.URL.RequestURI())
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(s.Close)
+	return s, &uris
+}
+
+func TestClient_Explore(t *testing.T) {
+	// Castling is sent as the king taking its rook.
+	const body = `{"white":10,"draws":5,"black":3,
+		"moves":[
+			{"uci":"e1h1","san":"O-O","averageRating":2500,"white":6,"draws":3,"black":1,"game":null},
+			{"uci":"d2d3","san":"d3","averageRating":2400,"white":0,"draws":0,"black":1,"game":{"id":"abc","winner":"black","white":{"name":"A","rating":2400},"black":{"name":"B","rating":2410},"year":2001,"month":"2001-05"}}
+		],
+		"topGames":[{"uci":"e1h1","id":"xyz","winner":null,"speed":"classical","white":{"name":"C","rating":2700},"black":{"name":"D","rating":2690},"year":2019}],
+		"opening":{"eco":"C65","name":"Ruy Lopez: Berlin Defense"}}`
+	s, uris := jsonServer(t, http.StatusOK, body)
+	c := &Client{ExplorerURL: s.URL}
+
+	const fen = "r1bqkb1r/pppp1ppp/2n2n2/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4"
+	got, err := c.Explore(context.Background(), MastersDB, &ExplorerQuery{FEN: fen, TopGames: 1, RecentGames: -1, Since: "1990", Speeds: []string{"ignored"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2d3 := chess.Move{From: chess.D2, To: chess.D3}
+	oo := chess.Move{From: chess.E1, To: chess.G1}
+	want := &Explorer{
+		White: 10, Draws: 5, Black: 3,
+		Moves: []ExplorerMove{
+			{Move: oo, SAN: "O-O", AverageRating: 2500, White: 6, Draws: 3, Black: 1},
+			{Move: d2d3, SAN: "d3", AverageRating: 2400, Black: 1, Game: &ExplorerGame{
+				ID: "abc", Move: d2d3, Winner: "black", White: User{Name: "A", Rating: 2400}, Black: User{Name: "B", Rating: 2410}, Year: 2001, Month: "2001-05",
+			}},
+		},
+		TopGames: []ExplorerGame{
+			{ID: "xyz", Move: oo, Speed: "classical", White: User{Name: "C", Rating: 2700}, Black: User{Name: "D", Rating: 2690}, Year: 2019},
+		},
+		Opening: &Opening{ECO: "C65", Name: "Ruy Lopez: Berlin Defense"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if n := got.Moves[0].Games(); n != 10 {
+		t.Errorf("Games: want 10, got %d", n)
+	}
+
+	// Options for other databases aren't sent to the masters database.
+	wantURI := "/masters?fen=r1bqkb1r%2Fpppp1ppp%2F2n2n2%2F1B2p3%2F4P3%2F5N2%2FPPPP1PPP%2FRNBQK2R+w+KQkq+-+4+4&recentGames=0&since=1990&topGames=1"
+	if diff := cmp.Diff([]string{wantURI}, *uris); diff != "" {
+		t.Errorf("URI mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Explore_Player(t *testing.T) {
+	// Results are streamed as they are computed.
+	const body = `{"white":1,"draws":0,"black":0,"moves":[{"uci":"e7e5","san":"e5","white":1,"draws":0,"black":0}]}
+{"white":2,"draws":1,"black":0,"moves":[{"uci":"e7e5","san":"e5","white":2,"draws":1,"black":0}]}
+`
+	s, uris := jsonServer(t, http.StatusOK, body)
+	c := &Client{ExplorerURL: s.URL}
+	e2e4 := chess.Move{From: chess.E2, To: chess.E4}
+	q := &ExplorerQuery{
+		Play:    []chess.Move{e2e4},
+		Player:  "alice",
+		Color:   chess.Black,
+		Speeds:  []string{"blitz", "rapid"},
+		Modes:   []string{"rated"},
+		Ratings: []int{2000},
+	}
+	got, err := c.Explore(context.Background(), PlayerDB, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.White != 2 || got.Draws != 1 || len(got.Moves) != 1 || got.Moves[0].Games() != 3 {
+		t.Errorf("want the last result, got %+v", got)
+	}
+	wantURI := "/player?color=black&modes=rated&play=e2e4&player=alice&ratings=2000&speeds=blitz%2Crapid"
+	if diff := cmp.Diff([]string{wantURI}, *uris); diff != "" {
+		t.Errorf("URI mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := c.Explore(context.Background(), PlayerDB, &ExplorerQuery{}); err == nil {
+		t.Error("no player: want error")
+	}
+	if _, err := c.Explore(context.Background(), LichessDB, &ExplorerQuery{Play: []chess.Move{{From: chess.E2, To: chess.E5}}}); err == nil {
+		t.Error("illegal move: want error")
+	}
+}
@@ -0,0 +1,228 @@
+// Package lichess is a client for the Lichess Bot API, and a bot that plays
+// games on Lichess with a UCI engine.
+//
+// A Client makes requests on behalf of a bot account, identified by a personal
+// access token with the bot:play scope. StreamEvents reports incoming
+// challenges and games that start, and StreamGame reports the moves and clocks
+// of a game as it is played.
+//
+// A Bot ties these together: it accepts or declines challenges, and plays
+// each game with an engine, mapping the Lichess clocks to the engine's time
+// limits, and resigning or agreeing to draws as configured.
+//
+// For analysis, Explore looks up positions in the opening explorer's
+// databases, and CloudEval returns the evaluations Lichess has cached.
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+// Default addresses of the Lichess servers.
+const (
+	DefaultBaseURL     = "https://lichess.org"
+	DefaultExplorerURL = "https://explorer.lichess.ovh"
+)
+
+// Client makes requests to the Lichess API. Its methods may be called
+// concurrently.
+type Client struct {
+	Token       string       // A personal access token with the bot:play scope.
+	BaseURL     string       // The server. Empty means DefaultBaseURL.
+	ExplorerURL string       // The opening explorer's server. Empty means DefaultExplorerURL.
+	HTTPClient  *http.Client // Nil means http.DefaultClient.
+}
+
+// APIError is an unsuccessful response from the API.
+type APIError struct {
+	StatusCode int
+	Message    string // The error the server reported, or the status text.
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("lichess: %d %s", e.StatusCode, e.Message)
+}
+
+// User is a Lichess account, or a computer opponent.
+type User struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Title   string `json:"title"` // Such as "GM" or "BOT", if any.
+	Rating  int    `json:"rating"`
+	AILevel int    `json:"aiLevel"` // The level of a computer opponent, which has no ID.
+}
+
+// UnmarshalJSON decodes a user, whose name some responses call "username".
+func (u *User) UnmarshalJSON(data []byte) error {
+	type user User // Without the method.
+	var v struct {
+		user
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*u = User(v.user)
+	if u.Name == "" {
+		u.Name = v.Username
+	}
+	return nil
+}
+
+// Account returns the account the token belongs to.
+func (c *Client) Account(ctx context.Context) (*User, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/api/account", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	u := new(User)
+	if err := json.NewDecoder(resp.Body).Decode(u); err != nil {
+		return nil, fmt.Errorf("lichess: account: %w", err)
+	}
+	return u, nil
+}
+
+// DeclineReason is a reason for declining a challenge.
+type DeclineReason string
+
+// Reasons for declining a challenge.
+const (
+	DeclineGeneric     DeclineReason = "generic"
+	DeclineLater       DeclineReason = "later"
+	DeclineTooFast     DeclineReason = "tooFast"
+	DeclineTooSlow     DeclineReason = "tooSlow"
+	DeclineTimeControl DeclineReason = "timeControl"
+	DeclineRated       DeclineReason = "rated"
+	DeclineCasual      DeclineReason = "casual"
+	DeclineStandard    DeclineReason = "standard"
+	DeclineVariant     DeclineReason = "variant"
+	DeclineNoBot       DeclineReason = "noBot"
+	DeclineOnlyBot     DeclineReason = "onlyBot"
+)
+
+// AcceptChallenge accepts the challenge with the given ID. The game that
+// results has the same ID.
+func (c *Client) AcceptChallenge(ctx context.Context, id string) error {
+	return c.post(ctx, "/api/challenge/"+url.PathEscape(id)+"/accept", nil)
+}
+
+// DeclineChallenge declines the challenge with the given ID.
+func (c *Client) DeclineChallenge(ctx context.Context, id string, reason DeclineReason) error {
+	form := url.Values{}
+	if reason != "" {
+		form.Set("reason", string(reason))
+	}
+	return c.post(ctx, "/api/challenge/"+url.PathEscape(id)+"/decline", form)
+}
+
+// Move plays m in the game with the given ID, optionally offering a draw, or
+// accepting the opponent's offer.
+func (c *Client) Move(ctx context.Context, gameID string, m chess.Move, offerDraw bool) error {
+	path := "/api/bot/game/" + url.PathEscape(gameID) + "/move/" + m.String()
+	if offerDraw {
+		path += "?offeringDraw=true"
+	}
+	return c.post(ctx, path, nil)
+}
+
+// Resign resigns the game with the given ID.
+func (c *Client) Resign(ctx context.Context, gameID string) error {
+	return c.post(ctx, "/api/bot/game/"+url.PathEscape(gameID)+"/resign", nil)
+}
+
+// Abort aborts the game with the given ID, which is only possible before
+// both sides have moved.
+func (c *Client) Abort(ctx context.Context, gameID string) error {
+	return c.post(ctx, "/api/bot/game/"+url.PathEscape(gameID)+"/abort", nil)
+}
+
+// HandleDrawOffer accepts or declines the opponent's draw offer in the game
+// with the given ID. Accepting when there is no offer offers a draw.
+func (c *Client) HandleDrawOffer(ctx context.Context, gameID string, accept bool) error {
+	answer := "no"
+	if accept {
+		answer = "yes"
+	}
+	return c.post(ctx, "/api/bot/game/"+url.PathEscape(gameID)+"/draw/"+answer, nil)
+}
+
+// post makes a POST request and discards the response.
+func (c *Client) post(ctx context.Context, path string, form url.Values) error {
+	resp, err := c.do(ctx, http.MethodPost, path, form)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// do makes a request to the server at path, sending form if it is non-nil.
+func (c *Client) do(ctx context.Context, method, path string, form url.Values) (*http.Response, error) {
+	return c.request(ctx, method, urlOr(c.BaseURL, DefaultBaseURL)+path, form)
+}
+
+// request makes a request, sending form if it is non-nil. Unsuccessful
+// responses are returned as an *APIError.
+func (c *Client) request(ctx context.Context, method, rawURL string, form url.Values) (*http.Response, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("lichess: %w", err)
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("lichess: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// urlOr returns the base URL u without a trailing slash, or def if u is empty.
+func urlOr(u, def string) string {
+	if u == "" {
+		return def
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+// responseError returns the error described by an unsuccessful response, whose
+// body is usually a JSON object such as {"error": "Not your turn"}.
+func responseError(resp *http.Response) error {
+	e := &APIError{StatusCode: resp.StatusCode}
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body) == nil {
+		e.Message = body.Error
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
@@ -0,0 +1,210 @@
+package lichess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeServer is a fake Lichess server. Streams send the lines written to
+// their channels, and requests are recorded.
+type fakeServer struct {
+	*httptest.Server
+	events chan string
+
+	mu       sync.Mutex
+	games    map[string]chan string
+	requests []string // Such as "POST /api/bot/game/g1/resign".
+
+	// onPost, if non-nil, is called for each POST request, after it is
+	// recorded.
+	onPost func(r *http.Request)
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	f := &fakeServer{events: make(chan string, 16), games: make(map[string]chan string)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// client returns a client for f.
+func (f *fakeServer) client() *Client {
+	return &Client{Token: "secret", BaseURL: f.URL}
+}
+
+// game returns the channel of lines for the stream of the game with the given
+// ID.
+func (f *fakeServer) game(id string) chan string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch, ok := f.games[id]
+	if !ok {
+		ch = make(chan string, 16)
+		f.games[id] = ch
+	}
+	return ch
+}
+
+// posts returns the POST requests made so far.
+func (f *fakeServer) posts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var posts []string
+	for _, r := range f.requests {
+		if strings.HasPrefix(r, "POST ") {
+			posts = append(posts, r)
+		}
+	}
+	return posts
+}
+
+func (f *fakeServer) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintln(w, `{"error":"No such token"}`)
+		return
+	}
+	r.ParseForm()
+	req := r.Method + " " + r.URL.RequestURI()
+	if len(r.PostForm) > 0 {
+		req += " " + r.PostForm.Encode()
+	}
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/api/account":
+		fmt.Fprintln(w, `{"id":"bot","username":"Bot","title":"BOT"}`)
+	case r.URL.Path == "/api/stream/event":
+		f.stream(w, r, f.events)
+	case strings.HasPrefix(r.URL.Path, "/api/bot/game/stream/"):
+		f.stream(w, r, f.game(strings.TrimPrefix(r.URL.Path, "/api/bot/game/stream/")))
+	case r.Method == http.MethodPost:
+		if f.onPost != nil {
+			f.onPost(r)
+		}
+		fmt.Fprintln(w, `{"ok":true}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// stream sends the lines from ch until it is closed.
+func (f *fakeServer) stream(w http.ResponseWriter, r *http.Request, ch chan string) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				return
+			}
+			fmt.Fprintln(w, line)
+			w.(http.Flusher).Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func TestClient_Requests(t *testing.T) {
+	f := newFakeServer(t)
+	c := f.client()
+	ctx := context.Background()
+	e2e4 := chess.Move{From: chess.E2, To: chess.E4}
+	for _, err := range []error{
+		c.AcceptChallenge(ctx, "c1"),
+		c.DeclineChallenge(ctx, "c2", DeclineTooFast),
+		c.Move(ctx, "g1", e2e4, false),
+		c.Move(ctx, "g1", chess.Move{From: chess.E7, To: chess.E8, Promotion: chess.Queen}, true),
+		c.HandleDrawOffer(ctx, "g1", true),
+		c.HandleDrawOffer(ctx, "g1", false),
+		c.Resign(ctx, "g1"),
+		c.Abort(ctx, "g2"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		"POST /api/challenge/c1/accept",
+		"POST /api/challenge/c2/decline reason=tooFast",
+		"POST /api/bot/game/g1/move/e2e4",
+		"POST /api/bot/game/g1/move/e7e8q?offeringDraw=true",
+		"POST /api/bot/game/g1/draw/yes",
+		"POST /api/bot/game/g1/draw/no",
+		"POST /api/bot/game/g1/resign",
+		"POST /api/bot/game/g2/abort",
+	}
+	if diff := cmp.Diff(want, f.posts()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Account(t *testing.T) {
+	f := newFakeServer(t)
+	got, err := f.client().Account(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &User{ID: "bot", Name: "Bot", Title: "BOT"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	f := newFakeServer(t)
+	c := f.client()
+	c.Token = "wrong"
+	err := c.Resign(context.Background(), "g1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("want *APIError, got %v", err)
+	}
+	if want := (&APIError{StatusCode: 401, Message: "No such token"}); !cmp.Equal(want, apiErr) {
+		t.Errorf("want %v, got %v", want, apiErr)
+	}
+
+	// Without an error message in the body, the status text is used.
+	c = f.client()
+	_, err = c.do(context.Background(), http.MethodGet, "/missing", nil)
+	if want := "lichess: 404 Not Found"; err == nil || err.Error() != want {
+		t.Errorf("want %q, got %v", want, err)
+	}
+}
+
+func TestClient_Stream(t *testing.T) {
+	f := newFakeServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := f.client().StreamEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	f.events <- ""
+	f.events <- `{"type":"gameFinish","game":{"gameId":"g1"}}`
+	ev, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Type != "gameFinish" || ev.Game == nil || ev.Game.ID != "g1" {
+		t.Errorf("got %+v", ev)
+	}
+
+	cancel()
+	if _, err := s.Next(); err == nil || err == io.EOF {
+		t.Errorf("after cancel: want an error, got %v", err)
+	}
+}
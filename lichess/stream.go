@@ -0,0 +1,282 @@
+package lichess
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// maxLine is the longest line a stream may send.
+const maxLine = 1 << 20
+
+// stream reads newline-delimited JSON from a response. Empty lines, which the
+// server sends to keep the connection open, are skipped.
+type stream struct {
+	body io.ReadCloser
+	s    *bufio.Scanner
+}
+
+func newStream(body io.ReadCloser) *stream {
+	s := bufio.NewScanner(body)
+	s.Buffer(make([]byte, 0, 4096), maxLine)
+	return &stream{body: body, s: s}
+}
+
+// next returns the next line, or io.EOF at the end of the stream.
+func (s *stream) next() ([]byte, error) {
+	for s.s.Scan() {
+		if line := bytes.TrimSpace(s.s.Bytes()); len(line) > 0 {
+			return line, nil
+		}
+	}
+	if err := s.s.Err(); err != nil {
+		return nil, fmt.Errorf("lichess: %w", err)
+	}
+	return nil, io.EOF
+}
+
+// Event is an event on the bot's account.
+type Event struct {
+	// Type is "challenge", "challengeCanceled", "challengeDeclined",
+	// "gameStart" or "gameFinish". Events of other types may be sent too.
+	Type string
+
+	Challenge *Challenge  // Set for challenge events.
+	Game      *GameStatus // Set for gameStart and gameFinish.
+}
+
+// Challenge is an invitation to play a game.
+type Challenge struct {
+	ID          string               `json:"id"`
+	URL         string               `json:"url"`
+	Status      string               `json:"status"` // Such as "created" or "declined".
+	Challenger  User                 `json:"challenger"`
+	DestUser    *User                `json:"destUser"` // Nil for open challenges.
+	Variant     Variant              `json:"variant"`
+	Rated       bool                 `json:"rated"`
+	Speed       string               `json:"speed"` // Such as "blitz" or "correspondence".
+	TimeControl ChallengeTimeControl `json:"timeControl"`
+	Color       string               `json:"color"`      // The challenger's color: "white", "black" or "random".
+	InitialFEN  string               `json:"initialFen"` // For games from a position.
+}
+
+// ChallengeTimeControl is the time control of a challenge.
+type ChallengeTimeControl struct {
+	Type        string `json:"type"`      // "clock", "correspondence" or "unlimited".
+	Limit       int    `json:"limit"`     // For clocks, the initial time in seconds.
+	Increment   int    `json:"increment"` // For clocks, the increment in seconds.
+	DaysPerTurn int    `json:"daysPerTurn"`
+}
+
+// Variant is a variant of chess.
+type Variant struct {
+	Key  string `json:"key"` // Such as "standard", "fromPosition" or "chess960".
+	Name string `json:"name"`
+}
+
+// GameStatus describes a game that started or finished.
+type GameStatus struct {
+	ID       string  `json:"gameId"`
+	Color    string  `json:"color"` // The bot's color, "white" or "black".
+	FEN      string  `json:"fen"`   // The current position.
+	IsMyTurn bool    `json:"isMyTurn"`
+	Rated    bool    `json:"rated"`
+	Speed    string  `json:"speed"`
+	Variant  Variant `json:"variant"`
+	Opponent User    `json:"opponent"`
+}
+
+// EventStream is the stream of events on the bot's account.
+type EventStream struct {
+	s *stream
+}
+
+// StreamEvents opens the stream of events on the bot's account. On opening,
+// it reports the challenges waiting for an answer and the games in progress.
+// The stream stays open until it is closed, or ctx is done.
+func (c *Client) StreamEvents(ctx context.Context) (*EventStream, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/api/stream/event", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{s: newStream(resp.Body)}, nil
+}
+
+// Next returns the next event, waiting for it if necessary. At the end of the
+// stream, it returns io.EOF.
+func (s *EventStream) Next() (*Event, error) {
+	line, err := s.s.next()
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Type      string      `json:"type"`
+		Challenge *Challenge  `json:"challenge"`
+		Game      *GameStatus `json:"game"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, fmt.Errorf("lichess: event: %w", err)
+	}
+	return &Event{Type: raw.Type, Challenge: raw.Challenge, Game: raw.Game}, nil
+}
+
+// Close closes the stream.
+func (s *EventStream) Close() error {
+	return s.s.body.Close()
+}
+
+// GameEvent is an event in a game.
+type GameEvent struct {
+	// Type is "gameFull", "gameState", "chatLine" or "opponentGone". Events
+	// of other types may be sent too, and only have a Type.
+	Type string
+
+	Full  *GameFull  // Set for gameFull.
+	State *GameState // Set for gameState.
+	Chat  *ChatLine  // Set for chatLine.
+}
+
+// GameFull describes a game and its current state. It is the first event in a
+// game's stream.
+type GameFull struct {
+	ID         string     `json:"id"`
+	Variant    Variant    `json:"variant"`
+	Clock      *GameClock `json:"clock"` // Nil for correspondence and unlimited games.
+	Speed      string     `json:"speed"`
+	Rated      bool       `json:"rated"`
+	White      User       `json:"white"`
+	Black      User       `json:"black"`
+	InitialFEN string     `json:"initialFen"` // A FEN, or "startpos".
+	State      GameState  `json:"state"`
+}
+
+// GameClock is the clock setting of a game.
+type GameClock struct {
+	Initial   int `json:"initial"`   // In milliseconds.
+	Increment int `json:"increment"` // In milliseconds.
+}
+
+// StartingPosition returns the position the game started from.
+func (g *GameFull) StartingPosition() (*chess.Position, error) {
+	if g.InitialFEN == "" || g.InitialFEN == "startpos" {
+		return chess.StartingPosition(), nil
+	}
+	p, err := chess.ParseFEN(g.InitialFEN)
+	if err != nil {
+		return nil, fmt.Errorf("lichess: game %s: %w", g.ID, err)
+	}
+	return p, nil
+}
+
+// GameState is the state of a game: its moves, clocks and status.
+type GameState struct {
+	Moves string `json:"moves"` // The moves played so far, in UCI notation, separated by spaces.
+
+	// Time left on the clocks, and their increments, in milliseconds.
+	WTime int `json:"wtime"`
+	BTime int `json:"btime"`
+	WInc  int `json:"winc"`
+	BInc  int `json:"binc"`
+
+	// Status is "started" while the game is in progress, and otherwise says
+	// how it ended, such as "mate", "resign" or "draw".
+	Status string `json:"status"`
+	Winner string `json:"winner"` // "white" or "black", if a side won.
+
+	WDraw     bool `json:"wdraw"` // White offers a draw.
+	BDraw     bool `json:"bdraw"` // Black offers a draw.
+	WTakeback bool `json:"wtakeback"`
+	BTakeback bool `json:"btakeback"`
+}
+
+// MoveList parses the moves played so far.
+func (s *GameState) MoveList() ([]chess.Move, error) {
+	var moves []chess.Move
+	for _, f := range strings.Fields(s.Moves) {
+		m, err := chess.ParseMove(f)
+		if err != nil {
+			return nil, fmt.Errorf("lichess: %w", err)
+		}
+		moves = append(moves, m)
+	}
+	return moves, nil
+}
+
+// Search returns the time left on the clocks, and their increments, as limits
+// for an engine's search.
+func (s *GameState) Search() uci.Search {
+	return uci.Search{
+		WhiteTime:      time.Duration(s.WTime) * time.Millisecond,
+		BlackTime:      time.Duration(s.BTime) * time.Millisecond,
+		WhiteIncrement: time.Duration(s.WInc) * time.Millisecond,
+		BlackIncrement: time.Duration(s.BInc) * time.Millisecond,
+	}
+}
+
+// ChatLine is a message in a game's chat.
+type ChatLine struct {
+	Username string `json:"username"`
+	Text     string `json:"text"`
+	Room     string `json:"room"` // "player" or "spectator".
+}
+
+// GameStream is the stream of events in a game.
+type GameStream struct {
+	s *stream
+}
+
+// StreamGame opens the stream of events in the game with the given ID. The
+// first event is a gameFull, and the stream ends soon after the game does.
+func (c *Client) StreamGame(ctx context.Context, gameID string) (*GameStream, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/api/bot/game/stream/"+url.PathEscape(gameID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &GameStream{s: newStream(resp.Body)}, nil
+}
+
+// Next returns the next event, waiting for it if necessary. At the end of the
+// stream, it returns io.EOF.
+func (s *GameStream) Next() (*GameEvent, error) {
+	line, err := s.s.next()
+	if err != nil {
+		return nil, err
+	}
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(line, &head); err != nil {
+		return nil, fmt.Errorf("lichess: game event: %w", err)
+	}
+	ev := &GameEvent{Type: head.Type}
+	switch head.Type {
+	case "gameFull":
+		ev.Full = new(GameFull)
+		err = json.Unmarshal(line, ev.Full)
+	case "gameState":
+		ev.State = new(GameState)
+		err = json.Unmarshal(line, ev.State)
+	case "chatLine":
+		ev.Chat = new(ChatLine)
+		err = json.Unmarshal(line, ev.Chat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lichess: %s event: %w", head.Type, err)
+	}
+	return ev, nil
+}
+
+// Close closes the stream.
+func (s *GameStream) Close() error {
+	return s.s.body.Close()
+}
@@ -0,0 +1,149 @@
+package lichess
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEventStream_Next(t *testing.T) {
+	in := `{"type":"challenge","challenge":{"id":"c1","url":"https://lichess.org/c1","status":"created","challenger":{"id":"alice","name":"Alice","rating":1500},"destUser":{"id":"bot","name":"Bot","title":"BOT"},"variant":{"key":"standard","name":"Standard"},"rated":true,"speed":"blitz","timeControl":{"type":"clock","limit":180,"increment":2,"show":"3+2"},"color":"random"}}
+
+{"type":"gameStart","game":{"gameId":"g1","fullId":"g1abcd","color":"black","fen":"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1","isMyTurn":true,"rated":false,"speed":"rapid","variant":{"key":"standard","name":"Standard"},"opponent":{"id":"alice","username":"Alice","rating":1500}}}
+{"type":"somethingNew"}
+`
+	s := &EventStream{s: newStream(io.NopCloser(strings.NewReader(in)))}
+	want := []*Event{
+		{Type: "challenge", Challenge: &Challenge{
+			ID:          "c1",
+			URL:         "https://lichess.org/c1",
+			Status:      "created",
+			Challenger:  User{ID: "alice", Name: "Alice", Rating: 1500},
+			DestUser:    &User{ID: "bot", Name: "Bot", Title: "BOT"},
+			Variant:     Variant{Key: "standard", Name: "Standard"},
+			Rated:       true,
+			Speed:       "blitz",
+			TimeControl: ChallengeTimeControl{Type: "clock", Limit: 180, Increment: 2},
+			Color:       "random",
+		}},
+		{Type: "gameStart", Game: &GameStatus{
+			ID:       "g1",
+			Color:    "black",
+			FEN:      "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			IsMyTurn: true,
+			Speed:    "rapid",
+			Variant:  Variant{Key: "standard", Name: "Standard"},
+			Opponent: User{ID: "alice", Name: "Alice", Rating: 1500},
+		}},
+		{Type: "somethingNew"},
+	}
+	for i, w := range want {
+		got, err := s.Next()
+		if err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+		if diff := cmp.Diff(w, got); diff != "" {
+			t.Errorf("event %d mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+	if _, err := s.Next(); err != io.EOF {
+		t.Errorf("at the end: want io.EOF, got %v", err)
+	}
+}
+
+func TestGameStream_Next(t *testing.T) {
+	in := `{"type":"gameFull","id":"g1","variant":{"key":"standard","name":"Standard"},"clock":{"initial":180000,"increment":2000},"speed":"blitz","rated":true,"white":{"id":"bot","name":"Bot","title":"BOT","rating":2000},"black":{"aiLevel":3},"initialFen":"startpos","state":{"type":"gameState","moves":"","wtime":180000,"btime":180000,"winc":2000,"binc":2000,"status":"started"}}
+{"type":"gameState","moves":"e2e4","wtime":179000,"btime":180000,"winc":2000,"binc":2000,"status":"started","bdraw":true}
+{"type":"chatLine","username":"lichess","text":"Good luck","room":"player"}
+{"type":"opponentGone","gone":true,"claimWinInSeconds":10}
+{"type":"gameState"
+`
+	s := &GameStream{s: newStream(io.NopCloser(strings.NewReader(in)))}
+	want := []*GameEvent{
+		{Type: "gameFull", Full: &GameFull{
+			ID:         "g1",
+			Variant:    Variant{Key: "standard", Name: "Standard"},
+			Clock:      &GameClock{Initial: 180000, Increment: 2000},
+			Speed:      "blitz",
+			Rated:      true,
+			White:      User{ID: "bot", Name: "Bot", Title: "BOT", Rating: 2000},
+			Black:      User{AILevel: 3},
+			InitialFEN: "startpos",
+			State:      GameState{WTime: 180000, BTime: 180000, WInc: 2000, BInc: 2000, Status: "started"},
+		}},
+		{Type: "gameState", State: &GameState{Moves: "e2e4", WTime: 179000, BTime: 180000, WInc: 2000, BInc: 2000, Status: "started", BDraw: true}},
+		{Type: "chatLine", Chat: &ChatLine{Username: "lichess", Text: "Good luck", Room: "player"}},
+		{Type: "opponentGone"},
+	}
+	for i, w := range want {
+		got, err := s.Next()
+		if err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+		if diff := cmp.Diff(w, got); diff != "" {
+			t.Errorf("event %d mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+	if _, err := s.Next(); err == nil || err == io.EOF {
+		t.Errorf("bad JSON: want an error, got %v", err)
+	}
+}
+
+func TestGameState_MoveList(t *testing.T) {
+	s := &GameState{Moves: "e2e4 e7e5 g1f3 b8c6 f1c4 g8f6 e1g1"}
+	got, err := s.MoveList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := chess.StartingPosition()
+	for _, m := range got {
+		if !p.IsLegal(m) {
+			t.Fatalf("%v is illegal in %v", m, p)
+		}
+		p.Apply(m)
+	}
+	if want := "r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQ1RK1 b kq - 5 4"; p.String() != want {
+		t.Errorf("want %s, got %s", want, p)
+	}
+
+	if got, err := (&GameState{}).MoveList(); err != nil || len(got) != 0 {
+		t.Errorf("no moves: got %v, %v", got, err)
+	}
+	if _, err := (&GameState{Moves: "e2e4 e9e5"}).MoveList(); err == nil {
+		t.Error("bad move: want error")
+	}
+}
+
+func TestGameState_Search(t *testing.T) {
+	s := &GameState{WTime: 60000, BTime: 59500, WInc: 1000, BInc: 2000}
+	want := uci.Search{
+		WhiteTime:      time.Minute,
+		BlackTime:      59500 * time.Millisecond,
+		WhiteIncrement: time.Second,
+		BlackIncrement: 2 * time.Second,
+	}
+	if diff := cmp.Diff(want, s.Search()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGameFull_StartingPosition(t *testing.T) {
+	for _, fen := range []string{"", "startpos"} {
+		p, err := (&GameFull{InitialFEN: fen}).StartingPosition()
+		if err != nil || p.String() != chess.StartingPosition().String() {
+			t.Errorf("%q: got %v, %v", fen, p, err)
+		}
+	}
+	const fen = "4k3/8/8/8/8/8/8/4K2R w K - 0 1"
+	if p, err := (&GameFull{InitialFEN: fen}).StartingPosition(); err != nil || p.String() != fen {
+		t.Errorf("got %v, %v", p, err)
+	}
+	if _, err := (&GameFull{InitialFEN: "bad"}).StartingPosition(); err == nil {
+		t.Error("bad FEN: want error")
+	}
+}
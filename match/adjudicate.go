@@ -0,0 +1,145 @@
+package match
+
+import (
+	"fmt"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// TerminationAdjudication is the termination of a game ended by Adjudication.
+const TerminationAdjudication = "adjudication"
+
+// Adjudication ends games early once their result is clear, to save time. The
+// zero value adjudicates nothing. Adjudicated games have the Termination tag
+// TerminationAdjudication, and a comment on the last move giving the reason.
+type Adjudication struct {
+	// A game is drawn once both engines have reported scores of at most
+	// DrawScore centipawns either way for their last DrawMoves moves, counting
+	// only moves from move number DrawMoveNumber on. A DrawMoves of 0 turns
+	// this off.
+	DrawMoveNumber int
+	DrawMoves      int
+	DrawScore      int
+
+	// A game is won once both engines have agreed, for their last ResignMoves
+	// moves each, that the same side is ahead by at least ResignScore
+	// centipawns. A ResignMoves of 0 turns this off.
+	ResignMoves int
+	ResignScore int
+
+	// Tablebase, if not nil, is asked for the result with perfect play of
+	// positions with at most TablebasePieces pieces, kings included. The game
+	// ends if it reports one.
+	Tablebase       func(pos *chess.Position) (chess.Outcome, bool)
+	TablebasePieces int
+
+	// Material draws games in which neither side can force mate, such as a
+	// minor piece against a minor piece, or two knights against a bare king,
+	// before the rules would end them.
+	Material bool
+}
+
+// mateScore is the score in centipawns that stands for a mate.
+const mateScore = 100000
+
+// adjudicator applies an Adjudication to a game as it is played.
+type adjudicator struct {
+	Adjudication
+	draw  [2]int    // Consecutive drawish scores of each engine.
+	ahead [2][2]int // Consecutive scores of each engine with each side ahead.
+}
+
+// record notes the score an engine reported for the move it played as side,
+// with the given move number, or nil if it reported none.
+func (j *adjudicator) record(side chess.Color, moveNumber int, s *uci.Score) {
+	if s == nil {
+		j.draw[side] = 0
+		j.ahead[side] = [2]int{}
+		return
+	}
+	cp := s.CP
+	if s.Mate.Found {
+		cp = mateScore
+		if s.Mate.MovesUntil <= 0 {
+			cp = -mateScore
+		}
+	}
+	if side == chess.Black {
+		cp = -cp // From White's point of view.
+	}
+
+	drawish := moveNumber >= j.DrawMoveNumber && cp >= -j.DrawScore && cp <= j.DrawScore
+	j.draw[side] = count(j.draw[side], drawish)
+	j.ahead[side][chess.White] = count(j.ahead[side][chess.White], cp >= j.ResignScore)
+	j.ahead[side][chess.Black] = count(j.ahead[side][chess.Black], cp <= -j.ResignScore)
+}
+
+// count returns n+1 if ok, and 0 otherwise.
+func count(n int, ok bool) int {
+	if !ok {
+		return 0
+	}
+	return n + 1
+}
+
+// check returns the outcome of a game in pos by adjudication, and the reason,
+// or NoOutcome if it goes on.
+func (j *adjudicator) check(pos *chess.Position) (chess.Outcome, string) {
+	if j.Tablebase != nil && pieces(pos) <= j.TablebasePieces {
+		if o, ok := j.Tablebase(pos); ok && o != chess.NoOutcome {
+			return o, adjudicated(o, "tablebase")
+		}
+	}
+	if j.Material && !canForceMate(pos, chess.White) && !canForceMate(pos, chess.Black) {
+		return chess.Draw, adjudicated(chess.Draw, "neither side can force mate")
+	}
+	if n := j.DrawMoves; n > 0 && j.draw[chess.White] >= n && j.draw[chess.Black] >= n {
+		return chess.Draw, adjudicated(chess.Draw, fmt.Sprintf("scores within %d cp for %d moves", j.DrawScore, n))
+	}
+	if n := j.ResignMoves; n > 0 {
+		for _, c := range []chess.Color{chess.White, chess.Black} {
+			if j.ahead[chess.White][c] >= n && j.ahead[chess.Black][c] >= n {
+				o := chess.WhiteWon
+				if c == chess.Black {
+					o = chess.BlackWon
+				}
+				return o, adjudicated(o, fmt.Sprintf("%s ahead by %d cp or more for %d moves", sideNames[c], j.ResignScore, n))
+			}
+		}
+	}
+	return chess.NoOutcome, ""
+}
+
+// adjudicated returns the comment for a game adjudicated as o.
+func adjudicated(o chess.Outcome, reason string) string {
+	switch o {
+	case chess.WhiteWon:
+		return "White wins by adjudication: " + reason
+	case chess.BlackWon:
+		return "Black wins by adjudication: " + reason
+	}
+	return "Draw by adjudication: " + reason
+}
+
+// pieces returns the number of pieces in pos, kings included.
+func pieces(pos *chess.Position) int {
+	return (pos.Occupied(chess.White) | pos.Occupied(chess.Black)).Count()
+}
+
+// canForceMate reports whether c has the material to force mate against any
+// defense: anything but a lone minor piece, or two knights against a bare
+// king. It doesn't look at where the pieces stand.
+func canForceMate(pos *chess.Position, c chess.Color) bool {
+	if pos.Pieces(c, chess.Pawn)|pos.Pieces(c, chess.Rook)|pos.Pieces(c, chess.Queen) != 0 {
+		return true
+	}
+	knights, bishops := pos.Pieces(c, chess.Knight).Count(), pos.Pieces(c, chess.Bishop).Count()
+	if knights+bishops <= 1 {
+		return false
+	}
+	if bishops == 0 && knights == 2 {
+		return pos.Occupied(c.Other()).Count() > 1
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package match
+
+import (
+	"context"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+func cp(n int) *uci.Score { return &uci.Score{CP: n} }
+
+func mate(n int) *uci.Score {
+	s := &uci.Score{}
+	s.Mate.Found, s.Mate.MovesUntil = true, n
+	return s
+}
+
+func TestAdjudicator_Draw(t *testing.T) {
+	j := adjudicator{Adjudication: Adjudication{DrawMoveNumber: 2, DrawMoves: 2, DrawScore: 10}}
+	pos := chess.StartingPosition()
+
+	// Scores before move 2 don't count, and a missing score resets the count.
+	j.record(chess.White, 1, cp(0))
+	j.record(chess.Black, 1, cp(0))
+	j.record(chess.White, 2, cp(5))
+	j.record(chess.Black, 2, nil)
+	j.record(chess.White, 3, cp(-10))
+	j.record(chess.Black, 3, cp(10))
+	if o, _ := j.check(pos); o != chess.NoOutcome {
+		t.Fatalf("after move 3: got %v", o)
+	}
+	j.record(chess.White, 4, cp(3))
+	if o, _ := j.check(pos); o != chess.NoOutcome {
+		t.Fatalf("after White's move 4: got %v", o)
+	}
+	j.record(chess.Black, 4, cp(-3))
+	o, reason := j.check(pos)
+	if o != chess.Draw || reason != "Draw by adjudication: scores within 10 cp for 2 moves" {
+		t.Errorf("after move 4: got %v, %q", o, reason)
+	}
+}
+
+func TestAdjudicator_Resign(t *testing.T) {
+	j := adjudicator{Adjudication: Adjudication{ResignMoves: 2, ResignScore: 500}}
+	pos := chess.StartingPosition()
+
+	// Scores are from the point of view of the engine's side.
+	j.record(chess.White, 1, cp(-600))
+	j.record(chess.Black, 1, cp(400))
+	j.record(chess.White, 2, cp(-700))
+	j.record(chess.Black, 2, cp(800))
+	if o, _ := j.check(pos); o != chess.NoOutcome {
+		t.Fatalf("after move 2: got %v", o)
+	}
+	j.record(chess.White, 3, mate(-4))
+	j.record(chess.Black, 3, mate(3))
+	o, reason := j.check(pos)
+	if o != chess.BlackWon || reason != "Black wins by adjudication: Black ahead by 500 cp or more for 2 moves" {
+		t.Errorf("after move 3: got %v, %q", o, reason)
+	}
+}
+
+func TestAdjudicator_Material(t *testing.T) {
+	cases := []struct {
+		fen  string
+		want chess.Outcome
+	}{
+		{"8/8/4k3/8/2n5/3B4/4K3/8 w - - 0 1", chess.Draw},       // Minor against minor.
+		{"8/8/4k3/8/8/3NN3/4K3/8 w - - 0 1", chess.Draw},        // Two knights against a bare king.
+		{"8/8/4k3/8/2n5/3NN3/4K3/8 w - - 0 1", chess.NoOutcome}, // Two knights against a knight.
+		{"8/8/4k3/8/8/3BN3/4K3/8 w - - 0 1", chess.NoOutcome},   // Bishop and knight.
+		{"8/8/4k3/8/2n5/3P4/4K3/8 w - - 0 1", chess.NoOutcome},  // A pawn.
+	}
+	for _, c := range cases {
+		pos, err := chess.ParseFEN(c.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j := adjudicator{Adjudication: Adjudication{Material: true}}
+		if o, _ := j.check(pos); o != c.want {
+			t.Errorf("%s: want %v, got %v", c.fen, c.want, o)
+		}
+	}
+}
+
+func TestAdjudicator_Tablebase(t *testing.T) {
+	pos, err := chess.ParseFEN("8/8/4k3/8/8/3Q4/4K3/8 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	probes := 0
+	tb := func(*chess.Position) (chess.Outcome, bool) {
+		probes++
+		return chess.WhiteWon, true
+	}
+
+	j := adjudicator{Adjudication: Adjudication{Tablebase: tb, TablebasePieces: 2}}
+	if o, _ := j.check(pos); o != chess.NoOutcome || probes != 0 {
+		t.Errorf("too many pieces: got %v after %d probes", o, probes)
+	}
+	j.TablebasePieces = 3
+	o, reason := j.check(pos)
+	if o != chess.WhiteWon || reason != "White wins by adjudication: tablebase" {
+		t.Errorf("got %v, %q", o, reason)
+	}
+}
+
+func TestMatch_Play_Adjudication(t *testing.T) {
+	// The scripted engines always report a score of 0.
+	m := &Match{
+		White:        scriptedEngine(t, "W", script("e2e4", "g1f3", "f1c4")),
+		Black:        scriptedEngine(t, "B", script("e7e5", "b8c6", "f8c5")),
+		Search:       uci.Search{Depth: 1},
+		Adjudication: Adjudication{DrawMoves: 2},
+	}
+	g, err := m.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Result != chess.Draw || g.Tag("Termination") != TerminationAdjudication {
+		t.Errorf("got %v by %q, want a draw by adjudication", g.Result, g.Tag("Termination"))
+	}
+	if len(g.Moves) != 4 {
+		t.Fatalf("got %d moves, want 4", len(g.Moves))
+	}
+	want := "Draw by adjudication: scores within 0 cp for 2 moves"
+	if c := g.Moves[3].Comments; len(c) != 1 || c[0] != want {
+		t.Errorf("comments: want [%q], got %q", want, c)
+	}
+}
@@ -0,0 +1,337 @@
+// Package match plays games between UCI engines.
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/book"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/timecontrol"
+	"github.com/clfs/chess/uci"
+)
+
+// Terminations, as in the PGN Termination tag.
+const (
+	TerminationNormal      = "normal"
+	TerminationTimeForfeit = "time forfeit"
+	TerminationInfraction  = "rules infraction" // The engine played an illegal move.
+	TerminationAbandoned   = "abandoned"        // The engine stopped responding.
+)
+
+// sideNames are the names of the sides in comments.
+var sideNames = [2]string{chess.White: "White", chess.Black: "Black"}
+
+// Match plays a game between two engines. Both clients must have completed the
+// "uci" handshake, and their options must already be set.
+type Match struct {
+	White, Black *uci.Client
+
+	// Control is the clock setting for both sides. If it is empty, there is
+	// no clock, and each move is searched with the limits in Search instead,
+	// such as a fixed depth.
+	Control timecontrol.Control
+	Search  uci.Search
+
+	// TimeControl is a clock setting with a single period, used if Control
+	// is empty.
+	//
+	// Deprecated: Use Control, which also allows several periods and delays.
+	TimeControl TimeControl
+
+	// Margin is how far an engine may exceed its remaining time before it
+	// loses on time, to allow for communication delays. An engine that
+	// exceeds its time by less is left with none.
+	Margin time.Duration
+
+	FEN     string       // The starting position. Empty means the standard one.
+	Opening []chess.Move // Moves to play from the starting position before the engines take over.
+
+	// Book, if not nil, supplies moves after Opening for as long as it has
+	// the position, picked with book.Pick using Rand, or a source seeded with
+	// the current time if Rand is nil. Book moves are marked with a "book"
+	// comment.
+	Book book.Book
+	Rand *rand.Rand
+
+	// Tags are extra tags for the game record, such as Event and Round. The
+	// White and Black tags default to the names the engines report.
+	Tags []pgn.Tag
+
+	// Fingerprints identify the engines, indexed by color, by their IDs in
+	// the WhiteFingerprint and BlackFingerprint tags of the record. If a
+	// fingerprint is nil, that of the client is used, which only covers the
+	// engine's "uci" handshake; set them, such as with uci.Engine's
+	// Fingerprint and a bench signature, to identify the exact build.
+	Fingerprints [2]*uci.Fingerprint
+
+	// Restart, if not nil, is called when the engine playing side stops
+	// responding in the middle of a search, such as when it crashes. It
+	// returns the client of a replacement engine, which Play brings to the
+	// state of old with uci.Client.Restore before repeating the search; the
+	// time the failed search took is still charged. An engine is restarted at
+	// most once per move, and Play doesn't close old.
+	Restart func(ctx context.Context, side chess.Color, old *uci.Client) (*uci.Client, error)
+
+	// Adjudication ends games early once their result is clear.
+	Adjudication Adjudication
+}
+
+// Play plays the game and returns its record, with the Termination tag set to
+// one of the Termination constants. Draws by threefold repetition and the
+// fifty-move rule are claimed as soon as possible.
+//
+// An engine that runs out of time, plays an illegal move or stops responding
+// loses the game; Play still returns the record, with a comment explaining
+// what happened. Play returns an error if the game can't be set up, or if ctx
+// is done.
+func (m *Match) Play(ctx context.Context) (*pgn.Game, error) {
+	params := uci.PositionParams{FEN: m.FEN, StartPos: m.FEN == ""}
+	start := chess.StartingPosition()
+	if m.FEN != "" {
+		var err error
+		if start, err = chess.ParseFEN(m.FEN); err != nil {
+			return nil, fmt.Errorf("match: %w", err)
+		}
+	}
+	g := chess.NewGameFromPosition(start)
+	for _, mv := range m.Opening {
+		if err := g.Play(mv); err != nil {
+			return nil, fmt.Errorf("match: opening: %w", err)
+		}
+	}
+	notes := map[int]string{} // Comments on moves, by index.
+	if m.Book != nil {
+		r := m.Rand
+		if r == nil {
+			r = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
+		for g.Outcome() == chess.NoOutcome {
+			if _, ok := g.CanClaimDraw(); ok {
+				break
+			}
+			mv, ok := book.Pick(m.Book, g.Position(), r)
+			if !ok {
+				break
+			}
+			if err := g.Play(mv); err != nil {
+				return nil, fmt.Errorf("match: book: %w", err)
+			}
+			notes[len(g.Moves())-1] = "book"
+		}
+	}
+
+	engines := [2]*uci.Client{chess.White: m.White, chess.Black: m.Black}
+	for _, e := range engines {
+		if err := e.UCINewGame(); err != nil {
+			return nil, err
+		}
+		if err := e.IsReadyContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var clock *timecontrol.Clock // Nil if there is no clock.
+	if control := m.control(); len(control) > 0 {
+		clock = timecontrol.NewClock(control)
+		clock.Grace = m.Margin
+	}
+	var (
+		outcome     chess.Outcome
+		termination = TerminationNormal
+		comment     string
+		adj         = adjudicator{Adjudication: m.Adjudication}
+	)
+	for {
+		if outcome = g.Outcome(); outcome != chess.NoOutcome {
+			break
+		}
+		if _, ok := g.CanClaimDraw(); ok {
+			g.ClaimDraw()
+			outcome = g.Outcome()
+			break
+		}
+		if outcome, comment = adj.check(g.Position()); outcome != chess.NoOutcome {
+			termination = TerminationAdjudication
+			break
+		}
+
+		side := g.Position().SideToMove()
+		moveNumber := g.Position().FullmoveNumber()
+		params.Moves = g.Moves()
+		bm, score, elapsed, err := m.think(ctx, engines[side], params, clock, side)
+		if err != nil && ctx.Err() == nil && m.Restart != nil {
+			cause := err
+			var e *uci.Client
+			if e, err = m.restart(ctx, side, engines[side]); err == nil {
+				engines[side] = e
+				notes[len(params.Moves)] = fmt.Sprintf("%s restarted: %v", sideNames[side], cause)
+				left := clock
+				if clock != nil {
+					c := *clock
+					c.Remaining[side] -= elapsed
+					left = &c
+				}
+				var more time.Duration
+				bm, score, more, err = m.think(ctx, e, params, left, side)
+				elapsed += more
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		loss := chess.WhiteWon
+		if side == chess.White {
+			loss = chess.BlackWon
+		}
+		switch {
+		case err != nil:
+			outcome, termination = loss, TerminationAbandoned
+			comment = fmt.Sprintf("%s stopped responding: %v", sideNames[side], err)
+		case clock != nil && !clock.Punch(side, elapsed):
+			outcome, termination = loss, TerminationTimeForfeit
+			comment = fmt.Sprintf("%s loses on time", sideNames[side])
+		case g.Play(bm.Move) != nil:
+			outcome, termination = loss, TerminationInfraction
+			comment = fmt.Sprintf("%s makes an illegal move: %v", sideNames[side], bm.Move)
+		}
+		if outcome != chess.NoOutcome {
+			break
+		}
+		adj.record(side, moveNumber, score)
+	}
+
+	return m.record(g, outcome, termination, comment, notes), nil
+}
+
+// control returns the time control, from Control or the deprecated
+// TimeControl.
+func (m *Match) control() timecontrol.Control {
+	if len(m.Control) == 0 {
+		return m.TimeControl.Control()
+	}
+	return m.Control
+}
+
+// search returns the limits of a search by side, with the times on clock, which
+// is nil if there is no clock.
+func (m *Match) search(clock *timecontrol.Clock, side chess.Color) uci.Search {
+	if clock == nil {
+		return m.Search
+	}
+	return uci.ClockSearch(timecontrol.Manager{}.Limits(clock, side))
+}
+
+// restart replaces old, the engine playing side, with a new one in the same
+// state.
+func (m *Match) restart(ctx context.Context, side chess.Color, old *uci.Client) (*uci.Client, error) {
+	e, err := m.Restart(ctx, side, old)
+	if err != nil {
+		return nil, fmt.Errorf("restart: %w", err)
+	}
+	if err := e.Restore(ctx, old); err != nil {
+		return nil, fmt.Errorf("restart: %w", err)
+	}
+	return e, nil
+}
+
+// think has e, the engine playing side, search the position described by p
+// with the times on clock, or nil if there is no clock, and returns its best
+// move, the last exact score it reported for the main line, if any, and how
+// long it took.
+func (m *Match) think(ctx context.Context, e *uci.Client, p uci.PositionParams, clock *timecontrol.Clock, side chess.Color) (uci.BestMove, *uci.Score, time.Duration, error) {
+	if err := e.Position(p); err != nil {
+		return uci.BestMove{}, nil, 0, err
+	}
+
+	if clock != nil {
+		// Stop an engine that overruns its clock, so that the game can end.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, clock.Allowance(side))
+		defer cancel()
+	}
+
+	start := time.Now()
+	infoCh, bestCh, err := e.GoContext(ctx, m.search(clock, side))
+	if err != nil {
+		return uci.BestMove{}, nil, 0, err
+	}
+	var score *uci.Score
+	for info := range infoCh {
+		if len(info.PV) > 0 && info.MultiPV <= 1 && !info.Score.LowerBound && !info.Score.UpperBound {
+			s := info.Score
+			score = &s
+		}
+	}
+	bm, ok := <-bestCh
+	elapsed := time.Since(start)
+	if !ok {
+		return uci.BestMove{}, nil, elapsed, errors.New("no best move")
+	}
+	return bm, score, elapsed, nil
+}
+
+// record returns the record of g, with notes on the moves at their indexes and
+// comment on the last one.
+func (m *Match) record(g *chess.Game, outcome chess.Outcome, termination, comment string, notes map[int]string) *pgn.Game {
+	rec := &pgn.Game{Tags: m.Tags, Result: outcome}
+	whiteName, blackName := rec.Tag("White"), rec.Tag("Black")
+	if whiteName == "" {
+		whiteName, _ = m.White.ID()
+	}
+	if blackName == "" {
+		blackName, _ = m.Black.ID()
+	}
+	rec.Tags = []pgn.Tag{{Name: "White", Value: whiteName}, {Name: "Black", Value: blackName}}
+	for _, t := range m.Tags {
+		if t.Name != "White" && t.Name != "Black" {
+			rec.Tags = append(rec.Tags, t)
+		}
+	}
+	if m.FEN != "" {
+		rec.Tags = append(rec.Tags, pgn.Tag{Name: "FEN", Value: m.FEN})
+	}
+	if control := m.control(); len(control) > 0 {
+		rec.Tags = append(rec.Tags, pgn.Tag{Name: "TimeControl", Value: control.String()})
+	}
+	for c, e := range [2]*uci.Client{chess.White: m.White, chess.Black: m.Black} {
+		fp := m.Fingerprints[c]
+		if fp == nil {
+			f := e.Fingerprint()
+			fp = &f
+		}
+		rec.Tags = append(rec.Tags, pgn.Tag{Name: sideNames[c] + "Fingerprint", Value: fp.ID()})
+	}
+	rec.Tags = append(rec.Tags, pgn.Tag{Name: "Termination", Value: termination})
+
+	p := g.StartingPosition()
+	for i, mv := range g.Moves() {
+		rec.Moves = append(rec.Moves, &pgn.Move{Move: mv, SAN: chess.FormatSAN(p, mv)})
+		if note, ok := notes[i]; ok {
+			rec.Moves[i].Comments = append(rec.Moves[i].Comments, note)
+		}
+		p.Apply(mv)
+	}
+	if comment == "" && g.Method() != chess.NoMethod {
+		comment = g.Method().String()
+	}
+	// A note on a move that was never played goes with the final comment.
+	var final []string
+	if note, ok := notes[len(rec.Moves)]; ok {
+		final = append(final, note)
+	}
+	if comment != "" {
+		final = append(final, comment)
+	}
+	if n := len(rec.Moves); n > 0 {
+		rec.Moves[n-1].Comments = append(rec.Moves[n-1].Comments, final...)
+	} else {
+		rec.Comments = append(rec.Comments, final...)
+	}
+	return rec
+}
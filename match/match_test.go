@@ -0,0 +1,399 @@
+package match
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/book"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/timecontrol"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+// scriptedEngine returns a client connected to a fake engine named name. When
+// searching, the engine calls play with the moves played so far and answers
+// with the returned move. An empty move makes the engine wait for "stop" and
+// then answer e2e4, and "crash" makes it exit.
+func scriptedEngine(t *testing.T, name string, play func(moves []string) string) *uci.Client {
+	t.Helper()
+	c, closer, err := startScriptedEngine(name, play)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closer.Close() })
+	return c
+}
+
+// startScriptedEngine is like scriptedEngine, but returns a closer that
+// disconnects the engine.
+func startScriptedEngine(name string, play func(moves []string) string) (*uci.Client, io.Closer, error) {
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	go func() {
+		var moves []string
+		s := bufio.NewScanner(engineIn)
+		for s.Scan() {
+			cmd := s.Text()
+			switch {
+			case cmd == "uci":
+				io.WriteString(engineOut, "id name "+name+"\nuciok\n")
+			case cmd == "isready":
+				io.WriteString(engineOut, "readyok\n")
+			case strings.HasPrefix(cmd, "position"):
+				moves = nil
+				if _, after, ok := strings.Cut(cmd, " moves "); ok {
+					moves = strings.Fields(after)
+				}
+			case strings.HasPrefix(cmd, "go"):
+				switch m := play(moves); m {
+				case "":
+				case "crash":
+					engineOut.Close()
+					return
+				default:
+					io.WriteString(engineOut, "info depth 1 score cp 0 pv "+m+"\nbestmove "+m+"\n")
+				}
+			case cmd == "stop":
+				io.WriteString(engineOut, "bestmove e2e4\n")
+			}
+		}
+	}()
+	c := uci.NewClient(r, w)
+	closer := closerFunc(func() error {
+		engineOut.Close()
+		return w.Close()
+	})
+	if _, _, _, err := c.UCI(); err != nil {
+		closer.Close()
+		return nil, nil, err
+	}
+	return c, closer, nil
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// script plays the given moves in order, one per call.
+func script(moves ...string) func([]string) string {
+	return func(played []string) string {
+		return moves[len(played)/2]
+	}
+}
+
+func TestMatch_Play(t *testing.T) {
+	cases := []struct {
+		name        string
+		white       func([]string) string
+		black       func([]string) string
+		tc          timecontrol.Control
+		result      chess.Outcome
+		termination string
+		moves       int
+		comment     string
+	}{
+		{
+			name:        "checkmate",
+			white:       script("f2f3", "g2g4"),
+			black:       script("e7e5", "d8h4"),
+			result:      chess.BlackWon,
+			termination: TerminationNormal,
+			moves:       4,
+			comment:     "checkmate",
+		},
+		{
+			name:        "illegal move",
+			white:       script("e2e4", "e1e3"),
+			black:       script("e7e5"),
+			result:      chess.BlackWon,
+			termination: TerminationInfraction,
+			moves:       2,
+			comment:     "White makes an illegal move: e1e3",
+		},
+		{
+			name:        "time forfeit",
+			white:       script("e2e4"),
+			black:       script(""),
+			tc:          timecontrol.Control{{Time: 20 * time.Millisecond}},
+			result:      chess.WhiteWon,
+			termination: TerminationTimeForfeit,
+			moves:       1,
+			comment:     "Black loses on time",
+		},
+		{
+			name:        "crash",
+			white:       script("crash"),
+			black:       script("e7e5"),
+			result:      chess.BlackWon,
+			termination: TerminationAbandoned,
+			moves:       0,
+		},
+		{
+			name:        "threefold repetition",
+			white:       func(m []string) string { return []string{"g1f3", "f3g1"}[len(m)/2%2] },
+			black:       func(m []string) string { return []string{"g8f6", "f6g8"}[len(m)/2%2] },
+			result:      chess.Draw,
+			termination: TerminationNormal,
+			moves:       8,
+			comment:     "threefold repetition",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Match{
+				White:   scriptedEngine(t, "W", tc.white),
+				Black:   scriptedEngine(t, "B", tc.black),
+				Control: tc.tc,
+				Search:  uci.Search{Depth: 1},
+				Tags:    []pgn.Tag{{Name: "Event", Value: "Test"}},
+			}
+			g, err := m.Play(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Result != tc.result {
+				t.Errorf("result: want %v, got %v", tc.result, g.Result)
+			}
+			if got := g.Tag("Termination"); got != tc.termination {
+				t.Errorf("termination: want %q, got %q", tc.termination, got)
+			}
+			if len(g.Moves) != tc.moves {
+				t.Fatalf("got %d moves, want %d", len(g.Moves), tc.moves)
+			}
+			if g.Tag("White") != "W" || g.Tag("Black") != "B" || g.Tag("Event") != "Test" {
+				t.Errorf("tags: got %v", g.Tags)
+			}
+			if tc.comment != "" {
+				if diff := cmp.Diff([]string{tc.comment}, g.Moves[len(g.Moves)-1].Comments); diff != "" {
+					t.Errorf("comments mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestMatch_Play_Opening(t *testing.T) {
+	var got []string // The moves Black is asked to search from.
+	m := &Match{
+		White: scriptedEngine(t, "W", func([]string) string { return "" }),
+		Black: scriptedEngine(t, "B", func(moves []string) string {
+			got = moves
+			return "b8c6"
+		}),
+		FEN:     "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		Opening: []chess.Move{{From: chess.E2, To: chess.E4}},
+		Search:  uci.Search{Depth: 1},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := m.Play(ctx); err != context.DeadlineExceeded {
+		t.Errorf("want %v, got %v", context.DeadlineExceeded, err)
+	}
+	if diff := cmp.Diff([]string{"e2e4"}, got); diff != "" {
+		t.Errorf("moves sent to Black mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMatch_Play_Book(t *testing.T) {
+	b := new(book.Memory)
+	b.AddGame(chess.StartingPosition(), []chess.Move{{From: chess.F2, To: chess.F3}, {From: chess.E7, To: chess.E5}}, 10)
+	m := &Match{
+		White:  scriptedEngine(t, "W", script("", "g2g4")),
+		Black:  scriptedEngine(t, "B", script("", "d8h4")),
+		Book:   b,
+		Rand:   rand.New(rand.NewSource(1)),
+		Search: uci.Search{Depth: 1},
+	}
+	g, err := m.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"f3", "e5", "g4", "Qh4#"}, mainLine(g)); diff != "" {
+		t.Errorf("moves mismatch (-want +got):\n%s", diff)
+	}
+	for i, mv := range g.Moves[:2] {
+		if diff := cmp.Diff([]string{"book"}, mv.Comments); diff != "" {
+			t.Errorf("move %d: comments mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestMatch_Play_Fingerprints(t *testing.T) {
+	white := scriptedEngine(t, "W", script("f2f3", "g2g4"))
+	black := &uci.Fingerprint{Name: "B", Binary: "abc", Bench: 1234}
+	m := &Match{
+		White:        white,
+		Black:        scriptedEngine(t, "B", script("e7e5", "d8h4")),
+		Search:       uci.Search{Depth: 1},
+		Fingerprints: [2]*uci.Fingerprint{chess.Black: black},
+	}
+	g, err := m.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.Tag("WhiteFingerprint"), white.Fingerprint().ID(); got != want {
+		t.Errorf("WhiteFingerprint: got %q, want %q", got, want)
+	}
+	if got, want := g.Tag("BlackFingerprint"), black.ID(); got != want {
+		t.Errorf("BlackFingerprint: got %q, want %q", got, want)
+	}
+}
+
+func TestMatch_Play_Margin(t *testing.T) {
+	// White's first move takes longer than its clock, but ends inside the
+	// margin, so White plays on with a negative clock.
+	white := scriptedEngine(t, "W", func(moves []string) string {
+		if len(moves) == 0 {
+			time.Sleep(60 * time.Millisecond)
+			return "f2f3"
+		}
+		return "g2g4"
+	})
+	var (
+		mu  sync.Mutex
+		gos []string // The "go" commands sent to White.
+	)
+	white.OnSend(func(line string) {
+		if strings.HasPrefix(line, "go") {
+			mu.Lock()
+			gos = append(gos, line)
+			mu.Unlock()
+		}
+	})
+	m := &Match{
+		White:   white,
+		Black:   scriptedEngine(t, "B", script("e7e5", "d8h4")),
+		Control: timecontrol.Control{{Time: 30 * time.Millisecond}},
+		Margin:  time.Second,
+	}
+	g, err := m.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Result != chess.BlackWon || g.Tag("Termination") != TerminationNormal {
+		t.Errorf("got %v by %q, want 0-1 by checkmate", g.Result, g.Tag("Termination"))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(gos) != 2 {
+		t.Fatalf("got %d go commands, want 2: %q", len(gos), gos)
+	}
+	// Zero or less would mean no limit.
+	if f := strings.Fields(gos[1]); len(f) < 3 || f[1] != "wtime" || f[2] != "1" {
+		t.Errorf("second go command: got %q, want wtime 1", gos[1])
+	}
+}
+
+func TestMatch_Play_Control(t *testing.T) {
+	white := scriptedEngine(t, "W", script("f2f3", "g2g4"))
+	var (
+		mu  sync.Mutex
+		gos []string // The "go" commands sent to White.
+	)
+	white.OnSend(func(line string) {
+		if strings.HasPrefix(line, "go") {
+			mu.Lock()
+			gos = append(gos, line)
+			mu.Unlock()
+		}
+	})
+	m := &Match{
+		White:   white,
+		Black:   scriptedEngine(t, "B", script("e7e5", "d8h4")),
+		Control: timecontrol.MustParse("1/60:60+1"),
+	}
+	g, err := m.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Tag("TimeControl"); got != "1/60:60+1" {
+		t.Errorf("TimeControl tag: want 1/60:60+1, got %q", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(gos) != 2 {
+		t.Fatalf("got %d go commands, want 2: %q", len(gos), gos)
+	}
+	if !strings.HasSuffix(gos[0], " movestogo 1") {
+		t.Errorf("first go command: got %q, want movestogo 1", gos[0])
+	}
+	if f := strings.Fields(gos[1]); len(f) < 9 || f[5] != "winc" || f[6] != "1000" {
+		t.Errorf("second go command: got %q, want winc 1000", gos[1])
+	}
+}
+
+func TestMatch_Play_TimeControl(t *testing.T) {
+	m := &Match{
+		White:       scriptedEngine(t, "W", script("f2f3", "g2g4")),
+		Black:       scriptedEngine(t, "B", script("e7e5", "d8h4")),
+		TimeControl: TimeControl{Moves: 40, Time: time.Minute},
+	}
+	g, err := m.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Tag("TimeControl"); got != "40/60" {
+		t.Errorf("TimeControl tag: want 40/60, got %q", got)
+	}
+}
+
+func TestMatch_Play_Restart(t *testing.T) {
+	white := scriptedEngine(t, "W", script("f2f3", "crash"))
+	m := &Match{
+		White:  white,
+		Black:  scriptedEngine(t, "B", script("e7e5", "d8h4")),
+		Search: uci.Search{Depth: 1},
+	}
+	restarts := 0
+	m.Restart = func(ctx context.Context, side chess.Color, old *uci.Client) (*uci.Client, error) {
+		restarts++
+		if side != chess.White || old != white {
+			t.Errorf("Restart: got side %v and client %p, want White and %p", side, old, white)
+		}
+		return scriptedEngine(t, "W", script("f2f3", "g2g4")), nil
+	}
+	g, err := m.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restarts != 1 {
+		t.Errorf("got %d restarts, want 1", restarts)
+	}
+	if g.Result != chess.BlackWon || g.Tag("Termination") != TerminationNormal {
+		t.Errorf("got %v by %q, want 0-1 by checkmate", g.Result, g.Tag("Termination"))
+	}
+	if len(g.Moves) != 4 {
+		t.Fatalf("got %d moves, want 4", len(g.Moves))
+	}
+	if c := g.Moves[2].Comments; len(c) != 1 || !strings.HasPrefix(c[0], "White restarted: ") {
+		t.Errorf("comments on the third move: got %q", c)
+	}
+	if diff := cmp.Diff([]string{"checkmate"}, g.Moves[3].Comments); diff != "" {
+		t.Errorf("final comments mismatch (-want +got):\n%s", diff)
+	}
+
+	// If the replacement fails too, the game is abandoned.
+	m.White = scriptedEngine(t, "W", script("crash"))
+	m.Black = scriptedEngine(t, "B", script("e7e5"))
+	m.Restart = func(ctx context.Context, side chess.Color, old *uci.Client) (*uci.Client, error) {
+		return scriptedEngine(t, "W", script("crash")), nil
+	}
+	g, err = m.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Result != chess.BlackWon || g.Tag("Termination") != TerminationAbandoned {
+		t.Errorf("got %v by %q, want 0-1 by abandonment", g.Result, g.Tag("Termination"))
+	}
+	if len(g.Comments) != 2 || !strings.HasPrefix(g.Comments[0], "White restarted: ") {
+		t.Errorf("comments: got %q", g.Comments)
+	}
+}
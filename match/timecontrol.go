@@ -0,0 +1,45 @@
+package match
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/clfs/chess/timecontrol"
+)
+
+// TimeControl is a chess clock setting, such as 40 moves in 60 minutes or 5
+// minutes plus 3 seconds per move.
+//
+// Deprecated: Use timecontrol.Control, which also allows several periods and
+// delays.
+type TimeControl struct {
+	Moves     int           // Moves per period. 0 means the rest of the game.
+	Time      time.Duration // Time per period.
+	Increment time.Duration // Time added after each move.
+}
+
+// ParseTimeControl parses a time control in the format "[moves/]time[+inc]",
+// with times in seconds, such as "40/3600", "300+3" or "0.5+0.05".
+//
+// Deprecated: Use timecontrol.Parse.
+func ParseTimeControl(s string) (TimeControl, error) {
+	c, err := timecontrol.Parse(s)
+	if err != nil || len(c) != 1 || c[0].Delay != 0 {
+		return TimeControl{}, fmt.Errorf("match: invalid time control %q", s)
+	}
+	return TimeControl{Moves: c[0].Moves, Time: c[0].Time, Increment: c[0].Increment}, nil
+}
+
+// Control returns the time control as a timecontrol.Control, which is empty if
+// tc is zero.
+func (tc TimeControl) Control() timecontrol.Control {
+	if tc == (TimeControl{}) {
+		return nil
+	}
+	return timecontrol.Control{{Moves: tc.Moves, Time: tc.Time, Increment: tc.Increment}}
+}
+
+// String returns the time control in the format of ParseTimeControl.
+func (tc TimeControl) String() string {
+	return tc.Control().String()
+}
@@ -0,0 +1,51 @@
+package match
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/timecontrol"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	cases := []struct {
+		in   string
+		want TimeControl
+	}{
+		{"40/3600", TimeControl{Moves: 40, Time: time.Hour}},
+		{"300+3", TimeControl{Time: 5 * time.Minute, Increment: 3 * time.Second}},
+		{"0.5+0.05", TimeControl{Time: 500 * time.Millisecond, Increment: 50 * time.Millisecond}},
+		{"40/60+0.6", TimeControl{Moves: 40, Time: time.Minute, Increment: 600 * time.Millisecond}},
+	}
+	for _, tc := range cases {
+		got, err := ParseTimeControl(tc.in)
+		if err != nil {
+			t.Errorf("ParseTimeControl(%q): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseTimeControl(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+		if s := got.String(); s != tc.in {
+			t.Errorf("%+v.String() = %q, want %q", got, s, tc.in)
+		}
+	}
+
+	for _, s := range []string{"", "0", "x/60", "0/60", "60+", "60+-1", "-5", "40/60:30", "60d5"} {
+		if _, err := ParseTimeControl(s); err == nil {
+			t.Errorf("ParseTimeControl(%q): want error", s)
+		}
+	}
+}
+
+func TestTimeControl_Control(t *testing.T) {
+	tc := TimeControl{Moves: 40, Time: time.Minute, Increment: time.Second}
+	want := timecontrol.Control{{Moves: 40, Time: time.Minute, Increment: time.Second}}
+	if diff := cmp.Diff(want, tc.Control()); diff != "" {
+		t.Errorf("Control mismatch (-want +got):\n%s", diff)
+	}
+	if got := (TimeControl{}).Control(); got != nil {
+		t.Errorf("zero: got %v", got)
+	}
+}
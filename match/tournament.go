@@ -0,0 +1,420 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/uci"
+)
+
+// Player is an engine taking part in a tournament.
+type Player struct {
+	Name string
+
+	// Start starts an instance of the engine. The client must have completed
+	// the "uci" handshake, and its options must be set. The closer is called
+	// when the instance is no longer needed.
+	//
+	// Concurrent games use separate instances, and an instance may be used for
+	// several games in a row.
+	Start func() (*uci.Client, io.Closer, error)
+}
+
+// EnginePlayer returns a player that runs the engine described by cfg, with
+// the given options set.
+func EnginePlayer(name string, cfg uci.EngineConfig, options map[string]string) Player {
+	return Player{
+		Name: name,
+		Start: func() (*uci.Client, io.Closer, error) {
+			e, err := cfg.Start()
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, _, _, err := e.UCI(); err != nil {
+				e.Close()
+				return nil, nil, err
+			}
+			for name, value := range options {
+				if err := e.SetOption(name, value); err != nil {
+					e.Close()
+					return nil, nil, err
+				}
+			}
+			return e.Client, e, nil
+		},
+	}
+}
+
+// Format is the way players are paired in a tournament.
+type Format int
+
+// Formats.
+const (
+	RoundRobin Format = iota // Every player plays every other player.
+	Gauntlet                 // The first player plays every other player.
+)
+
+// Opening is a starting point for games.
+type Opening struct {
+	FEN   string       // The starting position. Empty means the standard one.
+	Moves []chess.Move // Moves played from the starting position.
+}
+
+// Tournament plays games between several engines.
+type Tournament struct {
+	Players []Player
+	Format  Format
+
+	// GamesPerPairing is the number of games each pairing plays, alternating
+	// colors. 0 means 2.
+	GamesPerPairing int
+
+	// Openings are the openings played, in order. Each opening is played twice
+	// in a row by a pairing, once with each player as White, and all pairings
+	// play the same openings. If there are none, games start from the
+	// standard starting position.
+	Openings []Opening
+
+	// Concurrency is the number of games played at once. 0 means 1.
+	Concurrency int
+
+	// Match holds the settings for each game, such as the time control. Its
+	// engines, starting position and opening are set for each game.
+	Match Match
+
+	// Restart makes an engine that stops responding in the middle of a game,
+	// such as by crashing, be replaced by a new instance that resumes the
+	// game, rather than losing it. See Match.Restart.
+	Restart bool
+
+	// OnGame, if not nil, is called with the result of each game as it
+	// finishes. Calls are not concurrent. To end the tournament early, such
+	// as when a sequential test has reached a decision, OnGame can cancel the
+	// context passed to Play.
+	OnGame func(GameResult)
+}
+
+// GameResult is the result of a game in a tournament.
+type GameResult struct {
+	Round        int // The number of the game in the schedule, starting at 1.
+	White, Black int // Indexes of the players.
+
+	Game *pgn.Game // The record of the game, or nil if Err is set.
+	Err  error     // Why the game couldn't be played.
+}
+
+// Points returns the points scored by the given player, 1 for a win and ½ for
+// a draw. It returns false if the player didn't take part in the game, or if
+// the game wasn't finished.
+func (g GameResult) Points(player int) (float64, bool) {
+	if g.Game == nil || (player != g.White && player != g.Black) {
+		return 0, false
+	}
+	c := chess.White
+	if player == g.Black {
+		c = chess.Black
+	}
+	var s Score
+	s.add(g.Game.Result, c)
+	if s.Games() == 0 {
+		return 0, false
+	}
+	return s.Points(), true
+}
+
+// Results holds the results of a tournament.
+type Results struct {
+	Players []string
+	Games   []GameResult // In schedule order.
+}
+
+// schedule returns the games of the tournament, without records.
+func (t *Tournament) schedule() []GameResult {
+	n := t.GamesPerPairing
+	if n == 0 {
+		n = 2
+	}
+	var games []GameResult
+	pair := func(a, b int) {
+		for i := 0; i < n; i++ {
+			g := GameResult{Round: len(games) + 1, White: a, Black: b}
+			if i%2 == 1 {
+				g.White, g.Black = b, a
+			}
+			games = append(games, g)
+		}
+	}
+	for a := range t.Players {
+		if t.Format == Gauntlet && a > 0 {
+			break
+		}
+		for b := a + 1; b < len(t.Players); b++ {
+			pair(a, b)
+		}
+	}
+	return games
+}
+
+// opening returns the opening of the game with the given index within its
+// pairing.
+func (t *Tournament) opening(i int) Opening {
+	if len(t.Openings) == 0 {
+		return Opening{}
+	}
+	return t.Openings[i/2%len(t.Openings)]
+}
+
+// Play plays the tournament. Games that can't be played, for example because
+// an engine doesn't start, are recorded with an error, and the tournament
+// continues.
+//
+// If ctx is done, Play abandons the games in progress, which are recorded with
+// ctx's error, and returns the results so far together with that error. Games
+// that weren't started have neither a record nor an error.
+func (t *Tournament) Play(ctx context.Context) (*Results, error) {
+	games := t.schedule()
+	res := &Results{Games: games}
+	for _, p := range t.Players {
+		res.Players = append(res.Players, p.Name)
+	}
+
+	perPairing := t.GamesPerPairing
+	if perPairing == 0 {
+		perPairing = 2
+	}
+	workers := t.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var (
+		mu   sync.Mutex // Guards calls to OnGame.
+		wg   sync.WaitGroup
+		next = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inst := make(map[int]*instance)
+			defer func() {
+				for _, in := range inst {
+					in.closer.Close()
+				}
+			}()
+			for i := range next {
+				g := &games[i]
+				g.Game, g.Err = t.play(ctx, inst, g, t.opening(i%perPairing))
+				if ctx.Err() != nil {
+					continue
+				}
+				if t.OnGame != nil {
+					mu.Lock()
+					t.OnGame(*g)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range games {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(next)
+	wg.Wait()
+	return res, ctx.Err()
+}
+
+// instance is a running engine.
+type instance struct {
+	client *uci.Client
+	closer io.Closer
+}
+
+// play plays a scheduled game, using and adding to the engine instances in
+// inst.
+func (t *Tournament) play(ctx context.Context, inst map[int]*instance, g *GameResult, o Opening) (*pgn.Game, error) {
+	for _, p := range []int{g.White, g.Black} {
+		if inst[p] != nil {
+			continue
+		}
+		c, closer, err := t.Players[p].Start()
+		if err != nil {
+			return nil, fmt.Errorf("match: starting %s: %w", t.Players[p].Name, err)
+		}
+		inst[p] = &instance{client: c, closer: closer}
+	}
+
+	m := t.Match
+	m.White, m.Black = inst[g.White].client, inst[g.Black].client
+	m.FEN, m.Opening = o.FEN, o.Moves
+	m.Tags = append([]pgn.Tag{
+		{Name: "Date", Value: time.Now().Format("2006.01.02")},
+		{Name: "Round", Value: strconv.Itoa(g.Round)},
+		{Name: "White", Value: t.Players[g.White].Name},
+		{Name: "Black", Value: t.Players[g.Black].Name},
+	}, t.Match.Tags...)
+
+	if t.Restart {
+		m.Restart = func(ctx context.Context, side chess.Color, old *uci.Client) (*uci.Client, error) {
+			p := g.White
+			if side == chess.Black {
+				p = g.Black
+			}
+			c, closer, err := t.Players[p].Start()
+			if err != nil {
+				return nil, fmt.Errorf("starting %s: %w", t.Players[p].Name, err)
+			}
+			inst[p].closer.Close()
+			inst[p] = &instance{client: c, closer: closer}
+			return c, nil
+		}
+	}
+
+	rec, err := m.Play(ctx)
+	if err != nil {
+		// The engines may be in any state, so start afresh.
+		for _, p := range []int{g.White, g.Black} {
+			inst[p].closer.Close()
+			delete(inst, p)
+		}
+		return nil, err
+	}
+	if rec.Tag("Termination") == TerminationAbandoned {
+		// Don't reuse the engine that stopped responding, which lost.
+		p := g.White
+		if rec.Result == chess.WhiteWon {
+			p = g.Black
+		}
+		inst[p].closer.Close()
+		delete(inst, p)
+	}
+	return rec, nil
+}
+
+// Score is a tally of game results from one player's point of view.
+type Score struct {
+	Wins, Draws, Losses int
+}
+
+// Games returns the number of games played.
+func (s Score) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Points returns the points scored, 1 for a win and ½ for a draw.
+func (s Score) Points() float64 {
+	return float64(s.Wins) + float64(s.Draws)/2
+}
+
+// add tallies a game result for a player with the given color.
+func (s *Score) add(o chess.Outcome, c chess.Color) {
+	switch {
+	case o == chess.Draw:
+		s.Draws++
+	case o == chess.WhiteWon && c == chess.White, o == chess.BlackWon && c == chess.Black:
+		s.Wins++
+	case o == chess.WhiteWon, o == chess.BlackWon:
+		s.Losses++
+	}
+}
+
+// Crosstable returns the scores of the players against each other: the score
+// of player i against player j is at [i][j]. Unfinished games are not
+// counted.
+func (r *Results) Crosstable() [][]Score {
+	table := make([][]Score, len(r.Players))
+	for i := range table {
+		table[i] = make([]Score, len(r.Players))
+	}
+	for _, g := range r.Games {
+		if g.Game == nil {
+			continue
+		}
+		table[g.White][g.Black].add(g.Game.Result, chess.White)
+		table[g.Black][g.White].add(g.Game.Result, chess.Black)
+	}
+	return table
+}
+
+// Total returns the overall score of each player.
+func (r *Results) Total() []Score {
+	totals := make([]Score, len(r.Players))
+	for i, row := range r.Crosstable() {
+		for _, s := range row {
+			totals[i].Wins += s.Wins
+			totals[i].Draws += s.Draws
+			totals[i].Losses += s.Losses
+		}
+	}
+	return totals
+}
+
+// WriteCrosstable writes the crosstable as text, with players ranked by
+// points.
+func (r *Results) WriteCrosstable(w io.Writer) error {
+	table, totals := r.Crosstable(), r.Total()
+	order := make([]int, len(r.Players))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return totals[order[a]].Points() > totals[order[b]].Points()
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"#", "Player", "Points", "Games"}
+	for rank := range order {
+		header = append(header, strconv.Itoa(rank+1))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for rank, i := range order {
+		row := []string{strconv.Itoa(rank + 1), r.Players[i], formatPoints(totals[i].Points()), strconv.Itoa(totals[i].Games())}
+		for _, j := range order {
+			switch s := table[i][j]; {
+			case i == j:
+				row = append(row, "*")
+			case s.Games() == 0:
+				row = append(row, "-")
+			default:
+				row = append(row, formatPoints(s.Points()))
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func formatPoints(p float64) string {
+	return strconv.FormatFloat(p, 'f', 1, 64)
+}
+
+// WritePGN writes the records of the games that were played, in schedule
+// order.
+func (r *Results) WritePGN(w io.Writer) error {
+	pw := pgn.NewWriter(w)
+	for _, g := range r.Games {
+		if g.Game == nil {
+			continue
+		}
+		if err := pw.Write(g.Game); err != nil {
+			return err
+		}
+	}
+	return nil
+}
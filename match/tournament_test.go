@@ -0,0 +1,340 @@
+package match
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+// foolsMate plays the fool's mate from either side, so that Black wins every
+// game that doesn't interfere with it.
+func foolsMate(moves []string) string {
+	script := []string{"f2f3", "g2g4"}
+	if len(moves)%2 == 1 {
+		script = []string{"e7e5", "d8h4"}
+	}
+	for _, m := range script {
+		if !contains(moves, m) {
+			return m
+		}
+	}
+	return "crash"
+}
+
+func contains(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// countingPlayer returns a player that plays foolsMate, counting the instances
+// started.
+func countingPlayer(name string, starts *int, mu *sync.Mutex) Player {
+	return Player{
+		Name: name,
+		Start: func() (*uci.Client, io.Closer, error) {
+			mu.Lock()
+			*starts++
+			mu.Unlock()
+			return startScriptedEngine(name, foolsMate)
+		},
+	}
+}
+
+func TestTournament_RoundRobin(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		starts int
+		seen   []int
+	)
+	tour := &Tournament{
+		Players: []Player{
+			countingPlayer("A", &starts, &mu),
+			countingPlayer("B", &starts, &mu),
+			countingPlayer("C", &starts, &mu),
+		},
+		Openings: []Opening{
+			{Moves: []chess.Move{{From: chess.A2, To: chess.A3}, {From: chess.A7, To: chess.A6}}},
+		},
+		Concurrency: 2,
+		Match: Match{
+			Search: uci.Search{Depth: 1},
+			Tags:   []pgn.Tag{{Name: "Event", Value: "Test"}},
+		},
+		OnGame: func(g GameResult) { seen = append(seen, g.Round) },
+	}
+	res, err := tour.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 6 {
+		t.Errorf("OnGame called for rounds %v, want 6 calls", seen)
+	}
+	if starts > 6 {
+		t.Errorf("started %d engine instances, want at most 6", starts)
+	}
+
+	type pairing struct{ White, Black string }
+	var got []pairing
+	for i, g := range res.Games {
+		if g.Err != nil {
+			t.Fatalf("game %d: %v", i+1, g.Err)
+		}
+		if g.Game.Result != chess.BlackWon {
+			t.Errorf("game %d: result %v, want %v", i+1, g.Game.Result, chess.BlackWon)
+		}
+		if want := strings.Fields("a3 a6 f3 e5 g4 Qh4#"); !cmp.Equal(want, mainLine(g.Game)) {
+			t.Errorf("game %d: moves %v, want %v", i+1, mainLine(g.Game), want)
+		}
+		if g.Game.Tag("Event") != "Test" || g.Game.Tag("Round") != strconv.Itoa(i+1) {
+			t.Errorf("game %d: tags %v", i+1, g.Game.Tags)
+		}
+		got = append(got, pairing{g.Game.Tag("White"), g.Game.Tag("Black")})
+	}
+	want := []pairing{{"A", "B"}, {"B", "A"}, {"A", "C"}, {"C", "A"}, {"B", "C"}, {"C", "B"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("pairings mismatch (-want +got):\n%s", diff)
+	}
+
+	for i, s := range res.Total() {
+		if want := (Score{Wins: 2, Losses: 2}); s != want {
+			t.Errorf("player %d: total %+v, want %+v", i, s, want)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := res.WritePGN(&b); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "[Event \"Test\"]"); n != 6 {
+		t.Errorf("PGN output has %d games, want 6", n)
+	}
+}
+
+func TestTournament_Gauntlet(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		starts int
+	)
+	broken := Player{
+		Name: "Broken",
+		Start: func() (*uci.Client, io.Closer, error) {
+			return nil, nil, errors.New("no such engine")
+		},
+	}
+	tour := &Tournament{
+		Players: []Player{
+			countingPlayer("A", &starts, &mu),
+			countingPlayer("B", &starts, &mu),
+			broken,
+			countingPlayer("C", &starts, &mu),
+		},
+		Format:          Gauntlet,
+		GamesPerPairing: 1,
+		Match:           Match{Search: uci.Search{Depth: 1}},
+	}
+	res, err := tour.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Games) != 3 {
+		t.Fatalf("got %d games, want 3", len(res.Games))
+	}
+	for i, g := range res.Games {
+		if g.White != 0 || g.Black != i+1 {
+			t.Errorf("game %d: players %d-%d, want 0-%d", i+1, g.White, g.Black, i+1)
+		}
+		if broke := g.Black == 2; broke != (g.Err != nil) {
+			t.Errorf("game %d: error %v", i+1, g.Err)
+		}
+	}
+	if want := (Score{Losses: 2}); res.Total()[0] != want {
+		t.Errorf("A: total %+v, want %+v", res.Total()[0], want)
+	}
+}
+
+func TestTournament_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	hang := Player{
+		Name: "Hang",
+		Start: func() (*uci.Client, io.Closer, error) {
+			return startScriptedEngine("Hang", func([]string) string {
+				cancel()
+				return ""
+			})
+		},
+	}
+	tour := &Tournament{Players: []Player{hang, hang}, Match: Match{Search: uci.Search{Infinite: true}}}
+	res, err := tour.Play(ctx)
+	if err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+	if res == nil || len(res.Games) != 2 || res.Games[0].Err != context.Canceled {
+		t.Fatalf("want the interrupted game recorded with %v, got %+v", context.Canceled, res)
+	}
+}
+
+func TestTournament_CanceledFromOnGame(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		mu     sync.Mutex
+		starts int
+	)
+	tour := &Tournament{
+		Players:         []Player{countingPlayer("A", &starts, &mu), countingPlayer("B", &starts, &mu)},
+		GamesPerPairing: 4,
+		Match:           Match{Search: uci.Search{Depth: 1}},
+		OnGame:          func(GameResult) { cancel() },
+	}
+	res, err := tour.Play(ctx)
+	if err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+	if res == nil {
+		t.Fatal("got no results")
+	}
+	if len(res.Games) != 4 {
+		t.Fatalf("got %d games, want 4", len(res.Games))
+	}
+	if res.Games[0].Game == nil {
+		t.Errorf("the first game has no record: %v", res.Games[0].Err)
+	}
+	for _, g := range res.Games[1:] {
+		if g.Game != nil {
+			t.Errorf("game %d was played after the tournament was canceled", g.Round)
+		}
+	}
+	if want := (Score{Losses: 1}); res.Total()[0] != want {
+		t.Errorf("A: total %+v, want %+v", res.Total()[0], want)
+	}
+}
+
+func TestResults_WriteCrosstable(t *testing.T) {
+	game := func(o chess.Outcome) *pgn.Game { return &pgn.Game{Result: o} }
+	res := &Results{
+		Players: []string{"Alpha", "Beta", "Gamma"},
+		Games: []GameResult{
+			{White: 0, Black: 1, Game: game(chess.Draw)},
+			{White: 1, Black: 0, Game: game(chess.WhiteWon)},
+			{White: 0, Black: 2, Game: game(chess.BlackWon)},
+			{White: 2, Black: 0, Game: game(chess.NoOutcome)},
+			{White: 1, Black: 2, Err: errors.New("no such engine")},
+		},
+	}
+
+	want := [][]Score{
+		{{}, {Draws: 1, Losses: 1}, {Losses: 1}},
+		{{Wins: 1, Draws: 1}, {}, {}},
+		{{Wins: 1}, {}, {}},
+	}
+	if diff := cmp.Diff(want, res.Crosstable()); diff != "" {
+		t.Errorf("Crosstable mismatch (-want +got):\n%s", diff)
+	}
+
+	var b strings.Builder
+	if err := res.WriteCrosstable(&b); err != nil {
+		t.Fatal(err)
+	}
+	wantTable := "" +
+		"#  Player  Points  Games  1    2    3\n" +
+		"1  Beta    1.5     2      *    -    1.5\n" +
+		"2  Gamma   1.0     1      -    *    1.0\n" +
+		"3  Alpha   0.5     3      0.5  0.0  *\n"
+	if diff := cmp.Diff(wantTable, b.String()); diff != "" {
+		t.Errorf("WriteCrosstable mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGameResult_Points(t *testing.T) {
+	g := GameResult{White: 2, Black: 0, Game: &pgn.Game{Result: chess.BlackWon}}
+	for _, tc := range []struct {
+		player int
+		want   float64
+		ok     bool
+	}{
+		{0, 1, true},
+		{2, 0, true},
+		{1, 0, false},
+	} {
+		if got, ok := g.Points(tc.player); got != tc.want || ok != tc.ok {
+			t.Errorf("Points(%d) = %v, %v; want %v, %v", tc.player, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	g.Game.Result = chess.Draw
+	if got, ok := g.Points(2); got != 0.5 || !ok {
+		t.Errorf("Points of a draw = %v, %v; want 0.5, true", got, ok)
+	}
+	g.Game.Result = chess.NoOutcome
+	if _, ok := g.Points(2); ok {
+		t.Error("Points of an unfinished game: want false")
+	}
+}
+
+// mainLine returns the SAN of the main line of g.
+func mainLine(g *pgn.Game) []string {
+	var res []string
+	for _, m := range g.Moves {
+		res = append(res, m.SAN)
+	}
+	return res
+}
+
+func TestTournament_Restart(t *testing.T) {
+	// A's first instance crashes on its second move; later ones don't.
+	var (
+		mu     sync.Mutex
+		starts int
+	)
+	crashy := Player{
+		Name: "A",
+		Start: func() (*uci.Client, io.Closer, error) {
+			mu.Lock()
+			starts++
+			first := starts == 1
+			mu.Unlock()
+			return startScriptedEngine("A", func(moves []string) string {
+				if first && len(moves) == 2 {
+					return "crash"
+				}
+				return foolsMate(moves)
+			})
+		},
+	}
+	tour := &Tournament{
+		Players:         []Player{crashy, countingPlayer("B", new(int), &mu)},
+		GamesPerPairing: 1,
+		Match:           Match{Search: uci.Search{Depth: 1}},
+		Restart:         true,
+	}
+	res, err := tour.Play(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := res.Games[0]
+	if g.Err != nil {
+		t.Fatal(g.Err)
+	}
+	if g.Game.Result != chess.BlackWon || g.Game.Tag("Termination") != TerminationNormal {
+		t.Errorf("got %v by %q, want 0-1 by checkmate", g.Game.Result, g.Game.Tag("Termination"))
+	}
+	if starts != 2 {
+		t.Errorf("started A %d times, want 2", starts)
+	}
+}
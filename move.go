@@ -0,0 +1,98 @@
+package chess
+
+import "fmt"
+
+// Move is a move from one square to another, with an optional promotion.
+// Castling is encoded as the king's move, such as E1 to G1.
+//
+// In Crazyhouse, a move may instead drop a piece from the mover's hand onto an
+// empty square. Drops have Drop set, and From and To both set to the square.
+//
+// The zero value is the null move, written "0000" in UCI notation.
+type Move struct {
+	From      Square
+	To        Square
+	Promotion PieceType // The piece a pawn promotes to, or NoPieceType.
+	Drop      PieceType // The piece dropped, or NoPieceType.
+}
+
+// ParseMove parses a move in UCI long algebraic notation, such as "e2e4" or
+// "e7e8q", or a drop, such as "N@f3". It doesn't check that the move is legal
+// in any position.
+func ParseMove(s string) (Move, error) {
+	if s == "0000" {
+		return Move{}, nil
+	}
+	if len(s) == 4 && s[1] == '@' {
+		pc, ok := pieceFromLetter(s[0])
+		if !ok || pc.Color() != White || pc.Type() == King {
+			return Move{}, fmt.Errorf("invalid move %q", s)
+		}
+		sq, err := ParseSquare(s[2:])
+		if err != nil {
+			return Move{}, fmt.Errorf("invalid move %q", s)
+		}
+		return Move{From: sq, To: sq, Drop: pc.Type()}, nil
+	}
+	if len(s) != 4 && len(s) != 5 {
+		return Move{}, fmt.Errorf("invalid move %q", s)
+	}
+	from, err := ParseSquare(s[0:2])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid move %q", s)
+	}
+	to, err := ParseSquare(s[2:4])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid move %q", s)
+	}
+	m := Move{From: from, To: to}
+	if len(s) == 5 {
+		switch s[4] {
+		case 'n':
+			m.Promotion = Knight
+		case 'b':
+			m.Promotion = Bishop
+		case 'r':
+			m.Promotion = Rook
+		case 'q':
+			m.Promotion = Queen
+		case 'k':
+			m.Promotion = King // Antichess only.
+		default:
+			return Move{}, fmt.Errorf("invalid move %q", s)
+		}
+	}
+	return m, nil
+}
+
+// String returns the move in UCI long algebraic notation, such as "e2e4" or
+// "e7e8q", or "N@f3" for a drop.
+func (m Move) String() string {
+	if m == (Move{}) {
+		return "0000"
+	}
+	if m.Drop != NoPieceType {
+		return NewPiece(White, m.Drop).String() + "@" + m.To.String()
+	}
+	s := m.From.String() + m.To.String()
+	if m.Promotion != NoPieceType {
+		s += NewPiece(Black, m.Promotion).String()
+	}
+	return s
+}
+
+// MarshalText returns the move in UCI long algebraic notation, so that moves
+// are encoded as strings such as "e2e4" in JSON.
+func (m Move) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText parses a move in UCI long algebraic notation.
+func (m *Move) UnmarshalText(text []byte) error {
+	v, err := ParseMove(string(text))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
@@ -0,0 +1,63 @@
+package chess
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMove(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Move
+	}{
+		{"e2e4", Move{From: E2, To: E4}},
+		{"e1g1", Move{From: E1, To: G1}},
+		{"e7e8q", Move{From: E7, To: E8, Promotion: Queen}},
+		{"a2a1n", Move{From: A2, To: A1, Promotion: Knight}},
+		{"e7e8k", Move{From: E7, To: E8, Promotion: King}},
+		{"N@f3", Move{From: F3, To: F3, Drop: Knight}},
+		{"P@e4", Move{From: E4, To: E4, Drop: Pawn}},
+		{"0000", Move{}},
+	}
+	for _, tc := range cases {
+		got, err := ParseMove(tc.in)
+		if err != nil {
+			t.Errorf("ParseMove(%q): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseMove(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+		if got.String() != tc.in {
+			t.Errorf("%+v.String() = %q, want %q", got, got.String(), tc.in)
+		}
+	}
+
+	for _, s := range []string{"", "e2", "e2e", "e2e9", "e7e8p", "e7e8Q", "K@e4", "n@f3", "N@f9", "N@f"} {
+		if _, err := ParseMove(s); err == nil {
+			t.Errorf("ParseMove(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestMove_JSON(t *testing.T) {
+	in := []Move{{From: E2, To: E4}, {From: E7, To: E8, Promotion: Queen}, {}}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["e2e4","e7e8q","0000"]`; string(b) != want {
+		t.Errorf("want %s, got %s", want, b)
+	}
+
+	var out []Move
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(in) || out[0] != in[0] || out[1] != in[1] || out[2] != in[2] {
+		t.Errorf("want %v, got %v", in, out)
+	}
+	if err := json.Unmarshal([]byte(`"e2e9"`), new(Move)); err == nil {
+		t.Error("want error for an invalid move")
+	}
+}
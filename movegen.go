@@ -0,0 +1,333 @@
+package chess
+
+// Direction offsets, as file and rank deltas.
+var (
+	knightOffsets   = [][2]int8{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingOffsets     = [][2]int8{{0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}}
+	bishopOffsets   = [][2]int8{{1, 1}, {1, -1}, {-1, -1}, {-1, 1}}
+	rookOffsets     = [][2]int8{{0, 1}, {1, 0}, {0, -1}, {-1, 0}}
+	promotionPieces = []PieceType{Queen, Rook, Bishop, Knight}
+)
+
+// offset returns the square df files and dr ranks away from sq, if it is on the
+// board.
+func offset(sq Square, df, dr int8) (Square, bool) {
+	f, r := int8(sq.File())+df, int8(sq.Rank())+dr
+	if f < 0 || f > 7 || r < 0 || r > 7 {
+		return NoSquare, false
+	}
+	return NewSquare(File(f), Rank(r)), true
+}
+
+// pawnDirection returns the rank delta of pawn pushes for c.
+func pawnDirection(c Color) int8 {
+	if c == White {
+		return 1
+	}
+	return -1
+}
+
+// LegalMoves returns the legal moves in the position, following the rules of
+// its variant. There are none once the game is over by a rule of the variant;
+// see VariantOutcome.
+func (p *Position) LegalMoves() []Move {
+	return p.appendLegalMoves(make([]Move, 0, 48))
+}
+
+// IsLegal reports whether m is a legal move in the position.
+func (p *Position) IsLegal(m Move) bool {
+	pc := p.board[m.From]
+	if m.Drop == NoPieceType && (pc == NoPiece || pc.Color() != p.sideToMove) {
+		return false
+	}
+	for _, lm := range p.LegalMoves() {
+		if lm == m {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPseudoLegal reports whether m follows the movement rules of the piece on
+// its origin square, ignoring whether it leaves the mover's king in check.
+// Castling moves are pseudo-legal only if they are legal. Every legal move is
+// pseudo-legal.
+func (p *Position) IsPseudoLegal(m Move) bool {
+	if m.Drop != NoPieceType {
+		return p.IsLegal(m)
+	}
+	pc := p.board[m.From]
+	if pc == NoPiece || pc.Color() != p.sideToMove {
+		return false
+	}
+	if _, ok := p.castlingSide(m); ok {
+		return p.IsLegal(m)
+	}
+	if p.variant == Antichess || p.variant == Horde && m.From.Rank() == Rank1 {
+		// Promotions to kings, and double pushes from the first rank.
+		for _, pm := range p.appendPseudoLegalMoves(nil) {
+			if pm == m {
+				return true
+			}
+		}
+		return false
+	}
+	us := p.sideToMove
+	occ := p.colors[White] | p.colors[Black]
+	if p.colors[us].Has(m.To) {
+		return false
+	}
+	if pc.Type() == Pawn {
+		return p.isPseudoLegalPawn(m)
+	}
+	if m.Promotion != NoPieceType {
+		return false
+	}
+
+	var to Bitboard
+	switch pc.Type() {
+	case Knight:
+		to = knightAttacks[m.From]
+	case Bishop:
+		to = bishopAttacks(m.From, occ)
+	case Rook:
+		to = rookAttacks(m.From, occ)
+	case Queen:
+		to = bishopAttacks(m.From, occ) | rookAttacks(m.From, occ)
+	case King:
+		to = kingAttacks[m.From]
+	}
+	return to.Has(m.To)
+}
+
+// isPseudoLegalPawn is IsPseudoLegal for a pawn move to a square not occupied
+// by the side to move.
+func (p *Position) isPseudoLegalPawn(m Move) bool {
+	us := p.sideToMove
+	occ := p.colors[White] | p.colors[Black]
+	startRank, promoRank := Rank2, Rank8
+	if us == Black {
+		startRank, promoRank = Rank7, Rank1
+	}
+
+	if (m.To.Rank() == promoRank) != (m.Promotion != NoPieceType) {
+		return false
+	}
+	if m.Promotion != NoPieceType {
+		valid := false
+		for _, pt := range promotionPieces {
+			valid = valid || pt == m.Promotion
+		}
+		if !valid {
+			return false
+		}
+	}
+
+	if pawnAttacks[us][m.From].Has(m.To) {
+		return p.colors[us.Other()].Has(m.To) || m.To == p.enPassant
+	}
+	push, ok := offset(m.From, 0, pawnDirection(us))
+	if !ok || occ.Has(push) {
+		return false
+	}
+	if m.To == push {
+		return true
+	}
+	push2, ok := offset(push, 0, pawnDirection(us))
+	return ok && m.From.Rank() == startRank && m.To == push2 && !occ.Has(push2)
+}
+
+// InCheck reports whether the side to move is in check.
+func (p *Position) InCheck() bool {
+	return p.Checkers() != 0
+}
+
+// Checkers returns the enemy pieces giving check to the side to move. There are
+// none if the side to move has no king, in Antichess, or when the kings touch
+// in Atomic.
+func (p *Position) Checkers() Bitboard {
+	us := p.sideToMove
+	king := p.Pieces(us, King)
+	if king == 0 || p.variant == Antichess {
+		return 0
+	}
+	ksq := king.first()
+	if p.variant == Atomic && kingAttacks[ksq]&p.Pieces(us.Other(), King) != 0 {
+		return 0
+	}
+	return p.attackersTo(ksq, p.colors[White]|p.colors[Black]) & p.colors[us.Other()]
+}
+
+// AttackersOf returns the pieces of color c that attack sq, whether or not
+// they could legally capture on it.
+func (p *Position) AttackersOf(sq Square, c Color) Bitboard {
+	return p.attackersTo(sq, p.colors[White]|p.colors[Black]) & p.colors[c]
+}
+
+// isAttacked reports whether sq is attacked by any piece of color by.
+func (p *Position) isAttacked(sq Square, by Color) bool {
+	return p.attackersTo(sq, p.colors[White]|p.colors[Black])&p.colors[by] != 0
+}
+
+// attackersTo returns the pieces of either color that attack sq, given the
+// occupied squares occ.
+func (p *Position) attackersTo(sq Square, occ Bitboard) Bitboard {
+	queens := p.pieces[Queen]
+	return pawnAttacks[Black][sq]&p.pieces[Pawn]&p.colors[White] |
+		pawnAttacks[White][sq]&p.pieces[Pawn]&p.colors[Black] |
+		knightAttacks[sq]&p.pieces[Knight] |
+		kingAttacks[sq]&p.pieces[King] |
+		bishopAttacks(sq, occ)&(p.pieces[Bishop]|queens) |
+		rookAttacks(sq, occ)&(p.pieces[Rook]|queens)
+}
+
+// Pinned returns the pieces of color c that are pinned to their king: moving
+// them off the line between the king and an enemy slider would expose the king.
+func (p *Position) Pinned(c Color) Bitboard {
+	ksq := p.kingSquare(c)
+	occ := p.colors[White] | p.colors[Black]
+	them := p.colors[c.Other()]
+	queens := p.pieces[Queen]
+
+	snipers := (rookAttacks(ksq, 0)&(p.pieces[Rook]|queens) | bishopAttacks(ksq, 0)&(p.pieces[Bishop]|queens)) & them
+
+	var pinned Bitboard
+	for ; snipers != 0; snipers &= snipers - 1 {
+		b := between[ksq][snipers.first()] & occ
+		if b.Count() == 1 && b&p.colors[c] != 0 {
+			pinned |= b
+		}
+	}
+	return pinned
+}
+
+// appendLegalMoves appends the legal moves in the position to moves.
+func (p *Position) appendLegalMoves(moves []Move) []Move {
+	if p.variant != Standard && p.VariantOutcome() != NoOutcome {
+		return moves
+	}
+	switch p.variant {
+	case Atomic, Antichess:
+		return p.appendVariantMoves(moves)
+	case Horde:
+		if p.Pieces(p.sideToMove, King) == 0 {
+			return p.appendVariantMoves(moves)
+		}
+	case Crazyhouse:
+		return p.appendDrops(p.appendStandardMoves(moves))
+	}
+	return p.appendStandardMoves(moves)
+}
+
+// appendStandardMoves appends the legal moves of standard chess to moves.
+func (p *Position) appendStandardMoves(moves []Move) []Move {
+	us, them := p.sideToMove, p.sideToMove.Other()
+	own, enemy := p.colors[us], p.colors[them]
+	occ := own | enemy
+	ksq := p.kingSquare(us)
+
+	// The king may go anywhere that isn't attacked once it has moved, so the
+	// king itself mustn't block the attackers.
+	for to := kingAttacks[ksq] &^ own; to != 0; to &= to - 1 {
+		if p.attackersTo(to.first(), occ&^bb(ksq))&enemy == 0 {
+			moves = append(moves, Move{From: ksq, To: to.first()})
+		}
+	}
+
+	checkers := p.attackersTo(ksq, occ) & enemy
+	if checkers.Count() > 1 {
+		return moves // Only the king can escape a double check.
+	}
+
+	// Other pieces must capture or block the checker, if any, and pinned
+	// pieces must stay on the line between the king and the pinner.
+	target := ^own
+	if checkers != 0 {
+		target = between[ksq][checkers.first()] | checkers
+	}
+	pinned := p.Pinned(us)
+
+	add := func(from Square, to Bitboard) {
+		to &= target
+		if pinned.Has(from) {
+			to &= line[ksq][from]
+		}
+		for ; to != 0; to &= to - 1 {
+			moves = append(moves, Move{From: from, To: to.first()})
+		}
+	}
+
+	for b := p.pieces[Knight] & own &^ pinned; b != 0; b &= b - 1 {
+		from := b.first()
+		add(from, knightAttacks[from])
+	}
+	for b := (p.pieces[Bishop] | p.pieces[Queen]) & own; b != 0; b &= b - 1 {
+		from := b.first()
+		add(from, bishopAttacks(from, occ))
+	}
+	for b := (p.pieces[Rook] | p.pieces[Queen]) & own; b != 0; b &= b - 1 {
+		from := b.first()
+		add(from, rookAttacks(from, occ))
+	}
+
+	moves = p.appendPawnMoves(moves, target, pinned)
+
+	if checkers == 0 {
+		moves = p.appendCastlingMoves(moves)
+	}
+	return moves
+}
+
+func (p *Position) appendPawnMoves(moves []Move, target, pinned Bitboard) []Move {
+	us := p.sideToMove
+	occ := p.colors[White] | p.colors[Black]
+	enemy := p.colors[us.Other()]
+	ksq := p.kingSquare(us)
+
+	startRank, promoRank := Rank2, Rank8
+	if us == Black {
+		startRank, promoRank = Rank7, Rank1
+	}
+
+	for b := p.pieces[Pawn] & p.colors[us]; b != 0; b &= b - 1 {
+		from := b.first()
+
+		var to Bitboard
+		if push, ok := offset(from, 0, pawnDirection(us)); ok && !occ.Has(push) {
+			to |= bb(push)
+			if from.Rank() == startRank {
+				if push2, _ := offset(push, 0, pawnDirection(us)); !occ.Has(push2) {
+					to |= bb(push2)
+				}
+			}
+		}
+		to |= pawnAttacks[us][from] & enemy
+		to &= target
+		if pinned.Has(from) {
+			to &= line[ksq][from]
+		}
+
+		for ; to != 0; to &= to - 1 {
+			sq := to.first()
+			if sq.Rank() == promoRank {
+				for _, pt := range promotionPieces {
+					moves = append(moves, Move{From: from, To: sq, Promotion: pt})
+				}
+			} else {
+				moves = append(moves, Move{From: from, To: sq})
+			}
+		}
+
+		// En passant can expose the king in unusual ways, such as along the
+		// rank of both pawns, so check it by playing it.
+		if p.enPassant != NoSquare && pawnAttacks[us][from].Has(p.enPassant) {
+			m := Move{From: from, To: p.enPassant}
+			p.Apply(m)
+			if !p.isAttacked(p.kingSquare(us), us.Other()) {
+				moves = append(moves, m)
+			}
+			p.Unapply()
+		}
+	}
+	return moves
+}
@@ -0,0 +1,226 @@
+package chess
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLegalMoves(t *testing.T) {
+	cases := []struct {
+		name string
+		fen  string
+		want []string
+	}{
+		{
+			"en passant",
+			"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1",
+			[]string{"e1d2", "e1e2", "e1f1", "e1f2", "e5d6", "e5e6", "e1d1"},
+		},
+		{
+			"pinned en passant",
+			"8/8/8/KPp4r/8/8/8/4k3 w - c6 0 1",
+			[]string{"a5a4", "a5a6", "a5b6", "b5b6"},
+		},
+		{
+			"castling through check",
+			"4k3/8/8/8/8/8/5r2/R3K2R w KQ - 0 1",
+			[]string{"a1a2", "a1a3", "a1a4", "a1a5", "a1a6", "a1a7", "a1a8", "a1b1", "a1c1", "a1d1", "e1d1", "e1f2", "h1f1", "h1g1", "h1h2", "h1h3", "h1h4", "h1h5", "h1h6", "h1h7", "h1h8", "e1c1"},
+		},
+		{
+			"promotion",
+			"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1",
+			[]string{"b7b8q", "b7b8r", "b7b8b", "b7b8n", "e1d1", "e1d2", "e1e2", "e1f1", "e1f2"},
+		},
+		{
+			"checkmate",
+			"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3",
+			nil,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := ParseFEN(tc.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range p.LegalMoves() {
+				got = append(got, m.String())
+			}
+			sort.Strings(got)
+			want := append([]string(nil), tc.want...)
+			sort.Strings(want)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("moves mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPosition_InCheck(t *testing.T) {
+	p, err := ParseFEN("rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.InCheck() {
+		t.Error("InCheck() = false, want true")
+	}
+	if StartingPosition().InCheck() {
+		t.Error("InCheck() = true in the starting position")
+	}
+}
+
+func TestPosition_IsLegal(t *testing.T) {
+	p := StartingPosition()
+	for _, s := range []string{"e2e4", "g1f3"} {
+		if m, _ := ParseMove(s); !p.IsLegal(m) {
+			t.Errorf("IsLegal(%s) = false, want true", s)
+		}
+	}
+	for _, s := range []string{"e2e5", "e1e2", "e7e5", "a1a1"} {
+		if m, _ := ParseMove(s); p.IsLegal(m) {
+			t.Errorf("IsLegal(%s) = true, want false", s)
+		}
+	}
+}
+
+func TestPosition_IsPseudoLegal(t *testing.T) {
+	// White's e2 pawn and d2 knight are pinned, and e1g1 castles through the
+	// knight's attack on g1.
+	p, err := ParseFEN("4r1k1/8/8/b7/8/7n/3NP3/4K2R w K - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"e2e4", "d2f3", "h1h3", "e1d1"} {
+		if m, _ := ParseMove(s); !p.IsPseudoLegal(m) {
+			t.Errorf("IsPseudoLegal(%s) = false, want true", s)
+		}
+	}
+	for _, s := range []string{"e2e5", "e2d3", "h1e1", "d2d4", "h1h2q", "e1g1", "e8e7", "a1a2"} {
+		if m, _ := ParseMove(s); p.IsPseudoLegal(m) {
+			t.Errorf("IsPseudoLegal(%s) = true, want false", s)
+		}
+	}
+}
+
+// TestPosition_IsPseudoLegal_AllMoves checks that the pseudo-legal moves are
+// exactly the legal moves plus those that leave the king in check.
+func TestPosition_IsPseudoLegal_AllMoves(t *testing.T) {
+	fens := []string{
+		StartingFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1",
+	}
+	for _, fen := range fens {
+		p, err := ParseFEN(fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		legal := make(map[Move]bool)
+		for _, m := range p.LegalMoves() {
+			legal[m] = true
+		}
+		for from := A1; from <= H8; from++ {
+			for to := A1; to <= H8; to++ {
+				for _, promo := range []PieceType{NoPieceType, Knight, Queen, King} {
+					m := Move{From: from, To: to, Promotion: promo}
+					pseudo := p.IsPseudoLegal(m)
+					if legal[m] && !pseudo {
+						t.Errorf("%s: legal move %v is not pseudo-legal", fen, m)
+					}
+					if pseudo && !legal[m] {
+						us := p.SideToMove()
+						p.Apply(m)
+						if !p.isAttacked(p.kingSquare(us), us.Other()) {
+							t.Errorf("%s: %v is pseudo-legal but neither legal nor leaves the king in check", fen, m)
+						}
+						p.Unapply()
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPosition_Attacks(t *testing.T) {
+	// The knights on c6 and c3 are pinned by the bishop on b5 and the queen
+	// on a5.
+	p, err := ParseFEN("r1b1k2r/ppp2ppp/2n5/qB2p3/4P3/2N2N2/PPP2PPP/R1BQK2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.AttackersOf(E5, White), bb(F3); got != want {
+		t.Errorf("AttackersOf(e5, White) = %v, want %v", got, want)
+	}
+	if got, want := p.AttackersOf(D4, Black), bb(C6)|bb(E5); got != want {
+		t.Errorf("AttackersOf(d4, Black) = %v, want %v", got, want)
+	}
+	if got, want := p.Pinned(Black), bb(C6); got != want {
+		t.Errorf("Pinned(Black) = %v, want %v", got, want)
+	}
+	if got, want := p.Pinned(White), bb(C3); got != want {
+		t.Errorf("Pinned(White) = %v, want %v", got, want)
+	}
+	if got, want := p.Checkers(), Bitboard(0); got != want {
+		t.Errorf("Checkers() = %v, want %v", got, want)
+	}
+
+	q, err := ParseFEN("rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.Checkers(), bb(H4); got != want {
+		t.Errorf("Checkers() = %v, want %v", got, want)
+	}
+}
+
+func TestPosition_ApplyUnapply(t *testing.T) {
+	cases := []struct {
+		fen, move, want string
+	}{
+		{StartingFEN, "e2e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", "4k3/8/3P4/8/8/8/8/4K3 b - - 0 1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 3 10", "e1g1", "r3k2r/8/8/8/8/8/8/R4RK1 b kq - 4 10"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 3 10", "e8c8", "2kr3r/8/8/8/8/8/8/R3K2R w KQ - 4 11"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "a1a8", "R3k2r/8/8/8/8/8/8/4K2R b Kk - 0 1"},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 5 40", "b7b8n", "1N2k3/8/8/8/8/8/8/4K3 b - - 0 40"},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := ParseMove(tc.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.IsLegal(m) {
+			t.Fatalf("%s: %s is not legal", tc.fen, m)
+		}
+		p.Apply(m)
+		if got := p.String(); got != tc.want {
+			t.Errorf("%s: after %s got %q, want %q", tc.fen, m, got, tc.want)
+		}
+		p.Unapply()
+		if got := p.String(); got != tc.fen {
+			t.Errorf("%s: after unapplying %s got %q", tc.fen, m, got)
+		}
+	}
+}
+
+func TestPosition_Clone(t *testing.T) {
+	p := StartingPosition()
+	p.Apply(Move{From: E2, To: E4})
+	q := p.Clone()
+	q.Apply(Move{From: E7, To: E5})
+	p.Unapply()
+	q.Unapply()
+	q.Unapply()
+	if got := q.String(); got != StartingFEN {
+		t.Errorf("got %q after unapplying the clone", got)
+	}
+}
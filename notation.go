@@ -0,0 +1,96 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatFAN returns m in Figurine Algebraic Notation: SAN with the piece
+// letters replaced by the figurines of the moving side, such as "♘bd7" or
+// "e8=♛+". The move must be legal in p.
+func FormatFAN(p *Position, m Move) string {
+	san := FormatSAN(p, m)
+	c := p.board[m.From].Color()
+	var b strings.Builder
+	for i := 0; i < len(san); i++ {
+		if pt := PieceType(strings.IndexByte(sanLetters, san[i])); pt > Pawn {
+			b.WriteString(figurines[NewPiece(c, pt)])
+			continue
+		}
+		b.WriteByte(san[i])
+	}
+	return b.String()
+}
+
+// iccfPromotions are the ICCF digits for each promotion piece type, indexed by
+// PieceType.
+var iccfPromotions = [...]byte{Queen: '1', Rook: '2', Bishop: '3', Knight: '4'}
+
+// FormatICCF returns m in ICCF numeric notation, as used in correspondence
+// chess: the file and rank of the origin and destination squares as digits,
+// such as "5254" for e2e4, followed by a digit for a promotion (1 for a queen,
+// 2 a rook, 3 a bishop and 4 a knight). Castling is written as the king's move,
+// such as "5171", even in Chess960. The move must be legal in p.
+func FormatICCF(p *Position, m Move) string {
+	if i, ok := p.castlingSide(m); ok {
+		m = Move{From: m.From, To: p.castlingPath(i, m.From).kingTo}
+	}
+	b := []byte{
+		byte('1' + m.From.File()), byte('1' + m.From.Rank()),
+		byte('1' + m.To.File()), byte('1' + m.To.Rank()),
+	}
+	if m.Promotion != NoPieceType {
+		b = append(b, iccfPromotions[m.Promotion])
+	}
+	return string(b)
+}
+
+// ParseICCF parses a move in ICCF numeric notation, such as "5254", and returns
+// it if it is legal in p. See FormatICCF.
+func ParseICCF(p *Position, s string) (Move, error) {
+	if len(s) != 4 && len(s) != 5 {
+		return Move{}, fmt.Errorf("invalid ICCF move %q", s)
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '1' || s[i] > '8' || i == 4 && s[i] > '4' {
+			return Move{}, fmt.Errorf("invalid ICCF move %q", s)
+		}
+	}
+	for _, m := range p.LegalMoves() {
+		if FormatICCF(p, m) == s {
+			return m, nil
+		}
+	}
+	return Move{}, fmt.Errorf("illegal move %q", s)
+}
+
+// FormatSANMoves returns moves, played in order from p, in SAN. It fails at the
+// first illegal move. p is not modified.
+func FormatSANMoves(p *Position, moves []Move) ([]string, error) {
+	p = p.Clone()
+	sans := make([]string, len(moves))
+	for i, m := range moves {
+		if !p.IsLegal(m) {
+			return nil, fmt.Errorf("move %d: illegal move %v", i+1, m)
+		}
+		sans[i] = FormatSAN(p, m)
+		p.Apply(m)
+	}
+	return sans, nil
+}
+
+// ParseSANMoves parses moves in SAN, played in order from p, with ParseSAN. It
+// fails at the first move that can't be parsed. p is not modified.
+func ParseSANMoves(p *Position, sans []string) ([]Move, error) {
+	p = p.Clone()
+	moves := make([]Move, len(sans))
+	for i, s := range sans {
+		m, err := ParseSAN(p, s)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		moves[i] = m
+		p.Apply(m)
+	}
+	return moves, nil
+}
@@ -0,0 +1,119 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormatFAN(t *testing.T) {
+	cases := []struct {
+		fen, move, want string
+	}{
+		{StartingFEN, "g1f3", "♘f3"},
+		{StartingFEN, "e2e4", "e4"},
+		{"rnbqkbnr/pppppppp/8/8/8/5N2/PPPPPPPP/RNBQKB1R b KQkq - 1 1", "b8c6", "♞c6"},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8q", "b8=♕+"},
+		{"4k3/8/8/8/8/8/8/4K2R w K - 0 1", "e1g1", "O-O"},
+		{"r3k3/8/8/8/8/8/8/R3K3 w Qq - 0 1", "a1a8", "♖xa8+"},
+	}
+	for _, c := range cases {
+		p, err := ParseFEN(c.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormatFAN(p, mustParseMove(t, c.move)); got != c.want {
+			t.Errorf("%s: FormatFAN(%s) = %q, want %q", c.fen, c.move, got, c.want)
+		}
+	}
+}
+
+func TestICCF(t *testing.T) {
+	cases := []struct {
+		fen, move, want string
+	}{
+		{StartingFEN, "e2e4", "5254"},
+		{StartingFEN, "g1f3", "7163"},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8n", "27284"},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8q", "27281"},
+		{"r3k3/8/8/8/8/8/8/4K3 b q - 0 1", "e8c8", "5838"},
+	}
+	for _, c := range cases {
+		p, err := ParseFEN(c.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := mustParseMove(t, c.move)
+		if got := FormatICCF(p, m); got != c.want {
+			t.Errorf("%s: FormatICCF(%s) = %q, want %q", c.fen, c.move, got, c.want)
+		}
+		if got, err := ParseICCF(p, c.want); err != nil || got != m {
+			t.Errorf("%s: ParseICCF(%q) = %v, %v, want %v", c.fen, c.want, got, err, m)
+		}
+	}
+
+	// Chess960 castling is still written as the king's move.
+	p, err := ParseFEN("4k3/8/8/8/8/8/8/4K2R w K - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetChess960(true); err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatICCF(p, mustParseMove(t, "e1h1")); got != "5171" {
+		t.Errorf("Chess960 castling: got %q, want 5171", got)
+	}
+	if got, err := ParseICCF(p, "5171"); err != nil || got != mustParseMove(t, "e1h1") {
+		t.Errorf("Chess960 castling: ParseICCF = %v, %v", got, err)
+	}
+
+	for _, s := range []string{"", "525", "5259", "52540", "52545", "e2e4", "5255"} {
+		if _, err := ParseICCF(StartingPosition(), s); err == nil {
+			t.Errorf("ParseICCF(%q): want error", s)
+		}
+	}
+}
+
+func TestSANMoves(t *testing.T) {
+	p := StartingPosition()
+	moves := []Move{
+		mustParseMove(t, "e2e4"), mustParseMove(t, "e7e5"),
+		mustParseMove(t, "g1f3"), mustParseMove(t, "b8c6"),
+		mustParseMove(t, "f1b5"),
+	}
+	sans := []string{"e4", "e5", "Nf3", "Nc6", "Bb5"}
+
+	got, err := FormatSANMoves(p, moves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(sans, got); diff != "" {
+		t.Errorf("FormatSANMoves mismatch (-want +got):\n%s", diff)
+	}
+	back, err := ParseSANMoves(p, sans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(moves, back); diff != "" {
+		t.Errorf("ParseSANMoves mismatch (-want +got):\n%s", diff)
+	}
+	if p.String() != StartingFEN {
+		t.Errorf("position modified: %s", p)
+	}
+
+	if _, err := FormatSANMoves(p, []Move{moves[0], moves[0]}); err == nil || err.Error() != "move 2: illegal move e2e4" {
+		t.Errorf("FormatSANMoves: got error %v", err)
+	}
+	if _, err := ParseSANMoves(p, []string{"e4", "Nf3"}); err == nil {
+		t.Error("ParseSANMoves: want error")
+	}
+}
+
+func mustParseMove(t *testing.T, s string) Move {
+	t.Helper()
+	m, err := ParseMove(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
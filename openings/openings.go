@@ -0,0 +1,274 @@
+// Package openings builds suites of opening positions from game databases, for
+// starting the games of engine matches and tournaments.
+//
+// A suite is built in steps: Extract collects the distinct positions reached
+// after a fixed number of moves in a PGN database, Popular keeps those that
+// enough games reached, Evaluate has an engine evaluate them, and InEvalRange
+// keeps the balanced ones. The result is written as EPD or PGN, or used
+// directly as the openings of a match.Tournament:
+//
+//	suite, err := openings.Extract(f, 8)
+//	suite = openings.Popular(suite, 5)
+//	err = openings.Evaluate(ctx, engine, uci.Search{Depth: 16}, suite)
+//	suite = openings.InEvalRange(suite, -50, 50)
+//	err = openings.WriteEPD(os.Stdout, suite)
+package openings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/epd"
+	"github.com/clfs/chess/match"
+	"github.com/clfs/chess/pgn"
+	"github.com/clfs/chess/uci"
+)
+
+// Opening is an opening position, and a line that reaches it.
+type Opening struct {
+	Start    string          // The starting position of the line in FEN, or empty for the standard one.
+	Moves    []chess.Move    // The line, as played in the first game that reached the position.
+	Position *chess.Position // The position after the line.
+	Games    int             // The number of games that reached the position.
+
+	// Score is the engine's evaluation of the position from White's point of
+	// view, if Evaluated is set.
+	Score     uci.Score
+	Evaluated bool
+}
+
+// Extract reads games from r and returns the distinct positions reached after
+// the first plies moves of their main lines, most popular first, and in order
+// of first appearance otherwise. Positions are told apart by their Zobrist
+// hash, so transpositions count as one position. Games that can't be read,
+// that end sooner, that have an illegal move before then, or that are of a
+// variant other than Chess960 are skipped.
+func Extract(r io.Reader, plies int) ([]Opening, error) {
+	if plies < 0 {
+		return nil, errors.New("openings: negative plies")
+	}
+	var res []Opening
+	index := map[uint64]int{} // Indexes in res, by hash.
+	pr := pgn.NewReader(r)
+	for {
+		g, err := pr.Read()
+		if err == io.EOF {
+			break
+		}
+		var pe *pgn.ParseError
+		if errors.As(err, &pe) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pos, err := g.StartingPosition()
+		if err != nil || pos.Variant() != chess.Standard {
+			continue
+		}
+		moves := g.MainLine()
+		if len(moves) < plies || !play(pos, moves[:plies]) {
+			continue
+		}
+		if i, ok := index[pos.Hash()]; ok {
+			res[i].Games++
+			continue
+		}
+		index[pos.Hash()] = len(res)
+		res = append(res, Opening{
+			Start:    g.Tag("FEN"),
+			Moves:    append([]chess.Move(nil), moves[:plies]...),
+			Position: pos,
+			Games:    1,
+		})
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Games > res[j].Games })
+	return res, nil
+}
+
+// play plays moves in pos, and reports whether they were all legal.
+func play(pos *chess.Position, moves []chess.Move) bool {
+	for _, m := range moves {
+		if !pos.IsLegal(m) {
+			return false
+		}
+		pos.Apply(m)
+	}
+	return true
+}
+
+// Popular returns the openings that at least minGames games reached, in order.
+func Popular(openings []Opening, minGames int) []Opening {
+	var res []Opening
+	for _, o := range openings {
+		if o.Games >= minGames {
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// Evaluate searches every opening with c, with the limits in s, and sets their
+// scores. s must end on its own, as for uci.Client.SearchBatch.
+func Evaluate(ctx context.Context, c *uci.Client, s uci.Search, openings []Opening) error {
+	params := make([]uci.PositionParams, len(openings))
+	for i, o := range openings {
+		params[i] = uci.PositionParams{FEN: o.Position.String()}
+	}
+	_, err := c.SearchBatch(ctx, params, s, func(r uci.BatchResult) {
+		o := &openings[r.Index]
+		o.Score = r.Info.Score
+		if o.Position.SideToMove() == chess.Black {
+			o.Score = negate(o.Score)
+		}
+		o.Evaluated = true
+	})
+	if err != nil {
+		return fmt.Errorf("openings: %w", err)
+	}
+	return nil
+}
+
+// negate returns s from the other side's point of view.
+func negate(s uci.Score) uci.Score {
+	s.CP = -s.CP
+	s.Mate.MovesUntil = -s.Mate.MovesUntil
+	s.LowerBound, s.UpperBound = s.UpperBound, s.LowerBound
+	return s
+}
+
+// InEvalRange returns the evaluated openings whose score, from White's point of
+// view, is from min to max centipawns, in order. Mates are out of any range.
+func InEvalRange(openings []Opening, min, max int) []Opening {
+	var res []Opening
+	for _, o := range openings {
+		if o.Evaluated && !o.Score.Mate.Found && o.Score.CP >= min && o.Score.CP <= max {
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// WriteEPD writes the openings as EPD records, with the line in SAN as a "c0"
+// comment, the number of games as a "c1" comment, and the evaluation, if any,
+// as a "ce" operation, which is from the point of view of the side to move.
+func WriteEPD(w io.Writer, openings []Opening) error {
+	for _, o := range openings {
+		r := &epd.Record{Position: o.Position}
+		line, err := o.san()
+		if err != nil {
+			return err
+		}
+		if line != "" {
+			r.SetOp("c0", line)
+		}
+		r.SetOp("c1", o.games())
+		if o.Evaluated && !o.Score.Mate.Found {
+			cp := o.Score.CP
+			if o.Position.SideToMove() == chess.Black {
+				cp = -cp
+			}
+			r.SetOp("ce", strconv.Itoa(cp))
+		}
+		if _, err := fmt.Fprintln(w, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// san returns the line of o in SAN, with move numbers.
+func (o *Opening) san() (string, error) {
+	pos, err := o.start()
+	if err != nil {
+		return "", err
+	}
+	sans, err := chess.FormatSANMoves(pos, o.Moves)
+	if err != nil {
+		return "", fmt.Errorf("openings: %w", err)
+	}
+	var b strings.Builder
+	n, black := pos.FullmoveNumber(), pos.SideToMove() == chess.Black
+	for i, s := range sans {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		switch {
+		case !black:
+			fmt.Fprintf(&b, "%d. ", n)
+		case i == 0:
+			fmt.Fprintf(&b, "%d... ", n)
+		}
+		b.WriteString(s)
+		if black {
+			n++
+		}
+		black = !black
+	}
+	return b.String(), nil
+}
+
+// games returns the number of games of o, as in "3 games".
+func (o *Opening) games() string {
+	if o.Games == 1 {
+		return "1 game"
+	}
+	return strconv.Itoa(o.Games) + " games"
+}
+
+// start returns the starting position of o's line.
+func (o *Opening) start() (*chess.Position, error) {
+	if o.Start == "" {
+		return chess.StartingPosition(), nil
+	}
+	pos, err := chess.ParseFEN(o.Start)
+	if err != nil {
+		return nil, fmt.Errorf("openings: %w", err)
+	}
+	return pos, nil
+}
+
+// WritePGN writes each opening as an unfinished game of its line, with the
+// number of games and the evaluation, if any, as a comment.
+func WritePGN(w io.Writer, openings []Opening) error {
+	pw := pgn.NewWriter(w)
+	for _, o := range openings {
+		g := &pgn.Game{}
+		if o.Start != "" {
+			g.Tags = append(g.Tags, pgn.Tag{Name: "FEN", Value: o.Start})
+		}
+		comment := o.games()
+		if o.Evaluated {
+			comment += ", " + o.Score.String()
+		}
+		for _, m := range o.Moves {
+			g.Moves = append(g.Moves, &pgn.Move{Move: m})
+		}
+		if n := len(g.Moves); n > 0 {
+			g.Moves[n-1].Comments = []string{comment}
+		} else {
+			g.Comments = []string{comment}
+		}
+		if err := pw.Write(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match returns the openings as the openings of a match or tournament, which
+// play their lines from their starting positions.
+func Match(openings []Opening) []match.Opening {
+	res := make([]match.Opening, len(openings))
+	for i, o := range openings {
+		res[i] = match.Opening{FEN: o.Start, Moves: o.Moves}
+	}
+	return res
+}
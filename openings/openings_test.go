@@ -0,0 +1,187 @@
+package openings
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/match"
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+const games = `[Event "1"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 *
+
+[Event "2"]
+
+1. Nf3 Nc6 2. e4 e5 3. d4 *
+
+[Event "3"]
+
+1. d4 d5 2. c4 e6 1-0
+
+[Event "4"]
+
+1. e4 e5 2. Nf3 Nf6 *
+
+[Event "Too short"]
+
+1. e4 *
+
+[Event "Illegal"]
+
+1. e4 e4 2. Nf3 Nc6 *
+
+[Event "From a position"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. Kd2 Kd7 2. Ke3 Ke6 *
+
+[Event "Variant"]
+[Variant "Atomic"]
+
+1. e4 e5 2. Nf3 Nc6 *
+`
+
+func mustMoves(t *testing.T, s string) []chess.Move {
+	t.Helper()
+	var moves []chess.Move
+	for _, f := range strings.Fields(s) {
+		m, err := chess.ParseMove(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moves = append(moves, m)
+	}
+	return moves
+}
+
+func TestExtract(t *testing.T) {
+	got, err := Extract(strings.NewReader(games), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type summary struct {
+		Start string
+		Moves []chess.Move
+		FEN   string
+		Games int
+	}
+	var sums []summary
+	for _, o := range got {
+		sums = append(sums, summary{o.Start, o.Moves, o.Position.String(), o.Games})
+	}
+	want := []summary{
+		{"", mustMoves(t, "e2e4 e7e5 g1f3 b8c6"), "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3", 2},
+		{"", mustMoves(t, "d2d4 d7d5 c2c4 e7e6"), "rnbqkbnr/ppp2ppp/4p3/3p4/2PP4/8/PP2PPPP/RNBQKBNR w KQkq - 0 3", 1},
+		{"", mustMoves(t, "e2e4 e7e5 g1f3 g8f6"), "rnbqkb1r/pppp1ppp/5n2/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3", 1},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", mustMoves(t, "e1d2 e8d7 d2e3 d7e6"), "8/8/4k3/8/8/4K3/4P3/8 w - - 4 3", 1},
+	}
+	if diff := cmp.Diff(want, sums); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := Popular(got, 2); len(got) != 1 || got[0].Games != 2 {
+		t.Errorf("Popular: got %d openings, want the one with 2 games", len(got))
+	}
+}
+
+func TestExtract_NegativePlies(t *testing.T) {
+	if _, err := Extract(strings.NewReader(games), -1); err == nil {
+		t.Error("want an error")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	c, closer, err := refengine.New().Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+
+	white, _ := chess.ParseFEN("4k3/8/8/8/8/8/8/QQQ1K3 w - - 0 1")
+	black, _ := chess.ParseFEN("qqq1k3/8/8/8/8/8/8/4K3 w - - 0 1")
+	even, _ := chess.ParseFEN("4k3/4p3/8/8/8/8/4P3/4K3 b - - 0 1")
+	suite := []Opening{{Position: white}, {Position: black}, {Position: even}}
+	if err := Evaluate(context.Background(), c, uci.Search{Depth: 1}, suite); err != nil {
+		t.Fatal(err)
+	}
+	for i, o := range suite {
+		if !o.Evaluated {
+			t.Errorf("opening %d not evaluated", i)
+		}
+	}
+	if s := suite[0].Score; !s.Mate.Found && s.CP < 1000 {
+		t.Errorf("got %v for White with three queens, want a large advantage", s)
+	}
+	if s := suite[1].Score; !s.Mate.Found && s.CP > -1000 {
+		t.Errorf("got %v for Black with three queens, want a large disadvantage", s)
+	}
+
+	got := InEvalRange(suite, -100, 100)
+	if len(got) != 1 || got[0].Position != even {
+		t.Errorf("InEvalRange: got %d openings, want the even one", len(got))
+	}
+}
+
+func TestWriteEPD(t *testing.T) {
+	start, _ := chess.ParseFEN("4k3/8/8/8/8/8/4P3/4K3 b - - 0 10")
+	pos := start.Clone()
+	moves := mustMoves(t, "e8d7 e2e4 d7e6")
+	for _, m := range moves {
+		pos.Apply(m)
+	}
+	suite := []Opening{
+		{Start: start.String(), Moves: moves, Position: pos, Games: 3, Score: uci.Score{CP: 120}, Evaluated: true},
+		{Position: chess.StartingPosition(), Games: 1},
+	}
+	var b strings.Builder
+	if err := WriteEPD(&b, suite); err != nil {
+		t.Fatal(err)
+	}
+	want := `8/8/4k3/8/4P3/8/8/4K3 w - - c0 "10... Kd7 11. e4 Ke6"; c1 "3 games"; ce 120;
+rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - c1 "1 game";
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWritePGN(t *testing.T) {
+	suite, err := Extract(strings.NewReader(games), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suite[0].Score, suite[0].Evaluated = uci.Score{CP: 30}, true
+	var b strings.Builder
+	if err := WritePGN(&b, suite[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1. e4 e5 {2 games, +0.30} *"; !strings.Contains(b.String(), want) {
+		t.Errorf("output doesn't contain %q:\n%s", want, b.String())
+	}
+
+	reread, err := Extract(strings.NewReader(b.String()), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reread) != 1 || reread[0].Position.Hash() != suite[0].Position.Hash() {
+		t.Errorf("got %d openings back, want the written one", len(reread))
+	}
+}
+
+func TestMatch(t *testing.T) {
+	moves := mustMoves(t, "e2e4")
+	got := Match([]Opening{{Start: "fen", Moves: moves}, {Moves: moves}})
+	want := []match.Opening{{FEN: "fen", Moves: moves}, {Moves: moves}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
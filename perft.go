@@ -0,0 +1,131 @@
+package chess
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Perft returns the number of leaf nodes in the tree of legal moves from p to
+// the given depth. It is used to validate move generation against known counts.
+// Some well-known reference counts, from the Chess Programming Wiki, are:
+//
+//	Position                                                                  Depth  Nodes
+//	rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1                  5      4865609
+//	r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1      4      4085603
+//	8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1                                 5      674624
+//	r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1          4      422333
+//	rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8                 4      2103487
+//	r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10  4      3894594
+//
+// p is restored before Perft returns.
+func Perft(p *Position, depth int) int {
+	if depth <= 0 {
+		return 1
+	}
+	moves := p.LegalMoves()
+	if depth == 1 {
+		return len(moves)
+	}
+	n := 0
+	for _, m := range moves {
+		p.Apply(m)
+		n += Perft(p, depth-1)
+		p.Unapply()
+	}
+	return n
+}
+
+// Divide is like Perft, but returns the number of leaf nodes under each legal
+// move. Comparing the result with another move generator narrows down where
+// the two disagree.
+func Divide(p *Position, depth int) map[Move]int {
+	res := make(map[Move]int)
+	if depth <= 0 {
+		return res
+	}
+	for _, m := range p.LegalMoves() {
+		p.Apply(m)
+		res[m] = Perft(p, depth-1)
+		p.Unapply()
+	}
+	return res
+}
+
+// ParallelPerft is like Perft, but counts with the given number of goroutines,
+// or with runtime.GOMAXPROCS(0) if workers is 0 or less. Each goroutine plays
+// moves in its own copy of p.
+func ParallelPerft(p *Position, depth, workers int) int {
+	if depth <= 0 {
+		return 1
+	}
+	n := 0
+	for _, c := range ParallelDivide(p, depth, workers) {
+		n += c
+	}
+	return n
+}
+
+// ParallelDivide is like Divide, but counts with the given number of
+// goroutines, or with runtime.GOMAXPROCS(0) if workers is 0 or less.
+func ParallelDivide(p *Position, depth, workers int) map[Move]int {
+	res := make(map[Move]int)
+	if depth <= 0 {
+		return res
+	}
+
+	// Split the tree into the subtrees after each move and reply, or after
+	// each move for shallow counts, so that there are enough of them to
+	// keep the goroutines busy until the end.
+	var tasks [][]Move
+	for _, m := range p.LegalMoves() {
+		res[m] = 0
+		if depth < 3 {
+			tasks = append(tasks, []Move{m})
+			continue
+		}
+		p.Apply(m)
+		for _, r := range p.LegalMoves() {
+			tasks = append(tasks, []Move{m, r})
+		}
+		p.Unapply()
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
+	counts := make([]int, len(tasks))
+	var (
+		next int64 // The index of the next task.
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q := p.Clone()
+			for {
+				i := int(atomic.AddInt64(&next, 1) - 1)
+				if i >= len(tasks) {
+					return
+				}
+				for _, m := range tasks[i] {
+					q.Apply(m)
+				}
+				counts[i] = Perft(q, depth-len(tasks[i]))
+				for range tasks[i] {
+					q.Unapply()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, t := range tasks {
+		res[t[0]] += counts[i]
+	}
+	return res
+}
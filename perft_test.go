@@ -0,0 +1,170 @@
+package chess
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPerft(t *testing.T) {
+	// Reference counts from https://www.chessprogramming.org/Perft_Results.
+	cases := []struct {
+		fen    string
+		counts []int // Indexed by depth - 1.
+	}{
+		{StartingFEN, []int{20, 400, 8902, 197281}},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", []int{48, 2039, 97862}},
+		{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", []int{14, 191, 2812, 43238}},
+		{"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", []int{6, 264, 9467}},
+		{"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", []int{44, 1486, 62379}},
+		{"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", []int{46, 2079, 89890}},
+
+		// Chess960.
+		{"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", []int{21, 528, 12189}},
+		{"2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9", []int{21, 807, 18002}},
+		{"b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9", []int{20, 479, 10471}},
+		{"qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w hf - 0 9", []int{22, 593, 13440}},
+		{"1nbbnrkr/p1p1ppp1/3p4/1p3P1p/3Pq2P/8/PPP1P1P1/QNBBNRKR w HFhf - 0 9", []int{28, 1120, 31058}},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := p.Clone()
+		for i, want := range tc.counts {
+			depth := i + 1
+			if testing.Short() && depth > 2 {
+				break
+			}
+			if got := Perft(p, depth); got != want {
+				t.Errorf("%s: Perft(%d) = %d, want %d", tc.fen, depth, got, want)
+			}
+		}
+		if got := p.String(); got != want.String() {
+			t.Errorf("position changed to %q after perft", got)
+		}
+	}
+}
+
+func TestDivide(t *testing.T) {
+	got := Divide(StartingPosition(), 3)
+	if len(got) != 20 {
+		t.Fatalf("got %d moves, want 20", len(got))
+	}
+	total := 0
+	for _, n := range got {
+		total += n
+	}
+	if total != 8902 {
+		t.Errorf("got %d nodes in total, want 8902", total)
+	}
+	for m, want := range map[Move]int{{From: E2, To: E4}: 600, {From: G1, To: F3}: 440, {From: A2, To: A3}: 380} {
+		if got[m] != want {
+			t.Errorf("%v: got %d nodes, want %d", m, got[m], want)
+		}
+	}
+}
+
+func TestParallelPerft(t *testing.T) {
+	cases := []struct {
+		fen   string
+		depth int
+		want  int
+	}{
+		{StartingFEN, 0, 1},
+		{StartingFEN, 1, 20},
+		{StartingFEN, 2, 400},
+		{StartingFEN, 4, 197281},
+		{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 4, 43238},
+		{"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 3, 9467},
+		{"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", 3, 12189},
+
+		// Checkmate and stalemate.
+		{"7k/6Q1/6K1/8/8/8/8/8 b - - 0 1", 3, 0},
+		{"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", 3, 0},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := p.String()
+		for _, workers := range []int{0, 1, 3} {
+			if got := ParallelPerft(p, tc.depth, workers); got != tc.want {
+				t.Errorf("%s: ParallelPerft(%d) with %d workers = %d, want %d", tc.fen, tc.depth, workers, got, tc.want)
+			}
+		}
+		if got := p.String(); got != want {
+			t.Errorf("position changed to %q after perft", got)
+		}
+	}
+}
+
+func TestParallelDivide(t *testing.T) {
+	// A position where some moves lead to checkmate, which have no replies
+	// to split the count on.
+	p, err := ParseFEN("6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, depth := range []int{1, 2, 3} {
+		want := Divide(p, depth)
+		got := ParallelDivide(p, depth, 4)
+		if len(got) != len(want) {
+			t.Errorf("depth %d: got %d moves, want %d", depth, len(got), len(want))
+		}
+		for m, n := range want {
+			if got[m] != n {
+				t.Errorf("depth %d: %v: got %d nodes, want %d", depth, m, got[m], n)
+			}
+		}
+	}
+}
+
+// perftSuite is the positions of the reference counts in the doc comment of
+// Perft, for benchmarks.
+var perftSuite = []string{
+	StartingFEN,
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+}
+
+// benchmarkPerft counts every position of the suite to depth 3 with perft,
+// reporting the speed in nodes per second.
+func benchmarkPerft(b *testing.B, perft func(p *Position, depth int) int) {
+	var positions []*Position
+	for _, fen := range perftSuite {
+		p, err := ParseFEN(fen)
+		if err != nil {
+			b.Fatal(err)
+		}
+		positions = append(positions, p)
+	}
+	b.ResetTimer()
+	start := time.Now()
+	nodes := 0
+	for i := 0; i < b.N; i++ {
+		for _, p := range positions {
+			nodes += perft(p, 3)
+		}
+	}
+	b.ReportMetric(float64(nodes)/time.Since(start).Seconds(), "nodes/s")
+}
+
+func BenchmarkPerft(b *testing.B) {
+	benchmarkPerft(b, Perft)
+}
+
+func BenchmarkParallelPerft(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			benchmarkPerft(b, func(p *Position, depth int) int {
+				return ParallelPerft(p, depth, workers)
+			})
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package pgn
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clfs/chess"
+)
+
+// Commands are the commands embedded in the comments of a move, as written by
+// Lichess, ChessBase and others, such as "[%clk 0:03:00]" and "[%eval 0.31]".
+type Commands struct {
+	// Clock is the time left on the mover's clock after the move, from
+	// "%clk", if HasClock is set.
+	Clock    time.Duration
+	HasClock bool
+
+	Eval       *Eval       // The evaluation after the move, from "%eval", or nil.
+	Highlights []Highlight // Colored squares, from "%csl".
+	Arrows     []Arrow     // Colored arrows, from "%cal".
+}
+
+// IsZero reports whether there are no commands.
+func (c *Commands) IsZero() bool {
+	return !c.HasClock && c.Eval == nil && len(c.Highlights) == 0 && len(c.Arrows) == 0
+}
+
+// Eval is an evaluation of a position, from White's point of view.
+type Eval struct {
+	CP    int // The score in centipawns, if Mate is 0.
+	Mate  int // Moves until mate, negative if Black mates, or 0 if there is no mate.
+	Depth int // The depth of the search, or 0 if unknown.
+}
+
+// MarkColor is the color of a highlight or an arrow.
+type MarkColor byte
+
+// The colors of highlights and arrows.
+const (
+	Green  MarkColor = 'G'
+	Red    MarkColor = 'R'
+	Yellow MarkColor = 'Y'
+	Blue   MarkColor = 'B'
+)
+
+func parseMarkColor(b byte) (MarkColor, bool) {
+	switch c := MarkColor(b); c {
+	case Green, Red, Yellow, Blue:
+		return c, true
+	}
+	return 0, false
+}
+
+// Highlight is a colored square, as in "Gd4".
+type Highlight struct {
+	Color  MarkColor
+	Square chess.Square
+}
+
+// Arrow is a colored arrow between two squares, as in "Ge2e4".
+type Arrow struct {
+	Color    MarkColor
+	From, To chess.Square
+}
+
+// commandRE matches an embedded command, with its name and arguments.
+var commandRE = regexp.MustCompile(`\[%(\w+)\s+([^\]]*)\]`)
+
+// addComment adds a comment to m, moving the commands it recognizes to
+// m.Commands. A comment left empty is dropped. Commands with invalid arguments
+// are left in the comment.
+func (m *Move) addComment(c string) {
+	if !strings.Contains(c, "[%") {
+		m.Comments = append(m.Comments, c)
+		return
+	}
+	rest := commandRE.ReplaceAllStringFunc(c, func(s string) string {
+		sub := commandRE.FindStringSubmatch(s)
+		if !m.Commands.parse(sub[1], strings.TrimSpace(sub[2])) {
+			return s
+		}
+		return ""
+	})
+	if rest = strings.TrimSpace(rest); rest != "" {
+		m.Comments = append(m.Comments, rest)
+	}
+}
+
+// parse sets the command name with the arguments args, and reports whether it
+// is a known command with valid arguments.
+func (c *Commands) parse(name, args string) bool {
+	switch name {
+	case "clk":
+		d, ok := parseClock(args)
+		if ok {
+			c.Clock, c.HasClock = d, true
+		}
+		return ok
+	case "eval":
+		e, ok := parseEval(args)
+		if ok {
+			c.Eval = e
+		}
+		return ok
+	case "csl":
+		var hs []Highlight
+		for _, s := range strings.Split(args, ",") {
+			s = strings.TrimSpace(s)
+			if len(s) != 3 {
+				return false
+			}
+			color, ok := parseMarkColor(s[0])
+			sq, err := chess.ParseSquare(s[1:])
+			if !ok || err != nil {
+				return false
+			}
+			hs = append(hs, Highlight{color, sq})
+		}
+		c.Highlights = append(c.Highlights, hs...)
+		return true
+	case "cal":
+		var as []Arrow
+		for _, s := range strings.Split(args, ",") {
+			s = strings.TrimSpace(s)
+			if len(s) != 5 {
+				return false
+			}
+			color, ok := parseMarkColor(s[0])
+			from, err1 := chess.ParseSquare(s[1:3])
+			to, err2 := chess.ParseSquare(s[3:])
+			if !ok || err1 != nil || err2 != nil {
+				return false
+			}
+			as = append(as, Arrow{color, from, to})
+		}
+		c.Arrows = append(c.Arrows, as...)
+		return true
+	}
+	return false
+}
+
+// parseClock parses a time in the form h:mm:ss, with optional fractions of a
+// second.
+func parseClock(s string) (time.Duration, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	sec, err3 := strconv.ParseFloat(parts[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil || h < 0 || m < 0 || m > 59 || sec < 0 || sec >= 60 {
+		return 0, false
+	}
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+	return d + time.Duration(math.Round(sec*1000))*time.Millisecond, true
+}
+
+// parseEval parses an evaluation in pawns, as in "0.31", or a mate, as in
+// "#-3", optionally followed by a comma and the depth.
+func parseEval(s string) (*Eval, bool) {
+	var e Eval
+	if v, depth, ok := strings.Cut(s, ","); ok {
+		d, err := strconv.Atoi(strings.TrimSpace(depth))
+		if err != nil || d < 0 {
+			return nil, false
+		}
+		s, e.Depth = strings.TrimSpace(v), d
+	}
+	if strings.HasPrefix(s, "#") {
+		n, err := strconv.Atoi(s[1:])
+		if err != nil || n == 0 {
+			return nil, false
+		}
+		e.Mate = n
+		return &e, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, false
+	}
+	e.CP = int(math.Round(f * 100))
+	return &e, true
+}
+
+// String returns the commands as they are embedded in a comment, as in
+// "[%eval 0.31] [%clk 0:03:00]", or "" if there are none.
+func (c *Commands) String() string {
+	var cmds []string
+	if e := c.Eval; e != nil {
+		cmds = append(cmds, "[%eval "+e.String()+"]")
+	}
+	if c.HasClock {
+		cmds = append(cmds, "[%clk "+formatClock(c.Clock)+"]")
+	}
+	if len(c.Highlights) > 0 {
+		var hs []string
+		for _, h := range c.Highlights {
+			hs = append(hs, string(h.Color)+h.Square.String())
+		}
+		cmds = append(cmds, "[%csl "+strings.Join(hs, ",")+"]")
+	}
+	if len(c.Arrows) > 0 {
+		var as []string
+		for _, a := range c.Arrows {
+			as = append(as, string(a.Color)+a.From.String()+a.To.String())
+		}
+		cmds = append(cmds, "[%cal "+strings.Join(as, ",")+"]")
+	}
+	return strings.Join(cmds, " ")
+}
+
+// String returns the evaluation as in an "%eval" command, such as "0.31",
+// "#-3" or "0.31,20".
+func (e *Eval) String() string {
+	var s string
+	if e.Mate != 0 {
+		s = "#" + strconv.Itoa(e.Mate)
+	} else {
+		s = fmt.Sprintf("%.2f", float64(e.CP)/100)
+	}
+	if e.Depth > 0 {
+		s += "," + strconv.Itoa(e.Depth)
+	}
+	return s
+}
+
+// formatClock formats d as h:mm:ss, with tenths of a second if it has any.
+func formatClock(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	h, m := d/time.Hour, d%time.Hour/time.Minute
+	tenths := d % time.Minute / (100 * time.Millisecond)
+	if tenths%10 != 0 {
+		return fmt.Sprintf("%d:%02d:%02d.%d", h, m, tenths/10, tenths%10)
+	}
+	return fmt.Sprintf("%d:%02d:%02d", h, m, tenths/10)
+}
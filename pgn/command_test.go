@@ -0,0 +1,117 @@
+package pgn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMove_addComment(t *testing.T) {
+	tests := []struct {
+		comment  string
+		comments []string
+		commands Commands
+	}{
+		{"Good move", []string{"Good move"}, Commands{}},
+		{"[%clk 1:59:58]", nil, Commands{Clock: time.Hour + 59*time.Minute + 58*time.Second, HasClock: true}},
+		{"[%clk 0:00:05.3]", nil, Commands{Clock: 5300 * time.Millisecond, HasClock: true}},
+		{"[%eval 0.31]", nil, Commands{Eval: &Eval{CP: 31}}},
+		{"[%eval -1.5,24]", nil, Commands{Eval: &Eval{CP: -150, Depth: 24}}},
+		{"[%eval #-3]", nil, Commands{Eval: &Eval{Mate: -3}}},
+		{"[%csl Gd4,Re5]", nil, Commands{Highlights: []Highlight{{Green, chess.D4}, {Red, chess.E5}}}},
+		{"[%cal Ge2e4, Bg1f3]", nil, Commands{Arrows: []Arrow{{Green, chess.E2, chess.E4}, {Blue, chess.G1, chess.F3}}}},
+		{
+			"Sharp! [%eval 0.17] [%clk 0:00:30]",
+			[]string{"Sharp!"},
+			Commands{Eval: &Eval{CP: 17}, Clock: 30 * time.Second, HasClock: true},
+		},
+		// Unknown commands and invalid arguments stay in the comment.
+		{"[%emt 0:00:03]", []string{"[%emt 0:00:03]"}, Commands{}},
+		{"[%clk soon]", []string{"[%clk soon]"}, Commands{}},
+		{"[%eval #0]", []string{"[%eval #0]"}, Commands{}},
+		{"[%csl Pd4]", []string{"[%csl Pd4]"}, Commands{}},
+		{"[%cal Ge2]", []string{"[%cal Ge2]"}, Commands{}},
+	}
+	for _, tt := range tests {
+		var m Move
+		m.addComment(tt.comment)
+		if diff := cmp.Diff(tt.comments, m.Comments); diff != "" {
+			t.Errorf("%q: comments mismatch (-want +got):\n%s", tt.comment, diff)
+		}
+		if diff := cmp.Diff(tt.commands, m.Commands); diff != "" {
+			t.Errorf("%q: commands mismatch (-want +got):\n%s", tt.comment, diff)
+		}
+	}
+}
+
+func TestCommands_String(t *testing.T) {
+	tests := []struct {
+		c    Commands
+		want string
+	}{
+		{Commands{}, ""},
+		{Commands{Clock: 3 * time.Minute, HasClock: true}, "[%clk 0:03:00]"},
+		{Commands{Clock: 0, HasClock: true}, "[%clk 0:00:00]"},
+		{Commands{Clock: 2*time.Hour + 5*time.Second + 390*time.Millisecond, HasClock: true}, "[%clk 2:00:05.3]"},
+		{Commands{Eval: &Eval{CP: -5}}, "[%eval -0.05]"},
+		{Commands{Eval: &Eval{Mate: 2, Depth: 30}}, "[%eval #2,30]"},
+		{
+			Commands{
+				Clock:      time.Minute,
+				HasClock:   true,
+				Eval:       &Eval{CP: 31},
+				Highlights: []Highlight{{Yellow, chess.A1}},
+				Arrows:     []Arrow{{Red, chess.H7, chess.H8}, {Green, chess.A2, chess.A4}},
+			},
+			"[%eval 0.31] [%clk 0:01:00] [%csl Ya1] [%cal Rh7h8,Ga2a4]",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCommands_RoundTrip(t *testing.T) {
+	const game = `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+
+1. e4 {Best by test} {[%eval 0.31] [%clk 0:03:00] [%csl Gd4] [%cal Ge2e4]} 1...
+c5 {[%emt 0:00:02]} {[%clk 0:02:59.5]} *
+
+`
+	g, err := NewReader(strings.NewReader(game)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Moves[0].Commands.String(); got != "[%eval 0.31] [%clk 0:03:00] [%csl Gd4] [%cal Ge2e4]" {
+		t.Errorf("commands of e4: got %q", got)
+	}
+	var b bytes.Buffer
+	if err := NewWriter(&b).Write(g); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(game, b.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestImport_Commands(t *testing.T) {
+	g, _, err := Import("1. e4 {[%clk 0:01:00]} e5 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := g.Moves[0].Commands; !c.HasClock || c.Clock != time.Minute {
+		t.Errorf("got commands %+v, want a clock of 1m", c)
+	}
+}
@@ -0,0 +1,329 @@
+package pgn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+// Import reads a game from loosely formatted move text, such as a game pasted
+// from a book or an email. It is more forgiving than Reader:
+//
+//   - Tag pairs are optional, and a FEN on a line of its own sets the
+//     starting position.
+//   - Move numbers may be missing, wrong, or attached to moves ("1.e4"), and
+//     moves may be separated by commas.
+//   - Moves may be written in long algebraic notation ("Ng1-f3", "e2e4"), with
+//     lowercase or figurine piece letters ("nf3", "♘f3"), with ":" for
+//     captures, or with check marks like "ch" and "++".
+//   - Variations are skipped, and text after the result is ignored.
+//   - A missing result is inferred from the final position, if it is decided.
+//
+// Import also returns notes describing the assumptions it made, such as an
+// inferred result. Moves are checked for legality, and the SAN of each move is
+// rewritten in standard form. Import returns a *ParseError if a move can't be
+// read.
+func Import(text string) (*Game, []string, error) {
+	im := &importer{text: text, line: 1, game: new(Game)}
+	if err := im.run(); err != nil {
+		return nil, nil, err
+	}
+	return im.game, im.notes, nil
+}
+
+type importer struct {
+	text string
+	line int // The current line, starting at 1.
+
+	game   *Game
+	pos    *chess.Position
+	played *chess.Game // Tracks the outcome.
+	result string      // The result as written, if any.
+	notes  []string
+}
+
+// note adds a note, unless it was already added.
+func (im *importer) note(format string, args ...any) {
+	s := fmt.Sprintf(format, args...)
+	for _, n := range im.notes {
+		if n == s {
+			return
+		}
+	}
+	im.notes = append(im.notes, s)
+}
+
+func (im *importer) errorf(format string, args ...any) error {
+	return &ParseError{Line: im.line, Err: fmt.Errorf(format, args...)}
+}
+
+func (im *importer) run() error {
+	if err := im.header(); err != nil {
+		return err
+	}
+
+	depth := 0 // Of variations.
+	for im.text != "" && im.result == "" {
+		c := im.text[0]
+		switch {
+		case c == '\n':
+			im.line++
+			im.text = im.text[1:]
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			im.text = im.text[1:]
+		case c == '{':
+			end := strings.IndexByte(im.text, '}')
+			if end < 0 {
+				return im.errorf("unterminated comment")
+			}
+			comment := im.text[1:end]
+			im.line += strings.Count(comment, "\n")
+			im.text = im.text[end+1:]
+			if depth == 0 {
+				im.comment(strings.Join(strings.Fields(comment), " "))
+			}
+		case c == ';':
+			end := strings.IndexByte(im.text, '\n')
+			if end < 0 {
+				end = len(im.text)
+			}
+			comment := strings.TrimSpace(im.text[1:end])
+			im.text = im.text[end:]
+			if depth == 0 {
+				im.comment(comment)
+			}
+		case c == '(':
+			depth++
+			im.text = im.text[1:]
+			im.note("skipped variations")
+		case c == ')':
+			if depth == 0 {
+				return im.errorf(`unexpected ")"`)
+			}
+			depth--
+			im.text = im.text[1:]
+		default:
+			end := strings.IndexAny(im.text, " \t\r\n,{};()")
+			if end < 0 {
+				end = len(im.text)
+			}
+			word := im.text[:end]
+			im.text = im.text[end:]
+			if depth > 0 {
+				continue
+			}
+			if isMoveNumber(word) && strings.HasPrefix(im.text, ")") {
+				im.text = im.text[1:] // Numbered like "1)".
+			}
+			if err := im.word(word); err != nil {
+				return err
+			}
+		}
+	}
+	if depth > 0 {
+		return im.errorf("unterminated variation")
+	}
+	if strings.TrimSpace(im.text) != "" {
+		im.note("ignored text after the result")
+	}
+
+	im.finish()
+	return nil
+}
+
+// header reads any tag pairs, or a FEN on a line of its own, and sets up the
+// starting position.
+func (im *importer) header() error {
+	for {
+		line, rest, _ := strings.Cut(im.text, "\n")
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" && rest != "":
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name, value, ok := strings.Cut(line[1:len(line)-1], " ")
+			value = strings.TrimSpace(value)
+			if !ok || len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+				return im.errorf("invalid tag pair %s", line)
+			}
+			value = strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(value[1 : len(value)-1])
+			im.game.Tags = append(im.game.Tags, Tag{Name: name, Value: value})
+		case im.game.Tag("FEN") == "" && strings.Count(line, "/") == 7:
+			if _, err := chess.ParseFEN(line); err != nil {
+				return im.errorf("%v", err)
+			}
+			im.game.Tags = append(im.game.Tags, Tag{Name: "SetUp", Value: "1"}, Tag{Name: "FEN", Value: line})
+		default:
+			pos, err := im.game.StartingPosition()
+			if err != nil {
+				return im.errorf("%v", err)
+			}
+			if im.game.Tag("FEN") == "" {
+				im.note("assumed the standard starting position")
+			}
+			im.pos = pos
+			im.played = chess.NewGameFromPosition(pos)
+			return nil
+		}
+		im.text = rest
+		im.line++
+	}
+}
+
+// comment attaches a comment to the last move, or to the game if there are no
+// moves yet.
+func (im *importer) comment(c string) {
+	if c == "" {
+		return
+	}
+	if n := len(im.game.Moves); n > 0 {
+		im.game.Moves[n-1].addComment(c)
+	} else {
+		im.game.Comments = append(im.game.Comments, c)
+	}
+}
+
+// word reads a word of movetext: a move number, a move, a result, or a move
+// number attached to a move.
+func (im *importer) word(w string) error {
+	if r, ok := importResult(w); ok {
+		im.result = r
+		return nil
+	}
+
+	// Move numbers, such as "12.", "12...", "12…" or "12)".
+	i := 0
+	for i < len(w) && w[i] >= '0' && w[i] <= '9' {
+		i++
+	}
+	if i > 0 && !strings.HasPrefix(w, "0-0") {
+		n := 0
+		fmt.Sscan(w[:i], &n)
+		w = strings.TrimLeft(w[i:], ".)…")
+		if n != im.pos.FullmoveNumber() {
+			im.note("ignored move numbers that don't match the moves")
+		}
+		if w == "" {
+			return nil
+		}
+	}
+	switch {
+	case strings.Trim(w, ".…") == "":
+		return nil // Stray periods, as in "1. ... e5".
+	case w == "e.p." || w == "ep" || w == "ch" || w == "mate" || strings.Trim(w, "+#") == "":
+		return nil // Detached from the move, as in "exd6 e.p.".
+	}
+	return im.move(w)
+}
+
+// importResult parses the ways of writing a result, returning the termination
+// marker for it.
+func importResult(w string) (string, bool) {
+	switch w {
+	case "1-0", "0-1", "*":
+		return w, true
+	case "1/2-1/2", "½-½", "1/2", "½", "0.5-0.5", "=":
+		return "1/2-1/2", true
+	}
+	return "", false
+}
+
+// move reads a move and plays it.
+func (im *importer) move(w string) error {
+	if im.played.Outcome() != chess.NoOutcome {
+		return im.errorf("move %q after the game ended by %v", w, im.played.Method())
+	}
+
+	// Suffix annotations.
+	var nags []int
+	san := strings.TrimRight(w, "!?")
+	if suffix := w[len(san):]; suffix != "" {
+		if n, ok := suffixNAGs[suffix]; ok {
+			nags = append(nags, n)
+		}
+	}
+
+	m, err := im.parseMove(san)
+	if err != nil {
+		return im.errorf("%v", err)
+	}
+	im.game.Moves = append(im.game.Moves, &Move{Move: m, SAN: chess.FormatSAN(im.pos, m), NAGs: nags})
+	im.pos.Apply(m)
+	im.played.Play(m)
+	return nil
+}
+
+// figurines maps figurine piece symbols to SAN letters.
+var figurines = strings.NewReplacer(
+	"♔", "K", "♕", "Q", "♖", "R", "♗", "B", "♘", "N", "♙", "",
+	"♚", "K", "♛", "Q", "♜", "R", "♝", "B", "♞", "N", "♟", "",
+)
+
+// parseMove parses a move in any of the accepted notations.
+func (im *importer) parseMove(s string) (chess.Move, error) {
+	w := s
+	if strings.ContainsAny(w, "♔♕♖♗♘♙♚♛♜♝♞♟") {
+		w = figurines.Replace(w)
+		im.note("read figurine piece symbols as letters")
+	}
+	for _, check := range []string{"++", "+", "#", "ch", "mate", "e.p.", "ep"} {
+		w = strings.TrimSuffix(w, check)
+	}
+	w = strings.ReplaceAll(w, ":", "x")
+	switch strings.ToUpper(w) {
+	case "O-O", "0-0":
+		w = "O-O"
+	case "O-O-O", "0-0-0":
+		w = "O-O-O"
+	}
+
+	if m, err := chess.ParseSAN(im.pos, w); err == nil {
+		return m, nil
+	}
+	if m, ok := im.parseLong(w); ok {
+		im.note("read moves in long algebraic notation")
+		return m, nil
+	}
+	if w != "" && strings.IndexByte("kqrbn", w[0]) >= 0 {
+		if m, err := chess.ParseSAN(im.pos, strings.ToUpper(w[:1])+w[1:]); err == nil {
+			im.note("read lowercase piece letters as pieces")
+			return m, nil
+		}
+	}
+	return chess.Move{}, fmt.Errorf("invalid or illegal move %q", s)
+}
+
+// parseLong parses a legal move in long algebraic notation, such as "e2e4",
+// "e2-e4", "Ng1-f3", "Bb5xc6" or "e7-e8=Q".
+func (im *importer) parseLong(w string) (chess.Move, bool) {
+	if w != "" && strings.IndexByte("KQRBNP", w[0]) >= 0 {
+		w = w[1:]
+	}
+	w = strings.NewReplacer("-", "", "x", "", "=", "").Replace(w)
+	m, err := chess.ParseMove(strings.ToLower(w))
+	if err != nil || !im.pos.IsLegal(m) {
+		return chess.Move{}, false
+	}
+	return m, true
+}
+
+// finish sets the result, inferring it if it wasn't written.
+func (im *importer) finish() {
+	outcome := im.played.Outcome()
+	switch {
+	case im.result != "":
+		im.game.Result, _ = parseResult(im.result)
+		if outcome != chess.NoOutcome && outcome != im.game.Result {
+			im.note("the result %v doesn't match the final position, which is %v by %v", im.game.Result, outcome, im.played.Method())
+		}
+	case outcome != chess.NoOutcome:
+		im.game.Result = outcome
+		im.note("inferred the result %v from the final position (%v)", outcome, im.played.Method())
+	default:
+		im.game.Result = chess.NoOutcome
+		im.note("no result was given, so assumed the game is unfinished")
+	}
+	if im.game.Tag("Result") == "" {
+		im.game.Tags = append(im.game.Tags, Tag{Name: "Result", Value: im.game.Result.String()})
+	}
+}
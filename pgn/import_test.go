@@ -0,0 +1,178 @@
+package pgn
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImport(t *testing.T) {
+	cases := []struct {
+		name   string
+		text   string
+		moves  string
+		result chess.Outcome
+		notes  []string
+	}{
+		{
+			name:   "plain",
+			text:   "1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 1/2-1/2",
+			moves:  "e4 e5 Nf3 Nc6 Bb5 a6",
+			result: chess.Draw,
+			notes:  []string{"assumed the standard starting position"},
+		},
+		{
+			name:   "attached numbers and commas",
+			text:   "1.e4, e5 2.Nf3, Nc6\n3.Bb5 3...a6 ½-½",
+			moves:  "e4 e5 Nf3 Nc6 Bb5 a6",
+			result: chess.Draw,
+			notes:  []string{"assumed the standard starting position"},
+		},
+		{
+			name:   "no numbers",
+			text:   "d4 d5 c4 e6",
+			moves:  "d4 d5 c4 e6",
+			result: chess.NoOutcome,
+			notes: []string{
+				"assumed the standard starting position",
+				"no result was given, so assumed the game is unfinished",
+			},
+		},
+		{
+			name:   "long algebraic",
+			text:   "1) e2-e4 e7-e5 2) Ng1-f3 Nb8-c6 3) Bf1-b5 a7a6 4) Bb5:c6 d7xc6 5) O-O",
+			moves:  "e4 e5 Nf3 Nc6 Bb5 a6 Bxc6 dxc6 O-O",
+			result: chess.NoOutcome,
+			notes: []string{
+				"assumed the standard starting position",
+				"read moves in long algebraic notation",
+				"no result was given, so assumed the game is unfinished",
+			},
+		},
+		{
+			name:   "lowercase and figurines",
+			text:   "1. e4 e5 2. nf3 ♘c6 3. ♗c4 bc5",
+			moves:  "e4 e5 Nf3 Nc6 Bc4 Bc5",
+			result: chess.NoOutcome,
+			notes: []string{
+				"assumed the standard starting position",
+				"read lowercase piece letters as pieces",
+				"read figurine piece symbols as letters",
+				"no result was given, so assumed the game is unfinished",
+			},
+		},
+		{
+			name:   "inferred checkmate",
+			text:   "1. f3 e5 2. g4 Qh4 mate",
+			moves:  "f3 e5 g4 Qh4#",
+			result: chess.BlackWon,
+			notes: []string{
+				"assumed the standard starting position",
+				"inferred the result 0-1 from the final position (checkmate)",
+			},
+		},
+		{
+			name:   "variations and trailing text",
+			text:   "1. e4 (1. d4 d5) e5 {main line} 2. Nf3 1-0 Thanks for the game!",
+			moves:  "e4 e5 Nf3",
+			result: chess.WhiteWon,
+			notes: []string{
+				"assumed the standard starting position",
+				"skipped variations",
+				"ignored text after the result",
+			},
+		},
+		{
+			name:   "wrong numbers",
+			text:   "7. e4 e5 8. Nf3",
+			moves:  "e4 e5 Nf3",
+			result: chess.NoOutcome,
+			notes: []string{
+				"assumed the standard starting position",
+				"ignored move numbers that don't match the moves",
+				"no result was given, so assumed the game is unfinished",
+			},
+		},
+		{
+			name:   "FEN",
+			text:   "4k3/8/8/8/8/8/8/4K2R w K - 0 1\n\n1. O-O Kd7 2. Rd1+ ch Kc6 *",
+			moves:  "O-O Kd7 Rd1+ Kc6",
+			result: chess.NoOutcome,
+		},
+		{
+			name:   "tags",
+			text:   "[White \"Anderssen\"]\n[Black \"Kieseritzky\"]\n\n1. e4 e5 0-1",
+			moves:  "e4 e5",
+			result: chess.BlackWon,
+			notes:  []string{"assumed the standard starting position"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, notes, err := Import(tc.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(strings.Fields(tc.moves), mainLine(g.Moves)); diff != "" {
+				t.Errorf("moves mismatch (-want +got):\n%s", diff)
+			}
+			if g.Result != tc.result {
+				t.Errorf("result: want %v, got %v", tc.result, g.Result)
+			}
+			if g.Tag("Result") != tc.result.String() {
+				t.Errorf("Result tag: want %q, got %q", tc.result, g.Tag("Result"))
+			}
+			if diff := cmp.Diff(tc.notes, notes); diff != "" {
+				t.Errorf("notes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImport_Annotations(t *testing.T) {
+	g, _, err := Import("{Opening notes} 1. e4!? e5 {solid} 2. Qh5?! ; aggressive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"Opening notes"}, g.Comments); diff != "" {
+		t.Errorf("game comments mismatch (-want +got):\n%s", diff)
+	}
+	want := []*Move{
+		{SAN: "e4", NAGs: []int{5}},
+		{SAN: "e5", Comments: []string{"solid"}},
+		{SAN: "Qh5", NAGs: []int{6}, Comments: []string{"aggressive"}},
+	}
+	for _, m := range g.Moves {
+		m.Move = chess.Move{}
+	}
+	if diff := cmp.Diff(want, g.Moves); diff != "" {
+		t.Errorf("moves mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestImport_Error(t *testing.T) {
+	cases := []struct {
+		text string
+		line int
+	}{
+		{"1. e4 e5\n2. Ke3", 2},
+		{"1. e4 {unterminated", 1},
+		{"1. e4 (1. d4", 1},
+		{"1. f3 e5 2. g4 Qh4 3. a3", 1},
+		{"[White Anderssen]\n1. e4", 1},
+	}
+	for _, tc := range cases {
+		_, _, err := Import(tc.text)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Import(%q): want *ParseError, got %v", tc.text, err)
+			continue
+		}
+		if pe.Line != tc.line {
+			t.Errorf("Import(%q): want line %d, got %d", tc.text, tc.line, pe.Line)
+		}
+	}
+}
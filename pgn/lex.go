@@ -0,0 +1,232 @@
+package pgn
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF      tokenKind = iota
+	tokLBracket           // [
+	tokRBracket           // ]
+	tokLParen             // (
+	tokRParen             // )
+	tokPeriod             // .
+	tokString             // A quoted string, unescaped.
+	tokComment            // A brace or rest-of-line comment, trimmed.
+	tokNAG                // A Numeric Annotation Glyph, such as $1.
+	tokSuffix             // A suffix annotation, such as !?.
+	tokSymbol             // A tag name, move number, move or termination marker.
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of input"
+	case tokComment:
+		return "comment"
+	}
+	return strconv.Quote(t.text)
+}
+
+// suffixNAGs maps suffix annotations to their NAGs.
+var suffixNAGs = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+// nag returns the NAG of a tokNAG or tokSuffix token.
+func (t token) nag() (int, bool) {
+	if t.kind == tokSuffix {
+		n, ok := suffixNAGs[t.text]
+		return n, ok
+	}
+	n, err := strconv.Atoi(t.text[1:])
+	return n, err == nil && n >= 0 && n <= 255
+}
+
+// peek returns the next token without consuming it.
+func (r *Reader) peek() (token, error) {
+	if !r.peeked {
+		t, err := r.lex()
+		if err != nil {
+			return t, err
+		}
+		r.tok, r.peeked = t, true
+	}
+	return r.tok, nil
+}
+
+// next consumes and returns the next token.
+func (r *Reader) next() (token, error) {
+	t, err := r.peek()
+	r.peeked = false
+	return t, err
+}
+
+// readByte reads a byte, keeping track of lines.
+func (r *Reader) readByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.lineStart = b == '\n'
+	if b == '\n' {
+		r.line++
+	}
+	return b, nil
+}
+
+// unreadByte unreads b, the last byte read in the middle of a line.
+func (r *Reader) unreadByte(b byte) {
+	r.r.UnreadByte()
+	r.lineStart = false
+	if b == '\n' {
+		r.line--
+	}
+}
+
+// lex reads the next token.
+func (r *Reader) lex() (token, error) {
+	for {
+		start := r.lineStart
+		b, err := r.readByte()
+		if err == io.EOF {
+			return token{kind: tokEOF, line: r.line}, nil
+		}
+		if err != nil {
+			return token{}, err
+		}
+		t := token{line: r.line}
+
+		switch {
+		case b == ' ' || b == '\t' || b == '\r' || b == '\n':
+			continue
+		case b == '%' && start:
+			// An escaped line, for use by other software.
+			if _, err := r.readLine(); err != nil && err != io.EOF {
+				return token{}, err
+			}
+			continue
+		case b == '[':
+			t.kind, t.text = tokLBracket, "["
+		case b == ']':
+			t.kind, t.text = tokRBracket, "]"
+		case b == '(':
+			t.kind, t.text = tokLParen, "("
+		case b == ')':
+			t.kind, t.text = tokRParen, ")"
+		case b == '.':
+			t.kind, t.text = tokPeriod, "."
+		case b == '*':
+			t.kind, t.text = tokSymbol, "*"
+		case b == '"':
+			t.kind = tokString
+			t.text, err = r.lexString()
+		case b == '{':
+			t.kind = tokComment
+			t.text, err = r.lexUntil('}')
+			t.text = strings.TrimSpace(t.text)
+		case b == ';':
+			t.kind = tokComment
+			t.text, err = r.lexUntil('\n')
+			if err == io.EOF {
+				err = nil
+			}
+			t.text = strings.TrimSpace(t.text)
+		case b == '$':
+			t.kind = tokNAG
+			t.text = r.lexWhile(b, isDigit)
+		case b == '!' || b == '?':
+			t.kind = tokSuffix
+			t.text = r.lexWhile(b, isSuffix)
+		case isSymbolStart(b):
+			t.kind = tokSymbol
+			t.text = r.lexWhile(b, isSymbolContinue)
+		default:
+			return token{}, &ParseError{Line: t.line, Err: fmt.Errorf("unexpected character %q", b)}
+		}
+		if err == io.EOF {
+			err = &ParseError{Line: t.line, Err: errors.New("unexpected end of input")}
+		}
+		return t, err
+	}
+}
+
+// lexString reads the rest of a quoted string and unescapes it.
+func (r *Reader) lexString() (string, error) {
+	r.buf = r.buf[:0]
+	for {
+		c, err := r.readByte()
+		if err != nil {
+			return "", err
+		}
+		switch c {
+		case '"':
+			return string(r.buf), nil
+		case '\\':
+			if c, err = r.readByte(); err != nil {
+				return "", err
+			}
+		case '\n':
+			return "", &ParseError{Line: r.line - 1, Err: errors.New("unterminated string")}
+		}
+		r.buf = append(r.buf, c)
+	}
+}
+
+// lexUntil reads up to and including delim, and returns what came before it.
+func (r *Reader) lexUntil(delim byte) (string, error) {
+	r.buf = r.buf[:0]
+	for {
+		c, err := r.readByte()
+		if err != nil {
+			return string(r.buf), err
+		}
+		if c == delim {
+			return string(r.buf), nil
+		}
+		r.buf = append(r.buf, c)
+	}
+}
+
+// lexWhile reads bytes while f reports true for them, and returns them after
+// first.
+func (r *Reader) lexWhile(first byte, f func(byte) bool) string {
+	r.buf = append(r.buf[:0], first)
+	for {
+		c, err := r.readByte()
+		if err != nil {
+			return string(r.buf)
+		}
+		if !f(c) {
+			r.unreadByte(c)
+			return string(r.buf)
+		}
+		r.buf = append(r.buf, c)
+	}
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isSuffix(b byte) bool {
+	return b == '!' || b == '?'
+}
+
+func isSymbolStart(b byte) bool {
+	return isDigit(b) || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func isSymbolContinue(b byte) bool {
+	return isSymbolStart(b) || strings.IndexByte("_+#=:-/@", b) >= 0
+}
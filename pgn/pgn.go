@@ -0,0 +1,100 @@
+// Package pgn reads and writes chess games in Portable Game Notation (PGN).
+package pgn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+// Game is a game read from PGN.
+type Game struct {
+	Tags     []Tag         // The tag pairs, in order.
+	Comments []string      // Comments before the first move.
+	Moves    []*Move       // The main line.
+	Result   chess.Outcome // The game termination marker.
+}
+
+// Tag is a PGN tag pair, such as [Event "Casual game"].
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Move is a move in a game, with its annotations.
+type Move struct {
+	Move chess.Move
+	SAN  string // The move as written, without suffix annotations like "!?".
+
+	// Before holds comments that precede the move. Only the first move of a
+	// variation has them; comments before the first move of the main line are
+	// in Game.Comments.
+	Before []string
+
+	NAGs     []int    // Numeric Annotation Glyphs, such as 1 for "!".
+	Comments []string // Comments that follow the move.
+
+	// Commands are the commands embedded in the comments that follow the
+	// move, such as "[%clk 0:03:00]". Readers move the commands they
+	// recognize from Comments to Commands, and Writer writes them in a
+	// comment of their own after Comments.
+	Commands Commands
+
+	// Variations are alternatives to the move, each played from the position
+	// before it.
+	Variations [][]*Move
+}
+
+// Tag returns the value of the first tag with the given name, or the empty
+// string if there is none.
+func (g *Game) Tag(name string) string {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// MainLine returns the moves of the main line.
+func (g *Game) MainLine() []chess.Move {
+	moves := make([]chess.Move, len(g.Moves))
+	for i, m := range g.Moves {
+		moves[i] = m.Move
+	}
+	return moves
+}
+
+// StartingPosition returns the position the game starts from. It is given by
+// the FEN tag, or is the starting position of the variant if there is none.
+// The Variant tag may select Chess960 or any of the variants of the chess
+// package, such as "Crazyhouse", as well as standard chess.
+func (g *Game) StartingPosition() (*chess.Position, error) {
+	var chess960 bool
+	variant := chess.Standard
+	switch v := strings.ToLower(g.Tag("Variant")); v {
+	case "", "standard", "from position":
+	case "chess960", "chess 960", "fischerandom":
+		chess960 = true
+	default:
+		var err error
+		if variant, err = chess.ParseVariant(v); err != nil {
+			return nil, fmt.Errorf("unsupported variant %q", g.Tag("Variant"))
+		}
+	}
+
+	pos := chess.VariantStartingPosition(variant)
+	if fen := g.Tag("FEN"); fen != "" {
+		var err error
+		if pos, err = chess.ParseVariantFEN(variant, fen); err != nil {
+			return nil, err
+		}
+	}
+	if chess960 {
+		if err := pos.SetChess960(true); err != nil {
+			return nil, err
+		}
+	}
+	return pos, nil
+}
@@ -0,0 +1,91 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGame_StartingPosition(t *testing.T) {
+	const fen = "4k3/8/8/8/8/8/8/4K2R w K - 0 1"
+	cases := []struct {
+		tags     []Tag
+		want     string
+		chess960 bool
+	}{
+		{nil, chess.StartingFEN, false},
+		{[]Tag{{"SetUp", "1"}, {"FEN", fen}}, fen, false},
+		{[]Tag{{"Variant", "Chess960"}, {"FEN", fen}}, fen, true},
+		{[]Tag{{"Variant", "From Position"}, {"FEN", fen}}, fen, false},
+	}
+	for _, tc := range cases {
+		g := &Game{Tags: tc.tags}
+		p, err := g.StartingPosition()
+		if err != nil {
+			t.Errorf("%v: %v", tc.tags, err)
+			continue
+		}
+		if got := p.String(); got != tc.want {
+			t.Errorf("%v: want %q, got %q", tc.tags, tc.want, got)
+		}
+		if p.Chess960() != tc.chess960 {
+			t.Errorf("%v: Chess960() = %v, want %v", tc.tags, p.Chess960(), tc.chess960)
+		}
+	}
+
+	for _, tags := range [][]Tag{
+		{{"Variant", "Three-check"}},
+		{{"Variant", "Horde"}, {"FEN", chess.StartingFEN}},
+		{{"FEN", "not a fen"}},
+	} {
+		if _, err := (&Game{Tags: tags}).StartingPosition(); err == nil {
+			t.Errorf("%v: want error", tags)
+		}
+	}
+}
+
+func TestGame_StartingPosition_Variant(t *testing.T) {
+	g, err := NewReader(strings.NewReader(`[Variant "Crazyhouse"]
+
+1. e4 d5 2. exd5 Qxd5 3. P@e4 Qd8 *`)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := g.StartingPosition()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Variant() != chess.Crazyhouse {
+		t.Errorf("Variant() = %v, want Crazyhouse", p.Variant())
+	}
+	for _, m := range g.MainLine() {
+		p.Apply(m)
+	}
+	const want = "rnbqkbnr/ppp1pppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR[p] w KQkq - 1 4"
+	if got := p.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGame_Tag(t *testing.T) {
+	g := &Game{Tags: []Tag{{"Event", "A"}, {"Site", "B"}, {"Event", "C"}}}
+	if got := g.Tag("Event"); got != "A" {
+		t.Errorf("Tag(Event) = %q, want A", got)
+	}
+	if got := g.Tag("Round"); got != "" {
+		t.Errorf("Tag(Round) = %q, want empty", got)
+	}
+}
+
+func TestGame_MainLine(t *testing.T) {
+	g, err := NewReader(strings.NewReader("1. e4 (1. d4) e5 *")).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []chess.Move{{From: chess.E2, To: chess.E4}, {From: chess.E7, To: chess.E5}}
+	if diff := cmp.Diff(want, g.MainLine()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
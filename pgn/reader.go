@@ -0,0 +1,286 @@
+package pgn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+// ParseError is returned for PGN that can't be read.
+type ParseError struct {
+	Line int   // The line where the error occurred, starting at 1.
+	Err  error // The underlying error.
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("pgn: line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads games from PGN input one at a time, so that large databases
+// can be processed without loading them into memory.
+type Reader struct {
+	r         *bufio.Reader
+	line      int    // The current line, starting at 1.
+	lineStart bool   // Whether the next byte starts a line.
+	buf       []byte // Scratch space for token text.
+
+	tok    token // The peeked token, if any.
+	peeked bool
+}
+
+// NewReader returns a Reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), line: 1, lineStart: true}
+}
+
+// Read reads the next game. It returns io.EOF when there are no more games.
+//
+// Moves are checked for legality as they are read. If a game can't be read,
+// Read returns a *ParseError and skips to the next game, so that reading can
+// continue past a bad game. Other errors come from the underlying reader.
+func (r *Reader) Read() (*Game, error) {
+	g, err := r.readGame()
+	if err != nil {
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			if err := r.skipGame(); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+	return g, nil
+}
+
+func (r *Reader) readGame() (*Game, error) {
+	t, err := r.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind == tokEOF {
+		return nil, io.EOF
+	}
+
+	g := new(Game)
+	for t.kind == tokLBracket {
+		r.next()
+		tag, err := r.readTag()
+		if err != nil {
+			return nil, err
+		}
+		g.Tags = append(g.Tags, tag)
+		if t, err = r.peek(); err != nil {
+			return nil, err
+		}
+	}
+
+	pos, err := g.StartingPosition()
+	if err != nil {
+		return nil, &ParseError{Line: t.line, Err: err}
+	}
+	p := &parser{Reader: r, game: g}
+	if g.Moves, g.Comments, err = p.readLine(pos, 0); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// readTag reads a tag pair after its opening bracket.
+func (r *Reader) readTag() (Tag, error) {
+	name, err := r.expect(tokSymbol, "tag name")
+	if err != nil {
+		return Tag{}, err
+	}
+	value, err := r.expect(tokString, "tag value")
+	if err != nil {
+		return Tag{}, err
+	}
+	if _, err := r.expect(tokRBracket, `"]"`); err != nil {
+		return Tag{}, err
+	}
+	return Tag{Name: name.text, Value: value.text}, nil
+}
+
+// expect reads a token of the given kind, described by what.
+func (r *Reader) expect(kind tokenKind, what string) (token, error) {
+	t, err := r.next()
+	if err != nil {
+		return t, err
+	}
+	if t.kind != kind {
+		return t, &ParseError{Line: t.line, Err: fmt.Errorf("want %s, got %s", what, t)}
+	}
+	return t, nil
+}
+
+// parser reads the movetext of a single game.
+type parser struct {
+	*Reader
+	game *Game
+}
+
+// readLine reads a line of moves played from pos, which is the main line if
+// depth is 0 and a variation otherwise. It returns the moves and any comments
+// before the first move. pos is left as it was.
+func (p *parser) readLine(pos *chess.Position, depth int) ([]*Move, []string, error) {
+	var (
+		line    []*Move
+		leading []string
+	)
+	defer func() {
+		for range line {
+			pos.Unapply()
+		}
+	}()
+
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, nil, err
+		}
+		var last *Move
+		if len(line) > 0 {
+			last = line[len(line)-1]
+		}
+
+		switch t.kind {
+		case tokEOF:
+			if depth > 0 {
+				return nil, nil, &ParseError{Line: t.line, Err: errors.New("unterminated variation")}
+			}
+			return line, leading, nil
+		case tokLBracket:
+			if depth > 0 {
+				return nil, nil, &ParseError{Line: t.line, Err: errors.New("unterminated variation")}
+			}
+			// The next game started without a termination marker.
+			return line, leading, nil
+		case tokRParen:
+			if depth == 0 {
+				return nil, nil, &ParseError{Line: t.line, Err: errors.New(`unexpected ")"`)}
+			}
+			p.next()
+			return line, leading, nil
+		}
+		p.next()
+
+		switch t.kind {
+		case tokComment:
+			if last == nil {
+				leading = append(leading, t.text)
+			} else {
+				last.addComment(t.text)
+			}
+		case tokNAG, tokSuffix:
+			if last == nil {
+				return nil, nil, &ParseError{Line: t.line, Err: fmt.Errorf("annotation %s before any move", t)}
+			}
+			nag, ok := t.nag()
+			if !ok {
+				return nil, nil, &ParseError{Line: t.line, Err: fmt.Errorf("invalid annotation %s", t)}
+			}
+			last.NAGs = append(last.NAGs, nag)
+		case tokLParen:
+			if last == nil {
+				return nil, nil, &ParseError{Line: t.line, Err: errors.New("variation before any move")}
+			}
+			pos.Unapply()
+			moves, before, err := p.readLine(pos, depth+1)
+			pos.Apply(last.Move)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(moves) > 0 {
+				moves[0].Before = before
+				last.Variations = append(last.Variations, moves)
+			}
+		case tokPeriod:
+		case tokSymbol:
+			if isMoveNumber(t.text) {
+				continue
+			}
+			if o, ok := parseResult(t.text); ok {
+				if depth > 0 {
+					return nil, nil, &ParseError{Line: t.line, Err: errors.New("termination marker in variation")}
+				}
+				p.game.Result = o
+				return line, leading, nil
+			}
+			m, err := chess.ParseSAN(pos, t.text)
+			if err != nil {
+				return nil, nil, &ParseError{Line: t.line, Err: err}
+			}
+			pos.Apply(m)
+			line = append(line, &Move{Move: m, SAN: t.text})
+		default:
+			return nil, nil, &ParseError{Line: t.line, Err: fmt.Errorf("unexpected %s in movetext", t)}
+		}
+	}
+}
+
+// skipGame discards input up to the start of the next game, which is taken to
+// be a line starting with "[" after the movetext of the current one.
+func (r *Reader) skipGame() error {
+	r.peeked = false
+	movetext := false
+	if !r.lineStart {
+		movetext = true
+		if _, err := r.readLine(); err != nil {
+			return err
+		}
+	}
+	for {
+		b, err := r.r.Peek(1)
+		if err != nil {
+			return err
+		}
+		if b[0] == '[' && movetext {
+			return nil
+		}
+		s, err := r.readLine()
+		if err != nil {
+			return err
+		}
+		if s = strings.TrimSpace(s); s != "" && s[0] != '[' {
+			movetext = true
+		}
+	}
+}
+
+// readLine reads the rest of the current line.
+func (r *Reader) readLine() (string, error) {
+	s, err := r.r.ReadString('\n')
+	if err == nil {
+		r.line++
+		r.lineStart = true
+	}
+	return s, err
+}
+
+// isMoveNumber reports whether s is a move number, without its periods.
+func isMoveNumber(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseResult parses a game termination marker.
+func parseResult(s string) (chess.Outcome, bool) {
+	for _, o := range []chess.Outcome{chess.WhiteWon, chess.BlackWon, chess.Draw, chess.NoOutcome} {
+		if s == o.String() {
+			return o, true
+		}
+	}
+	return chess.NoOutcome, false
+}
@@ -0,0 +1,194 @@
+package pgn
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+// readAll reads all games from r, failing the test on any error.
+func readAll(t *testing.T, r io.Reader) []*Game {
+	t.Helper()
+	var games []*Game
+	pr := NewReader(r)
+	for {
+		g, err := pr.Read()
+		if err == io.EOF {
+			return games
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		games = append(games, g)
+	}
+}
+
+// mainLine returns the SAN of the moves in line.
+func mainLine(line []*Move) []string {
+	var res []string
+	for _, m := range line {
+		res = append(res, m.SAN)
+	}
+	return res
+}
+
+func TestReader(t *testing.T) {
+	f, err := os.Open("testdata/games.pgn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	games := readAll(t, f)
+	if len(games) != 4 {
+		t.Fatalf("got %d games, want 4", len(games))
+	}
+
+	g := games[0]
+	if want, got := `Italian Game: "Two Knights" \ Defense`, g.Tag("Opening"); got != want {
+		t.Errorf("Opening tag: want %q, got %q", want, got)
+	}
+	if g.Result != chess.WhiteWon {
+		t.Errorf("result: want %v, got %v", chess.WhiteWon, g.Result)
+	}
+	want := strings.Fields("e4 e5 Nf3 Nc6 Bc4 Nf6 Ng5 d5 exd5 Na5 Bb5+ c6 dxc6 bxc6 Qf3 cxb5 Qxa8")
+	if diff := cmp.Diff(want, mainLine(g.Moves)); diff != "" {
+		t.Errorf("moves mismatch (-want +got):\n%s", diff)
+	}
+	if c := g.Moves[0].Comments; len(c) != 0 {
+		t.Errorf("comments: want none, got %q", c)
+	}
+	if diff := cmp.Diff(Commands{Clock: 3 * time.Minute, HasClock: true}, g.Moves[0].Commands); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+	for i, nags := range map[int][]int{5: {6}, 6: {1, 18}, 15: {4}} {
+		if diff := cmp.Diff(nags, g.Moves[i].NAGs); diff != "" {
+			t.Errorf("NAGs of %s mismatch (-want +got):\n%s", g.Moves[i].SAN, diff)
+		}
+	}
+	if want, got := (chess.Move{From: chess.F3, To: chess.A8}), g.Moves[16].Move; got != want {
+		t.Errorf("last move: want %v, got %v", want, got)
+	}
+
+	g = games[1]
+	if diff := cmp.Diff([]string{"A short game."}, g.Comments); diff != "" {
+		t.Errorf("game comments mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"d4", "d5", "c4", "e6"}, mainLine(g.Moves)); diff != "" {
+		t.Errorf("moves mismatch (-want +got):\n%s", diff)
+	}
+	vars := g.Moves[1].Variations
+	if len(vars) != 2 {
+		t.Fatalf("got %d variations, want 2", len(vars))
+	}
+	if diff := cmp.Diff([]string{"Nf6", "c4", "e6"}, mainLine(vars[0])); diff != "" {
+		t.Errorf("first variation mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"f5"}, mainLine(vars[1])); diff != "" {
+		t.Errorf("second variation mismatch (-want +got):\n%s", diff)
+	}
+	nested := vars[0][1].Variations
+	if len(nested) != 1 {
+		t.Fatalf("got %d nested variations, want 1", len(nested))
+	}
+	if diff := cmp.Diff([]string{"Nf3", "g6"}, mainLine(nested[0])); diff != "" {
+		t.Errorf("nested variation mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"The main alternative."}, nested[0][0].Before); diff != "" {
+		t.Errorf("variation comments mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"Queen's Gambit"}, g.Moves[2].Comments); diff != "" {
+		t.Errorf("rest-of-line comment mismatch (-want +got):\n%s", diff)
+	}
+
+	g = games[2]
+	if g.Result != chess.NoOutcome || len(g.Moves) != 2 {
+		t.Errorf("game without result: got result %v and %d moves", g.Result, len(g.Moves))
+	}
+
+	g = games[3]
+	if g.Result != chess.Draw {
+		t.Errorf("result: want %v, got %v", chess.Draw, g.Result)
+	}
+	castles := []chess.Move{{From: chess.B8, To: chess.E8}, {From: chess.B1, To: chess.E1}}
+	if got := []chess.Move{g.Moves[1].Move, g.Moves[2].Move}; !cmp.Equal(castles, got) {
+		t.Errorf("castling: want %v, got %v", castles, got)
+	}
+}
+
+func TestReader_Errors(t *testing.T) {
+	input := strings.Join([]string{
+		`[Event "Illegal move"]`,
+		``,
+		`1. e4 e5 2. Ke3 *`,
+		``,
+		`[Event "Bad tag]`,
+		``,
+		`1. e4 *`,
+		``,
+		`[Event "Good"]`,
+		``,
+		`1. d4 *`,
+		``,
+		`[Event "Unterminated variation"]`,
+		``,
+		`1. d4 (1. e4 *`,
+	}, "\n")
+
+	r := NewReader(strings.NewReader(input))
+	var events []string
+	var lines []int
+	for {
+		g, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			lines = append(lines, pe.Line)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		events = append(events, g.Tag("Event"))
+	}
+	if diff := cmp.Diff([]string{"Good"}, events); diff != "" {
+		t.Errorf("games mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{3, 5, 15}, lines); diff != "" {
+		t.Errorf("error lines mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReader_Empty(t *testing.T) {
+	for _, s := range []string{"", "\n\n", "% just an escape\n"} {
+		if _, err := NewReader(strings.NewReader(s)).Read(); err != io.EOF {
+			t.Errorf("Read(%q): want %v, got %v", s, io.EOF, err)
+		}
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	data, err := os.ReadFile("testdata/games.pgn")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		r := NewReader(strings.NewReader(string(data)))
+		for {
+			if _, err := r.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+// maxLineLen is the maximum length of a line of movetext, as recommended for
+// the PGN export format.
+const maxLineLen = 79
+
+// sevenTagRoster lists the tags every exported game has, in order, with their
+// values for unknown data.
+var sevenTagRoster = []Tag{
+	{"Event", "?"},
+	{"Site", "?"},
+	{"Date", "????.??.??"},
+	{"Round", "?"},
+	{"White", "?"},
+	{"Black", "?"},
+	{"Result", "*"},
+}
+
+// Writer writes games in the PGN export format.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes g, followed by a blank line to separate it from the next game.
+//
+// The seven tag roster comes first, filled in with placeholders where g
+// doesn't have the tags, and with the Result tag taken from g.Result. Other
+// tags follow in order. Moves are written in SAN generated from the moves
+// themselves, so Move.SAN is ignored, and must be legal. Comments can't
+// contain "}", so any such characters are removed. The commands of a move
+// follow its comments, in a comment of their own.
+func (w *Writer) Write(g *Game) error {
+	pos, err := g.StartingPosition()
+	if err != nil {
+		return fmt.Errorf("pgn: %w", err)
+	}
+
+	var b strings.Builder
+	for _, t := range sevenTagRoster {
+		v := g.Tag(t.Name)
+		switch {
+		case t.Name == "Result":
+			v = g.Result.String()
+		case v == "":
+			v = t.Value
+		}
+		writeTag(&b, t.Name, v)
+	}
+	for _, t := range g.Tags {
+		if isRosterTag(t.Name) {
+			continue
+		}
+		if t.Name == "FEN" && g.Tag("SetUp") == "" {
+			writeTag(&b, "SetUp", "1")
+		}
+		writeTag(&b, t.Name, t.Value)
+	}
+	b.WriteByte('\n')
+
+	mw := &movetextWriter{b: &b}
+	mw.comments(g.Comments)
+	if err := mw.line(pos, g.Moves); err != nil {
+		return err
+	}
+	mw.token(g.Result.String())
+	b.WriteString("\n\n")
+
+	_, err = io.WriteString(w.w, b.String())
+	return err
+}
+
+func writeTag(b *strings.Builder, name, value string) {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	fmt.Fprintf(b, "[%s \"%s\"]\n", name, r.Replace(value))
+}
+
+func isRosterTag(name string) bool {
+	for _, t := range sevenTagRoster {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// movetextWriter writes movetext, wrapping lines between tokens.
+type movetextWriter struct {
+	b       *strings.Builder
+	col     int  // The length of the current line.
+	glue    bool // Whether the next token follows without a space.
+	needNum bool // Whether the next Black move needs its number.
+}
+
+// token writes a single token.
+func (mw *movetextWriter) token(s string) {
+	switch {
+	case mw.col == 0:
+	case mw.col+1+len(s) > maxLineLen:
+		mw.b.WriteByte('\n')
+		mw.col = 0
+	case !mw.glue:
+		mw.b.WriteByte(' ')
+		mw.col++
+	}
+	mw.b.WriteString(s)
+	mw.col += len(s)
+	mw.glue = false
+}
+
+// comments writes comments, which may be wrapped between words.
+func (mw *movetextWriter) comments(cs []string) {
+	for _, c := range cs {
+		words := strings.Fields(strings.ReplaceAll(c, "}", ""))
+		if len(words) == 0 {
+			mw.token("{}")
+			continue
+		}
+		words[0] = "{" + words[0]
+		words[len(words)-1] += "}"
+		for _, w := range words {
+			mw.token(w)
+		}
+		mw.needNum = true
+	}
+}
+
+// line writes a line of moves played from pos. pos is left as it was.
+func (mw *movetextWriter) line(pos *chess.Position, moves []*Move) error {
+	applied := 0
+	defer func() {
+		for ; applied > 0; applied-- {
+			pos.Unapply()
+		}
+	}()
+
+	mw.needNum = true
+	for _, m := range moves {
+		if !pos.IsLegal(m.Move) {
+			return fmt.Errorf("pgn: illegal move %v in position %v", m.Move, pos)
+		}
+
+		mw.comments(m.Before)
+		n := strconv.Itoa(pos.FullmoveNumber())
+		switch {
+		case pos.SideToMove() == chess.White:
+			mw.token(n + ".")
+		case mw.needNum:
+			mw.token(n + "...")
+		}
+		mw.needNum = false
+		mw.token(chess.FormatSAN(pos, m.Move))
+		for _, nag := range m.NAGs {
+			mw.token("$" + strconv.Itoa(nag))
+		}
+		mw.comments(m.Comments)
+		if !m.Commands.IsZero() {
+			mw.comments([]string{m.Commands.String()})
+		}
+
+		for _, v := range m.Variations {
+			mw.token("(")
+			mw.glue = true
+			if err := mw.line(pos, v); err != nil {
+				return err
+			}
+			mw.glue = true
+			mw.token(")")
+			mw.needNum = true
+		}
+
+		pos.Apply(m.Move)
+		applied++
+	}
+	return nil
+}
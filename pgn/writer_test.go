@@ -0,0 +1,117 @@
+package pgn
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriter(t *testing.T) {
+	const input = `[Event "Annotated"]
+[Result "*"]
+[Annotator "Someone \"Quoted\""]
+
+{A short game.} 1. d4 d5 (1... Nf6 2. c4 ({The main alternative.} 2. Nf3 g6) 2... e6) 2. c4 !? {Queen's Gambit} e6 *
+`
+	const want = `[Event "Annotated"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+[Annotator "Someone \"Quoted\""]
+
+{A short game.} 1. d4 d5 (1... Nf6 2. c4 ({The main alternative.} 2. Nf3 g6)
+2... e6) 2. c4 $5 {Queen's Gambit} 2... e6 *
+
+`
+	g, err := NewReader(strings.NewReader(input)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := NewWriter(&b).Write(g); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	f, err := os.Open("testdata/games.pgn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	games := readAll(t, f)
+
+	var b bytes.Buffer
+	w := NewWriter(&b)
+	for _, g := range games {
+		if err := w.Write(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if len(line) > maxLineLen {
+			t.Errorf("line too long: %q", line)
+		}
+	}
+
+	got := readAll(t, &b)
+	if len(got) != len(games) {
+		t.Fatalf("got %d games, want %d", len(got), len(games))
+	}
+	for i := range games {
+		// Tags are rearranged, and placeholders are added.
+		for _, tag := range games[i].Tags {
+			if v := got[i].Tag(tag.Name); v != tag.Value {
+				t.Errorf("game %d: tag %s: want %q, got %q", i, tag.Name, tag.Value, v)
+			}
+		}
+		got[i].Tags = games[i].Tags
+		if diff := cmp.Diff(games[i], got[i]); diff != "" {
+			t.Errorf("game %d mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestWriter_FromMoves(t *testing.T) {
+	const fen = "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1"
+	g := &Game{
+		Tags:   []Tag{{"White", "A"}, {"FEN", fen}},
+		Moves:  []*Move{{Move: chess.Move{From: chess.E1, To: chess.C1}}, {Move: chess.Move{From: chess.E8, To: chess.F7}}},
+		Result: chess.Draw,
+	}
+	var b bytes.Buffer
+	if err := NewWriter(&b).Write(g); err != nil {
+		t.Fatal(err)
+	}
+	want := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "A"]
+[Black "?"]
+[Result "1/2-1/2"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1"]
+
+1. O-O-O Kf7 1/2-1/2
+
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	g.Moves = append(g.Moves, &Move{Move: chess.Move{From: chess.E1, To: chess.E2}})
+	if err := NewWriter(&b).Write(g); err == nil {
+		t.Error("illegal move: want error")
+	}
+}
@@ -0,0 +1,130 @@
+package chess
+
+import "fmt"
+
+// Color is a side in a game of chess.
+type Color int8
+
+// Colors.
+const (
+	White Color = iota
+	Black
+)
+
+// Other returns the opposite color.
+func (c Color) Other() Color {
+	return c ^ 1
+}
+
+func (c Color) String() string {
+	switch c {
+	case White:
+		return "white"
+	case Black:
+		return "black"
+	}
+	return fmt.Sprintf("Color(%d)", int8(c))
+}
+
+// PieceType is a kind of piece, regardless of color.
+type PieceType int8
+
+// Piece types.
+const (
+	NoPieceType PieceType = iota
+	Pawn
+	Knight
+	Bishop
+	Rook
+	Queen
+	King
+)
+
+func (pt PieceType) String() string {
+	switch pt {
+	case NoPieceType:
+		return "none"
+	case Pawn:
+		return "pawn"
+	case Knight:
+		return "knight"
+	case Bishop:
+		return "bishop"
+	case Rook:
+		return "rook"
+	case Queen:
+		return "queen"
+	case King:
+		return "king"
+	}
+	return fmt.Sprintf("PieceType(%d)", int8(pt))
+}
+
+// Piece is a colored piece, or NoPiece for an empty square.
+type Piece int8
+
+// Pieces.
+const (
+	NoPiece Piece = iota
+	WhitePawn
+	WhiteKnight
+	WhiteBishop
+	WhiteRook
+	WhiteQueen
+	WhiteKing
+	BlackPawn
+	BlackKnight
+	BlackBishop
+	BlackRook
+	BlackQueen
+	BlackKing
+)
+
+// NewPiece returns the piece of the given color and type.
+func NewPiece(c Color, pt PieceType) Piece {
+	if pt == NoPieceType {
+		return NoPiece
+	}
+	return Piece(int8(c)*6 + int8(pt))
+}
+
+// Color returns the piece's color. It is meaningless for NoPiece.
+func (p Piece) Color() Color {
+	if p >= BlackPawn {
+		return Black
+	}
+	return White
+}
+
+// Type returns the piece's type.
+func (p Piece) Type() PieceType {
+	if p == NoPiece {
+		return NoPieceType
+	}
+	return PieceType((p-1)%6 + 1)
+}
+
+// pieceLetters are the FEN letters for each piece, indexed by Piece.
+const pieceLetters = " PNBRQKpnbrqk"
+
+// pieceFromLetter returns the piece for a FEN letter, such as 'N' or 'q'.
+func pieceFromLetter(b byte) (Piece, bool) {
+	for i := 1; i < len(pieceLetters); i++ {
+		if pieceLetters[i] == b {
+			return Piece(i), true
+		}
+	}
+	return NoPiece, false
+}
+
+// String returns the piece's FEN letter, such as "N" or "q", or "-" for
+// NoPiece.
+func (p Piece) String() string {
+	if p == NoPiece {
+		return "-"
+	}
+	if p < NoPiece || p > BlackKing {
+		return fmt.Sprintf("Piece(%d)", int8(p))
+	}
+	return pieceLetters[p : p+1]
+}
@@ -0,0 +1,24 @@
+package chess
+
+import "testing"
+
+func TestPiece(t *testing.T) {
+	for _, c := range []Color{White, Black} {
+		for pt := Pawn; pt <= King; pt++ {
+			p := NewPiece(c, pt)
+			if p.Color() != c || p.Type() != pt {
+				t.Errorf("NewPiece(%v, %v) = %v, which has color %v and type %v", c, pt, p, p.Color(), p.Type())
+			}
+			got, ok := pieceFromLetter(p.String()[0])
+			if !ok || got != p {
+				t.Errorf("pieceFromLetter(%q) = %v, %v, want %v", p.String(), got, ok, p)
+			}
+		}
+	}
+	if NewPiece(White, NoPieceType) != NoPiece {
+		t.Error("NewPiece(White, NoPieceType) != NoPiece")
+	}
+	if NoPiece.Type() != NoPieceType {
+		t.Error("NoPiece.Type() != NoPieceType")
+	}
+}
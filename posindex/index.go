@@ -0,0 +1,179 @@
+package posindex
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/clfs/chess"
+)
+
+// ErrFormat is returned for files that aren't valid indexes.
+var ErrFormat = errors.New("posindex: invalid index")
+
+// Index is an index written by an Indexer. It is safe for concurrent use.
+type Index struct {
+	r       io.ReaderAt
+	entries int64
+	games   []Game
+	closer  io.Closer
+}
+
+// Open opens the index file at path.
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	idx, err := New(f, fi.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	idx.closer = f
+	return idx, nil
+}
+
+// New returns the index of size bytes read from r, which must stay readable
+// while the index is in use.
+func New(r io.ReaderAt, size int64) (*Index, error) {
+	var h [headerSize]byte
+	if _, err := r.ReadAt(h[:], 0); err != nil || string(h[:len(magic)]) != magic {
+		return nil, ErrFormat
+	}
+	n := binary.BigEndian.Uint64(h[len(magic):])
+	off := int64(headerSize) + int64(n)*entrySize
+	if n > uint64(size)/entrySize || off > size {
+		return nil, ErrFormat
+	}
+	games, err := readGames(bufio.NewReader(io.NewSectionReader(r, off, size-off)))
+	if err != nil {
+		return nil, ErrFormat
+	}
+	return &Index{r: r, entries: int64(n), games: games}, nil
+}
+
+// readGames reads the files and games of an index.
+func readGames(r *bufio.Reader) ([]Game, error) {
+	nfiles, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for i := uint32(0); i < nfiles; i++ {
+		f, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	ngames, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	var games []Game
+	for i := uint32(0); i < ngames; i++ {
+		file, err := readUint32(r)
+		if err != nil {
+			return nil, err
+		}
+		if file >= nfiles {
+			return nil, ErrFormat
+		}
+		n, err := readUint32(r)
+		if err != nil {
+			return nil, err
+		}
+		g := Game{File: files[file], N: int(n)}
+		for _, s := range g.tags() {
+			if *s, err = readString(r); err != nil {
+				return nil, err
+			}
+		}
+		games = append(games, g)
+	}
+	return games, nil
+}
+
+func readUint32(r *bufio.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b[:]), nil
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return "", err
+	}
+	s := make([]byte, binary.BigEndian.Uint16(b[:]))
+	if _, err := io.ReadFull(r, s); err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// Close closes the file of an index from Open. It does nothing for an index
+// from New.
+func (idx *Index) Close() error {
+	if idx.closer == nil {
+		return nil
+	}
+	return idx.closer.Close()
+}
+
+// Games returns the indexed games, in the order they were indexed.
+func (idx *Index) Games() []Game {
+	return idx.games
+}
+
+// Find returns the games that reached pos, in the order they were indexed.
+func (idx *Index) Find(pos *chess.Position) ([]Hit, error) {
+	return idx.FindHash(pos.Hash())
+}
+
+// FindHash returns the games that reached a position with the Zobrist hash h,
+// in the order they were indexed.
+func (idx *Index) FindHash(h uint64) ([]Hit, error) {
+	var err error
+	read := func(i int64) entry {
+		var b [entrySize]byte
+		if _, rerr := idx.r.ReadAt(b[:], headerSize+i*entrySize); rerr != nil && err == nil {
+			err = rerr
+		}
+		return entry{
+			hash: binary.BigEndian.Uint64(b[:8]),
+			game: binary.BigEndian.Uint32(b[8:12]),
+			ply:  binary.BigEndian.Uint32(b[12:]),
+		}
+	}
+	// Entries with the same hash are in the order of their games, since the
+	// indexer sorts them stably.
+	i := int64(sort.Search(int(idx.entries), func(i int) bool { return read(int64(i)).hash >= h }))
+	var hits []Hit
+	for ; i < idx.entries && err == nil; i++ {
+		e := read(i)
+		if e.hash != h {
+			break
+		}
+		if int(e.game) >= len(idx.games) {
+			return nil, ErrFormat
+		}
+		hits = append(hits, Hit{Game: &idx.games[e.game], Ply: int(e.ply)})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("posindex: %w", err)
+	}
+	return hits, nil
+}
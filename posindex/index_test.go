@@ -0,0 +1,68 @@
+package posindex
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clfs/chess"
+)
+
+func TestOpen(t *testing.T) {
+	ix := NewIndexer()
+	if err := ix.AddPGN("twic.pgn", bytes.NewReader([]byte(twic))); err != nil {
+		t.Fatal(err)
+	}
+	if got := ix.Games(); got != 2 {
+		t.Errorf("got %d games, want 2", got)
+	}
+	path := filepath.Join(t.TempDir(), "twic.pidx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	hits, err := idx.Find(chess.StartingPosition())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 {
+		t.Errorf("got %d hits, want 2", len(hits))
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	var valid bytes.Buffer
+	ix := NewIndexer()
+	if err := ix.AddPGN("twic.pgn", bytes.NewReader([]byte(twic))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.WriteTo(&valid); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"magic":     append([]byte("NOTANIDX"), valid.Bytes()[8:]...),
+		"truncated": valid.Bytes()[:valid.Len()-1],
+		"entries":   append(append([]byte(magic), 0x7f, 0, 0, 0, 0, 0, 0, 0), valid.Bytes()[16:]...),
+	} {
+		_, err := New(bytes.NewReader(data), int64(len(data)))
+		if !errors.Is(err, ErrFormat) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrFormat)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package posindex indexes the positions of PGN databases by Zobrist hash, to
+// find the games that reached a position.
+//
+// An Indexer reads games and records the hash of every position of their main
+// lines, with a reference to the game and the ply, then writes the index to a
+// file. An Index searches the file in place, so that only the list of games is
+// loaded into memory:
+//
+//	ix := posindex.NewIndexer()
+//	err := ix.AddPGN("twic1450.pgn", f)
+//	_, err = ix.WriteTo(out)
+//
+//	idx, err := posindex.Open("twic.pidx")
+//	hits, err := idx.Find(pos)
+//
+// Positions are told apart by their hash alone, so a search may rarely find a
+// game that reached a different position with the same hash.
+package posindex
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/pgn"
+)
+
+// The layout of index files: a header with the magic string and the number of
+// entries, the entries sorted by hash, and then the files and games.
+const (
+	magic      = "POSIDX01"
+	headerSize = 16 // The magic string, and the number of entries as a uint64.
+	entrySize  = 16 // Hash uint64, game uint32, ply uint32.
+)
+
+// maxString is the longest string in an index file. Longer tag values are
+// truncated.
+const maxString = 1<<16 - 1
+
+// Game is a reference to a game of an indexed database, with tags of the Seven
+// Tag Roster to tell games apart in search results.
+type Game struct {
+	File string // The name of the file, as given to Indexer.AddPGN.
+	N    int    // The index of the game in the file, counting from 0.
+
+	Event, Site, Date, Round, White, Black, Result string
+}
+
+// Hit is a game that reached a position.
+type Hit struct {
+	Game *Game
+	Ply  int // The ply of the first occurrence of the position, from 0 for the starting position.
+}
+
+// entry records that a game reached a position.
+type entry struct {
+	hash uint64
+	game uint32
+	ply  uint32
+}
+
+// Indexer builds an index. Entries are kept in memory, taking 16 bytes per
+// position of each game, until the index is written.
+type Indexer struct {
+	files   []string
+	games   []Game
+	entries []entry
+}
+
+// NewIndexer returns an empty indexer.
+func NewIndexer() *Indexer {
+	return &Indexer{}
+}
+
+// Games returns the number of games indexed so far.
+func (ix *Indexer) Games() int {
+	return len(ix.games)
+}
+
+// AddPGN indexes the games read from r, under the file name name. Games that
+// can't be read, or of variants other than Chess960, are skipped, but still
+// count for the numbers of the games that follow them. Other errors from r
+// are returned, with the games before them indexed.
+func (ix *Indexer) AddPGN(name string, r io.Reader) error {
+	ix.files = append(ix.files, name)
+	pr := pgn.NewReader(r)
+	for n := 0; ; n++ {
+		g, err := pr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var pe *pgn.ParseError
+		if errors.As(err, &pe) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("posindex: %s: %w", name, err)
+		}
+		pos, err := g.StartingPosition()
+		if err != nil || pos.Variant() != chess.Standard {
+			continue
+		}
+		if len(ix.games) >= 1<<32-1 {
+			return errors.New("posindex: too many games")
+		}
+		ix.addGame(name, n, g, pos)
+	}
+}
+
+// addGame records the positions of g's main line, from pos.
+func (ix *Indexer) addGame(file string, n int, g *pgn.Game, pos *chess.Position) {
+	id := uint32(len(ix.games))
+	ix.games = append(ix.games, Game{
+		File:   file,
+		N:      n,
+		Event:  g.Tag("Event"),
+		Site:   g.Tag("Site"),
+		Date:   g.Tag("Date"),
+		Round:  g.Tag("Round"),
+		White:  g.Tag("White"),
+		Black:  g.Tag("Black"),
+		Result: g.Tag("Result"),
+	})
+	seen := map[uint64]bool{}
+	record := func(ply int) {
+		h := pos.Hash()
+		if !seen[h] {
+			seen[h] = true
+			ix.entries = append(ix.entries, entry{h, id, uint32(ply)})
+		}
+	}
+	record(0)
+	for i, m := range g.MainLine() {
+		pos.Apply(m)
+		record(i + 1)
+	}
+}
+
+// WriteTo writes the index to w.
+func (ix *Indexer) WriteTo(w io.Writer) (int64, error) {
+	sort.SliceStable(ix.entries, func(i, j int) bool { return ix.entries[i].hash < ix.entries[j].hash })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	bw.WriteString(magic)
+	var b [entrySize]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(len(ix.entries)))
+	bw.Write(b[:8])
+	for _, e := range ix.entries {
+		binary.BigEndian.PutUint64(b[:8], e.hash)
+		binary.BigEndian.PutUint32(b[8:12], e.game)
+		binary.BigEndian.PutUint32(b[12:], e.ply)
+		bw.Write(b[:])
+	}
+
+	fileIDs := make(map[string]uint32, len(ix.files))
+	writeUint32(bw, uint32(len(ix.files)))
+	for i, f := range ix.files {
+		fileIDs[f] = uint32(i)
+		writeString(bw, f)
+	}
+	writeUint32(bw, uint32(len(ix.games)))
+	for _, g := range ix.games {
+		writeUint32(bw, fileIDs[g.File])
+		writeUint32(bw, uint32(g.N))
+		for _, s := range g.tags() {
+			writeString(bw, *s)
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// tags returns pointers to the tags of g, in the order of index files.
+func (g *Game) tags() []*string {
+	return []*string{&g.Event, &g.Site, &g.Date, &g.Round, &g.White, &g.Black, &g.Result}
+}
+
+func writeUint32(w *bufio.Writer, v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	w.Write(b[:])
+}
+
+func writeString(w *bufio.Writer, s string) {
+	if len(s) > maxString {
+		s = s[:maxString]
+	}
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(len(s)))
+	w.Write(b[:])
+	w.WriteString(s)
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,165 @@
+package posindex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+const twic = `[Event "Open"]
+[White "A"]
+[Black "B"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 1-0
+
+[Event "Open"]
+[White "C"]
+[Black "D"]
+
+1. Nf3 Nc6 2. e4 e5 3. Ng1 Nb8 4. Nf3 Nc6 *
+
+[Event "Bad"]
+
+1. e5 *
+
+[Event "Variant"]
+[Variant "Atomic"]
+
+1. e4 e5 2. Nf3 Nc6 *
+`
+
+const other = `[Event "Other"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. e4 Kd7 *
+
+[Event "Other"]
+
+1. d4 d5 *
+`
+
+// build returns the index of the databases, by name.
+func build(t *testing.T, dbs ...string) *Index {
+	t.Helper()
+	ix := NewIndexer()
+	for i := 0; i < len(dbs); i += 2 {
+		if err := ix.AddPGN(dbs[i], strings.NewReader(dbs[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var b bytes.Buffer
+	n, err := ix.WriteTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(b.Len()) {
+		t.Errorf("WriteTo returned %d, wrote %d bytes", n, b.Len())
+	}
+	idx, err := New(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+// hit is a Hit without the pointer, for comparison.
+type hit struct {
+	File  string
+	N     int
+	White string
+	Ply   int
+}
+
+func find(t *testing.T, idx *Index, fen string, moves ...string) []hit {
+	t.Helper()
+	pos := chess.StartingPosition()
+	if fen != "" {
+		var err error
+		if pos, err = chess.ParseFEN(fen); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, s := range moves {
+		m, err := chess.ParseMove(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pos.Apply(m)
+	}
+	hits, err := idx.Find(pos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []hit
+	for _, h := range hits {
+		res = append(res, hit{h.Game.File, h.Game.N, h.Game.White, h.Ply})
+	}
+	return res
+}
+
+func TestIndex_Find(t *testing.T) {
+	idx := build(t, "twic.pgn", twic, "other.pgn", other)
+
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+		want  []hit
+	}{
+		{
+			name: "start",
+			want: []hit{{"twic.pgn", 0, "A", 0}, {"twic.pgn", 1, "C", 0}, {"other.pgn", 1, "", 0}},
+		},
+		{
+			// Reached by transposition, and repeated in the second game.
+			name:  "transposition",
+			moves: []string{"e2e4", "e7e5", "g1f3", "b8c6"},
+			want:  []hit{{"twic.pgn", 0, "A", 4}, {"twic.pgn", 1, "C", 4}},
+		},
+		{
+			name:  "only in one game",
+			moves: []string{"g1f3"},
+			want:  []hit{{"twic.pgn", 1, "C", 1}},
+		},
+		{
+			name: "from a set-up position",
+			fen:  "4k3/8/8/8/4P3/8/8/4K3 b - - 0 1",
+			want: []hit{{"other.pgn", 0, "", 1}},
+		},
+		{
+			name:  "not found",
+			moves: []string{"a2a4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := find(t, idx, tt.fen, tt.moves...)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIndex_Games(t *testing.T) {
+	idx := build(t, "twic.pgn", twic)
+	want := []Game{
+		{File: "twic.pgn", N: 0, Event: "Open", White: "A", Black: "B", Result: "1-0"},
+		{File: "twic.pgn", N: 1, Event: "Open", White: "C", Black: "D"},
+	}
+	if diff := cmp.Diff(want, idx.Games()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestIndex_Empty(t *testing.T) {
+	idx := build(t)
+	hits, err := idx.Find(chess.StartingPosition())
+	if err != nil || len(hits) != 0 {
+		t.Errorf("got %v, %v; want no hits", hits, err)
+	}
+}
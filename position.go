@@ -0,0 +1,514 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StartingFEN is the FEN of the standard starting position.
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// CastlingRights is a set of castling rights.
+type CastlingRights uint8
+
+// Castling rights.
+const (
+	WhiteKingside CastlingRights = 1 << iota
+	WhiteQueenside
+	BlackKingside
+	BlackQueenside
+
+	NoCastling  CastlingRights = 0
+	AllCastling                = WhiteKingside | WhiteQueenside | BlackKingside | BlackQueenside
+)
+
+// String returns the castling rights as in FEN, such as "KQkq" or "-".
+func (cr CastlingRights) String() string {
+	if cr == NoCastling {
+		return "-"
+	}
+	var b strings.Builder
+	for i, c := range "KQkq" {
+		if cr&(1<<i) != 0 {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Position is the state of a game at a single point in time.
+//
+// The zero value is an empty board with White to move, which is not a legal
+// position.
+type Position struct {
+	// The board is kept both as a mailbox, for looking up the piece on a
+	// square, and as bitboards, for move generation.
+	board  [64]Piece
+	pieces [7]Bitboard // Indexed by PieceType, for both colors.
+	colors [2]Bitboard // Indexed by Color.
+
+	sideToMove     Color
+	castling       CastlingRights
+	castlingRooks  [4]Square // The rook for each castling right.
+	chess960       bool
+	enPassant      Square
+	halfmoveClock  int
+	fullmoveNumber int
+
+	variant  Variant
+	pockets  [2][7]int8 // Pieces in hand in Crazyhouse, by Color and PieceType.
+	promoted Bitboard   // Promoted pieces in Crazyhouse.
+
+	history []undo // Applied moves, for Unapply.
+}
+
+// undo records what Unapply needs to take back a move.
+type undo struct {
+	move          Move
+	moved         Piece
+	captured      Piece
+	capturedOn    Square
+	castling      CastlingRights
+	castle        int // The castling right used, or -1.
+	enPassant     Square
+	halfmoveClock int
+	promoted      Bitboard
+	exploded      []placement // The pieces removed by an Atomic capture.
+}
+
+// StartingPosition returns the standard starting position.
+func StartingPosition() *Position {
+	p, err := ParseFEN(StartingFEN)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// ParseFEN parses a position in Forsyth-Edwards Notation, such as
+//
+//	rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1
+//
+// The halfmove clock and fullmove number may be omitted, in which case they
+// default to 0 and 1. Castling rights may also be given in X-FEN or
+// Shredder-FEN notation for Chess960; see Position.Chess960.
+func ParseFEN(fen string) (*Position, error) {
+	return ParseVariantFEN(Standard, fen)
+}
+
+// ParseVariantFEN is like ParseFEN, but for a position in variant v. Positions
+// in Antichess may have any number of kings, and in Horde, White has none and
+// may have pawns on the first rank. Crazyhouse positions list the pieces in
+// hand after the board, in brackets, with "~" marking promoted pieces, as in
+//
+//	r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB~R[Pp] w KQkq - 0 1
+func ParseVariantFEN(v Variant, fen string) (*Position, error) {
+	fields := strings.Fields(fen)
+	if len(fields) != 4 && len(fields) != 6 {
+		return nil, fmt.Errorf("invalid FEN %q: want 4 or 6 fields, got %d", fen, len(fields))
+	}
+
+	p := &Position{enPassant: NoSquare, fullmoveNumber: 1, variant: v}
+
+	if err := p.parseBoard(fields[0]); err != nil {
+		return nil, fmt.Errorf("invalid FEN %q: %w", fen, err)
+	}
+
+	switch fields[1] {
+	case "w":
+		p.sideToMove = White
+	case "b":
+		p.sideToMove = Black
+	default:
+		return nil, fmt.Errorf("invalid FEN %q: invalid side to move %q", fen, fields[1])
+	}
+
+	if v == Antichess && fields[2] != "-" {
+		return nil, fmt.Errorf("invalid FEN %q: castling rights in Antichess", fen)
+	}
+	if err := p.parseCastlingRights(fields[2]); err != nil {
+		return nil, fmt.Errorf("invalid FEN %q: %w", fen, err)
+	}
+
+	if fields[3] != "-" {
+		sq, err := ParseSquare(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid FEN %q: invalid en passant square: %w", fen, err)
+		}
+		want := Rank6
+		if p.sideToMove == Black {
+			want = Rank3
+		}
+		if sq.Rank() != want {
+			return nil, fmt.Errorf("invalid FEN %q: en passant square %v is on the wrong rank", fen, sq)
+		}
+		p.enPassant = sq
+	}
+
+	if len(fields) == 6 {
+		n, err := strconv.Atoi(fields[4])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid FEN %q: invalid halfmove clock %q", fen, fields[4])
+		}
+		p.halfmoveClock = n
+
+		n, err = strconv.Atoi(fields[5])
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid FEN %q: invalid fullmove number %q", fen, fields[5])
+		}
+		p.fullmoveNumber = n
+	}
+
+	return p, nil
+}
+
+// parseBoard parses the piece placement field of a FEN, following the rules
+// of p's variant.
+func (p *Position) parseBoard(s string) error {
+	if p.variant == Crazyhouse {
+		var err error
+		if s, err = p.parsePocket(s); err != nil {
+			return err
+		}
+	}
+
+	ranks := strings.Split(s, "/")
+	if len(ranks) != 8 {
+		return fmt.Errorf("want 8 ranks, got %d", len(ranks))
+	}
+
+	var kings [2]int
+	for i, row := range ranks {
+		r := Rank8 - Rank(i)
+		f := FileA
+		for j := 0; j < len(row); j++ {
+			c := row[j]
+			if c == '~' && p.variant == Crazyhouse && f > FileA {
+				sq := NewSquare(f-1, r)
+				if t := p.board[sq].Type(); t == Pawn || t == King {
+					return fmt.Errorf("promoted %v on %v", t, sq)
+				}
+				p.promoted |= bb(sq)
+				continue
+			}
+			if c >= '1' && c <= '8' {
+				f += File(c - '0')
+				if f > FileH+1 {
+					return fmt.Errorf("rank %v is too long", r)
+				}
+				continue
+			}
+			pc, ok := pieceFromLetter(c)
+			if !ok {
+				return fmt.Errorf("invalid piece %q", c)
+			}
+			if f > FileH {
+				return fmt.Errorf("rank %v is too long", r)
+			}
+			if pc.Type() == Pawn && (r == Rank1 || r == Rank8) && !(p.variant == Horde && pc == WhitePawn && r == Rank1) {
+				return fmt.Errorf("pawn on rank %v", r)
+			}
+			if pc.Type() == King {
+				kings[pc.Color()]++
+			}
+			p.put(pc, NewSquare(f, r))
+			f++
+		}
+		if f != FileH+1 {
+			return fmt.Errorf("rank %v is too short", r)
+		}
+	}
+
+	switch p.variant {
+	case Antichess:
+	case Horde:
+		if kings[White] != 0 || kings[Black] != 1 {
+			return fmt.Errorf("want no white king and one black king, got %d white and %d black", kings[White], kings[Black])
+		}
+	default:
+		if kings[White] != 1 || kings[Black] != 1 {
+			return fmt.Errorf("want one king per side, got %d white and %d black", kings[White], kings[Black])
+		}
+	}
+	return nil
+}
+
+// String returns the position in Forsyth-Edwards Notation.
+func (p *Position) String() string {
+	var b strings.Builder
+	writeBoard(&b, &p.board, p.promoted)
+	if p.variant == Crazyhouse {
+		b.WriteString(p.formatPocket())
+	}
+	side := "w"
+	if p.sideToMove == Black {
+		side = "b"
+	}
+	fmt.Fprintf(&b, " %s %v %v %d %d", side, p.formatCastlingRights(), p.enPassant, p.halfmoveClock, p.fullmoveNumber)
+	return b.String()
+}
+
+// writeBoard writes the piece placement field of a FEN for board to b, with
+// "~" after promoted pieces.
+func writeBoard(b *strings.Builder, board *[64]Piece, promoted Bitboard) {
+	for r := Rank8; r >= Rank1; r-- {
+		empty := 0
+		for f := FileA; f <= FileH; f++ {
+			pc := board[NewSquare(f, r)]
+			if pc == NoPiece {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				b.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			b.WriteString(pc.String())
+			if promoted.Has(NewSquare(f, r)) {
+				b.WriteByte('~')
+			}
+		}
+		if empty > 0 {
+			b.WriteByte(byte('0' + empty))
+		}
+		if r > Rank1 {
+			b.WriteByte('/')
+		}
+	}
+}
+
+// PieceAt returns the piece on sq, or NoPiece if sq is empty.
+func (p *Position) PieceAt(sq Square) Piece {
+	return p.board[sq]
+}
+
+// Pieces returns the squares occupied by pieces of color c and type pt.
+func (p *Position) Pieces(c Color, pt PieceType) Bitboard {
+	return p.pieces[pt] & p.colors[c]
+}
+
+// Occupied returns the squares occupied by pieces of color c.
+func (p *Position) Occupied(c Color) Bitboard {
+	return p.colors[c]
+}
+
+// put places pc on the empty square sq.
+func (p *Position) put(pc Piece, sq Square) {
+	p.board[sq] = pc
+	p.pieces[pc.Type()] |= bb(sq)
+	p.colors[pc.Color()] |= bb(sq)
+}
+
+// remove removes the piece on sq, which must not be empty.
+func (p *Position) remove(sq Square) {
+	pc := p.board[sq]
+	p.board[sq] = NoPiece
+	p.pieces[pc.Type()] &^= bb(sq)
+	p.colors[pc.Color()] &^= bb(sq)
+}
+
+// kingSquare returns the square of c's king, which must be on the board.
+func (p *Position) kingSquare(c Color) Square {
+	return p.Pieces(c, King).first()
+}
+
+// SideToMove returns the color whose turn it is.
+func (p *Position) SideToMove() Color {
+	return p.sideToMove
+}
+
+// CastlingRights returns the remaining castling rights. The rights do not
+// consider whether castling is currently possible.
+func (p *Position) CastlingRights() CastlingRights {
+	return p.castling
+}
+
+// EnPassant returns the en passant target square, or NoSquare. As in FEN, the
+// square is set after any double pawn push, whether or not an en passant
+// capture is possible.
+func (p *Position) EnPassant() Square {
+	return p.enPassant
+}
+
+// HalfmoveClock returns the number of halfmoves since the last capture or pawn
+// move, for the fifty-move rule.
+func (p *Position) HalfmoveClock() int {
+	return p.halfmoveClock
+}
+
+// FullmoveNumber returns the fullmove number, which starts at 1 and increments
+// after each Black move.
+func (p *Position) FullmoveNumber() int {
+	return p.fullmoveNumber
+}
+
+// Clone returns a copy of p, including its move history.
+func (p *Position) Clone() *Position {
+	q := *p
+	q.history = append([]undo(nil), p.history...)
+	return &q
+}
+
+// Apply plays m, which must be legal in the position. Use IsLegal or LegalMoves
+// to check moves from untrusted sources first.
+func (p *Position) Apply(m Move) {
+	if m.Drop != NoPieceType {
+		p.applyDrop(m)
+		return
+	}
+	pc := p.board[m.From]
+	us := pc.Color()
+
+	u := undo{
+		move:          m,
+		moved:         pc,
+		captured:      p.board[m.To],
+		capturedOn:    m.To,
+		castling:      p.castling,
+		castle:        -1,
+		enPassant:     p.enPassant,
+		halfmoveClock: p.halfmoveClock,
+		promoted:      p.promoted,
+	}
+
+	if i, ok := p.castlingSide(m); ok {
+		// Lift both pieces first, since their squares may overlap in
+		// Chess960.
+		c := p.castlingPath(i, m.From)
+		u.castle = i
+		u.captured = NoPiece
+		p.remove(c.king)
+		p.remove(c.rook)
+		p.put(pc, c.kingTo)
+		p.put(NewPiece(us, Rook), c.rookTo)
+	} else {
+		if pc.Type() == Pawn && m.To == p.enPassant && m.From.File() != m.To.File() {
+			u.capturedOn = NewSquare(m.To.File(), m.From.Rank())
+			u.captured = p.board[u.capturedOn]
+		}
+		if u.captured != NoPiece {
+			p.remove(u.capturedOn)
+		}
+		p.remove(m.From)
+		if m.Promotion != NoPieceType {
+			p.put(NewPiece(us, m.Promotion), m.To)
+		} else {
+			p.put(pc, m.To)
+		}
+	}
+
+	switch {
+	case p.variant == Crazyhouse:
+		p.pocketCapture(&u)
+	case p.variant == Atomic && u.captured != NoPiece:
+		u.exploded = p.explode(m.To)
+	}
+
+	if pc.Type() == King {
+		p.castling &^= 3 << castlingIndex(us, true)
+	}
+	for i, sq := range p.castlingRooks {
+		if m.From == sq || m.To == sq {
+			p.castling &^= 1 << i
+		}
+	}
+
+	// Horde pawns moving two squares from the first rank can't be taken en
+	// passant.
+	p.enPassant = NoSquare
+	if pc.Type() == Pawn && (m.To-m.From == 16 || m.From-m.To == 16) && m.From.Rank() != Rank1 && m.From.Rank() != Rank8 {
+		p.enPassant = (m.From + m.To) / 2
+	}
+
+	if pc.Type() == Pawn || u.captured != NoPiece {
+		p.halfmoveClock = 0
+	} else {
+		p.halfmoveClock++
+	}
+	if us == Black {
+		p.fullmoveNumber++
+	}
+	p.sideToMove = us.Other()
+
+	p.history = append(p.history, u)
+}
+
+// applyDrop is Apply for a Crazyhouse drop.
+func (p *Position) applyDrop(m Move) {
+	us := p.sideToMove
+	pc := NewPiece(us, m.Drop)
+	p.history = append(p.history, undo{
+		move:          m,
+		moved:         pc,
+		castle:        -1,
+		castling:      p.castling,
+		enPassant:     p.enPassant,
+		halfmoveClock: p.halfmoveClock,
+		promoted:      p.promoted,
+	})
+	p.pockets[us][m.Drop]--
+	p.put(pc, m.To)
+
+	p.enPassant = NoSquare
+	if m.Drop == Pawn {
+		p.halfmoveClock = 0
+	} else {
+		p.halfmoveClock++
+	}
+	if us == Black {
+		p.fullmoveNumber++
+	}
+	p.sideToMove = us.Other()
+}
+
+// Unapply takes back the last move played with Apply. It panics if there is no
+// such move.
+func (p *Position) Unapply() {
+	if len(p.history) == 0 {
+		panic("chess: Unapply with no moves applied")
+	}
+	u := p.history[len(p.history)-1]
+	p.history = p.history[:len(p.history)-1]
+
+	m := u.move
+	us := u.moved.Color()
+
+	for _, pl := range u.exploded {
+		p.put(pl.pc, pl.sq)
+	}
+	if p.variant == Crazyhouse && u.captured != NoPiece {
+		pt := u.captured.Type()
+		if u.promoted.Has(u.capturedOn) {
+			pt = Pawn
+		}
+		p.pockets[us][pt]--
+	}
+	p.promoted = u.promoted
+
+	switch {
+	case m.Drop != NoPieceType:
+		p.remove(m.To)
+		p.pockets[us][m.Drop]++
+	case u.castle >= 0:
+		c := p.castlingPath(u.castle, m.From)
+		p.remove(c.kingTo)
+		p.remove(c.rookTo)
+		p.put(u.moved, c.king)
+		p.put(NewPiece(us, Rook), c.rook)
+	default:
+		p.remove(m.To)
+		p.put(u.moved, m.From)
+		if u.captured != NoPiece {
+			p.put(u.captured, u.capturedOn)
+		}
+	}
+
+	p.castling = u.castling
+	p.enPassant = u.enPassant
+	p.halfmoveClock = u.halfmoveClock
+	if us == Black {
+		p.fullmoveNumber--
+	}
+	p.sideToMove = us
+}
@@ -0,0 +1,99 @@
+package chess
+
+import "testing"
+
+func TestParseFEN_RoundTrip(t *testing.T) {
+	fens := []string{
+		StartingFEN,
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"4k3/8/8/8/8/8/8/4K3 b - - 99 150",
+	}
+	for _, fen := range fens {
+		p, err := ParseFEN(fen)
+		if err != nil {
+			t.Errorf("ParseFEN(%q): %v", fen, err)
+			continue
+		}
+		if got := p.String(); got != fen {
+			t.Errorf("ParseFEN(%q).String() = %q", fen, got)
+		}
+	}
+}
+
+func TestParseFEN_DefaultCounters(t *testing.T) {
+	p, err := ParseFEN("4k3/8/8/8/8/8/8/4K3 w - -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.HalfmoveClock() != 0 || p.FullmoveNumber() != 1 {
+		t.Errorf("got counters %d, %d, want 0, 1", p.HalfmoveClock(), p.FullmoveNumber())
+	}
+}
+
+func TestParseFEN_Invalid(t *testing.T) {
+	fens := []string{
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNRR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/7/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/ppppxppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQQBNR w KQkq - 0 1",
+		"rnbqkbnp/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkx - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KKkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e3 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq z9 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 0",
+	}
+	for _, fen := range fens {
+		if _, err := ParseFEN(fen); err == nil {
+			t.Errorf("ParseFEN(%q) succeeded, want error", fen)
+		}
+	}
+}
+
+func TestPosition_Accessors(t *testing.T) {
+	p, err := ParseFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b Kq e3 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pieces := map[Square]Piece{
+		A1: WhiteRook,
+		E1: WhiteKing,
+		E2: NoPiece,
+		E4: WhitePawn,
+		D8: BlackQueen,
+		G8: BlackKnight,
+		E5: NoPiece,
+	}
+	for sq, want := range pieces {
+		if got := p.PieceAt(sq); got != want {
+			t.Errorf("PieceAt(%v) = %v, want %v", sq, got, want)
+		}
+	}
+
+	if got := p.SideToMove(); got != Black {
+		t.Errorf("SideToMove() = %v, want black", got)
+	}
+	if got := p.CastlingRights(); got != WhiteKingside|BlackQueenside {
+		t.Errorf("CastlingRights() = %v, want Kq", got)
+	}
+	if got := p.EnPassant(); got != E3 {
+		t.Errorf("EnPassant() = %v, want e3", got)
+	}
+}
+
+func TestStartingPosition(t *testing.T) {
+	if got := StartingPosition().String(); got != StartingFEN {
+		t.Errorf("got %q, want %q", got, StartingFEN)
+	}
+}
@@ -0,0 +1,64 @@
+package refengine
+
+import "github.com/clfs/chess"
+
+// pieceValues are the material values of the pieces in centipawns.
+var pieceValues = [...]int{
+	chess.Pawn:   100,
+	chess.Knight: 320,
+	chess.Bishop: 330,
+	chess.Rook:   500,
+	chess.Queen:  900,
+	chess.King:   0,
+}
+
+// evaluate returns a static evaluation of p in centipawns, from the point of
+// view of the side to move. It counts material, and rewards advanced pawns and
+// central knights and bishops.
+func evaluate(p *chess.Position) int {
+	score := 0
+	for _, c := range []chess.Color{chess.White, chess.Black} {
+		side := 0
+		for pt := chess.Pawn; pt <= chess.King; pt++ {
+			for _, sq := range p.Pieces(c, pt).Squares() {
+				side += pieceValues[pt] + placement(c, pt, sq)
+			}
+		}
+		if c == p.SideToMove() {
+			score += side
+		} else {
+			score -= side
+		}
+	}
+	return score
+}
+
+// placement returns the bonus for a piece of type pt and color c on sq.
+func placement(c chess.Color, pt chess.PieceType, sq chess.Square) int {
+	switch pt {
+	case chess.Pawn:
+		advanced := int(sq.Rank()) - 1
+		if c == chess.Black {
+			advanced = 6 - int(sq.Rank())
+		}
+		return 5 * advanced
+	case chess.Knight:
+		return 5*centrality(sq) - 15
+	case chess.Bishop:
+		return 3*centrality(sq) - 9
+	}
+	return 0
+}
+
+// centrality returns how close sq is to the center, from 0 in the corners to 6
+// in the center.
+func centrality(sq chess.Square) int {
+	f, r := int(sq.File()), int(sq.Rank())
+	if f > 3 {
+		f = 7 - f
+	}
+	if r > 3 {
+		r = 7 - r
+	}
+	return f + r
+}
@@ -0,0 +1,30 @@
+package refengine
+
+import (
+	"testing"
+
+	"github.com/clfs/chess"
+)
+
+func TestEvaluate(t *testing.T) {
+	cases := []struct {
+		fen  string
+		want int
+	}{
+		{chess.StartingPosition().String(), 0},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1", 0},
+		{"4k3/8/8/8/4P3/8/8/4K3 w - - 0 1", 110},
+		{"4k3/8/8/8/4P3/8/8/4K3 b - - 0 1", -110},
+		{"4k3/8/8/8/3N4/8/8/4K3 w - - 0 1", 320 + 5*6 - 15},
+		{"4k3/8/8/8/8/8/8/N3K3 w - - 0 1", 320 - 15},
+	}
+	for _, tc := range cases {
+		p, err := chess.ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := evaluate(p); got != tc.want {
+			t.Errorf("evaluate(%q): want %d, got %d", tc.fen, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package refengine implements a small reference chess engine.
+//
+// The engine evaluates material and piece placement, and searches with
+// alpha-beta to a fixed depth. It is weak, but it is deterministic: the same
+// position and limits always give the same best move. That makes it a useful
+// opponent in tests and examples of code that talks to engines, such as the
+// match runner.
+package refengine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/clfs/chess/uciengine"
+)
+
+// DefaultDepth is the depth the engine searches to when neither the "Depth"
+// option nor the search limits say otherwise.
+const DefaultDepth = 3
+
+// maxDepth is the deepest the engine searches.
+const maxDepth = 32
+
+// Engine is the reference engine. It implements uciengine.Engine. Use New to
+// create one.
+type Engine struct {
+	mu      sync.Mutex
+	depth   int
+	pos     *chess.Position
+	history []uint64 // Hashes of the positions played so far, including pos.
+}
+
+var _ uciengine.Engine = (*Engine)(nil)
+
+// New returns an engine set up at the starting position.
+func New() *Engine {
+	pos := chess.StartingPosition()
+	return &Engine{depth: DefaultDepth, pos: pos, history: []uint64{pos.Hash()}}
+}
+
+// ID implements uciengine.Engine.
+func (e *Engine) ID() (name, author string) {
+	return "Reference Engine", "clfs"
+}
+
+// Options implements uciengine.Engine. The engine has a single option, "Depth",
+// the number of plies to search when the search limits don't give a depth.
+func (e *Engine) Options() []uci.Option {
+	return []uci.Option{
+		uci.SpinOption{Name: "Depth", Default: DefaultDepth, Min: 1, Max: maxDepth},
+	}
+}
+
+// SetOption implements uciengine.Engine.
+func (e *Engine) SetOption(name, value string) error {
+	if name != "Depth" {
+		return fmt.Errorf("unknown option %q", name)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > maxDepth {
+		return fmt.Errorf("invalid depth %q", value)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.depth = n
+	return nil
+}
+
+// NewGame implements uciengine.Engine. The engine keeps no state between games,
+// so it does nothing.
+func (e *Engine) NewGame() {}
+
+// Position implements uciengine.Engine. It returns an error if the FEN is
+// invalid or one of the moves is illegal.
+func (e *Engine) Position(p uci.PositionParams) error {
+	pos := chess.StartingPosition()
+	if !p.StartPos {
+		var err error
+		if pos, err = chess.ParseFEN(p.FEN); err != nil {
+			return err
+		}
+	}
+	history := []uint64{pos.Hash()}
+	for _, m := range p.Moves {
+		if !pos.IsLegal(m) {
+			return fmt.Errorf("illegal move %v", m)
+		}
+		pos.Apply(m)
+		history = append(history, pos.Hash())
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pos, e.history = pos, history
+	return nil
+}
+
+// Search implements uciengine.Engine. It searches to the depth given by s, or
+// the "Depth" option, sending information after each completed iteration. A
+// mate search searches deep enough to find the mate. Searches with a move
+// time or a clock stop early when time runs out, and searches with a node
+// limit stop after searching that many nodes. When a search stops early, the
+// best move of the last completed iteration is returned.
+func (e *Engine) Search(s uci.Search, stop <-chan struct{}, info chan<- uci.Info) uci.BestMove {
+	e.mu.Lock()
+	depth := e.depth
+	sr := &searcher{
+		pos:      e.pos.Clone(),
+		history:  append([]uint64{}, e.history...),
+		stop:     stop,
+		maxNodes: s.Nodes,
+		start:    time.Now(),
+	}
+	e.mu.Unlock()
+
+	switch {
+	case s.Depth > 0:
+		depth = s.Depth
+	case s.Mate > 0:
+		depth = 2*s.Mate - 1
+	case s.Infinite:
+		depth = maxDepth
+	}
+	if depth > maxDepth {
+		depth = maxDepth
+	}
+	sr.deadline = deadline(s, sr.pos.SideToMove(), sr.start)
+
+	bm := sr.run(depth, s.SearchMoves, info)
+
+	if s.Infinite || s.Ponder {
+		<-stop
+	}
+	return bm
+}
+
+// deadline returns when a search started at start must stop, or the zero time
+// if it has no time limit. With a clock, the engine spends a fixed fraction of
+// its remaining time plus half its increment.
+func deadline(s uci.Search, c chess.Color, start time.Time) time.Time {
+	if s.Infinite || s.Ponder {
+		return time.Time{}
+	}
+	if s.MoveTime > 0 {
+		return start.Add(s.MoveTime)
+	}
+
+	left, inc := s.WhiteTime, s.WhiteIncrement
+	if c == chess.Black {
+		left, inc = s.BlackTime, s.BlackIncrement
+	}
+	if left <= 0 {
+		return time.Time{}
+	}
+	movesToGo := 30
+	if s.MovesToGo > 0 && s.MovesToGo < movesToGo {
+		movesToGo = s.MovesToGo
+	}
+	budget := left/time.Duration(movesToGo) + inc/2
+	if budget > left/2 {
+		budget = left / 2
+	}
+	return start.Add(budget)
+}
+
+// Client serves e in the background and returns a client connected to it. The
+// client hasn't done the "uci" handshake yet. Closing the returned io.Closer
+// disconnects the client and waits for the engine to shut down.
+//
+// The client and the engine talk over operating system pipes, which buffer
+// like the standard input and output of an engine process do.
+func (e *Engine) Client() (*uci.Client, io.Closer, error) {
+	r, engineOut, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	engineIn, w, err := os.Pipe()
+	if err != nil {
+		r.Close()
+		engineOut.Close()
+		return nil, nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		uciengine.Serve(engineIn, engineOut, e)
+		engineIn.Close()
+		engineOut.Close()
+	}()
+	return uci.NewClient(r, w), closerFunc(func() error {
+		w.Close()
+		<-done
+		return r.Close()
+	}), nil
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
@@ -0,0 +1,117 @@
+package refengine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/match"
+	"github.com/clfs/chess/uci"
+)
+
+// client returns a handshaken client connected to a new engine.
+func client(t *testing.T) *uci.Client {
+	t.Helper()
+	c, closer, err := New().Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closer.Close() })
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// bestMove runs a search and returns its best move.
+func bestMove(t *testing.T, c *uci.Client, s uci.Search) uci.BestMove {
+	t.Helper()
+	infos, bms, err := c.Go(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range infos {
+	}
+	bm, ok := <-bms
+	if !ok {
+		t.Fatal("no best move")
+	}
+	return bm
+}
+
+func TestEngine_Client(t *testing.T) {
+	c := client(t)
+	if name, _ := c.ID(); name != "Reference Engine" {
+		t.Errorf("want name %q, got %q", "Reference Engine", name)
+	}
+	if err := c.SetOption("Depth", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Position(uci.PositionParams{FEN: "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"}); err != nil {
+		t.Fatal(err)
+	}
+	if bm := bestMove(t, c, uci.Search{}); bm.Move != (chess.Move{From: chess.A1, To: chess.A8}) {
+		t.Errorf("want a1a8, got %v", bm.Move)
+	}
+
+}
+
+func TestEngine_Infinite(t *testing.T) {
+	c := client(t)
+	if err := c.PositionStartPos(nil); err != nil {
+		t.Fatal(err)
+	}
+	infos, bms, err := c.Go(uci.Search{Infinite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-infos // The search has started.
+	time.Sleep(50 * time.Millisecond)
+	if err := c.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	for range infos {
+	}
+	if bm, ok := <-bms; !ok || !chess.StartingPosition().IsLegal(bm.Move) {
+		t.Errorf("want a legal best move, got %v", bm)
+	}
+}
+
+func TestEngine_Position(t *testing.T) {
+	e := New()
+	for _, p := range []uci.PositionParams{
+		{FEN: "not a fen"},
+		{StartPos: true, Moves: []chess.Move{{From: chess.E2, To: chess.E5}}},
+	} {
+		if err := e.Position(p); err == nil {
+			t.Errorf("Position(%v): want error", p)
+		}
+	}
+
+	p := uci.PositionParams{StartPos: true, Moves: []chess.Move{{From: chess.E2, To: chess.E4}, {From: chess.E7, To: chess.E5}}}
+	if err := e.Position(p); err != nil {
+		t.Errorf("Position(%v): %v", p, err)
+	}
+}
+
+func TestEngine_Match(t *testing.T) {
+	white, black := client(t), client(t)
+	m := &match.Match{
+		White:  white,
+		Black:  black,
+		Search: uci.Search{Depth: 1},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	g, err := m.Play(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Result == chess.NoOutcome {
+		t.Errorf("game didn't finish: %v", g.Tag("Termination"))
+	}
+	if got := g.Tag("White"); got != "Reference Engine" {
+		t.Errorf("White: want %q, got %q", "Reference Engine", got)
+	}
+}
@@ -0,0 +1,291 @@
+package refengine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+const (
+	infinity  = 1 << 20
+	mateScore = 100000 // The score of checkmating on the next move, minus the ply.
+)
+
+// searcher is the state of a single search.
+type searcher struct {
+	pos     *chess.Position
+	history []uint64 // Hashes of the positions so far, including pos.
+
+	stop     <-chan struct{}
+	start    time.Time
+	deadline time.Time // Zero if there is no time limit.
+	maxNodes int64     // Zero if there is no node limit.
+
+	nodes   int64
+	aborted bool
+}
+
+// run searches the position with iterative deepening, from depth 1 to depth,
+// and returns the best move. If searchMoves isn't empty, only those moves are
+// searched at the root.
+func (s *searcher) run(depth int, searchMoves []chess.Move, info chan<- uci.Info) uci.BestMove {
+	root := s.pos.LegalMoves()
+	if len(searchMoves) > 0 {
+		var restricted []chess.Move
+		for _, m := range root {
+			for _, sm := range searchMoves {
+				if m == sm {
+					restricted = append(restricted, m)
+					break
+				}
+			}
+		}
+		root = restricted
+	}
+	if len(root) == 0 {
+		return uci.BestMove{}
+	}
+	s.order(root)
+
+	pv := []chess.Move{root[0]}
+	for d := 1; d <= depth; d++ {
+		score, line := s.searchRoot(d, root)
+		if s.aborted {
+			break
+		}
+		pv = line
+		info <- uci.Info{
+			Depth: d,
+			Score: uciScore(score),
+			Time:  time.Since(s.start),
+			Nodes: s.nodes,
+			PV:    append([]chess.Move{}, line...),
+		}
+
+		// Search the best move first in the next iteration.
+		for i, m := range root {
+			if m == line[0] {
+				copy(root[1:i+1], root[:i])
+				root[0] = m
+				break
+			}
+		}
+
+		if isMate(score) {
+			break
+		}
+		// Another iteration would likely run out of time.
+		if !s.deadline.IsZero() && time.Since(s.start) > s.deadline.Sub(s.start)/2 {
+			break
+		}
+	}
+
+	bm := uci.BestMove{Move: pv[0]}
+	if len(pv) > 1 {
+		bm.Ponder = pv[1]
+	}
+	return bm
+}
+
+// searchRoot searches the root moves to depth and returns the score and the
+// principal variation.
+func (s *searcher) searchRoot(depth int, root []chess.Move) (int, []chess.Move) {
+	alpha := -infinity
+	var pv []chess.Move
+	for _, m := range root {
+		s.apply(m)
+		score, line := s.search(depth-1, 1, -infinity, -alpha)
+		s.unapply()
+		if s.aborted {
+			return 0, nil
+		}
+		if score = -score; score > alpha || pv == nil {
+			alpha = score
+			pv = append([]chess.Move{m}, line...)
+		}
+	}
+	return alpha, pv
+}
+
+// search searches the current position to depth with alpha-beta, and returns
+// the score from the point of view of the side to move and the principal
+// variation.
+func (s *searcher) search(depth, ply, alpha, beta int) (int, []chess.Move) {
+	if s.abort() {
+		return 0, nil
+	}
+	if s.isDraw() {
+		return 0, nil
+	}
+
+	moves := s.pos.LegalMoves()
+	if len(moves) == 0 {
+		if s.pos.InCheck() {
+			return -mateScore + ply, nil
+		}
+		return 0, nil
+	}
+	if depth <= 0 {
+		return s.quiesce(alpha, beta), nil
+	}
+
+	s.order(moves)
+	var pv []chess.Move
+	for _, m := range moves {
+		s.apply(m)
+		score, line := s.search(depth-1, ply+1, -beta, -alpha)
+		s.unapply()
+		if s.aborted {
+			return 0, nil
+		}
+		if score = -score; score > alpha {
+			alpha = score
+			pv = append([]chess.Move{m}, line...)
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+	return alpha, pv
+}
+
+// quiesce searches captures and promotions until the position is quiet, so
+// that the evaluation doesn't stop in the middle of an exchange.
+func (s *searcher) quiesce(alpha, beta int) int {
+	if s.abort() {
+		return 0
+	}
+
+	standPat := evaluate(s.pos)
+	if standPat >= beta {
+		return standPat
+	}
+	if standPat > alpha {
+		alpha = standPat
+	}
+
+	moves := s.pos.LegalMoves()
+	s.order(moves)
+	for _, m := range moves {
+		if !s.isCapture(m) && m.Promotion == chess.NoPieceType {
+			// Ordering puts all captures and promotions first.
+			break
+		}
+		s.pos.Apply(m)
+		score := -s.quiesce(-beta, -alpha)
+		s.pos.Unapply()
+		if s.aborted {
+			return 0
+		}
+		if score > alpha {
+			alpha = score
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+	return alpha
+}
+
+// abort counts a node and reports whether the search must stop.
+func (s *searcher) abort() bool {
+	if s.aborted {
+		return true
+	}
+	s.nodes++
+	if s.maxNodes > 0 && s.nodes >= s.maxNodes {
+		s.aborted = true
+	}
+	if s.nodes%1024 == 0 {
+		select {
+		case <-s.stop:
+			s.aborted = true
+		default:
+		}
+		if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+			s.aborted = true
+		}
+	}
+	return s.aborted
+}
+
+func (s *searcher) apply(m chess.Move) {
+	s.pos.Apply(m)
+	s.history = append(s.history, s.pos.Hash())
+}
+
+func (s *searcher) unapply() {
+	s.pos.Unapply()
+	s.history = s.history[:len(s.history)-1]
+}
+
+// isDraw reports whether the position is drawn by the fifty-move rule, or
+// repeats an earlier one. A single repetition is enough, since the side that
+// repeated could repeat again.
+func (s *searcher) isDraw() bool {
+	clock := s.pos.HalfmoveClock()
+	if clock >= 100 {
+		return true
+	}
+	n := len(s.history) - 1
+	for i := n - 2; i >= 0 && i >= n-clock; i -= 2 {
+		if s.history[i] == s.history[n] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *searcher) isCapture(m chess.Move) bool {
+	if s.pos.PieceAt(m.To) != chess.NoPiece {
+		return true
+	}
+	return m.To == s.pos.EnPassant() && s.pos.PieceAt(m.From).Type() == chess.Pawn
+}
+
+// order sorts moves so that captures and promotions come first, with the most
+// valuable victims captured by the least valuable attackers first. The sort is
+// stable, so the order is deterministic.
+func (s *searcher) order(moves []chess.Move) {
+	keys := make(map[chess.Move]int, len(moves))
+	for _, m := range moves {
+		k := 0
+		if s.isCapture(m) {
+			victim := pieceValues[chess.Pawn]
+			if pc := s.pos.PieceAt(m.To); pc != chess.NoPiece {
+				victim = pieceValues[pc.Type()]
+			}
+			k = 10*victim - pieceValues[s.pos.PieceAt(m.From).Type()] + infinity
+		}
+		if m.Promotion != chess.NoPieceType {
+			k += pieceValues[m.Promotion] + infinity
+		}
+		keys[m] = k
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return keys[moves[i]] > keys[moves[j]]
+	})
+}
+
+// uciScore converts a score to UCI's representation.
+func uciScore(score int) uci.Score {
+	var s uci.Score
+	switch {
+	case isMate(score) && score > 0:
+		s.Mate.Found = true
+		s.Mate.MovesUntil = (mateScore - score + 1) / 2
+	case isMate(score):
+		s.Mate.Found = true
+		s.Mate.MovesUntil = -(mateScore + score) / 2
+	default:
+		s.CP = score
+	}
+	return s
+}
+
+// isMate reports whether score is a mating or mated score.
+func isMate(score int) bool {
+	return score >= mateScore-maxDepth || score <= -mateScore+maxDepth
+}
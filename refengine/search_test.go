@@ -0,0 +1,132 @@
+package refengine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+// search searches fen with the given limits and returns the best move and the
+// information sent.
+func search(t *testing.T, fen string, s uci.Search) (uci.BestMove, []uci.Info) {
+	t.Helper()
+	e := New()
+	if err := e.Position(uci.PositionParams{FEN: fen}); err != nil {
+		t.Fatal(err)
+	}
+	info := make(chan uci.Info, maxDepth)
+	bm := e.Search(s, nil, info)
+	close(info)
+	var infos []uci.Info
+	for i := range info {
+		infos = append(infos, i)
+	}
+	return bm, infos
+}
+
+func TestSearch(t *testing.T) {
+	cases := []struct {
+		name   string
+		fen    string
+		search uci.Search
+		want   chess.Move
+	}{
+		{"mate in one", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", uci.Search{Depth: 2}, chess.Move{From: chess.A1, To: chess.A8}},
+		{"hanging queen", "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", uci.Search{Depth: 1}, chess.Move{From: chess.D2, To: chess.D5}},
+		{"promotion", "8/4P3/8/8/8/8/k7/6K1 w - - 0 1", uci.Search{Depth: 2}, chess.Move{From: chess.E7, To: chess.E8, Promotion: chess.Queen}},
+		{"search moves", "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", uci.Search{Depth: 2, SearchMoves: []chess.Move{{From: chess.E1, To: chess.F1}}}, chess.Move{From: chess.E1, To: chess.F1}},
+		{"checkmated", "R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1", uci.Search{Depth: 2}, chess.Move{}},
+		{"node limit", chess.StartingPosition().String(), uci.Search{Depth: 10, Nodes: 500}, chess.Move{From: chess.B1, To: chess.C3}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bm, _ := search(t, tc.fen, tc.search)
+			if bm.Move != tc.want {
+				t.Errorf("want %v, got %v", tc.want, bm.Move)
+			}
+		})
+	}
+}
+
+func TestSearch_Info(t *testing.T) {
+	bm, infos := search(t, "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", uci.Search{Mate: 1})
+	if len(infos) != 1 {
+		t.Fatalf("want 1 info, got %d", len(infos))
+	}
+	want := uci.Score{}
+	want.Mate.Found = true
+	want.Mate.MovesUntil = 1
+	if diff := cmp.Diff(want, infos[0].Score); diff != "" {
+		t.Errorf("score mismatch (-want +got):\n%s", diff)
+	}
+	if got := infos[0].PV; len(got) != 1 || got[0] != bm.Move {
+		t.Errorf("PV: want [%v], got %v", bm.Move, got)
+	}
+
+	bm, infos = search(t, chess.StartingPosition().String(), uci.Search{Depth: 3})
+	if len(infos) != 3 {
+		t.Fatalf("want 3 infos, got %d", len(infos))
+	}
+	for i, info := range infos {
+		if info.Depth != i+1 {
+			t.Errorf("info %d: want depth %d, got %d", i, i+1, info.Depth)
+		}
+	}
+	last := infos[len(infos)-1]
+	if last.PV[0] != bm.Move || last.PV[1] != bm.Ponder {
+		t.Errorf("best move %v doesn't match PV %v", bm, last.PV)
+	}
+}
+
+func TestSearch_Time(t *testing.T) {
+	fen := chess.StartingPosition().String()
+	for _, s := range []uci.Search{
+		{Depth: maxDepth, MoveTime: 50 * time.Millisecond},
+		{Depth: maxDepth, WhiteTime: time.Second, BlackTime: time.Millisecond},
+		{Depth: maxDepth, WhiteTime: time.Second, MovesToGo: 1},
+	} {
+		start := time.Now()
+		search(t, fen, s)
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("%v took %v", s, d)
+		}
+	}
+}
+
+func TestSearch_Deterministic(t *testing.T) {
+	const fen = "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3"
+	want, _ := search(t, fen, uci.Search{Depth: 3})
+	for i := 0; i < 3; i++ {
+		if got, _ := search(t, fen, uci.Search{Depth: 3}); got != want {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+	}
+}
+
+func TestUCIScore(t *testing.T) {
+	mate := func(n int) uci.Score {
+		var s uci.Score
+		s.Mate.Found = true
+		s.Mate.MovesUntil = n
+		return s
+	}
+	cases := []struct {
+		score int
+		want  uci.Score
+	}{
+		{35, uci.Score{CP: 35}},
+		{-120, uci.Score{CP: -120}},
+		{mateScore - 1, mate(1)},
+		{mateScore - 3, mate(2)},
+		{-mateScore + 2, mate(-1)},
+		{-mateScore, mate(0)},
+	}
+	for _, tc := range cases {
+		if diff := cmp.Diff(tc.want, uciScore(tc.score)); diff != "" {
+			t.Errorf("uciScore(%d) mismatch (-want +got):\n%s", tc.score, diff)
+		}
+	}
+}
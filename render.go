@@ -0,0 +1,79 @@
+package chess
+
+import "strings"
+
+// RenderOptions control how Render draws a position.
+type RenderOptions struct {
+	Unicode     bool // Use figurines, such as "♘", instead of FEN letters.
+	Flipped     bool // Draw the board from Black's side, with rank 1 first.
+	Coordinates bool // Label the ranks and files.
+
+	// LastMove, if not the zero Move, marks the squares it moved from and to
+	// with brackets, such as "[N]".
+	LastMove Move
+}
+
+// figurines are the Unicode chess symbols for each piece, indexed by Piece.
+var figurines = [...]string{"·", "♙", "♘", "♗", "♖", "♕", "♔", "♟", "♞", "♝", "♜", "♛", "♚"}
+
+// Render returns a diagram of the board, one rank per line, for use in
+// terminals and logs. Empty squares are "." ("·" with Unicode figurines), and
+// each square is three characters wide:
+//
+//	8  r  n  b  q  k  b  n  r
+//	7  p  p  p  p  .  p  p  p
+//	...
+//	1  R  N  B  Q  K  B  N  R
+//	   a  b  c  d  e  f  g  h
+func (p *Position) Render(opts RenderOptions) string {
+	ranks := [8]Rank{Rank8, Rank7, Rank6, Rank5, Rank4, Rank3, Rank2, Rank1}
+	files := [8]File{FileA, FileB, FileC, FileD, FileE, FileF, FileG, FileH}
+	if opts.Flipped {
+		for i := 0; i < 4; i++ {
+			ranks[i], ranks[7-i] = ranks[7-i], ranks[i]
+			files[i], files[7-i] = files[7-i], files[i]
+		}
+	}
+	highlight := opts.LastMove != Move{}
+
+	var b strings.Builder
+	for _, r := range ranks {
+		if opts.Coordinates {
+			b.WriteString(r.String())
+			b.WriteByte(' ')
+		}
+		for _, f := range files {
+			sq := NewSquare(f, r)
+			left, right := " ", " "
+			if highlight && (sq == opts.LastMove.From || sq == opts.LastMove.To) {
+				left, right = "[", "]"
+			}
+			b.WriteString(left)
+			b.WriteString(p.renderPiece(sq, opts.Unicode))
+			b.WriteString(right)
+		}
+		b.WriteByte('\n')
+	}
+	if opts.Coordinates {
+		b.WriteString("  ")
+		for _, f := range files {
+			b.WriteByte(' ')
+			b.WriteString(f.String())
+			b.WriteByte(' ')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// renderPiece returns the symbol for the piece on sq.
+func (p *Position) renderPiece(sq Square, unicode bool) string {
+	pc := p.board[sq]
+	switch {
+	case unicode:
+		return figurines[pc]
+	case pc == NoPiece:
+		return "."
+	}
+	return pc.String()
+}
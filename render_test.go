@@ -0,0 +1,61 @@
+package chess
+
+import "testing"
+
+func TestPosition_Render(t *testing.T) {
+	p := StartingPosition()
+	p.Apply(Move{From: E2, To: E4})
+	last := Move{From: E2, To: E4}
+
+	cases := []struct {
+		name string
+		opts RenderOptions
+		want string
+	}{
+		{
+			name: "plain",
+			want: "" +
+				" r  n  b  q  k  b  n  r \n" +
+				" p  p  p  p  p  p  p  p \n" +
+				" .  .  .  .  .  .  .  . \n" +
+				" .  .  .  .  .  .  .  . \n" +
+				" .  .  .  .  P  .  .  . \n" +
+				" .  .  .  .  .  .  .  . \n" +
+				" P  P  P  P  .  P  P  P \n" +
+				" R  N  B  Q  K  B  N  R \n",
+		},
+		{
+			name: "coordinates and last move",
+			opts: RenderOptions{Coordinates: true, LastMove: last},
+			want: "" +
+				"8  r  n  b  q  k  b  n  r \n" +
+				"7  p  p  p  p  p  p  p  p \n" +
+				"6  .  .  .  .  .  .  .  . \n" +
+				"5  .  .  .  .  .  .  .  . \n" +
+				"4  .  .  .  . [P] .  .  . \n" +
+				"3  .  .  .  .  .  .  .  . \n" +
+				"2  P  P  P  P [.] P  P  P \n" +
+				"1  R  N  B  Q  K  B  N  R \n" +
+				"   a  b  c  d  e  f  g  h \n",
+		},
+		{
+			name: "unicode flipped",
+			opts: RenderOptions{Unicode: true, Flipped: true, Coordinates: true},
+			want: "" +
+				"1  ♖  ♘  ♗  ♔  ♕  ♗  ♘  ♖ \n" +
+				"2  ♙  ♙  ♙  ·  ♙  ♙  ♙  ♙ \n" +
+				"3  ·  ·  ·  ·  ·  ·  ·  · \n" +
+				"4  ·  ·  ·  ♙  ·  ·  ·  · \n" +
+				"5  ·  ·  ·  ·  ·  ·  ·  · \n" +
+				"6  ·  ·  ·  ·  ·  ·  ·  · \n" +
+				"7  ♟  ♟  ♟  ♟  ♟  ♟  ♟  ♟ \n" +
+				"8  ♜  ♞  ♝  ♚  ♛  ♝  ♞  ♜ \n" +
+				"   h  g  f  e  d  c  b  a \n",
+		},
+	}
+	for _, tc := range cases {
+		if got := p.Render(tc.opts); got != tc.want {
+			t.Errorf("%s: Render() =\n%s\nwant\n%s", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sanLetters are the SAN letters for each piece type, indexed by PieceType.
+const sanLetters = " PNBRQK"
+
+// FormatSAN returns m in Standard Algebraic Notation, such as "Nbd7", "exd6",
+// "e8=Q+" or "O-O#", or "N@f3" for a drop. The move must be legal in p.
+func FormatSAN(p *Position, m Move) string {
+	var b strings.Builder
+
+	pc := p.board[m.From]
+	capture := p.board[m.To] != NoPiece || (pc.Type() == Pawn && m.From.File() != m.To.File())
+
+	side, castles := p.castlingSide(m)
+	switch {
+	case m.Drop != NoPieceType:
+		b.WriteByte(sanLetters[m.Drop])
+		b.WriteByte('@')
+		b.WriteString(m.To.String())
+	case castles && side%2 == 0:
+		b.WriteString("O-O")
+	case castles:
+		b.WriteString("O-O-O")
+	case pc.Type() == Pawn:
+		if capture {
+			b.WriteString(m.From.File().String())
+			b.WriteByte('x')
+		}
+		b.WriteString(m.To.String())
+		if m.Promotion != NoPieceType {
+			b.WriteByte('=')
+			b.WriteByte(sanLetters[m.Promotion])
+		}
+	default:
+		b.WriteByte(sanLetters[pc.Type()])
+		b.WriteString(p.disambiguation(m))
+		if capture {
+			b.WriteByte('x')
+		}
+		b.WriteString(m.To.String())
+	}
+
+	p.Apply(m)
+	if p.InCheck() {
+		if len(p.LegalMoves()) == 0 {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('+')
+		}
+	}
+	p.Unapply()
+
+	return b.String()
+}
+
+// disambiguation returns the shortest prefix of m.From that distinguishes m
+// from other legal moves by the same kind of piece to the same square.
+func (p *Position) disambiguation(m Move) string {
+	pc := p.board[m.From]
+	var sameFile, sameRank, others bool
+	for _, lm := range p.LegalMoves() {
+		if lm.To != m.To || lm.From == m.From || p.board[lm.From] != pc {
+			continue
+		}
+		others = true
+		if lm.From.File() == m.From.File() {
+			sameFile = true
+		}
+		if lm.From.Rank() == m.From.Rank() {
+			sameRank = true
+		}
+	}
+	switch {
+	case !others:
+		return ""
+	case !sameFile:
+		return m.From.File().String()
+	case !sameRank:
+		return m.From.Rank().String()
+	default:
+		return m.From.String()
+	}
+}
+
+// ParseSAN parses a move in Standard Algebraic Notation, such as "Nbd7",
+// "exd6", "e8=Q+" or "O-O", and returns it if it is legal in p. Check and mate
+// indicators and annotations like "!?" are optional and ignored, as is
+// unnecessary disambiguation. Castling may also be written with zeros, and
+// pawn drops without the P, as in "@e4".
+func ParseSAN(p *Position, s string) (Move, error) {
+	san := strings.TrimRight(s, "+#!?")
+
+	switch san {
+	case "O-O", "0-0":
+		return p.parseCastling(s, true)
+	case "O-O-O", "0-0-0":
+		return p.parseCastling(s, false)
+	}
+
+	if piece, square, ok := strings.Cut(san, "@"); ok {
+		pt := Pawn
+		if piece != "" && piece != "P" {
+			pt = pieceTypeFromSAN(piece)
+		}
+		to, err := ParseSquare(square)
+		if err != nil || pt == NoPieceType || pt == King {
+			return Move{}, fmt.Errorf("invalid SAN %q", s)
+		}
+		m := Move{From: to, To: to, Drop: pt}
+		if !p.IsLegal(m) {
+			return Move{}, fmt.Errorf("illegal move %q", s)
+		}
+		return m, nil
+	}
+
+	pt := Pawn
+	if san != "" && strings.IndexByte(sanLetters[2:], san[0]) >= 0 {
+		pt = PieceType(strings.IndexByte(sanLetters, san[0]))
+		san = san[1:]
+	}
+
+	var promotion PieceType
+	if i := strings.IndexByte(san, '='); i >= 0 {
+		san, promotion = san[:i], pieceTypeFromSAN(san[i+1:])
+		if promotion == NoPieceType {
+			return Move{}, fmt.Errorf("invalid SAN %q", s)
+		}
+	} else if pt == Pawn && len(san) > 2 {
+		if promo := pieceTypeFromSAN(san[len(san)-1:]); promo != NoPieceType {
+			san, promotion = san[:len(san)-1], promo
+		}
+	}
+
+	if len(san) < 2 {
+		return Move{}, fmt.Errorf("invalid SAN %q", s)
+	}
+	to, err := ParseSquare(san[len(san)-2:])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid SAN %q", s)
+	}
+
+	// What remains is the disambiguation and capture marker.
+	from := strings.TrimSuffix(san[:len(san)-2], "x")
+	fromFile, fromRank := File(-1), Rank(-1)
+	for i := 0; i < len(from); i++ {
+		switch c := from[i]; {
+		case c >= 'a' && c <= 'h':
+			fromFile = File(c - 'a')
+		case c >= '1' && c <= '8':
+			fromRank = Rank(c - '1')
+		default:
+			return Move{}, fmt.Errorf("invalid SAN %q", s)
+		}
+	}
+
+	var (
+		match Move
+		n     int
+	)
+	for _, m := range p.LegalMoves() {
+		if m.To != to || m.Promotion != promotion || p.board[m.From].Type() != pt {
+			continue
+		}
+		if _, ok := p.castlingSide(m); ok {
+			continue // In Chess960, castling looks like the king taking a rook.
+		}
+		if (fromFile >= 0 && m.From.File() != fromFile) || (fromRank >= 0 && m.From.Rank() != fromRank) {
+			continue
+		}
+		match = m
+		n++
+	}
+	switch n {
+	case 0:
+		return Move{}, fmt.Errorf("illegal move %q", s)
+	case 1:
+		return match, nil
+	default:
+		return Move{}, fmt.Errorf("ambiguous move %q", s)
+	}
+}
+
+// parseCastling returns the castling move on the given side, if it is legal.
+func (p *Position) parseCastling(s string, kingside bool) (Move, error) {
+	i := castlingIndex(p.sideToMove, kingside)
+	if p.castling&(1<<i) != 0 {
+		m := p.castlingMove(i)
+		if p.IsLegal(m) {
+			return m, nil
+		}
+	}
+	return Move{}, fmt.Errorf("illegal move %q", s)
+}
+
+// pieceTypeFromSAN returns the promotion piece type for a SAN letter, or
+// NoPieceType. Kings are only promoted to in Antichess.
+func pieceTypeFromSAN(s string) PieceType {
+	switch s {
+	case "K":
+		return King
+	case "N":
+		return Knight
+	case "B":
+		return Bishop
+	case "R":
+		return Rook
+	case "Q":
+		return Queen
+	}
+	return NoPieceType
+}
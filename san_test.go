@@ -0,0 +1,118 @@
+package chess
+
+import "testing"
+
+func TestFormatSAN(t *testing.T) {
+	cases := []struct {
+		fen, move, want string
+	}{
+		{StartingFEN, "e2e4", "e4"},
+		{StartingFEN, "g1f3", "Nf3"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", "exd6"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "O-O-O"},
+		{"3k4/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8q", "b8=Q+"},
+		{"3k4/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8n", "b8=N"},
+		{"4k3/8/8/8/8/8/8/RN2K2R w - - 0 1", "a1d1", "Rd1"},
+		{"1k6/8/8/8/8/8/4K3/R6R w - - 0 1", "a1d1", "Rad1"},
+		{"4k3/8/8/8/R7/8/8/R3K3 w - - 0 1", "a1a2", "R1a2"},
+		{"k7/8/8/8/8/2Q1Q3/8/4Q2K w - - 0 1", "e3d2", "Qe3d2"},
+		{"4k3/8/8/3p4/8/2N1N3/8/4K3 w - - 0 1", "c3d5", "Ncxd5"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "d8h4", "Qh4#"},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := ParseMove(tc.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormatSAN(p, m); got != tc.want {
+			t.Errorf("%s: FormatSAN(%s) = %q, want %q", tc.fen, m, got, tc.want)
+		}
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	cases := []struct {
+		fen, san, want string
+	}{
+		{StartingFEN, "e4", "e2e4"},
+		{StartingFEN, "Nf3", "g1f3"},
+		{StartingFEN, "Ngf3", "g1f3"},
+		{StartingFEN, "Ng1f3!?", "g1f3"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", "e1g1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "0-0-0", "e1c1"},
+		{"3k4/1P6/8/8/8/8/8/4K3 w - - 0 1", "b8=Q+", "b7b8q"},
+		{"3k4/1P6/8/8/8/8/8/4K3 w - - 0 1", "b8N", "b7b8n"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6", "e5d6"},
+		{"4k3/8/8/8/8/8/8/R3K2R w - - 0 1", "Rhf1", "h1f1"},
+		{"k7/8/8/8/8/2Q1Q3/8/4Q2K w - - 0 1", "Qe3xd2", "e3d2"},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseSAN(p, tc.san)
+		if err != nil {
+			t.Errorf("%s: ParseSAN(%q): %v", tc.fen, tc.san, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("%s: ParseSAN(%q) = %s, want %s", tc.fen, tc.san, got, tc.want)
+		}
+	}
+}
+
+func TestParseSAN_Invalid(t *testing.T) {
+	cases := []struct {
+		fen, san string
+	}{
+		{StartingFEN, ""},
+		{StartingFEN, "e5"},
+		{StartingFEN, "O-O"},
+		{StartingFEN, "Ke2"},
+		{StartingFEN, "Nz3"},
+		{StartingFEN, "e"},
+		{"1k6/8/8/8/8/8/4K3/R6R w - - 0 1", "Rd1"},
+		{"3k4/1P6/8/8/8/8/8/4K3 w - - 0 1", "b8=K"},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m, err := ParseSAN(p, tc.san); err == nil {
+			t.Errorf("%s: ParseSAN(%q) = %s, want error", tc.fen, tc.san, m)
+		}
+	}
+}
+
+func TestSAN_RoundTrip(t *testing.T) {
+	fens := []string{
+		StartingFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	}
+	for _, fen := range fens {
+		p, err := ParseFEN(fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range p.LegalMoves() {
+			san := FormatSAN(p, m)
+			got, err := ParseSAN(p, san)
+			if err != nil {
+				t.Errorf("%s: ParseSAN(%q): %v", fen, san, err)
+				continue
+			}
+			if got != m {
+				t.Errorf("%s: ParseSAN(%q) = %s, want %s", fen, san, got, m)
+			}
+		}
+	}
+}
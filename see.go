@@ -0,0 +1,77 @@
+package chess
+
+// seeValues are the piece values used by SEE, in centipawns.
+var seeValues = [...]int{
+	NoPieceType: 0,
+	Pawn:        100,
+	Knight:      300,
+	Bishop:      300,
+	Rook:        500,
+	Queen:       900,
+	King:        20000,
+}
+
+// SEE returns the static exchange evaluation of m: the material the side to
+// move gains, in centipawns, if both sides keep capturing on m's destination
+// square with their least valuable piece for as long as it pays. Pawns are
+// worth 100, knights and bishops 300, rooks 500 and queens 900. Attacks
+// uncovered by the captures, such as a rook behind a queen, are counted, but
+// pins and checks elsewhere are not. SEE is 0 for castling, drops and for
+// moves that capture nothing and can't be captured back.
+//
+// m must be legal in the position.
+func (p *Position) SEE(m Move) int {
+	if _, ok := p.castlingSide(m); ok || m.Drop != NoPieceType {
+		return 0
+	}
+
+	to := m.To
+	occ := p.colors[White] | p.colors[Black]
+	var gain [32]int
+
+	attacker := p.board[m.From].Type()
+	gain[0] = seeValues[p.board[to].Type()]
+	if attacker == Pawn && to == p.enPassant {
+		gain[0] = seeValues[Pawn]
+		occ &^= bb(NewSquare(to.File(), m.From.Rank()))
+	}
+	if m.Promotion != NoPieceType {
+		gain[0] += seeValues[m.Promotion] - seeValues[Pawn]
+		attacker = m.Promotion
+	}
+
+	side := p.sideToMove
+	from := bb(m.From)
+	d := 0
+	for {
+		d++
+		gain[d] = seeValues[attacker] - gain[d-1]
+
+		// Capturing reveals sliders behind the piece, so the attackers are
+		// found again with it gone.
+		occ &^= from
+		side = side.Other()
+		attackers := p.attackersTo(to, occ) & occ
+		attacker, from = p.leastValuable(attackers & p.colors[side])
+		if from == 0 || attacker == King && attackers&p.colors[side.Other()] != 0 {
+			break
+		}
+	}
+	for d--; d > 0; d-- {
+		if -gain[d-1] < gain[d] {
+			gain[d-1] = -gain[d]
+		}
+	}
+	return gain[0]
+}
+
+// leastValuable returns the type and square of the least valuable piece among
+// attackers, or an empty bitboard if there are none.
+func (p *Position) leastValuable(attackers Bitboard) (PieceType, Bitboard) {
+	for pt := Pawn; pt <= King; pt++ {
+		if b := attackers & p.pieces[pt]; b != 0 {
+			return pt, bb(b.first())
+		}
+	}
+	return NoPieceType, 0
+}
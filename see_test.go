@@ -0,0 +1,51 @@
+package chess
+
+import "testing"
+
+func TestPosition_SEE(t *testing.T) {
+	cases := []struct {
+		fen  string
+		move string
+		want int
+	}{
+		// Undefended pawn.
+		{"1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", 100},
+		// Defended pawn, taken by a knight.
+		{"1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "d3e5", -200},
+		// Quiet move to an attacked square.
+		{"4k3/8/8/4p3/8/8/8/3QK3 w - - 0 1", "d1d4", -900},
+		// Quiet move to a safe square.
+		{StartingFEN, "e2e4", 0},
+		// Pawn takes a defended knight.
+		{"4k3/8/4p3/3n4/4P3/8/8/4K3 w - - 0 1", "e4d5", 200},
+		// A rook behind the queen joins in once the queen has captured.
+		{"3rk3/3r4/8/3p4/8/8/3Q4/3RK3 w - - 0 1", "d2d5", -800},
+		{"4k3/3r4/8/3p4/8/8/3R4/3QK3 w - - 0 1", "d2d5", 100},
+		// The king can't recapture a defended piece.
+		{"8/8/3k4/3p4/8/8/3R4/3RK3 w - - 0 1", "d2d5", 100},
+		{"8/8/3k4/3p4/8/8/3R4/4K3 w - - 0 1", "d2d5", -400},
+		// En passant.
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", 100},
+		// Promotion.
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8q", 800},
+		{"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7b8q", 1300},
+		// Castling.
+		{"4k3/8/8/8/8/8/8/4K2R w K - 0 1", "e1g1", 0},
+	}
+	for _, c := range cases {
+		p, err := ParseFEN(c.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := ParseMove(c.move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.IsLegal(m) {
+			t.Fatalf("%s: %s is illegal", c.fen, c.move)
+		}
+		if got := p.SEE(m); got != c.want {
+			t.Errorf("%s: SEE(%s) = %d, want %d", c.fen, c.move, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package chess
+
+import "testing"
+
+func TestParseSquare(t *testing.T) {
+	for sq := A1; sq <= H8; sq++ {
+		got, err := ParseSquare(sq.String())
+		if err != nil {
+			t.Errorf("ParseSquare(%q): %v", sq, err)
+			continue
+		}
+		if got != sq {
+			t.Errorf("ParseSquare(%q) = %d, want %d", sq, got, sq)
+		}
+	}
+
+	for _, s := range []string{"", "e", "e9", "i1", "E4", "e44"} {
+		if _, err := ParseSquare(s); err == nil {
+			t.Errorf("ParseSquare(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestSquare_FileRank(t *testing.T) {
+	cases := []struct {
+		sq   Square
+		f    File
+		r    Rank
+		name string
+	}{
+		{A1, FileA, Rank1, "a1"},
+		{H1, FileH, Rank1, "h1"},
+		{E4, FileE, Rank4, "e4"},
+		{A8, FileA, Rank8, "a8"},
+		{H8, FileH, Rank8, "h8"},
+	}
+	for _, tc := range cases {
+		if tc.sq.File() != tc.f || tc.sq.Rank() != tc.r {
+			t.Errorf("%v: got file %v rank %v, want %v %v", tc.sq, tc.sq.File(), tc.sq.Rank(), tc.f, tc.r)
+		}
+		if NewSquare(tc.f, tc.r) != tc.sq {
+			t.Errorf("NewSquare(%v, %v) = %v, want %v", tc.f, tc.r, NewSquare(tc.f, tc.r), tc.sq)
+		}
+		if tc.sq.String() != tc.name {
+			t.Errorf("got %q, want %q", tc.sq.String(), tc.name)
+		}
+	}
+}
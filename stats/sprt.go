@@ -0,0 +1,107 @@
+package stats
+
+import "math"
+
+// SPRT is a sequential probability ratio test between two hypotheses about
+// the Elo difference of an engine: H0, that it is Elo0, and H1, that it is
+// Elo1. Results are added as games finish, and the test ends as soon as the
+// log-likelihood ratio (LLR) leaves the bounds set by the error rates.
+//
+// The LLR is computed with the generalized SPRT approximation used by
+// fishtest, in the logistic Elo model.
+type SPRT struct {
+	Elo0, Elo1 float64
+	Alpha      float64 // The probability of accepting H1 when H0 is true.
+	Beta       float64 // The probability of accepting H0 when H1 is true.
+}
+
+// Decision is the state of an SPRT.
+type Decision int
+
+// Decisions.
+const (
+	Continue Decision = iota // Neither bound has been reached.
+	AcceptH0                 // The lower bound has been reached.
+	AcceptH1                 // The upper bound has been reached.
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Continue:
+		return "continue"
+	case AcceptH0:
+		return "H0 accepted"
+	case AcceptH1:
+		return "H1 accepted"
+	}
+	return "unknown"
+}
+
+// Bounds returns the bounds of the LLR.
+func (s SPRT) Bounds() (lower, upper float64) {
+	return math.Log(s.Beta / (1 - s.Alpha)), math.Log((1 - s.Beta) / s.Alpha)
+}
+
+// Decide returns the decision for an LLR.
+func (s SPRT) Decide(llr float64) Decision {
+	lower, upper := s.Bounds()
+	switch {
+	case llr <= lower:
+		return AcceptH0
+	case llr >= upper:
+		return AcceptH1
+	}
+	return Continue
+}
+
+// TrinomialLLR returns the LLR of game results.
+func (s SPRT) TrinomialLLR(t Trinomial) float64 {
+	return s.llr(t.dist())
+}
+
+// PentanomialLLR returns the LLR of results of pairs of games.
+func (s SPRT) PentanomialLLR(p Pentanomial) float64 {
+	return s.llr(p.dist())
+}
+
+// llr returns the LLR of a score distribution, approximating it with a
+// normal distribution of the same variance.
+func (s SPRT) llr(scores []float64, counts []int) float64 {
+	n, mean, variance := moments(scores, counts)
+	if n == 0 || variance == 0 {
+		return 0
+	}
+	s0, s1 := Score(s.Elo0), Score(s.Elo1)
+	return n * (s1 - s0) * (2*mean - s0 - s1) / (2 * variance)
+}
+
+// Tally accumulates results as games finish, in any order, for a streaming
+// test. Games are grouped into pairs by a pair number, such as the index of
+// the opening they were played from.
+type Tally struct {
+	Trinomial   Trinomial
+	Pentanomial Pentanomial
+
+	pending map[int]float64 // First games of unfinished pairs.
+}
+
+// Add tallies a game with the given score, 1 for a win, ½ for a draw and 0
+// for a loss, that belongs to the given pair. The pair is tallied when its
+// second game is added.
+func (t *Tally) Add(pair int, score float64) {
+	t.Trinomial.Add(score)
+	if first, ok := t.pending[pair]; ok {
+		delete(t.pending, pair)
+		t.Pentanomial.Add(first, score)
+		return
+	}
+	if t.pending == nil {
+		t.pending = make(map[int]float64)
+	}
+	t.pending[pair] = score
+}
+
+// Decide returns the decision of s for the pairs tallied so far.
+func (t *Tally) Decide(s SPRT) Decision {
+	return s.Decide(s.PentanomialLLR(t.Pentanomial))
+}
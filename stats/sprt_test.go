@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSPRT(t *testing.T) {
+	s := SPRT{Elo0: 0, Elo1: 5, Alpha: 0.05, Beta: 0.05}
+	lower, upper := s.Bounds()
+	if !near(lower, -2.944438979) || !near(upper, 2.944438979) {
+		t.Errorf("Bounds: got %v, %v", lower, upper)
+	}
+
+	if got, want := s.TrinomialLLR(Trinomial{Wins: 100, Draws: 200, Losses: 50}), 1.674010839; !near(got, want) {
+		t.Errorf("TrinomialLLR: want %v, got %v", want, got)
+	}
+	if got, want := s.PentanomialLLR(Pentanomial{10, 40, 100, 60, 20}), 1.140003360; !near(got, want) {
+		t.Errorf("PentanomialLLR: want %v, got %v", want, got)
+	}
+	if got := s.PentanomialLLR(Pentanomial{}); got != 0 {
+		t.Errorf("PentanomialLLR of nothing: want 0, got %v", got)
+	}
+
+	for _, tc := range []struct {
+		llr  float64
+		want Decision
+	}{
+		{0, Continue},
+		{-3, AcceptH0},
+		{2.95, AcceptH1},
+	} {
+		if got := s.Decide(tc.llr); got != tc.want {
+			t.Errorf("Decide(%v): want %v, got %v", tc.llr, tc.want, got)
+		}
+	}
+}
+
+func TestTally(t *testing.T) {
+	var tally Tally
+	// Pairs finish out of order, as in a concurrent tournament.
+	tally.Add(0, 1)
+	tally.Add(1, 0.5)
+	tally.Add(0, 0.5)
+	tally.Add(2, 0)
+	tally.Add(1, 0.5)
+
+	if want := (Trinomial{Wins: 1, Draws: 3, Losses: 1}); tally.Trinomial != want {
+		t.Errorf("Trinomial: want %+v, got %+v", want, tally.Trinomial)
+	}
+	if want := (Pentanomial{0, 0, 1, 1, 0}); tally.Pentanomial != want {
+		t.Errorf("Pentanomial: want %v, got %v", want, tally.Pentanomial)
+	}
+}
+
+// TestTally_Stops checks that a test between a much stronger engine and a
+// much weaker one ends early with the right decision.
+func TestTally_Stops(t *testing.T) {
+	s := SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+	rng := rand.New(rand.NewSource(1))
+	for _, tc := range []struct {
+		win  float64 // The probability of winning a game; the rest are losses.
+		want Decision
+	}{
+		{0.7, AcceptH1},
+		{0.3, AcceptH0},
+	} {
+		var tally Tally
+		decision := Continue
+		for games := 0; decision == Continue && games < 10000; games++ {
+			score := 0.0
+			if rng.Float64() < tc.win {
+				score = 1
+			}
+			tally.Add(games/2, score)
+			decision = tally.Decide(s)
+		}
+		if decision != tc.want {
+			t.Errorf("win rate %v: want %v, got %v after %+v", tc.win, tc.want, decision, tally.Trinomial)
+		}
+		if n := tally.Trinomial.Games(); n >= 2000 {
+			t.Errorf("win rate %v: took %d games", tc.win, n)
+		}
+	}
+}
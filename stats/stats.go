@@ -0,0 +1,164 @@
+// Package stats computes the statistics engine developers use to compare
+// engines from match results: Elo differences with error bars, the likelihood
+// of superiority (LOS), and sequential probability ratio tests (SPRT).
+//
+// Results are tallied from the point of view of one engine, either per game
+// (Trinomial) or per pair of games played from the same opening with colors
+// reversed (Pentanomial). Pairs are the better choice when openings are
+// unbalanced, since they cancel out the bias of the opening.
+package stats
+
+import "math"
+
+// z95 is the quantile of the standard normal distribution for a two-sided 95%
+// confidence interval.
+const z95 = 1.959963984540054
+
+// Trinomial counts the wins, draws and losses of an engine.
+type Trinomial struct {
+	Wins, Draws, Losses int
+}
+
+// Games returns the number of games.
+func (t Trinomial) Games() int {
+	return t.Wins + t.Draws + t.Losses
+}
+
+// Add tallies a game with the given score: 1 for a win, ½ for a draw and 0
+// for a loss.
+func (t *Trinomial) Add(score float64) {
+	switch score {
+	case 1:
+		t.Wins++
+	case 0.5:
+		t.Draws++
+	case 0:
+		t.Losses++
+	}
+}
+
+// dist returns the scores and their counts.
+func (t Trinomial) dist() ([]float64, []int) {
+	return []float64{0, 0.5, 1}, []int{t.Losses, t.Draws, t.Wins}
+}
+
+// Elo returns the estimated Elo difference.
+func (t Trinomial) Elo() Estimate {
+	return estimate(t.dist())
+}
+
+// LOS returns the likelihood of superiority: the probability that the engine
+// is stronger, given its wins and losses. Draws don't count. It returns 0.5
+// if there are no decisive games.
+func (t Trinomial) LOS() float64 {
+	if t.Wins+t.Losses == 0 {
+		return 0.5
+	}
+	return phi(float64(t.Wins-t.Losses) / math.Sqrt(float64(t.Wins+t.Losses)))
+}
+
+// Pentanomial counts the results of pairs of games. Each element is the
+// number of pairs with a total score of 0, ½, 1, 1½ and 2 points.
+type Pentanomial [5]int
+
+// Pairs returns the number of pairs.
+func (p Pentanomial) Pairs() int {
+	n := 0
+	for _, c := range p {
+		n += c
+	}
+	return n
+}
+
+// Add tallies a pair of games with the given scores, each 1 for a win, ½
+// for a draw and 0 for a loss.
+func (p *Pentanomial) Add(score1, score2 float64) {
+	i := int(math.Round((score1 + score2) * 2))
+	if i >= 0 && i < len(p) {
+		p[i]++
+	}
+}
+
+// dist returns the scores per game of the pairs and their counts.
+func (p Pentanomial) dist() ([]float64, []int) {
+	return []float64{0, 0.25, 0.5, 0.75, 1}, p[:]
+}
+
+// Elo returns the estimated Elo difference.
+func (p Pentanomial) Elo() Estimate {
+	return estimate(p.dist())
+}
+
+// LOS returns the likelihood of superiority: the probability that the engine
+// is stronger. It returns 0.5 if there are no pairs, or if all have the same
+// score.
+func (p Pentanomial) LOS() float64 {
+	n, mean, variance := moments(p.dist())
+	if n == 0 || variance == 0 {
+		return 0.5
+	}
+	return phi((mean - 0.5) / math.Sqrt(variance/n))
+}
+
+// Estimate is an estimated Elo difference with a 95% confidence interval.
+// Perfect and zero scores give infinite values.
+type Estimate struct {
+	Elo          float64
+	Lower, Upper float64
+}
+
+// estimate returns the Elo difference of a score distribution.
+func estimate(scores []float64, counts []int) Estimate {
+	n, mean, variance := moments(scores, counts)
+	if n == 0 {
+		return Estimate{}
+	}
+	margin := z95 * math.Sqrt(variance/n)
+	return Estimate{
+		Elo:   Elo(mean),
+		Lower: Elo(mean - margin),
+		Upper: Elo(mean + margin),
+	}
+}
+
+// moments returns the number of samples of a score distribution, their mean
+// and their variance.
+func moments(scores []float64, counts []int) (n, mean, variance float64) {
+	for i, c := range counts {
+		n += float64(c)
+		mean += float64(c) * scores[i]
+	}
+	if n == 0 {
+		return 0, 0, 0
+	}
+	mean /= n
+	for i, c := range counts {
+		d := scores[i] - mean
+		variance += float64(c) * d * d
+	}
+	return n, mean, variance / n
+}
+
+// Elo returns the Elo difference that gives the expected score, between 0 and
+// 1, in the logistic model.
+func Elo(score float64) float64 {
+	switch {
+	case score <= 0:
+		return math.Inf(-1)
+	case score >= 1:
+		return math.Inf(1)
+	}
+	return -400 * math.Log10(1/score-1)
+}
+
+// Score returns the expected score of an Elo difference in the logistic
+// model.
+func Score(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// phi is the cumulative distribution function of the standard normal
+// distribution.
+func phi(x float64) float64 {
+	return 0.5 * (1 + math.Erf(x/math.Sqrt2))
+}
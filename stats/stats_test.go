@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+// near reports whether a and b are within 1e-6 of each other.
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6 || a == b
+}
+
+func nearEstimate(a, b Estimate) bool {
+	return near(a.Elo, b.Elo) && near(a.Lower, b.Lower) && near(a.Upper, b.Upper)
+}
+
+func TestTrinomial(t *testing.T) {
+	tri := Trinomial{Wins: 100, Draws: 200, Losses: 50}
+	if got := tri.Games(); got != 350 {
+		t.Errorf("Games: want 350, got %d", got)
+	}
+	want := Estimate{Elo: 49.975494643, Lower: 26.430110552, Upper: 73.986520632}
+	if got := tri.Elo(); !nearEstimate(got, want) {
+		t.Errorf("Elo: want %+v, got %+v", want, got)
+	}
+	if got, want := tri.LOS(), 0.999977721; !near(got, want) {
+		t.Errorf("LOS: want %v, got %v", want, got)
+	}
+
+	var added Trinomial
+	for _, s := range []float64{1, 0.5, 0.5, 0, 1} {
+		added.Add(s)
+	}
+	if want := (Trinomial{Wins: 2, Draws: 2, Losses: 1}); added != want {
+		t.Errorf("Add: want %+v, got %+v", want, added)
+	}
+}
+
+func TestTrinomial_Degenerate(t *testing.T) {
+	if got := (Trinomial{}).Elo(); got != (Estimate{}) {
+		t.Errorf("no games: want zero estimate, got %+v", got)
+	}
+	if got := (Trinomial{Draws: 10}).LOS(); got != 0.5 {
+		t.Errorf("only draws: want LOS 0.5, got %v", got)
+	}
+	if got := (Trinomial{Wins: 3}).Elo(); !math.IsInf(got.Elo, 1) {
+		t.Errorf("only wins: want +Inf, got %+v", got)
+	}
+}
+
+func TestPentanomial(t *testing.T) {
+	p := Pentanomial{10, 40, 100, 60, 20}
+	if got := p.Pairs(); got != 230 {
+		t.Errorf("Pairs: want 230, got %d", got)
+	}
+	want := Estimate{Elo: 30.288285575, Lower: 8.606340567, Upper: 52.208445923}
+	if got := p.Elo(); !nearEstimate(got, want) {
+		t.Errorf("Elo: want %+v, got %+v", want, got)
+	}
+	if got, want := p.LOS(), 0.996932479; !near(got, want) {
+		t.Errorf("LOS: want %v, got %v", want, got)
+	}
+
+	var added Pentanomial
+	added.Add(1, 1)
+	added.Add(0.5, 0)
+	added.Add(0, 1)
+	added.Add(0.5, 0.5)
+	if want := (Pentanomial{0, 1, 2, 0, 1}); added != want {
+		t.Errorf("Add: want %v, got %v", want, added)
+	}
+}
+
+func TestElo(t *testing.T) {
+	for _, elo := range []float64{-400, -35.5, 0, 5, 191.3} {
+		if got := Elo(Score(elo)); !near(got, elo) {
+			t.Errorf("Elo(Score(%v)) = %v", elo, got)
+		}
+	}
+	if got := Score(400); !near(got, 10.0/11) {
+		t.Errorf("Score(400): want %v, got %v", 10.0/11, got)
+	}
+	if !math.IsInf(Elo(0), -1) || !math.IsInf(Elo(1), 1) {
+		t.Errorf("Elo(0), Elo(1) = %v, %v; want -Inf, +Inf", Elo(0), Elo(1))
+	}
+}
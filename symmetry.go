@@ -0,0 +1,95 @@
+package chess
+
+import "errors"
+
+// Mirror returns p reflected left to right, so that the a-file becomes the
+// h-file, as the position after 1. e4 becomes the one after 1. d4 with the
+// kings and queens swapped. Castling rights are dropped, since the king and
+// rooks would no longer castle onto the squares the rules require, so the
+// result is always legal but only equivalent to p if p had none. The result
+// has no move history.
+func (p *Position) Mirror() *Position {
+	q := p.transformed(func(sq Square) Square { return sq ^ 7 }, false)
+	q.castling = NoCastling
+	q.castlingRooks = [4]Square{H1, A1, H8, A8}
+	return q
+}
+
+// Flip returns p with the board turned upside down and the colors swapped, so
+// that White's pieces stand where Black's stood and the other side is to move.
+// This is the position from the other player's point of view: the result has
+// the same legal moves, reflected, and the same outcome with the colors
+// swapped, so engines should evaluate both alike. Castling rights, the en
+// passant square and pieces in hand follow their pieces. A vertical flip or a
+// color swap alone wouldn't keep pawns moving forward.
+//
+// Horde positions can't be flipped, since White and Black play by different
+// rules. The result has no move history.
+func (p *Position) Flip() (*Position, error) {
+	if p.variant == Horde {
+		return nil, errors.New("can't flip a Horde position")
+	}
+	q := p.transformed(func(sq Square) Square { return sq ^ 56 }, true)
+	q.sideToMove = p.sideToMove.Other()
+	q.castling = p.castling>>2 | p.castling&(WhiteKingside|WhiteQueenside)<<2
+	for i, sq := range p.castlingRooks {
+		q.castlingRooks[i^2] = sq ^ 56
+	}
+	q.pockets[White], q.pockets[Black] = p.pockets[Black], p.pockets[White]
+	return q, nil
+}
+
+// Symmetries returns the positions equivalent to p by symmetry: its flip, and,
+// if p has no castling rights, its mirror and its flipped mirror. Horde
+// positions have only their mirror. p itself isn't included.
+//
+// Engines should evaluate them all as p, with the sign of the score reversed
+// for flipped positions, which have the other side to move. Test suites can be
+// checked against them too, since their best moves are p's, transformed.
+func (p *Position) Symmetries() []*Position {
+	var res []*Position
+	flipped, err := p.Flip()
+	if err == nil {
+		res = append(res, flipped)
+	}
+	if p.castling == NoCastling {
+		res = append(res, p.Mirror())
+		if err == nil {
+			res = append(res, flipped.Mirror())
+		}
+	}
+	return res
+}
+
+// transformed returns a copy of p without history, with every piece moved by
+// f, and with its color swapped if swap is set. The en passant square and
+// promoted pieces are moved by f too.
+func (p *Position) transformed(f func(Square) Square, swap bool) *Position {
+	q := &Position{
+		sideToMove:     p.sideToMove,
+		castling:       p.castling,
+		castlingRooks:  p.castlingRooks,
+		chess960:       p.chess960,
+		enPassant:      p.enPassant,
+		halfmoveClock:  p.halfmoveClock,
+		fullmoveNumber: p.fullmoveNumber,
+		variant:        p.variant,
+		pockets:        p.pockets,
+	}
+	for sq, pc := range p.board {
+		if pc == NoPiece {
+			continue
+		}
+		if swap {
+			pc = NewPiece(pc.Color().Other(), pc.Type())
+		}
+		q.put(pc, f(Square(sq)))
+	}
+	for _, sq := range p.promoted.Squares() {
+		q.promoted |= bb(f(sq))
+	}
+	if p.enPassant != NoSquare {
+		q.enPassant = f(p.enPassant)
+	}
+	return q
+}
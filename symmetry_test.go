@@ -0,0 +1,84 @@
+package chess
+
+import "testing"
+
+func TestPosition_Mirror(t *testing.T) {
+	cases := []struct{ fen, want string }{
+		{StartingFEN, "rnbkqbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBKQBNR w - - 0 1"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 5", "3k4/8/8/3Pp3/8/8/8/3K4 w - e6 0 5"},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Mirror().String(); got != tc.want {
+			t.Errorf("%s: Mirror() = %s, want %s", tc.fen, got, tc.want)
+		}
+	}
+}
+
+func TestPosition_Flip(t *testing.T) {
+	cases := []struct{ fen, want string }{
+		{StartingFEN, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1"},
+		{"r3k2r/8/8/3pP3/8/8/8/R3K2R w Kq d6 0 1", "r3k2r/8/8/8/3Pp3/8/8/R3K2R b Qk d3 0 1"},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := p.Flip()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := q.String(); got != tc.want {
+			t.Errorf("%s: Flip() = %s, want %s", tc.fen, got, tc.want)
+		}
+		if back, _ := q.Flip(); back.String() != p.String() {
+			t.Errorf("%s: flipped twice = %s", tc.fen, back)
+		}
+	}
+
+	p, err := ParseVariantFEN(Horde, "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Flip(); err == nil {
+		t.Error("Flip() of a Horde position succeeded, want error")
+	}
+}
+
+func TestPosition_Symmetries(t *testing.T) {
+	cases := []struct {
+		variant Variant
+		fen     string
+		want    int // The number of symmetries.
+	}{
+		{Standard, StartingFEN, 1},
+		{Standard, "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 1},
+		{Standard, "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 3},
+		{Standard, "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", 1},
+		{Standard, "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", 3},
+		{Crazyhouse, "r1bqk2r/pppp1ppp/2n5/4p3/1b2P3/2N5/PPPP1PPP/R1BQK2R[Nn] w KQkq - 0 5", 1},
+		{Atomic, "rnbqkb1r/pppppppp/5n2/8/8/5N2/PPPPPPPP/RNBQKB1R w - - 0 1", 3},
+		{Horde, "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1", 0},
+		{Horde, "4k3/8/8/8/8/3PP3/8/8 w - - 0 1", 1},
+	}
+	for _, tc := range cases {
+		p, err := ParseVariantFEN(tc.variant, tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Perft(p, 3)
+		syms := p.Symmetries()
+		if len(syms) != tc.want {
+			t.Errorf("%s: got %d symmetries, want %d", tc.fen, len(syms), tc.want)
+		}
+		for _, q := range syms {
+			if got := Perft(q, 3); got != want {
+				t.Errorf("%s: perft(3) of %s = %d, want %d", tc.fen, q, got, want)
+			}
+		}
+	}
+}
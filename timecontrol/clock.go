@@ -0,0 +1,207 @@
+package timecontrol
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/clfs/chess"
+)
+
+// Clock is a chess clock for both sides. Moves can be recorded with their
+// duration using Punch, or the clock can be run against real time with Start
+// and Stop. Times are passed in explicitly, so that the clock is easy to test
+// and to replay.
+//
+// A Clock marshals to JSON, and the result can be unmarshaled to resume it.
+type Clock struct {
+	Control   Control
+	Remaining [2]time.Duration // Time left for each side, indexed by chess.Color.
+	Moves     [2]int           // Moves made by each side.
+	Flagged   [2]bool          // Whether each side ran out of time.
+
+	// Grace is how far a side may exceed its time before it is flagged, to
+	// allow for communication delays. A side that exceeds its time by less is
+	// left with none.
+	Grace time.Duration
+
+	running bool
+	side    chess.Color // The side whose clock is running.
+	since   time.Time   // When it started.
+}
+
+// NewClock returns a clock at the start of a game.
+func NewClock(c Control) *Clock {
+	clk := &Clock{Control: c}
+	if len(c) > 0 {
+		clk.Remaining = [2]time.Duration{c[0].Time, c[0].Time}
+	}
+	return clk
+}
+
+// Next returns the period in force for side's next move, and the number of
+// moves, including that one, left in the period; see Control.At.
+func (c *Clock) Next(side chess.Color) (p Period, movesToGo int) {
+	p, movesToGo, _ = c.Control.At(c.Moves[side] + 1)
+	return p, movesToGo
+}
+
+// Punch records that side took elapsed for a move. It applies the delay, then
+// adds the increment, and the time of the next period if the move ended one.
+// It reports false if the side ran out of time, beyond the grace, in which case
+// its clock is left at zero and it is flagged.
+func (c *Clock) Punch(side chess.Color, elapsed time.Duration) bool {
+	p, movesToGo := c.Next(side)
+	c.Moves[side]++
+	if c.Remaining[side]+c.Grace < c.cost(p, elapsed) {
+		c.Remaining[side] = 0
+		c.Flagged[side] = true
+		return false
+	}
+	c.Remaining[side] -= p.used(elapsed)
+	if c.Remaining[side] < 0 {
+		c.Remaining[side] = 0
+	}
+	c.Remaining[side] += p.Increment
+	if movesToGo == 1 {
+		next, _, _ := c.Control.At(c.Moves[side] + 1)
+		c.Remaining[side] += next.Time
+	}
+	return true
+}
+
+// cost returns the time that must be on the clock to make a move that takes
+// elapsed: all of it with a Bronstein delay, which is only given back after the
+// move, and the part beyond the delay otherwise.
+func (c *Clock) cost(p Period, elapsed time.Duration) time.Duration {
+	if p.DelayMode == Bronstein {
+		return elapsed
+	}
+	return p.used(elapsed)
+}
+
+// Allowance returns how long side may take for its next move before it is
+// flagged: the time on its clock, plus the grace and any simple delay.
+func (c *Clock) Allowance(side chess.Color) time.Duration {
+	p, _ := c.Next(side)
+	d := c.Remaining[side] + c.Grace
+	if p.DelayMode == SimpleDelay {
+		d += p.Delay
+	}
+	return d
+}
+
+// left returns the time shown on side's clock after thinking for elapsed in
+// period p, never less than zero.
+func (c *Clock) left(side chess.Color, p Period, elapsed time.Duration) time.Duration {
+	if d := c.Remaining[side] - c.cost(p, elapsed); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Start starts side's clock at now, stopping the other side's clock without
+// recording a move.
+func (c *Clock) Start(side chess.Color, now time.Time) {
+	c.running, c.side, c.since = true, side, now
+}
+
+// Running returns the side whose clock is running, if any.
+func (c *Clock) Running() (side chess.Color, ok bool) {
+	return c.side, c.running
+}
+
+// Stop stops the running clock at now and records a move for its side with
+// Punch, then starts the other side's clock. It reports false if the side ran
+// out of time, in which case no clock is left running. Stop does nothing and
+// reports true if no clock is running.
+func (c *Clock) Stop(now time.Time) bool {
+	if !c.running {
+		return true
+	}
+	side := c.side
+	c.running = false
+	if !c.Punch(side, now.Sub(c.since)) {
+		return false
+	}
+	c.Start(side.Other(), now)
+	return true
+}
+
+// Left returns the time shown on side's clock at now.
+func (c *Clock) Left(side chess.Color, now time.Time) time.Duration {
+	if !c.running || c.side != side {
+		return c.Remaining[side]
+	}
+	p, _ := c.Next(side)
+	return c.left(side, p, now.Sub(c.since))
+}
+
+// Expired reports whether the running clock has run out at now, so that
+// stopping it would flag its side.
+func (c *Clock) Expired(now time.Time) bool {
+	if !c.running {
+		return false
+	}
+	p, _ := c.Next(c.side)
+	return c.Remaining[c.side]+c.Grace < c.cost(p, now.Sub(c.since))
+}
+
+// clockJSON is the JSON form of a Clock, with times in milliseconds.
+type clockJSON struct {
+	Control   string     `json:"control"`
+	Remaining [2]int64   `json:"remaining_ms"`
+	Moves     [2]int     `json:"moves"`
+	Flagged   [2]bool    `json:"flagged"`
+	Grace     int64      `json:"grace_ms,omitempty"`
+	Running   string     `json:"running,omitempty"` // "white" or "black".
+	Since     *time.Time `json:"since,omitempty"`
+}
+
+func (c *Clock) MarshalJSON() ([]byte, error) {
+	j := clockJSON{
+		Control:   c.Control.String(),
+		Remaining: [2]int64{c.Remaining[0].Milliseconds(), c.Remaining[1].Milliseconds()},
+		Moves:     c.Moves,
+		Flagged:   c.Flagged,
+		Grace:     c.Grace.Milliseconds(),
+	}
+	if c.running {
+		j.Running = c.side.String()
+		j.Since = &c.since
+	}
+	return json.Marshal(j)
+}
+
+func (c *Clock) UnmarshalJSON(data []byte) error {
+	var j clockJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	control, err := Parse(j.Control)
+	if err != nil {
+		return err
+	}
+	res := Clock{
+		Control:   control,
+		Remaining: [2]time.Duration{time.Duration(j.Remaining[0]) * time.Millisecond, time.Duration(j.Remaining[1]) * time.Millisecond},
+		Moves:     j.Moves,
+		Flagged:   j.Flagged,
+		Grace:     time.Duration(j.Grace) * time.Millisecond,
+	}
+	switch j.Running {
+	case "":
+	case "white", "black":
+		if j.Since == nil {
+			return fmt.Errorf("timecontrol: running clock without a start time")
+		}
+		res.running, res.since = true, *j.Since
+		if j.Running == "black" {
+			res.side = chess.Black
+		}
+	default:
+		return fmt.Errorf("timecontrol: invalid running side %q", j.Running)
+	}
+	*c = res
+	return nil
+}
@@ -0,0 +1,158 @@
+package timecontrol
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClock(t *testing.T) {
+	c := NewClock(MustParse("2/60+1:30"))
+	if c.Remaining != [2]time.Duration{time.Minute, time.Minute} {
+		t.Fatalf("initial clock: %v", c.Remaining)
+	}
+
+	steps := []struct {
+		side    chess.Color
+		elapsed time.Duration
+		ok      bool
+		want    time.Duration
+	}{
+		{chess.White, 10 * time.Second, true, 51 * time.Second},
+		{chess.Black, 5 * time.Second, true, 56 * time.Second},
+		// The second move ends the first period.
+		{chess.White, 20 * time.Second, true, 62 * time.Second},
+		// Sudden death, without increment.
+		{chess.White, 2 * time.Second, true, 60 * time.Second},
+		{chess.Black, 57 * time.Second, false, 0},
+	}
+	for i, s := range steps {
+		ok := c.Punch(s.side, s.elapsed)
+		if ok != s.ok || c.Remaining[s.side] != s.want {
+			t.Errorf("step %d: got %t with %v left, want %t with %v", i, ok, c.Remaining[s.side], s.ok, s.want)
+		}
+	}
+	if c.Moves != [2]int{3, 2} {
+		t.Errorf("moves: got %v", c.Moves)
+	}
+}
+
+func TestClock_Delay(t *testing.T) {
+	cases := []struct {
+		control string
+		elapsed time.Duration
+		ok      bool
+		want    time.Duration
+	}{
+		{"10d5", 3 * time.Second, true, 10 * time.Second},
+		{"10d5", 8 * time.Second, true, 7 * time.Second},
+		{"10b5", 3 * time.Second, true, 10 * time.Second},
+		{"10b5", 8 * time.Second, true, 7 * time.Second},
+		// A simple delay lets a move take longer than the clock shows.
+		{"10d5", 14 * time.Second, true, time.Second},
+		{"10b5", 14 * time.Second, false, 0},
+	}
+	for _, tc := range cases {
+		c := NewClock(MustParse(tc.control))
+		ok := c.Punch(chess.White, tc.elapsed)
+		if ok != tc.ok || c.Remaining[chess.White] != tc.want || c.Flagged[chess.White] == ok {
+			t.Errorf("%s, %v: got %t with %v left, want %t with %v", tc.control, tc.elapsed, ok, c.Remaining[chess.White], tc.ok, tc.want)
+		}
+	}
+}
+
+func TestClock_Grace(t *testing.T) {
+	c := NewClock(MustParse("10+1"))
+	c.Grace = time.Second
+	if got := c.Allowance(chess.White); got != 11*time.Second {
+		t.Errorf("Allowance: want 11s, got %v", got)
+	}
+	// Within the grace, the clock is emptied before the increment.
+	if !c.Punch(chess.White, 10500*time.Millisecond) || c.Remaining[chess.White] != time.Second {
+		t.Errorf("within grace: got %v left, flagged %t", c.Remaining[chess.White], c.Flagged[chess.White])
+	}
+	if c.Punch(chess.Black, 11001*time.Millisecond) || !c.Flagged[chess.Black] {
+		t.Error("beyond grace: want flagged")
+	}
+
+	d := NewClock(MustParse("10d5"))
+	if got := d.Allowance(chess.White); got != 15*time.Second {
+		t.Errorf("simple delay Allowance: want 15s, got %v", got)
+	}
+}
+
+func TestClock_StartStop(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewClock(MustParse("60+2"))
+	if !c.Stop(t0) {
+		t.Error("Stop without a running clock: want true")
+	}
+
+	c.Start(chess.White, t0)
+	if got := c.Left(chess.White, t0.Add(10*time.Second)); got != 50*time.Second {
+		t.Errorf("White's clock while running: want 50s, got %v", got)
+	}
+	if got := c.Left(chess.Black, t0.Add(10*time.Second)); got != time.Minute {
+		t.Errorf("Black's clock while stopped: want 1m, got %v", got)
+	}
+	if !c.Stop(t0.Add(10 * time.Second)) {
+		t.Fatal("Stop: want true")
+	}
+	if side, ok := c.Running(); !ok || side != chess.Black {
+		t.Errorf("want Black's clock running, got %v, %t", side, ok)
+	}
+	if got := c.Remaining[chess.White]; got != 52*time.Second {
+		t.Errorf("White's clock: want 52s, got %v", got)
+	}
+
+	if c.Expired(t0.Add(70 * time.Second)) {
+		t.Error("expired too soon")
+	}
+	late := t0.Add(71 * time.Second)
+	if !c.Expired(late) {
+		t.Error("want expired")
+	}
+	if c.Stop(late) || !c.Flagged[chess.Black] {
+		t.Error("want Black flagged")
+	}
+	if _, ok := c.Running(); ok {
+		t.Error("want no clock running after a flag")
+	}
+}
+
+func TestClock_JSON(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewClock(MustParse("40/5400+30:1800b5"))
+	c.Punch(chess.White, 10*time.Second)
+	c.Start(chess.Black, t0)
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"control":"40/5400+30:1800b5","remaining_ms":[5420000,5400000],"moves":[1,0],"flagged":[false,false],"running":"black","since":"2024-01-01T12:00:00Z"}`
+	if string(data) != want {
+		t.Errorf("want %s, got %s", want, data)
+	}
+
+	var got Clock
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(c, &got, cmp.AllowUnexported(Clock{})); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, s := range []string{
+		`{"control":"x"}`,
+		`{"control":"60","running":"white"}`,
+		`{"control":"60","running":"red","since":"2024-01-01T12:00:00Z"}`,
+	} {
+		if err := json.Unmarshal([]byte(s), &got); err == nil {
+			t.Errorf("Unmarshal(%s): want error", s)
+		}
+	}
+}
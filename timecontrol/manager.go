@@ -0,0 +1,90 @@
+package timecontrol
+
+import (
+	"time"
+
+	"github.com/clfs/chess"
+)
+
+// Manager turns the state of a clock into search limits.
+type Manager struct {
+	// Overhead is the time reserved for each move to cover communication
+	// and process scheduling delays.
+	Overhead time.Duration
+
+	// MinMoveTime is the shortest budget for a move, as long as the clock
+	// allows it.
+	MinMoveTime time.Duration
+
+	// MovesToGo is the number of moves expected before the end of a period
+	// without a move count. Defaults to 30.
+	MovesToGo int
+}
+
+// Limits are the clock fields of a search, as sent to a UCI engine with "go".
+// uci.ClockSearch turns them into a uci.Search.
+type Limits struct {
+	WhiteTime      time.Duration // Time remaining for White.
+	BlackTime      time.Duration // Time remaining for Black.
+	WhiteIncrement time.Duration // Time increment for White.
+	BlackIncrement time.Duration // Time increment for Black.
+	MovesToGo      int           // Moves until the next period. 0 is the rest of the game.
+}
+
+// Limits returns search limits that pass the clock to the engine, which manages
+// its own time. Overhead is subtracted from both sides' remaining time.
+func (m Manager) Limits(c *Clock, side chess.Color) Limits {
+	var l Limits
+	white, mtgWhite := c.Next(chess.White)
+	black, mtgBlack := c.Next(chess.Black)
+	l.WhiteTime = m.available(c.Remaining[chess.White])
+	l.BlackTime = m.available(c.Remaining[chess.Black])
+	l.WhiteIncrement = white.Increment
+	l.BlackIncrement = black.Increment
+	l.MovesToGo = mtgWhite
+	if side == chess.Black {
+		l.MovesToGo = mtgBlack
+	}
+	return l
+}
+
+// MoveTime returns a time budget for side's next move, for use as a fixed move
+// time.
+func (m Manager) MoveTime(c *Clock, side chess.Color) time.Duration {
+	p, movesToGo := c.Next(side)
+	return m.Budget(c.Remaining[side], p.Increment, movesToGo)
+}
+
+// Budget returns a time budget for a move, given the remaining time, the
+// increment and the number of moves until the next period, or 0 if the period
+// lasts for the rest of the game.
+//
+// The remaining time, less the overhead, is shared equally between the moves
+// to go, and most of the increment is added. The budget never exceeds the
+// remaining time less the overhead.
+func (m Manager) Budget(remaining, increment time.Duration, movesToGo int) time.Duration {
+	avail := m.available(remaining)
+	if movesToGo <= 0 {
+		movesToGo = m.MovesToGo
+		if movesToGo <= 0 {
+			movesToGo = 30
+		}
+	}
+	budget := avail/time.Duration(movesToGo) + increment*3/4
+	if budget < m.MinMoveTime {
+		budget = m.MinMoveTime
+	}
+	if budget > avail {
+		budget = avail
+	}
+	return budget
+}
+
+// available returns the remaining time less the overhead, but at least a
+// millisecond, since engines treat a zero clock as infinite.
+func (m Manager) available(remaining time.Duration) time.Duration {
+	if avail := remaining - m.Overhead; avail > time.Millisecond {
+		return avail
+	}
+	return time.Millisecond
+}
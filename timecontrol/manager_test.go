@@ -0,0 +1,62 @@
+package timecontrol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestManager_Limits(t *testing.T) {
+	c := NewClock(MustParse("40/5400+30:1800+30"))
+	c.Punch(chess.White, 10*time.Second)
+	m := Manager{Overhead: 50 * time.Millisecond}
+
+	want := Limits{
+		WhiteTime:      90*time.Minute + 20*time.Second - 50*time.Millisecond,
+		BlackTime:      90*time.Minute - 50*time.Millisecond,
+		WhiteIncrement: 30 * time.Second,
+		BlackIncrement: 30 * time.Second,
+		MovesToGo:      40,
+	}
+	if diff := cmp.Diff(want, m.Limits(c, chess.Black)); diff != "" {
+		t.Errorf("Limits mismatch (-want +got):\n%s", diff)
+	}
+	if got := m.Limits(c, chess.White).MovesToGo; got != 39 {
+		t.Errorf("White's moves to go: want 39, got %d", got)
+	}
+
+	c.Remaining[chess.White] = 10 * time.Millisecond
+	if got := m.Limits(c, chess.White).WhiteTime; got != time.Millisecond {
+		t.Errorf("nearly flagged: want 1ms, got %v", got)
+	}
+}
+
+func TestManager_Budget(t *testing.T) {
+	cases := []struct {
+		m         Manager
+		remaining time.Duration
+		increment time.Duration
+		movesToGo int
+		want      time.Duration
+	}{
+		{Manager{}, 60 * time.Second, 0, 0, 2 * time.Second},
+		{Manager{MovesToGo: 20}, 60 * time.Second, 4 * time.Second, 0, 6 * time.Second},
+		{Manager{Overhead: time.Second}, 11 * time.Second, 0, 10, time.Second},
+		{Manager{MinMoveTime: time.Second}, 10 * time.Second, 0, 0, time.Second},
+		// The budget never exceeds the clock.
+		{Manager{Overhead: 100 * time.Millisecond}, 1100 * time.Millisecond, 10 * time.Second, 1, time.Second},
+		{Manager{MinMoveTime: time.Second}, 500 * time.Millisecond, 0, 0, 500 * time.Millisecond},
+	}
+	for i, tc := range cases {
+		if got := tc.m.Budget(tc.remaining, tc.increment, tc.movesToGo); got != tc.want {
+			t.Errorf("case %d: want %v, got %v", i, tc.want, got)
+		}
+	}
+
+	c := NewClock(MustParse("40/60"))
+	if got := (Manager{}).MoveTime(c, chess.White); got != 1500*time.Millisecond {
+		t.Errorf("MoveTime: want 1.5s, got %v", got)
+	}
+}
@@ -0,0 +1,193 @@
+// Package timecontrol models chess clocks and budgets thinking time.
+//
+// A Control is a sequence of periods, such as "40 moves in 90 minutes, then 30
+// minutes for the rest of the game, with 30 seconds added per move". Periods
+// may have a Fischer increment or a delay. A Clock tracks both sides'
+// remaining time through a game, and a Manager turns the state of the clock
+// into search limits for an engine.
+package timecontrol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is a stage of a time control.
+type Period struct {
+	Moves     int           // Moves to make in the period. 0 means the rest of the game.
+	Time      time.Duration // Time added to the clock at the start of the period.
+	Increment time.Duration // Time added after each move in the period (Fischer increment).
+	Delay     time.Duration // Time each move may take for free, according to DelayMode.
+	DelayMode DelayMode
+}
+
+// DelayMode is the way a delay is applied.
+type DelayMode int
+
+// Delay modes.
+const (
+	// Bronstein gives back the time a move took, up to the delay, after the
+	// move. It never adds more than was used.
+	Bronstein DelayMode = iota
+
+	// SimpleDelay starts the clock only after the delay has passed, as is
+	// common in the United States.
+	SimpleDelay
+)
+
+// used returns the time a move that took elapsed costs in the period, once the
+// delay is applied. Both delay modes cost the same in the end, but a Bronstein
+// delay is only given back after the move.
+func (p Period) used(elapsed time.Duration) time.Duration {
+	if elapsed < p.Delay {
+		return 0
+	}
+	return elapsed - p.Delay
+}
+
+// Control is a time control: periods that follow each other. When the last
+// period has a move count, it repeats, so "40/5400" means 40 moves in 90
+// minutes, again and again.
+type Control []Period
+
+// Parse parses a time control in the format of the PGN TimeControl tag, with
+// periods separated by colons, such as "40/5400+30:1800+30" or "300+3". Times
+// are in seconds unless they have a unit, so "40/90m+30s" is also accepted.
+//
+// As an extension, a period may end with a delay instead of an increment:
+// "d" for a simple delay, as in "5400d5", or "b" for a Bronstein delay, as in
+// "5400b5".
+func Parse(s string) (Control, error) {
+	if s == "" {
+		return nil, fmt.Errorf("timecontrol: empty time control")
+	}
+	var c Control
+	for _, part := range strings.Split(s, ":") {
+		p, err := parsePeriod(part)
+		if err != nil {
+			return nil, fmt.Errorf("timecontrol: invalid time control %q: %w", s, err)
+		}
+		c = append(c, p)
+	}
+	for _, p := range c[:len(c)-1] {
+		if p.Moves == 0 {
+			return nil, fmt.Errorf("timecontrol: invalid time control %q: only the last period can be for the rest of the game", s)
+		}
+	}
+	return c, nil
+}
+
+// MustParse is like Parse, but panics on error.
+func MustParse(s string) Control {
+	c, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// parsePeriod parses a period such as "40/5400+30".
+func parsePeriod(s string) (Period, error) {
+	var p Period
+	rest := s
+	if moves, after, ok := strings.Cut(rest, "/"); ok {
+		n, err := strconv.Atoi(moves)
+		if err != nil || n <= 0 {
+			return Period{}, fmt.Errorf("invalid move count %q", moves)
+		}
+		p.Moves, rest = n, after
+	}
+	t, extra, sep := rest, "", byte(0)
+	if i := strings.IndexAny(rest, "+db"); i >= 0 {
+		t, extra, sep = rest[:i], rest[i+1:], rest[i]
+	}
+	var err error
+	if p.Time, err = parseTime(t); err != nil {
+		return Period{}, err
+	}
+	if p.Time <= 0 {
+		return Period{}, fmt.Errorf("invalid time %q", t)
+	}
+	switch sep {
+	case '+':
+		p.Increment, err = parseTime(extra)
+	case 'd':
+		p.DelayMode = SimpleDelay
+		p.Delay, err = parseTime(extra)
+	case 'b':
+		p.Delay, err = parseTime(extra)
+	}
+	if err != nil {
+		return Period{}, err
+	}
+	return p, nil
+}
+
+// parseTime parses a non-negative time in seconds, or with a unit.
+func parseTime(s string) (time.Duration, error) {
+	var d time.Duration
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		d = time.Duration(f * float64(time.Second))
+	} else if d, err = time.ParseDuration(s); err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return d, nil
+}
+
+// String returns the time control in the format of the PGN TimeControl tag,
+// with times in seconds.
+func (c Control) String() string {
+	parts := make([]string, len(c))
+	for i, p := range c {
+		var b strings.Builder
+		if p.Moves > 0 {
+			fmt.Fprintf(&b, "%d/", p.Moves)
+		}
+		b.WriteString(formatSeconds(p.Time))
+		if p.Increment > 0 {
+			b.WriteString("+" + formatSeconds(p.Increment))
+		}
+		if p.Delay > 0 {
+			mode := "b"
+			if p.DelayMode == SimpleDelay {
+				mode = "d"
+			}
+			b.WriteString(mode + formatSeconds(p.Delay))
+		}
+		parts[i] = b.String()
+	}
+	return strings.Join(parts, ":")
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// At returns the period in force for a side's nth move, counting from 1, and
+// how many moves, including that one, remain in the period. movesToGo is 0 if
+// the period lasts for the rest of the game. start reports whether the move is
+// the first of its period.
+func (c Control) At(n int) (p Period, movesToGo int, start bool) {
+	if len(c) == 0 || n < 1 {
+		return Period{}, 0, false
+	}
+	played := n - 1 // Moves made before this one.
+	for i := 0; ; i++ {
+		if i >= len(c) {
+			i = len(c) - 1 // The last period repeats.
+		}
+		p = c[i]
+		if p.Moves == 0 {
+			return p, 0, played == 0
+		}
+		if played < p.Moves {
+			return p, p.Moves - played, played == 0
+		}
+		played -= p.Moves
+	}
+}
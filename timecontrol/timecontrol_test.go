@@ -0,0 +1,85 @@
+package timecontrol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		s    string
+		want Control
+		str  string
+	}{
+		{"300+3", Control{{Time: 300 * time.Second, Increment: 3 * time.Second}}, "300+3"},
+		{"40/5400+30:1800+30", Control{
+			{Moves: 40, Time: 90 * time.Minute, Increment: 30 * time.Second},
+			{Time: 30 * time.Minute, Increment: 30 * time.Second},
+		}, "40/5400+30:1800+30"},
+		{"40/90m+30s", Control{{Moves: 40, Time: 90 * time.Minute, Increment: 30 * time.Second}}, "40/5400+30"},
+		{"0.5+0.05", Control{{Time: 500 * time.Millisecond, Increment: 50 * time.Millisecond}}, "0.5+0.05"},
+		{"5400d5", Control{{Time: 90 * time.Minute, Delay: 5 * time.Second, DelayMode: SimpleDelay}}, "5400d5"},
+		{"40/90mb10s:30m", Control{
+			{Moves: 40, Time: 90 * time.Minute, Delay: 10 * time.Second, DelayMode: Bronstein},
+			{Time: 30 * time.Minute},
+		}, "40/5400b10:1800"},
+		{"40/7200:20/3600:900", Control{
+			{Moves: 40, Time: 2 * time.Hour},
+			{Moves: 20, Time: time.Hour},
+			{Time: 15 * time.Minute},
+		}, "40/7200:20/3600:900"},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.s)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.s, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("Parse(%q) mismatch (-want +got):\n%s", tc.s, diff)
+		}
+		if s := got.String(); s != tc.str {
+			t.Errorf("Parse(%q).String() = %q, want %q", tc.s, s, tc.str)
+		}
+	}
+
+	for _, s := range []string{"", "0", "x", "40/", "0/60", "60+-1", "-60", "60:40/60", "60+x", "40/60::60", "60d", "60b-1"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q): want error", s)
+		}
+	}
+}
+
+func TestControl_At(t *testing.T) {
+	c := MustParse("40/5400+30:20/3600:900")
+	cases := []struct {
+		n         int
+		period    int
+		movesToGo int
+		start     bool
+	}{
+		{1, 0, 40, true},
+		{40, 0, 1, false},
+		{41, 1, 20, true},
+		{60, 1, 1, false},
+		{61, 2, 0, true},
+		{100, 2, 0, false},
+	}
+	for _, tc := range cases {
+		p, mtg, start := c.At(tc.n)
+		if p != c[tc.period] || mtg != tc.movesToGo || start != tc.start {
+			t.Errorf("At(%d) = %+v, %d, %t; want %+v, %d, %t", tc.n, p, mtg, start, c[tc.period], tc.movesToGo, tc.start)
+		}
+	}
+
+	// The last period repeats when it has a move count.
+	repeating := MustParse("40/3600")
+	if _, mtg, start := repeating.At(81); mtg != 40 || !start {
+		t.Errorf("repeating At(81): got %d moves to go, start %t", mtg, start)
+	}
+	if p, _, _ := (Control{}).At(1); p != (Period{}) {
+		t.Errorf("empty control: got %+v", p)
+	}
+}
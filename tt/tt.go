@@ -0,0 +1,174 @@
+// Package tt provides a transposition table: a fixed-size cache of search
+// results keyed by position hash, such as chess.Position.Hash.
+//
+// Entries are grouped in buckets. When a bucket is full, storing an entry
+// replaces one of the bucket's entries according to the table's Policy. Each
+// search should start with NewSearch, which ages the entries of earlier
+// searches so that they are replaced first.
+package tt
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// BucketSize is the number of entries in a bucket.
+const BucketSize = 4
+
+// shards is the number of locks guarding the buckets.
+const shards = 256
+
+// Policy decides which entry a store replaces.
+type Policy int
+
+// Replacement policies.
+const (
+	// DepthPreferred replaces the entry with the least depth, counting
+	// entries from earlier searches as shallower the older they are.
+	DepthPreferred Policy = iota
+
+	// AlwaysReplace replaces the oldest entry of the bucket, whatever its
+	// depth.
+	AlwaysReplace
+)
+
+// agePenalty is the depth an entry loses for each search since it was stored,
+// under DepthPreferred.
+const agePenalty = 8
+
+// Table is a transposition table holding values of type V. It is safe for
+// concurrent use by multiple goroutines.
+type Table[V any] struct {
+	policy     Policy
+	buckets    []bucket[V]
+	mask       uint64
+	locks      [shards]sync.Mutex
+	generation uint32 // Accessed atomically.
+}
+
+type bucket[V any] [BucketSize]entry[V]
+
+type entry[V any] struct {
+	key        uint64
+	depth      int
+	generation uint32
+	used       bool
+	value      V
+}
+
+// New returns a table with room for at least entries entries, rounded up to a
+// power of two number of buckets.
+func New[V any](entries int, policy Policy) *Table[V] {
+	n := 1
+	for n*BucketSize < entries {
+		n *= 2
+	}
+	return &Table[V]{policy: policy, buckets: make([]bucket[V], n), mask: uint64(n - 1)}
+}
+
+// Len returns the number of entries the table can hold.
+func (t *Table[V]) Len() int {
+	return len(t.buckets) * BucketSize
+}
+
+// lock locks the bucket for key and returns it, and a function that unlocks it.
+func (t *Table[V]) lock(key uint64) (*bucket[V], func()) {
+	return t.lockIndex(int(key & t.mask))
+}
+
+// Probe looks up key, and returns its value and the depth it was stored with.
+func (t *Table[V]) Probe(key uint64) (v V, depth int, ok bool) {
+	b, unlock := t.lock(key)
+	defer unlock()
+	for i := range b {
+		if e := &b[i]; e.used && e.key == key {
+			// Refresh the entry, so that it isn't aged out while in use.
+			e.generation = atomic.LoadUint32(&t.generation)
+			return e.value, e.depth, true
+		}
+	}
+	return v, 0, false
+}
+
+// Store stores v for key, found by a search of the given depth. An entry for
+// the same key is replaced, unless it is from the current search and deeper.
+func (t *Table[V]) Store(key uint64, depth int, v V) {
+	gen := atomic.LoadUint32(&t.generation)
+	b, unlock := t.lock(key)
+	defer unlock()
+
+	victim := -1
+	for i := range b {
+		e := &b[i]
+		if e.used && e.key == key {
+			if e.generation == gen && e.depth > depth && t.policy == DepthPreferred {
+				return
+			}
+			victim = i
+			break
+		}
+		if !e.used {
+			victim = i
+			break
+		}
+		if victim == -1 || t.worth(e, gen) < t.worth(&b[victim], gen) {
+			victim = i
+		}
+	}
+	b[victim] = entry[V]{key: key, depth: depth, generation: gen, used: true, value: v}
+}
+
+// worth returns how much an entry is worth keeping.
+func (t *Table[V]) worth(e *entry[V], gen uint32) int {
+	age := int(gen - e.generation)
+	if t.policy == AlwaysReplace {
+		return -age
+	}
+	return e.depth - agePenalty*age
+}
+
+// NewSearch starts a new search, aging the entries stored so far.
+func (t *Table[V]) NewSearch() {
+	atomic.AddUint32(&t.generation, 1)
+}
+
+// Clear removes all entries.
+func (t *Table[V]) Clear() {
+	for i := range t.locks {
+		t.locks[i].Lock()
+	}
+	for i := range t.buckets {
+		t.buckets[i] = bucket[V]{}
+	}
+	for i := range t.locks {
+		t.locks[i].Unlock()
+	}
+}
+
+// HashFull returns how full the table is with entries of the current search,
+// in parts per thousand, as UCI engines report in "info hashfull". It samples
+// the first thousand entries.
+func (t *Table[V]) HashFull() int {
+	gen := atomic.LoadUint32(&t.generation)
+	n, full := 0, 0
+	for i := range t.buckets {
+		b, unlock := t.lockIndex(i)
+		for _, e := range b {
+			if e.used && e.generation == gen {
+				full++
+			}
+		}
+		unlock()
+		if n += BucketSize; n >= 1000 {
+			break
+		}
+	}
+	return full * 1000 / n
+}
+
+// lockIndex locks the bucket at index i.
+func (t *Table[V]) lockIndex(i int) (*bucket[V], func()) {
+	mu := &t.locks[i%shards]
+	mu.Lock()
+	return &t.buckets[i], mu.Unlock
+}
@@ -0,0 +1,177 @@
+package tt
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	tab := New[string](10, DepthPreferred)
+	if got := tab.Len(); got != 16 {
+		t.Errorf("Len: want 16, got %d", got)
+	}
+	if _, _, ok := tab.Probe(1); ok {
+		t.Error("empty table: found an entry")
+	}
+
+	tab.Store(1, 5, "a")
+	if v, depth, ok := tab.Probe(1); !ok || v != "a" || depth != 5 {
+		t.Errorf("Probe: got %q, %d, %t", v, depth, ok)
+	}
+
+	// A shallower result for the same key doesn't replace a deeper one from
+	// the same search, but one from an earlier search does.
+	tab.Store(1, 3, "b")
+	if v, _, _ := tab.Probe(1); v != "a" {
+		t.Errorf("shallower store replaced the entry: got %q", v)
+	}
+	tab.NewSearch()
+	tab.Store(1, 3, "b")
+	if v, _, _ := tab.Probe(1); v != "b" {
+		t.Errorf("store in a new search didn't replace the entry: got %q", v)
+	}
+
+	tab.Clear()
+	if _, _, ok := tab.Probe(1); ok {
+		t.Error("Clear: entry still present")
+	}
+}
+
+// collide returns n keys that fall in the same bucket of tab.
+func collide[V any](tab *Table[V], n int) []uint64 {
+	keys := make([]uint64, n)
+	for i := range keys {
+		keys[i] = uint64(i) * (tab.mask + 1)
+	}
+	return keys
+}
+
+func TestTable_DepthPreferred(t *testing.T) {
+	tab := New[int](BucketSize, DepthPreferred)
+	keys := collide(tab, BucketSize+1)
+	for i, k := range keys[:BucketSize] {
+		tab.Store(k, 10+i, i)
+	}
+	// The shallowest entry makes room.
+	tab.Store(keys[BucketSize], 1, BucketSize)
+	if _, _, ok := tab.Probe(keys[0]); ok {
+		t.Error("shallowest entry wasn't replaced")
+	}
+	for _, k := range keys[1:] {
+		if _, _, ok := tab.Probe(k); !ok {
+			t.Errorf("key %d was replaced", k)
+		}
+	}
+
+	// Old entries lose their depth advantage.
+	tab.Clear()
+	tab.Store(keys[0], 20, 0)
+	tab.NewSearch()
+	tab.NewSearch()
+	for i, k := range keys[1:BucketSize] {
+		tab.Store(k, 10+i, i)
+	}
+	tab.Store(keys[BucketSize], 1, BucketSize)
+	if _, _, ok := tab.Probe(keys[0]); ok {
+		t.Error("old entry wasn't replaced")
+	}
+}
+
+func TestTable_AlwaysReplace(t *testing.T) {
+	tab := New[int](BucketSize, AlwaysReplace)
+	keys := collide(tab, BucketSize+1)
+	tab.Store(keys[0], 30, 0)
+	tab.NewSearch()
+	for i, k := range keys[1:BucketSize] {
+		tab.Store(k, 1, i)
+	}
+	tab.Store(keys[BucketSize], 1, BucketSize)
+	if _, _, ok := tab.Probe(keys[0]); ok {
+		t.Error("oldest entry wasn't replaced")
+	}
+
+	// Shallower results replace deeper ones for the same key.
+	tab.Store(keys[1], 0, 99)
+	if v, depth, _ := tab.Probe(keys[1]); v != 99 || depth != 0 {
+		t.Errorf("got %d at depth %d", v, depth)
+	}
+}
+
+func TestTable_HashFull(t *testing.T) {
+	tab := New[int](4000, DepthPreferred)
+	if got := tab.HashFull(); got != 0 {
+		t.Errorf("empty table: want 0, got %d", got)
+	}
+	for i := 0; i < tab.Len(); i++ {
+		tab.Store(uint64(i), 1, i)
+	}
+	if got := tab.HashFull(); got != 1000 {
+		t.Errorf("full table: want 1000, got %d", got)
+	}
+	tab.NewSearch()
+	if got := tab.HashFull(); got != 0 {
+		t.Errorf("after NewSearch: want 0, got %d", got)
+	}
+}
+
+func TestTable_Concurrent(t *testing.T) {
+	tab := New[uint64](1<<10, DepthPreferred)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for i := 0; i < 10000; i++ {
+				k := r.Uint64() % 4096
+				if v, _, ok := tab.Probe(k); ok && v != k*2 {
+					t.Errorf("key %d has value %d", k, v)
+					return
+				}
+				tab.Store(k, r.Intn(20), k*2)
+			}
+		}(int64(g))
+	}
+	wg.Wait()
+}
+
+func BenchmarkTable_Store(b *testing.B) {
+	tab := New[int32](1<<20, DepthPreferred)
+	r := rand.New(rand.NewSource(1))
+	keys := make([]uint64, 1<<16)
+	for i := range keys {
+		keys[i] = r.Uint64()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tab.Store(keys[i&(len(keys)-1)], i&15, int32(i))
+	}
+}
+
+func BenchmarkTable_Probe(b *testing.B) {
+	tab := New[int32](1<<20, DepthPreferred)
+	r := rand.New(rand.NewSource(1))
+	keys := make([]uint64, 1<<16)
+	for i := range keys {
+		keys[i] = r.Uint64()
+		tab.Store(keys[i], 1, int32(i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tab.Probe(keys[i&(len(keys)-1)])
+	}
+}
+
+func BenchmarkTable_Parallel(b *testing.B) {
+	tab := New[int32](1<<20, DepthPreferred)
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			k := r.Uint64()
+			if _, _, ok := tab.Probe(k); !ok {
+				tab.Store(k, 1, 0)
+			}
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package uci
+
+import (
+	"sort"
+	"time"
+
+	"github.com/clfs/chess"
+)
+
+// InfoAggregator keeps track of the search information of a single search: the
+// latest line for each MultiPV index, the best line at each depth, and overall
+// statistics. The zero value is ready to use. It isn't safe for concurrent use.
+//
+// Engines report information piecemeal, such as a PV in one line and node
+// counts in another, and may report a shallower iteration after a deeper one
+// when using several threads. The aggregator copes with both.
+type InfoAggregator struct {
+	lines   map[int]Info        // By MultiPV index, counting from 1.
+	depths  map[int]DepthResult // The best line at each depth.
+	summary SearchSummary
+}
+
+// DepthResult is the best line found at a depth.
+type DepthResult struct {
+	Depth    int
+	SelDepth int
+	Score    Score
+	PV       []chess.Move
+	Nodes    int64
+	Time     time.Duration
+}
+
+// SearchSummary summarizes a search.
+type SearchSummary struct {
+	Depth    int           // The deepest depth reported.
+	SelDepth int           // The deepest selective depth reported.
+	Nodes    int64         // The most nodes reported.
+	Time     time.Duration // The most time reported.
+	NPS      int64         // The latest speed reported.
+	TBHits   int64         // The most tablebase hits reported.
+	HashFull int           // The latest hash table fullness reported.
+
+	// Score and PV are those of the best line at the deepest depth, or the
+	// zero Score and nil if there was none.
+	Score Score
+	PV    []chess.Move
+}
+
+// Add records info.
+func (a *InfoAggregator) Add(info Info) {
+	s := &a.summary
+	s.Depth = max(s.Depth, info.Depth)
+	s.SelDepth = max(s.SelDepth, info.SelDepth)
+	s.Nodes = max(s.Nodes, info.Nodes)
+	s.TBHits = max(s.TBHits, info.TBHits)
+	if info.Time > s.Time {
+		s.Time = info.Time
+	}
+	if info.NPS != 0 {
+		s.NPS = info.NPS
+	}
+	if info.HashFull != 0 {
+		s.HashFull = info.HashFull
+	}
+
+	// Lines cut short by a bound are incomplete.
+	if len(info.PV) == 0 || info.Score.LowerBound || info.Score.UpperBound {
+		return
+	}
+	idx := info.MultiPV
+	if idx == 0 {
+		idx = 1
+	}
+	if a.lines == nil {
+		a.lines = make(map[int]Info)
+		a.depths = make(map[int]DepthResult)
+	}
+	if old, ok := a.lines[idx]; !ok || info.Depth >= old.Depth {
+		a.lines[idx] = info
+	}
+	if idx != 1 {
+		return
+	}
+	a.depths[info.Depth] = DepthResult{
+		Depth:    info.Depth,
+		SelDepth: info.SelDepth,
+		Score:    info.Score,
+		PV:       info.PV,
+		Nodes:    info.Nodes,
+		Time:     info.Time,
+	}
+	if info.Depth >= a.deepest() {
+		s.Score, s.PV = info.Score, info.PV
+	}
+}
+
+// deepest returns the deepest depth with a best line.
+func (a *InfoAggregator) deepest() int {
+	deepest := 0
+	for d := range a.depths {
+		deepest = max(deepest, d)
+	}
+	return deepest
+}
+
+// Lines returns the latest line for each MultiPV index, ordered by index.
+func (a *InfoAggregator) Lines() []Info {
+	idxs := make([]int, 0, len(a.lines))
+	for idx := range a.lines {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+	res := make([]Info, len(idxs))
+	for i, idx := range idxs {
+		res[i] = a.lines[idx]
+	}
+	return res
+}
+
+// Depths returns the best line at each depth, shallowest first.
+func (a *InfoAggregator) Depths() []DepthResult {
+	res := make([]DepthResult, 0, len(a.depths))
+	for _, d := range a.depths {
+		res = append(res, d)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Depth < res[j].Depth })
+	return res
+}
+
+// Summary returns a summary of the information recorded so far.
+func (a *InfoAggregator) Summary() SearchSummary {
+	return a.summary
+}
+
+func max[T int | int64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
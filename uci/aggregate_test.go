@@ -0,0 +1,65 @@
+package uci
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInfoAggregator(t *testing.T) {
+	var a InfoAggregator
+	if got := a.Summary(); !cmp.Equal(got, SearchSummary{}) {
+		t.Errorf("zero aggregator: got %+v", got)
+	}
+
+	lines := []string{
+		"info depth 1 seldepth 1 multipv 1 score cp 20 nodes 20 time 1 pv e2e4",
+		"info depth 1 seldepth 1 multipv 2 score cp 10 nodes 40 time 1 pv d2d4",
+		"info depth 2 seldepth 3 multipv 1 score cp 30 nodes 200 time 5 pv e2e4 e7e5",
+		"info depth 2 currmove g1f3 currmovenumber 3",
+		"info depth 3 seldepth 5 multipv 1 score cp 80 lowerbound nodes 500 time 9 pv d2d4",
+		"info depth 2 seldepth 2 multipv 2 score cp 15 nodes 300 time 8 pv d2d4 d7d5",
+		"info nodes 900 nps 100000 hashfull 12 tbhits 3 time 9",
+		// A helper thread reports a shallower depth late.
+		"info depth 1 seldepth 1 multipv 1 score cp 25 nodes 50 time 2 pv g1f3",
+	}
+	for _, l := range lines {
+		info, err := ParseInfo(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.Add(info)
+	}
+
+	want := SearchSummary{
+		Depth:    3,
+		SelDepth: 5,
+		Nodes:    900,
+		Time:     9 * time.Millisecond,
+		NPS:      100000,
+		TBHits:   3,
+		HashFull: 12,
+		Score:    Score{CP: 30},
+		PV:       moves("e2e4", "e7e5"),
+	}
+	if diff := cmp.Diff(want, a.Summary()); diff != "" {
+		t.Errorf("summary mismatch (-want +got):\n%s", diff)
+	}
+
+	var gotLines [][2]any
+	for _, l := range a.Lines() {
+		gotLines = append(gotLines, [2]any{l.MultiPV, l.Depth})
+	}
+	if diff := cmp.Diff([][2]any{{1, 2}, {2, 2}}, gotLines); diff != "" {
+		t.Errorf("lines mismatch (-want +got):\n%s", diff)
+	}
+
+	wantDepths := []DepthResult{
+		{Depth: 1, SelDepth: 1, Score: Score{CP: 25}, PV: moves("g1f3"), Nodes: 50, Time: 2 * time.Millisecond},
+		{Depth: 2, SelDepth: 3, Score: Score{CP: 30}, PV: moves("e2e4", "e7e5"), Nodes: 200, Time: 5 * time.Millisecond},
+	}
+	if diff := cmp.Diff(wantDepths, a.Depths()); diff != "" {
+		t.Errorf("depths mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,258 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Analysis runs an infinite search on a position and keeps track of the latest
+// results, restarting the search whenever the position changes. It is meant for
+// "infinite analysis" panels in GUIs. It is safe for concurrent use by multiple
+// goroutines.
+//
+// While an Analysis is running, it owns the client's search; callers must not
+// call Go on the client.
+type Analysis struct {
+	// NewGame controls when "ucinewgame" is sent before a new position. It
+	// must not be changed while the analysis is running.
+	NewGame NewGamePolicy
+
+	// Duty limits the engine's CPU usage, for analyzing in the background. If
+	// it is between 0 and 1, the engine searches for only that fraction of
+	// each Period and is stopped for the rest, picking up where it left off
+	// through its hash table. Otherwise, the engine searches continuously.
+	// Duty and Period must not be changed while the analysis is running.
+	Duty   float64
+	Period time.Duration // The length of a duty cycle. 0 means one second.
+
+	c *Client
+
+	mu     sync.Mutex         // Serializes Start, Update and Stop.
+	pos    *PositionParams    // The last position analyzed, if any.
+	cancel context.CancelFunc // Stops the analysis, if running.
+	done   chan struct{}      // Closed once the analysis has stopped.
+
+	rmu      sync.Mutex
+	results  map[int]Info  // The latest info with a PV, by MultiPV index.
+	best     *BestMove     // The best move of the last finished search, if any.
+	err      error         // Why the analysis stopped early, if it did.
+	started  time.Time     // When the analysis started.
+	searched time.Duration // Time spent on finished searches.
+	current  time.Time     // When the current search started, if any.
+}
+
+// NewAnalysis returns an Analysis that uses c.
+func NewAnalysis(c *Client) *Analysis {
+	return &Analysis{c: c}
+}
+
+// Start starts analyzing the position described by p, stopping any analysis
+// already in progress and discarding its results.
+func (a *Analysis) Start(p PositionParams) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.start(p)
+}
+
+// Update analyzes the position described by p. If the analysis is already
+// running on that position, it continues undisturbed; otherwise, it is
+// restarted as with Start.
+func (a *Analysis) Update(p PositionParams) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cancel != nil && reflect.DeepEqual(*a.pos, p) {
+		return nil
+	}
+	return a.start(p)
+}
+
+func (a *Analysis) start(p PositionParams) error {
+	a.stop()
+
+	a.rmu.Lock()
+	a.results = make(map[int]Info)
+	a.best, a.err = nil, nil
+	a.started, a.searched = time.Now(), 0
+	a.rmu.Unlock()
+
+	if a.NewGame.needsNewGame(a.pos, p) {
+		if err := a.c.UCINewGame(); err != nil {
+			return err
+		}
+		if err := a.c.IsReady(); err != nil {
+			return err
+		}
+	}
+	if err := a.c.Position(p); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sr, err := a.search(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	done := make(chan struct{})
+	go a.run(ctx, sr, done)
+
+	a.pos = &p
+	a.cancel = cancel
+	a.done = done
+	return nil
+}
+
+// throttled reports whether the analysis runs in duty cycles.
+func (a *Analysis) throttled() bool {
+	return a.Duty > 0 && a.Duty < 1
+}
+
+// period returns the length of a duty cycle.
+func (a *Analysis) period() time.Duration {
+	if a.Period > 0 {
+		return a.Period
+	}
+	return time.Second
+}
+
+// analysisSearch is a single search run by an Analysis.
+type analysisSearch struct {
+	infoCh <-chan Info
+	bestCh <-chan BestMove
+	cancel context.CancelFunc
+	start  time.Time
+}
+
+// search starts an infinite search that stops when ctx is done, or at the end
+// of the on-time of a duty cycle.
+func (a *Analysis) search(ctx context.Context) (*analysisSearch, error) {
+	cancel := context.CancelFunc(func() {})
+	if a.throttled() {
+		on := time.Duration(a.Duty * float64(a.period()))
+		ctx, cancel = context.WithTimeout(ctx, on)
+	}
+	infoCh, bestCh, err := a.c.GoContext(ctx, Search{Infinite: true})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &analysisSearch{infoCh, bestCh, cancel, time.Now()}, nil
+}
+
+// run records the results of sr and any later searches until ctx is done,
+// then closes done.
+func (a *Analysis) run(ctx context.Context, sr *analysisSearch, done chan<- struct{}) {
+	defer close(done)
+	for {
+		a.rmu.Lock()
+		a.current = sr.start
+		a.rmu.Unlock()
+
+		for info := range sr.infoCh {
+			if len(info.PV) == 0 {
+				continue
+			}
+			idx := info.MultiPV
+			if idx == 0 {
+				idx = 1
+			}
+			a.rmu.Lock()
+			a.results[idx] = info
+			a.rmu.Unlock()
+		}
+		bm, ok := <-sr.bestCh
+		sr.cancel()
+
+		a.rmu.Lock()
+		a.searched += time.Since(sr.start)
+		a.current = time.Time{}
+		if ok {
+			a.best = &bm
+		}
+		a.rmu.Unlock()
+
+		if !ok || !a.throttled() {
+			return
+		}
+		off := time.Duration((1 - a.Duty) * float64(a.period()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(off):
+		}
+
+		var err error
+		if sr, err = a.search(ctx); err != nil {
+			a.rmu.Lock()
+			a.err = err
+			a.rmu.Unlock()
+			return
+		}
+	}
+}
+
+// Results returns the latest search information that has a PV for each MultiPV
+// index, ordered by index.
+func (a *Analysis) Results() []Info {
+	a.rmu.Lock()
+	defer a.rmu.Unlock()
+
+	res := make([]Info, 0, len(a.results))
+	for _, info := range a.results {
+		res = append(res, info)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].MultiPV < res[j].MultiPV })
+	return res
+}
+
+// Stop stops the analysis and returns the engine's best move. The results of
+// the analysis remain available.
+func (a *Analysis) Stop() (BestMove, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stop()
+}
+
+func (a *Analysis) stop() (BestMove, error) {
+	if a.cancel == nil {
+		return BestMove{}, errors.New("uci: analysis is not running")
+	}
+	a.cancel()
+	<-a.done
+
+	a.cancel = nil
+	a.done = nil
+
+	a.rmu.Lock()
+	defer a.rmu.Unlock()
+	switch {
+	case a.err != nil:
+		return BestMove{}, a.err
+	case a.best == nil:
+		return BestMove{}, errors.New("uci: engine stopped without a best move")
+	}
+	return *a.best, nil
+}
+
+// MeasuredDuty returns the fraction of time the engine has spent searching
+// since the analysis started, which is about Duty for throttled analyses.
+func (a *Analysis) MeasuredDuty() float64 {
+	a.rmu.Lock()
+	defer a.rmu.Unlock()
+	if a.started.IsZero() {
+		return 0
+	}
+	elapsed := time.Since(a.started)
+	if elapsed <= 0 {
+		return 0
+	}
+	searched := a.searched
+	if !a.current.IsZero() {
+		searched += time.Since(a.current)
+	}
+	return float64(searched) / float64(elapsed)
+}
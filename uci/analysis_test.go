@@ -0,0 +1,147 @@
+package uci
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeEngine returns a client connected to a fake engine. The engine calls
+// respond with every command it receives and writes the result, if any.
+func fakeEngine(t testing.TB, respond func(cmd string) string) *Client {
+	t.Helper()
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	t.Cleanup(func() {
+		engineOut.Close()
+		w.Close()
+	})
+	go func() {
+		s := bufio.NewScanner(engineIn)
+		for s.Scan() {
+			if out := respond(s.Text()); out != "" {
+				io.WriteString(engineOut, out)
+			}
+		}
+	}()
+	return NewClient(r, w)
+}
+
+// analysisEngine pretends to analyze any position with MultiPV 2, and plays
+// the first move of the position command's move list, or e2e4.
+func analysisEngine(cmd string) string {
+	switch {
+	case strings.HasPrefix(cmd, "position"):
+		return ""
+	case cmd == "go infinite":
+		return "info depth 1 multipv 1 score cp 30 pv e2e4 e7e5\n" +
+			"info depth 1 multipv 2 score cp 20 pv d2d4 d7d5\n" +
+			"info depth 2 currmove e2e4 currmovenumber 1\n" +
+			"info depth 2 multipv 1 score cp 25 pv e2e4 c7c5\n"
+	case cmd == "stop":
+		return "bestmove e2e4 ponder c7c5\n"
+	}
+	return ""
+}
+
+// waitFor polls f until it returns true or the test times out.
+func waitFor(t *testing.T, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAnalysis(t *testing.T) {
+	a := NewAnalysis(fakeEngine(t, analysisEngine))
+
+	if err := a.Start(PositionParams{StartPos: true}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitFor(t, func() bool {
+		res := a.Results()
+		return len(res) == 2 && res[0].Depth == 2
+	})
+
+	want := []Info{
+		{Depth: 2, MultiPV: 1, Score: Score{CP: 25}, PV: moves("e2e4", "c7c5")},
+		{Depth: 1, MultiPV: 2, Score: Score{CP: 20}, PV: moves("d2d4", "d7d5")},
+	}
+	if diff := cmp.Diff(want, a.Results()); diff != "" {
+		t.Errorf("results: mismatch (-want +got):\n%s", diff)
+	}
+
+	// Updating with the same position keeps the search running.
+	if err := a.Update(PositionParams{StartPos: true}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := len(a.Results()); got != 2 {
+		t.Errorf("results after no-op update: want 2, got %d", got)
+	}
+
+	// Updating with a new position restarts the search.
+	if err := a.Update(PositionParams{StartPos: true, Moves: moves("e2e4")}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	waitFor(t, func() bool { return len(a.Results()) == 2 })
+
+	bm, err := a.Stop()
+	if err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if want := (BestMove{Move: move("e2e4"), Ponder: move("c7c5")}); want != bm {
+		t.Errorf("best move: want %+v, got %+v", want, bm)
+	}
+	if _, err := a.Stop(); err == nil {
+		t.Error("second Stop: want error")
+	}
+}
+
+func TestAnalysis_Duty(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		gos int
+	)
+	a := NewAnalysis(fakeEngine(t, func(cmd string) string {
+		if cmd == "go infinite" {
+			mu.Lock()
+			gos++
+			mu.Unlock()
+		}
+		return analysisEngine(cmd)
+	}))
+	a.Duty = 0.5
+	a.Period = 20 * time.Millisecond
+
+	if err := a.Start(PositionParams{StartPos: true}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return gos >= 4
+	})
+	if d := a.MeasuredDuty(); d < 0.2 || d > 0.8 {
+		t.Errorf("measured duty: got %.2f, want about 0.5", d)
+	}
+	if len(a.Results()) != 2 {
+		t.Errorf("got %d results, want 2", len(a.Results()))
+	}
+
+	bm, err := a.Stop()
+	if err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if want := (BestMove{Move: move("e2e4"), Ponder: move("c7c5")}); want != bm {
+		t.Errorf("best move: want %+v, got %+v", want, bm)
+	}
+}
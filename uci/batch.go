@@ -0,0 +1,176 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/clfs/chess"
+)
+
+// BatchResult is the result of searching one position of a batch.
+type BatchResult struct {
+	Index    int      // The index of the position in the batch.
+	Info     Info     // The last search information with a PV, not counting secondary lines of MultiPV.
+	BestMove BestMove // The engine's best move.
+}
+
+// BatchStats describes the throughput of a batch search.
+type BatchStats struct {
+	Positions int           // The number of positions searched.
+	Elapsed   time.Duration // The time taken.
+}
+
+// PerSecond returns the number of positions searched per second.
+func (s BatchStats) PerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Positions) / s.Elapsed.Seconds()
+}
+
+// batch is a running SearchBatch.
+type batch struct {
+	positions []string          // The "position" commands.
+	boards    []*chess.Position // The positions, in Chess960 mode.
+	search    string            // The "go" command.
+
+	next    int              // The index of the next position to send.
+	info    Info             // The last search information with a PV.
+	results chan BatchResult // Buffered to hold every result.
+	stopped bool             // Whether to send no more positions.
+	err     error            // Why the batch ended early.
+}
+
+// SearchBatch searches each of positions in turn with the limits in s, and
+// calls fn with the results in order. It is meant for bulk evaluation at a
+// fixed depth or node count, such as running an EPD test suite.
+//
+// SearchBatch is faster than calling Position and Go in a loop. The engine
+// isn't sent "ucinewgame" or "isready" between positions, and the next
+// position and search are sent as soon as the engine reports its best move,
+// without waiting for fn or for the search information to be delivered. Only
+// the last line of search information with a PV is kept for each position.
+//
+// s must end on its own, so it can't be infinite or ponder, and it can't have
+// search moves. If ctx is done, the running search is stopped and SearchBatch
+// returns ctx.Err() without reporting it. SearchBatch returns the number of
+// positions searched and the time taken, whether or not it succeeds.
+func (c *Client) SearchBatch(ctx context.Context, positions []PositionParams, s Search, fn func(BatchResult)) (BatchStats, error) {
+	if s.Infinite || s.Ponder || len(s.SearchMoves) > 0 {
+		return BatchStats{}, errors.New("uci: batch search must end on its own and have no search moves")
+	}
+	if len(positions) == 0 {
+		return BatchStats{}, nil
+	}
+
+	b := &batch{search: s.String(), results: make(chan BatchResult, len(positions))}
+	chess960, variant := c.Chess960(), c.Variant()
+	for _, p := range positions {
+		if !p.StartPos && p.FEN == "" {
+			return BatchStats{}, errors.New("uci: position has neither StartPos nor FEN")
+		}
+		var pos *chess.Position
+		if chess960 {
+			var err error
+			if p, pos, err = chess960Position(variant, p); err != nil {
+				return BatchStats{}, err
+			}
+		}
+		b.positions = append(b.positions, p.String())
+		b.boards = append(b.boards, pos)
+	}
+
+	c.mu.Lock()
+	var err error
+	switch {
+	case c.err != nil:
+		err = c.err
+	case c.search != nil || c.batch != nil:
+		err = errSearchInProgress
+	default:
+		c.batch = b
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return BatchStats{}, err
+	}
+	c.start()
+
+	start := time.Now()
+	c.mu.Lock()
+	c.sendBatch()
+	c.mu.Unlock()
+
+	var stats BatchStats
+	done := ctx.Done()
+	for {
+		select {
+		case r, ok := <-b.results:
+			if !ok {
+				stats.Elapsed = time.Since(start)
+				if err := ctx.Err(); err != nil {
+					return stats, err
+				}
+				return stats, b.err
+			}
+			stats.Positions++
+			fn(r)
+		case <-done:
+			done = nil
+			c.mu.Lock()
+			b.stopped = true
+			c.mu.Unlock()
+			c.Stop()
+		}
+	}
+}
+
+// sendBatch sends the next position of the running batch and starts searching
+// it, or ends the batch if there are no more positions. The caller must hold
+// c.mu.
+func (c *Client) sendBatch() {
+	b := c.batch
+	if b.stopped || b.next == len(b.positions) {
+		c.endBatch(nil)
+		return
+	}
+	if b.boards[b.next] != nil {
+		c.pos = b.boards[b.next]
+	}
+	b.info = Info{}
+	if err := c.send("%s", b.positions[b.next]); err != nil {
+		c.endBatch(err)
+		return
+	}
+	if err := c.send("%s", b.search); err != nil {
+		c.endBatch(err)
+		return
+	}
+	b.next++
+}
+
+// endBatch ends the running batch with err. The caller must hold c.mu.
+func (c *Client) endBatch(err error) {
+	c.batch.err = err
+	close(c.batch.results)
+	c.batch = nil
+}
+
+// batchInfo records search information for the running batch. The caller
+// must hold c.mu.
+func (c *Client) batchInfo(info Info) {
+	if len(info.PV) > 0 && info.MultiPV <= 1 {
+		c.batch.info = info
+	}
+}
+
+// batchBestMove reports the result of the running search of the batch and
+// starts the next one. The caller must hold c.mu.
+func (c *Client) batchBestMove(bm BestMove) {
+	b := c.batch
+	if !b.stopped {
+		b.results <- BatchResult{Index: b.next - 1, Info: b.info, BestMove: bm}
+	}
+	c.sendBatch()
+}
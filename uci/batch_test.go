@@ -0,0 +1,213 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+// batchEngine answers each search with the last move of the position, or
+// e2e4 if there are no moves.
+func batchEngine() func(cmd string) string {
+	last := "e2e4"
+	return func(cmd string) string {
+		switch {
+		case strings.HasPrefix(cmd, "position"):
+			last = "e2e4"
+			if _, after, ok := strings.Cut(cmd, " moves "); ok {
+				f := strings.Fields(after)
+				last = f[len(f)-1]
+			}
+		case strings.HasPrefix(cmd, "go"):
+			return "info depth 1 score cp 10 pv " + last + "\n" +
+				"info depth 2 multipv 2 score cp 5 pv a2a3\n" +
+				"info depth 2 score cp 20 pv " + last + " e7e5\n" +
+				"info depth 2 nodes 100\n" +
+				"bestmove " + last + "\n"
+		}
+		return ""
+	}
+}
+
+func TestClient_SearchBatch(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	c := fakeEngine(t, batchEngine())
+	c.OnSend(func(line string) {
+		mu.Lock()
+		sent = append(sent, line)
+		mu.Unlock()
+	})
+
+	positions := []PositionParams{
+		{StartPos: true},
+		{StartPos: true, Moves: moves("d2d4")},
+		{FEN: "4k3/8/8/8/8/8/8/4K2R w K - 0 1", Moves: moves("h1h2")},
+	}
+	var got []BatchResult
+	stats, err := c.SearchBatch(context.Background(), positions, Search{Depth: 2}, func(r BatchResult) {
+		got = append(got, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Positions != 3 || stats.Elapsed <= 0 || stats.PerSecond() <= 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	var want []BatchResult
+	for i, m := range []string{"e2e4", "d2d4", "h1h2"} {
+		info := Info{Depth: 2, Score: Score{CP: 20}, PV: moves(m, "e7e5")}
+		want = append(want, BatchResult{Index: i, Info: info, BestMove: BestMove{Move: move(m)}})
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("results mismatch (-want +got):\n%s", diff)
+	}
+
+	mu.Lock()
+	wantSent := []string{
+		"position startpos", "go depth 2",
+		"position startpos moves d2d4", "go depth 2",
+		"position fen 4k3/8/8/8/8/8/8/4K2R w K - 0 1 moves h1h2", "go depth 2",
+	}
+	if diff := cmp.Diff(wantSent, sent); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+	mu.Unlock()
+
+	// The client can search normally afterwards.
+	if err := c.PositionStartPos(nil); err != nil {
+		t.Fatal(err)
+	}
+	infoCh, bestCh, err := c.Go(Search{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range infoCh {
+	}
+	if bm := <-bestCh; bm.Move != move("e2e4") {
+		t.Errorf("best move: want e2e4, got %v", bm.Move)
+	}
+}
+
+func TestClient_SearchBatch_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := fakeEngine(t, func(cmd string) string {
+		switch {
+		case strings.HasPrefix(cmd, "go"):
+			cancel()
+		case cmd == "stop":
+			return "bestmove a2a3\n"
+		}
+		return ""
+	})
+
+	positions := []PositionParams{{StartPos: true}, {StartPos: true}}
+	stats, err := c.SearchBatch(ctx, positions, Search{Depth: 30}, func(r BatchResult) {
+		t.Errorf("unexpected result %+v", r)
+	})
+	if err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+	if stats.Positions != 0 {
+		t.Errorf("want 0 positions, got %d", stats.Positions)
+	}
+}
+
+func TestClient_SearchBatch_Disconnect(t *testing.T) {
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+	go func() {
+		s := bufio.NewScanner(engineIn)
+		for s.Scan() {
+			if strings.HasPrefix(s.Text(), "go") {
+				engineOut.Close()
+			}
+		}
+	}()
+
+	c := NewClient(r, w)
+	positions := []PositionParams{{StartPos: true}, {StartPos: true}}
+	stats, err := c.SearchBatch(context.Background(), positions, Search{Depth: 1}, func(BatchResult) {})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("want %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+	if stats.Positions != 0 {
+		t.Errorf("want 0 positions, got %d", stats.Positions)
+	}
+}
+
+func TestClient_SearchBatch_Invalid(t *testing.T) {
+	c := fakeEngine(t, batchEngine())
+	positions := []PositionParams{{StartPos: true}}
+	for _, s := range []Search{{Infinite: true}, {Ponder: true}, {SearchMoves: moves("e2e4")}} {
+		if _, err := c.SearchBatch(context.Background(), positions, s, func(BatchResult) {}); err == nil {
+			t.Errorf("SearchBatch(%q): want error", s)
+		}
+	}
+	if _, err := c.SearchBatch(context.Background(), []PositionParams{{}}, Search{Depth: 1}, func(BatchResult) {}); err == nil {
+		t.Error("SearchBatch with an empty position: want error")
+	}
+}
+
+// benchPositions returns n positions for benchmarks.
+func benchPositions(n int) []PositionParams {
+	positions := make([]PositionParams, n)
+	for i := range positions {
+		positions[i] = PositionParams{StartPos: true, Moves: []chess.Move{move("e2e4")}}
+	}
+	return positions
+}
+
+func BenchmarkSearchBatch(b *testing.B) {
+	c := fakeEngine(b, batchEngine())
+	positions := benchPositions(b.N)
+	b.ResetTimer()
+	stats, err := c.SearchBatch(context.Background(), positions, Search{Depth: 1}, func(BatchResult) {})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportMetric(stats.PerSecond(), "positions/s")
+}
+
+// BenchmarkSearchLoop searches the same positions as BenchmarkSearchBatch with
+// a plain loop, for comparison.
+func BenchmarkSearchLoop(b *testing.B) {
+	engine := batchEngine()
+	c := fakeEngine(b, func(cmd string) string {
+		if cmd == "isready" {
+			return "readyok\n"
+		}
+		return engine(cmd)
+	})
+	for _, p := range benchPositions(b.N) {
+		if err := c.UCINewGame(); err != nil {
+			b.Fatal(err)
+		}
+		if err := c.IsReady(); err != nil {
+			b.Fatal(err)
+		}
+		if err := c.Position(p); err != nil {
+			b.Fatal(err)
+		}
+		infoCh, bestCh, err := c.Go(Search{Depth: 1})
+		if err != nil {
+			b.Fatal(err)
+		}
+		for range infoCh {
+		}
+		if _, ok := <-bestCh; !ok {
+			b.Fatal("no best move")
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package uci
+
+import (
+	"fmt"
+
+	"github.com/clfs/chess"
+)
+
+// SetChess960 sets the engine's "UCI_Chess960" option, which switches it
+// between standard chess and Chess960.
+//
+// In Chess960 mode, engines encode castling as the king capturing its own
+// rook, such as "e1h1" rather than "e1g1". The client translates: moves passed
+// to Position are re-encoded for the engine, and moves in search information
+// and best moves are re-encoded to match the position they were played in, as
+// reported by chess.Position.Chess960. Callers can use either encoding
+// throughout.
+func (c *Client) SetChess960(on bool) error {
+	value := "false"
+	if on {
+		value = "true"
+	}
+	if err := c.SetOption("UCI_Chess960", value); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chess960 = on
+	c.pos = nil
+	return nil
+}
+
+// Chess960 reports whether UCI_Chess960 was turned on with SetChess960.
+func (c *Client) Chess960() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.chess960
+}
+
+// EngineMove returns m, a legal move in pos, with castling encoded as the
+// engine expects after the last SetChess960. Moves from the engine can be
+// converted back with pos.ConvertCastling(m, pos.Chess960()).
+//
+// Client already does this for moves passed to Position and Go, and for the
+// moves the engine reports back.
+func (c *Client) EngineMove(pos *chess.Position, m chess.Move) chess.Move {
+	return pos.ConvertCastling(m, c.Chess960())
+}
+
+// chess960Position replays p under the rules of v and returns it with castling
+// moves re-encoded for a Chess960 engine, along with the resulting position.
+func chess960Position(v chess.Variant, p PositionParams) (PositionParams, *chess.Position, error) {
+	var pos *chess.Position
+	if p.StartPos {
+		pos = chess.VariantStartingPosition(v)
+	} else {
+		var err error
+		if pos, err = chess.ParseVariantFEN(v, p.FEN); err != nil {
+			return p, nil, err
+		}
+	}
+
+	moves := make([]chess.Move, len(p.Moves))
+	for i, m := range p.Moves {
+		if !pos.IsLegal(m) {
+			return p, nil, fmt.Errorf("uci: illegal move %v in position %v", m, pos)
+		}
+		moves[i] = pos.ConvertCastling(m, true)
+		pos.Apply(m)
+	}
+	p.Moves = moves
+	return p, pos, nil
+}
+
+// fromChess960 re-encodes castling moves in a line played from pos to match
+// pos. It stops at the first illegal move, leaving the rest as is.
+func fromChess960(pos *chess.Position, line []chess.Move) {
+	pos = pos.Clone()
+	for i, m := range line {
+		m = pos.ConvertCastling(m, pos.Chess960())
+		if !pos.IsLegal(m) {
+			return
+		}
+		line[i] = m
+		pos.Apply(m)
+	}
+}
+
+// toChess960 returns moves played from c.pos re-encoded for a Chess960
+// engine. The caller must hold c.mu.
+func (c *Client) toChess960(moves []chess.Move) []chess.Move {
+	if c.pos == nil || len(moves) == 0 {
+		return moves
+	}
+	res := make([]chess.Move, len(moves))
+	for i, m := range moves {
+		res[i] = c.pos.ConvertCastling(m, true)
+	}
+	return res
+}
+
+// translateInfo re-encodes the moves in info to match c.pos, if set. The
+// caller must hold c.mu.
+func (c *Client) translateInfo(info *Info) {
+	if c.pos == nil {
+		return
+	}
+	fromChess960(c.pos, info.PV)
+	fromChess960(c.pos, info.Refutation)
+	fromChess960(c.pos, info.CurrLine)
+	if info.CurrMove != (chess.Move{}) {
+		info.CurrMove = c.pos.ConvertCastling(info.CurrMove, c.pos.Chess960())
+	}
+}
+
+// translateBestMove re-encodes the moves in bm to match c.pos, if set. The
+// caller must hold c.mu.
+func (c *Client) translateBestMove(bm *BestMove) {
+	if c.pos == nil || bm.Move == (chess.Move{}) {
+		return
+	}
+	line := []chess.Move{bm.Move}
+	if bm.Ponder != (chess.Move{}) {
+		line = append(line, bm.Ponder)
+	}
+	fromChess960(c.pos, line)
+	bm.Move = line[0]
+	if len(line) > 1 {
+		bm.Ponder = line[1]
+	}
+}
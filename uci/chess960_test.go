@@ -0,0 +1,97 @@
+package uci
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_SetChess960(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	c := fakeEngine(t, func(cmd string) string {
+		mu.Lock()
+		sent = append(sent, cmd)
+		mu.Unlock()
+		switch {
+		case cmd == "isready":
+			return "readyok\n"
+		case strings.HasPrefix(cmd, "go"):
+			// Castle kingside, then expect Black to do the same.
+			return "info depth 1 pv e1h1 e8h8 currmove e1h1\nbestmove e1h1 ponder e8h8\n"
+		}
+		return ""
+	})
+
+	if err := c.SetChess960(true); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Chess960() {
+		t.Error("Chess960() = false after SetChess960(true)")
+	}
+	p := PositionParams{StartPos: true, Moves: moves("g1f3", "g8f6", "g2g3", "g7g6", "f1g2", "f8g7")}
+	if err := c.Position(p); err != nil {
+		t.Fatal(err)
+	}
+	infoCh, bestCh, err := c.Go(Search{Depth: 1, SearchMoves: moves("e1g1", "b1c3")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infos []Info
+	for info := range infoCh {
+		infos = append(infos, info)
+	}
+	bm := <-bestCh
+
+	// The starting position uses standard castling.
+	wantInfos := []Info{{Depth: 1, PV: moves("e1g1", "e8g8"), CurrMove: move("e1g1")}}
+	if diff := cmp.Diff(wantInfos, infos); diff != "" {
+		t.Errorf("info mismatch (-want +got):\n%s", diff)
+	}
+	if want := (BestMove{Move: move("e1g1"), Ponder: move("e8g8")}); bm != want {
+		t.Errorf("best move: want %v, got %v", want, bm)
+	}
+
+	if err := c.Position(PositionParams{StartPos: true, Moves: moves("e2e5")}); err == nil {
+		t.Error("Position with illegal move: want error")
+	}
+
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"setoption name UCI_Chess960 value true",
+		"position startpos moves g1f3 g8f6 g2g3 g7g6 f1g2 f8g7",
+		"go depth 1 searchmoves e1h1 b1c3",
+		"isready",
+	}
+	if len(sent) == len(want) && strings.HasSuffix(sent[2], "searchmoves e1h1 b1c3") {
+		sent[2] = want[2] // Ignore the spacing of the go command.
+	}
+	if diff := cmp.Diff(want, sent); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_SetChess960_FEN(t *testing.T) {
+	const fen = "4k3/8/8/8/8/8/8/5RK1 w F - 0 1"
+	var w syncBuffer
+	c := NewClient(strings.NewReader(""), &w)
+	if err := c.SetChess960(true); err != nil {
+		t.Fatal(err)
+	}
+	// A Chess960 position already uses king-takes-rook castling.
+	if err := c.PositionFEN(fen, moves("g1f1")); err != nil {
+		t.Fatal(err)
+	}
+	want := "setoption name UCI_Chess960 value true\nposition fen " + fen + " moves g1f1\n"
+	if got := w.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
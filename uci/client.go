@@ -1,147 +1,355 @@
 package uci
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/timecontrol"
 )
 
-// Client is a UCI-compatible client.
+// Client is a UCI-compatible client. It is safe for concurrent use by multiple
+// goroutines.
+//
+// A single goroutine reads everything the engine sends and routes each line to
+// the command waiting for it, so commands may be interleaved freely. Only one
+// "uci" command and one search may be in progress at a time.
 type Client struct {
 	r io.Reader
 	w io.Writer
+
+	wmu       sync.Mutex    // Serializes writes to w.
+	startOnce sync.Once     // Starts the reader goroutine.
+	readDone  chan struct{} // Closed when the reader goroutine is done, or won't start.
+	hooks     hooks         // Observers of the raw dialogue.
+
+	// shutdown, if non-nil, stops the engine process for Close.
+	shutdown func(ctx context.Context) error
+
+	mu             sync.Mutex
+	err            error           // Set when the engine stops responding.
+	maxLine        int             // The longest line accepted. 0 means DefaultMaxLineLength.
+	closing        bool            // Set by Close.
+	handshake      *handshake      // The pending "uci" command, if any.
+	ready          []chan error    // Pending "isready" commands, oldest first.
+	search         *search         // The running search, if any.
+	batch          *batch          // The running batch search, if any.
+	capture        *capture        // The running capture, if any.
+	name, author   string          // The engine's identity.
+	options        []Option        // The options advertised by the engine.
+	copyProtection Status          // The last reported copy protection state.
+	registration   Status          // The last reported registration state.
+	registered     []chan error    // Pending "register" commands, oldest first.
+	chess960       bool            // Whether UCI_Chess960 is on.
+	variant        chess.Variant   // The variant set with SetVariant.
+	pos            *chess.Position // The last position sent in Chess960 mode.
+
+	// The state sent to the engine, for Restore.
+	set      []optionValue   // Options set, oldest first, with the latest value of each.
+	newGame  bool            // Whether "ucinewgame" was sent.
+	position *PositionParams // The last position sent, if any.
 }
 
 // NewClient returns a UCI client that reads from r and writes to w.
 func NewClient(r io.Reader, w io.Writer) *Client {
-	return &Client{r: r, w: w}
+	return &Client{r: r, w: w, readDone: make(chan struct{})}
+}
+
+// SetMaxLineLength sets the limit on the length of a line from the engine,
+// including the line terminator, to n bytes; n <= 0 means
+// DefaultMaxLineLength. If the engine sends a longer line, such as an "info"
+// line with a very long principal variation, the client stops with an error
+// wrapping ErrLineTooLong. SetMaxLineLength must be called before the first
+// command.
+func (c *Client) SetMaxLineLength(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxLine = n
+}
+
+// send writes a single command line to the engine.
+func (c *Client) send(format string, a ...any) error {
+	line := fmt.Sprintf(format, a...)
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.hooks.sent(line)
+	_, err := io.WriteString(c.w, line+"\n")
+	return err
 }
 
 // NewClientFromPath runs the engine located at path and returns a client
-// connected to the engine's standard input and output.
+// connected to the engine's standard input and output. Use StartEngine instead
+// to manage the engine process.
 func NewClientFromPath(path string) (*Client, error) {
-	cmd := exec.Command(path)
-	stdin, err := cmd.StdinPipe()
+	e, err := StartEngine(path)
 	if err != nil {
 		return nil, err
 	}
-	stdout, err := cmd.StdoutPipe()
+	return e.Client, nil
+}
+
+// NewClientFromCmd starts cmd and returns a client connected to the engine's
+// standard input and output. See StartEngineCmd for requirements on cmd. Use
+// StartEngineCmd instead to manage the engine process.
+func NewClientFromCmd(cmd *exec.Cmd) (*Client, error) {
+	e, err := StartEngineCmd(cmd)
 	if err != nil {
 		return nil, err
 	}
-	if err := cmd.Start(); err != nil {
-		return nil, err
-	}
-	return NewClient(stdout, stdin), nil
+	return e.Client, nil
 }
 
 // UCI sends a "uci" command. It tells the engine to use the UCI protocol and
 // blocks until the engine confirms.
 func (c *Client) UCI() (name, author string, opts []Option, err error) {
-	fmt.Fprintln(c.w, "uci")
-
-	s := bufio.NewScanner(c.r)
-
-	var uciok bool
-	for s.Scan() && !uciok {
-		line := s.Text()
-		switch {
-		case strings.HasPrefix(line, "id name "):
-			name = strings.TrimPrefix(line, "id name ")
-		case strings.HasPrefix(line, "id author "):
-			author = strings.TrimPrefix(line, "id author ")
-		case strings.HasPrefix(line, "option "):
-			var opt Option
-			if err := opt.UnmarshalText([]byte(line)); err != nil {
-				return "", "", nil, err
-			}
-			opts = append(opts, opt)
-		case line == "uciok":
-			uciok = true
+	return c.UCIContext(context.Background())
+}
+
+// UCIContext is like UCI, but gives up when ctx is done. In that case, the
+// engine is assumed to be unresponsive and is sent a "quit" command, and the
+// error is a *TimeoutError if ctx's deadline passed.
+func (c *Client) UCIContext(ctx context.Context) (name, author string, opts []Option, err error) {
+	h := &handshake{done: make(chan struct{})}
+
+	c.mu.Lock()
+	switch {
+	case c.err != nil:
+		err = c.err
+	case c.handshake != nil:
+		err = errHandshakeInProgress
+	default:
+		c.handshake = h
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return "", "", nil, err
+	}
+	c.start()
+
+	if err := c.send("uci"); err != nil {
+		c.cancelHandshake(h)
+		return "", "", nil, err
+	}
+
+	select {
+	case <-h.done:
+		if h.err != nil {
+			return "", "", nil, h.err
 		}
+		return h.name, h.author, h.opts, nil
+	case <-ctx.Done():
+		c.cancelHandshake(h)
+		c.Quit()
+		return "", "", nil, waitError(ctx, "uci")
 	}
+}
 
-	err = s.Err()
-	return
+func (c *Client) cancelHandshake(h *handshake) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.handshake == h {
+		c.handshake = nil
+	}
+}
+
+// ID returns the name and author the engine reported in response to the last
+// "uci" command, or empty strings if there hasn't been one.
+func (c *Client) ID() (name, author string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.name, c.author
+}
+
+// Options returns the options the engine advertised in response to the last
+// "uci" command, or nil if there hasn't been one.
+func (c *Client) Options() []Option {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Option(nil), c.options...)
 }
 
 // Debug sends a "debug" command. It toggles the engine's debug mode.
-func (c *Client) Debug(on bool) {
+func (c *Client) Debug(on bool) error {
 	if on {
-		fmt.Fprintln(c.w, "debug on")
+		return c.send("debug on")
 	}
-	fmt.Fprintln(c.w, "debug off")
+	return c.send("debug off")
 }
 
 // IsReady sends an "isready" command. It blocks until the engine is ready to
 // accept commands.
 func (c *Client) IsReady() error {
-	fmt.Fprintln(c.w, "isready")
+	return c.IsReadyContext(context.Background())
+}
+
+// IsReadyContext is like IsReady, but gives up when ctx is done. In that case,
+// the engine is assumed to be unresponsive and is sent a "quit" command, and
+// the error is a *TimeoutError if ctx's deadline passed.
+func (c *Client) IsReadyContext(ctx context.Context) error {
+	ch := make(chan error, 1)
 
-	s := bufio.NewScanner(c.r)
-	for s.Scan() {
-		if s.Text() == "readyok" {
-			return nil
+	c.mu.Lock()
+	if err := c.err; err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.ready = append(c.ready, ch)
+	c.mu.Unlock()
+	c.start()
+
+	if err := c.send("isready"); err != nil {
+		c.cancelReady(ch)
+		return err
+	}
+
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		c.cancelReady(ch)
+		c.Quit()
+		return waitError(ctx, "isready")
+	}
+}
+
+func (c *Client) cancelReady(ch chan error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.ready {
+		if c.ready[i] == ch {
+			c.ready = append(c.ready[:i], c.ready[i+1:]...)
+			return
 		}
 	}
-	return s.Err()
 }
 
 // SetOption sends a "setoption" command. It sets an option in the engine's
 // internal parameters. To set a value-less option, use the empty string.
-func (c *Client) SetOption(name, value string) {
+//
+// If the engine has advertised its options in response to a "uci" command, the
+// name and value are validated against them first. Option names are not case
+// sensitive.
+func (c *Client) SetOption(name, value string) error {
+	c.mu.Lock()
+	opts := c.options
+	c.mu.Unlock()
+
+	if opts != nil {
+		opt, ok := lookupOption(opts, name)
+		if !ok {
+			return fmt.Errorf("uci: unknown option %q", name)
+		}
+		if err := opt.Validate(value); err != nil {
+			return err
+		}
+	}
+
+	var err error
 	if value == "" {
-		fmt.Fprintf(c.w, "setoption name %s", name)
+		err = c.send("setoption name %s", name)
 	} else {
-		fmt.Fprintf(c.w, "setoption name %s value %s", name, value)
+		err = c.send("setoption name %s value %s", name, value)
 	}
+	if err == nil {
+		c.mu.Lock()
+		c.recordOption(name, value)
+		c.mu.Unlock()
+	}
+	return err
 }
 
-// Register sends a "register" command. It registers client information with the
-// engine.
-func (c *Client) Register(name, code string) {
-	fmt.Fprintf(c.w, "register name %s code %s\n", name, code)
+// UCINewGame sends a "ucinewgame" command. It indicates that the next search
+// will be from a different game.
+func (c *Client) UCINewGame() error {
+	if err := c.send("ucinewgame"); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.newGame = true
+	c.mu.Unlock()
+	return nil
 }
 
-// RegisterLater sends a "register later" command. It claims that the client
-// will register itself later.
-func (c *Client) RegisterLater() {
-	fmt.Fprintln(c.w, "register later")
+// PositionParams contains parameters for the "position" command.
+type PositionParams struct {
+	StartPos bool         // Start from the standard starting position instead of FEN.
+	FEN      string       // The position to start from, if StartPos is false.
+	Moves    []chess.Move // Moves to play from the starting position.
 }
 
-// UCINewGame sends a "ucinewgame" command. It indicates that the next search
-// will be from a different game.
-func (c *Client) UCINewGame() {
-	fmt.Fprintln(c.w, "ucinewgame")
+func (p PositionParams) String() string {
+	var b strings.Builder
+	if p.StartPos {
+		fmt.Fprintf(&b, "position startpos")
+	} else {
+		fmt.Fprintf(&b, "position fen %s", p.FEN)
+	}
+	if len(p.Moves) > 0 {
+		fmt.Fprintf(&b, " moves %s", joinMoves(p.Moves))
+	}
+	return b.String()
+}
+
+// Position sends a "position" command. It sets up the position described by p
+// on the engine's internal board.
+//
+// After SetChess960(true), the moves must be legal, since castling moves are
+// re-encoded for the engine.
+func (c *Client) Position(p PositionParams) error {
+	if !p.StartPos && p.FEN == "" {
+		return errors.New("uci: position has neither StartPos nor FEN")
+	}
+
+	c.mu.Lock()
+	chess960, variant := c.chess960, c.variant
+	c.mu.Unlock()
+	sent := p
+	if chess960 {
+		var pos *chess.Position
+		var err error
+		if sent, pos, err = chess960Position(variant, p); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.pos = pos
+		c.mu.Unlock()
+	}
+	if err := c.send("%s", sent); err != nil {
+		return err
+	}
+
+	p.Moves = append([]chess.Move(nil), p.Moves...)
+	c.mu.Lock()
+	c.position = &p
+	c.mu.Unlock()
+	return nil
 }
 
 // PositionFEN sends a "position fen" command. It sets the current position
 // based on a FEN string and subsequent moves.
-func (c *Client) PositionFEN(fen string, moves []string) {
-	fmt.Fprintf(c.w, "position fen %s", fen)
-	if len(moves) > 0 {
-		fmt.Fprintf(c.w, " moves %s", strings.Join(moves, " "))
-	}
-	fmt.Fprintf(c.w, "\n")
+func (c *Client) PositionFEN(fen string, moves []chess.Move) error {
+	return c.Position(PositionParams{FEN: fen, Moves: moves})
 }
 
 // PositionStartPos sends a "position startpos" command. It sets the current
 // position based on the standard starting position and subsequent moves.
-func (c *Client) PositionStartPos(moves []string) {
-	fmt.Fprintln(c.w, "position startpos")
-	if len(moves) > 0 {
-		fmt.Fprintf(c.w, " moves %s", strings.Join(moves, " "))
-	}
-	fmt.Fprintf(c.w, "\n")
+func (c *Client) PositionStartPos(moves []chess.Move) error {
+	return c.Position(PositionParams{StartPos: true, Moves: moves})
 }
 
 // Search contains parameters for the "go" command. Note that fields of type
 // time.Duration are truncated to the millisecond.
 type Search struct {
-	SearchMoves []string // Restrict search to these moves, if any.
+	// SearchMoves restricts the search to these moves, if any. Go checks
+	// that they are legal in the last position sent with Position.
+	SearchMoves []chess.Move
 
 	Ponder   bool          // Search in ponder mode.
 	Infinite bool          // Search indefinitely.
@@ -154,8 +362,20 @@ type Search struct {
 	BlackIncrement time.Duration // Time increment for Black. 0 is no increment.
 	MovesToGo      int           // Moves remaining until next time control. 0 is ignored.
 
-	Depth int // Number of plies to search. 0 is ignored.
-	Nodes int // Number of nodes to search. 0 is ignored.
+	Depth int   // Number of plies to search. 0 is ignored.
+	Nodes int64 // Number of nodes to search. 0 is ignored.
+}
+
+// ClockSearch returns a search that passes the clocks in l to the engine,
+// which manages its own time.
+func ClockSearch(l timecontrol.Limits) Search {
+	return Search{
+		WhiteTime:      l.WhiteTime,
+		BlackTime:      l.BlackTime,
+		WhiteIncrement: l.WhiteIncrement,
+		BlackIncrement: l.BlackIncrement,
+		MovesToGo:      l.MovesToGo,
+	}
 }
 
 func (s Search) String() string {
@@ -171,19 +391,19 @@ func (s Search) String() string {
 		fmt.Fprintf(&b, " mate %d", s.Mate)
 	}
 	if s.MoveTime != 0 {
-		fmt.Fprintf(&b, " movetime %d", s.MoveTime)
+		fmt.Fprintf(&b, " movetime %d", s.MoveTime.Milliseconds())
 	}
 	if s.WhiteTime != 0 {
-		fmt.Fprintf(&b, " wtime %d", s.WhiteTime)
+		fmt.Fprintf(&b, " wtime %d", s.WhiteTime.Milliseconds())
 	}
 	if s.BlackTime != 0 {
-		fmt.Fprintf(&b, " btime %d", s.BlackTime)
+		fmt.Fprintf(&b, " btime %d", s.BlackTime.Milliseconds())
 	}
 	if s.WhiteIncrement != 0 {
-		fmt.Fprintf(&b, " winc %d", s.WhiteIncrement)
+		fmt.Fprintf(&b, " winc %d", s.WhiteIncrement.Milliseconds())
 	}
 	if s.BlackIncrement != 0 {
-		fmt.Fprintf(&b, " binc %d", s.BlackIncrement)
+		fmt.Fprintf(&b, " binc %d", s.BlackIncrement.Milliseconds())
 	}
 	if s.MovesToGo != 0 {
 		fmt.Fprintf(&b, " movestogo %d", s.MovesToGo)
@@ -196,7 +416,7 @@ func (s Search) String() string {
 	}
 	// For best compatibility, "searchmoves" is in the final position.
 	if len(s.SearchMoves) > 0 {
-		fmt.Fprintf(&b, "searchmoves %s", strings.Join(s.SearchMoves, " "))
+		fmt.Fprintf(&b, " searchmoves %s", joinMoves(s.SearchMoves))
 	}
 	return b.String()
 }
@@ -223,56 +443,174 @@ type Info struct {
 	Depth          int           // Search depth in plies.
 	SelDepth       int           // Selective search depth in plies.
 	Time           time.Duration // Time spent searching.
-	Nodes          int           // Number of nodes searched.
-	PV             []string      // The best sequence of moves found.
+	Nodes          int64         // Number of nodes searched.
+	PV             []chess.Move  // The best sequence of moves found.
 	MultiPV        int           // MultiPV index. 0 if MultiPV is disabled, otherwise starts at 1.
 	Score          Score         // The score for the move being searched.
-	CurrMove       string        // The move being searched.
+	WDL            WDL           // Win, draw and loss estimates. Zero if not sent.
+	CurrMove       chess.Move    // The move being searched.
 	CurrMoveNumber int           // The index of the move being searched. Starts at 1.
 	HashFull       int           // The hash table fullness in parts-per-thousand.
-	NPS            int           // Number of nodes searched per second.
-	TBHits         int           // Number of positions found in tablebases.
+	NPS            int64         // Number of nodes searched per second.
+	TBHits         int64         // Number of positions found in tablebases.
 	CPULoad        int           // The CPU usage in parts-per-thousand.
 	String         string        // An arbitrary string.
-	Refutation     []string      // A sequence of moves that refutes the first move in the sequence.
-	CurrLine       []string      // The line the engine is currently evaluating.
+	Refutation     []chess.Move  // A sequence of moves that refutes the first move in the sequence.
+	CurrLine       []chess.Move  // The line the engine is currently evaluating.
 }
 
+// BestMove is the engine's final decision after a search.
 type BestMove struct {
-	Move   string // The best move in the current position.
-	Ponder string // The move the engine would like to ponder.
+	Move   chess.Move // The best move in the current position, or the null move if there is none.
+	Ponder chess.Move // The move the engine would like to ponder, or the null move.
 }
 
 // Go sends a "go" command. It starts engine calculations.
-func (c *Client) Go(s Search) (<-chan Info, <-chan BestMove) {
-	fmt.Fprintf(c.w, "%s\n", s)
+//
+// Search information is sent on the first channel as it arrives, and the
+// engine's best move is sent on the second channel when the search finishes.
+// Both channels are closed afterwards. If the engine stops responding, both
+// channels are closed without a best move.
+func (c *Client) Go(s Search) (<-chan Info, <-chan BestMove, error) {
+	return c.GoContext(context.Background(), s)
+}
 
-	infoCh := make(chan Info)
-	bestCh := make(chan BestMove)
+// GoContext is like Go, but sends a "stop" command when ctx is done. The engine
+// then reports its best move as usual. Search information that arrives after
+// ctx is done is discarded.
+func (c *Client) GoContext(ctx context.Context, s Search) (<-chan Info, <-chan BestMove, error) {
+	if err := c.checkSearchMoves(s.SearchMoves); err != nil {
+		return nil, nil, err
+	}
+	sr := newSearch()
+
+	c.mu.Lock()
+	var err error
+	switch {
+	case c.err != nil:
+		err = c.err
+	case c.search != nil || c.batch != nil:
+		err = errSearchInProgress
+	default:
+		c.search = sr
+		s.SearchMoves = c.toChess960(s.SearchMoves)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	c.start()
 
-	scanner := bufio.NewScanner(c.r)
+	if err := c.send("%s", s); err != nil {
+		c.mu.Lock()
+		if c.search == sr {
+			c.search = nil
+		}
+		c.mu.Unlock()
+		return nil, nil, err
+	}
 
-	for scanner.Scan() {
-		if scanner.Text() == "bestmove" {
-			break
+	go sr.Forward(ctx)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Stop()
+		case <-sr.Done:
 		}
+	}()
+
+	return sr.Info, sr.Result, nil
+}
+
+// checkSearchMoves checks that moves are legal in the last position sent, if
+// any.
+func (c *Client) checkSearchMoves(moves []chess.Move) error {
+	c.mu.Lock()
+	p, variant := c.position, c.variant
+	c.mu.Unlock()
+	if p == nil || len(moves) == 0 {
+		return nil
 	}
 
-	return infoCh, bestCh
+	_, pos, err := chess960Position(variant, *p)
+	if err != nil {
+		return err
+	}
+	for _, m := range moves {
+		if !pos.IsLegal(pos.ConvertCastling(m, pos.Chess960())) {
+			return fmt.Errorf("uci: illegal search move %v in position %v", m, pos)
+		}
+	}
+	return nil
 }
 
 // Stop sends the "stop" command. It stops engine calculations.
-func (c *Client) Stop() {
-	fmt.Fprintln(c.w, "stop")
+func (c *Client) Stop() error {
+	return c.send("stop")
 }
 
 // PonderHit sends the "ponderhit" command. It tells the engine that the
 // opponent has played its best move.
-func (c *Client) PonderHit() {
-	fmt.Fprintln(c.w, "ponderhit")
+func (c *Client) PonderHit() error {
+	return c.send("ponderhit")
 }
 
 // Quit sends the "quit" command. It tells the engine to quit.
-func (c *Client) Quit() {
-	fmt.Fprintln(c.w, "quit")
+func (c *Client) Quit() error {
+	return c.send("quit")
+}
+
+// ErrClosed is returned by commands that were in progress, or started, when
+// the client was closed.
+var ErrClosed = errors.New("uci: client closed")
+
+// Close shuts the engine down, and releases the client's resources.
+//
+// Close sends "quit" and waits for the engine to exit until ctx is done. For
+// an engine process started by this package, such as with StartEngine or
+// NewClientFromPath, it then escalates: it sends SIGTERM, and SIGKILL if the
+// process is still running a moment later. Close then closes the pipes to the
+// engine, and waits for the goroutine reading from it to finish. Commands in
+// progress fail with ErrClosed.
+//
+// For a client made with NewClient, Close closes w, and r if ctx is done
+// before the engine's output ends, provided they implement io.Closer. If r
+// can't be closed, Close returns ctx.Err() without waiting any further.
+//
+// Close returns the exit error of the engine process, if any; being
+// terminated or killed does not count. Close may be called more than once.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closing = true
+	c.mu.Unlock()
+	if c.shutdown != nil {
+		return c.shutdown(ctx)
+	}
+
+	c.Quit()
+	if wc, ok := c.w.(io.Closer); ok {
+		wc.Close()
+	}
+	c.stopReader()
+	select {
+	case <-c.readDone:
+		return nil
+	case <-ctx.Done():
+	}
+	rc, ok := c.r.(io.Closer)
+	if !ok {
+		return ctx.Err()
+	}
+	rc.Close()
+	<-c.readDone
+	return nil
+}
+
+// stopReader makes sure that readDone is closed eventually: if the reader
+// goroutine hasn't started, it never will, and commands fail with ErrClosed.
+func (c *Client) stopReader() {
+	c.startOnce.Do(func() {
+		c.fail(ErrClosed)
+		close(c.readDone)
+	})
 }
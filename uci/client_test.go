@@ -1,12 +1,18 @@
 package uci
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/clfs/chess"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
@@ -20,6 +26,24 @@ func readTestdata(t *testing.T, name string) []byte {
 	return data
 }
 
+// move parses a move in UCI notation.
+func move(s string) chess.Move {
+	m, err := chess.ParseMove(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// moves parses moves in UCI notation.
+func moves(s ...string) []chess.Move {
+	res := make([]chess.Move, 0, len(s))
+	for _, m := range s {
+		res = append(res, move(m))
+	}
+	return res
+}
+
 func TestClient_UCI(t *testing.T) {
 	data := readTestdata(t, "uci-response.txt")
 	want := struct {
@@ -30,8 +54,8 @@ func TestClient_UCI(t *testing.T) {
 		"My Chess Engine",
 		"Firstname Lastname",
 		[]Option{
-			{Name: "DoFoo", Type: ButtonOptionType, Default: ""},
-			{Name: "Fruit", Type: ComboOptionType, Default: "apple", Vars: []string{"apple", "banana"}},
+			ButtonOption{Name: "DoFoo"},
+			ComboOption{Name: "Fruit", Default: "apple", Vars: []string{"apple", "banana"}},
 		},
 	}
 	c := NewClient(bytes.NewReader(data), io.Discard)
@@ -49,3 +73,297 @@ func TestClient_UCI(t *testing.T) {
 		t.Errorf("opts: mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestClient_Go(t *testing.T) {
+	data := readTestdata(t, "go-response.txt")
+	wantInfo := []Info{
+		{Depth: 1, SelDepth: 1, MultiPV: 1, Score: Score{CP: 20}, Nodes: 20, NPS: 10000, Time: 2 * time.Millisecond, PV: moves("e2e4")},
+		{Depth: 2, SelDepth: 2, MultiPV: 1, Score: Score{CP: 35, LowerBound: true}, Nodes: 62, NPS: 31000, Time: 2 * time.Millisecond, PV: moves("e2e4", "e7e5")},
+		{String: "NNUE evaluation using nn.nnue enabled"},
+	}
+	wantBest := BestMove{Move: move("e2e4"), Ponder: move("e7e5")}
+
+	c := NewClient(bytes.NewReader(data), io.Discard)
+	infoCh, bestCh, err := c.Go(Search{Depth: 2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var gotInfo []Info
+	for info := range infoCh {
+		gotInfo = append(gotInfo, info)
+	}
+	if diff := cmp.Diff(wantInfo, gotInfo); diff != "" {
+		t.Errorf("info: mismatch (-want +got):\n%s", diff)
+	}
+
+	gotBest, ok := <-bestCh
+	if !ok {
+		t.Fatal("best move channel closed without a value")
+	}
+	if wantBest != gotBest {
+		t.Errorf("best move: want %+v, got %+v", wantBest, gotBest)
+	}
+	if _, ok := <-bestCh; ok {
+		t.Error("best move channel not closed")
+	}
+}
+
+func TestClient_IsReadyContext(t *testing.T) {
+	r, _ := io.Pipe() // The engine never responds.
+	var w syncBuffer
+	c := NewClient(r, &w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := c.IsReadyContext(ctx)
+	var te *TimeoutError
+	if !errors.As(err, &te) || te.Command != "isready" || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err: want a timeout of isready, got %v", err)
+	}
+
+	if want, got := "isready\nquit\n", w.String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// Canceling isn't a timeout.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := c.IsReadyContext(ctx); err != context.Canceled {
+		t.Errorf("err: want %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestClient_GoContext(t *testing.T) {
+	r, engine := io.Pipe()
+	var w syncBuffer
+	c := NewClient(r, &w)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	infoCh, bestCh, err := c.GoContext(ctx, Search{Infinite: true})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cancel()
+
+	go func() {
+		for !strings.HasSuffix(w.String(), "stop\n") {
+			time.Sleep(time.Millisecond)
+		}
+		io.WriteString(engine, "info depth 1 pv e2e4\nbestmove e2e4\n")
+	}()
+
+	for range infoCh {
+	}
+	if want, got := (BestMove{Move: move("e2e4")}), <-bestCh; want != got {
+		t.Errorf("best move: want %+v, got %+v", want, got)
+	}
+	if want, got := "go infinite\nstop\n", w.String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestClient_Commands(t *testing.T) {
+	cases := []struct {
+		f    func(c *Client) error
+		want string
+	}{
+		{func(c *Client) error { return c.Debug(true) }, "debug on\n"},
+		{func(c *Client) error { return c.Debug(false) }, "debug off\n"},
+		{func(c *Client) error { return c.SetOption("Clear Hash", "") }, "setoption name Clear Hash\n"},
+		{func(c *Client) error { return c.SetOption("Hash", "128") }, "setoption name Hash value 128\n"},
+		{func(c *Client) error { return c.UCINewGame() }, "ucinewgame\n"},
+		{func(c *Client) error { return c.PositionStartPos(nil) }, "position startpos\n"},
+		{func(c *Client) error { return c.PositionStartPos(moves("e2e4", "e7e5")) }, "position startpos moves e2e4 e7e5\n"},
+		{func(c *Client) error { return c.PositionFEN("8/8/8/8/8/8/8/K6k w - - 0 1", moves("a1a2")) }, "position fen 8/8/8/8/8/8/8/K6k w - - 0 1 moves a1a2\n"},
+		{func(c *Client) error { return c.Position(PositionParams{StartPos: true, Moves: moves("g1f3")}) }, "position startpos moves g1f3\n"},
+		{func(c *Client) error { return c.Position(PositionParams{FEN: "8/8/8/8/8/8/8/K6k b - - 0 1"}) }, "position fen 8/8/8/8/8/8/8/K6k b - - 0 1\n"},
+		{func(c *Client) error { return c.Stop() }, "stop\n"},
+		{func(c *Client) error { return c.PonderHit() }, "ponderhit\n"},
+		{func(c *Client) error { return c.Quit() }, "quit\n"},
+	}
+	for i, tc := range cases {
+		var w bytes.Buffer
+		c := NewClient(bytes.NewReader(nil), &w)
+		if err := tc.f(c); err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+		}
+		if got := w.String(); tc.want != got {
+			t.Errorf("#%d: want %q, got %q", i, tc.want, got)
+		}
+	}
+}
+
+func TestClient_Interleaved(t *testing.T) {
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	c := NewClient(r, w)
+
+	// The fake engine answers "isready" in the middle of a search.
+	go func() {
+		s := bufio.NewScanner(engineIn)
+		for s.Scan() {
+			switch s.Text() {
+			case "go infinite":
+				io.WriteString(engineOut, "info depth 1 pv e2e4\n")
+			case "isready":
+				io.WriteString(engineOut, "readyok\ninfo depth 2 pv d2d4\nbestmove d2d4\n")
+			}
+		}
+	}()
+
+	infoCh, bestCh, err := c.Go(Search{Infinite: true})
+	if err != nil {
+		t.Fatalf("Go: %v", err)
+	}
+	if _, _, err := c.Go(Search{Depth: 1}); err == nil {
+		t.Error("second Go: want error")
+	}
+	if err := c.IsReady(); err != nil {
+		t.Errorf("IsReady: %v", err)
+	}
+
+	var depths []int
+	for info := range infoCh {
+		depths = append(depths, info.Depth)
+	}
+	if diff := cmp.Diff([]int{1, 2}, depths); diff != "" {
+		t.Errorf("depths: mismatch (-want +got):\n%s", diff)
+	}
+	if want, got := (BestMove{Move: move("d2d4")}), <-bestCh; want != got {
+		t.Errorf("best move: want %+v, got %+v", want, got)
+	}
+}
+
+func TestClient_EngineGone(t *testing.T) {
+	c := NewClient(strings.NewReader("copyprotection ok\n"), io.Discard)
+	err := c.IsReady()
+	var te *EngineTerminatedError
+	if !errors.As(err, &te) || te.Err != io.ErrUnexpectedEOF {
+		t.Errorf("IsReady: want the engine terminated by %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+	if _, _, err2 := c.Go(Search{Depth: 1}); err2 != err {
+		t.Errorf("Go: want %v, got %v", err, err2)
+	}
+	if want, got := StatusOK, c.CopyProtection(); want != got {
+		t.Errorf("CopyProtection: want %q, got %q", want, got)
+	}
+}
+
+func TestClient_SetOption_Validate(t *testing.T) {
+	data := readTestdata(t, "uci-response.txt")
+	var w syncBuffer
+	c := NewClient(bytes.NewReader(data), &w)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatalf("UCI: %v", err)
+	}
+
+	if err := c.SetOption("fruit", "banana"); err != nil {
+		t.Errorf("SetOption: %v", err)
+	}
+	if err := c.SetOption("Fruit", "cherry"); err == nil {
+		t.Error("SetOption with invalid value: want error")
+	}
+	if err := c.SetOption("Vegetable", "carrot"); err == nil {
+		t.Error("SetOption with unknown name: want error")
+	}
+	if want, got := "uci\nsetoption name fruit value banana\n", w.String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestClient_Position_Empty(t *testing.T) {
+	var w bytes.Buffer
+	c := NewClient(bytes.NewReader(nil), &w)
+	if err := c.Position(PositionParams{Moves: moves("e2e4")}); err == nil {
+		t.Error("want error")
+	}
+	if w.Len() != 0 {
+		t.Errorf("wrote %q", w.String())
+	}
+}
+
+func TestClient_LongLines(t *testing.T) {
+	// 20000 moves of PV make a line of about 100 KB, more than the default
+	// buffer of bufio.Scanner.
+	pv := strings.Repeat(" g1f3 g8f6 f3g1 f6g8", 5000)
+	data := "info depth 30 pv" + pv + "\nbestmove g1f3\n"
+
+	c := NewClient(strings.NewReader(data), io.Discard)
+	infoCh, bestCh, err := c.Go(Search{Depth: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Info
+	for info := range infoCh {
+		got = append(got, info)
+	}
+	if len(got) != 1 || len(got[0].PV) != 20000 {
+		t.Errorf("got %d infos, want 1 with a PV of 20000 moves", len(got))
+	}
+	if _, ok := <-bestCh; !ok {
+		t.Error("no best move")
+	}
+
+	c = NewClient(strings.NewReader(data), io.Discard)
+	c.SetMaxLineLength(1000)
+	infoCh, bestCh, err = c.Go(Search{Depth: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range infoCh {
+	}
+	if _, ok := <-bestCh; ok {
+		t.Error("got a best move after a line that's too long")
+	}
+	if err := c.IsReady(); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("IsReady: want %v, got %v", ErrLineTooLong, err)
+	}
+}
+
+func TestSearch_String(t *testing.T) {
+	s := Search{
+		SearchMoves:    moves("e2e4", "d2d4"),
+		MoveTime:       1500 * time.Millisecond,
+		WhiteTime:      time.Minute,
+		BlackTime:      59999 * time.Microsecond,
+		WhiteIncrement: time.Second,
+		BlackIncrement: 2 * time.Second,
+		MovesToGo:      20,
+	}
+	want := "go movetime 1500 wtime 60000 btime 59 winc 1000 binc 2000 movestogo 20 searchmoves e2e4 d2d4"
+	if got := s.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// The command parses back, up to the truncated milliseconds.
+	got, err := ParseSearch(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.BlackTime = 59 * time.Millisecond
+	if diff := cmp.Diff(s, got); diff != "" {
+		t.Errorf("ParseSearch mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Go_SearchMoves(t *testing.T) {
+	var w syncBuffer
+	c := NewClient(strings.NewReader("bestmove g1f3\n"), &w)
+	if err := c.PositionStartPos(moves("e2e4", "e7e5")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Go(Search{SearchMoves: moves("g1f3", "e4e5")}); err == nil {
+		t.Error("illegal search move: want error")
+	}
+	_, bestCh, err := c.Go(Search{Depth: 5, SearchMoves: moves("g1f3", "d2d4")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-bestCh
+	want := "position startpos moves e2e4 e7e5\ngo depth 5 searchmoves g1f3 d2d4\n"
+	if got := w.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,173 @@
+package uci
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clfs/chess"
+)
+
+// ParsePosition parses a "position" command, such as "position startpos moves
+// e2e4". It doesn't check that the FEN is valid or the moves are legal.
+func ParsePosition(line string) (PositionParams, error) {
+	var p PositionParams
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "position" {
+		return p, &ParseError{Line: line, Reason: "not a position command"}
+	}
+
+	if len(fields) < 2 {
+		return p, &ParseError{Line: line, Reason: "missing position"}
+	}
+
+	pos := 2
+	switch fields[1] {
+	case "startpos":
+		p.StartPos = true
+	case "fen":
+		for pos < len(fields) && fields[pos] != "moves" {
+			pos++
+		}
+		p.FEN = strings.Join(fields[2:pos], " ")
+		if p.FEN == "" {
+			return p, &ParseError{Line: line, Field: "fen", Reason: "missing value"}
+		}
+	default:
+		return p, &ParseError{Line: line, Field: fields[1], Reason: "unknown position"}
+	}
+
+	if pos < len(fields) {
+		if fields[pos] != "moves" {
+			return p, &ParseError{Line: line, Field: fields[pos], Reason: "unexpected field"}
+		}
+		for _, f := range fields[pos+1:] {
+			m, err := chess.ParseMove(f)
+			if err != nil {
+				return p, &ParseError{Line: line, Field: "moves", Reason: "invalid move", Err: err}
+			}
+			p.Moves = append(p.Moves, m)
+		}
+	}
+	return p, nil
+}
+
+// ParseSearch parses a "go" command, such as "go wtime 60000 btime 60000".
+// Times are in milliseconds, and unknown tokens are skipped.
+func ParseSearch(line string) (Search, error) {
+	var s Search
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "go" {
+		return s, &ParseError{Line: line, Reason: "not a go command"}
+	}
+
+	// integer64 parses fields[pos] as an integer of the given bit size, with
+	// 0 meaning int.
+	integer64 := func(pos, bitSize int) (int64, error) {
+		if pos >= len(fields) {
+			return 0, &ParseError{Line: line, Field: fields[pos-1], Reason: "missing value"}
+		}
+		n, err := strconv.ParseInt(fields[pos], 10, bitSize)
+		if err != nil {
+			return 0, &ParseError{Line: line, Field: fields[pos-1], Reason: "invalid value", Err: err}
+		}
+		return n, nil
+	}
+	integer := func(pos int) (int, error) {
+		n, err := integer64(pos, 0)
+		return int(n), err
+	}
+	millis := func(pos int) (time.Duration, error) {
+		return parseMillis(line, fields, pos)
+	}
+
+	for pos := 1; pos < len(fields); {
+		key := fields[pos]
+		pos++
+
+		var err error
+		switch key {
+		case "ponder":
+			s.Ponder = true
+			continue
+		case "infinite":
+			s.Infinite = true
+			continue
+		case "searchmoves":
+			for ; pos < len(fields) && !goKeywords[fields[pos]]; pos++ {
+				m, err := chess.ParseMove(fields[pos])
+				if err != nil {
+					return s, &ParseError{Line: line, Field: "searchmoves", Reason: "invalid move", Err: err}
+				}
+				s.SearchMoves = append(s.SearchMoves, m)
+			}
+			continue
+		case "mate":
+			s.Mate, err = integer(pos)
+		case "movetime":
+			s.MoveTime, err = millis(pos)
+		case "wtime":
+			s.WhiteTime, err = millis(pos)
+		case "btime":
+			s.BlackTime, err = millis(pos)
+		case "winc":
+			s.WhiteIncrement, err = millis(pos)
+		case "binc":
+			s.BlackIncrement, err = millis(pos)
+		case "movestogo":
+			s.MovesToGo, err = integer(pos)
+		case "depth":
+			s.Depth, err = integer(pos)
+		case "nodes":
+			s.Nodes, err = integer64(pos, 64)
+		default:
+			// Skip unknown tokens.
+			continue
+		}
+		if err != nil {
+			return s, err
+		}
+		pos++
+	}
+	return s, nil
+}
+
+// goKeywords are the tokens that start a new field in a "go" command.
+var goKeywords = map[string]bool{
+	"searchmoves": true,
+	"ponder":      true,
+	"wtime":       true,
+	"btime":       true,
+	"winc":        true,
+	"binc":        true,
+	"movestogo":   true,
+	"depth":       true,
+	"nodes":       true,
+	"mate":        true,
+	"movetime":    true,
+	"infinite":    true,
+}
+
+// ParseSetOption parses a "setoption" command, such as "setoption name Hash
+// value 64". The value is empty for button options, and for "<empty>".
+func ParseSetOption(line string) (name, value string, err error) {
+	if f := strings.Fields(line); len(f) == 0 || f[0] != "setoption" {
+		return "", "", &ParseError{Line: line, Reason: "not a setoption command"}
+	}
+	_, rest, ok := strings.Cut(line, " name ")
+	if !ok {
+		return "", "", &ParseError{Line: line, Field: "name", Reason: "missing value"}
+	}
+	name, value, _ = strings.Cut(rest, " value ")
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+	if value == "<empty>" {
+		value = ""
+	}
+	if name == "" {
+		return "", "", &ParseError{Line: line, Field: "name", Reason: "missing value"}
+	}
+	return name, value, nil
+}
@@ -0,0 +1,120 @@
+package uci
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSearch(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Search
+	}{
+		{"go", Search{}},
+		{"go infinite", Search{Infinite: true}},
+		{
+			"go wtime 300000 btime 290000 winc 2000 binc 2000 movestogo 40",
+			Search{
+				WhiteTime:      300 * time.Second,
+				BlackTime:      290 * time.Second,
+				WhiteIncrement: 2 * time.Second,
+				BlackIncrement: 2 * time.Second,
+				MovesToGo:      40,
+			},
+		},
+		{
+			"go searchmoves e2e4 d2d4 depth 10 ponder",
+			Search{SearchMoves: moves("e2e4", "d2d4"), Depth: 10, Ponder: true},
+		},
+		{"go movetime 1500 nodes 1000 mate 3", Search{MoveTime: 1500 * time.Millisecond, Nodes: 1000, Mate: 3}},
+		{"go nodes 9223372036854775807 wtime 9223372036854", Search{Nodes: math.MaxInt64, WhiteTime: 9223372036854 * time.Millisecond}},
+	}
+	for _, tc := range cases {
+		got, err := ParseSearch(tc.in)
+		if err != nil {
+			t.Errorf("ParseSearch(%q): %v", tc.in, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("ParseSearch(%q) mismatch (-want +got):\n%s", tc.in, diff)
+		}
+	}
+}
+
+func TestParseSearch_Error(t *testing.T) {
+	for _, in := range []string{
+		"go depth",
+		"go nodes 9223372036854775808",
+		"go movetime 9223372036855",
+		"go wtime -9223372036855",
+	} {
+		if _, err := ParseSearch(in); err == nil {
+			t.Errorf("ParseSearch(%q): want error", in)
+		}
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	const fen = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+	cases := []struct {
+		in   string
+		want PositionParams
+	}{
+		{"position startpos", PositionParams{StartPos: true}},
+		{"position startpos moves e2e4 e7e5", PositionParams{StartPos: true, Moves: moves("e2e4", "e7e5")}},
+		{"position fen " + fen, PositionParams{FEN: fen}},
+		{"position fen " + fen + " moves e7e5", PositionParams{FEN: fen, Moves: moves("e7e5")}},
+	}
+	for _, tc := range cases {
+		got, err := ParsePosition(tc.in)
+		if err != nil {
+			t.Errorf("ParsePosition(%q): %v", tc.in, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("ParsePosition(%q) mismatch (-want +got):\n%s", tc.in, diff)
+		}
+	}
+}
+
+func TestParseSetOption(t *testing.T) {
+	cases := []struct {
+		in          string
+		name, value string
+	}{
+		{"setoption name Hash value 64", "Hash", "64"},
+		{"setoption name Clear Hash", "Clear Hash", ""},
+		{"setoption name Skill Level value 10", "Skill Level", "10"},
+		{"setoption name UCI_Opponent value none 2800 computer Some Engine", "UCI_Opponent", "none 2800 computer Some Engine"},
+		{"setoption name SyzygyPath value <empty>", "SyzygyPath", ""},
+	}
+	for _, tc := range cases {
+		name, value, err := ParseSetOption(tc.in)
+		if err != nil {
+			t.Errorf("ParseSetOption(%q): %v", tc.in, err)
+			continue
+		}
+		if name != tc.name || value != tc.value {
+			t.Errorf("ParseSetOption(%q): want %q, %q, got %q, %q", tc.in, tc.name, tc.value, name, value)
+		}
+	}
+
+	for _, in := range []string{"", "setoption", "setoption value 1", "go name x"} {
+		if _, _, err := ParseSetOption(in); err == nil {
+			t.Errorf("ParseSetOption(%q): want error", in)
+		}
+	}
+	for _, in := range []string{"", "stop", "position", "position somewhere"} {
+		if _, err := ParsePosition(in); err == nil {
+			t.Errorf("ParsePosition(%q): want error", in)
+		}
+	}
+	for _, in := range []string{"", "stop", "go depth", "go depth x"} {
+		if _, err := ParseSearch(in); err == nil {
+			t.Errorf("ParseSearch(%q): want error", in)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package uci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EngineInfo describes an executable examined by Discover. If it isn't a UCI
+// engine, or didn't complete the handshake in time, Error says why and only
+// the fields describing the file are set.
+type EngineInfo struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Error   string    `json:"error,omitempty"`
+
+	// The engine's response to "uci".
+	Name    string   `json:"name,omitempty"`
+	Author  string   `json:"author,omitempty"`
+	Options []Option `json:"options,omitempty"`
+
+	// Capabilities derived from the options.
+	Chess960 bool     `json:"chess960,omitempty"` // It has a "UCI_Chess960" option.
+	MultiPV  int      `json:"multipv,omitempty"`  // The most lines it can search at once, 1 without a "MultiPV" option.
+	Variants []string `json:"variants,omitempty"` // The choices of its "UCI_Variant" option, if any.
+}
+
+// UnmarshalJSON decodes an EngineInfo, using UnmarshalOption for the options.
+func (info *EngineInfo) UnmarshalJSON(data []byte) error {
+	type plain EngineInfo
+	var j struct {
+		plain
+		Options []json.RawMessage `json:"options,omitempty"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	v := EngineInfo(j.plain)
+	v.Options = nil
+	for _, data := range j.Options {
+		o, err := UnmarshalOption(data)
+		if err != nil {
+			return err
+		}
+		v.Options = append(v.Options, o)
+	}
+	*info = v
+	return nil
+}
+
+// DefaultEngineNames are the patterns Discover matches against the names of
+// files in PATH, when DiscoverConfig.Names is nil.
+var DefaultEngineNames = []string{
+	"stockfish*", "fairy-stockfish*", "lc0*", "komodo*", "dragon*", "berserk*",
+	"ethereal*", "rubichess*", "koivisto*", "igel*", "weiss*", "arasan*",
+	"laser*", "texel*", "halogen*", "seer*", "caissa*", "obsidian*",
+}
+
+// DiscoverConfig controls Discover.
+type DiscoverConfig struct {
+	// Dirs are directories to scan. Every executable in them is examined.
+	Dirs []string
+
+	// PATH adds the directories of the PATH environment variable. Since PATH
+	// holds many programs that aren't engines, and running them with "uci"
+	// on their input may do anything, only the files whose names match one
+	// of the patterns in Names are examined there.
+	PATH  bool
+	Names []string // Patterns as in filepath.Match. If nil, DefaultEngineNames.
+
+	// Timeout limits the handshake with each executable. If zero, it is five
+	// seconds.
+	Timeout time.Duration
+
+	// Cache is the path of a registry file, as written by SaveRegistry. If it
+	// is not empty, executables whose size and modification time match an
+	// entry of the file aren't run again, and the file is updated afterwards.
+	Cache string
+}
+
+// Discover scans the directories in cfg for engines. It runs each executable
+// it finds, performs the "uci" handshake with a timeout, and records what the
+// engine reported. The executables run in an empty temporary directory, with
+// their standard error discarded, and are killed if they don't quit after the
+// handshake. This limits the harm of running programs that aren't engines, but
+// is no security boundary: only scan directories whose programs are trusted.
+//
+// Discover returns an entry for every executable, sorted by path; entries with
+// an empty Error are engines. It fails only if ctx is done or the cache can't
+// be read or written.
+func Discover(ctx context.Context, cfg DiscoverConfig) ([]EngineInfo, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.Names == nil {
+		cfg.Names = DefaultEngineNames
+	}
+
+	cached := make(map[string]EngineInfo)
+	if cfg.Cache != "" {
+		entries, err := LoadRegistry(cfg.Cache)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, e := range entries {
+			cached[e.Path] = e
+		}
+	}
+
+	work, err := os.MkdirTemp("", "uci-discover")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(work)
+
+	var found []EngineInfo
+	for _, f := range candidates(cfg) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info := EngineInfo{Path: f.path, Size: f.Size(), ModTime: f.ModTime()}
+		if c, ok := cached[f.path]; ok && c.Size == info.Size && c.ModTime.Equal(info.ModTime) {
+			found = append(found, c)
+			continue
+		}
+		if err := probe(ctx, &info, work, cfg.Timeout); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			info.Error = err.Error()
+		}
+		found = append(found, info)
+	}
+
+	if cfg.Cache != "" {
+		if err := SaveRegistry(cfg.Cache, found); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// candidate is an executable for Discover to examine.
+type candidate struct {
+	path string
+	fs.FileInfo
+}
+
+// candidates lists the executables to examine, sorted by path and without
+// duplicates. Unreadable directories are skipped.
+func candidates(cfg DiscoverConfig) []candidate {
+	seen := make(map[string]bool)
+	var list []candidate
+	scan := func(dir string, match func(name string) bool) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			if seen[path] || !match(e.Name()) {
+				continue
+			}
+			fi, err := os.Stat(path)
+			if err != nil || !isExecutable(fi) {
+				continue
+			}
+			seen[path] = true
+			list = append(list, candidate{path, fi})
+		}
+	}
+
+	for _, dir := range cfg.Dirs {
+		scan(dir, func(string) bool { return true })
+	}
+	if cfg.PATH {
+		for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+			scan(dir, func(name string) bool {
+				name = strings.ToLower(strings.TrimSuffix(name, ".exe"))
+				for _, p := range cfg.Names {
+					if ok, _ := filepath.Match(p, name); ok {
+						return true
+					}
+				}
+				return false
+			})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].path < list[j].path })
+	return list
+}
+
+// isExecutable reports whether fi is a regular file that can be run.
+func isExecutable(fi fs.FileInfo) bool {
+	if !fi.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(fi.Name()), ".exe")
+	}
+	return fi.Mode()&0o111 != 0
+}
+
+// probe runs the engine at info.Path in dir and fills in its response to
+// "uci".
+func probe(ctx context.Context, info *EngineInfo, dir string, timeout time.Duration) error {
+	e, err := EngineConfig{Path: info.Path, Dir: dir}.Start()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		e.Client.Close(ctx)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	name, author, opts, err := e.UCIContext(ctx)
+	if err != nil {
+		return fmt.Errorf("uci handshake: %w", err)
+	}
+
+	info.Name, info.Author, info.Options = name, author, opts
+	info.MultiPV = 1
+	if o, ok := lookupOption(opts, "UCI_Chess960"); ok {
+		_, info.Chess960 = o.(CheckOption)
+	}
+	if o, ok := lookupOption(opts, "MultiPV"); ok {
+		if spin, ok := o.(SpinOption); ok && spin.Max > 1 {
+			info.MultiPV = spin.Max
+		}
+	}
+	if o, ok := lookupOption(opts, "UCI_Variant"); ok {
+		if combo, ok := o.(ComboOption); ok {
+			info.Variants = combo.Vars
+		}
+	}
+	return nil
+}
+
+// LoadRegistry reads the engines recorded by SaveRegistry.
+func LoadRegistry(path string) ([]EngineInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []EngineInfo
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("uci: %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// SaveRegistry writes engines found by Discover to a JSON file that
+// LoadRegistry can read.
+func SaveRegistry(path string, entries []EngineInfo) error {
+	data, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,108 @@
+package uci
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeScript writes an executable shell script to dir.
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs shell scripts")
+	}
+	dir := t.TempDir()
+	runs := filepath.Join(t.TempDir(), "runs")
+	opts := "UCI_Chess960 type check default false;MultiPV type spin default 1 min 1 max 256;UCI_Variant type combo default chess var chess var crazyhouse"
+	engine := writeScript(t, dir, "helper", fmt.Sprintf(
+		"echo run >> %q\nUCI_HELPER_ENGINE=1 UCI_HELPER_NAME=Helper UCI_HELPER_OPTIONS=%q exec %q -test.run='^TestHelperEngine$'\n",
+		runs, opts, os.Args[0]))
+	broken := writeScript(t, dir, "broken", "exit 1\n")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a program"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DiscoverConfig{Dirs: []string{dir, dir}, Cache: filepath.Join(t.TempDir(), "engines.json")}
+	got, err := Discover(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Path != broken || got[1].Path != engine {
+		t.Fatalf("want entries for %s and %s, got %+v", broken, engine, got)
+	}
+	if got[0].Error == "" {
+		t.Error("broken: want error")
+	}
+	h := got[1]
+	if h.Error != "" {
+		t.Fatalf("helper: %s", h.Error)
+	}
+	if h.Name != "Helper" || h.Author != "Nobody" || len(h.Options) != 3 {
+		t.Errorf("helper: got name %q, author %q, options %v", h.Name, h.Author, h.Options)
+	}
+	if !h.Chess960 || h.MultiPV != 256 || !cmp.Equal(h.Variants, []string{"chess", "crazyhouse"}) {
+		t.Errorf("helper: got capabilities %v, %d, %q", h.Chess960, h.MultiPV, h.Variants)
+	}
+
+	// The second scan reads the cache instead of running the engine again.
+	again, err := Discover(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, again); diff != "" {
+		t.Errorf("cached mismatch (-want +got):\n%s", diff)
+	}
+	data, err := os.ReadFile(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "run"); n != 1 {
+		t.Errorf("engine ran %d times, want 1", n)
+	}
+}
+
+func TestDiscover_PATH(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs shell scripts")
+	}
+	dir := t.TempDir()
+	writeScript(t, dir, "ls-like", "echo should not run\n")
+	engine := writeScript(t, dir, "stockfish-17", "exit 1\n")
+	t.Setenv("PATH", dir)
+
+	got, err := Discover(context.Background(), DiscoverConfig{PATH: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Path != engine {
+		t.Errorf("want only %s, got %+v", engine, got)
+	}
+}
+
+func TestDiscover_Canceled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs shell scripts")
+	}
+	dir := t.TempDir()
+	writeScript(t, dir, "engine", "exit 1\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Discover(ctx, DiscoverConfig{Dirs: []string{dir}}); err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,194 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// closeTimeout is how long Close waits for the engine to quit on its own
+// before terminating it, and termTimeout how long it then waits before
+// killing it. They are replaced in tests.
+var (
+	closeTimeout = 5 * time.Second
+	termTimeout  = time.Second
+)
+
+// Engine is a UCI engine running as a child process.
+type Engine struct {
+	*Client
+
+	cmd    *exec.Cmd
+	stdin  io.Closer
+	stdout *os.File
+	done   chan struct{}
+	err    error // The result of cmd.Wait. Valid after done is closed.
+
+	transcript *transcript // The recent dialogue, for DumpTranscript.
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// StartEngine runs the engine located at path and returns it. The embedded
+// Client is connected to the engine's standard input and output.
+func StartEngine(path string) (*Engine, error) {
+	return StartEngineCmd(exec.Command(path))
+}
+
+// EngineConfig describes how to start an engine process.
+type EngineConfig struct {
+	Path   string    // The path to the engine executable.
+	Args   []string  // Command-line arguments, not including the program name.
+	Env    []string  // The environment. If nil, the current environment is used.
+	Dir    string    // The working directory. If empty, the current directory is used.
+	Stderr io.Writer // Receives the engine's standard error. If nil, it is discarded.
+}
+
+// Start runs the engine described by cfg and returns it.
+func (cfg EngineConfig) Start() (*Engine, error) {
+	cmd := exec.Command(cfg.Path, cfg.Args...)
+	cmd.Env = cfg.Env
+	cmd.Dir = cfg.Dir
+	cmd.Stderr = cfg.Stderr
+	return StartEngineCmd(cmd)
+}
+
+// StartEngineCmd starts cmd and returns it as an engine. The embedded Client is
+// connected to the engine's standard input and output, so cmd.Stdin and
+// cmd.Stdout must not be set. Other fields, such as cmd.Args, cmd.Env, cmd.Dir
+// and cmd.Stderr, are used as is.
+func StartEngineCmd(cmd *exec.Cmd) (*Engine, error) {
+	if cmd.Stdin != nil || cmd.Stdout != nil {
+		return nil, errors.New("uci: Stdin or Stdout already set")
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	// Use an explicit pipe instead of cmd.StdoutPipe, since cmd.Wait closes the
+	// latter as soon as the process exits, possibly before the final lines have
+	// been read.
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		stdin.Close()
+		return nil, err
+	}
+	cmd.Stdout = pw
+
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		pr.Close()
+		pw.Close()
+		return nil, err
+	}
+	pw.Close()
+
+	e := &Engine{
+		Client:     NewClient(pr, stdin),
+		cmd:        cmd,
+		stdin:      stdin,
+		stdout:     pr,
+		done:       make(chan struct{}),
+		transcript: newTranscript(transcriptSize),
+	}
+	e.Client.shutdown = e.shutdown
+	e.OnSend(func(line string) { e.transcript.add(">", line) })
+	e.OnReceive(func(line string) { e.transcript.add("<", line) })
+	go func() {
+		e.err = cmd.Wait()
+		close(e.done)
+	}()
+	return e, nil
+}
+
+// PID returns the process ID of the engine.
+func (e *Engine) PID() int {
+	return e.cmd.Process.Pid
+}
+
+// Done returns a channel that is closed when the engine process exits, whether
+// because it quit, crashed or was killed.
+func (e *Engine) Done() <-chan struct{} {
+	return e.done
+}
+
+// Wait blocks until the engine process exits and returns its exit error, if
+// any. See exec.Cmd.Wait for details.
+func (e *Engine) Wait() error {
+	<-e.done
+	return e.err
+}
+
+// ExitCode returns the exit code of the engine process, or -1 if the process
+// has not exited or was terminated by a signal.
+func (e *Engine) ExitCode() int {
+	select {
+	case <-e.done:
+		return e.cmd.ProcessState.ExitCode()
+	default:
+		return -1
+	}
+}
+
+// Kill kills the engine process immediately.
+func (e *Engine) Kill() error {
+	err := e.cmd.Process.Kill()
+	if errors.Is(err, os.ErrProcessDone) {
+		return nil
+	}
+	return err
+}
+
+// Close shuts the engine down like Client.Close, waiting up to five seconds
+// for it to quit on its own.
+func (e *Engine) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	return e.Client.Close(ctx)
+}
+
+// shutdown stops the engine process for Client.Close.
+func (e *Engine) shutdown(ctx context.Context) error {
+	e.closeOnce.Do(func() {
+		e.Quit()
+		e.closeErr = e.waitExit(ctx)
+		e.stdin.Close()
+		e.stdout.Close()
+		e.stopReader()
+		<-e.readDone
+	})
+	return e.closeErr
+}
+
+// waitExit waits for the engine process to exit until ctx is done, then
+// terminates it, and kills it if it is still running termTimeout later. It
+// returns the exit error of a process that exited on its own.
+func (e *Engine) waitExit(ctx context.Context) error {
+	select {
+	case <-e.done:
+		return e.err
+	case <-ctx.Done():
+	}
+
+	// SIGTERM isn't supported everywhere, such as on Windows.
+	if e.cmd.Process.Signal(syscall.SIGTERM) == nil {
+		select {
+		case <-e.done:
+			return nil
+		case <-time.After(termTimeout):
+		}
+	}
+	if err := e.Kill(); err != nil {
+		return err
+	}
+	<-e.done
+	return nil
+}
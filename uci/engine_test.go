@@ -0,0 +1,259 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestHelperEngine is not a real test. It acts as a minimal UCI engine when
+// run as a child process by helperEngine.
+func TestHelperEngine(t *testing.T) {
+	if os.Getenv("UCI_HELPER_ENGINE") != "1" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "helper started with %q\n", os.Args[1:])
+	hang := false
+	s := bufio.NewScanner(os.Stdin)
+	for s.Scan() {
+		switch s.Text() {
+		case "uci":
+			fmt.Printf("id name %s\n", os.Getenv("UCI_HELPER_NAME"))
+			fmt.Println("id author Nobody")
+			if opts := os.Getenv("UCI_HELPER_OPTIONS"); opts != "" {
+				for _, o := range strings.Split(opts, ";") {
+					fmt.Println("option name " + o)
+				}
+			}
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "hang":
+			hang = true
+		case "ignoreterm":
+			signal.Ignore(syscall.SIGTERM)
+		case "crash":
+			os.Exit(3)
+		case "quit":
+			if !hang {
+				os.Exit(0)
+			}
+		}
+	}
+	os.Exit(0)
+}
+
+// helperCommand returns a command that runs TestHelperEngine.
+func helperCommand() *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperEngine$")
+	cmd.Env = append(os.Environ(), "UCI_HELPER_ENGINE=1", "UCI_HELPER_NAME=Helper")
+	return cmd
+}
+
+func startHelperEngine(t *testing.T) *Engine {
+	t.Helper()
+	e, err := StartEngineCmd(helperCommand())
+	if err != nil {
+		t.Fatalf("StartEngineCmd: %v", err)
+	}
+	return e
+}
+
+func TestEngine_Close(t *testing.T) {
+	e := startHelperEngine(t)
+	if e.PID() <= 0 {
+		t.Errorf("PID: got %d", e.PID())
+	}
+	name, _, _, err := e.UCI()
+	if err != nil {
+		t.Fatalf("UCI: %v", err)
+	}
+	if name != "Helper" {
+		t.Errorf("name: want %q, got %q", "Helper", name)
+	}
+	if err := e.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if got := e.ExitCode(); got != 0 {
+		t.Errorf("ExitCode: want 0, got %d", got)
+	}
+}
+
+func TestEngine_Crash(t *testing.T) {
+	e := startHelperEngine(t)
+	if err := e.send("crash"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	select {
+	case <-e.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the engine to exit")
+	}
+	if e.Wait() == nil {
+		t.Error("Wait: want error")
+	}
+	if got := e.ExitCode(); got != 3 {
+		t.Errorf("ExitCode: want 3, got %d", got)
+	}
+}
+
+func TestEngine_CloseKills(t *testing.T) {
+	defer func(d time.Duration) { closeTimeout = d }(closeTimeout)
+	closeTimeout = 10 * time.Millisecond
+
+	e := startHelperEngine(t)
+	if err := e.send("hang"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	select {
+	case <-e.Done():
+	default:
+		t.Error("engine still running after Close")
+	}
+}
+
+func TestEngine_CloseEscalates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no SIGTERM on Windows")
+	}
+	defer func(d time.Duration) { termTimeout = d }(termTimeout)
+	termTimeout = 10 * time.Millisecond
+
+	for _, tc := range []struct {
+		cmds []string
+		want string // The end of the process state.
+	}{
+		{[]string{"hang"}, "terminated"},
+		{[]string{"ignoreterm", "hang"}, "killed"},
+	} {
+		e := startHelperEngine(t)
+		for _, cmd := range tc.cmds {
+			if err := e.send(cmd); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+		}
+		// Make sure the commands were handled.
+		if err := e.IsReady(); err != nil {
+			t.Fatalf("IsReady: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := e.Client.Close(ctx)
+		cancel()
+		if err != nil {
+			t.Errorf("%v: Close: %v", tc.cmds, err)
+		}
+		if got := e.cmd.ProcessState.String(); !strings.HasSuffix(got, tc.want) {
+			t.Errorf("%v: want the engine %s, got %q", tc.cmds, tc.want, got)
+		}
+	}
+}
+
+func TestClient_Close_Process(t *testing.T) {
+	// A client that doesn't expose its engine still stops it.
+	c, err := NewClientFromCmd(helperCommand())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatalf("UCI: %v", err)
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if err := c.IsReady(); !errors.Is(err, ErrClosed) {
+		t.Errorf("IsReady after Close: want ErrClosed, got %v", err)
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestClient_Close_Pipes(t *testing.T) {
+	// The engine reads its input, but never answers.
+	engineIn, w := io.Pipe()
+	r, engineOut := io.Pipe()
+	defer engineOut.Close()
+	go io.Copy(io.Discard, engineIn)
+
+	c := NewClient(r, w)
+	pending := make(chan error, 1)
+	go func() { pending <- c.IsReady() }()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Close(ctx); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	select {
+	case err := <-pending:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("pending IsReady: want ErrClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("IsReady still pending after Close")
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("the engine's input is still open")
+	}
+}
+
+func TestClient_Close_Unstarted(t *testing.T) {
+	// A reader that can't be closed isn't waited for.
+	c := NewClient(strings.NewReader(""), io.Discard)
+	if err := c.Close(context.Background()); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if _, _, _, err := c.UCI(); !errors.Is(err, ErrClosed) {
+		t.Errorf("UCI after Close: want ErrClosed, got %v", err)
+	}
+}
+
+func TestEngineConfig_Start(t *testing.T) {
+	var stderr syncBuffer
+	cfg := EngineConfig{
+		Path:   os.Args[0],
+		Args:   []string{"-test.run=^TestHelperEngine$"},
+		Env:    append(os.Environ(), "UCI_HELPER_ENGINE=1", "UCI_HELPER_NAME=Configured"),
+		Dir:    t.TempDir(),
+		Stderr: &stderr,
+	}
+	e, err := cfg.Start()
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	name, _, _, err := e.UCI()
+	if err != nil {
+		t.Fatalf("UCI: %v", err)
+	}
+	if name != "Configured" {
+		t.Errorf("name: want %q, got %q", "Configured", name)
+	}
+	if err := e.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if want, got := `helper started with ["-test.run=^TestHelperEngine$"]`, stderr.String(); !strings.Contains(got, want) {
+		t.Errorf("stderr: want %q, got %q", want, got)
+	}
+}
+
+func TestStartEngineCmd_StdoutSet(t *testing.T) {
+	cmd := helperCommand()
+	cmd.Stdout = io.Discard
+	if _, err := StartEngineCmd(cmd); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,68 @@
+package uci
+
+import (
+	"context"
+	"fmt"
+)
+
+// ParseError is an error parsing a line of the protocol, such as an "info"
+// line from the engine or a "go" command.
+type ParseError struct {
+	Line   string // The offending line.
+	Field  string // The field at fault, such as "depth", if any.
+	Reason string // What is wrong, such as "invalid value".
+	Err    error  // The underlying error, if any.
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("uci: line %q: %s", e.Line, e.Reason)
+	if e.Field != "" {
+		msg += fmt.Sprintf(" for %q", e.Field)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// EngineTerminatedError is the error of a client whose engine stopped
+// responding: its output ended, as when the process exits, or couldn't be
+// read. Every later command fails with the same error.
+type EngineTerminatedError struct {
+	Err error // Why reading stopped, such as io.ErrUnexpectedEOF.
+}
+
+func (e *EngineTerminatedError) Error() string {
+	return "uci: engine terminated: " + e.Err.Error()
+}
+
+func (e *EngineTerminatedError) Unwrap() error { return e.Err }
+
+// TimeoutError is the error of a command whose response didn't arrive before
+// the deadline of its context.
+type TimeoutError struct {
+	Command string // The command, such as "isready".
+	Err     error  // The context's error.
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("uci: no response to %q: %v", e.Command, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// Timeout reports true, like the timeout errors of package net.
+func (e *TimeoutError) Timeout() bool { return true }
+
+// waitError returns the error of a command that stopped waiting for the
+// engine because ctx is done: a TimeoutError if its deadline passed, and
+// ctx.Err() if it was canceled.
+func waitError(ctx context.Context, cmd string) error {
+	err := ctx.Err()
+	if err == context.DeadlineExceeded {
+		return &TimeoutError{Command: cmd, Err: err}
+	}
+	return err
+}
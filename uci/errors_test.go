@@ -0,0 +1,89 @@
+package uci
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseError(t *testing.T) {
+	cases := []struct {
+		name  string
+		parse func(string) error
+		line  string
+		want  *ParseError
+	}{
+		{
+			"info", func(s string) error { _, err := ParseInfo(s); return err },
+			"info depth 3 nodes x",
+			&ParseError{Line: "info depth 3 nodes x", Field: "nodes", Reason: "invalid value", Err: strconv.ErrSyntax},
+		},
+		{
+			"info score", func(s string) error { _, err := ParseInfo(s); return err },
+			"info score cp",
+			&ParseError{Line: "info score cp", Field: "score cp", Reason: "missing value"},
+		},
+		{
+			"not info", func(s string) error { _, err := ParseInfo(s); return err },
+			"bestmove e2e4",
+			&ParseError{Line: "bestmove e2e4", Reason: "not an info line"},
+		},
+		{
+			"bestmove", func(s string) error { _, err := parseBestMove(s); return err },
+			"bestmove e2e4 ponder e7",
+			&ParseError{Line: "bestmove e2e4 ponder e7", Field: "ponder", Reason: "invalid move", Err: errAny},
+		},
+		{
+			"go", func(s string) error { _, err := ParseSearch(s); return err },
+			"go wtime",
+			&ParseError{Line: "go wtime", Field: "wtime", Reason: "missing value"},
+		},
+		{
+			"position", func(s string) error { _, err := ParsePosition(s); return err },
+			"position startpos e2e4",
+			&ParseError{Line: "position startpos e2e4", Field: "e2e4", Reason: "unexpected field"},
+		},
+		{
+			"option", func(s string) error { _, err := ParseOption(s); return err },
+			"option name Hash type spin default 16 min one max 1024",
+			&ParseError{Line: "option name Hash type spin default 16 min one max 1024", Field: "min", Reason: "invalid value", Err: strconv.ErrSyntax},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.parse(tc.line)
+			var got *ParseError
+			if !errors.As(err, &got) {
+				t.Fatalf("want a *ParseError, got %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(ParseError{}, "Err")); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+			switch {
+			case tc.want.Err == nil && got.Err != nil:
+				t.Errorf("want no underlying error, got %v", got.Err)
+			case tc.want.Err == errAny && got.Err == nil:
+				t.Error("want an underlying error")
+			case tc.want.Err != nil && tc.want.Err != errAny && !errors.Is(err, tc.want.Err):
+				t.Errorf("want %v underneath, got %v", tc.want.Err, got.Err)
+			}
+		})
+	}
+}
+
+// errAny stands for any underlying error in TestParseError.
+var errAny = errors.New("any error")
+
+func TestParseError_Error(t *testing.T) {
+	err := &ParseError{Line: "info depth x", Field: "depth", Reason: "invalid value", Err: errors.New("bad")}
+	if want := `uci: line "info depth x": invalid value for "depth": bad`; err.Error() != want {
+		t.Errorf("want %s, got %s", want, err)
+	}
+	err = &ParseError{Line: "hello", Reason: "not an info line"}
+	if want := `uci: line "hello": not an info line`; err.Error() != want {
+		t.Errorf("want %s, got %s", want, err)
+	}
+}
@@ -0,0 +1,137 @@
+package uci
+
+import (
+	"context"
+
+	"github.com/clfs/chess/internal/relay"
+)
+
+// Event is something that happened on a client, as delivered by Subscribe. It
+// is one of LineSent, LineReceived, InfoReceived, InfoParseFailed,
+// BestMoveReceived, OptionParsed and EngineTerminated.
+type Event interface {
+	event()
+}
+
+// LineSent is a line sent to the engine, without the trailing newline.
+type LineSent struct {
+	Line string
+}
+
+// LineReceived is a line received from the engine, without the trailing
+// newline. It comes before any event parsed from the line.
+type LineReceived struct {
+	Line string
+}
+
+// InfoReceived is search information from the engine, whether or not a search
+// is running, with castling moves translated as for Go.
+type InfoReceived struct {
+	Info Info
+}
+
+// InfoParseFailed is an "info" line from the engine that couldn't be parsed,
+// and so is left out of the search's information. Err is a *ParseError, which
+// holds the line.
+type InfoParseFailed struct {
+	Err error
+}
+
+// BestMoveReceived is a best move from the engine, with castling moves
+// translated as for Go.
+type BestMoveReceived struct {
+	BestMove BestMove
+}
+
+// OptionParsed is an option advertised by the engine during a "uci" handshake.
+type OptionParsed struct {
+	Option Option
+}
+
+// EngineTerminated is the last event of a client: the engine stopped
+// responding, with the error commands now fail with, such as an
+// *EngineTerminatedError, or ErrClosed after Close.
+type EngineTerminated struct {
+	Err error
+}
+
+func (LineSent) event()         {}
+func (LineReceived) event()     {}
+func (InfoReceived) event()     {}
+func (InfoParseFailed) event()  {}
+func (BestMoveReceived) event() {}
+func (OptionParsed) event()     {}
+func (EngineTerminated) event() {}
+
+// subscription is the queue of events of a subscriber.
+type subscription struct {
+	relay *relay.Relay[Event, struct{}]
+}
+
+// Subscribe returns a channel that receives the client's events in order, so
+// that several components, such as a logger, a GUI and a recorder, can observe
+// the client without sharing the channels returned by Go. Events are queued,
+// so a slow subscriber never delays the client or other subscribers.
+//
+// The channel is closed after EngineTerminated, or when cancel is called, in
+// which case events not yet received are discarded. Subscribing to a client
+// whose engine has already stopped responding delivers only EngineTerminated.
+func (c *Client) Subscribe() (events <-chan Event, cancel func()) {
+	ctx, stop := context.WithCancel(context.Background())
+	sub := &subscription{relay: relay.New[Event, struct{}]()}
+	go sub.relay.Forward(ctx)
+
+	c.mu.Lock()
+	err := c.err
+	if err == nil {
+		c.hooks.mu.Lock()
+		c.hooks.subs = append(c.hooks.subs, sub)
+		c.hooks.mu.Unlock()
+	}
+	c.mu.Unlock()
+	if err != nil {
+		sub.relay.Queue(EngineTerminated{Err: err})
+		sub.relay.Finish(nil)
+	}
+
+	return sub.relay.Info, func() {
+		c.hooks.mu.Lock()
+		for i, s := range c.hooks.subs {
+			if s == sub {
+				c.hooks.subs = append(c.hooks.subs[:i:i], c.hooks.subs[i+1:]...)
+				break
+			}
+		}
+		c.hooks.mu.Unlock()
+		stop()
+		sub.relay.Finish(nil)
+	}
+}
+
+// subscribed reports whether there are any subscribers.
+func (h *hooks) subscribed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs) > 0
+}
+
+// publish queues e for every subscriber.
+func (h *hooks) publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, s := range h.subs {
+		s.relay.Queue(e)
+	}
+}
+
+// terminate publishes EngineTerminated, and ends every subscription.
+func (h *hooks) terminate(err error) {
+	h.mu.Lock()
+	subs := h.subs
+	h.subs = nil
+	h.mu.Unlock()
+	for _, s := range subs {
+		s.relay.Queue(EngineTerminated{Err: err})
+		s.relay.Finish(nil)
+	}
+}
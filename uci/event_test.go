@@ -0,0 +1,192 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// describe returns a short description of e, for comparisons.
+func describe(e Event) string {
+	switch e := e.(type) {
+	case LineSent:
+		return "> " + e.Line
+	case LineReceived:
+		return "< " + e.Line
+	case InfoReceived:
+		return fmt.Sprintf("info depth %d pv %v", e.Info.Depth, e.Info.PV)
+	case InfoParseFailed:
+		return fmt.Sprintf("info failed: %v", e.Err)
+	case BestMoveReceived:
+		return "bestmove " + e.BestMove.Move.String()
+	case OptionParsed:
+		return "option " + e.Option.OptionName()
+	case EngineTerminated:
+		return fmt.Sprintf("terminated: %v", e.Err)
+	}
+	return fmt.Sprintf("unknown %T", e)
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	r := &recorder{uci: "id name Fake\noption name Hash type spin default 16 min 1 max 64\n"}
+	c := fakeEngine(t, r.respond)
+
+	events1, cancel1 := c.Subscribe()
+	events2, cancel2 := c.Subscribe()
+	defer cancel2()
+
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	infoCh, bestCh, err := c.Go(Search{Nodes: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range infoCh {
+	}
+	<-bestCh
+
+	want := []string{
+		"> uci",
+		"< id name Fake",
+		"< option name Hash type spin default 16 min 1 max 64",
+		"option Hash",
+		"< uciok",
+		"> go nodes 1",
+		"< info depth 1 pv e2e4",
+		"info depth 1 pv [e2e4]",
+		"< bestmove e2e4",
+		"bestmove e2e4",
+	}
+	for i, events := range []<-chan Event{events1, events2} {
+		var got []string
+		for len(got) < len(want) {
+			got = append(got, describe(<-events))
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("subscriber %d: mismatch (-want +got):\n%s", i+1, diff)
+		}
+	}
+
+	// A canceled subscription's channel is closed.
+	cancel1()
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	for e := range events1 {
+		t.Errorf("got %s after cancel", describe(e))
+	}
+	if got := describe(<-events2); got != "> isready" {
+		t.Errorf("got %s, want > isready", got)
+	}
+}
+
+func TestClient_Subscribe_InfoOutsideSearch(t *testing.T) {
+	c := fakeEngine(t, func(cmd string) string {
+		if cmd == "isready" {
+			return "info depth 3 pv d2d4\nreadyok\n"
+		}
+		return ""
+	})
+	events, cancel := c.Subscribe()
+	defer cancel()
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for len(got) < 3 {
+		got = append(got, describe(<-events))
+	}
+	want := []string{"> isready", "< info depth 3 pv d2d4", "info depth 3 pv [d2d4]"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Subscribe_InfoParseFailed(t *testing.T) {
+	c := fakeEngine(t, func(cmd string) string {
+		if strings.HasPrefix(cmd, "go") {
+			return "info depth x pv e2e4\ninfo depth 2 pv d2d4\nbestmove d2d4\n"
+		}
+		return ""
+	})
+	events, cancel := c.Subscribe()
+	defer cancel()
+	infoCh, bestCh, err := c.Go(Search{Depth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infos []Info
+	for info := range infoCh {
+		infos = append(infos, info)
+	}
+	<-bestCh
+	if len(infos) != 1 || infos[0].Depth != 2 {
+		t.Errorf("got info %+v, want only depth 2", infos)
+	}
+
+	// The failure is published before the best move.
+	for e := range events {
+		switch e := e.(type) {
+		case InfoParseFailed:
+			var pe *ParseError
+			if !errors.As(e.Err, &pe) || pe.Line != "info depth x pv e2e4" || pe.Field != "depth" {
+				t.Errorf("got error %v, want a *ParseError for depth", e.Err)
+			}
+			return
+		case BestMoveReceived:
+			t.Fatal("no InfoParseFailed event")
+		}
+	}
+}
+
+func TestClient_Subscribe_Terminated(t *testing.T) {
+	c := NewClient(strings.NewReader("readyok\n"), io.Discard)
+	events, cancel := c.Subscribe()
+	defer cancel()
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+
+	var last Event
+	for e := range events {
+		last = e
+	}
+	term, ok := last.(EngineTerminated)
+	var ete *EngineTerminatedError
+	if !ok || !errors.As(term.Err, &ete) {
+		t.Fatalf("got last event %v, want EngineTerminated", last)
+	}
+
+	// Subscribing afterwards delivers only the termination.
+	events, cancel = c.Subscribe()
+	defer cancel()
+	var got []string
+	for e := range events {
+		got = append(got, describe(e))
+	}
+	if want := []string{"terminated: " + term.Err.Error()}; !cmp.Equal(want, got) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestClient_Subscribe_Close(t *testing.T) {
+	c := fakeEngine(t, func(cmd string) string { return "" })
+	events, _ := c.Subscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Close(ctx)
+
+	var last Event
+	for e := range events {
+		last = e
+	}
+	if term, ok := last.(EngineTerminated); !ok || !errors.Is(term.Err, ErrClosed) {
+		t.Errorf("got last event %v, want EngineTerminated with ErrClosed", last)
+	}
+}
@@ -0,0 +1,103 @@
+package uci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fingerprint identifies an engine build, so that results can be traced back
+// to the engine that produced them.
+type Fingerprint struct {
+	Name    string // The engine's name, as reported by "id name".
+	Author  string // The engine's author, as reported by "id author".
+	Binary  string // The hex-encoded SHA-256 of the engine executable, if known.
+	Options string // The hex-encoded SHA-256 of the engine's option declarations.
+
+	// Bench is the engine's bench signature, as returned by BenchSignature,
+	// or 0 if unknown.
+	Bench int64
+}
+
+// Fingerprint returns the fingerprint of the engine, based on its response to
+// the last "uci" command. Binary is left empty.
+func (c *Client) Fingerprint() Fingerprint {
+	name, author := c.ID()
+	return Fingerprint{
+		Name:    name,
+		Author:  author,
+		Options: hashOptions(c.Options()),
+	}
+}
+
+// BenchSignature runs the engine's "bench" command with the given arguments,
+// or its defaults if there are none, and returns the total number of nodes
+// searched. Stockfish and engines derived from it search the same number of
+// nodes on every run of a build, as long as the benchmark uses one thread, so
+// the count, which they call the bench signature, tells builds apart. It is
+// meant for Fingerprint's Bench field. See Bench for how the output is read.
+func (c *Client) BenchSignature(ctx context.Context, args ...string) (int64, error) {
+	res, err := c.Bench(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.Nodes, nil
+}
+
+// Fingerprint is like Client.Fingerprint, but also hashes the engine
+// executable.
+func (e *Engine) Fingerprint() (Fingerprint, error) {
+	fp := e.Client.Fingerprint()
+
+	f, err := os.Open(e.cmd.Path)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return Fingerprint{}, err
+	}
+	fp.Binary = hex.EncodeToString(h.Sum(nil))
+	return fp, nil
+}
+
+// hashOptions hashes the option declarations, ignoring their order.
+func hashOptions(opts []Option) string {
+	lines := make([]string, 0, len(opts))
+	for _, o := range opts {
+		text, err := o.MarshalText()
+		if err != nil {
+			text = []byte(o.OptionName())
+		}
+		lines = append(lines, string(text))
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ID returns a short identifier derived from every field of the fingerprint,
+// suitable for PGN tags and analysis metadata. Two engines have the same ID
+// only if their fingerprints are equal.
+func (fp Fingerprint) ID() string {
+	fields := []string{fp.Name, fp.Author, fp.Binary, fp.Options}
+	if fp.Bench != 0 {
+		fields = append(fields, strconv.FormatInt(fp.Bench, 10))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// String returns the engine name followed by the fingerprint ID.
+func (fp Fingerprint) String() string {
+	return fmt.Sprintf("%s (%s)", fp.Name, fp.ID())
+}
@@ -0,0 +1,88 @@
+package uci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+)
+
+func fingerprintEngine(opts string) func(cmd string) string {
+	return func(cmd string) string {
+		if cmd == "uci" {
+			return "id name Fake\nid author Someone\n" + opts + "uciok\n"
+		}
+		return ""
+	}
+}
+
+func TestClient_Fingerprint(t *testing.T) {
+	const (
+		hash    = "option name Hash type spin default 16 min 1 max 1024\n"
+		threads = "option name Threads type spin default 1 min 1 max 512\n"
+	)
+
+	fingerprint := func(opts string) Fingerprint {
+		t.Helper()
+		c := fakeEngine(t, fingerprintEngine(opts))
+		if _, _, _, err := c.UCI(); err != nil {
+			t.Fatal(err)
+		}
+		return c.Fingerprint()
+	}
+
+	a := fingerprint(hash + threads)
+	if a.Name != "Fake" || a.Author != "Someone" || a.Binary != "" {
+		t.Errorf("unexpected fingerprint %+v", a)
+	}
+	if b := fingerprint(threads + hash); b != a {
+		t.Errorf("option order changed the fingerprint: %+v != %+v", b, a)
+	}
+	if c := fingerprint(hash); c.ID() == a.ID() {
+		t.Errorf("different options have the same ID %s", a.ID())
+	}
+}
+
+func TestEngine_Fingerprint(t *testing.T) {
+	e := startHelperEngine(t)
+	defer e.Close()
+	if _, _, _, err := e.UCI(); err != nil {
+		t.Fatal(err)
+	}
+
+	fp, err := e.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if want := hex.EncodeToString(sum[:]); fp.Binary != want {
+		t.Errorf("got binary hash %s, want %s", fp.Binary, want)
+	}
+	if fp.Name != "Helper" {
+		t.Errorf("got name %q, want Helper", fp.Name)
+	}
+}
+
+func TestClient_BenchSignature(t *testing.T) {
+	c := fakeEngine(t, stockfishEngine)
+	sig, err := c.BenchSignature(context.Background(), "16", "1", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig != 200 {
+		t.Errorf("got signature %d, want 200", sig)
+	}
+
+	fp := Fingerprint{Name: "Fake"}
+	id := fp.ID()
+	fp.Bench = sig
+	if fp.ID() == id {
+		t.Errorf("the bench signature doesn't change the ID %s", id)
+	}
+}
@@ -0,0 +1,345 @@
+package uci
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/clfs/chess"
+)
+
+// infoKeywords are the tokens that start a new field in an "info" line.
+var infoKeywords = map[string]bool{
+	"depth":          true,
+	"seldepth":       true,
+	"time":           true,
+	"nodes":          true,
+	"pv":             true,
+	"multipv":        true,
+	"score":          true,
+	"currmove":       true,
+	"currmovenumber": true,
+	"hashfull":       true,
+	"nps":            true,
+	"tbhits":         true,
+	"sbhits":         true,
+	"cpuload":        true,
+	"string":         true,
+	"refutation":     true,
+	"currline":       true,
+	"wdl":            true,
+}
+
+// ParseInfo parses an "info" line sent by the engine, such as
+//
+//	info depth 20 seldepth 28 multipv 1 score cp 31 nodes 1201432 nps 1204043 time 998 pv e2e4 e7e5
+//
+// As the protocol requires, unknown fields are skipped along with any values up
+// to the next known field. The "string" field consumes the rest of the line.
+func ParseInfo(line string) (Info, error) {
+	var info Info
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "info" {
+		return Info{}, &ParseError{Line: line, Reason: "not an info line"}
+	}
+
+	// next returns the position of the next keyword at or after fields[pos].
+	next := func(pos int) int {
+		for pos < len(fields) && !infoKeywords[fields[pos]] {
+			pos++
+		}
+		return pos
+	}
+
+	// moves parses the moves starting at fields[pos], stopping at the next
+	// keyword, and returns the position of that keyword.
+	moves := func(pos int) ([]chess.Move, int, error) {
+		end := next(pos)
+		acc := make([]chess.Move, 0, end-pos)
+		for _, f := range fields[pos:end] {
+			m, err := chess.ParseMove(f)
+			if err != nil {
+				return nil, 0, &ParseError{Line: line, Field: fields[pos-1], Reason: "invalid value", Err: err}
+			}
+			acc = append(acc, m)
+		}
+		return acc, end, nil
+	}
+
+	// integer64 parses fields[pos] as an integer of the given bit size, with
+	// 0 meaning int.
+	integer64 := func(pos, bitSize int) (int64, error) {
+		if pos >= len(fields) {
+			return 0, &ParseError{Line: line, Field: fields[pos-1], Reason: "missing value"}
+		}
+		n, err := strconv.ParseInt(fields[pos], 10, bitSize)
+		if err != nil {
+			return 0, &ParseError{Line: line, Field: fields[pos-1], Reason: "invalid value", Err: err}
+		}
+		return n, nil
+	}
+	// integer parses fields[pos] as an int.
+	integer := func(pos int) (int, error) {
+		n, err := integer64(pos, 0)
+		return int(n), err
+	}
+
+	for pos := 1; pos < len(fields); {
+		key := fields[pos]
+		pos++
+
+		var err error
+		switch key {
+		case "depth":
+			info.Depth, err = integer(pos)
+			pos++
+		case "seldepth":
+			info.SelDepth, err = integer(pos)
+			pos++
+		case "time":
+			info.Time, err = parseMillis(line, fields, pos)
+			pos++
+		case "nodes":
+			info.Nodes, err = integer64(pos, 64)
+			pos++
+		case "multipv":
+			info.MultiPV, err = integer(pos)
+			pos++
+		case "currmove":
+			if pos >= len(fields) {
+				return Info{}, &ParseError{Line: line, Field: key, Reason: "missing value"}
+			}
+			if info.CurrMove, err = chess.ParseMove(fields[pos]); err != nil {
+				err = &ParseError{Line: line, Field: key, Reason: "invalid value", Err: err}
+			}
+			pos++
+		case "currmovenumber":
+			info.CurrMoveNumber, err = integer(pos)
+			pos++
+		case "hashfull":
+			info.HashFull, err = integer(pos)
+			pos++
+		case "nps":
+			info.NPS, err = integer64(pos, 64)
+			pos++
+		case "tbhits":
+			info.TBHits, err = integer64(pos, 64)
+			pos++
+		case "sbhits":
+			_, err = integer(pos)
+			pos++
+		case "cpuload":
+			info.CPULoad, err = integer(pos)
+			pos++
+		case "pv":
+			info.PV, pos, err = moves(pos)
+		case "refutation":
+			info.Refutation, pos, err = moves(pos)
+		case "currline":
+			// The line may be prefixed with a CPU number.
+			if pos < len(fields) {
+				if _, err := strconv.Atoi(fields[pos]); err == nil {
+					pos++
+				}
+			}
+			info.CurrLine, pos, err = moves(pos)
+		case "string":
+			// The string extends to the end of the line.
+			info.String = strings.TrimSpace(line[fieldEnd(line, pos-1):])
+			pos = len(fields)
+		case "score":
+			pos, err = parseScore(line, fields, pos, &info.Score)
+		case "wdl":
+			for _, p := range []*int{&info.WDL.Win, &info.WDL.Draw, &info.WDL.Loss} {
+				if *p, err = integer(pos); err != nil {
+					break
+				}
+				pos++
+			}
+		default:
+			pos = next(pos)
+		}
+		if err != nil {
+			return Info{}, err
+		}
+	}
+
+	return info, nil
+}
+
+// fieldEnd returns the offset in line just past field n, counting from zero,
+// where fields are split as by strings.Fields.
+func fieldEnd(line string, n int) int {
+	inField := false
+	for i, r := range line {
+		switch space := unicode.IsSpace(r); {
+		case space && inField:
+			if n == 0 {
+				return i
+			}
+			n--
+			inField = false
+		case !space:
+			inField = true
+		}
+	}
+	return len(line)
+}
+
+// maxMillis is the longest time in milliseconds that fits in a time.Duration.
+const maxMillis = math.MaxInt64 / int64(time.Millisecond)
+
+// parseMillis parses fields[pos] of line as a time in milliseconds.
+func parseMillis(line string, fields []string, pos int) (time.Duration, error) {
+	if pos >= len(fields) {
+		return 0, &ParseError{Line: line, Field: fields[pos-1], Reason: "missing value"}
+	}
+	n, err := strconv.ParseInt(fields[pos], 10, 64)
+	if err == nil && (n > maxMillis || n < -maxMillis) {
+		err = strconv.ErrRange
+	}
+	if err != nil {
+		return 0, &ParseError{Line: line, Field: fields[pos-1], Reason: "invalid value", Err: err}
+	}
+	return time.Duration(n) * time.Millisecond, nil
+}
+
+// FormatInfo formats info as an "info" line, as sent by engines. Fields with
+// zero values are omitted, except that the score is always included alongside a
+// PV. The "string" field, if any, comes last.
+func FormatInfo(info Info) string {
+	var b strings.Builder
+	b.WriteString("info")
+
+	intField := func(name string, n int64) {
+		if n != 0 {
+			fmt.Fprintf(&b, " %s %d", name, n)
+		}
+	}
+	movesField := func(name string, moves []chess.Move) {
+		if len(moves) > 0 {
+			fmt.Fprintf(&b, " %s %s", name, joinMoves(moves))
+		}
+	}
+
+	intField("depth", int64(info.Depth))
+	intField("seldepth", int64(info.SelDepth))
+	intField("multipv", int64(info.MultiPV))
+	if info.Score != (Score{}) || len(info.PV) > 0 {
+		if info.Score.Mate.Found {
+			fmt.Fprintf(&b, " score mate %d", info.Score.Mate.MovesUntil)
+		} else {
+			fmt.Fprintf(&b, " score cp %d", info.Score.CP)
+		}
+		if info.Score.LowerBound {
+			b.WriteString(" lowerbound")
+		}
+		if info.Score.UpperBound {
+			b.WriteString(" upperbound")
+		}
+	}
+	if info.WDL != (WDL{}) {
+		fmt.Fprintf(&b, " wdl %d %d %d", info.WDL.Win, info.WDL.Draw, info.WDL.Loss)
+	}
+	intField("nodes", info.Nodes)
+	intField("nps", info.NPS)
+	intField("hashfull", int64(info.HashFull))
+	intField("tbhits", info.TBHits)
+	intField("cpuload", int64(info.CPULoad))
+	intField("time", info.Time.Milliseconds())
+	if info.CurrMove != (chess.Move{}) {
+		fmt.Fprintf(&b, " currmove %s", info.CurrMove)
+	}
+	intField("currmovenumber", int64(info.CurrMoveNumber))
+	movesField("pv", info.PV)
+	movesField("refutation", info.Refutation)
+	movesField("currline", info.CurrLine)
+	if info.String != "" {
+		fmt.Fprintf(&b, " string %s", info.String)
+	}
+	return b.String()
+}
+
+// parseScore parses the score starting at fields[pos] of line into s, and
+// returns the position of the first field after the score.
+func parseScore(line string, fields []string, pos int, s *Score) (int, error) {
+	for pos < len(fields) {
+		switch fields[pos] {
+		case "cp", "mate":
+			if pos+1 >= len(fields) {
+				return 0, &ParseError{Line: line, Field: "score " + fields[pos], Reason: "missing value"}
+			}
+			n, err := strconv.Atoi(fields[pos+1])
+			if err != nil {
+				return 0, &ParseError{Line: line, Field: "score " + fields[pos], Reason: "invalid value", Err: err}
+			}
+			if fields[pos] == "cp" {
+				s.CP = n
+			} else {
+				s.Mate.Found = true
+				s.Mate.MovesUntil = n
+			}
+			pos += 2
+		case "lowerbound":
+			s.LowerBound = true
+			pos++
+		case "upperbound":
+			s.UpperBound = true
+			pos++
+		default:
+			return pos, nil
+		}
+	}
+	return pos, nil
+}
+
+func (bm BestMove) String() string {
+	if bm.Ponder != (chess.Move{}) {
+		return fmt.Sprintf("bestmove %s ponder %s", bm.Move, bm.Ponder)
+	}
+	return fmt.Sprintf("bestmove %s", bm.Move)
+}
+
+// parseBestMove parses a "bestmove" line sent by the engine.
+func parseBestMove(line string) (BestMove, error) {
+	var bm BestMove
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "bestmove" {
+		return BestMove{}, &ParseError{Line: line, Reason: "not a bestmove line"}
+	}
+
+	// Engines without a legal move may send "(none)" instead of "0000".
+	if fields[1] != "(none)" {
+		m, err := chess.ParseMove(fields[1])
+		if err != nil {
+			return BestMove{}, &ParseError{Line: line, Field: "bestmove", Reason: "invalid move", Err: err}
+		}
+		bm.Move = m
+	}
+
+	if len(fields) >= 4 && fields[2] == "ponder" && fields[3] != "(none)" {
+		m, err := chess.ParseMove(fields[3])
+		if err != nil {
+			return BestMove{}, &ParseError{Line: line, Field: "ponder", Reason: "invalid move", Err: err}
+		}
+		bm.Ponder = m
+	}
+	return bm, nil
+}
+
+// joinMoves formats moves separated by spaces.
+func joinMoves(moves []chess.Move) string {
+	var b strings.Builder
+	for i, m := range moves {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(m.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,223 @@
+package uci
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/uci/infocorpus"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseInfo(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Info
+	}{
+		{
+			"info depth 12 seldepth 15 time 120 nodes 84213 pv d2d4 g8f6 c2c4",
+			Info{Depth: 12, SelDepth: 15, Time: 120 * time.Millisecond, Nodes: 84213, PV: moves("d2d4", "g8f6", "c2c4")},
+		},
+		{
+			"info score mate -3 upperbound",
+			Info{Score: Score{Mate: struct {
+				Found      bool
+				MovesUntil int
+			}{true, -3}, UpperBound: true}},
+		},
+		{
+			"info currmove e2e4 currmovenumber 1",
+			Info{CurrMove: move("e2e4"), CurrMoveNumber: 1},
+		},
+		{
+			"info hashfull 512 cpuload 990 refutation d1h5 g6h5",
+			Info{HashFull: 512, CPULoad: 990, Refutation: moves("d1h5", "g6h5")},
+		},
+		{
+			"info currline 1 e2e4 e7e5",
+			Info{CurrLine: moves("e2e4", "e7e5")},
+		},
+		{
+			"info multipv 2 score cp -15 lowerbound nodes 10 nps 5000 tbhits 3 sbhits 0",
+			Info{MultiPV: 2, Score: Score{CP: -15, LowerBound: true}, Nodes: 10, NPS: 5000, TBHits: 3},
+		},
+		{
+			"info depth 7 bogus 1 2 nodes 99",
+			Info{Depth: 7, Nodes: 99},
+		},
+		{
+			"info depth 24 score cp 35 wdl 102 860 38 nodes 5",
+			Info{Depth: 24, Score: Score{CP: 35}, WDL: WDL{102, 860, 38}, Nodes: 5},
+		},
+		{
+			"info depth 3 string hello   world",
+			Info{Depth: 3, String: "hello   world"},
+		},
+		{
+			"info depth 3\tstring hello\tworld",
+			Info{Depth: 3, String: "hello\tworld"},
+		},
+		{
+			"info depth 3 foo stringy string hi",
+			Info{Depth: 3, String: "hi"},
+		},
+		{
+			"info string",
+			Info{},
+		},
+	}
+	for i, c := range cases {
+		got, err := ParseInfo(c.in)
+		if err != nil {
+			t.Errorf("#%d: ParseInfo: %v", i, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Errorf("#%d: mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestParseInfo_Error(t *testing.T) {
+	cases := []string{
+		"",
+		"bestmove e2e4",
+		"info depth",
+		"info depth x",
+		"info score cp",
+		"info pv e2e4 e7e9",
+		"info currmove castle",
+		"info wdl 100 900",
+		"info wdl 100 x 0",
+		"info nodes 9223372036854775808",
+		"info time 9223372036855",
+	}
+	for i, c := range cases {
+		if _, err := ParseInfo(c); err == nil {
+			t.Errorf("#%d: ParseInfo(%q): want error", i, c)
+		}
+	}
+}
+
+func TestParseInfo_Extremes(t *testing.T) {
+	// Counters beyond 32 bits, and the longest time a time.Duration holds.
+	line := "info depth 99 nodes 9223372036854775807 nps 4294967296 tbhits 5000000000 time 9223372036854"
+	want := Info{
+		Depth:  99,
+		Nodes:  math.MaxInt64,
+		NPS:    1 << 32,
+		TBHits: 5000000000,
+		Time:   9223372036854 * time.Millisecond,
+	}
+	got, err := ParseInfo(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if got := FormatInfo(want); got != "info depth 99 nodes 9223372036854775807 nps 4294967296 tbhits 5000000000 time 9223372036854" {
+		t.Errorf("FormatInfo: got %q", got)
+	}
+}
+
+func TestFormatInfo(t *testing.T) {
+	cases := []struct {
+		in   Info
+		want string
+	}{
+		{Info{}, "info"},
+		{
+			Info{Depth: 5, SelDepth: 7, MultiPV: 1, Nodes: 1000, NPS: 50000, Time: 20 * time.Millisecond, PV: moves("e2e4", "e7e5")},
+			"info depth 5 seldepth 7 multipv 1 score cp 0 nodes 1000 nps 50000 time 20 pv e2e4 e7e5",
+		},
+		{
+			Info{Score: Score{CP: -30, UpperBound: true}},
+			"info score cp -30 upperbound",
+		},
+		{
+			Info{Score: Score{CP: 12}, WDL: WDL{Win: 40, Draw: 950, Loss: 10}, Nodes: 8},
+			"info score cp 12 wdl 40 950 10 nodes 8",
+		},
+		{
+			Info{CurrMove: move("g1f3"), CurrMoveNumber: 2},
+			"info currmove g1f3 currmovenumber 2",
+		},
+		{
+			Info{Depth: 1, String: "hello world"},
+			"info depth 1 string hello world",
+		},
+	}
+	for i, c := range cases {
+		if got := FormatInfo(c.in); c.want != got {
+			t.Errorf("#%d: want %q, got %q", i, c.want, got)
+		}
+	}
+}
+
+func TestFormatInfo_RoundTrip(t *testing.T) {
+	for _, s := range infocorpus.Samples() {
+		info, err := ParseInfo(s.Line)
+		if err != nil {
+			continue
+		}
+		back, err := ParseInfo(FormatInfo(info))
+		if err != nil {
+			t.Errorf("%s: ParseInfo(FormatInfo(%q)): %v", s.Engine, s.Line, err)
+			continue
+		}
+		if diff := cmp.Diff(info, back); diff != "" {
+			t.Errorf("%s: %q: round trip mismatch (-want +got):\n%s", s.Engine, s.Line, diff)
+		}
+	}
+}
+
+func TestBestMove_String(t *testing.T) {
+	if want, got := "bestmove e2e4", (BestMove{Move: move("e2e4")}).String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if want, got := "bestmove e2e4 ponder e7e5", (BestMove{Move: move("e2e4"), Ponder: move("e7e5")}).String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestParseBestMove(t *testing.T) {
+	cases := []struct {
+		in   string
+		want BestMove
+	}{
+		{"bestmove e2e4", BestMove{Move: move("e2e4")}},
+		{"bestmove e2e4 ponder e7e5", BestMove{Move: move("e2e4"), Ponder: move("e7e5")}},
+		{"bestmove (none)", BestMove{}},
+		{"bestmove 0000", BestMove{}},
+		{"bestmove e1g1 ponder (none)", BestMove{Move: move("e1g1")}},
+	}
+	for i, c := range cases {
+		got, err := parseBestMove(c.in)
+		if err != nil {
+			t.Errorf("#%d: parseBestMove: %v", i, err)
+			continue
+		}
+		if c.want != got {
+			t.Errorf("#%d: want %+v, got %+v", i, c.want, got)
+		}
+	}
+}
+
+func TestParseInfo_Corpus(t *testing.T) {
+	for _, s := range infocorpus.Samples() {
+		if _, err := ParseInfo(s.Line); err != nil {
+			t.Errorf("%s: ParseInfo(%q): %v", s.Engine, s.Line, err)
+		}
+	}
+}
+
+func BenchmarkParseInfo(b *testing.B) {
+	samples := infocorpus.Samples()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, s := range samples {
+			ParseInfo(s.Line)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package infocorpus provides a corpus of "info" lines in the formats emitted
+// by popular UCI engines, for testing and benchmarking info line parsers.
+//
+// Programs that encounter engines with unusual output can add their own lines
+// with Register, so that tests built on the corpus cover them too.
+package infocorpus
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed testdata/*.txt
+var files embed.FS
+
+// Sample is a single info line emitted by an engine.
+type Sample struct {
+	Engine string // The name of the engine, such as "stockfish".
+	Line   string // The raw info line, without a trailing newline.
+}
+
+var (
+	mu         sync.Mutex
+	registered []Sample
+)
+
+// Register adds lines emitted by the named engine to the corpus.
+func Register(engine string, lines ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, line := range lines {
+		registered = append(registered, Sample{Engine: engine, Line: line})
+	}
+}
+
+// Samples returns every sample in the corpus: the built-in samples, ordered by
+// engine, followed by registered samples in the order they were registered.
+func Samples() []Sample {
+	res := builtin()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return append(res, registered...)
+}
+
+// Engines returns the names of the engines in the corpus, sorted.
+func Engines() []string {
+	seen := make(map[string]bool)
+	for _, s := range Samples() {
+		seen[s.Engine] = true
+	}
+	res := make([]string, 0, len(seen))
+	for e := range seen {
+		res = append(res, e)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func builtin() []Sample {
+	names, err := files.ReadDir("testdata")
+	if err != nil {
+		panic(err) // The embedded directory always exists.
+	}
+
+	var res []Sample
+	for _, n := range names { // ReadDir sorts by name.
+		data, err := files.ReadFile(path.Join("testdata", n.Name()))
+		if err != nil {
+			panic(err)
+		}
+		engine := strings.TrimSuffix(n.Name(), ".txt")
+		s := bufio.NewScanner(bytes.NewReader(data))
+		for s.Scan() {
+			if s.Text() != "" {
+				res = append(res, Sample{Engine: engine, Line: s.Text()})
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,36 @@
+package infocorpus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSamples(t *testing.T) {
+	for _, s := range Samples() {
+		if !strings.HasPrefix(s.Line, "info") {
+			t.Errorf("%s: not an info line: %q", s.Engine, s.Line)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	before := Samples()
+	Register("custom", "info depth 1 nodes 2", "info string hi")
+	after := Samples()
+
+	var got []string
+	for _, s := range after[len(before):] {
+		got = append(got, s.Engine+": "+s.Line)
+	}
+	if want := "custom: info depth 1 nodes 2|custom: info string hi"; strings.Join(got, "|") != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	var found bool
+	for _, e := range Engines() {
+		found = found || e == "custom"
+	}
+	if !found {
+		t.Errorf("Engines: %q is missing", "custom")
+	}
+}
@@ -0,0 +1,460 @@
+package uci
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/clfs/chess"
+)
+
+// The JSON encodings of the types in this package are stable. Field names
+// follow the keywords of the protocol, moves are strings in long algebraic
+// notation, durations are integers in milliseconds, and zero values are
+// omitted.
+
+// millis converts d to whole milliseconds.
+func millis(d time.Duration) int64 {
+	return d.Milliseconds()
+}
+
+// fromMillis converts whole milliseconds to a duration.
+func fromMillis(ms int64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+// moveString returns m as a string, or "" for the null move.
+func moveString(m chess.Move) string {
+	if m == (chess.Move{}) {
+		return ""
+	}
+	return m.String()
+}
+
+// parseMoveString is the inverse of moveString.
+func parseMoveString(s string) (chess.Move, error) {
+	if s == "" {
+		return chess.Move{}, nil
+	}
+	return chess.ParseMove(s)
+}
+
+// scoreJSON is the JSON form of a Score. Exactly one of CP and Mate is set.
+type scoreJSON struct {
+	CP         *int `json:"cp,omitempty"`
+	Mate       *int `json:"mate,omitempty"`
+	LowerBound bool `json:"lowerbound,omitempty"`
+	UpperBound bool `json:"upperbound,omitempty"`
+}
+
+// MarshalJSON encodes the score as {"cp": 31} or {"mate": -3}, with
+// "lowerbound" or "upperbound" set to true for bounds.
+func (s Score) MarshalJSON() ([]byte, error) {
+	j := scoreJSON{LowerBound: s.LowerBound, UpperBound: s.UpperBound}
+	if s.Mate.Found {
+		j.Mate = &s.Mate.MovesUntil
+	} else {
+		j.CP = &s.CP
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes a score encoded by MarshalJSON.
+func (s *Score) UnmarshalJSON(data []byte) error {
+	var j scoreJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	var v Score
+	switch {
+	case j.CP != nil && j.Mate != nil:
+		return fmt.Errorf("uci: score has both cp and mate")
+	case j.Mate != nil:
+		v.Mate.Found = true
+		v.Mate.MovesUntil = *j.Mate
+	case j.CP != nil:
+		v.CP = *j.CP
+	}
+	v.LowerBound, v.UpperBound = j.LowerBound, j.UpperBound
+	*s = v
+	return nil
+}
+
+// infoJSON is the JSON form of an Info.
+type infoJSON struct {
+	Depth          int          `json:"depth,omitempty"`
+	SelDepth       int          `json:"seldepth,omitempty"`
+	Time           int64        `json:"time,omitempty"`
+	Nodes          int64        `json:"nodes,omitempty"`
+	PV             []chess.Move `json:"pv,omitempty"`
+	MultiPV        int          `json:"multipv,omitempty"`
+	Score          *Score       `json:"score,omitempty"`
+	WDL            *WDL         `json:"wdl,omitempty"`
+	CurrMove       string       `json:"currmove,omitempty"`
+	CurrMoveNumber int          `json:"currmovenumber,omitempty"`
+	HashFull       int          `json:"hashfull,omitempty"`
+	NPS            int64        `json:"nps,omitempty"`
+	TBHits         int64        `json:"tbhits,omitempty"`
+	CPULoad        int          `json:"cpuload,omitempty"`
+	String         string       `json:"string,omitempty"`
+	Refutation     []chess.Move `json:"refutation,omitempty"`
+	CurrLine       []chess.Move `json:"currline,omitempty"`
+}
+
+// MarshalJSON encodes the information as an object with the fields of an
+// "info" line, such as {"depth": 20, "score": {"cp": 31}, "pv": ["e2e4"]}.
+// A zero score or WDL is omitted.
+func (i Info) MarshalJSON() ([]byte, error) {
+	j := infoJSON{
+		Depth:          i.Depth,
+		SelDepth:       i.SelDepth,
+		Time:           millis(i.Time),
+		Nodes:          i.Nodes,
+		PV:             i.PV,
+		MultiPV:        i.MultiPV,
+		CurrMove:       moveString(i.CurrMove),
+		CurrMoveNumber: i.CurrMoveNumber,
+		HashFull:       i.HashFull,
+		NPS:            i.NPS,
+		TBHits:         i.TBHits,
+		CPULoad:        i.CPULoad,
+		String:         i.String,
+		Refutation:     i.Refutation,
+		CurrLine:       i.CurrLine,
+	}
+	if i.Score != (Score{}) {
+		j.Score = &i.Score
+	}
+	if i.WDL != (WDL{}) {
+		j.WDL = &i.WDL
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes information encoded by MarshalJSON.
+func (i *Info) UnmarshalJSON(data []byte) error {
+	var j infoJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	curr, err := parseMoveString(j.CurrMove)
+	if err != nil {
+		return err
+	}
+	v := Info{
+		Depth:          j.Depth,
+		SelDepth:       j.SelDepth,
+		Time:           fromMillis(j.Time),
+		Nodes:          j.Nodes,
+		PV:             j.PV,
+		MultiPV:        j.MultiPV,
+		CurrMove:       curr,
+		CurrMoveNumber: j.CurrMoveNumber,
+		HashFull:       j.HashFull,
+		NPS:            j.NPS,
+		TBHits:         j.TBHits,
+		CPULoad:        j.CPULoad,
+		String:         j.String,
+		Refutation:     j.Refutation,
+		CurrLine:       j.CurrLine,
+	}
+	if j.Score != nil {
+		v.Score = *j.Score
+	}
+	if j.WDL != nil {
+		v.WDL = *j.WDL
+	}
+	*i = v
+	return nil
+}
+
+// bestMoveJSON is the JSON form of a BestMove.
+type bestMoveJSON struct {
+	Move   string `json:"move,omitempty"`
+	Ponder string `json:"ponder,omitempty"`
+}
+
+// MarshalJSON encodes the best move as {"move": "e2e4", "ponder": "e7e5"}.
+// Null moves are omitted.
+func (bm BestMove) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bestMoveJSON{Move: moveString(bm.Move), Ponder: moveString(bm.Ponder)})
+}
+
+// UnmarshalJSON decodes a best move encoded by MarshalJSON.
+func (bm *BestMove) UnmarshalJSON(data []byte) error {
+	var j bestMoveJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	m, err := parseMoveString(j.Move)
+	if err != nil {
+		return err
+	}
+	p, err := parseMoveString(j.Ponder)
+	if err != nil {
+		return err
+	}
+	*bm = BestMove{Move: m, Ponder: p}
+	return nil
+}
+
+// searchJSON is the JSON form of a Search.
+type searchJSON struct {
+	SearchMoves []chess.Move `json:"searchmoves,omitempty"`
+	Ponder      bool         `json:"ponder,omitempty"`
+	Infinite    bool         `json:"infinite,omitempty"`
+	Mate        int          `json:"mate,omitempty"`
+	MoveTime    int64        `json:"movetime,omitempty"`
+	WTime       int64        `json:"wtime,omitempty"`
+	BTime       int64        `json:"btime,omitempty"`
+	WInc        int64        `json:"winc,omitempty"`
+	BInc        int64        `json:"binc,omitempty"`
+	MovesToGo   int          `json:"movestogo,omitempty"`
+	Depth       int          `json:"depth,omitempty"`
+	Nodes       int64        `json:"nodes,omitempty"`
+}
+
+// MarshalJSON encodes the limits as an object with the fields of a "go"
+// command, such as {"wtime": 60000, "btime": 60000, "movestogo": 40}.
+func (s Search) MarshalJSON() ([]byte, error) {
+	return json.Marshal(searchJSON{
+		SearchMoves: s.SearchMoves,
+		Ponder:      s.Ponder,
+		Infinite:    s.Infinite,
+		Mate:        s.Mate,
+		MoveTime:    millis(s.MoveTime),
+		WTime:       millis(s.WhiteTime),
+		BTime:       millis(s.BlackTime),
+		WInc:        millis(s.WhiteIncrement),
+		BInc:        millis(s.BlackIncrement),
+		MovesToGo:   s.MovesToGo,
+		Depth:       s.Depth,
+		Nodes:       s.Nodes,
+	})
+}
+
+// UnmarshalJSON decodes limits encoded by MarshalJSON.
+func (s *Search) UnmarshalJSON(data []byte) error {
+	var j searchJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*s = Search{
+		SearchMoves:    j.SearchMoves,
+		Ponder:         j.Ponder,
+		Infinite:       j.Infinite,
+		Mate:           j.Mate,
+		MoveTime:       fromMillis(j.MoveTime),
+		WhiteTime:      fromMillis(j.WTime),
+		BlackTime:      fromMillis(j.BTime),
+		WhiteIncrement: fromMillis(j.WInc),
+		BlackIncrement: fromMillis(j.BInc),
+		MovesToGo:      j.MovesToGo,
+		Depth:          j.Depth,
+		Nodes:          j.Nodes,
+	}
+	return nil
+}
+
+// optionJSON is the JSON form of an Option. Default holds a bool, an integer or
+// a string, depending on the type.
+type optionJSON struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+	Min     *int            `json:"min,omitempty"`
+	Max     *int            `json:"max,omitempty"`
+	Vars    []string        `json:"vars,omitempty"`
+}
+
+func marshalOption(name, typ string, def any, o optionJSON) ([]byte, error) {
+	o.Name, o.Type = name, typ
+	if def != nil {
+		b, err := json.Marshal(def)
+		if err != nil {
+			return nil, err
+		}
+		o.Default = b
+	}
+	return json.Marshal(o)
+}
+
+// decodeOption decodes the JSON form of an option of type typ, and its default
+// into def, if it has one.
+func decodeOption(data []byte, typ string, def any) (optionJSON, error) {
+	var o optionJSON
+	if err := json.Unmarshal(data, &o); err != nil {
+		return o, err
+	}
+	if o.Type != typ {
+		return o, fmt.Errorf("uci: option %q has type %q, want %q", o.Name, o.Type, typ)
+	}
+	if def != nil && o.Default != nil {
+		if err := json.Unmarshal(o.Default, def); err != nil {
+			return o, fmt.Errorf("uci: invalid default for option %q: %w", o.Name, err)
+		}
+	}
+	return o, nil
+}
+
+// MarshalJSON encodes the option as {"name": ..., "type": "check",
+// "default": false}.
+func (o CheckOption) MarshalJSON() ([]byte, error) {
+	return marshalOption(o.Name, CheckOptionType, o.Default, optionJSON{})
+}
+
+// UnmarshalJSON decodes an option encoded by MarshalJSON.
+func (o *CheckOption) UnmarshalJSON(data []byte) error {
+	var v CheckOption
+	j, err := decodeOption(data, CheckOptionType, &v.Default)
+	if err != nil {
+		return err
+	}
+	v.Name = j.Name
+	*o = v
+	return nil
+}
+
+// MarshalJSON encodes the option as {"name": ..., "type": "spin",
+// "default": 16, "min": 1, "max": 1024}.
+func (o SpinOption) MarshalJSON() ([]byte, error) {
+	return marshalOption(o.Name, SpinOptionType, o.Default, optionJSON{Min: &o.Min, Max: &o.Max})
+}
+
+// UnmarshalJSON decodes an option encoded by MarshalJSON.
+func (o *SpinOption) UnmarshalJSON(data []byte) error {
+	var v SpinOption
+	j, err := decodeOption(data, SpinOptionType, &v.Default)
+	if err != nil {
+		return err
+	}
+	if j.Min == nil || j.Max == nil {
+		return fmt.Errorf("uci: option %q has no min or max", j.Name)
+	}
+	v.Name, v.Min, v.Max = j.Name, *j.Min, *j.Max
+	*o = v
+	return nil
+}
+
+// MarshalJSON encodes the option as {"name": ..., "type": "combo",
+// "default": "Normal", "vars": ["Solid", "Normal"]}.
+func (o ComboOption) MarshalJSON() ([]byte, error) {
+	return marshalOption(o.Name, ComboOptionType, o.Default, optionJSON{Vars: o.Vars})
+}
+
+// UnmarshalJSON decodes an option encoded by MarshalJSON.
+func (o *ComboOption) UnmarshalJSON(data []byte) error {
+	var v ComboOption
+	j, err := decodeOption(data, ComboOptionType, &v.Default)
+	if err != nil {
+		return err
+	}
+	v.Name, v.Vars = j.Name, j.Vars
+	*o = v
+	return nil
+}
+
+// MarshalJSON encodes the option as {"name": ..., "type": "button"}.
+func (o ButtonOption) MarshalJSON() ([]byte, error) {
+	return marshalOption(o.Name, ButtonOptionType, nil, optionJSON{})
+}
+
+// UnmarshalJSON decodes an option encoded by MarshalJSON.
+func (o *ButtonOption) UnmarshalJSON(data []byte) error {
+	j, err := decodeOption(data, ButtonOptionType, nil)
+	if err != nil {
+		return err
+	}
+	*o = ButtonOption{Name: j.Name}
+	return nil
+}
+
+// MarshalJSON encodes the option as {"name": ..., "type": "string",
+// "default": "<empty>"}.
+func (o StringOption) MarshalJSON() ([]byte, error) {
+	return marshalOption(o.Name, StringOptionType, o.Default, optionJSON{})
+}
+
+// UnmarshalJSON decodes an option encoded by MarshalJSON.
+func (o *StringOption) UnmarshalJSON(data []byte) error {
+	var v StringOption
+	j, err := decodeOption(data, StringOptionType, &v.Default)
+	if err != nil {
+		return err
+	}
+	v.Name = j.Name
+	*o = v
+	return nil
+}
+
+// UnmarshalOption decodes an option encoded by the MarshalJSON method of any
+// option type, returning an option of the appropriate type. It is the JSON
+// counterpart of ParseOption.
+func UnmarshalOption(data []byte) (Option, error) {
+	var j struct{ Type string }
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	switch j.Type {
+	case CheckOptionType:
+		var o CheckOption
+		err := o.UnmarshalJSON(data)
+		return o, err
+	case SpinOptionType:
+		var o SpinOption
+		err := o.UnmarshalJSON(data)
+		return o, err
+	case ComboOptionType:
+		var o ComboOption
+		err := o.UnmarshalJSON(data)
+		return o, err
+	case ButtonOptionType:
+		var o ButtonOption
+		err := o.UnmarshalJSON(data)
+		return o, err
+	case StringOptionType:
+		var o StringOption
+		err := o.UnmarshalJSON(data)
+		return o, err
+	}
+	return nil, fmt.Errorf("uci: option has unknown type %q", j.Type)
+}
+
+// optionSnapshotJSON is the JSON form of an OptionSnapshot.
+type optionSnapshotJSON struct {
+	Name    string            `json:"name,omitempty"`
+	Author  string            `json:"author,omitempty"`
+	Options []json.RawMessage `json:"options"`
+}
+
+// MarshalJSON encodes the snapshot as {"name": ..., "author": ...,
+// "options": [...]}, with the options encoded by their MarshalJSON methods.
+func (s OptionSnapshot) MarshalJSON() ([]byte, error) {
+	j := optionSnapshotJSON{Name: s.Name, Author: s.Author, Options: []json.RawMessage{}}
+	for _, o := range s.Options {
+		b, err := json.Marshal(o)
+		if err != nil {
+			return nil, err
+		}
+		j.Options = append(j.Options, b)
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes a snapshot encoded by MarshalJSON.
+func (s *OptionSnapshot) UnmarshalJSON(data []byte) error {
+	var j optionSnapshotJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	v := OptionSnapshot{Name: j.Name, Author: j.Author}
+	for _, raw := range j.Options {
+		o, err := UnmarshalOption(raw)
+		if err != nil {
+			return err
+		}
+		v.Options = append(v.Options, o)
+	}
+	*s = v
+	return nil
+}
@@ -0,0 +1,157 @@
+package uci
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testJSON checks that v encodes to want, and decodes back to v.
+func testJSON[T any](t *testing.T, v T, want string) {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal(%+v): %v", v, err)
+	}
+	if string(b) != want {
+		t.Errorf("Marshal(%+v):\nwant %s\ngot  %s", v, want, b)
+	}
+	var got T
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal(%s): %v", b, err)
+	}
+	if diff := cmp.Diff(v, got); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestScore_JSON(t *testing.T) {
+	var mate, mated Score
+	mate.Mate.Found, mate.Mate.MovesUntil = true, 3
+	mated.Mate.Found, mated.Mate.MovesUntil = true, 0
+	mated.UpperBound = true
+
+	testJSON(t, Score{CP: 31}, `{"cp":31}`)
+	testJSON(t, Score{}, `{"cp":0}`)
+	testJSON(t, Score{CP: -12, LowerBound: true}, `{"cp":-12,"lowerbound":true}`)
+	testJSON(t, mate, `{"mate":3}`)
+	testJSON(t, mated, `{"mate":0,"upperbound":true}`)
+
+	if err := json.Unmarshal([]byte(`{"cp":1,"mate":2}`), new(Score)); err == nil {
+		t.Error("want error for a score with both cp and mate")
+	}
+}
+
+func TestInfo_JSON(t *testing.T) {
+	info := Info{
+		Depth:    20,
+		SelDepth: 28,
+		Time:     998 * time.Millisecond,
+		Nodes:    1201432,
+		PV:       moves("e2e4", "e7e5"),
+		MultiPV:  1,
+		Score:    Score{CP: 31},
+		CurrMove: move("e2e4"),
+		NPS:      1204043,
+	}
+	testJSON(t, info, `{"depth":20,"seldepth":28,"time":998,"nodes":1201432,"pv":["e2e4","e7e5"],"multipv":1,"score":{"cp":31},"currmove":"e2e4","nps":1204043}`)
+	testJSON(t, Info{String: "hello"}, `{"string":"hello"}`)
+	testJSON(t, Info{WDL: WDL{Win: 5, Draw: 990, Loss: 5}}, `{"wdl":{"win":5,"draw":990,"loss":5}}`)
+
+	for _, data := range []string{`{"pv":["e2e9"]}`, `{"currmove":"x"}`, `{"score":{"cp":"1"}}`} {
+		if err := json.Unmarshal([]byte(data), new(Info)); err == nil {
+			t.Errorf("Unmarshal(%s): want error", data)
+		}
+	}
+}
+
+func TestBestMove_JSON(t *testing.T) {
+	testJSON(t, BestMove{Move: move("e2e4"), Ponder: move("e7e5")}, `{"move":"e2e4","ponder":"e7e5"}`)
+	testJSON(t, BestMove{Move: move("e7e8q")}, `{"move":"e7e8q"}`)
+	testJSON(t, BestMove{}, `{}`)
+}
+
+func TestSearch_JSON(t *testing.T) {
+	s := Search{
+		SearchMoves: moves("e2e4"),
+		WhiteTime:   time.Minute, BlackTime: 59500 * time.Millisecond,
+		WhiteIncrement: time.Second, BlackIncrement: time.Second,
+		MovesToGo: 40,
+	}
+	testJSON(t, s, `{"searchmoves":["e2e4"],"wtime":60000,"btime":59500,"winc":1000,"binc":1000,"movestogo":40}`)
+	testJSON(t, Search{Infinite: true}, `{"infinite":true}`)
+	testJSON(t, Search{Ponder: true, Mate: 3, MoveTime: 5 * time.Second, Depth: 10, Nodes: 1000}, `{"ponder":true,"mate":3,"movetime":5000,"depth":10,"nodes":1000}`)
+}
+
+func TestOption_JSON(t *testing.T) {
+	cases := []struct {
+		opt  Option
+		want string
+	}{
+		{CheckOption{Name: "Ponder", Default: true}, `{"name":"Ponder","type":"check","default":true}`},
+		{SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 1024}, `{"name":"Hash","type":"spin","default":16,"min":1,"max":1024}`},
+		{SpinOption{Name: "Contempt", Min: -100, Max: 100}, `{"name":"Contempt","type":"spin","default":0,"min":-100,"max":100}`},
+		{ComboOption{Name: "Style", Default: "Normal", Vars: []string{"Solid", "Normal"}}, `{"name":"Style","type":"combo","default":"Normal","vars":["Solid","Normal"]}`},
+		{ButtonOption{Name: "Clear Hash"}, `{"name":"Clear Hash","type":"button"}`},
+		{StringOption{Name: "BookFile", Default: "book.bin"}, `{"name":"BookFile","type":"string","default":"book.bin"}`},
+	}
+	for _, tc := range cases {
+		b, err := json.Marshal(tc.opt)
+		if err != nil {
+			t.Fatalf("Marshal(%v): %v", tc.opt, err)
+		}
+		if string(b) != tc.want {
+			t.Errorf("Marshal(%v):\nwant %s\ngot  %s", tc.opt, tc.want, b)
+		}
+		got, err := UnmarshalOption(b)
+		if err != nil {
+			t.Fatalf("UnmarshalOption(%s): %v", b, err)
+		}
+		if diff := cmp.Diff(tc.opt, got); diff != "" {
+			t.Errorf("UnmarshalOption(%s) mismatch (-want +got):\n%s", b, diff)
+		}
+	}
+
+	// A list of options encodes as a list of objects.
+	b, err := json.Marshal([]Option{CheckOption{Name: "Ponder"}, ButtonOption{Name: "Clear Hash"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"name":"Ponder","type":"check","default":false},{"name":"Clear Hash","type":"button"}]`; string(b) != want {
+		t.Errorf("want %s, got %s", want, b)
+	}
+
+	for _, data := range []string{
+		`{"name":"Hash","type":"dial"}`,
+		`{"name":"Hash","type":"spin","default":16}`,
+		`{"name":"Ponder","type":"check","default":"yes"}`,
+		`[]`,
+	} {
+		if o, err := UnmarshalOption([]byte(data)); err == nil {
+			t.Errorf("UnmarshalOption(%s) = %v, want error", data, o)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"name":"Hash","type":"string"}`), new(SpinOption)); err == nil {
+		t.Error("want error decoding a string option as a spin option")
+	}
+}
+
+func TestOptionSnapshot_JSON(t *testing.T) {
+	testJSON(t, OptionSnapshot{
+		Name:   "Fake",
+		Author: "Someone",
+		Options: []Option{
+			SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 64},
+			ComboOption{Name: "Style", Default: "Normal", Vars: []string{"Solid", "Normal"}},
+			ButtonOption{Name: "Clear Hash"},
+		},
+	}, `{"name":"Fake","author":"Someone","options":[{"name":"Hash","type":"spin","default":16,"min":1,"max":64},{"name":"Style","type":"combo","default":"Normal","vars":["Solid","Normal"]},{"name":"Clear Hash","type":"button"}]}`)
+	testJSON(t, OptionSnapshot{}, `{"options":[]}`)
+
+	var s OptionSnapshot
+	if err := json.Unmarshal([]byte(`{"options":[{"name":"Hash","type":"dial"}]}`), &s); err == nil {
+		t.Error("want error decoding an option of unknown type")
+	}
+}
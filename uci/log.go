@@ -0,0 +1,57 @@
+package uci
+
+import "strings"
+
+// LogLine is a diagnostic message from the engine.
+type LogLine struct {
+	Text string
+
+	// Unknown is set for a whole line that isn't part of the protocol, such
+	// as a message from a neural network backend, rather than the text of an
+	// "info string" line.
+	Unknown bool
+}
+
+// engineCommands are the commands an engine may send.
+var engineCommands = map[string]bool{
+	"id":             true,
+	"uciok":          true,
+	"readyok":        true,
+	"bestmove":       true,
+	"copyprotection": true,
+	"registration":   true,
+	"info":           true,
+	"option":         true,
+}
+
+// OnLog registers f to be called with the engine's diagnostics: the text of
+// every "info string" line, whether or not a search is running, and every
+// non-empty line that doesn't start with a command of the protocol. Such lines
+// are otherwise ignored, except that "info string" lines during a search are
+// also reported as Info. Like OnReceive, functions are called in the order
+// they were registered, and must not call methods on c.
+func (c *Client) OnLog(f func(LogLine)) {
+	c.OnReceive(func(line string) {
+		if l, ok := parseLogLine(line); ok {
+			f(l)
+		}
+	})
+}
+
+// parseLogLine returns the diagnostic message in line, if any.
+func parseLogLine(line string) (LogLine, bool) {
+	fields := strings.Fields(line)
+	switch {
+	case len(fields) == 0:
+		return LogLine{}, false
+	case !engineCommands[fields[0]]:
+		return LogLine{Text: strings.TrimSpace(line), Unknown: true}, true
+	case fields[0] == "info":
+		info, err := ParseInfo(line)
+		if err != nil || info.String == "" {
+			return LogLine{}, false
+		}
+		return LogLine{Text: info.String}, true
+	}
+	return LogLine{}, false
+}
@@ -0,0 +1,34 @@
+package uci
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_OnLog(t *testing.T) {
+	in := `Loaded network weights from lc0.pb.gz
+info string NNUE evaluation enabled
+id name Foo
+
+info depth 1 score cp 10 pv e2e4 string Only move
+info depth 2 nodes x string Broken
+uciok
+`
+	c := NewClient(strings.NewReader(in), io.Discard)
+	var got []LogLine
+	c.OnLog(func(l LogLine) { got = append(got, l) })
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	want := []LogLine{
+		{Text: "Loaded network weights from lc0.pb.gz", Unknown: true},
+		{Text: "NNUE evaluation enabled"},
+		{Text: "Only move"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,249 @@
+// Package metrics collects runtime metrics of engines and engine pools, for
+// monitoring long-running services such as analysis servers.
+//
+// A Registry watches the protocol dialogue of engines for their search
+// statistics, counts their searches and crashes, and tracks the utilization of
+// pools. It publishes them through expvar, as a Var, and serves them in the
+// Prometheus text format:
+//
+//	r := metrics.NewRegistry()
+//	r.WatchProcess("stockfish-1", engine)
+//	expvar.Publish("engines", r)
+//	http.Handle("/metrics", r)
+package metrics
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/clfs/chess/uci"
+)
+
+// EngineStats are the metrics of an engine.
+type EngineStats struct {
+	// The statistics of the last "info" lines of the current or last search.
+	// Each is the last value the engine reported, or zero if it reported none.
+	NPS      int64 `json:"nps"`
+	Depth    int   `json:"depth"`
+	SelDepth int   `json:"seldepth"`
+	HashFull int   `json:"hashfull"` // In parts-per-thousand.
+	CPULoad  int   `json:"cpuload"`  // In parts-per-thousand.
+
+	Searching bool  `json:"searching"` // Whether a search is running.
+	Searches  int64 `json:"searches"`  // Searches finished.
+	Crashes   int64 `json:"crashes"`   // Processes that exited with an error.
+}
+
+// PoolStats are the metrics of a pool of engines.
+type PoolStats struct {
+	Size     int   `json:"size"`     // Engines in the pool.
+	InUse    int   `json:"in_use"`   // Engines handed out.
+	Acquired int64 `json:"acquired"` // Engines handed out in total.
+}
+
+// Utilization returns the fraction of the pool in use, from 0 to 1, or 0 for
+// an empty pool.
+func (s PoolStats) Utilization() float64 {
+	if s.Size <= 0 {
+		return 0
+	}
+	return float64(s.InUse) / float64(s.Size)
+}
+
+// Registry collects the metrics of engines and pools, by name. It is safe for
+// concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	engines map[string]*EngineStats
+	pools   map[string]*Pool
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{engines: map[string]*EngineStats{}, pools: map[string]*Pool{}}
+}
+
+// engine returns the metrics of the named engine, creating them if needed.
+// The caller must hold r.mu.
+func (r *Registry) engine(name string) *EngineStats {
+	s, ok := r.engines[name]
+	if !ok {
+		s = &EngineStats{}
+		r.engines[name] = s
+	}
+	return s
+}
+
+// Watch collects the metrics of c under name. It should be called before the
+// first search. Watching a new client under the name of an earlier one, such
+// as a replacement for an engine that crashed, resets the search statistics
+// but keeps the counts.
+func (r *Registry) Watch(name string, c *uci.Client) {
+	r.mu.Lock()
+	s := r.engine(name)
+	searches, crashes := s.Searches, s.Crashes
+	*s = EngineStats{Searches: searches, Crashes: crashes}
+	r.mu.Unlock()
+
+	c.OnSend(func(line string) {
+		if cmd, _, _ := strings.Cut(line, " "); cmd == "go" {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			s.Searching = true
+		}
+	})
+	c.OnReceive(func(line string) {
+		switch cmd, _, _ := strings.Cut(strings.TrimSpace(line), " "); cmd {
+		case "info":
+			info, err := uci.ParseInfo(line)
+			if err != nil {
+				return
+			}
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			s.update(info)
+		case "bestmove":
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if s.Searching {
+				s.Searching = false
+				s.Searches++
+			}
+		}
+	})
+}
+
+// update records the statistics in info, skipping those it lacks.
+func (s *EngineStats) update(info uci.Info) {
+	if info.NPS > 0 {
+		s.NPS = info.NPS
+	}
+	if info.Depth > 0 {
+		s.Depth = info.Depth
+	}
+	if info.SelDepth > 0 {
+		s.SelDepth = info.SelDepth
+	}
+	if info.HashFull > 0 {
+		s.HashFull = info.HashFull
+	}
+	if info.CPULoad > 0 {
+		s.CPULoad = info.CPULoad
+	}
+}
+
+// WatchProcess is like Watch, and also counts a crash if the engine process
+// exits with an error, as reported by e.Wait. That includes being killed, such
+// as by Close when the engine doesn't quit in time.
+func (r *Registry) WatchProcess(name string, e *uci.Engine) {
+	r.Watch(name, e.Client)
+	go func() {
+		if e.Wait() == nil {
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		s := r.engine(name)
+		s.Crashes++
+		s.Searching = false
+	}()
+}
+
+// Remove stops reporting the named engine. Its client is still watched, but
+// its metrics are no longer reported.
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.engines, name)
+}
+
+// Engines returns the metrics of every engine, by name.
+func (r *Registry) Engines() map[string]EngineStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make(map[string]EngineStats, len(r.engines))
+	for name, s := range r.engines {
+		res[name] = *s
+	}
+	return res
+}
+
+// Pool returns the named pool, creating it if needed.
+func (r *Registry) Pool(name string) *Pool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.pools[name]
+	if !ok {
+		p = &Pool{}
+		r.pools[name] = p
+	}
+	return p
+}
+
+// Pools returns the metrics of every pool, by name.
+func (r *Registry) Pools() map[string]PoolStats {
+	r.mu.Lock()
+	pools := make(map[string]*Pool, len(r.pools))
+	for name, p := range r.pools {
+		pools[name] = p
+	}
+	r.mu.Unlock()
+
+	res := make(map[string]PoolStats, len(pools))
+	for name, p := range pools {
+		res[name] = p.Stats()
+	}
+	return res
+}
+
+// String returns the metrics as a JSON object with "engines" and "pools"
+// objects keyed by name, so that r can be published with expvar.Publish.
+func (r *Registry) String() string {
+	b, err := json.Marshal(struct {
+		Engines map[string]EngineStats `json:"engines"`
+		Pools   map[string]PoolStats   `json:"pools"`
+	}{r.Engines(), r.Pools()})
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// Pool tracks the utilization of a pool of engines. Pools call Acquire and
+// Release as they hand out engines and take them back.
+type Pool struct {
+	mu    sync.Mutex
+	stats PoolStats
+}
+
+// SetSize sets the number of engines in the pool.
+func (p *Pool) SetSize(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Size = n
+}
+
+// Acquire records that an engine was handed out.
+func (p *Pool) Acquire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.InUse++
+	p.stats.Acquired++
+}
+
+// Release records that an engine was taken back.
+func (p *Pool) Release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stats.InUse > 0 {
+		p.stats.InUse--
+	}
+}
+
+// Stats returns the pool's metrics.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/uci"
+	"github.com/clfs/chess/uci/ucitest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegistry_Watch(t *testing.T) {
+	e := ucitest.New(t, ucitest.Script{
+		Name: "Fake",
+		Searches: []ucitest.Search{
+			{Raw: []string{
+				"info depth 10 seldepth 14 nodes 100000 nps 500000 hashfull 120 cpuload 990",
+				"info currmove e2e4 currmovenumber 1",
+				"info depth 11 seldepth 15 nodes 200000 nps 510000",
+			}},
+			{WaitStop: true},
+		},
+	})
+	c := e.Client()
+	r := NewRegistry()
+	r.Watch("fake", c)
+
+	infoCh, bestCh, err := c.Go(uci.Search{Depth: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range infoCh {
+	}
+	<-bestCh
+
+	want := EngineStats{NPS: 510000, Depth: 11, SelDepth: 15, HashFull: 120, CPULoad: 990, Searches: 1}
+	if diff := cmp.Diff(map[string]EngineStats{"fake": want}, r.Engines()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	infoCh, bestCh, err = c.GoContext(ctx, uci.Search{Infinite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Engines()["fake"].Searching {
+		t.Error("want searching during a search")
+	}
+	cancel()
+	for range infoCh {
+	}
+	<-bestCh
+	if s := r.Engines()["fake"]; s.Searching || s.Searches != 2 {
+		t.Errorf("got %+v after the second search, want 2 searches", s)
+	}
+
+	// A replacement keeps the counts.
+	r.Watch("fake", ucitest.New(t, ucitest.Script{}).Client())
+	if diff := cmp.Diff(EngineStats{Searches: 2}, r.Engines()["fake"]); diff != "" {
+		t.Errorf("mismatch after replacement (-want +got):\n%s", diff)
+	}
+
+	r.Remove("fake")
+	if len(r.Engines()) != 0 {
+		t.Errorf("got %v after Remove, want no engines", r.Engines())
+	}
+}
+
+func TestRegistry_WatchProcess(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell")
+	}
+	r := NewRegistry()
+	for name, script := range map[string]string{"ok": "exit 0", "crash": "exit 3"} {
+		e, err := uci.StartEngineCmd(exec.Command("sh", "-c", script))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.WatchProcess(name, e)
+		e.Wait()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Engines()["crash"].Crashes == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	got := r.Engines()
+	if got["crash"].Crashes != 1 {
+		t.Errorf("got %d crashes, want 1", got["crash"].Crashes)
+	}
+	if got["ok"].Crashes != 0 {
+		t.Errorf("got %d crashes for a clean exit, want 0", got["ok"].Crashes)
+	}
+}
+
+func TestPool(t *testing.T) {
+	r := NewRegistry()
+	p := r.Pool("analysis")
+	if r.Pool("analysis") != p {
+		t.Error("want the same pool for the same name")
+	}
+	p.SetSize(4)
+	p.Acquire()
+	p.Acquire()
+	p.Acquire()
+	p.Release()
+	p.Release()
+	p.Release()
+	p.Release()
+	p.Acquire()
+
+	want := PoolStats{Size: 4, InUse: 1, Acquired: 4}
+	if diff := cmp.Diff(map[string]PoolStats{"analysis": want}, r.Pools()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if got := want.Utilization(); got != 0.25 {
+		t.Errorf("got utilization %v, want 0.25", got)
+	}
+	if got := (PoolStats{}).Utilization(); got != 0 {
+		t.Errorf("got utilization %v for an empty pool, want 0", got)
+	}
+}
+
+func TestRegistry_Expvar(t *testing.T) {
+	r := NewRegistry()
+	r.Pool("p").SetSize(2)
+	r.Watch("e", ucitest.New(t, ucitest.Script{}).Client())
+
+	var _ expvar.Var = r
+	var got struct {
+		Engines map[string]EngineStats
+		Pools   map[string]PoolStats
+	}
+	if err := json.Unmarshal([]byte(r.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.Engines["e"]; !ok || got.Pools["p"].Size != 2 {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// metric is a metric in the Prometheus text format.
+type metric struct {
+	name, help, typ string
+}
+
+// The metrics of engines, labeled by engine name.
+var engineMetrics = []struct {
+	metric
+	value func(EngineStats) float64
+}{
+	{metric{"uci_engine_nps", "Nodes per second of the current or last search.", "gauge"}, func(s EngineStats) float64 { return float64(s.NPS) }},
+	{metric{"uci_engine_depth", "Depth of the current or last search.", "gauge"}, func(s EngineStats) float64 { return float64(s.Depth) }},
+	{metric{"uci_engine_seldepth", "Selective depth of the current or last search.", "gauge"}, func(s EngineStats) float64 { return float64(s.SelDepth) }},
+	{metric{"uci_engine_hashfull_ratio", "Fullness of the hash table.", "gauge"}, func(s EngineStats) float64 { return float64(s.HashFull) / 1000 }},
+	{metric{"uci_engine_cpu_load_ratio", "CPU load reported by the engine.", "gauge"}, func(s EngineStats) float64 { return float64(s.CPULoad) / 1000 }},
+	{metric{"uci_engine_searching", "Whether a search is running.", "gauge"}, func(s EngineStats) float64 { return boolValue(s.Searching) }},
+	{metric{"uci_engine_searches_total", "Searches finished.", "counter"}, func(s EngineStats) float64 { return float64(s.Searches) }},
+	{metric{"uci_engine_crashes_total", "Engine processes that exited with an error.", "counter"}, func(s EngineStats) float64 { return float64(s.Crashes) }},
+}
+
+// The metrics of pools, labeled by pool name.
+var poolMetrics = []struct {
+	metric
+	value func(PoolStats) float64
+}{
+	{metric{"uci_pool_size", "Engines in the pool.", "gauge"}, func(s PoolStats) float64 { return float64(s.Size) }},
+	{metric{"uci_pool_in_use", "Engines handed out.", "gauge"}, func(s PoolStats) float64 { return float64(s.InUse) }},
+	{metric{"uci_pool_utilization_ratio", "Fraction of the pool in use.", "gauge"}, func(s PoolStats) float64 { return s.Utilization() }},
+	{metric{"uci_pool_acquired_total", "Engines handed out in total.", "counter"}, func(s PoolStats) float64 { return float64(s.Acquired) }},
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format,
+// with engines labeled by "engine" and pools by "pool", as in
+//
+//	# HELP uci_engine_nps Nodes per second of the current or last search.
+//	# TYPE uci_engine_nps gauge
+//	uci_engine_nps{engine="stockfish-1"} 1.204043e+06
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	engines, pools := r.Engines(), r.Pools()
+	if len(engines) > 0 {
+		names := sortedKeys(engines)
+		for _, m := range engineMetrics {
+			writeHeader(bw, m.metric)
+			for _, name := range names {
+				fmt.Fprintf(bw, "%s{engine=%s} %g\n", m.name, quoteLabel(name), m.value(engines[name]))
+			}
+		}
+	}
+	if len(pools) > 0 {
+		names := sortedKeys(pools)
+		for _, m := range poolMetrics {
+			writeHeader(bw, m.metric)
+			for _, name := range names {
+				fmt.Fprintf(bw, "%s{pool=%s} %g\n", m.name, quoteLabel(name), m.value(pools[name]))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func writeHeader(w io.Writer, m metric) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+}
+
+// labelEscaper escapes label values, as the text format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WritePrometheus(w)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.engines["b"] = &EngineStats{NPS: 1204043, Depth: 20, HashFull: 500, Searching: true, Searches: 3}
+	r.engines[`a "1"`] = &EngineStats{Crashes: 2}
+	p := r.Pool("pool")
+	p.SetSize(2)
+	p.Acquire()
+
+	var b strings.Builder
+	if err := r.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP uci_engine_nps Nodes per second of the current or last search.
+# TYPE uci_engine_nps gauge
+uci_engine_nps{engine="a \"1\""} 0
+uci_engine_nps{engine="b"} 1.204043e+06
+# HELP uci_engine_depth Depth of the current or last search.
+# TYPE uci_engine_depth gauge
+uci_engine_depth{engine="a \"1\""} 0
+uci_engine_depth{engine="b"} 20
+# HELP uci_engine_seldepth Selective depth of the current or last search.
+# TYPE uci_engine_seldepth gauge
+uci_engine_seldepth{engine="a \"1\""} 0
+uci_engine_seldepth{engine="b"} 0
+# HELP uci_engine_hashfull_ratio Fullness of the hash table.
+# TYPE uci_engine_hashfull_ratio gauge
+uci_engine_hashfull_ratio{engine="a \"1\""} 0
+uci_engine_hashfull_ratio{engine="b"} 0.5
+# HELP uci_engine_cpu_load_ratio CPU load reported by the engine.
+# TYPE uci_engine_cpu_load_ratio gauge
+uci_engine_cpu_load_ratio{engine="a \"1\""} 0
+uci_engine_cpu_load_ratio{engine="b"} 0
+# HELP uci_engine_searching Whether a search is running.
+# TYPE uci_engine_searching gauge
+uci_engine_searching{engine="a \"1\""} 0
+uci_engine_searching{engine="b"} 1
+# HELP uci_engine_searches_total Searches finished.
+# TYPE uci_engine_searches_total counter
+uci_engine_searches_total{engine="a \"1\""} 0
+uci_engine_searches_total{engine="b"} 3
+# HELP uci_engine_crashes_total Engine processes that exited with an error.
+# TYPE uci_engine_crashes_total counter
+uci_engine_crashes_total{engine="a \"1\""} 2
+uci_engine_crashes_total{engine="b"} 0
+# HELP uci_pool_size Engines in the pool.
+# TYPE uci_pool_size gauge
+uci_pool_size{pool="pool"} 2
+# HELP uci_pool_in_use Engines handed out.
+# TYPE uci_pool_in_use gauge
+uci_pool_in_use{pool="pool"} 1
+# HELP uci_pool_utilization_ratio Fraction of the pool in use.
+# TYPE uci_pool_utilization_ratio gauge
+uci_pool_utilization_ratio{pool="pool"} 0.5
+# HELP uci_pool_acquired_total Engines handed out in total.
+# TYPE uci_pool_acquired_total counter
+uci_pool_acquired_total{pool="pool"} 1
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.Pool("pool").SetSize(1)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("got content type %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "uci_pool_size{pool=\"pool\"} 1\n") {
+		t.Errorf("got body:\n%s", rec.Body.String())
+	}
+}
+
+func TestRegistry_WritePrometheus_Empty(t *testing.T) {
+	var b strings.Builder
+	if err := NewRegistry().WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("got %q, want nothing", b.String())
+	}
+}
@@ -0,0 +1,89 @@
+package uci
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/clfs/chess"
+)
+
+// Line is one of the candidate lines found by a MultiPV search.
+type Line struct {
+	Move  chess.Move   // The first move of the line.
+	Score Score        // The score of the line.
+	PV    []chess.Move // The full line, starting with Move.
+	Depth int          // The search depth at which the line was found.
+}
+
+// TopMoves searches the position described by pos and returns up to n of the
+// best lines, best first. It sets the engine's "MultiPV" option to n, which is
+// left in place afterwards.
+//
+// The lines come from the deepest iteration that reported the most lines, so
+// that they can be compared with each other. Search information with bound
+// scores is ignored.
+func (c *Client) TopMoves(pos PositionParams, n int, limits Search) ([]Line, error) {
+	if n < 1 {
+		return nil, errors.New("uci: TopMoves needs at least one line")
+	}
+	if err := c.SetOption("MultiPV", strconv.Itoa(n)); err != nil {
+		return nil, err
+	}
+	if err := c.Position(pos); err != nil {
+		return nil, err
+	}
+	infoCh, bestCh, err := c.Go(limits)
+	if err != nil {
+		return nil, err
+	}
+
+	byDepth := make(map[int]map[int]Info) // Depth to MultiPV index to info.
+	for info := range infoCh {
+		if len(info.PV) == 0 || info.Score.LowerBound || info.Score.UpperBound {
+			continue
+		}
+		idx := info.MultiPV
+		if idx == 0 {
+			idx = 1
+		}
+		if idx > n {
+			continue
+		}
+		if byDepth[info.Depth] == nil {
+			byDepth[info.Depth] = make(map[int]Info)
+		}
+		byDepth[info.Depth][idx] = info
+	}
+	if _, ok := <-bestCh; !ok {
+		return nil, errors.New("uci: engine stopped without a best move")
+	}
+
+	best := -1
+	for depth, lines := range byDepth {
+		if best == -1 || len(lines) > len(byDepth[best]) || len(lines) == len(byDepth[best]) && depth > best {
+			best = depth
+		}
+	}
+	if best == -1 {
+		return nil, nil
+	}
+
+	idxs := make([]int, 0, len(byDepth[best]))
+	for idx := range byDepth[best] {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+
+	res := make([]Line, 0, len(idxs))
+	for _, idx := range idxs {
+		info := byDepth[best][idx]
+		res = append(res, Line{
+			Move:  info.PV[0],
+			Score: info.Score,
+			PV:    info.PV,
+			Depth: info.Depth,
+		})
+	}
+	return res, nil
+}
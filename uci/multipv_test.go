@@ -0,0 +1,45 @@
+package uci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_TopMoves(t *testing.T) {
+	var cmds []string
+	c := fakeEngine(t, func(cmd string) string {
+		cmds = append(cmds, cmd)
+		if cmd != "go depth 3" {
+			return ""
+		}
+		return "info depth 1 multipv 1 score cp 40 pv e2e4\n" +
+			"info depth 1 multipv 2 score cp 35 pv d2d4\n" +
+			"info depth 1 multipv 3 score cp 10 pv g1f3\n" +
+			"info depth 2 multipv 1 score cp 30 pv d2d4 d7d5\n" +
+			"info depth 2 multipv 2 score cp 25 pv e2e4 e7e5\n" +
+			"info depth 2 multipv 3 score cp 20 pv c2c4 e7e5\n" +
+			"info depth 3 multipv 1 score cp 45 lowerbound pv e2e4\n" +
+			"info depth 3 multipv 1 score cp 35 pv e2e4 c7c5 g1f3\n" +
+			"bestmove e2e4 ponder c7c5\n"
+	})
+
+	got, err := c.TopMoves(PositionParams{StartPos: true}, 3, Search{Depth: 3})
+	if err != nil {
+		t.Fatalf("TopMoves: %v", err)
+	}
+	want := []Line{
+		{Move: move("d2d4"), Score: Score{CP: 30}, PV: moves("d2d4", "d7d5"), Depth: 2},
+		{Move: move("e2e4"), Score: Score{CP: 25}, PV: moves("e2e4", "e7e5"), Depth: 2},
+		{Move: move("c2c4"), Score: Score{CP: 20}, PV: moves("c2c4", "e7e5"), Depth: 2},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	wantCmds := "setoption name MultiPV value 3|position startpos|go depth 3"
+	if got := strings.Join(cmds, "|"); wantCmds != got {
+		t.Errorf("commands: want %q, got %q", wantCmds, got)
+	}
+}
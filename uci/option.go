@@ -6,6 +6,7 @@ import (
 	"strings"
 )
 
+// Option types, as they appear in "option" lines.
 const (
 	CheckOptionType  = "check"  // A boolean option.
 	SpinOptionType   = "spin"   // An integer option in a certain range.
@@ -14,66 +15,398 @@ const (
 	StringOptionType = "string" // A string option.
 )
 
-// Option represents an option that engines can set.
-type Option struct {
-	Name    string
-	Type    string
-	Default string
-	Min     int
-	Max     int
-	Vars    []string
+// Option is an option that engines can set. It is implemented by CheckOption,
+// SpinOption, ComboOption, ButtonOption and StringOption.
+//
+// MarshalText returns the option as an "option" line, as sent by engines during
+// the "uci" handshake.
+type Option interface {
+	OptionName() string          // The name of the option.
+	Validate(value string) error // Reports whether value is a valid setting.
+	MarshalText() ([]byte, error)
 }
 
-func (o *Option) UnmarshalText(text []byte) error {
-	fields := strings.Fields(string(text))
-
-	if len(fields) < 5 {
-		return fmt.Errorf("todo")
+// ParseOption parses an "option" line sent by an engine, returning an option
+// of the appropriate type.
+func ParseOption(line string) (Option, error) {
+	l, err := parseOptionLine([]byte(line))
+	if err != nil {
+		return nil, err
 	}
+	switch l.typ {
+	case CheckOptionType:
+		var o CheckOption
+		err = o.fromLine(l)
+		return o, err
+	case SpinOptionType:
+		var o SpinOption
+		err = o.fromLine(l)
+		return o, err
+	case ComboOptionType:
+		var o ComboOption
+		err = o.fromLine(l)
+		return o, err
+	case ButtonOptionType:
+		var o ButtonOption
+		err = o.fromLine(l)
+		return o, err
+	case StringOptionType:
+		var o StringOption
+		err = o.fromLine(l)
+		return o, err
+	}
+	return nil, &ParseError{Line: l.line, Field: "type", Reason: "unknown value"}
+}
+
+// optionLine is the generic form of an "option" line.
+type optionLine struct {
+	line      string // The whole line.
+	name, typ string
+	def       string
+	min, max  string
+	vars      []string
+}
 
-	var pos int
+// optionKeywords are the keywords that may follow the type of an option, by
+// option type.
+var optionKeywords = map[string]map[string]bool{
+	CheckOptionType:  {"default": true},
+	SpinOptionType:   {"default": true, "min": true, "max": true},
+	ComboOptionType:  {"default": true, "var": true},
+	ButtonOptionType: {},
+	StringOptionType: {"default": true},
+}
+
+// token is a whitespace-separated word of a line, and its offsets in the line.
+type token struct {
+	text       string
+	start, end int
+}
 
-	if f := fields[pos]; string(f) != "option" {
-		return fmt.Errorf("todo")
+// tokenize splits line into tokens.
+func tokenize(line string) []token {
+	var toks []token
+	for i := 0; i < len(line); {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		start := i
+		for i < len(line) && !isSpace(line[i]) {
+			i++
+		}
+		if start < i {
+			toks = append(toks, token{line[start:i], start, i})
+		}
 	}
-	pos++
+	return toks
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
+
+// parseOptionLine splits an "option" line into its fields.
+//
+// Real engines send names that contain the word "type", and string or combo
+// values that contain spaces, so this is more involved than splitting on
+// keywords. The name extends up to the first "type" that is followed by a known
+// option type and then by a keyword valid for that type, or nothing. Values
+// extend up to the next keyword valid for the option type; string defaults
+// extend to the end of the line. An empty string value may be written as
+// "<empty>".
+func parseOptionLine(text []byte) (optionLine, error) {
+	var l optionLine
 
-	if f := fields[pos]; string(f) != "name" {
-		return fmt.Errorf("todo")
+	line := string(text)
+	l.line = line
+	toks := tokenize(line)
+
+	if len(toks) == 0 || toks[0].text != "option" {
+		return l, &ParseError{Line: line, Reason: "not an option line"}
+	}
+	if len(toks) < 2 || toks[1].text != "name" {
+		return l, &ParseError{Line: line, Field: "name", Reason: "missing value"}
 	}
-	pos++
 
-	var acc []string
-	for ; pos < len(fields); pos++ {
-		if string(fields[pos]) == "type" {
+	typePos := -1
+	for i := 3; i+1 < len(toks); i++ {
+		if toks[i].text != "type" {
+			continue
+		}
+		keywords, ok := optionKeywords[toks[i+1].text]
+		if !ok {
+			continue
+		}
+		if i+2 == len(toks) || keywords[toks[i+2].text] {
+			typePos = i
 			break
 		}
-		acc = append(acc, (fields[pos]))
 	}
-	o.Name = strings.Join(acc, " ")
+	if typePos == -1 {
+		return l, &ParseError{Line: line, Field: "type", Reason: "missing or unknown value"}
+	}
+
+	l.name = line[toks[2].start:toks[typePos-1].end]
+	l.typ = toks[typePos+1].text
+	keywords := optionKeywords[l.typ]
 
-	for ; pos < len(fields)-1; pos++ {
-		cur, nxt := fields[pos], fields[pos+1]
-		switch cur {
-		case "type":
-			o.Type = nxt
+	rest := toks[typePos+2:]
+	for i := 0; i < len(rest); {
+		key := rest[i].text
+		if !keywords[key] {
+			return l, &ParseError{Line: line, Field: key, Reason: "unexpected field"}
+		}
+		i++
+
+		// The value extends up to the next keyword.
+		j := i
+		if l.typ == StringOptionType {
+			j = len(rest)
+		} else {
+			for j < len(rest) && !keywords[rest[j].text] {
+				j++
+			}
+		}
+		var value string
+		if i < j {
+			value = line[rest[i].start:rest[j-1].end]
+		}
+		if value == "<empty>" {
+			value = ""
+		}
+		i = j
+
+		switch key {
 		case "default":
-			o.Default = nxt
+			l.def = value
 		case "min":
-			min, err := strconv.Atoi(nxt)
-			if err != nil {
-				return fmt.Errorf("todo")
-			}
-			o.Min = min
+			l.min = value
 		case "max":
-			max, err := strconv.Atoi(nxt)
-			if err != nil {
-				return fmt.Errorf("todo")
-			}
-			o.Max = max
+			l.max = value
 		case "var":
-			o.Vars = append(o.Vars, nxt)
+			l.vars = append(l.vars, value)
 		}
 	}
+	return l, nil
+}
+
+// unmarshalOption parses text into l and checks that it has the wanted type.
+func unmarshalOption(text []byte, typ string) (optionLine, error) {
+	l, err := parseOptionLine(text)
+	if err != nil {
+		return l, err
+	}
+	if l.typ != typ {
+		return l, fmt.Errorf("uci: option %q: want type %s, got %s", l.name, typ, l.typ)
+	}
+	return l, nil
+}
+
+// CheckOption is a boolean option.
+type CheckOption struct {
+	Name    string
+	Default bool
+}
+
+func (o CheckOption) OptionName() string { return o.Name }
+
+func (o CheckOption) Validate(value string) error {
+	if value != "true" && value != "false" {
+		return fmt.Errorf("uci: option %q: want true or false, got %q", o.Name, value)
+	}
 	return nil
 }
+
+func (o CheckOption) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("option name %s type check default %t", o.Name, o.Default)), nil
+}
+
+func (o *CheckOption) UnmarshalText(text []byte) error {
+	l, err := unmarshalOption(text, CheckOptionType)
+	if err != nil {
+		return err
+	}
+	return o.fromLine(l)
+}
+
+func (o *CheckOption) fromLine(l optionLine) error {
+	o.Name = l.name
+	switch l.def {
+	case "true":
+		o.Default = true
+	case "false", "":
+		o.Default = false
+	default:
+		return &ParseError{Line: l.line, Field: "default", Reason: "invalid value"}
+	}
+	return nil
+}
+
+// SpinOption is an integer option in a certain range.
+type SpinOption struct {
+	Name     string
+	Default  int
+	Min, Max int
+}
+
+func (o SpinOption) OptionName() string { return o.Name }
+
+func (o SpinOption) Validate(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("uci: option %q: want an integer, got %q", o.Name, value)
+	}
+	if n < o.Min || n > o.Max {
+		return fmt.Errorf("uci: option %q: %d is out of range [%d, %d]", o.Name, n, o.Min, o.Max)
+	}
+	return nil
+}
+
+func (o SpinOption) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("option name %s type spin default %d min %d max %d", o.Name, o.Default, o.Min, o.Max)), nil
+}
+
+func (o *SpinOption) UnmarshalText(text []byte) error {
+	l, err := unmarshalOption(text, SpinOptionType)
+	if err != nil {
+		return err
+	}
+	return o.fromLine(l)
+}
+
+func (o *SpinOption) fromLine(l optionLine) error {
+	o.Name = l.name
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *int
+	}{
+		{"default", l.def, &o.Default},
+		{"min", l.min, &o.Min},
+		{"max", l.max, &o.Max},
+	} {
+		if f.value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(f.value)
+		if err != nil {
+			return &ParseError{Line: l.line, Field: f.name, Reason: "invalid value", Err: err}
+		}
+		*f.dst = n
+	}
+	return nil
+}
+
+// ComboOption is a string option from a list of available strings.
+type ComboOption struct {
+	Name    string
+	Default string
+	Vars    []string
+}
+
+func (o ComboOption) OptionName() string { return o.Name }
+
+func (o ComboOption) Validate(value string) error {
+	for _, v := range o.Vars {
+		if strings.EqualFold(v, value) {
+			return nil
+		}
+	}
+	return fmt.Errorf("uci: option %q: %q is not one of %q", o.Name, value, o.Vars)
+}
+
+func (o ComboOption) MarshalText() ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "option name %s type combo default %s", o.Name, o.Default)
+	for _, v := range o.Vars {
+		fmt.Fprintf(&b, " var %s", v)
+	}
+	return []byte(b.String()), nil
+}
+
+func (o *ComboOption) UnmarshalText(text []byte) error {
+	l, err := unmarshalOption(text, ComboOptionType)
+	if err != nil {
+		return err
+	}
+	return o.fromLine(l)
+}
+
+func (o *ComboOption) fromLine(l optionLine) error {
+	o.Name = l.name
+	o.Default = l.def
+	o.Vars = l.vars
+	return nil
+}
+
+// ButtonOption is an option that causes an effect when set. It has no value.
+type ButtonOption struct {
+	Name string
+}
+
+func (o ButtonOption) OptionName() string { return o.Name }
+
+func (o ButtonOption) Validate(value string) error {
+	if value != "" {
+		return fmt.Errorf("uci: option %q: button options take no value", o.Name)
+	}
+	return nil
+}
+
+func (o ButtonOption) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("option name %s type button", o.Name)), nil
+}
+
+func (o *ButtonOption) UnmarshalText(text []byte) error {
+	l, err := unmarshalOption(text, ButtonOptionType)
+	if err != nil {
+		return err
+	}
+	return o.fromLine(l)
+}
+
+func (o *ButtonOption) fromLine(l optionLine) error {
+	o.Name = l.name
+	return nil
+}
+
+// StringOption is a string option.
+type StringOption struct {
+	Name    string
+	Default string
+}
+
+func (o StringOption) OptionName() string { return o.Name }
+
+func (o StringOption) Validate(value string) error { return nil }
+
+func (o StringOption) MarshalText() ([]byte, error) {
+	if o.Default == "" {
+		return []byte(fmt.Sprintf("option name %s type string default <empty>", o.Name)), nil
+	}
+	return []byte(fmt.Sprintf("option name %s type string default %s", o.Name, o.Default)), nil
+}
+
+func (o *StringOption) UnmarshalText(text []byte) error {
+	l, err := unmarshalOption(text, StringOptionType)
+	if err != nil {
+		return err
+	}
+	return o.fromLine(l)
+}
+
+func (o *StringOption) fromLine(l optionLine) error {
+	o.Name = l.name
+	o.Default = l.def
+	return nil
+}
+
+// lookupOption returns the option with the given name, ignoring case.
+func lookupOption(opts []Option, name string) (Option, bool) {
+	for _, o := range opts {
+		if strings.EqualFold(o.OptionName(), name) {
+			return o, true
+		}
+	}
+	return nil, false
+}
@@ -7,43 +7,177 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
-func TestOption_UnmarshalText(t *testing.T) {
+func TestParseOption(t *testing.T) {
 	cases := []struct {
-		in   []byte
+		in   string
 		want Option
 	}{
 		{
-			[]byte("option name WeightsFile type string default <autodiscover>"),
-			Option{Name: "WeightsFile", Type: StringOptionType, Default: "<autodiscover>"},
+			"option name WeightsFile type string default <autodiscover>",
+			StringOption{Name: "WeightsFile", Default: "<autodiscover>"},
+		},
+		{
+			"option name BackendOptions type string default",
+			StringOption{Name: "BackendOptions"},
+		},
+		{
+			"option name Move Overhead type spin default 10 min 0 max 5000",
+			SpinOption{Name: "Move Overhead", Default: 10, Min: 0, Max: 5000},
+		},
+		{
+			"option name HistoryFill type combo default fen_only var no var fen_only var always",
+			ComboOption{Name: "HistoryFill", Default: "fen_only", Vars: []string{"no", "fen_only", "always"}},
+		},
+		{
+			"option name Clear Hash type button",
+			ButtonOption{Name: "Clear Hash"},
+		},
+		{
+			"option name Ponder type check default false",
+			CheckOption{Name: "Ponder", Default: false},
+		},
+		{
+			"option name UCI_ShowWDL type check default true",
+			CheckOption{Name: "UCI_ShowWDL", Default: true},
 		},
 		{
-			[]byte("option name BackendOptions type string default"),
-			Option{Name: "BackendOptions", Type: StringOptionType},
+			"option name EvalFile type string default Eval File.nnue",
+			StringOption{Name: "EvalFile", Default: "Eval File.nnue"},
 		},
 		{
-			[]byte("option name Move Overhead type spin default 10 min 0 max 5000"),
-			Option{Name: "Move Overhead", Type: SpinOptionType, Default: "10", Min: 0, Max: 5000},
+			"option name Debug Log File type string default <empty>",
+			StringOption{Name: "Debug Log File"},
 		},
 		{
-			[]byte("option name HistoryFill type combo default fen_only var no var fen_only var always"),
-			Option{Name: "HistoryFill", Type: ComboOptionType, Default: "fen_only", Vars: []string{"no", "fen_only", "always"}},
+			"option name SyzygyPath type string default C:\\Program Files\\tb  var min",
+			StringOption{Name: "SyzygyPath", Default: "C:\\Program Files\\tb  var min"},
 		},
 		{
-			[]byte("option name Clear Hash type button"),
-			Option{Name: "Clear Hash", Type: ButtonOptionType},
+			"option name Piece type Values type spin default 100 min 0 max 1000",
+			SpinOption{Name: "Piece type Values", Default: 100, Min: 0, Max: 1000},
 		},
 		{
-			[]byte("option name Ponder type check default false"),
-			Option{Name: "Ponder", Type: CheckOptionType, Default: "false"},
+			"option name Analysis Contempt type combo default Both Sides var Off var White var Black var Both Sides",
+			ComboOption{Name: "Analysis Contempt", Default: "Both Sides", Vars: []string{"Off", "White", "Black", "Both Sides"}},
+		},
+		{
+			"option name Contempt type spin min -100 max 100 default -5",
+			SpinOption{Name: "Contempt", Default: -5, Min: -100, Max: 100},
 		},
 	}
 	for i, c := range cases {
-		var opt Option
-		if err := opt.UnmarshalText(c.in); err != nil {
-			t.Errorf("#%d: Option.UnmarshalText: %v", i, err)
+		got, err := ParseOption(c.in)
+		if err != nil {
+			t.Errorf("#%d: ParseOption: %v", i, err)
 		}
-		if diff := cmp.Diff(c.want, opt, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		if diff := cmp.Diff(c.want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
 			t.Errorf("#%d: mismatch (-want +got):\n%s", i, diff)
 		}
 	}
 }
+
+func TestParseOption_Error(t *testing.T) {
+	cases := []string{
+		"option name Foo type dial default 3",
+		"option name Hash type spin default big min 1 max 2",
+		"option name Ponder type check default maybe",
+		"id name Foo",
+		"option name type spin default 1",
+		"option Hash type spin default 1",
+		"option name Hash type spin default 1 min 1 max 2 var 3",
+	}
+	for i, c := range cases {
+		if _, err := ParseOption(c); err == nil {
+			t.Errorf("#%d: ParseOption(%q): want error", i, c)
+		}
+	}
+}
+
+func TestOption_MarshalText(t *testing.T) {
+	cases := []struct {
+		in   Option
+		want string
+	}{
+		{CheckOption{Name: "Ponder"}, "option name Ponder type check default false"},
+		{SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 33554432}, "option name Hash type spin default 16 min 1 max 33554432"},
+		{ComboOption{Name: "Style", Default: "Normal", Vars: []string{"Solid", "Normal"}}, "option name Style type combo default Normal var Solid var Normal"},
+		{ButtonOption{Name: "Clear Hash"}, "option name Clear Hash type button"},
+		{StringOption{Name: "SyzygyPath", Default: "/tb"}, "option name SyzygyPath type string default /tb"},
+		{StringOption{Name: "BackendOptions"}, "option name BackendOptions type string default <empty>"},
+	}
+	for i, c := range cases {
+		text, err := c.in.MarshalText()
+		if err != nil {
+			t.Errorf("#%d: MarshalText: %v", i, err)
+			continue
+		}
+		if got := string(text); c.want != got {
+			t.Errorf("#%d: want %q, got %q", i, c.want, got)
+		}
+
+		// The line must parse back to the same option.
+		back, err := ParseOption(string(text))
+		if err != nil {
+			t.Errorf("#%d: ParseOption: %v", i, err)
+			continue
+		}
+		if diff := cmp.Diff(c.in, back); diff != "" {
+			t.Errorf("#%d: round trip mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestOption_UnmarshalText(t *testing.T) {
+	var spin SpinOption
+	if err := spin.UnmarshalText([]byte("option name Threads type spin default 1 min 1 max 512")); err != nil {
+		t.Fatalf("SpinOption.UnmarshalText: %v", err)
+	}
+	if want := (SpinOption{Name: "Threads", Default: 1, Min: 1, Max: 512}); want != spin {
+		t.Errorf("want %+v, got %+v", want, spin)
+	}
+
+	var check CheckOption
+	if err := check.UnmarshalText([]byte("option name Threads type spin default 1 min 1 max 512")); err == nil {
+		t.Error("CheckOption.UnmarshalText of a spin option: want error")
+	}
+}
+
+func TestOption_Validate(t *testing.T) {
+	var (
+		check  = CheckOption{Name: "Ponder"}
+		spin   = SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 1024}
+		combo  = ComboOption{Name: "Style", Default: "Normal", Vars: []string{"Solid", "Normal", "Risky"}}
+		button = ButtonOption{Name: "Clear Hash"}
+		str    = StringOption{Name: "SyzygyPath"}
+	)
+	cases := []struct {
+		opt   Option
+		value string
+		ok    bool
+	}{
+		{check, "true", true},
+		{check, "false", true},
+		{check, "yes", false},
+		{spin, "1", true},
+		{spin, "1024", true},
+		{spin, "0", false},
+		{spin, "1025", false},
+		{spin, "big", false},
+		{combo, "Risky", true},
+		{combo, "risky", true},
+		{combo, "Wild", false},
+		{button, "", true},
+		{button, "now", false},
+		{str, "/tb/syzygy", true},
+		{str, "", true},
+	}
+	for i, c := range cases {
+		err := c.opt.Validate(c.value)
+		if c.ok && err != nil {
+			t.Errorf("#%d: %s=%q: unexpected error: %v", i, c.opt.OptionName(), c.value, err)
+		}
+		if !c.ok && err == nil {
+			t.Errorf("#%d: %s=%q: want error", i, c.opt.OptionName(), c.value)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package uci
+
+import (
+	"errors"
+	"sync"
+)
+
+// Ponder is a search in ponder mode. It is created by Client.Ponder, and must
+// be resolved with exactly one call to Hit or Miss before the client can start
+// another search.
+type Ponder struct {
+	c      *Client
+	infoCh <-chan Info
+	bestCh <-chan BestMove
+
+	mu       sync.Mutex
+	resolved bool
+}
+
+var errPonderResolved = errors.New("uci: ponder search already resolved")
+
+// Ponder sets up the position described by pos, which should end with the
+// opponent's predicted move, and starts a search on it in ponder mode. The
+// limits should contain the clock information for the search that would follow
+// if the prediction is correct; limits.Ponder is set automatically.
+func (c *Client) Ponder(pos PositionParams, limits Search) (*Ponder, error) {
+	if err := c.Position(pos); err != nil {
+		return nil, err
+	}
+	limits.Ponder = true
+	infoCh, bestCh, err := c.Go(limits)
+	if err != nil {
+		return nil, err
+	}
+	return &Ponder{c: c, infoCh: infoCh, bestCh: bestCh}, nil
+}
+
+// Hit tells the engine that the opponent played the predicted move, turning the
+// ponder search into a normal search. The returned channels behave like those
+// returned by Client.Go, and include any search information sent while
+// pondering.
+func (p *Ponder) Hit() (<-chan Info, <-chan BestMove, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resolved {
+		return nil, nil, errPonderResolved
+	}
+	p.resolved = true
+
+	if err := p.c.PonderHit(); err != nil {
+		return nil, nil, err
+	}
+	return p.infoCh, p.bestCh, nil
+}
+
+// Miss tells the engine that the opponent did not play the predicted move. It
+// stops the ponder search and waits for it to end, discarding its results. The
+// client is then ready for a new position and search.
+func (p *Ponder) Miss() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resolved {
+		return errPonderResolved
+	}
+	p.resolved = true
+
+	err := p.c.Stop()
+	for range p.infoCh {
+	}
+	for range p.bestCh {
+	}
+	return err
+}
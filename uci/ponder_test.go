@@ -0,0 +1,70 @@
+package uci
+
+import (
+	"testing"
+)
+
+// ponderEngine answers ponder searches only after "ponderhit" or "stop", as a
+// real engine does. A normal search for depth 1 is answered immediately.
+func ponderEngine(cmd string) string {
+	switch cmd {
+	case "go ponder depth 5":
+		return "info depth 1 score cp 10 pv g1f3\n"
+	case "ponderhit":
+		return "info depth 2 score cp 15 pv g1f3 b8c6\nbestmove g1f3 ponder b8c6\n"
+	case "stop":
+		return "bestmove g1f3\n"
+	case "go depth 1":
+		return "bestmove d2d4\n"
+	}
+	return ""
+}
+
+func TestPonder_Hit(t *testing.T) {
+	c := fakeEngine(t, ponderEngine)
+	limits := Search{Depth: 5}
+
+	p, err := c.Ponder(PositionParams{StartPos: true, Moves: moves("e2e4", "e7e5")}, limits)
+	if err != nil {
+		t.Fatalf("Ponder: %v", err)
+	}
+	infoCh, bestCh, err := p.Hit()
+	if err != nil {
+		t.Fatalf("Hit: %v", err)
+	}
+	var n int
+	for range infoCh {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("info: want 2, got %d", n)
+	}
+	if want, got := (BestMove{Move: move("g1f3"), Ponder: move("b8c6")}), <-bestCh; want != got {
+		t.Errorf("best move: want %+v, got %+v", want, got)
+	}
+	if err := p.Miss(); err == nil {
+		t.Error("Miss after Hit: want error")
+	}
+}
+
+func TestPonder_Miss(t *testing.T) {
+	c := fakeEngine(t, ponderEngine)
+	limits := Search{Depth: 5}
+
+	p, err := c.Ponder(PositionParams{StartPos: true, Moves: moves("e2e4", "e7e5")}, limits)
+	if err != nil {
+		t.Fatalf("Ponder: %v", err)
+	}
+	if err := p.Miss(); err != nil {
+		t.Fatalf("Miss: %v", err)
+	}
+
+	// The pondered best move must not leak into the next search.
+	_, bestCh, err := c.Go(Search{Depth: 1})
+	if err != nil {
+		t.Fatalf("Go: %v", err)
+	}
+	if want, got := (BestMove{Move: move("d2d4")}), <-bestCh; want != got {
+		t.Errorf("best move: want %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,139 @@
+package uci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Profile is a saved engine configuration: how to start the engine, and the
+// options to set once it runs. Profiles are stored as JSON; see LoadProfiles.
+type Profile struct {
+	Name string   `json:"name"` // The name of the profile, such as "Stockfish (4 threads)".
+	Path string   `json:"path"` // The path to the engine executable.
+	Args []string `json:"args,omitempty"`
+	Env  []string `json:"env,omitempty"`
+	Dir  string   `json:"dir,omitempty"`
+
+	// Options are the values of engine options, by name.
+	Options map[string]string `json:"options,omitempty"`
+
+	// EngineName, if not empty, must be a prefix of the name the engine
+	// reports, which catches profiles pointing at the wrong executable.
+	EngineName string `json:"engine_name,omitempty"`
+}
+
+// ProfileDiff compares the options of a profile with those an engine
+// advertises. All lists are sorted.
+type ProfileDiff struct {
+	Set     []string // Options of the profile that were set.
+	Unknown []string // Options of the profile the engine doesn't advertise. They aren't set.
+	Missing []string // Options the engine advertises that the profile doesn't mention, except buttons.
+}
+
+// Config returns the configuration to start the engine.
+func (p *Profile) Config() EngineConfig {
+	return EngineConfig{Path: p.Path, Args: p.Args, Env: p.Env, Dir: p.Dir}
+}
+
+// Start starts the engine, completes the "uci" handshake and applies the
+// profile with Apply.
+func (p *Profile) Start(ctx context.Context) (*Engine, ProfileDiff, error) {
+	e, err := p.Config().Start()
+	if err != nil {
+		return nil, ProfileDiff{}, err
+	}
+	if _, _, _, err := e.UCIContext(ctx); err != nil {
+		e.Close()
+		return nil, ProfileDiff{}, err
+	}
+	diff, err := p.Apply(e.Client)
+	if err != nil {
+		e.Close()
+		return nil, ProfileDiff{}, err
+	}
+	return e, diff, nil
+}
+
+// Apply checks the engine's name and sets the options of the profile that the
+// engine advertises. The client must have completed the "uci" handshake;
+// otherwise, every option counts as unknown.
+//
+// Apply returns an error, without setting any option, if the engine's name
+// doesn't match or if a value is invalid for its option.
+func (p *Profile) Apply(c *Client) (ProfileDiff, error) {
+	name, _ := c.ID()
+	if p.EngineName != "" && !strings.HasPrefix(name, p.EngineName) {
+		return ProfileDiff{}, fmt.Errorf("uci: profile %q expects engine %q, got %q", p.Name, p.EngineName, name)
+	}
+	opts := c.Options()
+
+	var diff ProfileDiff
+	for name, value := range p.Options {
+		o, ok := lookupOption(opts, name)
+		if !ok {
+			diff.Unknown = append(diff.Unknown, name)
+			continue
+		}
+		if err := o.Validate(value); err != nil {
+			return ProfileDiff{}, fmt.Errorf("uci: profile %q: %w", p.Name, err)
+		}
+		diff.Set = append(diff.Set, name)
+	}
+	for _, o := range opts {
+		if _, ok := o.(ButtonOption); ok || p.hasOption(o.OptionName()) {
+			continue
+		}
+		diff.Missing = append(diff.Missing, o.OptionName())
+	}
+	sort.Strings(diff.Set)
+	sort.Strings(diff.Unknown)
+	sort.Strings(diff.Missing)
+
+	for _, name := range diff.Set {
+		if err := c.SetOption(name, p.Options[name]); err != nil {
+			return ProfileDiff{}, err
+		}
+	}
+	return diff, nil
+}
+
+// hasOption reports whether the profile sets the option, ignoring case like
+// engines do.
+func (p *Profile) hasOption(name string) bool {
+	for n := range p.Options {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadProfiles reads profiles from a JSON file holding an array of profiles.
+// Unknown fields are an error, to catch misspellings.
+func LoadProfiles(path string) ([]Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	var profiles []Profile
+	if err := d.Decode(&profiles); err != nil {
+		return nil, fmt.Errorf("uci: %s: %w", path, err)
+	}
+	return profiles, nil
+}
+
+// SaveProfiles writes profiles to a JSON file that LoadProfiles can read.
+func SaveProfiles(path string, profiles []Profile) error {
+	data, err := json.MarshalIndent(profiles, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,128 @@
+package uci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// profileEngine advertises a few options.
+func profileEngine(cmd string) string {
+	if cmd == "uci" {
+		return "id name Profiled 2.0\n" +
+			"option name Hash type spin default 16 min 1 max 1024\n" +
+			"option name Threads type spin default 1 min 1 max 64\n" +
+			"option name Ponder type check default false\n" +
+			"option name Clear Hash type button\n" +
+			"uciok\n"
+	}
+	return ""
+}
+
+func TestProfile_Apply(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	c := fakeEngine(t, profileEngine)
+	c.OnSend(func(line string) {
+		mu.Lock()
+		sent = append(sent, line)
+		mu.Unlock()
+	})
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Profile{
+		Name:       "test",
+		EngineName: "Profiled",
+		Options:    map[string]string{"threads": "4", "Hash": "256", "SyzygyPath": "/tb"},
+	}
+	diff, err := p.Apply(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ProfileDiff{
+		Set:     []string{"Hash", "threads"},
+		Unknown: []string{"SyzygyPath"},
+		Missing: []string{"Ponder"},
+	}
+	if d := cmp.Diff(want, diff); d != "" {
+		t.Errorf("diff mismatch (-want +got):\n%s", d)
+	}
+	mu.Lock()
+	wantSent := []string{"uci", "setoption name Hash value 256", "setoption name threads value 4"}
+	if d := cmp.Diff(wantSent, sent); d != "" {
+		t.Errorf("sent mismatch (-want +got):\n%s", d)
+	}
+	mu.Unlock()
+
+	for _, p := range []*Profile{
+		{Name: "wrong engine", EngineName: "Stockfish"},
+		{Name: "invalid value", Options: map[string]string{"Hash": "0", "Threads": "2"}},
+	} {
+		if _, err := p.Apply(c); err == nil {
+			t.Errorf("%s: want error", p.Name)
+		}
+	}
+	mu.Lock()
+	if len(sent) != len(wantSent) {
+		t.Errorf("failed applications sent %q", sent[len(wantSent):])
+	}
+	mu.Unlock()
+}
+
+func TestProfile_Start(t *testing.T) {
+	p := &Profile{
+		Name:       "helper",
+		Path:       os.Args[0],
+		Args:       []string{"-test.run=^TestHelperEngine$"},
+		Env:        append(os.Environ(), "UCI_HELPER_ENGINE=1", "UCI_HELPER_NAME=Helper 1.0"),
+		Options:    map[string]string{"Hash": "64"},
+		EngineName: "Helper",
+	}
+	e, diff, err := p.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	if d := cmp.Diff(ProfileDiff{Unknown: []string{"Hash"}}, diff); d != "" {
+		t.Errorf("diff mismatch (-want +got):\n%s", d)
+	}
+
+	p.EngineName = "Other"
+	if _, _, err := p.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "Other") {
+		t.Errorf("want engine name error, got %v", err)
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engines.json")
+	profiles := []Profile{
+		{Name: "a", Path: "/usr/bin/a", Options: map[string]string{"Hash": "128"}},
+		{Name: "b", Path: "b", Args: []string{"--uci"}, Dir: "/tmp", EngineName: "B"},
+	}
+	if err := SaveProfiles(path, profiles); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(profiles, got); d != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", d)
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"name": "a", "pth": "/usr/bin/a"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProfiles(path); err == nil || !strings.Contains(err.Error(), "pth") {
+		t.Errorf("want unknown field error, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errCaptureInProgress = errors.New("uci: capture already in progress")
+
+// capture collects the engine's output for Capture.
+type capture struct {
+	done  func(line string) bool
+	lines []string
+	err   error
+	end   chan struct{} // Closed when done matches or the engine stops responding.
+}
+
+// rawReserved are commands whose responses the client routes itself, which
+// SendRaw refuses to send.
+var rawReserved = map[string]bool{"uci": true, "isready": true, "go": true, "register": true}
+
+// SendRaw sends cmd to the engine as is, such as Stockfish's "d" or "bench".
+// It is an escape hatch for non-standard commands; use Capture to read their
+// output.
+//
+// SendRaw refuses commands that span several lines, and the standard commands
+// whose responses the client tracks: "uci", "isready", "go" and "register".
+// Sending them behind the client's back would confuse it.
+func (c *Client) SendRaw(cmd string) error {
+	if strings.ContainsAny(cmd, "\r\n") {
+		return fmt.Errorf("uci: raw command %q spans several lines", cmd)
+	}
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return errors.New("uci: empty raw command")
+	}
+	if rawReserved[fields[0]] {
+		return fmt.Errorf("uci: can't send %q as a raw command", fields[0])
+	}
+	return c.send("%s", cmd)
+}
+
+// Capture sends cmd with SendRaw and returns the lines the engine sends until
+// done returns true for one, which is included. The lines are still processed
+// as usual, so a search running at the same time isn't disturbed.
+//
+// If ctx is done first, Capture returns the lines received so far along with
+// ctx.Err(). Only one capture may be in progress at a time.
+func (c *Client) Capture(ctx context.Context, cmd string, done func(line string) bool) ([]string, error) {
+	return c.captureFunc(ctx, func() error { return c.SendRaw(cmd) }, done)
+}
+
+// captureFunc is like Capture, but calls send to send the commands.
+func (c *Client) captureFunc(ctx context.Context, send func() error, done func(line string) bool) ([]string, error) {
+	cp := &capture{done: done, end: make(chan struct{})}
+
+	c.mu.Lock()
+	var err error
+	switch {
+	case c.err != nil:
+		err = c.err
+	case c.capture != nil:
+		err = errCaptureInProgress
+	default:
+		c.capture = cp
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	c.start()
+
+	if err := send(); err != nil {
+		c.endCapture(cp, nil)
+		return nil, err
+	}
+
+	select {
+	case <-cp.end:
+		return cp.lines, cp.err
+	case <-ctx.Done():
+		c.endCapture(cp, ctx.Err())
+		<-cp.end
+		return cp.lines, cp.err
+	}
+}
+
+// captureLine records a line for the capture in progress, if any.
+func (c *Client) captureLine(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp := c.capture
+	if cp == nil {
+		return
+	}
+	cp.lines = append(cp.lines, line)
+	if cp.done(line) {
+		c.capture = nil
+		close(cp.end)
+	}
+}
+
+// endCapture ends cp with err, unless it already ended.
+func (c *Client) endCapture(cp *capture, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.capture != cp {
+		return
+	}
+	c.capture = nil
+	cp.err = err
+	close(cp.end)
+}
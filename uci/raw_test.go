@@ -0,0 +1,84 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// rawEngine answers Stockfish's "d" command, and nothing else.
+func rawEngine(cmd string) string {
+	switch cmd {
+	case "d":
+		return "\n +---+---+\n | k | K |\n +---+---+\nFen: 8/8/8/8/8/8/8/kK6 w - - 0 1\nKey: 0123\nCheckers:\n"
+	case "isready":
+		return "readyok\n"
+	}
+	return ""
+}
+
+func TestClient_Capture(t *testing.T) {
+	c := fakeEngine(t, rawEngine)
+	lines, err := c.Capture(context.Background(), "d", func(line string) bool {
+		return strings.HasPrefix(line, "Checkers:")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"", " +---+---+", " | k | K |", " +---+---+", "Fen: 8/8/8/8/8/8/8/kK6 w - - 0 1", "Key: 0123", "Checkers:"}
+	if diff := cmp.Diff(want, lines); diff != "" {
+		t.Errorf("lines mismatch (-want +got):\n%s", diff)
+	}
+
+	// The client still works afterwards.
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_Capture_Context(t *testing.T) {
+	c := fakeEngine(t, rawEngine)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	lines, err := c.Capture(ctx, "d", func(string) bool { return false })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded, got %v", err)
+	}
+	if len(lines) != 7 {
+		t.Errorf("want the 7 lines received, got %q", lines)
+	}
+	// A new capture can start.
+	if _, err := c.Capture(context.Background(), "d", func(line string) bool { return strings.HasPrefix(line, "Fen:") }); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClient_Capture_EOF(t *testing.T) {
+	r, w := io.Pipe()
+	c := NewClient(strings.NewReader("partial output\n"), w)
+	go io.Copy(io.Discard, r)
+	lines, err := c.Capture(context.Background(), "bench", func(string) bool { return false })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("want unexpected EOF, got %v", err)
+	}
+	if diff := cmp.Diff([]string{"partial output"}, lines); diff != "" {
+		t.Errorf("lines mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_SendRaw(t *testing.T) {
+	c := fakeEngine(t, rawEngine)
+	for _, cmd := range []string{"", "  ", "go depth 1", "uci", "isready", "register later", "d\nisready"} {
+		if err := c.SendRaw(cmd); err == nil {
+			t.Errorf("SendRaw(%q): want error", cmd)
+		}
+	}
+	if err := c.SendRaw("eval"); err != nil {
+		t.Errorf("SendRaw(eval): %v", err)
+	}
+}
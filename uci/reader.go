@@ -0,0 +1,220 @@
+package uci
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/clfs/chess/internal/relay"
+)
+
+// DefaultMaxLineLength is the default limit on the length of a line from the
+// engine. See Client.SetMaxLineLength.
+const DefaultMaxLineLength = 1 << 20
+
+// ErrLineTooLong is the error of a client whose engine sent a line longer
+// than the limit set with SetMaxLineLength, and of ReadTrace for a transcript
+// with a line longer than DefaultMaxLineLength.
+var ErrLineTooLong = errors.New("uci: line from engine too long")
+
+var (
+	errHandshakeInProgress = errors.New("uci: handshake already in progress")
+	errSearchInProgress    = errors.New("uci: search already in progress")
+)
+
+// handshake is a pending "uci" command.
+type handshake struct {
+	name, author string
+	opts         []Option
+	err          error
+	done         chan struct{} // Closed when the engine sends "uciok" or stops responding.
+}
+
+// search is a running "go" command. Information from the engine is queued so
+// that a slow consumer never blocks the reader.
+type search = relay.Relay[Info, BestMove]
+
+func newSearch() *search {
+	return relay.New[Info, BestMove]()
+}
+
+// start starts the reader goroutine, if it isn't already running. It must be
+// called after registering for a response, so that the response isn't dropped.
+func (c *Client) start() {
+	c.startOnce.Do(func() { go c.read() })
+}
+
+// read reads lines from the engine until it stops responding, routing each line
+// to the command waiting for it. A line longer than the limit stops it, rather
+// than being cut short, since the rest of the line would be misread.
+func (c *Client) read() {
+	defer close(c.readDone)
+	c.mu.Lock()
+	max := c.maxLine
+	c.mu.Unlock()
+	if max <= 0 {
+		max = DefaultMaxLineLength
+	}
+
+	s := bufio.NewScanner(c.r)
+	s.Buffer(make([]byte, 0, 4096), max)
+	for s.Scan() {
+		c.hooks.received(s.Text())
+		c.captureLine(s.Text())
+		c.dispatch(s.Text())
+	}
+
+	err := s.Err()
+	switch {
+	case err == nil:
+		err = &EngineTerminatedError{Err: io.ErrUnexpectedEOF}
+	case errors.Is(err, bufio.ErrTooLong):
+		err = fmt.Errorf("%w: limit is %d bytes", ErrLineTooLong, max)
+	default:
+		err = &EngineTerminatedError{Err: err}
+	}
+	c.fail(err)
+}
+
+// fail records that the engine stopped responding, and fails the commands
+// waiting for it with err, or ErrClosed if the client is closing.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closing {
+		err = ErrClosed
+	}
+	c.err = err
+	c.hooks.terminate(err)
+	if h := c.handshake; h != nil {
+		h.err = err
+		close(h.done)
+		c.handshake = nil
+	}
+	for _, ch := range c.ready {
+		ch <- err
+	}
+	c.ready = nil
+	for _, ch := range c.registered {
+		ch <- err
+	}
+	c.registered = nil
+	if c.search != nil {
+		c.search.Finish(nil)
+		c.search = nil
+	}
+	if c.batch != nil {
+		c.endBatch(err)
+	}
+	if cp := c.capture; cp != nil {
+		cp.err = err
+		close(cp.end)
+		c.capture = nil
+	}
+}
+
+// dispatch routes a line from the engine.
+func (c *Client) dispatch(line string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch fields[0] {
+	case "id":
+		if h := c.handshake; h != nil {
+			switch {
+			case strings.HasPrefix(line, "id name "):
+				h.name = strings.TrimPrefix(line, "id name ")
+			case strings.HasPrefix(line, "id author "):
+				h.author = strings.TrimPrefix(line, "id author ")
+			}
+		}
+	case "option":
+		if h := c.handshake; h != nil {
+			opt, err := ParseOption(line)
+			if err != nil {
+				if h.err == nil {
+					h.err = err
+				}
+				return
+			}
+			h.opts = append(h.opts, opt)
+			c.hooks.publish(OptionParsed{Option: opt})
+		}
+	case "uciok":
+		if h := c.handshake; h != nil {
+			if h.err == nil {
+				c.name, c.author = h.name, h.author
+				c.options = append([]Option{}, h.opts...)
+			}
+			close(h.done)
+			c.handshake = nil
+		}
+	case "readyok":
+		if len(c.ready) > 0 {
+			c.ready[0] <- nil
+			c.ready = c.ready[1:]
+		}
+	case "info":
+		if c.search == nil && c.batch == nil && !c.hooks.subscribed() {
+			return
+		}
+		info, err := ParseInfo(line)
+		if err != nil {
+			c.hooks.publish(InfoParseFailed{Err: err})
+			return
+		}
+		c.translateInfo(&info)
+		c.hooks.publish(InfoReceived{Info: info})
+		switch {
+		case c.search != nil:
+			c.search.Queue(info)
+		case c.batch != nil:
+			c.batchInfo(info)
+		}
+	case "bestmove":
+		bm, err := parseBestMove(line)
+		if err == nil {
+			c.translateBestMove(&bm)
+			c.hooks.publish(BestMoveReceived{BestMove: bm})
+		}
+		switch {
+		case c.search != nil:
+			if err == nil {
+				c.search.Finish(&bm)
+			} else {
+				c.search.Finish(nil)
+			}
+			c.search = nil
+		case c.batch != nil:
+			if err != nil {
+				c.endBatch(err)
+				return
+			}
+			c.batchBestMove(bm)
+		}
+	case "copyprotection":
+		if len(fields) > 1 {
+			c.copyProtection = Status(fields[1])
+		}
+	case "registration":
+		if len(fields) > 1 {
+			c.registration = Status(fields[1])
+			if c.registration != StatusChecking && len(c.registered) > 0 {
+				var err error
+				if c.registration != StatusOK {
+					err = ErrRegistration
+				}
+				c.registered[0] <- err
+				c.registered = c.registered[1:]
+			}
+		}
+	}
+}
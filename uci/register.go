@@ -0,0 +1,95 @@
+package uci
+
+import (
+	"context"
+	"errors"
+)
+
+// Status is the state of an engine's copy protection or registration check.
+type Status string
+
+// Copy protection and registration states reported by engines.
+const (
+	StatusChecking Status = "checking" // The engine is checking.
+	StatusOK       Status = "ok"       // The check succeeded.
+	StatusError    Status = "error"    // The check failed.
+)
+
+// ErrRegistration is returned when the engine rejects a registration.
+var ErrRegistration = errors.New("uci: registration failed")
+
+// CopyProtection returns the copy protection state last reported by the engine,
+// or the empty string if the engine has not reported one. Engines report it
+// after the "uci" handshake.
+func (c *Client) CopyProtection() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.copyProtection
+}
+
+// RegistrationStatus returns the registration state last reported by the
+// engine, or the empty string if the engine has not reported one. Engines that
+// require registration report StatusError after the "uci" handshake, until
+// Register succeeds.
+func (c *Client) RegistrationStatus() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.registration
+}
+
+// Register sends a "register" command. It registers client information with the
+// engine, and blocks until the engine accepts or rejects the registration. If
+// the engine rejects it, Register returns ErrRegistration.
+func (c *Client) Register(name, code string) error {
+	return c.RegisterContext(context.Background(), name, code)
+}
+
+// RegisterContext is like Register, but gives up when ctx is done, with a
+// *TimeoutError if its deadline passed.
+func (c *Client) RegisterContext(ctx context.Context, name, code string) error {
+	ch := make(chan error, 1)
+
+	c.mu.Lock()
+	if err := c.err; err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.registered = append(c.registered, ch)
+	c.mu.Unlock()
+	c.start()
+
+	cancel := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i := range c.registered {
+			if c.registered[i] == ch {
+				c.registered = append(c.registered[:i], c.registered[i+1:]...)
+				return
+			}
+		}
+	}
+
+	if err := c.send("register name %s code %s", name, code); err != nil {
+		cancel()
+		return err
+	}
+
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		cancel()
+		return waitError(ctx, "register")
+	}
+}
+
+// RegisterLater sends a "register later" command. It claims that the client
+// will register itself later. Since engines need not respond to it, it then
+// waits for the engine to be ready, so that RegistrationStatus reflects any
+// response.
+func (c *Client) RegisterLater() error {
+	if err := c.send("register later"); err != nil {
+		return err
+	}
+	return c.IsReady()
+}
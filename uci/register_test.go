@@ -0,0 +1,60 @@
+package uci
+
+import (
+	"strings"
+	"testing"
+)
+
+// registrationEngine requires registration and only accepts the code 1234.
+func registrationEngine(cmd string) string {
+	switch {
+	case cmd == "uci":
+		return "id name Commercial\ncopyprotection checking\ncopyprotection ok\nuciok\nregistration checking\nregistration error\n"
+	case cmd == "isready":
+		return "readyok\n"
+	case cmd == "register later":
+		return "registration checking\nregistration ok\n"
+	case strings.HasPrefix(cmd, "register name"):
+		if strings.HasSuffix(cmd, " code 1234") {
+			return "registration checking\nregistration ok\n"
+		}
+		return "registration checking\nregistration error\n"
+	}
+	return ""
+}
+
+func TestClient_Register(t *testing.T) {
+	c := fakeEngine(t, registrationEngine)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatalf("UCI: %v", err)
+	}
+	if err := c.IsReady(); err != nil {
+		t.Fatalf("IsReady: %v", err)
+	}
+	if want, got := StatusOK, c.CopyProtection(); want != got {
+		t.Errorf("CopyProtection: want %q, got %q", want, got)
+	}
+	if want, got := StatusError, c.RegistrationStatus(); want != got {
+		t.Errorf("RegistrationStatus: want %q, got %q", want, got)
+	}
+
+	if err := c.Register("Jane Doe", "0000"); err != ErrRegistration {
+		t.Errorf("Register with bad code: want %v, got %v", ErrRegistration, err)
+	}
+	if err := c.Register("Jane Doe", "1234"); err != nil {
+		t.Errorf("Register: %v", err)
+	}
+	if want, got := StatusOK, c.RegistrationStatus(); want != got {
+		t.Errorf("RegistrationStatus: want %q, got %q", want, got)
+	}
+}
+
+func TestClient_RegisterLater(t *testing.T) {
+	c := fakeEngine(t, registrationEngine)
+	if err := c.RegisterLater(); err != nil {
+		t.Fatalf("RegisterLater: %v", err)
+	}
+	if want, got := StatusOK, c.RegistrationStatus(); want != got {
+		t.Errorf("RegistrationStatus: want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,205 @@
+// Package remote runs UCI engines on other machines, as if they were local.
+//
+// A Server listens for connections and starts a fresh engine process for each
+// one, relaying the connection to the engine's standard input and output. A
+// Dialer connects to a server and returns an Engine, whose embedded Client
+// speaks to the remote engine. Alternatively, StartSSH and DialSSH run an
+// engine on a host that is reachable over SSH, with no server needed.
+//
+// Before relaying, the client authenticates with a single line,
+// "auth TOKEN", which the server answers with "ok" or "denied". Tokens are
+// sent in the clear, so connections over untrusted networks should use TLS:
+// wrap the server's listener with tls.NewListener, and set Dialer.TLSConfig.
+// A server without a token only serves on loopback addresses and Unix sockets.
+//
+// A Dialer can retry a failed attempt to connect, but a connection that is
+// lost later isn't resumed: the server stops the engine when its client goes
+// away, so its options, position and hash are lost with it. The Engine's Done
+// channel is closed, and the caller dials again for a fresh engine.
+package remote
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clfs/chess/uci"
+)
+
+// ErrDenied is returned when a server rejects the token of a client.
+var ErrDenied = errors.New("remote: authentication failed")
+
+// Dialer connects to remote engines. The zero value connects without a token,
+// TLS or retries.
+type Dialer struct {
+	Token     string      // The token expected by the server.
+	TLSConfig *tls.Config // If non-nil, connections use TLS.
+
+	// Timeout limits each attempt to connect and authenticate. If zero, only
+	// the context limits them.
+	Timeout time.Duration
+
+	// Retries is the number of times Dial tries again after a failed attempt
+	// to connect, waiting Backoff before the first retry and twice as long
+	// before each of the next. Backoff defaults to half a second. A denied
+	// token isn't retried, and neither is a connection lost after Dial
+	// returns.
+	Retries int
+	Backoff time.Duration
+}
+
+// Dial connects to the server at addr on the named network, such as "tcp",
+// and returns the remote engine. The engine is ready for the "uci" handshake.
+func (d *Dialer) Dial(ctx context.Context, network, addr string) (*Engine, error) {
+	backoff := d.Backoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+	for attempt := 0; ; attempt++ {
+		e, err := d.dial(ctx, network, addr)
+		if err == nil || errors.Is(err, ErrDenied) || attempt >= d.Retries {
+			return e, err
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// dial makes a single attempt to connect and authenticate.
+func (d *Dialer) dial(ctx context.Context, network, addr string) (*Engine, error) {
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+	var nd net.Dialer
+	conn, err := nd.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if d.TLSConfig != nil {
+		tc := tls.Client(conn, d.TLSConfig)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tc
+	}
+
+	br, err := authenticate(ctx, conn, d.Token)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return newEngine(br, conn, func(context.Context) error { return conn.Close() }), nil
+}
+
+// authenticate sends token over conn and waits for the server's answer. It
+// returns a reader for the rest of the connection.
+func authenticate(ctx context.Context, conn net.Conn, token string) (*bufio.Reader, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Interrupt the exchange below.
+			conn.SetDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
+
+	if _, err := fmt.Fprintf(conn, "auth %s\n", token); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	line, err := br.ReadString('\n')
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("remote: no answer to authentication: %w", err)
+	}
+	switch strings.TrimSpace(line) {
+	case "ok":
+		return br, nil
+	case "denied":
+		return nil, ErrDenied
+	}
+	return nil, fmt.Errorf("remote: unexpected answer to authentication %q", line)
+}
+
+// Engine is a UCI engine on a remote server, or on a remote host reached over
+// SSH.
+type Engine struct {
+	*uci.Client
+
+	close     func(ctx context.Context) error // Tears down the connection.
+	done      chan struct{}
+	doneOnce  sync.Once
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// newEngine returns an engine that reads from r and writes to w, and is torn
+// down with close.
+func newEngine(r io.Reader, w io.Writer, close func(ctx context.Context) error) *Engine {
+	e := &Engine{close: close, done: make(chan struct{})}
+	e.Client = uci.NewClient(&watchReader{r: r, e: e}, w)
+	return e
+}
+
+// Done returns a channel that is closed when the connection to the engine is
+// lost, whether because the engine quit or crashed, the network failed, or
+// Close was called. The remote engine is gone with the connection; to go on,
+// dial again and set up the new engine from scratch.
+func (e *Engine) Done() <-chan struct{} {
+	return e.done
+}
+
+// Close sends a "quit" command and closes the connection. A server then stops
+// the engine, and an SSH session is ended once the engine quits, a timeout
+// passes or ctx is done. Close then closes the client, as uci.Client's Close
+// does, and returns the first error. Close may be called more than once.
+func (e *Engine) Close(ctx context.Context) error {
+	e.closeOnce.Do(func() {
+		e.Quit()
+		e.closeErr = e.close(ctx)
+	})
+	err := e.Client.Close(ctx)
+	if e.closeErr != nil {
+		return e.closeErr
+	}
+	return err
+}
+
+// watchReader reads from the connection, and marks the engine as done when
+// reading fails.
+type watchReader struct {
+	r io.Reader
+	e *Engine
+}
+
+func (w *watchReader) Read(b []byte) (int, error) {
+	n, err := w.r.Read(b)
+	if err != nil {
+		w.e.doneOnce.Do(func() { close(w.e.done) })
+	}
+	return n, err
+}
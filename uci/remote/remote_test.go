@@ -0,0 +1,121 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDialer_Dial(t *testing.T) {
+	addr := startServer(t, "secret")
+	d := &Dialer{Token: "secret", Timeout: 5 * time.Second}
+	e, err := d.Dial(context.Background(), "tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, author, _, err := e.UCI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Remote Helper" || author != "Nobody" {
+		t.Errorf("want Remote Helper by Nobody, got %q by %q", name, author)
+	}
+	if err := e.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Quitting the engine ends the connection.
+	if err := e.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-e.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection still open after quit")
+	}
+	e.Close(context.Background())
+}
+
+func TestDialer_Dial_Denied(t *testing.T) {
+	addr := startServer(t, "secret")
+	d := &Dialer{Token: "guess", Retries: 5, Backoff: time.Hour}
+	if _, err := d.Dial(context.Background(), "tcp", addr); !errors.Is(err, ErrDenied) {
+		t.Errorf("want %v, got %v", ErrDenied, err)
+	}
+}
+
+func TestDialer_Dial_Retry(t *testing.T) {
+	// Find a free address, and only start serving on it after a while.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() { l.Close() })
+		s := &Server{Engine: helperConfig()}
+		s.Serve(l)
+	}()
+
+	d := &Dialer{Retries: 10, Backoff: 5 * time.Millisecond}
+	e, err := d.Dial(context.Background(), "tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close(context.Background())
+	if _, _, _, err := e.UCI(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDialer_Dial_Canceled(t *testing.T) {
+	// A server that never answers.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	d := &Dialer{Retries: 3, Backoff: time.Hour}
+	if _, err := d.Dial(ctx, "tcp", l.Addr().String()); err != context.DeadlineExceeded {
+		t.Errorf("want %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestEngine_Close(t *testing.T) {
+	addr := startServer(t, "")
+	var d Dialer
+	e, err := d.Dial(context.Background(), "tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := e.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-e.Done()
+	if err := e.IsReady(); err == nil {
+		t.Error("IsReady after Close: want error")
+	}
+}
@@ -0,0 +1,134 @@
+package remote
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/clfs/chess/uci"
+)
+
+// quitTimeout is how long the server waits for an engine to quit after its
+// client disconnects, before killing it.
+var quitTimeout = 5 * time.Second
+
+// ErrNoToken is returned by Serve when a server without a token would listen
+// on an address other than loopback.
+var ErrNoToken = errors.New("remote: a token is required to serve on a non-loopback address")
+
+// Server serves an engine to remote clients. Each connection gets its own
+// engine process, which is stopped when the client disconnects.
+type Server struct {
+	Engine uci.EngineConfig // The engine to start for each connection.
+
+	// Token is the token clients must present. If empty, any token is
+	// accepted, which Serve only allows on loopback addresses and Unix
+	// sockets.
+	Token string
+
+	// AuthTimeout limits how long a client may take to authenticate. It
+	// defaults to ten seconds.
+	AuthTimeout time.Duration
+}
+
+// ListenAndServe listens on the TCP address addr and serves connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return s.Serve(l)
+}
+
+// Serve serves connections accepted from l until accepting fails, such as
+// when l is closed, and returns the error. Running engines are stopped when
+// their clients disconnect, not when Serve returns.
+//
+// If the server has no token, and l listens on a TCP address other than
+// loopback, Serve returns ErrNoToken without accepting any connection.
+func (s *Server) Serve(l net.Listener) error {
+	if addr, ok := l.Addr().(*net.TCPAddr); ok && s.Token == "" && !addr.IP.IsLoopback() {
+		return ErrNoToken
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn authenticates the client on conn, then relays the connection to a
+// new engine process until either side goes away.
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	br, ok := s.authenticate(conn)
+	if !ok {
+		return
+	}
+
+	cmd := exec.Command(s.Engine.Path, s.Engine.Args...)
+	cmd.Env = s.Engine.Env
+	cmd.Dir = s.Engine.Dir
+	cmd.Stderr = s.Engine.Stderr
+	cmd.Stdout = conn
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		return
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		io.Copy(stdin, br)
+		// The client is gone. Ask the engine to quit, and kill it if it
+		// doesn't.
+		io.WriteString(stdin, "quit\n")
+		stdin.Close()
+		t := time.NewTimer(quitTimeout)
+		defer t.Stop()
+		select {
+		case <-exited:
+		case <-t.C:
+			cmd.Process.Kill()
+		}
+	}()
+	cmd.Wait()
+	close(exited)
+}
+
+// authenticate checks the client's token, and returns a reader for the rest
+// of the connection if it is accepted.
+func (s *Server) authenticate(conn net.Conn) (*bufio.Reader, bool) {
+	timeout := s.AuthTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	defer conn.SetDeadline(time.Time{})
+
+	br := bufio.NewReader(conn)
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, false
+	}
+	cmd, token, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if cmd != "auth" || s.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+		io.WriteString(conn, "denied\n")
+		return nil, false
+	}
+	if _, err := io.WriteString(conn, "ok\n"); err != nil {
+		return nil, false
+	}
+	return br, true
+}
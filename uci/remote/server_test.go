@@ -0,0 +1,110 @@
+package remote
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/uci"
+)
+
+// TestHelperEngine is not a real test. It acts as a minimal UCI engine when
+// run as a child process by helperConfig.
+func TestHelperEngine(t *testing.T) {
+	if os.Getenv("REMOTE_HELPER_ENGINE") != "1" {
+		return
+	}
+	s := bufio.NewScanner(os.Stdin)
+	for s.Scan() {
+		switch s.Text() {
+		case "uci":
+			fmt.Println("id name Remote Helper")
+			fmt.Println("id author Nobody")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "quit":
+			os.Exit(0)
+		}
+	}
+	os.Exit(0)
+}
+
+// helperConfig returns a configuration that runs TestHelperEngine.
+func helperConfig() uci.EngineConfig {
+	return uci.EngineConfig{
+		Path: os.Args[0],
+		Args: []string{"-test.run=^TestHelperEngine$"},
+		Env:  append(os.Environ(), "REMOTE_HELPER_ENGINE=1"),
+	}
+}
+
+// startServer starts a server for the helper engine, and returns its address.
+func startServer(t *testing.T, token string) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	s := &Server{Engine: helperConfig(), Token: token}
+	go s.Serve(l)
+	return l.Addr().String()
+}
+
+func TestServer_Denied(t *testing.T) {
+	addr := startServer(t, "secret")
+	for _, hello := range []string{"auth wrong", "auth", "hello secret"} {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(conn, "%s\nuci\n", hello)
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		var lines []string
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			lines = append(lines, s.Text())
+		}
+		conn.Close()
+		if got := strings.Join(lines, "|"); got != "denied" {
+			t.Errorf("%q: want %q, got %q", hello, "denied", got)
+		}
+	}
+}
+
+func TestServer_AuthTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s := &Server{Engine: helperConfig(), AuthTimeout: 10 * time.Millisecond}
+	go s.Serve(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if n, err := conn.Read(make([]byte, 1)); n != 0 || err == nil {
+		t.Errorf("want the connection closed, got %d bytes and %v", n, err)
+	}
+}
+
+func TestServer_NoToken(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s := &Server{Engine: helperConfig()}
+	if err := s.Serve(l); err != ErrNoToken {
+		t.Errorf("want %v, got %v", ErrNoToken, err)
+	}
+}
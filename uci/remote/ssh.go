@@ -0,0 +1,100 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sessionTimeout is how long Close waits for an engine started over SSH to
+// quit before ending its session. It is replaced in tests.
+var sessionTimeout = 5 * time.Second
+
+// StartSSH runs command, the command line of an engine, in a new session on
+// the SSH connection c, and returns the engine. The engine's standard error is
+// discarded.
+//
+// Closing the engine ends the session, but not c.
+func StartSSH(c *ssh.Client, command string) (*Engine, error) {
+	return startSSH(c, command, nil)
+}
+
+// DialSSH connects to the SSH server at addr with config, and runs command,
+// the command line of an engine, in a session. The engine's standard error is
+// discarded.
+//
+// Closing the engine ends the session and the SSH connection.
+func DialSSH(ctx context.Context, addr string, config *ssh.ClientConfig, command string) (*Engine, error) {
+	var nd net.Dialer
+	conn, err := nd.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	sc, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	c := ssh.NewClient(sc, chans, reqs)
+	e, err := startSSH(c, command, c.Close)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// startSSH runs command in a new session on c. closeConn, if non-nil, is called
+// after the session ends.
+func startSSH(c *ssh.Client, command string, closeConn func() error) (*Engine, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	if err := session.Start(command); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("remote: starting %q: %w", command, err)
+	}
+
+	var e *Engine
+	e = newEngine(stdout, stdin, func(ctx context.Context) error {
+		t := time.NewTimer(sessionTimeout)
+		defer t.Stop()
+		select {
+		case <-e.Done():
+		case <-t.C:
+		case <-ctx.Done():
+		}
+		err := session.Close()
+		if errors.Is(err, io.EOF) {
+			// The session already ended.
+			err = nil
+		}
+		if closeConn != nil {
+			if cerr := closeConn(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	})
+	return e, nil
+}
@@ -0,0 +1,225 @@
+package remote
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshServer is an in-process SSH server whose sessions run a minimal UCI
+// engine, whatever the command.
+type sshServer struct {
+	addr   string
+	config *ssh.ClientConfig // A configuration that is accepted.
+
+	mu       sync.Mutex
+	commands []string // The commands run so far.
+}
+
+func startSSHServer(t *testing.T) *sshServer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "tester" && string(pass) == "hunter2" {
+				return nil, nil
+			}
+			return nil, errors.New("wrong password")
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	s := &sshServer{
+		addr: l.Addr().String(),
+		config: &ssh.ClientConfig{
+			User:            "tester",
+			Auth:            []ssh.AuthMethod{ssh.Password("hunter2")},
+			HostKeyCallback: ssh.FixedHostKey(signer.PublicKey()),
+		},
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveConn(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *sshServer) serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "sessions only")
+			continue
+		}
+		ch, reqs, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go s.serveSession(ch, reqs)
+	}
+}
+
+func (s *sshServer) serveSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	for req := range reqs {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			req.Reply(false, nil)
+			continue
+		}
+		req.Reply(true, nil)
+		s.mu.Lock()
+		s.commands = append(s.commands, payload.Command)
+		s.mu.Unlock()
+		go runEngine(ch, payload.Command == "hang")
+	}
+}
+
+// runEngine runs a minimal UCI engine on ch. If hang is true, it ignores
+// "quit".
+func runEngine(ch ssh.Channel, hang bool) {
+	defer ch.Close()
+	sc := bufio.NewScanner(ch)
+	for sc.Scan() {
+		switch sc.Text() {
+		case "uci":
+			fmt.Fprint(ch, "id name SSH Helper\nid author Nobody\nuciok\n")
+		case "isready":
+			fmt.Fprint(ch, "readyok\n")
+		case "quit":
+			if !hang {
+				ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+				return
+			}
+		}
+	}
+}
+
+func (s *sshServer) ran() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func TestDialSSH(t *testing.T) {
+	s := startSSHServer(t)
+	e, err := DialSSH(context.Background(), s.addr, s.config, "/usr/games/stockfish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, _, _, err := e.UCI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "SSH Helper" {
+		t.Errorf("want SSH Helper, got %q", name)
+	}
+	if err := e.Close(context.Background()); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	<-e.Done()
+	if got := s.ran(); len(got) != 1 || got[0] != "/usr/games/stockfish" {
+		t.Errorf("want /usr/games/stockfish to run, got %q", got)
+	}
+}
+
+func TestDialSSH_Denied(t *testing.T) {
+	s := startSSHServer(t)
+	config := *s.config
+	config.Auth = []ssh.AuthMethod{ssh.Password("letmein")}
+	if _, err := DialSSH(context.Background(), s.addr, &config, "engine"); err == nil {
+		t.Error("want error for a wrong password")
+	}
+}
+
+func TestStartSSH(t *testing.T) {
+	s := startSSHServer(t)
+	c, err := ssh.Dial("tcp", s.addr, s.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// Several engines can share a connection.
+	e1, err := StartSSH(c, "engine1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, err := StartSSH(c, "engine2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := e1.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e1.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := e2.UCI(); err != nil {
+		t.Fatalf("second engine after closing the first: %v", err)
+	}
+	if err := e2.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartSSH_Hang(t *testing.T) {
+	defer func(d time.Duration) { sessionTimeout = d }(sessionTimeout)
+	sessionTimeout = 10 * time.Millisecond
+
+	s := startSSHServer(t)
+	c, err := ssh.Dial("tcp", s.addr, s.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	e, err := StartSSH(c, "hang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- e.Close(context.Background()) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close didn't end the session of a hung engine")
+	}
+}
@@ -0,0 +1,44 @@
+package uci
+
+import (
+	"fmt"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/pgn"
+)
+
+// GamePosition returns the parameters of a "position" command that replays
+// the main line of g.
+func GamePosition(g *pgn.Game) PositionParams {
+	p := PositionParams{FEN: g.Tag("FEN"), Moves: g.MainLine()}
+	if p.FEN == "" {
+		p.StartPos = true
+	}
+	return p
+}
+
+// SANPosition returns the parameters of a "position" command that replays
+// moves in SAN from the position given by fen, or from the standard starting
+// position if fen is empty.
+func SANPosition(fen string, san []string) (PositionParams, error) {
+	p := PositionParams{FEN: fen, StartPos: fen == ""}
+
+	pos := chess.StartingPosition()
+	if !p.StartPos {
+		var err error
+		if pos, err = chess.ParseFEN(fen); err != nil {
+			return PositionParams{}, err
+		}
+	}
+
+	p.Moves = make([]chess.Move, 0, len(san))
+	for i, s := range san {
+		m, err := chess.ParseSAN(pos, s)
+		if err != nil {
+			return PositionParams{}, fmt.Errorf("uci: move %d: %w", i+1, err)
+		}
+		pos.Apply(m)
+		p.Moves = append(p.Moves, m)
+	}
+	return p, nil
+}
@@ -0,0 +1,59 @@
+package uci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clfs/chess/pgn"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGamePosition(t *testing.T) {
+	cases := []struct {
+		pgn  string
+		want PositionParams
+	}{
+		{"1. e4 e5 (1... c5) 2. Nf3 *", PositionParams{StartPos: true, Moves: moves("e2e4", "e7e5", "g1f3")}},
+		{
+			"[FEN \"4k3/8/8/8/8/8/8/4K2R w K - 0 1\"]\n\n1. O-O *",
+			PositionParams{FEN: "4k3/8/8/8/8/8/8/4K2R w K - 0 1", Moves: moves("e1g1")},
+		},
+	}
+	for _, tc := range cases {
+		g, err := pgn.NewReader(strings.NewReader(tc.pgn)).Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tc.want, GamePosition(g)); diff != "" {
+			t.Errorf("%q: mismatch (-want +got):\n%s", tc.pgn, diff)
+		}
+	}
+}
+
+func TestSANPosition(t *testing.T) {
+	got, err := SANPosition("", []string{"e4", "e5", "Nf3", "Nc6", "Bb5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PositionParams{StartPos: true, Moves: moves("e2e4", "e7e5", "g1f3", "b8c6", "f1b5")}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	const fen = "4k3/8/8/8/8/8/8/4K2R w K - 0 1"
+	got, err = SANPosition(fen, []string{"O-O", "Kd7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = PositionParams{FEN: fen, Moves: moves("e1g1", "e8d7")}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := SANPosition("", []string{"e4", "e4"}); err == nil {
+		t.Error("illegal move: want error")
+	}
+	if _, err := SANPosition("bogus", nil); err == nil {
+		t.Error("invalid FEN: want error")
+	}
+}
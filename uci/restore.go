@@ -0,0 +1,74 @@
+package uci
+
+import (
+	"context"
+	"strings"
+)
+
+// optionValue is an option set with SetOption.
+type optionValue struct {
+	name, value string
+}
+
+// recordOption records that an option was set, for Restore. The caller must
+// hold c.mu.
+func (c *Client) recordOption(name, value string) {
+	for i, o := range c.set {
+		if strings.EqualFold(o.name, name) {
+			c.set = append(c.set[:i], c.set[i+1:]...)
+			break
+		}
+	}
+	c.set = append(c.set, optionValue{name, value})
+}
+
+// Restore brings c, a client for a replacement engine, to the state of old,
+// typically the client of an engine that crashed, so that the replacement can
+// take over in the middle of a game.
+//
+// Restore performs the "uci" handshake, unless it was done already. It then
+// sets the options that were set on old with SetOption, SetChess960 or
+// SetVariant, in the order they were last set, and sends "ucinewgame" and the last position sent
+// to old, if any. Finally, it waits for the engine to be ready, or for ctx to
+// be done.
+func (c *Client) Restore(ctx context.Context, old *Client) error {
+	old.mu.Lock()
+	set := append([]optionValue(nil), old.set...)
+	newGame, position := old.newGame, old.position
+	old.mu.Unlock()
+
+	c.mu.Lock()
+	handshook := c.options != nil
+	c.mu.Unlock()
+	if !handshook {
+		if _, _, _, err := c.UCIContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range set {
+		var err error
+		switch v, ok := variantFromName(o.value); {
+		case strings.EqualFold(o.name, "UCI_Chess960"):
+			err = c.SetChess960(o.value == "true")
+		case strings.EqualFold(o.name, "UCI_Variant") && ok:
+			err = c.SetVariant(v)
+		default:
+			err = c.SetOption(o.name, o.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if newGame {
+		if err := c.UCINewGame(); err != nil {
+			return err
+		}
+	}
+	if position != nil {
+		if err := c.Position(*position); err != nil {
+			return err
+		}
+	}
+	return c.IsReadyContext(ctx)
+}
@@ -0,0 +1,82 @@
+package uci
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_Restore(t *testing.T) {
+	old := NewClient(strings.NewReader(""), io.Discard)
+	for _, f := range []func() error{
+		func() error { return old.SetOption("Hash", "64") },
+		func() error { return old.SetOption("Threads", "2") },
+		func() error { return old.SetChess960(true) },
+		func() error { return old.SetOption("hash", "128") },
+		old.UCINewGame,
+		func() error { return old.PositionStartPos(moves("e2e4", "e7e5")) },
+	} {
+		if err := f(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := helperCommand()
+	cmd.Env = append(cmd.Env, "UCI_HELPER_OPTIONS=Hash type spin default 16 min 1 max 1024;Threads type spin default 1 min 1 max 64;UCI_Chess960 type check default false")
+	e, err := StartEngineCmd(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	e.OnSend(func(line string) {
+		mu.Lock()
+		sent = append(sent, line)
+		mu.Unlock()
+	})
+	if err := e.Restore(context.Background(), old); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"uci",
+		"setoption name Threads value 2",
+		"setoption name UCI_Chess960 value true",
+		"setoption name hash value 128",
+		"ucinewgame",
+		"position startpos moves e2e4 e7e5",
+		"isready",
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(want, sent); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if !e.Chess960() {
+		t.Error("Chess960 isn't on")
+	}
+}
+
+func TestClient_Restore_Handshake(t *testing.T) {
+	// A fresh client with nothing to restore only needs to be ready, and the
+	// handshake isn't repeated.
+	e := startHelperEngine(t)
+	defer e.Close()
+	if _, _, _, err := e.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	var sent []string
+	e.OnSend(func(line string) { sent = append(sent, line) })
+	if err := e.Restore(context.Background(), NewClient(strings.NewReader(""), io.Discard)); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"isready"}, sent); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,83 @@
+package uci
+
+import (
+	"fmt"
+	"math"
+)
+
+// String returns the score in pawns from the point of view of the side to
+// move, such as "+1.53" or "-0.20", or as a mate, such as "#3" or "#-3". A
+// checkmated position is "#0". Bounds aren't shown.
+func (s Score) String() string {
+	if s.Mate.Found {
+		return fmt.Sprintf("#%d", s.Mate.MovesUntil)
+	}
+	if s.CP == 0 {
+		return "0.00"
+	}
+	return fmt.Sprintf("%+.2f", float64(s.CP)/100)
+}
+
+// WinProbability returns the expected score of the side to move, from 0 to 1,
+// using the logistic model of the Elo rating system: a centipawn is treated
+// as an Elo point, so an advantage of 400 centipawns wins ten times as often as
+// it loses. Mating scores return 1, and mated scores 0.
+func (s Score) WinProbability() float64 {
+	if s.Mate.Found {
+		if s.Mate.MovesUntil > 0 {
+			return 1
+		}
+		return 0
+	}
+	return 1 / (1 + math.Pow(10, -float64(s.CP)/400))
+}
+
+// mateValue ranks mates above any centipawn score.
+const mateValue = math.MaxInt32
+
+// rank returns the value of the score for ordering, and a tiebreak for bounds.
+func (s Score) rank() (value int64, bound int) {
+	switch {
+	case s.Mate.Found && s.Mate.MovesUntil > 0:
+		// Mating sooner is better.
+		value = mateValue - int64(s.Mate.MovesUntil)
+	case s.Mate.Found:
+		// Being mated later is better.
+		value = -mateValue - int64(s.Mate.MovesUntil)
+	default:
+		value = int64(s.CP)
+	}
+	switch {
+	case s.LowerBound:
+		bound = 1
+	case s.UpperBound:
+		bound = -1
+	}
+	return value, bound
+}
+
+// Compare returns -1 if s is worse than t for the side to move, 1 if it is
+// better, and 0 if they are equal. Any mate is better than any centipawn score,
+// and a quicker mate is better than a slower one; being mated is worse than
+// any centipawn score, and sooner is worse than later. Between equal values, an
+// upper bound is worse than an exact score, which is worse than a lower bound.
+func (s Score) Compare(t Score) int {
+	sv, sb := s.rank()
+	tv, tb := t.rank()
+	switch {
+	case sv < tv:
+		return -1
+	case sv > tv:
+		return 1
+	case sb < tb:
+		return -1
+	case sb > tb:
+		return 1
+	}
+	return 0
+}
+
+// Less reports whether s is worse than t for the side to move. See Compare.
+func (s Score) Less(t Score) bool {
+	return s.Compare(t) < 0
+}
@@ -0,0 +1,94 @@
+package uci
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func mateScore(n int) Score {
+	var s Score
+	s.Mate.Found = true
+	s.Mate.MovesUntil = n
+	return s
+}
+
+func TestScore_String(t *testing.T) {
+	cases := []struct {
+		s    Score
+		want string
+	}{
+		{Score{CP: 153}, "+1.53"},
+		{Score{CP: -20}, "-0.20"},
+		{Score{CP: 5}, "+0.05"},
+		{Score{}, "0.00"},
+		{Score{CP: 31, LowerBound: true}, "+0.31"},
+		{mateScore(3), "#3"},
+		{mateScore(-3), "#-3"},
+		{mateScore(0), "#0"},
+	}
+	for _, tc := range cases {
+		if got := tc.s.String(); got != tc.want {
+			t.Errorf("%+v.String() = %q, want %q", tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestScore_WinProbability(t *testing.T) {
+	cases := []struct {
+		s    Score
+		want float64
+	}{
+		{Score{}, 0.5},
+		{Score{CP: 400}, 10.0 / 11},
+		{Score{CP: -400}, 1.0 / 11},
+		{mateScore(5), 1},
+		{mateScore(-5), 0},
+		{mateScore(0), 0},
+	}
+	for _, tc := range cases {
+		if got := tc.s.WinProbability(); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%v.WinProbability() = %v, want %v", tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestScore_Compare(t *testing.T) {
+	// From worst to best.
+	want := []Score{
+		mateScore(0),
+		mateScore(-1),
+		mateScore(-10),
+		{CP: -300},
+		{CP: 0, UpperBound: true},
+		{CP: 0},
+		{CP: 0, LowerBound: true},
+		{CP: 25},
+		mateScore(10),
+		mateScore(1),
+	}
+	got := make([]Score, len(want))
+	for i, j := range []int{5, 9, 0, 3, 7, 1, 6, 8, 2, 4} {
+		got[i] = want[j]
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Less(got[j]) })
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("order mismatch (-want +got):\n%s", diff)
+	}
+
+	for i, s := range want {
+		if c := s.Compare(s); c != 0 {
+			t.Errorf("%v.Compare(itself) = %d", s, c)
+		}
+		if i > 0 {
+			if c := s.Compare(want[i-1]); c != 1 {
+				t.Errorf("%v.Compare(%v) = %d, want 1", s, want[i-1], c)
+			}
+			if c := want[i-1].Compare(s); c != -1 {
+				t.Errorf("%v.Compare(%v) = %d, want -1", want[i-1], s, c)
+			}
+		}
+	}
+}
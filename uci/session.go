@@ -0,0 +1,194 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/timecontrol"
+)
+
+// ErrGameOver is returned by GameSession methods called after the game has
+// ended.
+var ErrGameOver = errors.New("uci: game over")
+
+// GameConfig describes a game for NewGameSession.
+type GameConfig struct {
+	FEN     string            // The starting position. Empty means the variant's.
+	Options map[string]string // Options to set before the game starts.
+
+	// Variant is the variant to play, which is set with Client.SetVariant
+	// unless it is standard chess.
+	Variant chess.Variant
+
+	// Engine is the side the engine plays.
+	Engine chess.Color
+
+	// Control sets both clocks, and may have several periods or a delay.
+	// Time and Increment are a shorthand for a single period with a Fischer
+	// increment. If neither is set, there is no clock, and each move is
+	// searched with the limits in Search instead, such as a fixed depth.
+	Control         timecontrol.Control
+	Time, Increment time.Duration
+	Search          Search
+}
+
+// GameSession plays a game against an engine. It owns the engine's side of the
+// protocol: the handshake, options, "ucinewgame", the position before each
+// search, and the clocks. The opponent, such as a person or another program,
+// only passes in its moves.
+//
+// A GameSession is not safe for concurrent use.
+type GameSession struct {
+	c      *Client
+	cfg    GameConfig
+	params PositionParams
+	game   *chess.Game
+	clock  *timecontrol.Clock // Nil if there is no clock.
+
+	// turnStart is when the opponent's turn began, for its clock.
+	turnStart time.Time
+}
+
+// NewGameSession prepares c to play the game described by cfg. It performs
+// the "uci" handshake, unless it was done already, sets the options, and
+// sends "ucinewgame". If the engine moves first, call EngineMove next, and
+// otherwise PlayMove.
+func NewGameSession(ctx context.Context, c *Client, cfg GameConfig) (*GameSession, error) {
+	start := chess.VariantStartingPosition(cfg.Variant)
+	if cfg.FEN != "" {
+		var err error
+		if start, err = chess.ParseVariantFEN(cfg.Variant, cfg.FEN); err != nil {
+			return nil, fmt.Errorf("uci: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	handshook := c.options != nil
+	c.mu.Unlock()
+	if !handshook {
+		if _, _, _, err := c.UCIContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Variant != chess.Standard {
+		if err := c.SetVariant(cfg.Variant); err != nil {
+			return nil, err
+		}
+	}
+	for name, value := range cfg.Options {
+		if err := c.SetOption(name, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.UCINewGame(); err != nil {
+		return nil, err
+	}
+	if err := c.IsReadyContext(ctx); err != nil {
+		return nil, err
+	}
+
+	s := &GameSession{
+		c:         c,
+		cfg:       cfg,
+		params:    PositionParams{FEN: cfg.FEN, StartPos: cfg.FEN == ""},
+		game:      chess.NewGameFromPosition(start),
+		turnStart: time.Now(),
+	}
+	control := cfg.Control
+	if len(control) == 0 && cfg.Time != 0 {
+		control = timecontrol.Control{{Time: cfg.Time, Increment: cfg.Increment}}
+	}
+	if len(control) > 0 {
+		s.clock = timecontrol.NewClock(control)
+	}
+	return s, nil
+}
+
+// PlayMove plays m, the opponent's move, and returns the engine's reply, along
+// with the last search information that had a principal variation. If m ends
+// the game, PlayMove returns ErrGameOver without asking the engine for a move.
+func (s *GameSession) PlayMove(ctx context.Context, m chess.Move) (chess.Move, Info, error) {
+	if s.game.Position().SideToMove() == s.cfg.Engine {
+		return chess.Move{}, Info{}, errors.New("uci: not the opponent's turn")
+	}
+	if err := s.game.Play(m); err != nil {
+		return chess.Move{}, Info{}, fmt.Errorf("uci: %w", err)
+	}
+	// The session doesn't adjudicate, so a side that runs out of time plays
+	// on with an empty clock.
+	if s.clock != nil {
+		s.clock.Punch(1-s.cfg.Engine, time.Since(s.turnStart))
+	}
+	return s.EngineMove(ctx)
+}
+
+// EngineMove has the engine search the current position and plays its move,
+// which it returns along with the last search information that had a
+// principal variation. It is called by PlayMove, and directly when the engine
+// moves first.
+func (s *GameSession) EngineMove(ctx context.Context) (chess.Move, Info, error) {
+	if s.game.Outcome() != chess.NoOutcome {
+		return chess.Move{}, Info{}, ErrGameOver
+	}
+	side := s.game.Position().SideToMove()
+	if side != s.cfg.Engine {
+		return chess.Move{}, Info{}, errors.New("uci: not the engine's turn")
+	}
+
+	s.params.Moves = s.game.Moves()
+	if err := s.c.Position(s.params); err != nil {
+		return chess.Move{}, Info{}, err
+	}
+	start := time.Now()
+	infoCh, bestCh, err := s.c.GoContext(ctx, s.search())
+	if err != nil {
+		return chess.Move{}, Info{}, err
+	}
+	var last Info
+	for info := range infoCh {
+		if len(info.PV) > 0 && info.MultiPV <= 1 {
+			last = info
+		}
+	}
+	bm, ok := <-bestCh
+	if !ok {
+		if err := ctx.Err(); err != nil {
+			return chess.Move{}, last, err
+		}
+		return chess.Move{}, last, errors.New("uci: engine stopped without a best move")
+	}
+	if s.clock != nil {
+		s.clock.Punch(side, time.Since(start))
+	}
+
+	if err := s.game.Play(bm.Move); err != nil {
+		return chess.Move{}, last, fmt.Errorf("uci: engine move: %w", err)
+	}
+	s.turnStart = time.Now()
+	return bm.Move, last, nil
+}
+
+// search returns the limits of the engine's next search.
+func (s *GameSession) search() Search {
+	if s.clock == nil {
+		return s.cfg.Search
+	}
+	return ClockSearch(timecontrol.Manager{}.Limits(s.clock, s.cfg.Engine))
+}
+
+// Game returns the game so far. It must not be modified.
+func (s *GameSession) Game() *chess.Game {
+	return s.game
+}
+
+// Clock returns the time left on side's clock, which is zero if the side has
+// run out of time or there is no clock.
+func (s *GameSession) Clock(side chess.Color) time.Duration {
+	if s.clock == nil {
+		return 0
+	}
+	return s.clock.Remaining[side]
+}
@@ -0,0 +1,163 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/timecontrol"
+	"github.com/google/go-cmp/cmp"
+)
+
+// sessionEngine returns a client connected to a fake engine that answers each
+// "go" with the next of replies, and a function returning the commands it
+// received.
+func sessionEngine(t *testing.T, replies ...string) (*Client, func() []string) {
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	go func() {
+		s := bufio.NewScanner(engineIn)
+		for s.Scan() {
+			cmd := s.Text()
+			mu.Lock()
+			received = append(received, cmd)
+			mu.Unlock()
+			switch {
+			case cmd == "uci":
+				io.WriteString(engineOut, "id name Fake\noption name Hash type spin default 16 min 1 max 64\nuciok\n")
+			case cmd == "isready":
+				io.WriteString(engineOut, "readyok\n")
+			case strings.HasPrefix(cmd, "go"):
+				m := replies[0]
+				replies = replies[1:]
+				io.WriteString(engineOut, "info depth 1 score cp 5 pv "+m+"\ninfo string done\nbestmove "+m+"\n")
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		engineOut.Close()
+		w.Close()
+	})
+	return NewClient(r, w), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), received...)
+	}
+}
+
+func TestGameSession(t *testing.T) {
+	c, received := sessionEngine(t, "e7e5", "b8c6")
+	ctx := context.Background()
+	s, err := NewGameSession(ctx, c, GameConfig{
+		Options: map[string]string{"Hash": "32"},
+		Engine:  chess.Black,
+		Search:  Search{Depth: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.EngineMove(ctx); err == nil {
+		t.Error("EngineMove on White's turn: want error")
+	}
+
+	m, info, err := s.PlayMove(ctx, move("e2e4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != move("e7e5") || info.Score.CP != 5 || len(info.PV) != 1 {
+		t.Errorf("got %v with %+v", m, info)
+	}
+	if _, _, err := s.PlayMove(ctx, move("e4e5")); err == nil {
+		t.Error("illegal move: want error")
+	}
+	if m, _, err = s.PlayMove(ctx, move("g1f3")); err != nil || m != move("b8c6") {
+		t.Errorf("got %v, %v", m, err)
+	}
+
+	want := []string{
+		"uci",
+		"setoption name Hash value 32",
+		"ucinewgame",
+		"isready",
+		"position startpos moves e2e4",
+		"go depth 3",
+		"position startpos moves e2e4 e7e5 g1f3",
+		"go depth 3",
+	}
+	if diff := cmp.Diff(want, received()); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(moves("e2e4", "e7e5", "g1f3", "b8c6"), s.Game().Moves()); diff != "" {
+		t.Errorf("moves mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGameSession_Clock(t *testing.T) {
+	c, received := sessionEngine(t, "e2e4")
+	ctx := context.Background()
+	s, err := NewGameSession(ctx, c, GameConfig{
+		FEN:       "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1",
+		Engine:    chess.White,
+		Time:      time.Minute,
+		Increment: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.EngineMove(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := received()[4]; got != "go wtime 60000 btime 60000 winc 1000 binc 1000" {
+		t.Errorf("got %q", got)
+	}
+	if got := s.Clock(chess.White); got <= time.Minute || got > time.Minute+time.Second {
+		t.Errorf("White's clock: got %v", got)
+	}
+	if got := s.Clock(chess.Black); got != time.Minute {
+		t.Errorf("Black's clock: got %v", got)
+	}
+}
+
+func TestGameSession_Control(t *testing.T) {
+	c, received := sessionEngine(t, "e2e4")
+	ctx := context.Background()
+	s, err := NewGameSession(ctx, c, GameConfig{
+		FEN:     "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1",
+		Engine:  chess.White,
+		Control: timecontrol.MustParse("2/60:30"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.EngineMove(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := received()[4]; got != "go wtime 60000 btime 60000 movestogo 2" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestGameSession_GameOver(t *testing.T) {
+	c, _ := sessionEngine(t)
+	ctx := context.Background()
+	s, err := NewGameSession(ctx, c, GameConfig{FEN: "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", Engine: chess.Black, Search: Search{Depth: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.PlayMove(ctx, move("a1a8")); !errors.Is(err, ErrGameOver) {
+		t.Errorf("want %v, got %v", ErrGameOver, err)
+	}
+	if s.Game().Outcome() != chess.WhiteWon {
+		t.Errorf("got outcome %v", s.Game().Outcome())
+	}
+}
@@ -0,0 +1,160 @@
+package uci
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// SignalError is returned by Shutdown.Stop when a signal started the shutdown.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e *SignalError) Error() string {
+	return fmt.Sprintf("uci: shut down by %v", e.Signal)
+}
+
+// ExitCode returns the conventional exit status of a process ended by the
+// signal: 128 plus the signal number, such as 130 for SIGINT and 143 for
+// SIGTERM.
+func (e *SignalError) ExitCode() int {
+	if s, ok := e.Signal.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// Shutdown ties a graceful shutdown to SIGINT and SIGTERM, so that command-line
+// tools behave well under Ctrl-C. See ShutdownOnSignal.
+type Shutdown struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	grace  time.Duration
+	sigCh  chan os.Signal
+	done   chan struct{} // Closed when the clients are closed after a signal.
+
+	mu      sync.Mutex
+	clients []*Client
+	sig     os.Signal // The signal received, if any.
+	stopped bool
+}
+
+// ShutdownOnSignal arranges for a graceful shutdown when the process receives
+// SIGINT or SIGTERM. The signal cancels the shutdown's context, and then every
+// client given here or to Add is sent "stop" and closed with Close, which has
+// grace to let the engine exit before it is terminated.
+//
+// Searches, matches and pools shut down by being run with the context:
+// GoContext and SearchBatch stop their searches, and match.Tournament.Play
+// closes its engines and returns the games it finished. Engines started outside
+// of them, such as those of a pool, are added with Add as they start.
+//
+// The caller then writes out partial results, and calls Stop, which reports
+// the signal so that the process can exit with the matching status:
+//
+//	sh := uci.ShutdownOnSignal(context.Background(), 5*time.Second)
+//	res, err := t.Play(sh.Context())
+//	// Write out res.
+//	var se *uci.SignalError
+//	if errors.As(sh.Stop(), &se) {
+//		os.Exit(se.ExitCode())
+//	}
+func ShutdownOnSignal(parent context.Context, grace time.Duration, clients ...*Client) *Shutdown {
+	ctx, cancel := context.WithCancel(parent)
+	s := &Shutdown{
+		ctx:     ctx,
+		cancel:  cancel,
+		grace:   grace,
+		sigCh:   make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+		clients: clients,
+	}
+	signal.Notify(s.sigCh, os.Interrupt, syscall.SIGTERM)
+	go s.wait()
+	return s
+}
+
+// Context returns a context that is cancelled when a signal arrives, or when
+// Stop is called.
+func (s *Shutdown) Context() context.Context {
+	return s.ctx
+}
+
+// Add adds a client to close on a signal. If a signal has already arrived, c
+// is closed at once.
+func (s *Shutdown) Add(c *Client) {
+	s.mu.Lock()
+	if s.sig == nil {
+		s.clients = append(s.clients, c)
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	s.close(c)
+}
+
+// wait waits for a signal, and shuts down when it arrives.
+func (s *Shutdown) wait() {
+	sig, ok := <-s.sigCh
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	if s.stopped {
+		// Stop won the race with a signal that was already queued, and
+		// promised to leave the clients running.
+		s.mu.Unlock()
+		return
+	}
+	s.sig = sig
+	clients := s.clients
+	s.mu.Unlock()
+
+	s.cancel()
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			s.close(c)
+		}(c)
+	}
+	wg.Wait()
+	close(s.done)
+}
+
+// close stops c's search, if any, and closes c.
+func (s *Shutdown) close(c *Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.grace)
+	defer cancel()
+	c.Stop()
+	c.Close(ctx)
+}
+
+// Stop ends the arrangement, and cancels the context. If a signal arrived, it
+// waits for the clients to be closed, and returns a *SignalError. Otherwise
+// the clients are left running, and Stop returns nil. Stop may be called more
+// than once.
+func (s *Shutdown) Stop() error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		signal.Stop(s.sigCh)
+		if s.sig == nil {
+			close(s.sigCh)
+		}
+	}
+	sig := s.sig
+	s.mu.Unlock()
+	s.cancel()
+	if sig == nil {
+		return nil
+	}
+	<-s.done
+	return &SignalError{Signal: sig}
+}
@@ -0,0 +1,114 @@
+package uci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestShutdownOnSignal(t *testing.T) {
+	var w syncBuffer
+	c := NewClient(bytes.NewReader(nil), &w)
+	sh := ShutdownOnSignal(context.Background(), time.Second, c)
+	defer sh.Stop()
+	var added syncBuffer
+	sh.Add(NewClient(bytes.NewReader(nil), &added))
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("os.FindProcess: %v", err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot signal self: %v", err)
+	}
+
+	select {
+	case <-sh.Context().Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for shutdown")
+	}
+	var se *SignalError
+	if err := sh.Stop(); !errors.As(err, &se) || se.ExitCode() != 130 {
+		t.Errorf("Stop() = %v, want a *SignalError with exit code 130", err)
+	}
+	for _, b := range []*syncBuffer{&w, &added} {
+		if want, got := "stop\nquit\n", b.String(); want != got {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+
+	// Clients added after the signal are closed at once.
+	var late syncBuffer
+	sh.Add(NewClient(bytes.NewReader(nil), &late))
+	if want, got := "stop\nquit\n", late.String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestShutdownOnSignal_Stop(t *testing.T) {
+	var w syncBuffer
+	sh := ShutdownOnSignal(context.Background(), time.Second, NewClient(bytes.NewReader(nil), &w))
+	if err := sh.Stop(); err != nil {
+		t.Errorf("Stop() = %v, want nil", err)
+	}
+	if err := sh.Stop(); err != nil {
+		t.Errorf("second Stop() = %v, want nil", err)
+	}
+	if sh.Context().Err() == nil {
+		t.Error("context not cancelled by Stop")
+	}
+	if w.String() != "" {
+		t.Errorf("got %q sent without a signal, want nothing", w.String())
+	}
+}
+
+// A signal that arrives just before Stop either shuts down the clients, and
+// is reported by Stop, or is ignored.
+func TestShutdownOnSignal_SignalBeforeStop(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		var w syncBuffer
+		sh := ShutdownOnSignal(context.Background(), time.Second, NewClient(bytes.NewReader(nil), &w))
+		sh.sigCh <- os.Interrupt
+		err := sh.Stop()
+		// Give a late shutdown time to show up.
+		time.Sleep(time.Millisecond)
+		got := w.String()
+		if err == nil && got != "" {
+			t.Fatalf("Stop() = nil, but the client was sent %q", got)
+		}
+		if err != nil && got != "stop\nquit\n" {
+			t.Fatalf("Stop() = %v, but the client was sent %q", err, got)
+		}
+	}
+}
+
+func TestSignalError_ExitCode(t *testing.T) {
+	for sig, want := range map[os.Signal]int{syscall.SIGINT: 130, syscall.SIGTERM: 143} {
+		if got := (&SignalError{Signal: sig}).ExitCode(); got != want {
+			t.Errorf("ExitCode() for %v = %d, want %d", sig, got, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package uci
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OptionSnapshot is the set of options an engine advertises, saved to be
+// compared with that of another build of the engine with DiffOptions. Its JSON
+// encoding is stable, so snapshots can be kept next to tournament
+// configurations.
+type OptionSnapshot struct {
+	Name    string   // The engine's name, as reported by "id name".
+	Author  string   // The engine's author, as reported by "id author".
+	Options []Option // The options, in the order the engine advertised them.
+}
+
+// OptionSnapshot returns a snapshot of the engine's options, as advertised in
+// response to the last "uci" command.
+func (c *Client) OptionSnapshot() OptionSnapshot {
+	name, author := c.ID()
+	return OptionSnapshot{Name: name, Author: author, Options: c.Options()}
+}
+
+// OptionDiff is the difference between two option snapshots. Options are
+// matched by name, ignoring case, as engines do. All lists are sorted by name.
+type OptionDiff struct {
+	Added   []Option       // Options only the new snapshot has.
+	Removed []Option       // Options only the old snapshot has.
+	Changed []OptionChange // Options both have, with different declarations.
+}
+
+// OptionChange is an option whose declaration changed between two snapshots.
+type OptionChange struct {
+	Old, New Option
+
+	// Fields are the parts of the declaration that changed, as named in
+	// "option" lines: "name" if only its case changed, "type", "default",
+	// "min", "max" and "var".
+	Fields []string
+}
+
+// DiffOptions compares the options of two snapshots of an engine, such as
+// before and after an upgrade.
+func DiffOptions(before, after OptionSnapshot) OptionDiff {
+	var d OptionDiff
+	for _, o := range after.Options {
+		prev, ok := lookupOption(before.Options, o.OptionName())
+		if !ok {
+			d.Added = append(d.Added, o)
+			continue
+		}
+		if fields := changedFields(optionFields(prev), optionFields(o)); len(fields) > 0 {
+			d.Changed = append(d.Changed, OptionChange{Old: prev, New: o, Fields: fields})
+		}
+	}
+	for _, o := range before.Options {
+		if _, ok := lookupOption(after.Options, o.OptionName()); !ok {
+			d.Removed = append(d.Removed, o)
+		}
+	}
+	sortOptions(d.Added)
+	sortOptions(d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool {
+		return optionLess(d.Changed[i].New, d.Changed[j].New)
+	})
+	return d
+}
+
+// sortOptions sorts opts by name, ignoring case.
+func sortOptions(opts []Option) {
+	sort.Slice(opts, func(i, j int) bool { return optionLess(opts[i], opts[j]) })
+}
+
+// optionLess reports whether a's name sorts before b's, ignoring case.
+func optionLess(a, b Option) bool {
+	return strings.ToLower(a.OptionName()) < strings.ToLower(b.OptionName())
+}
+
+// IsZero reports whether the snapshots had the same options.
+func (d OptionDiff) IsZero() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String returns the difference as one line per option: added options as
+// "+" and their "option" line, removed ones as "-" and their "option" line,
+// and changed ones as "~" and their changes, such as
+// "~ Hash: default 16 -> 64, max 1024 -> 33554432".
+func (d OptionDiff) String() string {
+	var b strings.Builder
+	for _, o := range d.Added {
+		fmt.Fprintf(&b, "+ %s\n", optionText(o))
+	}
+	for _, o := range d.Removed {
+		fmt.Fprintf(&b, "- %s\n", optionText(o))
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&b, "~ %s\n", c)
+	}
+	return b.String()
+}
+
+// String returns the change as the option's name and the old and new values of
+// each changed field, such as "Hash: default 16 -> 64".
+func (c OptionChange) String() string {
+	before, after := optionFields(c.Old), optionFields(c.New)
+	parts := make([]string, len(c.Fields))
+	for i, f := range c.Fields {
+		parts[i] = fmt.Sprintf("%s %s -> %s", f, before.field(f), after.field(f))
+	}
+	return fmt.Sprintf("%s: %s", c.New.OptionName(), strings.Join(parts, ", "))
+}
+
+// optionText returns o as an "option" line, or its name if it can't be
+// marshaled.
+func optionText(o Option) string {
+	text, err := o.MarshalText()
+	if err != nil {
+		return o.OptionName()
+	}
+	return string(text)
+}
+
+// optionFields returns the declaration of o in generic form. Fields that o's
+// type doesn't have are empty.
+func optionFields(o Option) optionLine {
+	l := optionLine{name: o.OptionName()}
+	switch o := o.(type) {
+	case CheckOption:
+		l.typ, l.def = CheckOptionType, strconv.FormatBool(o.Default)
+	case SpinOption:
+		l.typ, l.def = SpinOptionType, strconv.Itoa(o.Default)
+		l.min, l.max = strconv.Itoa(o.Min), strconv.Itoa(o.Max)
+	case ComboOption:
+		l.typ, l.def, l.vars = ComboOptionType, o.Default, o.Vars
+	case ButtonOption:
+		l.typ = ButtonOptionType
+	case StringOption:
+		l.typ, l.def = StringOptionType, o.Default
+	default:
+		l.typ = fmt.Sprintf("%T", o)
+	}
+	return l
+}
+
+// changedFields returns the names of the fields that differ between two
+// declarations of an option.
+func changedFields(before, after optionLine) []string {
+	var fields []string
+	for _, f := range []string{"name", "type", "default", "min", "max", "var"} {
+		if before.field(f) != after.field(f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// field returns the value of the field called f, as written in an "option"
+// line. Empty values are written "<empty>", and combo values are separated by
+// spaces.
+func (l optionLine) field(f string) string {
+	var v string
+	switch f {
+	case "name":
+		v = l.name
+	case "type":
+		v = l.typ
+	case "default":
+		v = l.def
+	case "min":
+		v = l.min
+	case "max":
+		v = l.max
+	case "var":
+		v = strings.Join(l.vars, " ")
+	}
+	if v == "" {
+		return "<empty>"
+	}
+	return v
+}
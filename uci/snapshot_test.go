@@ -0,0 +1,96 @@
+package uci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_OptionSnapshot(t *testing.T) {
+	c := fakeEngine(t, func(cmd string) string {
+		if cmd == "uci" {
+			return "id name Fake 2\nid author Someone\noption name Hash type spin default 16 min 1 max 64\noption name Ponder type check default false\nuciok\n"
+		}
+		return ""
+	})
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	want := OptionSnapshot{
+		Name:   "Fake 2",
+		Author: "Someone",
+		Options: []Option{
+			SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 64},
+			CheckOption{Name: "Ponder"},
+		},
+	}
+	if diff := cmp.Diff(want, c.OptionSnapshot()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	before := OptionSnapshot{Options: []Option{
+		SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 1024},
+		CheckOption{Name: "Ponder"},
+		ComboOption{Name: "Style", Default: "Normal", Vars: []string{"Solid", "Normal"}},
+		StringOption{Name: "SyzygyPath"},
+		ButtonOption{Name: "Clear Hash"},
+		SpinOption{Name: "Contempt", Default: 24, Min: -100, Max: 100},
+	}}
+	after := OptionSnapshot{Options: []Option{
+		SpinOption{Name: "Threads", Default: 1, Min: 1, Max: 512},
+		SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 33554432},
+		CheckOption{Name: "ponder"},
+		ComboOption{Name: "Style", Default: "Solid", Vars: []string{"Solid", "Normal", "Risky"}},
+		StringOption{Name: "SyzygyPath", Default: "/tb"},
+		ButtonOption{Name: "Clear Hash"},
+		CheckOption{Name: "UCI_ShowWDL"},
+	}}
+	got := DiffOptions(before, after)
+	want := OptionDiff{
+		Added: []Option{
+			SpinOption{Name: "Threads", Default: 1, Min: 1, Max: 512},
+			CheckOption{Name: "UCI_ShowWDL"},
+		},
+		Removed: []Option{SpinOption{Name: "Contempt", Default: 24, Min: -100, Max: 100}},
+		Changed: []OptionChange{
+			{Old: before.Options[0], New: after.Options[1], Fields: []string{"max"}},
+			{Old: before.Options[1], New: after.Options[2], Fields: []string{"name"}},
+			{Old: before.Options[2], New: after.Options[3], Fields: []string{"default", "var"}},
+			{Old: before.Options[3], New: after.Options[4], Fields: []string{"default"}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	wantText := `+ option name Threads type spin default 1 min 1 max 512
++ option name UCI_ShowWDL type check default false
+- option name Contempt type spin default 24 min -100 max 100
+~ Hash: max 1024 -> 33554432
+~ ponder: name Ponder -> ponder
+~ Style: default Normal -> Solid, var Solid Normal -> Solid Normal Risky
+~ SyzygyPath: default <empty> -> /tb
+`
+	if diff := cmp.Diff(wantText, got.String()); diff != "" {
+		t.Errorf("String mismatch (-want +got):\n%s", diff)
+	}
+
+	if d := DiffOptions(before, before); !d.IsZero() {
+		t.Errorf("got %v comparing a snapshot with itself, want no difference", d)
+	}
+}
+
+func TestDiffOptions_TypeChange(t *testing.T) {
+	before := OptionSnapshot{Options: []Option{SpinOption{Name: "Skill", Default: 20, Min: 0, Max: 20}}}
+	after := OptionSnapshot{Options: []Option{StringOption{Name: "Skill", Default: "max"}}}
+	got := DiffOptions(before, after)
+	if len(got.Changed) != 1 {
+		t.Fatalf("got %v, want one change", got)
+	}
+	want := "Skill: type spin -> string, default 20 -> max, min 0 -> <empty>, max 20 -> <empty>"
+	if s := got.Changed[0].String(); s != want {
+		t.Errorf("got %q, want %q", s, want)
+	}
+}
@@ -0,0 +1,59 @@
+package sse
+
+import "time"
+
+// bucket is the token bucket of a client.
+type bucket struct {
+	tokens float64
+	last   time.Time // When tokens was last updated.
+}
+
+// allow reports whether the client may make a request now, taking a token
+// from its bucket if so, or else how long until it may.
+func (s *Server) allow(client string) (time.Duration, bool) {
+	if s.Rate <= 0 {
+		return 0, true
+	}
+	burst := float64(s.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := now()
+	s.sweep(t, burst)
+	if s.buckets == nil {
+		s.buckets = map[string]*bucket{}
+	}
+	b, ok := s.buckets[client]
+	if !ok {
+		b = &bucket{tokens: burst, last: t}
+		s.buckets[client] = b
+	}
+	b.tokens += t.Sub(b.last).Seconds() * s.Rate
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = t
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / s.Rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// sweep forgets the clients whose buckets have refilled, since they are as
+// good as new, at most once per refill time. The caller must hold s.mu.
+func (s *Server) sweep(t time.Time, burst float64) {
+	refill := time.Duration(burst / s.Rate * float64(time.Second))
+	if t.Sub(s.swept) < refill {
+		return
+	}
+	s.swept = t
+	for client, b := range s.buckets {
+		if t.Sub(b.last) >= refill {
+			delete(s.buckets, client)
+		}
+	}
+}
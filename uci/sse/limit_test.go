@@ -0,0 +1,52 @@
+package sse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServer_allow(t *testing.T) {
+	t0 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := t0
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	s := &Server{Rate: 2, Burst: 3}
+	for _, step := range []struct {
+		at     time.Duration
+		client string
+		wait   time.Duration
+		ok     bool
+	}{
+		{0, "a", 0, true},
+		{0, "a", 0, true},
+		{0, "a", 0, true},
+		{0, "a", 500 * time.Millisecond, false},
+		{0, "b", 0, true}, // Clients have separate buckets.
+		{250 * time.Millisecond, "a", 250 * time.Millisecond, false},
+		{500 * time.Millisecond, "a", 0, true},
+		{500 * time.Millisecond, "a", 500 * time.Millisecond, false},
+	} {
+		clock = t0.Add(step.at)
+		wait, ok := s.allow(step.client)
+		if wait != step.wait || ok != step.ok {
+			t.Errorf("at %v, client %s: got (%v, %v), want (%v, %v)", step.at, step.client, wait, ok, step.wait, step.ok)
+		}
+	}
+
+	// Once their buckets refill, clients are forgotten.
+	clock = t0.Add(time.Minute)
+	s.allow("c")
+	if len(s.buckets) != 1 {
+		t.Errorf("got %d buckets after a minute, want 1", len(s.buckets))
+	}
+}
+
+func TestServer_allow_Unlimited(t *testing.T) {
+	s := &Server{}
+	for i := 0; i < 100; i++ {
+		if _, ok := s.allow("a"); !ok {
+			t.Fatalf("request %d refused", i)
+		}
+	}
+}
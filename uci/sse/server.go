@@ -0,0 +1,263 @@
+// Package sse serves engine analysis over HTTP, streaming search information
+// as Server-Sent Events, so that a small analysis service needs little more
+// than an engine and this handler:
+//
+//	http.Handle("/analyze", &sse.Server{Start: start, Rate: 1, Burst: 5})
+//
+// Each request analyzes one position with an engine from Server.Start. A GET
+// request, as sent by a browser's EventSource, has the position and limits in
+// its query:
+//
+//	/analyze?fen=...&moves=e2e4+e7e5&depth=20
+//
+// The query parameters are "fen", "moves", separated by spaces, and the
+// limits "depth", "nodes", "mate" and "movetime", in milliseconds. A POST
+// request has them in a JSON body instead, with the limits in the JSON
+// encoding of uci.Search:
+//
+//	{"fen": "...", "moves": ["e2e4", "e7e5"], "search": {"depth": 20}}
+//
+// A request without "fen" starts from the standard starting position, and one
+// without limits searches until the client disconnects or MaxTime passes.
+// Invalid requests are answered with status 400, before any engine is
+// searched. The response is a stream of events with JSON data:
+//
+//	event: info
+//	data: {"depth": 20, "score": {"cp": 31}, "pv": ["e2e4"]}
+//
+//	event: bestmove
+//	data: {"move": "e2e4", "ponder": "e7e5"}
+//
+// The stream ends after "bestmove", or after an "error" event, whose data is
+// {"error": "..."}, if the engine fails during the search.
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// Server is an http.Handler that streams engine analysis.
+type Server struct {
+	// Start starts an engine for a request. The client must have completed
+	// the "uci" handshake. The closer is called when the search ends. Start
+	// may also hand out engines from a pool, with a closer that returns the
+	// engine to it; the engine is idle by then.
+	Start func() (*uci.Client, io.Closer, error)
+
+	// MaxTime limits every search, if non-zero.
+	MaxTime time.Duration
+
+	// Rate limits the requests of each client to Rate per second on
+	// average, in bursts of up to Burst requests, or 1 if Burst is zero.
+	// Requests over the limit are answered with status 429. If Rate is zero,
+	// requests are unlimited.
+	Rate  float64
+	Burst int
+
+	// ClientID identifies the client of a request, for Rate. If nil, clients
+	// are identified by their IP address.
+	ClientID func(r *http.Request) string
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// request is the body of a POST request.
+type request struct {
+	FEN    string       `json:"fen"`
+	Moves  []chess.Move `json:"moves"`
+	Search *uci.Search  `json:"search"`
+}
+
+// ServeHTTP analyzes the position of r, and streams the search.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if wait, ok := s.allow(s.clientID(r)); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+	req, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	c, closer, err := s.Start()
+	if err != nil {
+		http.Error(w, "engine unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer closer.Close()
+	if err := checkPosition(c.Variant(), req.FEN, req.Moves); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if s.MaxTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.MaxTime)
+		defer cancel()
+	}
+	err = c.Position(uci.PositionParams{StartPos: req.FEN == "", FEN: req.FEN, Moves: req.Moves})
+	if err != nil {
+		http.Error(w, "engine unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	infoCh, bestCh, err := c.GoContext(ctx, *req.Search)
+	if err != nil {
+		http.Error(w, "engine unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Write errors are ignored: the request's context is then done, which
+	// stops the search, and the channels are drained so that the engine is
+	// idle when it is closed.
+	for info := range infoCh {
+		writeEvent(w, "info", info)
+		flusher.Flush()
+	}
+	if bm, ok := <-bestCh; ok {
+		writeEvent(w, "bestmove", bm)
+	} else {
+		writeEvent(w, "error", struct {
+			Error string `json:"error"`
+		}{"engine stopped without a best move"})
+	}
+	flusher.Flush()
+}
+
+// parseRequest returns the position and limits of r. The search is never nil.
+func parseRequest(r *http.Request) (*request, error) {
+	var req request
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, fmt.Errorf("invalid request: %w", err)
+		}
+	} else if err := req.parseQuery(r); err != nil {
+		return nil, err
+	}
+	if req.Search == nil {
+		req.Search = &uci.Search{}
+	}
+	switch s := req.Search; {
+	case s.Ponder:
+		return nil, errors.New("ponder searches aren't supported")
+	case s.Depth == 0 && s.Nodes == 0 && s.Mate == 0 && s.MoveTime == 0 &&
+		s.WhiteTime == 0 && s.BlackTime == 0:
+		s.Infinite = true
+	}
+	return &req, nil
+}
+
+// parseQuery sets the fields of req from the query of r.
+func (req *request) parseQuery(r *http.Request) error {
+	q := r.URL.Query()
+	req.FEN = q.Get("fen")
+	for _, f := range strings.Fields(q.Get("moves")) {
+		m, err := chess.ParseMove(f)
+		if err != nil {
+			return err
+		}
+		req.Moves = append(req.Moves, m)
+	}
+
+	s := &uci.Search{}
+	for _, p := range []struct {
+		name string
+		set  func(n int64)
+	}{
+		{"depth", func(n int64) { s.Depth = int(n) }},
+		{"nodes", func(n int64) { s.Nodes = n }},
+		{"mate", func(n int64) { s.Mate = int(n) }},
+		{"movetime", func(n int64) { s.MoveTime = time.Duration(n) * time.Millisecond }},
+	} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid %s %q", p.name, v)
+		}
+		p.set(n)
+	}
+	req.Search = s
+	return nil
+}
+
+// checkPosition checks that fen, or the starting position if it is empty, is
+// valid and that moves are legal from it, since engines may crash on positions
+// that aren't.
+func checkPosition(v chess.Variant, fen string, moves []chess.Move) error {
+	p := chess.VariantStartingPosition(v)
+	if fen != "" {
+		var err error
+		if p, err = chess.ParseVariantFEN(v, fen); err != nil {
+			return err
+		}
+	}
+	for _, m := range moves {
+		m = p.ConvertCastling(m, p.Chess960())
+		if !p.IsLegal(m) {
+			return fmt.Errorf("illegal move %v in position %v", m, p)
+		}
+		p.Apply(m)
+	}
+	return nil
+}
+
+// writeEvent writes an event with v as its JSON data.
+func writeEvent(w io.Writer, event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+func (s *Server) clientID(r *http.Request) string {
+	if s.ClientID != nil {
+		return s.ClientID(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,207 @@
+package sse
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/uci"
+)
+
+// event is an event of a response.
+type event struct {
+	name, data string
+}
+
+// readEvents reads the events of a response until it ends.
+func readEvents(t *testing.T, r io.Reader) []event {
+	t.Helper()
+	var events []event
+	var e event
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			events = append(events, e)
+			e = event{}
+		case strings.HasPrefix(line, "event: "):
+			e.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		default:
+			t.Fatalf("unexpected line %q", line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func startRefEngine() (*uci.Client, io.Closer, error) {
+	c, closer, err := refengine.New().Client()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, _, _, err := c.UCI(); err != nil {
+		closer.Close()
+		return nil, nil, err
+	}
+	return c, closer, nil
+}
+
+func refServer(t *testing.T, s *Server) *httptest.Server {
+	t.Helper()
+	if s.Start == nil {
+		s.Start = startRefEngine
+	}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// checkSearch checks that events are the events of a finished search, and
+// returns its best move.
+func checkSearch(t *testing.T, events []event) uci.BestMove {
+	t.Helper()
+	if len(events) < 2 {
+		t.Fatalf("got %d events, want info and a best move", len(events))
+	}
+	for _, e := range events[:len(events)-1] {
+		var info uci.Info
+		if e.name != "info" {
+			t.Fatalf("got %q event during the search", e.name)
+		}
+		if err := json.Unmarshal([]byte(e.data), &info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	last := events[len(events)-1]
+	if last.name != "bestmove" {
+		t.Fatalf("got %q event, want bestmove", last.name)
+	}
+	var bm uci.BestMove
+	if err := json.Unmarshal([]byte(last.data), &bm); err != nil {
+		t.Fatal(err)
+	}
+	return bm
+}
+
+func TestServer_Get(t *testing.T) {
+	srv := refServer(t, &Server{})
+	q := url.Values{"fen": {"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"}, "depth": {"2"}}
+	resp, err := http.Get(srv.URL + "?" + q.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("got content type %q", got)
+	}
+	if bm := checkSearch(t, readEvents(t, resp.Body)); bm.Move.String() != "a1a8" {
+		t.Errorf("got best move %v, want a1a8", bm.Move)
+	}
+}
+
+func TestServer_Post(t *testing.T) {
+	srv := refServer(t, &Server{})
+	body := `{"moves": ["e2e4", "e7e5"], "search": {"depth": 1}}`
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	checkSearch(t, readEvents(t, resp.Body))
+}
+
+func TestServer_MaxTime(t *testing.T) {
+	srv := refServer(t, &Server{MaxTime: 50 * time.Millisecond})
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	checkSearch(t, readEvents(t, resp.Body))
+}
+
+func TestServer_BadRequests(t *testing.T) {
+	srv := refServer(t, &Server{})
+	for _, q := range []string{
+		"fen=nonsense",
+		"moves=e2e5",
+		"moves=zz",
+		"depth=-1",
+		"nodes=many",
+	} {
+		resp, err := http.Get(srv.URL + "?" + q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want %d", q, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	for _, body := range []string{`{`, `{"search": {"ponder": true, "depth": 1}}`} {
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want %d", body, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE: got status %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServer_EngineUnavailable(t *testing.T) {
+	srv := refServer(t, &Server{Start: func() (*uci.Client, io.Closer, error) {
+		return nil, nil, errors.New("pool exhausted")
+	}})
+	resp, err := http.Get(srv.URL + "?depth=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	srv := refServer(t, &Server{Rate: 0.001, Burst: 2})
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		resp, err := http.Get(srv.URL + "?depth=1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("request %d: got status %d, want %d", i, resp.StatusCode, want)
+		}
+		if want == http.StatusTooManyRequests && resp.Header.Get("Retry-After") == "" {
+			t.Error("no Retry-After header")
+		}
+	}
+}
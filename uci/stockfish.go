@@ -0,0 +1,288 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clfs/chess"
+)
+
+// PerftResult is the result of Stockfish's "go perft" command.
+type PerftResult struct {
+	Moves map[chess.Move]int // Leaf nodes under each legal move.
+	Nodes int                // Leaf nodes in total.
+}
+
+// Perft runs Stockfish's "go perft" command to depth from the current position
+// and returns the number of leaf nodes under each move, like chess.Divide. It
+// blocks until the count is done, which may take a while at high depths.
+func (c *Client) Perft(ctx context.Context, depth int) (*PerftResult, error) {
+	lines, err := c.captureFunc(ctx, func() error {
+		return c.send("go perft %d", depth)
+	}, func(line string) bool { return strings.HasPrefix(line, "Nodes searched:") })
+	if err != nil {
+		return nil, err
+	}
+	return ParsePerft(lines)
+}
+
+// ParsePerft parses the output of Stockfish's "go perft" command: a line such
+// as "e2e4: 20" for each move, followed by "Nodes searched: 400". Other lines
+// are ignored.
+func ParsePerft(lines []string) (*PerftResult, error) {
+	res := &PerftResult{Moves: make(map[chess.Move]int)}
+	total := false
+	for _, line := range lines {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		if key == "Nodes searched" {
+			res.Nodes, total = n, true
+			continue
+		}
+		if m, err := chess.ParseMove(key); err == nil {
+			res.Moves[m] = n
+		}
+	}
+	if !total {
+		return nil, errors.New("uci: no node count in perft output")
+	}
+	return res, nil
+}
+
+// BenchResult is the result of Stockfish's "bench" command.
+type BenchResult struct {
+	Positions []BenchPosition
+
+	// Totals, from Stockfish's summary if it printed one on standard output,
+	// and otherwise from the positions.
+	Nodes int64
+	Time  time.Duration
+	NPS   int64
+}
+
+// BenchPosition is the search of one position during a benchmark.
+type BenchPosition struct {
+	FEN      string // The position, if the engine reported it.
+	Depth    int
+	Nodes    int64
+	NPS      int64
+	Time     time.Duration
+	BestMove chess.Move
+}
+
+// Bench runs Stockfish's "bench" command with the given arguments, such as
+// "16", "1", "13", "default", "depth", and returns its results. It blocks until
+// the benchmark is done, which may take a while.
+//
+// Stockfish prints the summary and the positions on standard error; if it
+// isn't visible, the totals are computed from the searches. The end of the
+// benchmark is detected by following it with "isready", so no other commands
+// should be in progress.
+func (c *Client) Bench(ctx context.Context, args ...string) (*BenchResult, error) {
+	cmd := strings.Join(append([]string{"bench"}, args...), " ")
+	lines, err := c.captureUntilReady(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBench(lines)
+}
+
+// captureUntilReady sends cmd, then "isready", and returns the lines before
+// "readyok".
+func (c *Client) captureUntilReady(ctx context.Context, cmd string) ([]string, error) {
+	lines, err := c.captureFunc(ctx, func() error {
+		if err := c.SendRaw(cmd); err != nil {
+			return err
+		}
+		return c.send("isready")
+	}, func(line string) bool { return line == "readyok" })
+	if err != nil {
+		return lines, err
+	}
+	return lines[:len(lines)-1], nil
+}
+
+// ParseBench parses the output of Stockfish's "bench" command. Each search
+// ends with a "bestmove" line, and its statistics come from the last "info"
+// line before it.
+func ParseBench(lines []string) (*BenchResult, error) {
+	res := &BenchResult{}
+	var (
+		cur     BenchPosition
+		summary bool
+	)
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "info "):
+			info, err := ParseInfo(line)
+			if err != nil {
+				continue
+			}
+			if info.Depth > 0 {
+				cur.Depth = info.Depth
+			}
+			if info.Nodes > 0 {
+				cur.Nodes, cur.NPS, cur.Time = info.Nodes, info.NPS, info.Time
+			}
+		case strings.HasPrefix(line, "bestmove"):
+			bm, err := parseBestMove(line)
+			if err != nil {
+				return nil, err
+			}
+			cur.BestMove = bm.Move
+			res.Positions = append(res.Positions, cur)
+			cur = BenchPosition{}
+		case strings.HasPrefix(line, "Position:"):
+			// Position: 3/50 (r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 10)
+			if i, j := strings.IndexByte(line, '('), strings.LastIndexByte(line, ')'); i >= 0 && j > i {
+				cur.FEN = line[i+1 : j]
+			}
+		default:
+			name, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				continue
+			}
+			switch strings.TrimSpace(name) {
+			case "Total time (ms)":
+				res.Time, summary = time.Duration(n)*time.Millisecond, true
+			case "Nodes searched":
+				res.Nodes, summary = n, true
+			case "Nodes/second":
+				res.NPS, summary = n, true
+			}
+		}
+	}
+	if len(res.Positions) == 0 {
+		return nil, errors.New("uci: no searches in bench output")
+	}
+	if !summary {
+		for _, p := range res.Positions {
+			res.Nodes += p.Nodes
+			res.Time += p.Time
+		}
+		if ms := res.Time.Milliseconds(); ms > 0 {
+			res.NPS = res.Nodes * 1000 / ms
+		}
+	}
+	return res, nil
+}
+
+// Eval is the result of Stockfish's "eval" command: the static evaluation of
+// the current position, in pawns from White's point of view.
+type Eval struct {
+	Final float64
+
+	// Components holds the partial evaluations the engine reported, such
+	// as "NNUE" and "Classical", by name.
+	Components map[string]float64
+
+	// Terms is the table of evaluation terms printed by versions of
+	// Stockfish with a classical evaluation.
+	Terms []EvalTerm
+}
+
+// EvalTerm is a row of the table of evaluation terms. Values are in pawns,
+// for the middlegame and endgame phases; missing values are NaN.
+type EvalTerm struct {
+	Name                string
+	White, Black, Total [2]float64
+}
+
+// ErrNoEval is returned by Eval and ParseEval when the engine has no static
+// evaluation for the position, such as when the side to move is in check.
+var ErrNoEval = errors.New("uci: no static evaluation")
+
+// Eval runs Stockfish's "eval" command on the current position, which is
+// set with Position. No other commands should be in progress.
+func (c *Client) Eval(ctx context.Context) (*Eval, error) {
+	lines, err := c.captureUntilReady(ctx, "eval")
+	if err != nil {
+		return nil, err
+	}
+	return ParseEval(lines)
+}
+
+// ParseEval parses the output of Stockfish's "eval" command.
+func ParseEval(lines []string) (*Eval, error) {
+	e := &Eval{Components: make(map[string]float64)}
+	final := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Final evaluation: none") {
+			return nil, ErrNoEval
+		}
+		if name, v, ok := parseEvalLine(line); ok {
+			if name == "Final" {
+				e.Final, final = v, true
+			} else {
+				e.Components[name] = v
+			}
+			continue
+		}
+		if t, ok := parseEvalTerm(line); ok {
+			e.Terms = append(e.Terms, t)
+		}
+	}
+	if !final {
+		return nil, errors.New("uci: no final evaluation in eval output")
+	}
+	return e, nil
+}
+
+// parseEvalLine parses a line such as "NNUE evaluation +0.25 (white side)".
+func parseEvalLine(line string) (name string, v float64, ok bool) {
+	f := strings.Fields(line)
+	if len(f) < 3 || f[1] != "evaluation" {
+		return "", 0, false
+	}
+	v, err := strconv.ParseFloat(f[2], 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return f[0], v, true
+}
+
+// parseEvalTerm parses a row of the table of evaluation terms, such as
+//
+//	Material |  ----  ---- |  ----  ---- |  0.00  0.00
+func parseEvalTerm(line string) (EvalTerm, bool) {
+	cols := strings.Split(line, "|")
+	if len(cols) != 4 {
+		return EvalTerm{}, false
+	}
+	t := EvalTerm{Name: strings.TrimSpace(cols[0])}
+	for i, dst := range []*[2]float64{&t.White, &t.Black, &t.Total} {
+		f := strings.Fields(cols[i+1])
+		if len(f) != 2 {
+			return EvalTerm{}, false
+		}
+		for j, s := range f {
+			if strings.Trim(s, "-") == "" {
+				dst[j] = math.NaN()
+				continue
+			}
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				// The header rows.
+				return EvalTerm{}, false
+			}
+			dst[j] = v
+		}
+	}
+	return t, true
+}
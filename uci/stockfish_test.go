@@ -0,0 +1,163 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// stockfishEngine answers "bench" and "eval" like Stockfish does on standard
+// output.
+func stockfishEngine(cmd string) string {
+	switch cmd {
+	case "bench 16 1 2":
+		return "info depth 1 seldepth 1 multipv 1 score cp 20 nodes 20 nps 20000 time 1 pv e2e4\n" +
+			"info depth 2 seldepth 2 multipv 1 score cp 30 nodes 60 nps 30000 time 2 pv e2e4 e7e5\n" +
+			"bestmove e2e4 ponder e7e5\n" +
+			"info string NNUE evaluation using nn.nnue\n" +
+			"info depth 1 seldepth 1 multipv 1 score cp -10 nodes 40 nps 40000 time 1 pv a7a6\n" +
+			"info depth 2 seldepth 3 multipv 1 score cp -5 nodes 140 nps 35000 time 4 pv a7a6 a2a3\n" +
+			"bestmove a7a6\n"
+	case "eval":
+		return stockfishEval
+	case "go perft 1":
+		return "info string NNUE evaluation using nn.nnue\n" +
+			"a2a3: 1\n" + "b2b4: 1\n" + "g1f3: 1\n" + "\n" + "Nodes searched: 3\n" + "\n"
+	case "isready":
+		return "readyok\n"
+	}
+	return ""
+}
+
+const stockfishEval = `
+     Term    |    White    |    Black    |    Total
+             |   MG    EG  |   MG    EG  |   MG    EG
+ ------------+-------------+-------------+------------
+    Material |  ----  ---- |  ----  ---- |  0.00  0.00
+   Imbalance |  ----  ---- |  ----  ---- |  0.00  0.00
+       Pawns |  0.43 -0.09 |  0.43 -0.09 |  0.00  0.00
+     Mobility | -0.81 -1.13 | -0.73 -1.06 | -0.08 -0.07
+ ------------+-------------+-------------+------------
+       Total |  ----  ---- |  ----  ---- |  0.12  0.07
+
+Classical evaluation   +0.12 (white side)
+NNUE evaluation        +0.25 (white side)
+Final evaluation       +0.19 (white side) [with scaled NNUE, hybrid, ...]
+`
+
+func TestClient_Perft(t *testing.T) {
+	c := fakeEngine(t, stockfishEngine)
+	res, err := c.Perft(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &PerftResult{
+		Moves: map[chess.Move]int{move("a2a3"): 1, move("b2b4"): 1, move("g1f3"): 1},
+		Nodes: 3,
+	}
+	if diff := cmp.Diff(want, res); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParsePerft([]string{"a2a3: 1"}); err == nil {
+		t.Error("ParsePerft without a total: want error")
+	}
+}
+
+func TestClient_Bench(t *testing.T) {
+	c := fakeEngine(t, stockfishEngine)
+	res, err := c.Bench(context.Background(), "16", "1", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &BenchResult{
+		Positions: []BenchPosition{
+			{Depth: 2, Nodes: 60, NPS: 30000, Time: 2 * time.Millisecond, BestMove: move("e2e4")},
+			{Depth: 2, Nodes: 140, NPS: 35000, Time: 4 * time.Millisecond, BestMove: move("a7a6")},
+		},
+		Nodes: 200,
+		Time:  6 * time.Millisecond,
+		NPS:   33333,
+	}
+	if diff := cmp.Diff(want, res); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseBench_Summary(t *testing.T) {
+	lines := []string{
+		"Position: 1/1 (rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1)",
+		"info depth 13 nodes 5000 nps 500000 time 10 pv e2e4",
+		"bestmove e2e4",
+		"===========================",
+		"Total time (ms) : 12",
+		"Nodes searched  : 5000",
+		"Nodes/second    : 416666",
+	}
+	res, err := ParseBench(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &BenchResult{
+		Positions: []BenchPosition{{
+			FEN:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			Depth: 13, Nodes: 5000, NPS: 500000, Time: 10 * time.Millisecond, BestMove: move("e2e4"),
+		}},
+		Nodes: 5000,
+		Time:  12 * time.Millisecond,
+		NPS:   416666,
+	}
+	if diff := cmp.Diff(want, res); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseBench([]string{"Nodes searched  : 5000"}); err == nil {
+		t.Error("want error without searches")
+	}
+}
+
+func TestClient_Eval(t *testing.T) {
+	c := fakeEngine(t, stockfishEngine)
+	e, err := c.Eval(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	nan := math.NaN()
+	want := &Eval{
+		Final:      0.19,
+		Components: map[string]float64{"Classical": 0.12, "NNUE": 0.25},
+		Terms: []EvalTerm{
+			{Name: "Material", White: [2]float64{nan, nan}, Black: [2]float64{nan, nan}, Total: [2]float64{0, 0}},
+			{Name: "Imbalance", White: [2]float64{nan, nan}, Black: [2]float64{nan, nan}, Total: [2]float64{0, 0}},
+			{Name: "Pawns", White: [2]float64{0.43, -0.09}, Black: [2]float64{0.43, -0.09}, Total: [2]float64{0, 0}},
+			{Name: "Mobility", White: [2]float64{-0.81, -1.13}, Black: [2]float64{-0.73, -1.06}, Total: [2]float64{-0.08, -0.07}},
+			{Name: "Total", White: [2]float64{nan, nan}, Black: [2]float64{nan, nan}, Total: [2]float64{0.12, 0.07}},
+		},
+	}
+	if diff := cmp.Diff(want, e, cmpopts.EquateNaNs()); diff != "" {
+		t.Errorf("eval mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseEval(t *testing.T) {
+	e, err := ParseEval([]string{"NNUE evaluation        -0.40 (white side)", "Final evaluation       -0.38 (white side) [with scaled NNUE, optimism, ...]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Final != -0.38 || e.Components["NNUE"] != -0.40 || e.Terms != nil {
+		t.Errorf("unexpected eval %+v", e)
+	}
+
+	if _, err := ParseEval([]string{"Final evaluation: none (in check)"}); !errors.Is(err, ErrNoEval) {
+		t.Errorf("in check: want ErrNoEval, got %v", err)
+	}
+	if _, err := ParseEval([]string{"NNUE evaluation +0.1 (white side)"}); err == nil {
+		t.Error("want error without a final evaluation")
+	}
+}
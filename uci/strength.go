@@ -0,0 +1,114 @@
+package uci
+
+import (
+	"math"
+	"strconv"
+)
+
+// StrengthMethod is how SetStrength weakens an engine.
+type StrengthMethod int
+
+const (
+	// StrengthElo sets the standard "UCI_LimitStrength" and "UCI_Elo"
+	// options.
+	StrengthElo StrengthMethod = iota
+
+	// StrengthSkillLevel sets a "Skill Level" option, as in Stockfish, mapped
+	// from the rating.
+	StrengthSkillLevel
+
+	// StrengthNodes limits the number of nodes of each search. It is the
+	// fallback for engines with neither option, and the caller must apply it
+	// by adding Strength.Search to each search.
+	StrengthNodes
+)
+
+func (m StrengthMethod) String() string {
+	switch m {
+	case StrengthElo:
+		return "UCI_Elo"
+	case StrengthSkillLevel:
+		return "Skill Level"
+	case StrengthNodes:
+		return "nodes"
+	}
+	return "StrengthMethod(" + strconv.Itoa(int(m)) + ")"
+}
+
+// Strength is how SetStrength configured an engine.
+type Strength struct {
+	Method StrengthMethod
+
+	// Elo is the approximate rating configured. It differs from the requested
+	// rating when that is outside the range the method supports.
+	Elo int
+
+	// Search holds the limits to add to each search, for StrengthNodes. It is
+	// zero for the other methods.
+	Search Search
+}
+
+// The ratings mapped onto the range of a "Skill Level" option, and onto the
+// node limits of StrengthNodes. Both mappings are rough: the strength of an
+// engine at a given level depends on the engine, the hardware and the time
+// control.
+const (
+	skillMinElo = 1000
+	skillMaxElo = 3000
+	nodesMinElo = 600
+	nodesMaxElo = 3000
+)
+
+// SetStrength limits the engine to play at about the given Elo rating. It uses
+// the first of these that the engine advertised in response to the last "uci"
+// command: the "UCI_LimitStrength" and "UCI_Elo" options, a "Skill Level"
+// option, or else a node limit, which the caller applies to each search. The
+// returned Strength reports which method was used and the rating it
+// corresponds to.
+func (c *Client) SetStrength(elo int) (Strength, error) {
+	c.mu.Lock()
+	limit, hasLimit := lookupOption(c.options, "UCI_LimitStrength")
+	rating, hasRating := lookupOption(c.options, "UCI_Elo")
+	skill, hasSkill := lookupOption(c.options, "Skill Level")
+	c.mu.Unlock()
+
+	if _, ok := limit.(CheckOption); ok && hasLimit && hasRating {
+		if spin, ok := rating.(SpinOption); ok {
+			elo = clamp(elo, spin.Min, spin.Max)
+			if err := c.SetOption(limit.OptionName(), "true"); err != nil {
+				return Strength{}, err
+			}
+			if err := c.SetOption(spin.Name, strconv.Itoa(elo)); err != nil {
+				return Strength{}, err
+			}
+			return Strength{Method: StrengthElo, Elo: elo}, nil
+		}
+	}
+
+	if spin, ok := skill.(SpinOption); ok && hasSkill && spin.Max > spin.Min {
+		elo = clamp(elo, skillMinElo, skillMaxElo)
+		span := float64(spin.Max - spin.Min)
+		level := spin.Min + int(math.Round(float64(elo-skillMinElo)/(skillMaxElo-skillMinElo)*span))
+		if err := c.SetOption(spin.Name, strconv.Itoa(level)); err != nil {
+			return Strength{}, err
+		}
+		elo = skillMinElo + int(math.Round(float64(level-spin.Min)/span*(skillMaxElo-skillMinElo)))
+		return Strength{Method: StrengthSkillLevel, Elo: elo}, nil
+	}
+
+	// Each doubling of the nodes is worth about 100 Elo at these depths.
+	elo = clamp(elo, nodesMinElo, nodesMaxElo)
+	nodes := int64(math.Round(math.Exp2(float64(elo-nodesMinElo) / 100)))
+	return Strength{Method: StrengthNodes, Elo: elo, Search: Search{Nodes: nodes}}, nil
+}
+
+// clamp returns n limited to the range [lo, hi].
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
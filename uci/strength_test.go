@@ -0,0 +1,99 @@
+package uci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_SetStrength(t *testing.T) {
+	cases := []struct {
+		name    string
+		options string
+		elo     int
+		want    Strength
+		cmds    []string
+	}{
+		{
+			"elo",
+			"option name UCI_LimitStrength type check default false\n" +
+				"option name UCI_Elo type spin default 1320 min 1320 max 3190\n" +
+				"option name Skill Level type spin default 20 min 0 max 20\n",
+			1500,
+			Strength{Method: StrengthElo, Elo: 1500},
+			[]string{"setoption name UCI_LimitStrength value true", "setoption name UCI_Elo value 1500"},
+		},
+		{
+			"elo clamped",
+			"option name UCI_LimitStrength type check default false\n" +
+				"option name UCI_Elo type spin default 1320 min 1320 max 3190\n",
+			800,
+			Strength{Method: StrengthElo, Elo: 1320},
+			[]string{"setoption name UCI_LimitStrength value true", "setoption name UCI_Elo value 1320"},
+		},
+		{
+			"skill level",
+			"option name Skill Level type spin default 20 min 0 max 20\n",
+			1500,
+			Strength{Method: StrengthSkillLevel, Elo: 1500},
+			[]string{"setoption name Skill Level value 5"},
+		},
+		{
+			"skill level rounded",
+			"option name Skill Level type spin default 20 min 0 max 20\n",
+			1520,
+			Strength{Method: StrengthSkillLevel, Elo: 1500},
+			[]string{"setoption name Skill Level value 5"},
+		},
+		{
+			"nodes",
+			"option name Hash type spin default 16 min 1 max 1024\n",
+			1500,
+			Strength{Method: StrengthNodes, Elo: 1500, Search: Search{Nodes: 512}},
+			nil,
+		},
+		{
+			"nodes clamped",
+			"",
+			0,
+			Strength{Method: StrengthNodes, Elo: 600, Search: Search{Nodes: 1}},
+			nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := &recorder{uci: c.options}
+			client := fakeEngine(t, r.respond)
+			if _, _, _, err := client.UCI(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := client.SetStrength(c.elo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("strength mismatch (-want +got):\n%s", diff)
+			}
+			if err := client.IsReady(); err != nil {
+				t.Fatal(err)
+			}
+			want := append(append([]string{"uci"}, c.cmds...), "isready")
+			if diff := cmp.Diff(want, r.commands()); diff != "" {
+				t.Errorf("commands mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStrengthMethod_String(t *testing.T) {
+	for m, want := range map[StrengthMethod]string{
+		StrengthElo:        "UCI_Elo",
+		StrengthSkillLevel: "Skill Level",
+		StrengthNodes:      "nodes",
+		StrengthMethod(9):  "StrengthMethod(9)",
+	} {
+		if got := m.String(); got != want {
+			t.Errorf("%d: want %q, got %q", int(m), want, got)
+		}
+	}
+}
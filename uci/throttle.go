@@ -0,0 +1,100 @@
+package uci
+
+import "time"
+
+// Update is search information aggregated by Throttle.
+type Update struct {
+	// Lines are the latest complete lines for each MultiPV index, ordered by
+	// index, and Summary summarizes the search so far, as for
+	// InfoAggregator.
+	Lines   []Info
+	Summary SearchSummary
+
+	// Received is the number of "info" lines aggregated since the previous
+	// update.
+	Received int
+
+	// Completed reports whether the update was sent early because the
+	// search completed a depth.
+	Completed bool
+}
+
+// Throttle aggregates the search information of infoCh, as returned by Go,
+// into updates sent at most once per interval, so that engines sending
+// hundreds of "info" lines per second don't overwhelm consumers such as user
+// interfaces. A depth is never skipped: the best line of each new depth is
+// sent at once, and the last update is sent when infoCh is closed, after
+// which the returned channel is closed too.
+//
+// Information keeps being aggregated while the consumer is busy, so a slow
+// consumer only receives fewer updates.
+func Throttle(infoCh <-chan Info, interval time.Duration) <-chan Update {
+	out := make(chan Update)
+	go throttle(infoCh, interval, out)
+	return out
+}
+
+func throttle(in <-chan Info, interval time.Duration, out chan<- Update) {
+	defer close(out)
+	var (
+		agg       InfoAggregator
+		received  int
+		completed bool // Whether a depth completed since the last update.
+		depth     int  // The deepest completed depth sent.
+		last      time.Time
+		timer     *time.Timer
+		armed     bool // Whether timer is running.
+	)
+	for in != nil || received > 0 {
+		var (
+			send chan<- Update
+			u    Update
+			tick <-chan time.Time
+		)
+		if received > 0 {
+			wait := interval - time.Since(last)
+			if in == nil || completed || wait <= 0 {
+				send = out
+				u = Update{Lines: agg.Lines(), Summary: agg.Summary(), Received: received, Completed: completed}
+			} else {
+				if !armed {
+					if timer == nil {
+						timer = time.NewTimer(wait)
+					} else {
+						timer.Reset(wait)
+					}
+					armed = true
+				}
+				tick = timer.C
+			}
+		}
+
+		select {
+		case info, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			agg.Add(info)
+			received++
+			if completesDepth(info) && info.Depth > depth {
+				completed = true
+				depth = info.Depth
+			}
+		case send <- u:
+			received, completed = 0, false
+			last = time.Now()
+		case <-tick:
+			armed = false
+		}
+	}
+	if armed && !timer.Stop() {
+		<-timer.C
+	}
+}
+
+// completesDepth reports whether info is the best line of a depth, which
+// engines send when they complete it.
+func completesDepth(info Info) bool {
+	return len(info.PV) > 0 && info.MultiPV <= 1 && !info.Score.LowerBound && !info.Score.UpperBound
+}
@@ -0,0 +1,114 @@
+package uci
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+)
+
+func TestThrottle(t *testing.T) {
+	e4 := chess.Move{From: chess.E2, To: chess.E4}
+	in := make(chan Info)
+	updates := Throttle(in, time.Hour)
+
+	// The first line of a depth is sent at once.
+	in <- Info{Depth: 1, Score: Score{CP: 10}, PV: []chess.Move{e4}}
+	u := <-updates
+	if !u.Completed || u.Received != 1 || u.Summary.Depth != 1 {
+		t.Errorf("got %+v, want depth 1 completed", u)
+	}
+
+	// Other lines wait for the interval, or for the next depth.
+	in <- Info{Depth: 2, Nodes: 100}
+	in <- Info{Depth: 2, Nodes: 200, CurrMove: e4}
+	in <- Info{Depth: 2, Score: Score{CP: 20, LowerBound: true}, PV: []chess.Move{e4}}
+	select {
+	case u := <-updates:
+		t.Fatalf("got early update %+v", u)
+	case <-time.After(20 * time.Millisecond):
+	}
+	in <- Info{Depth: 2, Score: Score{CP: 15}, PV: []chess.Move{e4}, Nodes: 300}
+	u = <-updates
+	if !u.Completed || u.Received != 4 || u.Summary.Nodes != 300 || u.Summary.Score.CP != 15 {
+		t.Errorf("got %+v, want depth 2 completed after 4 lines", u)
+	}
+	if len(u.Lines) != 1 || u.Lines[0].Depth != 2 {
+		t.Errorf("got lines %+v, want the line of depth 2", u.Lines)
+	}
+
+	// The last update is sent when the search ends.
+	in <- Info{Depth: 3, Nodes: 400}
+	close(in)
+	u, ok := <-updates
+	if !ok || u.Completed || u.Received != 1 || u.Summary.Nodes != 400 {
+		t.Errorf("got %+v, %v; want the last update", u, ok)
+	}
+	if u, ok := <-updates; ok {
+		t.Errorf("got %+v after the end", u)
+	}
+}
+
+func TestThrottle_Interval(t *testing.T) {
+	in := make(chan Info)
+	updates := Throttle(in, 30*time.Millisecond)
+	start := time.Now()
+	go func() {
+		for i := 0; i < 100; i++ {
+			in <- Info{Depth: 1, Nodes: int64(i)}
+			time.Sleep(time.Millisecond)
+		}
+		close(in)
+	}()
+
+	n, received := 0, 0
+	for u := range updates {
+		n++
+		received += u.Received
+	}
+	if received != 100 {
+		t.Errorf("got %d lines in updates, want 100", received)
+	}
+	// At most one update per interval, and the last one.
+	if max := int(time.Since(start)/(30*time.Millisecond)) + 2; n > max {
+		t.Errorf("got %d updates, want at most %d", n, max)
+	}
+}
+
+func TestThrottle_SlowConsumer(t *testing.T) {
+	in := make(chan Info)
+	updates := Throttle(in, 0)
+	// Nothing is received, but the lines are still taken.
+	for i := 0; i < 10; i++ {
+		in <- Info{Depth: 1, Nodes: int64(i)}
+	}
+	close(in)
+	u := <-updates
+	if u.Received != 10 || u.Summary.Nodes != 9 {
+		t.Errorf("got %+v, want all 10 lines in one update", u)
+	}
+	if _, ok := <-updates; ok {
+		t.Error("channel not closed")
+	}
+}
+
+func TestThrottle_Client(t *testing.T) {
+	c := fakeEngine(t, func(cmd string) string {
+		if cmd == "go depth 3" {
+			return "info depth 1 score cp 5 pv e2e4\ninfo depth 2 score cp 8 pv e2e4\ninfo depth 3 score cp 11 pv e2e4\nbestmove e2e4\n"
+		}
+		return ""
+	})
+	infoCh, bestCh, err := c.Go(Search{Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var depths []int
+	for u := range Throttle(infoCh, time.Hour) {
+		depths = append(depths, u.Summary.Depth)
+	}
+	<-bestCh
+	if len(depths) == 0 || depths[len(depths)-1] != 3 {
+		t.Errorf("got updates at depths %v, want the last at depth 3", depths)
+	}
+}
@@ -0,0 +1,137 @@
+package uci
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// hooks holds the functions that observe the raw protocol dialogue.
+type hooks struct {
+	mu        sync.Mutex
+	onSend    []func(line string)
+	onReceive []func(line string)
+	subs      []*subscription // Subscribers to events.
+}
+
+func (h *hooks) sent(line string) {
+	h.mu.Lock()
+	fs := h.onSend
+	h.mu.Unlock()
+	for _, f := range fs {
+		f(line)
+	}
+	h.publish(LineSent{Line: line})
+}
+
+func (h *hooks) received(line string) {
+	h.mu.Lock()
+	fs := h.onReceive
+	h.mu.Unlock()
+	for _, f := range fs {
+		f(line)
+	}
+	h.publish(LineReceived{Line: line})
+}
+
+// OnSend registers f to be called with every line sent to the engine, without
+// the trailing newline. Functions are called in the order they were registered,
+// before the line is written. They must not call methods on c.
+func (c *Client) OnSend(f func(line string)) {
+	c.hooks.mu.Lock()
+	defer c.hooks.mu.Unlock()
+	c.hooks.onSend = append(c.hooks.onSend, f)
+}
+
+// OnReceive registers f to be called with every line received from the engine,
+// without the trailing newline. Functions are called in the order they were
+// registered, before the line is processed. They must not call methods on c.
+func (c *Client) OnReceive(f func(line string)) {
+	c.hooks.mu.Lock()
+	defer c.hooks.mu.Unlock()
+	c.hooks.onReceive = append(c.hooks.onReceive, f)
+}
+
+// TraceTo writes a timestamped transcript of the protocol dialogue to w. Sent
+// lines are marked with ">" and received lines with "<", as in
+//
+//	2022-06-01T12:00:00.000Z > isready
+//	2022-06-01T12:00:00.002Z < readyok
+//
+// Writes to w are serialized. Write errors are ignored.
+//
+// A transcript of a whole session, started before the "uci" handshake, can be
+// read back with ReadTrace and replayed by a fake engine, as with
+// ucitest.Replay.
+func (c *Client) TraceTo(w io.Writer) {
+	var mu sync.Mutex
+	trace := func(dir, line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintln(w, formatTraceLine(now(), dir, line))
+	}
+	c.OnSend(func(line string) { trace(">", line) })
+	c.OnReceive(func(line string) { trace("<", line) })
+}
+
+// TraceLine is a line of the protocol dialogue, as written by TraceTo.
+type TraceLine struct {
+	Time time.Time
+	Sent bool // Whether the line was sent to the engine, rather than received.
+	Line string
+}
+
+// maxTracePrefix is the length of the longest timestamp and direction that
+// can precede a line in a transcript.
+const maxTracePrefix = len("2006-01-02T15:04:05.999999999-07:00 > ")
+
+// ReadTrace reads a transcript written by TraceTo or Engine.DumpTranscript.
+// Blank lines and header lines starting with "#" are skipped. A line of the
+// dialogue longer than DefaultMaxLineLength makes it fail with an error
+// wrapping ErrLineTooLong.
+func ReadTrace(r io.Reader) ([]TraceLine, error) {
+	var lines []TraceLine
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 4096), DefaultMaxLineLength+maxTracePrefix)
+	for n := 1; s.Scan(); n++ {
+		text := s.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		l, err := parseTraceLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("uci: trace line %d: %w", n, err)
+		}
+		lines = append(lines, l)
+	}
+	if err := s.Err(); errors.Is(err, bufio.ErrTooLong) {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrLineTooLong, DefaultMaxLineLength)
+	} else if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseTraceLine parses a line formatted by formatTraceLine.
+func parseTraceLine(s string) (TraceLine, error) {
+	ts, rest, _ := strings.Cut(s, " ")
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return TraceLine{}, err
+	}
+	dir, line, _ := strings.Cut(rest, " ")
+	switch dir {
+	case ">":
+		return TraceLine{Time: t, Sent: true, Line: line}, nil
+	case "<":
+		return TraceLine{Time: t, Line: line}, nil
+	}
+	return TraceLine{}, fmt.Errorf("invalid direction %q", dir)
+}
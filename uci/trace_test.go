@@ -0,0 +1,131 @@
+package uci
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient_TraceTo(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	var trace syncBuffer
+	c := NewClient(strings.NewReader("readyok\n"), io.Discard)
+	c.TraceTo(&trace)
+
+	if err := c.IsReady(); err != nil {
+		t.Fatalf("IsReady: %v", err)
+	}
+	want := "2022-06-01T12:00:00.000Z > isready\n" +
+		"2022-06-01T12:00:00.000Z < readyok\n"
+	if got := trace.String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestClient_OnSend(t *testing.T) {
+	var w bytes.Buffer
+	c := NewClient(strings.NewReader(""), &w)
+
+	var sent []string
+	c.OnSend(func(line string) { sent = append(sent, "a:"+line) })
+	c.OnSend(func(line string) { sent = append(sent, "b:"+line) })
+
+	if err := c.UCINewGame(); err != nil {
+		t.Fatalf("UCINewGame: %v", err)
+	}
+	if want, got := "a:ucinewgame b:ucinewgame", strings.Join(sent, " "); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if want, got := "ucinewgame\n", w.String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestReadTrace(t *testing.T) {
+	trace := `# engine: Fake by Nobody
+# command: /usr/bin/fake
+2022-06-01T12:00:00.000Z > uci
+2022-06-01T12:00:00.001Z < id name Fake
+2022-06-01T12:00:00.001Z < uciok
+
+2022-06-01T12:00:00.250Z > isready
+2022-06-01T12:00:00.252Z < 
+2022-06-01T12:00:00.253Z < readyok
+`
+	got, err := ReadTrace(strings.NewReader(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(ms int) time.Time {
+		return time.Date(2022, 6, 1, 12, 0, 0, ms*int(time.Millisecond), time.UTC)
+	}
+	want := []TraceLine{
+		{Time: at(0), Sent: true, Line: "uci"},
+		{Time: at(1), Line: "id name Fake"},
+		{Time: at(1), Line: "uciok"},
+		{Time: at(250), Sent: true, Line: "isready"},
+		{Time: at(252), Line: ""},
+		{Time: at(253), Line: "readyok"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadTrace_RoundTrip(t *testing.T) {
+	var trace syncBuffer
+	c := NewClient(strings.NewReader("id name Fake\nuciok\n"), io.Discard)
+	c.TraceTo(&trace)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTrace(strings.NewReader(trace.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, l := range got {
+		lines = append(lines, fmt.Sprint(l.Sent, " ", l.Line))
+	}
+	want := []string{"true uci", "false id name Fake", "false uciok"}
+	if diff := cmp.Diff(want, lines); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadTrace_Invalid(t *testing.T) {
+	for _, trace := range []string{
+		"isready\n",
+		"2022-06-01T12:00:00.000Z isready\n",
+		"2022-06-01T12:00:00.000Z = isready\n",
+	} {
+		if _, err := ReadTrace(strings.NewReader(trace)); err == nil {
+			t.Errorf("ReadTrace(%q): want an error", trace)
+		}
+	}
+}
+
+func TestReadTrace_LongLine(t *testing.T) {
+	const prefix = "2022-06-01T12:00:00.000Z < "
+	long := "info string " + strings.Repeat("x", DefaultMaxLineLength-len("info string ")-1)
+	lines, err := ReadTrace(strings.NewReader(prefix + long + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0].Line != long {
+		t.Errorf("got %d lines, want the long line", len(lines))
+	}
+
+	tooLong := long + strings.Repeat("x", 100)
+	if _, err := ReadTrace(strings.NewReader(prefix + tooLong + "\n")); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("want %v, got %v", ErrLineTooLong, err)
+	}
+}
@@ -0,0 +1,85 @@
+package uci
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// transcriptSize is the number of lines an Engine keeps for DumpTranscript.
+const transcriptSize = 1000
+
+// transcript keeps the most recent lines of the protocol dialogue.
+type transcript struct {
+	mu      sync.Mutex
+	entries []transcriptEntry // A ring buffer.
+	next    int               // The index of the next entry to overwrite.
+}
+
+type transcriptEntry struct {
+	t    time.Time
+	dir  string // ">" for sent lines, "<" for received ones.
+	line string
+}
+
+func newTranscript(size int) *transcript {
+	return &transcript{entries: make([]transcriptEntry, 0, size)}
+}
+
+func (t *transcript) add(dir, line string) {
+	e := transcriptEntry{now(), dir, line}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.entries) < cap(t.entries) {
+		t.entries = append(t.entries, e)
+		return
+	}
+	t.entries[t.next] = e
+	t.next = (t.next + 1) % len(t.entries)
+}
+
+// lines returns the entries, oldest first.
+func (t *transcript) lines() []transcriptEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := make([]transcriptEntry, 0, len(t.entries))
+	res = append(res, t.entries[t.next:]...)
+	return append(res, t.entries[:t.next]...)
+}
+
+// formatTraceLine formats a line of the dialogue as in TraceTo.
+func formatTraceLine(t time.Time, dir, line string) string {
+	return fmt.Sprintf("%s %s %s", t.UTC().Format("2006-01-02T15:04:05.000Z07:00"), dir, line)
+}
+
+// DumpTranscript writes the engine's identity, its command line and the
+// options it advertised, followed by the most recent lines of the protocol
+// dialogue in the format of TraceTo, for attaching to bug reports. Header lines
+// start with "#", as in
+//
+//	# engine: Stockfish 15 by the Stockfish developers
+//	# command: /usr/bin/stockfish
+//	# option name Hash type spin default 16 min 1 max 33554432
+//	2022-06-01T12:00:00.000Z > isready
+//	2022-06-01T12:00:00.002Z < readyok
+func (e *Engine) DumpTranscript(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	name, author := e.ID()
+	fmt.Fprintf(bw, "# engine: %s by %s\n", name, author)
+	fmt.Fprintf(bw, "# command: %s\n", strings.Join(e.cmd.Args, " "))
+	for _, o := range e.Options() {
+		text, err := o.MarshalText()
+		if err != nil {
+			text = []byte("option name " + o.OptionName())
+		}
+		fmt.Fprintf(bw, "# %s\n", text)
+	}
+	for _, l := range e.transcript.lines() {
+		fmt.Fprintln(bw, formatTraceLine(l.t, l.dir, l.line))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,55 @@
+package uci
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTranscript(t *testing.T) {
+	tr := newTranscript(3)
+	for _, line := range []string{"a", "b", "c", "d", "e"} {
+		tr.add(">", line)
+	}
+	var got []string
+	for _, e := range tr.lines() {
+		got = append(got, e.line)
+	}
+	if diff := cmp.Diff([]string{"c", "d", "e"}, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEngine_DumpTranscript(t *testing.T) {
+	e := startHelperEngine(t)
+	defer e.Close()
+
+	if _, _, _, err := e.UCI(); err != nil {
+		t.Fatalf("UCI: %v", err)
+	}
+	if err := e.IsReady(); err != nil {
+		t.Fatalf("IsReady: %v", err)
+	}
+
+	var b bytes.Buffer
+	if err := e.DumpTranscript(&b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("got %d lines, want 8:\n%s", len(lines), b.String())
+	}
+	if want := "# engine: Helper by Nobody"; lines[0] != want {
+		t.Errorf("got %q, want %q", lines[0], want)
+	}
+	if !strings.HasPrefix(lines[1], "# command: ") {
+		t.Errorf("got %q, want the command", lines[1])
+	}
+	for i, want := range []string{"> uci", "< id name Helper", "< id author Nobody", "< uciok", "> isready", "< readyok"} {
+		if got := lines[i+2]; !strings.HasSuffix(got, " "+want) {
+			t.Errorf("got %q, want a line ending in %q", got, want)
+		}
+	}
+}
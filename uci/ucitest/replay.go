@@ -0,0 +1,146 @@
+package ucitest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/uci"
+)
+
+// Session is a recorded session to replay, such as a transcript of a real
+// engine written by uci.Client.TraceTo and read with uci.ReadTrace.
+type Session struct {
+	Lines []uci.TraceLine
+
+	// RealTime replays the engine's lines with the delays between them in the
+	// recording, so that timeouts and time management see realistic timing.
+	// Otherwise, they are sent as soon as possible.
+	RealTime bool
+}
+
+// Replay is a fake engine that replays a recorded session. It expects the
+// client to send the recorded commands in the recorded order, and answers
+// each with the lines the engine sent after it, up to the next command. Lines
+// the engine sent before the first command are sent on start.
+//
+// A command that differs from the recording fails the test and disconnects the
+// engine, as does a command after the end of the recording, other than
+// "quit".
+type Replay struct {
+	t       testing.TB
+	session Session
+	client  *uci.Client
+
+	in  io.ReadCloser
+	out io.WriteCloser
+
+	mu     sync.Mutex
+	next   int // The index of the next line to replay.
+	closed bool
+}
+
+// NewReplay starts a fake engine that replays session. The engine stops when
+// the test ends.
+func NewReplay(t testing.TB, session Session) *Replay {
+	t.Helper()
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	e := &Replay{t: t, session: session, in: engineIn, out: engineOut}
+	e.client = uci.NewClient(r, w)
+	t.Cleanup(func() {
+		e.Close()
+		w.Close()
+	})
+	go e.run()
+	return e
+}
+
+// Client returns a client connected to the engine. The handshake hasn't been
+// done.
+func (e *Replay) Client() *uci.Client {
+	return e.client
+}
+
+// Close disconnects the engine, as if its process had exited.
+func (e *Replay) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return
+	}
+	e.closed = true
+	e.out.Close()
+	e.in.Close()
+}
+
+// ExpectDone fails the test unless every recorded command has been received.
+func (e *Replay) ExpectDone() {
+	e.t.Helper()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, l := range e.session.Lines[e.next:] {
+		if l.Sent {
+			e.t.Errorf("ucitest: recorded command %q not received", l.Line)
+			return
+		}
+	}
+}
+
+// run replays the session until the engine is closed.
+func (e *Replay) run() {
+	e.reply(time.Time{})
+	lines := e.session.Lines
+	s := bufio.NewScanner(e.in)
+	for s.Scan() {
+		cmd := s.Text()
+		e.mu.Lock()
+		closed, next := e.closed, e.next
+		e.mu.Unlock()
+		if closed {
+			return
+		}
+
+		switch {
+		case next == len(lines):
+			if cmd != "quit" {
+				e.t.Errorf("ucitest: got command %q after the end of the recording", cmd)
+			}
+			e.Close()
+			return
+		case cmd != lines[next].Line:
+			e.t.Errorf("ucitest: got command %q, want %q", cmd, lines[next].Line)
+			e.Close()
+			return
+		}
+		e.mu.Lock()
+		e.next++
+		e.mu.Unlock()
+		e.reply(lines[next].Time)
+	}
+}
+
+// reply sends the recorded lines up to the next command. In real time, the
+// first is delayed by its time since last, unless last is zero.
+func (e *Replay) reply(last time.Time) {
+	for {
+		e.mu.Lock()
+		if e.closed || e.next == len(e.session.Lines) || e.session.Lines[e.next].Sent {
+			e.mu.Unlock()
+			return
+		}
+		l := e.session.Lines[e.next]
+		e.next++
+		e.mu.Unlock()
+
+		if e.session.RealTime && !last.IsZero() && l.Time.After(last) {
+			time.Sleep(l.Time.Sub(last))
+		}
+		last = l.Time
+		// Errors are ignored, since the client may have disconnected.
+		fmt.Fprintln(e.out, l.Line)
+	}
+}
@@ -0,0 +1,183 @@
+package ucitest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+// errorsTB records the errors reported by a fake engine.
+type errorsTB struct {
+	testing.TB
+	mu   sync.Mutex
+	errs []string
+}
+
+func (t *errorsTB) Helper() {}
+
+func (t *errorsTB) Errorf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+func (t *errorsTB) errors() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.errs...)
+}
+
+// analyze is client logic under test: it analyzes a position to depth 2.
+func analyze(c *uci.Client) (uci.BestMove, []uci.Info, error) {
+	if _, _, _, err := c.UCI(); err != nil {
+		return uci.BestMove{}, nil, err
+	}
+	if err := c.SetOption("Depth", "2"); err != nil {
+		return uci.BestMove{}, nil, err
+	}
+	if err := c.IsReady(); err != nil {
+		return uci.BestMove{}, nil, err
+	}
+	if err := c.PositionFEN("6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", nil); err != nil {
+		return uci.BestMove{}, nil, err
+	}
+	infoCh, bestCh, err := c.GoContext(context.Background(), uci.Search{Depth: 2})
+	if err != nil {
+		return uci.BestMove{}, nil, err
+	}
+	var infos []uci.Info
+	for info := range infoCh {
+		info.Time, info.Nodes = 0, 0
+		infos = append(infos, info)
+	}
+	return <-bestCh, infos, nil
+}
+
+func TestReplay(t *testing.T) {
+	// Record a session with a real engine.
+	c, closer, err := refengine.New().Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	var trace strings.Builder
+	var mu sync.Mutex
+	c.TraceTo(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return trace.Write(p)
+	}))
+	wantBest, wantInfos, err := analyze(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	lines, err := uci.ReadTrace(strings.NewReader(trace.String()))
+	mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replay it without the engine.
+	e := NewReplay(t, Session{Lines: lines})
+	gotBest, gotInfos, err := analyze(e.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.ExpectDone()
+	if gotBest != wantBest {
+		t.Errorf("got best move %v, want %v", gotBest, wantBest)
+	}
+	if diff := cmp.Diff(wantInfos, gotInfos); diff != "" {
+		t.Errorf("info mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// at returns a trace line at ms milliseconds.
+func at(ms int, sent bool, line string) uci.TraceLine {
+	return uci.TraceLine{Time: time.UnixMilli(int64(ms)), Sent: sent, Line: line}
+}
+
+func TestReplay_RealTime(t *testing.T) {
+	e := NewReplay(t, Session{
+		Lines: []uci.TraceLine{
+			at(0, false, "info string hello"),
+			at(10, true, "isready"),
+			at(110, false, "readyok"),
+		},
+		RealTime: true,
+	})
+	c := e.Client()
+	start := time.Now()
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Errorf("readyok after %v, want at least 100ms", d)
+	}
+	e.ExpectDone()
+}
+
+func TestReplay_Mismatch(t *testing.T) {
+	tb := &errorsTB{TB: t}
+	e := NewReplay(tb, Session{Lines: []uci.TraceLine{
+		at(0, true, "isready"),
+		at(1, false, "readyok"),
+		at(2, true, "ucinewgame"),
+	}})
+	c := e.Client()
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Position(uci.PositionParams{StartPos: true}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.IsReadyContext(ctx); err == nil {
+		t.Error("want an error after the engine disconnects")
+	}
+	want := []string{`ucitest: got command "position startpos", want "ucinewgame"`}
+	if diff := cmp.Diff(want, tb.errors()); diff != "" {
+		t.Errorf("errors mismatch (-want +got):\n%s", diff)
+	}
+
+	tb.errs = nil
+	e.ExpectDone()
+	want = []string{`ucitest: recorded command "ucinewgame" not received`}
+	if diff := cmp.Diff(want, tb.errors()); diff != "" {
+		t.Errorf("errors mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReplay_EndOfRecording(t *testing.T) {
+	tb := &errorsTB{TB: t}
+	e := NewReplay(tb, Session{Lines: []uci.TraceLine{
+		at(0, true, "isready"),
+		at(1, false, "readyok"),
+	}})
+	c := e.Client()
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.IsReadyContext(ctx); err == nil {
+		t.Error("want an error after the engine disconnects")
+	}
+	want := []string{`ucitest: got command "isready" after the end of the recording`}
+	if diff := cmp.Diff(want, tb.errors()); diff != "" {
+		t.Errorf("errors mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,331 @@
+// Package ucitest provides a scripted fake UCI engine for tests.
+//
+// A fake engine runs in the test's process, connected to a uci.Client by
+// in-memory pipes. It answers the handshake with its name and options, answers
+// "isready" after an optional delay, and answers each "go" command with the
+// next scripted search. Every command it receives is recorded, so that tests
+// can assert on what the client sent.
+//
+//	e := ucitest.New(t, ucitest.Script{
+//		Name: "Fake",
+//		Searches: []ucitest.Search{
+//			{Info: []uci.Info{{Depth: 1, PV: moves}}, BestMove: uci.BestMove{Move: m}},
+//		},
+//	})
+//	c := e.Client()
+//	// ... use c ...
+//	e.ExpectCommands("uci", "isready", "position startpos", "go depth 1")
+//
+// A Replay instead replays a session recorded with a real engine, so that
+// code can be tested against the engine's actual output without the engine
+// installed:
+//
+//	c.TraceTo(f) // Before the handshake, with the real engine.
+//	// ... later, in a test ...
+//	lines, err := uci.ReadTrace(f)
+//	e := ucitest.NewReplay(t, ucitest.Session{Lines: lines})
+//	// ... run the same code with e.Client() ...
+//	e.ExpectDone()
+package ucitest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+// Script describes how a fake engine behaves.
+type Script struct {
+	Name, Author string       // Sent as "id name" and "id author".
+	Options      []uci.Option // Sent during the "uci" handshake.
+
+	// ReadyDelay delays every "readyok".
+	ReadyDelay time.Duration
+
+	// Searches answer "go" commands, in order. Running out of searches fails
+	// the test.
+	Searches []Search
+
+	// Respond, if not nil, is called with every command. Any lines it
+	// returns are written before the engine's own answer, if any.
+	Respond func(cmd string) []string
+}
+
+// Search is the engine's answer to a "go" command.
+type Search struct {
+	Info []uci.Info // Sent as "info" lines when the search starts.
+	Raw  []string   // Sent after Info, for lines Info can't express.
+
+	// BestMove ends the search. It is sent after Delay, or when the engine
+	// receives "stop". Infinite searches, and searches with WaitStop, only
+	// end on "stop"; ponder searches end on "stop" or "ponderhit".
+	BestMove uci.BestMove
+	Delay    time.Duration
+	WaitStop bool
+}
+
+// Engine is a fake engine.
+type Engine struct {
+	t      testing.TB
+	script Script
+	client *uci.Client
+
+	in  io.ReadCloser  // Commands from the client.
+	out io.WriteCloser // Output to the client.
+
+	mu       sync.Mutex
+	cond     *sync.Cond // Signaled when a command is received.
+	received []string
+	searches int
+	pending  *pending // The running search, if any.
+	closed   bool
+}
+
+// pending is a search whose best move hasn't been sent.
+type pending struct {
+	line     string
+	delay    time.Duration
+	waitStop bool // Whether only "stop" or "ponderhit" ends the search.
+	ponder   bool
+	timer    *time.Timer
+}
+
+// New starts a fake engine that follows script. The engine stops when the test
+// ends.
+func New(t testing.TB, script Script) *Engine {
+	t.Helper()
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	e := &Engine{t: t, script: script, in: engineIn, out: engineOut}
+	e.cond = sync.NewCond(&e.mu)
+	e.client = uci.NewClient(r, w)
+	t.Cleanup(func() {
+		e.Close()
+		w.Close()
+	})
+	go e.run()
+	return e
+}
+
+// Client returns a client connected to the engine. The handshake hasn't been
+// done.
+func (e *Engine) Client() *uci.Client {
+	return e.client
+}
+
+// Close disconnects the engine, as if its process had exited.
+func (e *Engine) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closeLocked()
+}
+
+func (e *Engine) closeLocked() {
+	if e.closed {
+		return
+	}
+	e.closed = true
+	if e.pending != nil && e.pending.timer != nil {
+		e.pending.timer.Stop()
+	}
+	e.pending = nil
+	e.out.Close()
+	e.in.Close()
+	e.cond.Broadcast()
+}
+
+// Commands returns the commands received so far.
+func (e *Engine) Commands() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.received...)
+}
+
+// ExpectCommands fails the test unless the commands received so far are want.
+func (e *Engine) ExpectCommands(want ...string) {
+	e.t.Helper()
+	if diff := cmp.Diff(want, e.Commands()); diff != "" {
+		e.t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// WaitCommand waits until the engine receives a command starting with prefix,
+// and returns it. It fails the test if none arrives within timeout, or if the
+// engine is closed first.
+func (e *Engine) WaitCommand(prefix string, timeout time.Duration) string {
+	e.t.Helper()
+	timer := time.AfterFunc(timeout, func() {
+		e.mu.Lock()
+		e.cond.Broadcast()
+		e.mu.Unlock()
+	})
+	defer timer.Stop()
+	deadline := time.Now().Add(timeout)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for {
+		for _, cmd := range e.received {
+			if strings.HasPrefix(cmd, prefix) {
+				return cmd
+			}
+		}
+		if e.closed || !time.Now().Before(deadline) {
+			e.t.Errorf("engine didn't receive %q; got %q", prefix, e.received)
+			return ""
+		}
+		e.cond.Wait()
+	}
+}
+
+// run reads and answers commands until the engine is closed.
+func (e *Engine) run() {
+	s := bufio.NewScanner(e.in)
+	for s.Scan() {
+		cmd := s.Text()
+		e.mu.Lock()
+		if e.closed {
+			e.mu.Unlock()
+			return
+		}
+		e.received = append(e.received, cmd)
+		e.cond.Broadcast()
+		e.mu.Unlock()
+
+		if e.script.Respond != nil {
+			e.write(e.script.Respond(cmd)...)
+		}
+		e.handle(cmd)
+	}
+}
+
+// handle answers cmd.
+func (e *Engine) handle(cmd string) {
+	name, args, _ := strings.Cut(cmd, " ")
+	switch name {
+	case "uci":
+		var lines []string
+		if e.script.Name != "" {
+			lines = append(lines, "id name "+e.script.Name)
+		}
+		if e.script.Author != "" {
+			lines = append(lines, "id author "+e.script.Author)
+		}
+		for _, o := range e.script.Options {
+			text, err := o.MarshalText()
+			if err != nil {
+				e.t.Errorf("ucitest: %v", err)
+				continue
+			}
+			lines = append(lines, string(text))
+		}
+		e.write(append(lines, "uciok")...)
+	case "isready":
+		if e.script.ReadyDelay > 0 {
+			time.AfterFunc(e.script.ReadyDelay, func() { e.write("readyok") })
+		} else {
+			e.write("readyok")
+		}
+	case "go":
+		e.search(args)
+	case "stop":
+		e.finish(false)
+	case "ponderhit":
+		e.finish(true)
+	case "quit":
+		e.Close()
+	}
+}
+
+// search starts the next scripted search.
+func (e *Engine) search(args string) {
+	e.mu.Lock()
+	if e.searches == len(e.script.Searches) {
+		e.mu.Unlock()
+		e.t.Errorf("ucitest: unexpected go %s after %d searches", args, len(e.script.Searches))
+		e.write("bestmove 0000")
+		return
+	}
+	s := e.script.Searches[e.searches]
+	e.searches++
+	e.mu.Unlock()
+
+	lines := make([]string, 0, len(s.Info)+len(s.Raw))
+	for _, info := range s.Info {
+		lines = append(lines, uci.FormatInfo(info))
+	}
+	lines = append(lines, s.Raw...)
+	e.write(lines...)
+
+	fields := strings.Fields(args)
+	p := &pending{line: s.BestMove.String(), delay: s.Delay, waitStop: s.WaitStop}
+	for _, f := range fields {
+		switch f {
+		case "infinite":
+			p.waitStop = true
+		case "ponder":
+			p.waitStop, p.ponder = true, true
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return
+	}
+	e.pending = p
+	if !p.waitStop {
+		p.timer = time.AfterFunc(p.delay, func() { e.complete(p) })
+	}
+}
+
+// finish ends the running search on "stop", or on "ponderhit" if hit is
+// true.
+func (e *Engine) finish(hit bool) {
+	e.mu.Lock()
+	p := e.pending
+	if p == nil || (hit && !p.ponder) {
+		e.mu.Unlock()
+		return
+	}
+	if hit {
+		// The search continues as a normal search.
+		p.ponder, p.waitStop = false, false
+		p.timer = time.AfterFunc(p.delay, func() { e.complete(p) })
+		e.mu.Unlock()
+		return
+	}
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	e.mu.Unlock()
+	e.complete(p)
+}
+
+// complete sends the best move of p, unless it was already sent.
+func (e *Engine) complete(p *pending) {
+	e.mu.Lock()
+	if e.pending != p {
+		e.mu.Unlock()
+		return
+	}
+	e.pending = nil
+	e.mu.Unlock()
+	e.write(p.line)
+}
+
+// write sends lines to the client. Errors are ignored, since the client may
+// have disconnected.
+func (e *Engine) write(lines ...string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprint(e.out, strings.Join(lines, "\n")+"\n")
+}
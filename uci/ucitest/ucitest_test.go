@@ -0,0 +1,153 @@
+package ucitest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+func move(s string) chess.Move {
+	m, err := chess.ParseMove(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestEngine(t *testing.T) {
+	opts := []uci.Option{
+		uci.SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 1024},
+		uci.CheckOption{Name: "Ponder"},
+	}
+	info := uci.Info{Depth: 1, Score: uci.Score{CP: 20}, PV: []chess.Move{move("e2e4")}}
+	e := New(t, Script{
+		Name:       "Fake",
+		Author:     "Tester",
+		Options:    opts,
+		ReadyDelay: 10 * time.Millisecond,
+		Searches: []Search{
+			{Info: []uci.Info{info}, BestMove: uci.BestMove{Move: move("e2e4"), Ponder: move("e7e5")}},
+			{BestMove: uci.BestMove{Move: move("d2d4")}, WaitStop: true},
+		},
+	})
+	c := e.Client()
+
+	name, author, gotOpts, err := c.UCI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Fake" || author != "Tester" {
+		t.Errorf("want Fake by Tester, got %s by %s", name, author)
+	}
+	if diff := cmp.Diff(opts, gotOpts); diff != "" {
+		t.Errorf("options mismatch (-want +got):\n%s", diff)
+	}
+
+	start := time.Now()
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Errorf("readyok after %v, want at least 10ms", d)
+	}
+
+	if err := c.PositionStartPos(nil); err != nil {
+		t.Fatal(err)
+	}
+	infos, bm, err := c.Go(uci.Search{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotInfo []uci.Info
+	for i := range infos {
+		gotInfo = append(gotInfo, i)
+	}
+	if diff := cmp.Diff([]uci.Info{info}, gotInfo); diff != "" {
+		t.Errorf("info mismatch (-want +got):\n%s", diff)
+	}
+	if got := <-bm; got.Move != move("e2e4") || got.Ponder != move("e7e5") {
+		t.Errorf("want e2e4 ponder e7e5, got %v", got)
+	}
+
+	// The second search only ends on "stop".
+	_, bm, err = c.Go(uci.Search{Depth: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-bm:
+		t.Fatalf("search ended before stop with %v", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-bm; got.Move != move("d2d4") {
+		t.Errorf("want d2d4, got %v", got)
+	}
+
+	e.ExpectCommands("uci", "isready", "position startpos", "go depth 1", "go depth 5", "stop")
+}
+
+func TestEngine_Ponder(t *testing.T) {
+	e := New(t, Script{
+		Searches: []Search{
+			{BestMove: uci.BestMove{Move: move("g1f3")}, Raw: []string{"info string pondering"}},
+		},
+	})
+	c := e.Client()
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	infos, bm, err := c.Go(uci.Search{Ponder: true, Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-infos; got.String != "pondering" {
+		t.Errorf("want info string, got %+v", got)
+	}
+	e.WaitCommand("go", time.Second)
+	if err := c.PonderHit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-bm; got.Move != move("g1f3") {
+		t.Errorf("want g1f3, got %v", got)
+	}
+}
+
+func TestEngine_Respond(t *testing.T) {
+	e := New(t, Script{
+		Options: []uci.Option{uci.SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 1024}},
+		Respond: func(cmd string) []string {
+			if cmd == "isready" {
+				return []string{"info string almost ready"}
+			}
+			return nil
+		},
+	})
+	c := e.Client()
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetOption("Hash", "32"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.WaitCommand("setoption", time.Second); got != "setoption name Hash value 32" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestEngine_Close(t *testing.T) {
+	e := New(t, Script{})
+	c := e.Client()
+	e.Close()
+	if _, _, _, err := c.UCI(); err == nil {
+		t.Error("want error after the engine exits")
+	}
+}
@@ -0,0 +1,110 @@
+package uci
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/clfs/chess"
+)
+
+var errNoVariant = errors.New(`uci: engine has no "UCI_Variant" option`)
+
+// variantNames are the values of the "UCI_Variant" option for each variant,
+// in order of preference, as used by engines such as Fairy-Stockfish and
+// Multi-Variant Stockfish.
+var variantNames = map[chess.Variant][]string{
+	chess.Standard:      {"chess", "standard", "normal"},
+	chess.Crazyhouse:    {"crazyhouse"},
+	chess.Atomic:        {"atomic"},
+	chess.KingOfTheHill: {"kingofthehill", "koth"},
+	chess.Antichess:     {"antichess", "giveaway"},
+	chess.Horde:         {"horde"},
+}
+
+// Variants returns the choices of the engine's "UCI_Variant" option, as
+// advertised in response to the last "uci" command, or nil if it has none.
+// Engines may list variants that the chess package doesn't support.
+func (c *Client) Variants() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if combo, ok := c.variantOption(); ok {
+		return append([]string(nil), combo.Vars...)
+	}
+	return nil
+}
+
+// variantOption returns the engine's "UCI_Variant" option. The caller must hold
+// c.mu.
+func (c *Client) variantOption() (ComboOption, bool) {
+	opt, ok := lookupOption(c.options, "UCI_Variant")
+	if !ok {
+		return ComboOption{}, false
+	}
+	combo, ok := opt.(ComboOption)
+	return combo, ok
+}
+
+// SetVariant sets the engine's "UCI_Variant" option to play v, using the name
+// the engine lists for it. Setting chess.Standard succeeds without sending
+// anything if the engine has no such option.
+//
+// Afterwards, "position startpos" refers to v's starting position, and the
+// positions and search moves passed to Position and Go follow v's rules, as do
+// the moves the engine reports back. Drops are written as in "P@e4".
+func (c *Client) SetVariant(v chess.Variant) error {
+	c.mu.Lock()
+	combo, ok := c.variantOption()
+	c.mu.Unlock()
+
+	if !ok {
+		if v != chess.Standard {
+			return errNoVariant
+		}
+		c.mu.Lock()
+		c.variant = v
+		c.mu.Unlock()
+		return nil
+	}
+
+	name := ""
+	for _, want := range variantNames[v] {
+		for _, have := range combo.Vars {
+			if name == "" && strings.EqualFold(want, have) {
+				name = have
+			}
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("uci: engine doesn't play %v", v)
+	}
+	if err := c.SetOption(combo.Name, name); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.variant = v
+	c.pos = nil
+	return nil
+}
+
+// Variant returns the variant set with SetVariant, which is chess.Standard
+// by default.
+func (c *Client) Variant() chess.Variant {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.variant
+}
+
+// variantFromName returns the variant with the given "UCI_Variant" name.
+func variantFromName(name string) (chess.Variant, bool) {
+	for v, names := range variantNames {
+		for _, n := range names {
+			if strings.EqualFold(n, name) {
+				return v, true
+			}
+		}
+	}
+	return chess.Standard, false
+}
@@ -0,0 +1,85 @@
+package uci
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clfs/chess"
+	"github.com/google/go-cmp/cmp"
+)
+
+const variantOption = "option name UCI_Variant type combo default chess var chess var giveaway var crazyhouse var shogi\n"
+
+func TestClient_SetVariant(t *testing.T) {
+	r := &recorder{uci: variantOption}
+	c := fakeEngine(t, r.respond)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"chess", "giveaway", "crazyhouse", "shogi"}, c.Variants()); diff != "" {
+		t.Errorf("variants mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := c.SetVariant(chess.Horde); err == nil {
+		t.Error("SetVariant(Horde): want error")
+	}
+	if err := c.SetVariant(chess.Antichess); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetVariant(chess.Crazyhouse); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Variant(); got != chess.Crazyhouse {
+		t.Errorf("Variant() = %v, want Crazyhouse", got)
+	}
+
+	// Drops are legal search moves after the opponent's capture.
+	moves := []chess.Move{{From: chess.E2, To: chess.E4}, {From: chess.D7, To: chess.D5}, {From: chess.E4, To: chess.D5}}
+	if err := c.PositionStartPos(moves); err != nil {
+		t.Fatal(err)
+	}
+	drop := chess.Move{From: chess.E6, To: chess.E6, Drop: chess.Pawn}
+	if _, _, err := c.Go(Search{Nodes: 1, SearchMoves: []chess.Move{drop}}); err == nil {
+		t.Error("Go: want error for a drop from an empty pocket")
+	}
+	moves = append(moves, chess.Move{From: chess.D8, To: chess.D5})
+	if err := c.PositionStartPos(moves); err != nil {
+		t.Fatal(err)
+	}
+	infoCh, bestCh, err := c.Go(Search{Nodes: 1, SearchMoves: []chess.Move{drop}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range infoCh {
+	}
+	<-bestCh
+
+	want := []string{
+		"uci",
+		"setoption name UCI_Variant value giveaway",
+		"setoption name UCI_Variant value crazyhouse",
+		"position startpos moves e2e4 d7d5 e4d5",
+		"position startpos moves e2e4 d7d5 e4d5 d8d5",
+		"go nodes 1 searchmoves P@e6",
+	}
+	if diff := cmp.Diff(want, r.commands()); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_SetVariant_NoOption(t *testing.T) {
+	r := &recorder{}
+	c := fakeEngine(t, r.respond)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Variants(); got != nil {
+		t.Errorf("Variants() = %q, want nil", got)
+	}
+	if err := c.SetVariant(chess.Crazyhouse); !errors.Is(err, errNoVariant) {
+		t.Errorf("SetVariant(Crazyhouse) = %v, want %v", err, errNoVariant)
+	}
+	if err := c.SetVariant(chess.Standard); err != nil {
+		t.Errorf("SetVariant(Standard) = %v", err)
+	}
+}
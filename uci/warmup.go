@@ -0,0 +1,90 @@
+package uci
+
+import "errors"
+
+var errNoClearHash = errors.New(`uci: engine has no "Clear Hash" button`)
+
+// ClearHash presses the engine's "Clear Hash" button and waits for the engine
+// to be ready again. It fails if the engine didn't advertise the button in
+// response to the last "uci" command.
+func (c *Client) ClearHash() error {
+	c.mu.Lock()
+	opt, ok := lookupOption(c.options, "Clear Hash")
+	c.mu.Unlock()
+	if _, button := opt.(ButtonOption); !ok || !button {
+		return errNoClearHash
+	}
+	if err := c.SetOption(opt.OptionName(), ""); err != nil {
+		return err
+	}
+	return c.IsReady()
+}
+
+// WarmUp runs a search of the given number of nodes from the starting
+// position, so that later searches don't pay for one-time costs like
+// allocating the hash table or loading network weights. Afterwards, it sends
+// "ucinewgame" so that the warm-up search doesn't influence later results, and
+// waits for the engine to be ready.
+func (c *Client) WarmUp(nodes int64) error {
+	if err := c.PositionStartPos(nil); err != nil {
+		return err
+	}
+	infoCh, bestCh, err := c.Go(Search{Nodes: nodes})
+	if err != nil {
+		return err
+	}
+	for range infoCh {
+	}
+	if _, ok := <-bestCh; !ok {
+		return errors.New("uci: engine stopped without a best move")
+	}
+	if err := c.UCINewGame(); err != nil {
+		return err
+	}
+	return c.IsReady()
+}
+
+// NewGamePolicy controls when an Analysis sends "ucinewgame" before analyzing a
+// new position. Engines keep their hash table and other state between searches
+// otherwise, which helps when positions are related but makes results depend
+// on what was analyzed before.
+type NewGamePolicy int
+
+const (
+	// NewGameNever never sends "ucinewgame".
+	NewGameNever NewGamePolicy = iota
+
+	// NewGameAlways sends "ucinewgame" before every new position.
+	NewGameAlways
+
+	// NewGameUnrelated sends "ucinewgame" unless the new position continues
+	// the previous one, that is, it starts from the same position and its
+	// moves extend the previous moves.
+	NewGameUnrelated
+)
+
+// needsNewGame reports whether the policy calls for "ucinewgame" when moving
+// from prev to next. A nil prev means there was no previous position.
+func (pol NewGamePolicy) needsNewGame(prev *PositionParams, next PositionParams) bool {
+	switch pol {
+	case NewGameAlways:
+		return true
+	case NewGameUnrelated:
+		return prev == nil || !continues(*prev, next)
+	}
+	return false
+}
+
+// continues reports whether next is reached by playing zero or more moves after
+// prev.
+func continues(prev, next PositionParams) bool {
+	if prev.StartPos != next.StartPos || prev.FEN != next.FEN || len(prev.Moves) > len(next.Moves) {
+		return false
+	}
+	for i, m := range prev.Moves {
+		if next.Moves[i] != m {
+			return false
+		}
+	}
+	return true
+}
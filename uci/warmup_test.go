@@ -0,0 +1,121 @@
+package uci
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recorder is a fake engine that records the commands it receives.
+type recorder struct {
+	mu   sync.Mutex
+	cmds []string
+	uci  string // The response to "uci", without "uciok".
+}
+
+func (r *recorder) respond(cmd string) string {
+	r.mu.Lock()
+	r.cmds = append(r.cmds, cmd)
+	r.mu.Unlock()
+
+	switch {
+	case cmd == "uci":
+		return r.uci + "uciok\n"
+	case cmd == "isready":
+		return "readyok\n"
+	case strings.HasPrefix(cmd, "go nodes"):
+		return "info depth 1 pv e2e4\nbestmove e2e4\n"
+	case cmd == "go infinite":
+		return "info depth 1 pv e2e4\n"
+	case cmd == "stop":
+		return "bestmove e2e4\n"
+	}
+	return ""
+}
+
+func (r *recorder) commands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.cmds...)
+}
+
+func TestClient_ClearHash(t *testing.T) {
+	r := &recorder{uci: "option name Clear Hash type button\n"}
+	c := fakeEngine(t, r.respond)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ClearHash(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"uci", "setoption name Clear Hash", "isready"}
+	if diff := cmp.Diff(want, r.commands()); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_ClearHash_Unsupported(t *testing.T) {
+	r := &recorder{uci: "option name Clear Hash type check default false\n"}
+	c := fakeEngine(t, r.respond)
+	if err := c.ClearHash(); err != errNoClearHash {
+		t.Errorf("before handshake: want %v, got %v", errNoClearHash, err)
+	}
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ClearHash(); err != errNoClearHash {
+		t.Errorf("want %v, got %v", errNoClearHash, err)
+	}
+}
+
+func TestClient_WarmUp(t *testing.T) {
+	r := &recorder{}
+	c := fakeEngine(t, r.respond)
+	if err := c.WarmUp(1000); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"position startpos", "go nodes 1000", "ucinewgame", "isready"}
+	if diff := cmp.Diff(want, r.commands()); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAnalysis_NewGame(t *testing.T) {
+	start := PositionParams{StartPos: true}
+	e4 := PositionParams{StartPos: true, Moves: moves("e2e4")}
+	d4 := PositionParams{StartPos: true, Moves: moves("d2d4")}
+
+	cases := []struct {
+		policy NewGamePolicy
+		want   int // Number of "ucinewgame" commands.
+	}{
+		{NewGameNever, 0},
+		{NewGameAlways, 3},
+		{NewGameUnrelated, 2}, // Before start and d4, but not e4.
+	}
+	for _, tc := range cases {
+		r := &recorder{}
+		a := NewAnalysis(fakeEngine(t, r.respond))
+		a.NewGame = tc.policy
+		for _, p := range []PositionParams{start, e4, d4} {
+			if err := a.Update(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := a.Stop(); err != nil {
+			t.Fatal(err)
+		}
+
+		got := 0
+		for _, cmd := range r.commands() {
+			if cmd == "ucinewgame" {
+				got++
+			}
+		}
+		if got != tc.want {
+			t.Errorf("policy %d: got %d ucinewgame commands, want %d", tc.policy, got, tc.want)
+		}
+	}
+}
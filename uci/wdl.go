@@ -0,0 +1,33 @@
+package uci
+
+import "errors"
+
+// WDL is the engine's estimate of the outcome of the game from the point of
+// view of the side to move, in parts-per-thousand, as sent by engines with
+// the "UCI_ShowWDL" option enabled. The three values add up to 1000.
+type WDL struct {
+	Win  int `json:"win"`
+	Draw int `json:"draw"`
+	Loss int `json:"loss"`
+}
+
+// Score returns the expected score of the side to move, from 0 to 1, counting
+// a draw as half a win.
+func (w WDL) Score() float64 {
+	return (float64(w.Win) + float64(w.Draw)/2) / 1000
+}
+
+var errNoShowWDL = errors.New(`uci: engine has no "UCI_ShowWDL" option`)
+
+// ShowWDL enables the engine's "UCI_ShowWDL" option, so that its "info" lines
+// include win, draw and loss estimates in Info.WDL. It fails if the engine
+// didn't advertise the option in response to the last "uci" command.
+func (c *Client) ShowWDL() error {
+	c.mu.Lock()
+	opt, ok := lookupOption(c.options, "UCI_ShowWDL")
+	c.mu.Unlock()
+	if _, check := opt.(CheckOption); !ok || !check {
+		return errNoShowWDL
+	}
+	return c.SetOption(opt.OptionName(), "true")
+}
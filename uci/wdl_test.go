@@ -0,0 +1,58 @@
+package uci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWDL_Score(t *testing.T) {
+	cases := []struct {
+		in   WDL
+		want float64
+	}{
+		{WDL{1000, 0, 0}, 1},
+		{WDL{0, 1000, 0}, 0.5},
+		{WDL{0, 0, 1000}, 0},
+		{WDL{250, 500, 250}, 0.5},
+		{WDL{100, 800, 100}, 0.5},
+		{WDL{600, 400, 0}, 0.8},
+	}
+	for i, c := range cases {
+		if got := c.in.Score(); got != c.want {
+			t.Errorf("#%d: %+v: want %v, got %v", i, c.in, c.want, got)
+		}
+	}
+}
+
+func TestClient_ShowWDL(t *testing.T) {
+	r := &recorder{uci: "option name UCI_ShowWDL type check default false\n"}
+	c := fakeEngine(t, r.respond)
+	if err := c.ShowWDL(); err != errNoShowWDL {
+		t.Errorf("before handshake: want %v, got %v", errNoShowWDL, err)
+	}
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ShowWDL(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"uci", "setoption name UCI_ShowWDL value true", "isready"}
+	if diff := cmp.Diff(want, r.commands()); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_ShowWDL_Unsupported(t *testing.T) {
+	r := &recorder{uci: "option name Hash type spin default 16 min 1 max 1024\n"}
+	c := fakeEngine(t, r.respond)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ShowWDL(); err != errNoShowWDL {
+		t.Errorf("want %v, got %v", errNoShowWDL, err)
+	}
+}
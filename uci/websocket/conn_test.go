@@ -0,0 +1,231 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient is the browser side of a WebSocket connection, for tests.
+type testClient struct {
+	t  *testing.T
+	nc net.Conn
+	br *bufio.Reader
+}
+
+// dial opens a WebSocket connection to the test server at url.
+func dial(t *testing.T, url string, header http.Header) *testClient {
+	t.Helper()
+	c, resp, err := tryDial(url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("got status %s, want 101", resp.Status)
+	}
+	c.t = t
+	t.Cleanup(func() { c.nc.Close() })
+	return c
+}
+
+func tryDial(url string, header http.Header) (*testClient, *http.Response, error) {
+	addr := strings.TrimPrefix(url, "http://")
+	nc, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	req, _ := http.NewRequest("GET", url, nil)
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if err := req.Write(nc); err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
+	br := bufio.NewReader(nc)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols && resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		nc.Close()
+		return nil, nil, errors.New("wrong accept key")
+	}
+	return &testClient{nc: nc, br: br}, resp, nil
+}
+
+// writeFrame writes a masked frame.
+func (c *testClient) writeFrame(fin bool, op byte, payload []byte) {
+	c.t.Helper()
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	b := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		b = append(b, 0x80|byte(n))
+	case n <= 0xffff:
+		b = append(b, 0x80|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		b = append(append(b, 0x80|127), ext[:]...)
+	}
+	mask := [4]byte{1, 2, 3, 4}
+	b = append(b, mask[:]...)
+	for i, x := range payload {
+		b = append(b, x^mask[i%4])
+	}
+	if _, err := c.nc.Write(b); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// readFrame reads an unmasked frame.
+func (c *testClient) readFrame() (op byte, payload []byte, err error) {
+	c.nc.SetReadDeadline(time.Now().Add(10 * time.Second))
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return 0, nil, err
+	}
+	if h[0]&0x80 == 0 || h[1]&0x80 != 0 {
+		return 0, nil, fmt.Errorf("unexpected frame header %x", h)
+	}
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		io.ReadFull(c.br, b[:])
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		io.ReadFull(c.br, b[:])
+		n = binary.BigEndian.Uint64(b[:])
+	}
+	payload = make([]byte, n)
+	_, err = io.ReadFull(c.br, payload)
+	return h[0] & 0x0f, payload, err
+}
+
+// echoServer serves a connection that echoes messages back.
+func echoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer c.close()
+		for {
+			msg, err := c.readMessage()
+			if err != nil {
+				if err != io.EOF {
+					c.writeText([]byte("error: " + err.Error()))
+				}
+				return
+			}
+			c.writeText(msg)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAcceptKey(t *testing.T) {
+	// The example of RFC 6455, section 1.3.
+	if got, want := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestConn(t *testing.T) {
+	c := dial(t, echoServer(t).URL, nil)
+
+	want := func(wantOp byte, wantPayload []byte) {
+		t.Helper()
+		op, payload, err := c.readFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op != wantOp || !bytes.Equal(payload, wantPayload) {
+			t.Fatalf("got frame %x %q, want %x %q", op, payload, wantOp, wantPayload)
+		}
+	}
+
+	c.writeFrame(true, opText, []byte("hello"))
+	want(opText, []byte("hello"))
+
+	// Fragmented, with a ping in between.
+	c.writeFrame(false, opText, []byte("hel"))
+	c.writeFrame(true, opPing, []byte("p"))
+	c.writeFrame(true, opContinuation, []byte("lo"))
+	want(opPong, []byte("p"))
+	want(opText, []byte("hello"))
+
+	// Extended lengths.
+	for _, n := range []int{200, 70000} {
+		long := bytes.Repeat([]byte("x"), n)
+		c.writeFrame(true, opText, long)
+		want(opText, long)
+	}
+
+	c.writeFrame(true, opClose, []byte{0x03, 0xe8, 'b', 'y', 'e'})
+	want(opClose, []byte{0x03, 0xe8})
+}
+
+func TestConn_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+		want  error
+	}{
+		{"unmasked", []byte{0x81, 0x01, 'x'}, errUnmasked},
+		{"continuation first", []byte{0x80, 0x81, 0, 0, 0, 0, 'x'}, errBadFrame},
+		{"reserved bits", []byte{0xc1, 0x81, 0, 0, 0, 0, 'x'}, errBadFrame},
+		{"too large", []byte{0x81, 0xff, 0, 0, 0, 0, 1, 0, 0, 0}, errTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, echoServer(t).URL, nil)
+			c.nc.Write(tt.frame)
+			_, payload, err := c.readFrame()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "error: " + tt.want.Error(); string(payload) != want {
+				t.Errorf("got %q, want %q", payload, want)
+			}
+		})
+	}
+}
+
+func TestUpgrade_NotWebSocket(t *testing.T) {
+	resp, err := http.Get(echoServer(t).URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %s, want 400", resp.Status)
+	}
+}
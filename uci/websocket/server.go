@@ -0,0 +1,263 @@
+// Package websocket serves UCI engines over WebSocket, so that a chessboard
+// frontend in a browser can drive engines through this module directly.
+//
+// Each WebSocket connection gets its own engine, from Server.Start. Messages in
+// both directions are JSON objects, one per text message, with a "type" field.
+// The browser sends:
+//
+//	{"type": "position", "fen": "...", "moves": ["e2e4", "e7e5"]}
+//	{"type": "go", "search": {"depth": 20}}
+//	{"type": "stop"}
+//	{"type": "ponderhit"}
+//	{"type": "setoption", "name": "Hash", "value": "128"}
+//	{"type": "newgame"}
+//
+// A position without "fen" starts from the standard starting position. Invalid
+// positions and illegal moves are refused, rather than passed to the engine.
+// The search limits of "go" have the JSON encoding of uci.Search, in which
+// times are in milliseconds; without them, the search is infinite and runs
+// until "stop". The server sends:
+//
+//	{"type": "id", "name": "Stockfish 15", "author": "...", "options": [...]}
+//	{"type": "info", "info": {"depth": 20, "score": {"cp": 31}, "pv": ["e2e4"]}}
+//	{"type": "bestmove", "bestmove": {"move": "e2e4", "ponder": "e7e5"}}
+//	{"type": "error", "error": "..."}
+//
+// The "id" message is sent when the connection opens, with the engine's
+// options in the JSON encoding of this module's uci package. Searches report
+// "info" messages as the engine sends them, and end with a "bestmove" message.
+// Only one search runs at a time, and the other commands, except "stop" and
+// "ponderhit", are refused while it runs. Invalid messages, and commands the
+// engine fails, are answered with an "error" message.
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// Server is an http.Handler that serves engines over WebSocket.
+type Server struct {
+	// Start starts an engine for a new connection. The client must have
+	// completed the "uci" handshake. The closer is called when the connection
+	// ends. Start may also hand out engines from a pool, with a closer that
+	// returns the engine to it.
+	Start func() (*uci.Client, io.Closer, error)
+
+	// CheckOrigin reports whether to accept a connection from the page of
+	// the request's Origin header. If nil, only requests without an Origin
+	// header, and requests from a page on the server's host, are accepted, so
+	// that other websites can't use the engines of a browser's local server.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// message is a message in either direction.
+type message struct {
+	Type string `json:"type"`
+
+	// "position".
+	FEN   string       `json:"fen,omitempty"`
+	Moves []chess.Move `json:"moves,omitempty"`
+
+	// "go".
+	Search *uci.Search `json:"search,omitempty"`
+
+	// "setoption" and "id".
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+
+	// "id".
+	Author  string       `json:"author,omitempty"`
+	Options []uci.Option `json:"options,omitempty"`
+
+	Info     *uci.Info     `json:"info,omitempty"`
+	BestMove *uci.BestMove `json:"bestmove,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+var errSearching = errors.New("a search is running")
+
+// ServeHTTP starts an engine and serves it on the WebSocket connection of r,
+// until the browser disconnects.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	c, closer, err := s.Start()
+	if err != nil {
+		http.Error(w, "engine unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer closer.Close()
+
+	ws, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.close()
+	(&session{ws: ws, c: c}).run()
+}
+
+func (s *Server) checkOrigin(r *http.Request) bool {
+	if s.CheckOrigin != nil {
+		return s.CheckOrigin(r)
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// session is a connection between a browser and an engine.
+type session struct {
+	ws *conn
+	c  *uci.Client
+
+	mu        sync.Mutex
+	searching bool
+	wg        sync.WaitGroup // The running search.
+}
+
+// run relays messages until the browser disconnects. A running search is
+// stopped before run returns.
+func (s *session) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		s.wg.Wait()
+	}()
+
+	name, author := s.c.ID()
+	s.send(message{Type: "id", Name: name, Author: author, Options: s.c.Options()})
+	for {
+		data, err := s.ws.readMessage()
+		if err != nil {
+			return
+		}
+		var m message
+		if err := json.Unmarshal(data, &m); err != nil {
+			s.sendError(fmt.Errorf("invalid message: %w", err))
+			continue
+		}
+		if err := s.handle(ctx, m); err != nil {
+			s.sendError(err)
+		}
+	}
+}
+
+// handle carries out a message from the browser.
+func (s *session) handle(ctx context.Context, m message) error {
+	switch m.Type {
+	case "stop":
+		return s.c.Stop()
+	case "ponderhit":
+		return s.c.PonderHit()
+	}
+
+	s.mu.Lock()
+	searching := s.searching
+	s.mu.Unlock()
+	if searching {
+		return fmt.Errorf("%s: %w", m.Type, errSearching)
+	}
+
+	switch m.Type {
+	case "position":
+		if err := checkPosition(s.c.Variant(), m.FEN, m.Moves); err != nil {
+			return err
+		}
+		return s.c.Position(uci.PositionParams{StartPos: m.FEN == "", FEN: m.FEN, Moves: m.Moves})
+	case "go":
+		search := uci.Search{Infinite: true}
+		if m.Search != nil {
+			search = *m.Search
+		}
+		return s.search(ctx, search)
+	case "setoption":
+		return s.c.SetOption(m.Name, m.Value)
+	case "newgame":
+		return s.c.UCINewGame()
+	}
+	return fmt.Errorf("unknown message type %q", m.Type)
+}
+
+// checkPosition checks that fen, or the starting position if it is empty, is
+// valid and that moves are legal from it, since engines may crash on positions
+// that aren't.
+func checkPosition(v chess.Variant, fen string, moves []chess.Move) error {
+	p := chess.VariantStartingPosition(v)
+	if fen != "" {
+		var err error
+		if p, err = chess.ParseVariantFEN(v, fen); err != nil {
+			return err
+		}
+	}
+	for _, m := range moves {
+		m = p.ConvertCastling(m, p.Chess960())
+		if !p.IsLegal(m) {
+			return fmt.Errorf("illegal move %v in position %v", m, p)
+		}
+		p.Apply(m)
+	}
+	return nil
+}
+
+// search starts a search, and relays its output in the background.
+func (s *session) search(ctx context.Context, search uci.Search) error {
+	infoCh, bestCh, err := s.c.GoContext(ctx, search)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.searching = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for info := range infoCh {
+			info := info
+			s.send(message{Type: "info", Info: &info})
+		}
+		bm, ok := <-bestCh
+
+		// Accept the next search as soon as the browser learns of this one's
+		// end.
+		s.mu.Lock()
+		s.searching = false
+		s.mu.Unlock()
+		if !ok {
+			s.sendError(errors.New("engine stopped without a best move"))
+			return
+		}
+		s.send(message{Type: "bestmove", BestMove: &bm})
+	}()
+	return nil
+}
+
+// send sends m to the browser. Errors are ignored, since the reading side
+// notices a broken connection.
+func (s *session) send(m message) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		data, _ = json.Marshal(message{Type: "error", Error: err.Error()})
+	}
+	s.ws.writeText(data)
+}
+
+func (s *session) sendError(err error) {
+	s.send(message{Type: "error", Error: err.Error()})
+}
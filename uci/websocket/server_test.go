@@ -0,0 +1,178 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/clfs/chess/refengine"
+	"github.com/clfs/chess/uci"
+)
+
+// received is a message from the server, as a browser would decode it.
+type received struct {
+	Type     string            `json:"type"`
+	Name     string            `json:"name"`
+	Options  []json.RawMessage `json:"options"`
+	Info     *uci.Info         `json:"info"`
+	BestMove *uci.BestMove     `json:"bestmove"`
+	Error    string            `json:"error"`
+}
+
+func refServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(&Server{
+		Start: func() (*uci.Client, io.Closer, error) {
+			c, closer, err := refengine.New().Client()
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, _, _, err := c.UCI(); err != nil {
+				closer.Close()
+				return nil, nil, err
+			}
+			return c, closer, nil
+		},
+	})
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (c *testClient) send(msg string) {
+	c.t.Helper()
+	c.writeFrame(true, opText, []byte(msg))
+}
+
+func (c *testClient) receive() received {
+	c.t.Helper()
+	op, payload, err := c.readFrame()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if op != opText {
+		c.t.Fatalf("got opcode %x, want text", op)
+	}
+	var m received
+	if err := json.Unmarshal(payload, &m); err != nil {
+		c.t.Fatalf("%v: %s", err, payload)
+	}
+	return m
+}
+
+// receiveSearch returns the info and best move messages of a search.
+func (c *testClient) receiveSearch() ([]uci.Info, uci.BestMove) {
+	c.t.Helper()
+	var infos []uci.Info
+	for {
+		m := c.receive()
+		switch m.Type {
+		case "info":
+			infos = append(infos, *m.Info)
+		case "bestmove":
+			return infos, *m.BestMove
+		default:
+			c.t.Fatalf("got %+v during a search", m)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	c := dial(t, refServer(t).URL, nil)
+
+	if m := c.receive(); m.Type != "id" || m.Name == "" || len(m.Options) == 0 {
+		t.Fatalf("got %+v, want the engine's id", m)
+	}
+
+	c.send(`{"type": "newgame"}`)
+	c.send(`{"type": "setoption", "name": "Depth", "value": "2"}`)
+	c.send(`{"type": "position", "fen": "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"}`)
+	c.send(`{"type": "go", "search": {"depth": 2}}`)
+	infos, bm := c.receiveSearch()
+	if len(infos) == 0 {
+		t.Error("got no info messages")
+	}
+	if got := bm.Move.String(); got != "a1a8" {
+		t.Errorf("got best move %s, want a1a8", got)
+	}
+
+	c.send(`{"type": "position", "moves": ["e2e4"]}`)
+	c.send(`{"type": "go"}`)
+	c.send(`{"type": "position"}`)
+	if m := c.receive(); m.Type == "info" {
+		// Skip the search's output up to the error.
+		for m.Type == "info" {
+			m = c.receive()
+		}
+		if m.Type != "error" || m.Error != "position: "+errSearching.Error() {
+			t.Errorf("got %+v, want a refused position", m)
+		}
+	} else if m.Type != "error" {
+		t.Errorf("got %+v, want a refused position", m)
+	}
+	c.send(`{"type": "stop"}`)
+	if _, bm := c.receiveSearch(); bm.Move.String() == "" {
+		t.Error("got no best move after stop")
+	}
+}
+
+func TestServer_InvalidMessages(t *testing.T) {
+	c := dial(t, refServer(t).URL, nil)
+	c.receive()
+	c.send(`{"type": "position"}`)
+
+	for _, msg := range []string{
+		`not json`,
+		`{"type": "castle"}`,
+		`{"type": "position", "fen": "8/8/8/8/8/8/8/8 w - - 0 1"}`,
+		`{"type": "position", "moves": ["e2e5"]}`,
+		`{"type": "go", "search": {"searchmoves": ["e2e5"]}}`,
+	} {
+		c.send(msg)
+		if m := c.receive(); m.Type != "error" || m.Error == "" {
+			t.Errorf("%s: got %+v, want an error", msg, m)
+		}
+	}
+}
+
+func TestServer_Origin(t *testing.T) {
+	srv := refServer(t)
+	for _, tt := range []struct {
+		origin string
+		want   int
+	}{
+		{"", http.StatusSwitchingProtocols},
+		{srv.URL, http.StatusSwitchingProtocols},
+		{"https://example.com", http.StatusForbidden},
+	} {
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		c, resp, err := tryDial(srv.URL, header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.nc.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("origin %q: got status %d, want %d", tt.origin, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestServer_StartFails(t *testing.T) {
+	srv := httptest.NewServer(&Server{
+		Start: func() (*uci.Client, io.Closer, error) { return nil, nil, errors.New("no engines left") },
+	})
+	defer srv.Close()
+	c, resp, err := tryDial(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.nc.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want 503", resp.StatusCode)
+	}
+}
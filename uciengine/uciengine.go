@@ -0,0 +1,239 @@
+// Package uciengine implements the engine side of the Universal Chess
+// Interface (UCI) protocol.
+//
+// An engine implements the Engine interface, and Serve takes care of the
+// protocol: it parses commands from the GUI, calls the engine, and reports
+// identification, options, search information and best moves.
+package uciengine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/clfs/chess/uci"
+)
+
+// Engine is a chess engine that can be served over UCI.
+type Engine interface {
+	// ID returns the engine's name and author.
+	ID() (name, author string)
+
+	// Options returns the options the engine supports. Serve validates
+	// "setoption" commands against them before calling SetOption.
+	Options() []uci.Option
+
+	// SetOption sets an option. For button options, value is empty.
+	SetOption(name, value string) error
+
+	// NewGame tells the engine that the next search will be from a different
+	// game.
+	NewGame()
+
+	// Position sets up the position to search.
+	Position(p uci.PositionParams) error
+
+	// Search searches the current position within the limits of s, sending
+	// search information on info as it goes. It returns the best move when the
+	// limits are reached or stop is closed, whichever comes first. Infinite and
+	// ponder searches must only return once stop is closed, although Serve
+	// withholds a ponder search's best move until "ponderhit" or "stop" anyway.
+	//
+	// Search must not close info.
+	Search(s uci.Search, stop <-chan struct{}, info chan<- uci.Info) uci.BestMove
+}
+
+// Ponderer is implemented by engines that want to know when the opponent has
+// played the move they were pondering on, for example to switch to normal time
+// management.
+type Ponderer interface {
+	PonderHit()
+}
+
+// server is the state of a single Serve call.
+type server struct {
+	e Engine
+
+	wmu sync.Mutex // Serializes writes to w.
+	w   io.Writer
+
+	// The running search, if any.
+	stop      chan struct{}
+	ponderHit chan struct{}
+	done      chan struct{}
+}
+
+// Serve serves e over the UCI protocol, reading commands from r and writing
+// responses to w. It returns nil when it receives "quit" or r is exhausted, or
+// the first error writing to w or reading from r.
+func Serve(r io.Reader, w io.Writer, e Engine) error {
+	s := &server{e: e, w: w}
+	defer s.stopSearch()
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		quit, err := s.handle(sc.Text())
+		if err != nil {
+			return err
+		}
+		if quit {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+func (s *server) println(format string, a ...any) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	_, err := fmt.Fprintf(s.w, format+"\n", a...)
+	return err
+}
+
+// handle handles a single command. Unknown commands are ignored, as the
+// protocol requires.
+func (s *server) handle(line string) (quit bool, err error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, nil
+	}
+
+	switch fields[0] {
+	case "uci":
+		return false, s.handleUCI()
+	case "isready":
+		return false, s.println("readyok")
+	case "setoption":
+		s.stopSearch()
+		return false, s.handleSetOption(line)
+	case "ucinewgame":
+		s.stopSearch()
+		s.e.NewGame()
+	case "position":
+		s.stopSearch()
+		p, err := uci.ParsePosition(line)
+		if err != nil {
+			return false, s.println("info string %v", err)
+		}
+		if err := s.e.Position(p); err != nil {
+			return false, s.println("info string %v", err)
+		}
+	case "go":
+		s.stopSearch()
+		search, err := uci.ParseSearch(line)
+		if err != nil {
+			return false, s.println("info string %v", err)
+		}
+		s.startSearch(search)
+	case "stop":
+		s.stopSearch()
+	case "ponderhit":
+		if s.ponderHit != nil {
+			if p, ok := s.e.(Ponderer); ok {
+				p.PonderHit()
+			}
+			close(s.ponderHit)
+			s.ponderHit = nil
+		}
+	case "quit":
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *server) handleUCI() error {
+	name, author := s.e.ID()
+	if err := s.println("id name %s", name); err != nil {
+		return err
+	}
+	if err := s.println("id author %s", author); err != nil {
+		return err
+	}
+	for _, o := range s.e.Options() {
+		text, err := o.MarshalText()
+		if err != nil {
+			return err
+		}
+		if err := s.println("%s", text); err != nil {
+			return err
+		}
+	}
+	return s.println("uciok")
+}
+
+func (s *server) handleSetOption(line string) error {
+	name, value, err := uci.ParseSetOption(line)
+	if err != nil {
+		return s.println("info string %v", err)
+	}
+
+	var opt uci.Option
+	for _, o := range s.e.Options() {
+		if strings.EqualFold(o.OptionName(), name) {
+			opt = o
+			break
+		}
+	}
+	if opt == nil {
+		return s.println("info string unknown option %q", name)
+	}
+	if err := opt.Validate(value); err != nil {
+		return s.println("info string %v", err)
+	}
+	if err := s.e.SetOption(opt.OptionName(), value); err != nil {
+		return s.println("info string %v", err)
+	}
+	return nil
+}
+
+// startSearch starts a search in the background.
+func (s *server) startSearch(search uci.Search) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	info := make(chan uci.Info)
+
+	var ponderHit chan struct{}
+	if search.Ponder {
+		ponderHit = make(chan struct{})
+	}
+	s.stop, s.ponderHit, s.done = stop, ponderHit, done
+
+	// Forward search information.
+	infoDone := make(chan struct{})
+	go func() {
+		defer close(infoDone)
+		for i := range info {
+			s.println("%s", uci.FormatInfo(i))
+		}
+	}()
+
+	go func() {
+		defer close(done)
+		bm := s.e.Search(search, stop, info)
+		close(info)
+		<-infoDone
+
+		// A ponder search's best move is only reported once the GUI has
+		// resolved the ponder.
+		if ponderHit != nil {
+			select {
+			case <-ponderHit:
+			case <-stop:
+			}
+		}
+		s.println("%s", bm)
+	}()
+}
+
+// stopSearch stops the running search, if any, and waits for it to report its
+// best move.
+func (s *server) stopSearch() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop, s.ponderHit, s.done = nil, nil, nil
+}
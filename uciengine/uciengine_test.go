@@ -0,0 +1,284 @@
+package uciengine
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+// testEngine is a trivial engine that plays the first search move, or e2e4.
+type testEngine struct {
+	mu        sync.Mutex
+	options   map[string]string
+	newGames  int
+	position  uci.PositionParams
+	ponderHit bool
+}
+
+func (e *testEngine) ID() (name, author string) {
+	return "Test Engine", "Test Author"
+}
+
+func (e *testEngine) Options() []uci.Option {
+	return []uci.Option{
+		uci.SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 1024},
+		uci.CheckOption{Name: "Ponder", Default: false},
+		uci.ButtonOption{Name: "Clear Hash"},
+	}
+}
+
+func (e *testEngine) SetOption(name, value string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.options == nil {
+		e.options = make(map[string]string)
+	}
+	e.options[name] = value
+	return nil
+}
+
+func (e *testEngine) NewGame() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.newGames++
+}
+
+func (e *testEngine) Position(p uci.PositionParams) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.position = p
+	return nil
+}
+
+func (e *testEngine) Search(s uci.Search, stop <-chan struct{}, info chan<- uci.Info) uci.BestMove {
+	move := chess.Move{From: chess.E2, To: chess.E4}
+	if len(s.SearchMoves) > 0 {
+		move = s.SearchMoves[0]
+	}
+	info <- uci.Info{Depth: 1, Score: uci.Score{CP: 20}, PV: []chess.Move{move}}
+	if s.Infinite || s.Ponder {
+		<-stop
+	}
+	return uci.BestMove{Move: move, Ponder: chess.Move{From: chess.E7, To: chess.E5}}
+}
+
+func (e *testEngine) PonderHit() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ponderHit = true
+}
+
+// moves parses moves in UCI notation.
+func moves(s ...string) []chess.Move {
+	var res []chess.Move
+	for _, m := range s {
+		move, err := chess.ParseMove(m)
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, move)
+	}
+	return res
+}
+
+// serve serves e and returns a client connected to it.
+func serve(t *testing.T, e Engine) *uci.Client {
+	t.Helper()
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(engineIn, engineOut, e)
+		engineOut.Close()
+	}()
+	t.Cleanup(func() {
+		w.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return uci.NewClient(r, w)
+}
+
+func TestServe_UCI(t *testing.T) {
+	c := serve(t, &testEngine{})
+
+	name, author, opts, err := c.UCI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Test Engine" || author != "Test Author" {
+		t.Errorf("got id %q, %q", name, author)
+	}
+	if diff := cmp.Diff((&testEngine{}).Options(), opts); diff != "" {
+		t.Errorf("options mismatch (-want +got):\n%s", diff)
+	}
+	if err := c.IsReady(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestServe_SetOption(t *testing.T) {
+	e := &testEngine{}
+	c := serve(t, e)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, o := range [][2]string{{"hash", "64"}, {"Clear Hash", ""}} {
+		if err := c.SetOption(o[0], o[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.IsReady(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"Hash": "64", "Clear Hash": ""}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if diff := cmp.Diff(want, e.options); diff != "" {
+		t.Errorf("options mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestServe_Search(t *testing.T) {
+	e := &testEngine{}
+	c := serve(t, e)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.UCINewGame(); err != nil {
+		t.Fatal(err)
+	}
+	p := uci.PositionParams{StartPos: true, Moves: moves("d2d4", "d7d5")}
+	if err := c.Position(p); err != nil {
+		t.Fatal(err)
+	}
+
+	infoCh, bestCh, err := c.Go(uci.Search{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infos []uci.Info
+	for info := range infoCh {
+		infos = append(infos, info)
+	}
+	bm := <-bestCh
+
+	wantInfos := []uci.Info{{Depth: 1, Score: uci.Score{CP: 20}, PV: moves("e2e4")}}
+	if diff := cmp.Diff(wantInfos, infos); diff != "" {
+		t.Errorf("info mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(uci.BestMove{Move: moves("e2e4")[0], Ponder: moves("e7e5")[0]}, bm); diff != "" {
+		t.Errorf("best move mismatch (-want +got):\n%s", diff)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.newGames != 1 {
+		t.Errorf("got %d new games, want 1", e.newGames)
+	}
+	if diff := cmp.Diff(p, e.position); diff != "" {
+		t.Errorf("position mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestServe_Infinite(t *testing.T) {
+	c := serve(t, &testEngine{})
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+
+	infoCh, bestCh, err := c.Go(uci.Search{Infinite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-infoCh
+
+	select {
+	case bm := <-bestCh:
+		t.Fatalf("got best move %v before stop", bm)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := c.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if bm := <-bestCh; bm.Move.String() != "e2e4" {
+		t.Errorf("got best move %v, want e2e4", bm.Move)
+	}
+}
+
+func TestServe_PonderHit(t *testing.T) {
+	e := &testEngine{}
+	c := serve(t, e)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+
+	infoCh, bestCh, err := c.Go(uci.Search{Ponder: true, Infinite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-infoCh
+	if err := c.PonderHit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	<-bestCh
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.ponderHit {
+		t.Error("engine was not told about the ponder hit")
+	}
+}
+
+func TestServe_Quit(t *testing.T) {
+	in := strings.NewReader("uci\nquit\nisready\n")
+	var out strings.Builder
+	if err := Serve(in, &out, &testEngine{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "readyok") {
+		t.Errorf("Serve handled commands after quit:\n%s", out.String())
+	}
+}
+
+func TestServe_InvalidCommands(t *testing.T) {
+	in := strings.NewReader(strings.Join([]string{
+		"setoption name Threads value 4",
+		"setoption name Hash value 0",
+		"position",
+		"go depth x",
+		"nonsense",
+		"isready",
+	}, "\n"))
+	var out strings.Builder
+	if err := Serve(in, &out, &testEngine{}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5:\n%s", len(lines), out.String())
+	}
+	for _, line := range lines[:4] {
+		if !strings.HasPrefix(line, "info string ") {
+			t.Errorf("got %q, want an info string", line)
+		}
+	}
+	if lines[4] != "readyok" {
+		t.Errorf("got %q, want readyok", lines[4])
+	}
+}
@@ -0,0 +1,168 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AmbiguousMoveError is returned by ParseUserMove for input that matches more
+// than one legal move.
+type AmbiguousMoveError struct {
+	Input       string
+	Moves       []Move   // The legal moves the input matches.
+	Suggestions []string // The moves in SAN, in the same order.
+}
+
+func (e *AmbiguousMoveError) Error() string {
+	return fmt.Sprintf("ambiguous move %q: could be %s", e.Input, strings.Join(e.Suggestions, ", "))
+}
+
+// userMoveReplacer strips the punctuation ParseUserMove ignores.
+var userMoveReplacer = strings.NewReplacer("x", "", "X", "", ":", "", "-", "", "=", "", " ", "")
+
+// ParseUserMove parses a move typed by a person, and returns it if it is legal
+// in p. It accepts SAN, as ParseSAN does, and also UCI notation ("e2e4",
+// "e7e8Q"), castling written with zeros, lowercase letters or no dashes ("0-0",
+// "o-o", "OO"), missing or extra capture signs, dashes and equals signs
+// ("Nxf3", "e2-e4", "ed5", "e8Q"), uppercase files ("E4"), figurines ("♘f3"),
+// and trailing annotations ("Nf3!?", "exd6 e.p."). A missing piece letter means
+// a pawn, unless the whole from square is given. "b" is read as the b-file and
+// "B" as a bishop, unless only the other reading is legal.
+//
+// If the input matches several legal moves, as "Nd2" with two knights that can
+// move there or "e8" with a pawn that can promote, the error is an
+// *AmbiguousMoveError that lists them.
+func ParseUserMove(p *Position, s string) (Move, error) {
+	t := strings.TrimSpace(s)
+	for pt := Knight; pt <= King; pt++ {
+		t = strings.ReplaceAll(t, figurines[NewPiece(White, pt)], sanLetters[pt:pt+1])
+		t = strings.ReplaceAll(t, figurines[NewPiece(Black, pt)], sanLetters[pt:pt+1])
+	}
+	t = strings.TrimSpace(strings.TrimSuffix(strings.TrimRight(t, "+#!? "), "e.p."))
+	if t == "" {
+		return Move{}, fmt.Errorf("invalid move %q", s)
+	}
+
+	switch strings.ToUpper(strings.NewReplacer("0", "O", "-", "").Replace(t)) {
+	case "OO":
+		return p.parseCastling(s, true)
+	case "OOO":
+		return p.parseCastling(s, false)
+	}
+	if strings.Contains(t, "@") {
+		if m, err := ParseSAN(p, strings.ToUpper(t[:1])+strings.ToLower(t[1:])); err == nil {
+			return m, nil
+		}
+		return Move{}, fmt.Errorf("illegal move %q", s)
+	}
+	if m, err := ParseSAN(p, t); err == nil {
+		return m, nil
+	}
+
+	t = userMoveReplacer.Replace(t)
+	if t == "" {
+		return Move{}, fmt.Errorf("invalid move %q", s)
+	}
+	if m, err := ParseMove(strings.ToLower(t)); err == nil {
+		if m = p.ConvertCastling(m, p.Chess960()); p.IsLegal(m) {
+			return m, nil
+		}
+	}
+
+	// Try each reading of the first letter, preferred first.
+	type reading struct {
+		piece PieceType
+		rest  string
+	}
+	var readings []reading
+	switch c := t[0]; c {
+	case 'K', 'k', 'Q', 'q', 'R', 'r', 'N', 'n', 'P', 'p':
+		readings = []reading{{pieceTypeFromLetter(c), t[1:]}}
+	case 'B':
+		readings = []reading{{Bishop, t[1:]}, {NoPieceType, t}}
+	case 'b':
+		readings = []reading{{NoPieceType, t}, {Bishop, t[1:]}}
+	default:
+		readings = []reading{{NoPieceType, t}}
+	}
+	valid := false
+	for _, r := range readings {
+		moves, ok := p.matchUserMove(r.piece, strings.ToLower(r.rest))
+		valid = valid || ok
+		switch len(moves) {
+		case 0:
+			continue
+		case 1:
+			return moves[0], nil
+		}
+		err := &AmbiguousMoveError{Input: s, Moves: moves}
+		for _, m := range moves {
+			err.Suggestions = append(err.Suggestions, FormatSAN(p, m))
+		}
+		return Move{}, err
+	}
+	if !valid {
+		return Move{}, fmt.Errorf("invalid move %q", s)
+	}
+	return Move{}, fmt.Errorf("illegal move %q", s)
+}
+
+// pieceTypeFromLetter returns the piece type for a SAN letter in either case.
+func pieceTypeFromLetter(c byte) PieceType {
+	if c == 'P' || c == 'p' {
+		return Pawn
+	}
+	return pieceTypeFromSAN(strings.ToUpper(string(c)))
+}
+
+// matchUserMove returns the legal moves, other than castling, of a piece of
+// type pt, or of a pawn if pt is NoPieceType, that match s: an optional from
+// file and rank, a to square and an optional promotion, all lowercase. If s
+// has a whole from square, NoPieceType matches any piece. It reports whether s
+// is well formed.
+func (p *Position) matchUserMove(pt PieceType, s string) ([]Move, bool) {
+	promotion := NoPieceType
+	if n := len(s); n > 0 && strings.IndexByte("nbrqk", s[n-1]) >= 0 {
+		promotion = pieceTypeFromLetter(s[n-1])
+		s = s[:n-1]
+	}
+	if len(s) < 2 || len(s) > 4 {
+		return nil, false
+	}
+	to, err := ParseSquare(s[len(s)-2:])
+	if err != nil {
+		return nil, false
+	}
+	fromFile, fromRank := File(-1), Rank(-1)
+	for i := 0; i < len(s)-2; i++ {
+		switch c := s[i]; {
+		case c >= 'a' && c <= 'h' && fromFile < 0 && fromRank < 0:
+			fromFile = File(c - 'a')
+		case c >= '1' && c <= '8' && fromRank < 0:
+			fromRank = Rank(c - '1')
+		default:
+			return nil, false
+		}
+	}
+	if pt == NoPieceType && (fromFile < 0 || fromRank < 0) {
+		pt = Pawn
+	}
+
+	var res []Move
+	for _, m := range p.LegalMoves() {
+		if m.To != to || m.Drop != NoPieceType || (pt != NoPieceType && p.board[m.From].Type() != pt) {
+			continue
+		}
+		if promotion != NoPieceType && m.Promotion != promotion {
+			continue
+		}
+		if _, ok := p.castlingSide(m); ok {
+			continue
+		}
+		if (fromFile >= 0 && m.From.File() != fromFile) || (fromRank >= 0 && m.From.Rank() != fromRank) {
+			continue
+		}
+		res = append(res, m)
+	}
+	return res, true
+}
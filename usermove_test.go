@@ -0,0 +1,96 @@
+package chess
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseUserMove(t *testing.T) {
+	const (
+		castling = "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+		promote  = "3k4/1P6/8/8/8/8/8/4K3 w - - 0 1"
+		bpawn    = "4k3/8/8/8/2p5/1P6/8/4KB2 w - - 0 1"
+	)
+	cases := []struct {
+		fen, in, want string
+	}{
+		{StartingFEN, "e4", "e2e4"},
+		{StartingFEN, "e2e4", "e2e4"},
+		{StartingFEN, "e2-e4", "e2e4"},
+		{StartingFEN, "E4", "e2e4"},
+		{StartingFEN, " Nf3!? ", "g1f3"},
+		{StartingFEN, "nf3", "g1f3"},
+		{StartingFEN, "♘f3", "g1f3"},
+		{StartingFEN, "Ng1-f3", "g1f3"},
+		{StartingFEN, "G1F3", "g1f3"},
+		{castling, "O-O", "e1g1"},
+		{castling, "0-0", "e1g1"},
+		{castling, "o-o-o", "e1c1"},
+		{castling, "OO", "e1g1"},
+		{castling, "e1g1", "e1g1"},
+		{castling, "e1h1", "e1g1"},
+		{promote, "b7b8Q", "b7b8q"},
+		{promote, "b8Q", "b7b8q"},
+		{promote, "b8=n", "b7b8n"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "ed6", "e5d6"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6 e.p.", "e5d6"},
+		{"4k3/8/8/3p4/8/2N1N3/8/4K3 w - - 0 1", "Ncd5", "c3d5"},
+		{"4k3/8/8/3p4/8/2N1N3/8/4K3 w - - 0 1", "Nc:d5+", "c3d5"},
+		{bpawn, "bc4", "b3c4"},
+		{bpawn, "Bc4", "f1c4"},
+		{bpawn, "B4", "b3b4"},
+		{bpawn, "bd3", "f1d3"},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseUserMove(p, tc.in)
+		if err != nil {
+			t.Errorf("%s: ParseUserMove(%q): %v", tc.fen, tc.in, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("%s: ParseUserMove(%q) = %v, want %s", tc.fen, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseUserMove_Ambiguous(t *testing.T) {
+	cases := []struct {
+		fen, in string
+		want    []string
+	}{
+		{"4k3/8/8/3p4/8/2N1N3/8/4K3 w - - 0 1", "Nd5", []string{"Ncxd5", "Nexd5"}},
+		{"3k4/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8", []string{"b8=Q+", "b8=R+", "b8=B", "b8=N"}},
+	}
+	for _, tc := range cases {
+		p, err := ParseFEN(tc.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ParseUserMove(p, tc.in)
+		var ae *AmbiguousMoveError
+		if !errors.As(err, &ae) {
+			t.Errorf("ParseUserMove(%q) = %v, want an *AmbiguousMoveError", tc.in, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, ae.Suggestions); diff != "" {
+			t.Errorf("ParseUserMove(%q) suggestions mismatch (-want +got):\n%s", tc.in, diff)
+		}
+		if len(ae.Moves) != len(ae.Suggestions) {
+			t.Errorf("got %d moves for %d suggestions", len(ae.Moves), len(ae.Suggestions))
+		}
+	}
+}
+
+func TestParseUserMove_Invalid(t *testing.T) {
+	for _, in := range []string{"", "!?", "e5", "Ke2", "O-O", "z9", "Nf3f", "e2e4e5", "i2i4", "x", "X", "-", "=", ":", "xx"} {
+		if m, err := ParseUserMove(StartingPosition(), in); err == nil {
+			t.Errorf("ParseUserMove(%q) = %v, want error", in, m)
+		}
+	}
+}
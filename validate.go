@@ -0,0 +1,256 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FENField is a field of a FEN.
+type FENField int
+
+// FEN fields, in order. FENRecord refers to the FEN as a whole.
+const (
+	FENRecord FENField = iota - 1
+	FENBoard
+	FENSideToMove
+	FENCastling
+	FENEnPassant
+	FENHalfmoveClock
+	FENFullmoveNumber
+)
+
+var fenFieldNames = [...]string{
+	"FEN",
+	"piece placement",
+	"side to move",
+	"castling rights",
+	"en passant square",
+	"halfmove clock",
+	"fullmove number",
+}
+
+func (f FENField) String() string {
+	if f < FENRecord || f > FENFullmoveNumber {
+		return fmt.Sprintf("FENField(%d)", int(f))
+	}
+	return fenFieldNames[f+1]
+}
+
+// FENError is a problem with a FEN, found by ValidateFEN.
+type FENError struct {
+	FEN   string
+	Field FENField // The offending field.
+	Value string   // The value of the field.
+	Msg   string   // What is wrong with it.
+}
+
+func (e *FENError) Error() string {
+	if e.Field == FENRecord {
+		return fmt.Sprintf("invalid FEN %q: %s", e.FEN, e.Msg)
+	}
+	return fmt.Sprintf("invalid FEN %q: %v %q: %s", e.FEN, e.Field, e.Value, e.Msg)
+}
+
+// ValidateFEN checks fen more strictly than ParseFEN, and returns every problem
+// it finds, or nil if there are none. Besides syntax errors, it reports
+// positions that can't arise in a game: too many pieces for one side, the side
+// not to move in check, impossible checks, castling rights without the king
+// and rook in place, and en passant squares without a pawn that just moved two
+// squares.
+//
+// Problems in the piece placement prevent checking the fields that depend on
+// it.
+func ValidateFEN(fen string) []*FENError {
+	v := &fenValidator{fen: fen}
+	fields := strings.Fields(fen)
+	if len(fields) != 4 && len(fields) != 6 {
+		v.fail(FENRecord, "", "want 4 or 6 fields, got %d", len(fields))
+		return v.errs
+	}
+	v.fields = fields
+
+	p := v.board()
+	side, sideOK := v.sideToMove()
+	if p != nil && sideOK {
+		p.sideToMove = side
+		v.check(p)
+		v.castling(p)
+		v.enPassant(p)
+	}
+	v.clocks()
+	return v.errs
+}
+
+// fenValidator collects the problems with a FEN.
+type fenValidator struct {
+	fen    string
+	fields []string
+	errs   []*FENError
+}
+
+func (v *fenValidator) fail(f FENField, value, format string, args ...any) {
+	v.errs = append(v.errs, &FENError{FEN: v.fen, Field: f, Value: value, Msg: fmt.Sprintf(format, args...)})
+}
+
+// board checks the piece placement, and returns the position if it is
+// usable for further checks.
+func (v *fenValidator) board() *Position {
+	s := v.fields[FENBoard]
+	fail := func(format string, args ...any) { v.fail(FENBoard, s, format, args...) }
+	n := len(v.errs)
+
+	ranks := strings.Split(s, "/")
+	if len(ranks) != 8 {
+		fail("want 8 ranks, got %d", len(ranks))
+		return nil
+	}
+	var counts [13]int
+	for i, row := range ranks {
+		r := Rank8 - Rank(i)
+		width, digit := 0, false
+		for j := 0; j < len(row); j++ {
+			c := row[j]
+			if c >= '1' && c <= '8' {
+				if digit {
+					fail("rank %v has consecutive digits", r)
+				}
+				width += int(c - '0')
+				digit = true
+				continue
+			}
+			digit = false
+			pc, ok := pieceFromLetter(c)
+			if !ok {
+				fail("rank %v has an invalid piece %q", r, c)
+				continue
+			}
+			width++
+			counts[pc]++
+			if pc.Type() == Pawn && (r == Rank1 || r == Rank8) {
+				fail("rank %v has a pawn", r)
+			}
+		}
+		if width != 8 {
+			fail("rank %v has %d squares, want 8", r, width)
+		}
+	}
+
+	for _, c := range []Color{White, Black} {
+		count := func(pt PieceType) int { return counts[NewPiece(c, pt)] }
+		if n := count(King); n != 1 {
+			fail("%v has %d kings, want 1", c, n)
+		}
+		if n := count(Pawn); n > 8 {
+			fail("%v has %d pawns", c, n)
+		}
+		// Pieces beyond the initial ones must be promoted pawns.
+		extra := 0
+		for _, pc := range []struct {
+			pt      PieceType
+			initial int
+		}{{Knight, 2}, {Bishop, 2}, {Rook, 2}, {Queen, 1}} {
+			if n := count(pc.pt); n > pc.initial {
+				extra += n - pc.initial
+			}
+		}
+		if missing := 8 - count(Pawn); missing >= 0 && extra > missing {
+			fail("%v has %d promoted pieces but only %d missing pawns", c, extra, missing)
+		}
+	}
+
+	if len(v.errs) > n {
+		return nil
+	}
+	p := &Position{enPassant: NoSquare}
+	if err := p.parseBoard(s); err != nil {
+		fail("%v", err)
+		return nil
+	}
+	return p
+}
+
+func (v *fenValidator) sideToMove() (Color, bool) {
+	switch s := v.fields[FENSideToMove]; s {
+	case "w":
+		return White, true
+	case "b":
+		return Black, true
+	default:
+		v.fail(FENSideToMove, s, `want "w" or "b"`)
+		return White, false
+	}
+}
+
+// check checks that the side not to move isn't in check, and that the side to
+// move isn't in a check that no move could give.
+func (v *fenValidator) check(p *Position) {
+	s := v.fields[FENSideToMove]
+	them := p.sideToMove.Other()
+	if p.isAttacked(p.kingSquare(them), p.sideToMove) {
+		v.fail(FENSideToMove, s, "%v is in check but not to move", them)
+	}
+	if n := p.Checkers().Count(); n > 2 {
+		v.fail(FENSideToMove, s, "%v is in check by %d pieces", p.sideToMove, n)
+	}
+}
+
+func (v *fenValidator) castling(p *Position) {
+	s := v.fields[FENCastling]
+	if err := p.parseCastlingRights(s); err != nil {
+		// Keep the reason, which follows the field in the error.
+		msg := "invalid or repeated right"
+		if _, reason, ok := strings.Cut(err.Error(), fmt.Sprintf("%q without", s)); ok {
+			msg = "without" + reason
+		}
+		v.fail(FENCastling, s, "%s", msg)
+	}
+}
+
+// enPassant checks that a pawn could have just moved two squares past the en
+// passant square.
+func (v *fenValidator) enPassant(p *Position) {
+	s := v.fields[FENEnPassant]
+	if s == "-" {
+		return
+	}
+	sq, err := ParseSquare(s)
+	if err != nil {
+		v.fail(FENEnPassant, s, "invalid square")
+		return
+	}
+	them := p.sideToMove.Other()
+	want := Rank6
+	if them == White {
+		want = Rank3
+	}
+	if sq.Rank() != want {
+		v.fail(FENEnPassant, s, "wrong rank for %v to move", p.sideToMove)
+		return
+	}
+	dir := pawnDirection(them)
+	to, _ := offset(sq, 0, dir)
+	from, _ := offset(sq, 0, -dir)
+	if p.board[to] != NewPiece(them, Pawn) {
+		v.fail(FENEnPassant, s, "no %v pawn on %v", them, to)
+	}
+	if p.board[sq] != NoPiece || p.board[from] != NoPiece {
+		v.fail(FENEnPassant, s, "%v and %v must be empty", from, sq)
+	}
+}
+
+func (v *fenValidator) clocks() {
+	if len(v.fields) != 6 {
+		return
+	}
+	s := v.fields[FENHalfmoveClock]
+	if n, err := strconv.Atoi(s); err != nil || n < 0 {
+		v.fail(FENHalfmoveClock, s, "want a non-negative integer")
+	} else if n != 0 && v.fields[FENEnPassant] != "-" {
+		v.fail(FENHalfmoveClock, s, "want 0 after a pawn move with an en passant square")
+	}
+	s = v.fields[FENFullmoveNumber]
+	if n, err := strconv.Atoi(s); err != nil || n < 1 {
+		v.fail(FENFullmoveNumber, s, "want a positive integer")
+	}
+}
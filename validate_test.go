@@ -0,0 +1,121 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateFEN(t *testing.T) {
+	type problem struct {
+		Field FENField
+		Msg   string
+	}
+	cases := []struct {
+		fen  string
+		want []problem
+	}{
+		{fen: StartingFEN},
+		{fen: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"},
+		{fen: "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9"},
+		{fen: "4k3/8/8/8/8/8/8/4K3 w - -"},
+		{
+			fen:  "rnbqkbnr/pppppppp/8/8/8 w KQkq - 0 1",
+			want: []problem{{FENBoard, "want 8 ranks, got 5"}},
+		},
+		{
+			fen: "rnbqkbnr/ppppppp/8/44/8/8/PPPPPPPPP/RNBQKBNX w - - 0 1",
+			want: []problem{
+				{FENBoard, "rank 7 has 7 squares, want 8"},
+				{FENBoard, "rank 5 has consecutive digits"},
+				{FENBoard, "rank 2 has 9 squares, want 8"},
+				{FENBoard, `rank 1 has an invalid piece 'X'`},
+				{FENBoard, "rank 1 has 7 squares, want 8"},
+				{FENBoard, "white has 9 pawns"},
+			},
+		},
+		{
+			fen: "QQQQQQQQ/4k3/8/8/8/8/PPPPPPPP/P3K2P w - - 0 1",
+			want: []problem{
+				{FENBoard, "rank 1 has a pawn"},
+				{FENBoard, "rank 1 has a pawn"},
+				{FENBoard, "white has 10 pawns"},
+			},
+		},
+		{
+			fen:  "8/4k3/8/8/8/8/PPPPPPP1/QQQ1K3 w - - 0 1",
+			want: []problem{{FENBoard, "white has 2 promoted pieces but only 1 missing pawns"}},
+		},
+		{
+			fen:  "8/8/8/8/8/8/8/4K3 w - - 0 1",
+			want: []problem{{FENBoard, "black has 0 kings, want 1"}},
+		},
+		{
+			fen:  "4k3/8/8/8/8/8/8/4K2R x - - 0 0",
+			want: []problem{{FENSideToMove, `want "w" or "b"`}, {FENFullmoveNumber, "want a positive integer"}},
+		},
+		{
+			fen:  "4k3/4R3/8/8/8/8/8/4K3 w - - 0 1",
+			want: []problem{{FENSideToMove, "black is in check but not to move"}},
+		},
+		{
+			fen:  "4k3/8/5N2/1B6/8/8/8/4R1K1 b - - 0 1",
+			want: []problem{{FENSideToMove, "black is in check by 3 pieces"}},
+		},
+		{
+			fen:  "4k3/8/8/8/8/8/3K4/7R w K - 0 1",
+			want: []problem{{FENCastling, "without the king on its back rank"}},
+		},
+		{
+			fen:  "4k3/8/8/8/8/8/8/4K3 w Kk - 0 1",
+			want: []problem{{FENCastling, "without a rook"}},
+		},
+		{
+			fen:  "4k3/8/8/8/8/8/8/R3K3 w QQ - 0 1",
+			want: []problem{{FENCastling, "invalid or repeated right"}},
+		},
+		{
+			fen:  "4k3/8/8/8/8/8/8/4K3 w - e3 0 1",
+			want: []problem{{FENEnPassant, "wrong rank for white to move"}},
+		},
+		{
+			fen: "4k3/8/8/8/8/4N3/8/4K3 b - e3 3 1",
+			want: []problem{
+				{FENEnPassant, "no white pawn on e4"},
+				{FENEnPassant, "e2 and e3 must be empty"},
+				{FENHalfmoveClock, "want 0 after a pawn move with an en passant square"},
+			},
+		},
+		{
+			fen:  "4k3/8/8/8/8/8/8/4K3 w - z9 -1 1",
+			want: []problem{{FENEnPassant, "invalid square"}, {FENHalfmoveClock, "want a non-negative integer"}},
+		},
+		{
+			fen:  "4k3/8/8/8/8/8/8/4K3 w - - 0",
+			want: []problem{{FENRecord, "want 4 or 6 fields, got 5"}},
+		},
+	}
+	for _, tc := range cases {
+		var got []problem
+		for _, e := range ValidateFEN(tc.fen) {
+			if e.FEN != tc.fen {
+				t.Errorf("ValidateFEN(%q): error has FEN %q", tc.fen, e.FEN)
+			}
+			got = append(got, problem{e.Field, e.Msg})
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("ValidateFEN(%q) mismatch (-want +got):\n%s", tc.fen, diff)
+		}
+	}
+}
+
+func TestFENError_Error(t *testing.T) {
+	errs := ValidateFEN("4k3/8/8/8/8/8/8/4K3 w X - 0 1")
+	if len(errs) != 1 {
+		t.Fatalf("want 1 error, got %v", errs)
+	}
+	want := `invalid FEN "4k3/8/8/8/8/8/8/4K3 w X - 0 1": castling rights "X": invalid or repeated right`
+	if got := errs[0].Error(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,425 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variant is a set of rules. Positions follow the rules of their variant, which
+// is chosen when they are parsed with ParseVariantFEN.
+type Variant int
+
+// Variants, as played on Lichess.
+const (
+	// Standard is chess under the FIDE laws, including Chess960.
+	Standard Variant = iota
+
+	// Crazyhouse lets players drop captured pieces back on the board as their
+	// move. Promoted pieces go back to being pawns when captured.
+	Crazyhouse
+
+	// Atomic makes captures explode: the capturing piece and every piece
+	// other than a pawn next to the capture square are removed. Kings can't
+	// capture, and a player wins by exploding the other king.
+	Atomic
+
+	// KingOfTheHill is won by checkmate, or by moving the king to one of the
+	// four central squares.
+	KingOfTheHill
+
+	// Antichess, also known as losing chess, is won by losing all one's
+	// pieces or being stalemated. Capturing is compulsory, there is no check,
+	// the king is an ordinary piece, and pawns may promote to kings.
+	Antichess
+
+	// Horde pits White's horde of pawns, and no king, against Black's usual
+	// army. Black wins by capturing the whole horde, and White by
+	// checkmate. White pawns on the first rank may move two squares.
+	Horde
+)
+
+var variantNames = [...]string{
+	Standard:      "Standard",
+	Crazyhouse:    "Crazyhouse",
+	Atomic:        "Atomic",
+	KingOfTheHill: "King of the Hill",
+	Antichess:     "Antichess",
+	Horde:         "Horde",
+}
+
+// String returns the name of the variant as in a PGN Variant tag, such as
+// "King of the Hill".
+func (v Variant) String() string {
+	if v < Standard || v > Horde {
+		return fmt.Sprintf("Variant(%d)", int(v))
+	}
+	return variantNames[v]
+}
+
+// ParseVariant parses the name of a variant, as returned by String or as used
+// by Lichess, such as "King of the Hill" or "kingOfTheHill". Case, spaces and
+// hyphens don't matter, and a few common aliases are accepted.
+func ParseVariant(s string) (Variant, error) {
+	key := strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(s))
+	switch key {
+	case "standard", "chess", "normal", "chess960", "fromposition":
+		return Standard, nil
+	case "crazyhouse", "zh":
+		return Crazyhouse, nil
+	case "atomic":
+		return Atomic, nil
+	case "kingofthehill", "koth":
+		return KingOfTheHill, nil
+	case "antichess", "giveaway", "suicide":
+		return Antichess, nil
+	case "horde":
+		return Horde, nil
+	}
+	return Standard, fmt.Errorf("unknown variant %q", s)
+}
+
+// StartingFEN returns the FEN of the variant's starting position.
+func (v Variant) StartingFEN() string {
+	switch v {
+	case Crazyhouse:
+		return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1"
+	case Antichess:
+		return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1"
+	case Horde:
+		return "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1"
+	}
+	return StartingFEN
+}
+
+// VariantStartingPosition returns the starting position of v.
+func VariantStartingPosition(v Variant) *Position {
+	p, err := ParseVariantFEN(v, v.StartingFEN())
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Variant returns the rules that p follows.
+func (p *Position) Variant() Variant {
+	return p.variant
+}
+
+// Pocket returns the number of pieces of color c and type pt that c has in
+// hand to drop, in Crazyhouse.
+func (p *Position) Pocket(c Color, pt PieceType) int {
+	return int(p.pockets[c][pt])
+}
+
+// IsPromoted reports whether the piece on sq is a promoted pawn, which goes
+// back to being a pawn when captured, in Crazyhouse.
+func (p *Position) IsPromoted(sq Square) bool {
+	return p.promoted.Has(sq)
+}
+
+// hill is the center of the board, which kings race to in King of the Hill.
+const hill = Bitboard(1)<<D4 | Bitboard(1)<<E4 | Bitboard(1)<<D5 | Bitboard(1)<<E5
+
+// VariantOutcome returns the outcome of the game if it is over by a rule of
+// the variant rather than by checkmate or stalemate, or NoOutcome: a king on
+// the hill, an exploded king, a captured horde, or a side in Antichess with no
+// pieces left to lose. Stalemate in Antichess is a win, which Game reports.
+func (p *Position) VariantOutcome() Outcome {
+	switch p.variant {
+	case KingOfTheHill:
+		for _, c := range []Color{White, Black} {
+			if p.Pieces(c, King)&hill != 0 {
+				return winFor(c)
+			}
+		}
+	case Atomic:
+		for _, c := range []Color{White, Black} {
+			if p.Pieces(c, King) == 0 {
+				return winFor(c.Other())
+			}
+		}
+	case Horde:
+		if p.colors[White] == 0 {
+			return BlackWon
+		}
+	case Antichess:
+		for _, c := range []Color{White, Black} {
+			if p.colors[c] == 0 {
+				return winFor(c)
+			}
+		}
+	}
+	return NoOutcome
+}
+
+// winFor returns the outcome where c wins.
+func winFor(c Color) Outcome {
+	if c == White {
+		return WhiteWon
+	}
+	return BlackWon
+}
+
+// parsePocket splits the pieces in hand off the piece placement field of a
+// Crazyhouse FEN, where they follow the board in brackets, as in
+// "rnbqkbnr/.../RNBQKBNR[Qp]", or as a ninth rank. They are added to p's
+// pockets, and the board is returned.
+func (p *Position) parsePocket(s string) (string, error) {
+	var pocket string
+	switch {
+	case strings.HasSuffix(s, "]"):
+		i := strings.LastIndexByte(s, '[')
+		if i < 0 {
+			return "", fmt.Errorf("invalid pocket in %q", s)
+		}
+		s, pocket = s[:i], s[i+1:len(s)-1]
+	case strings.Count(s, "/") == 8:
+		i := strings.LastIndexByte(s, '/')
+		s, pocket = s[:i], s[i+1:]
+	}
+	for i := 0; i < len(pocket); i++ {
+		pc, ok := pieceFromLetter(pocket[i])
+		if !ok || pc.Type() == King {
+			return "", fmt.Errorf("invalid piece %q in pocket", pocket[i])
+		}
+		p.pockets[pc.Color()][pc.Type()]++
+	}
+	return s, nil
+}
+
+// formatPocket returns p's pieces in hand as in a Crazyhouse FEN, in brackets,
+// such as "[QNpp]".
+func (p *Position) formatPocket() string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for _, c := range []Color{White, Black} {
+		for pt := Queen; pt >= Pawn; pt-- {
+			for n := p.pockets[c][pt]; n > 0; n-- {
+				b.WriteString(NewPiece(c, pt).String())
+			}
+		}
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// placement is a piece on a square.
+type placement struct {
+	sq Square
+	pc Piece
+}
+
+// explode removes the piece on sq, which has just captured, and the pieces
+// around it other than pawns, as in Atomic. It returns what it removed, for
+// Unapply, and drops the castling rights of exploded kings and rooks.
+func (p *Position) explode(sq Square) []placement {
+	occ := p.colors[White] | p.colors[Black]
+	blast := bb(sq) | kingAttacks[sq]&occ&^p.pieces[Pawn]
+	removed := make([]placement, 0, blast.Count())
+	for ; blast != 0; blast &= blast - 1 {
+		s := blast.first()
+		pc := p.board[s]
+		removed = append(removed, placement{s, pc})
+		p.remove(s)
+		if pc.Type() == King {
+			p.castling &^= 3 << castlingIndex(pc.Color(), true)
+		}
+		for i, rook := range p.castlingRooks {
+			if s == rook {
+				p.castling &^= 1 << i
+			}
+		}
+	}
+	return removed
+}
+
+// pocketCapture updates the pockets and promoted pieces of a Crazyhouse
+// position for the move in u, which has just been played, apart from drops.
+func (p *Position) pocketCapture(u *undo) {
+	m, us := u.move, u.moved.Color()
+	if u.captured != NoPiece {
+		pt := u.captured.Type()
+		if p.promoted.Has(u.capturedOn) {
+			pt = Pawn
+		}
+		p.pockets[us][pt]++
+		p.promoted &^= bb(u.capturedOn)
+	}
+	if p.promoted.Has(m.From) {
+		p.promoted = p.promoted&^bb(m.From) | bb(m.To)
+	}
+	if m.Promotion != NoPieceType {
+		p.promoted |= bb(m.To)
+	}
+}
+
+// isCapture reports whether m captures a piece of the other side.
+func (p *Position) isCapture(m Move) bool {
+	if m.Drop != NoPieceType {
+		return false
+	}
+	if p.colors[p.sideToMove.Other()].Has(m.To) {
+		return true
+	}
+	return p.board[m.From].Type() == Pawn && m.To == p.enPassant && m.From.File() != m.To.File()
+}
+
+// appendVariantMoves appends the legal moves of Atomic, Antichess and the
+// kingless side in Horde to moves. The moves are generated by the movement
+// rules of the pieces, and then filtered by the variant's rules.
+func (p *Position) appendVariantMoves(moves []Move) []Move {
+	start := len(moves)
+	moves = p.appendPseudoLegalMoves(moves)
+	if p.variant == Atomic && p.Checkers() == 0 {
+		moves = p.appendCastlingMoves(moves)
+	}
+
+	legal := moves[:start]
+	switch p.variant {
+	case Atomic:
+		kings := p.Pieces(p.sideToMove, King)
+		for _, m := range moves[start:] {
+			if kings.Has(m.From) && p.isCapture(m) {
+				continue // Kings can't capture.
+			}
+			if p.isAtomicLegal(m) {
+				legal = append(legal, m)
+			}
+		}
+	case Antichess:
+		// Captures are compulsory.
+		for _, m := range moves[start:] {
+			if p.isCapture(m) {
+				legal = append(legal, m)
+			}
+		}
+		if len(legal) == start {
+			legal = moves
+		}
+	default:
+		legal = moves
+	}
+	return legal
+}
+
+// isAtomicLegal reports whether m, a pseudo-legal move in an Atomic position,
+// neither explodes the mover's king nor leaves it in check. Exploding the
+// other king wins outright, whatever else happens, and kings that touch can't
+// check each other.
+func (p *Position) isAtomicLegal(m Move) bool {
+	us := p.sideToMove
+	p.Apply(m)
+	defer p.Unapply()
+	switch {
+	case p.Pieces(us, King) == 0:
+		return false
+	case p.Pieces(us.Other(), King) == 0:
+		return true
+	}
+	ksq := p.kingSquare(us)
+	if kingAttacks[ksq]&p.Pieces(us.Other(), King) != 0 {
+		return true
+	}
+	return !p.isAttacked(ksq, us.Other())
+}
+
+// antichessPromotions are the pieces a pawn may promote to in Antichess.
+var antichessPromotions = []PieceType{Queen, Rook, Bishop, Knight, King}
+
+// appendPseudoLegalMoves appends the moves that follow the movement rules of
+// the pieces, ignoring checks and castling, to moves.
+func (p *Position) appendPseudoLegalMoves(moves []Move) []Move {
+	us := p.sideToMove
+	own, enemy := p.colors[us], p.colors[us.Other()]
+	occ := own | enemy
+
+	for b := own &^ p.pieces[Pawn]; b != 0; b &= b - 1 {
+		from := b.first()
+		var to Bitboard
+		switch p.board[from].Type() {
+		case Knight:
+			to = knightAttacks[from]
+		case Bishop:
+			to = bishopAttacks(from, occ)
+		case Rook:
+			to = rookAttacks(from, occ)
+		case Queen:
+			to = bishopAttacks(from, occ) | rookAttacks(from, occ)
+		case King:
+			to = kingAttacks[from]
+		}
+		for to &^= own; to != 0; to &= to - 1 {
+			moves = append(moves, Move{From: from, To: to.first()})
+		}
+	}
+
+	promotions := promotionPieces
+	if p.variant == Antichess {
+		promotions = antichessPromotions
+	}
+	startRank, promoRank := Rank2, Rank8
+	if us == Black {
+		startRank, promoRank = Rank7, Rank1
+	}
+	dir := pawnDirection(us)
+	for b := p.pieces[Pawn] & own; b != 0; b &= b - 1 {
+		from := b.first()
+
+		var to Bitboard
+		if push, ok := offset(from, 0, dir); ok && !occ.Has(push) {
+			to |= bb(push)
+			// Horde pawns on the first rank may also move two squares.
+			if from.Rank() == startRank || from.Rank() == startRank-Rank(dir) {
+				if push2, ok := offset(push, 0, dir); ok && !occ.Has(push2) {
+					to |= bb(push2)
+				}
+			}
+		}
+		to |= pawnAttacks[us][from] & enemy
+		if p.enPassant != NoSquare {
+			to |= pawnAttacks[us][from] & bb(p.enPassant)
+		}
+
+		for ; to != 0; to &= to - 1 {
+			sq := to.first()
+			if sq.Rank() != promoRank {
+				moves = append(moves, Move{From: from, To: sq})
+				continue
+			}
+			for _, pt := range promotions {
+				moves = append(moves, Move{From: from, To: sq, Promotion: pt})
+			}
+		}
+	}
+	return moves
+}
+
+// appendDrops appends the legal Crazyhouse drops to moves: pieces in hand may
+// be dropped on any empty square that doesn't leave the king in check, except
+// pawns on the first and last ranks.
+func (p *Position) appendDrops(moves []Move) []Move {
+	us := p.sideToMove
+	target := ^(p.colors[White] | p.colors[Black])
+	switch checkers := p.Checkers(); checkers.Count() {
+	case 0:
+	case 1:
+		target &= between[p.kingSquare(us)][checkers.first()]
+	default:
+		return moves
+	}
+	const backRanks Bitboard = 0xFF000000000000FF
+	for pt := Pawn; pt <= Queen; pt++ {
+		if p.pockets[us][pt] == 0 {
+			continue
+		}
+		to := target
+		if pt == Pawn {
+			to &^= backRanks
+		}
+		for ; to != 0; to &= to - 1 {
+			sq := to.first()
+			moves = append(moves, Move{From: sq, To: sq, Drop: pt})
+		}
+	}
+	return moves
+}
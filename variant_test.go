@@ -0,0 +1,270 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVariant_Perft(t *testing.T) {
+	cases := []struct {
+		v     Variant
+		fen   string
+		depth int
+		want  int
+	}{
+		{Crazyhouse, "", 5, 4888832},
+		{Atomic, "", 4, 197326},
+		{KingOfTheHill, "", 4, 197281},
+		{Antichess, "", 5, 2732672},
+		{Horde, "", 5, 265223},
+	}
+	for _, c := range cases {
+		fen := c.fen
+		if fen == "" {
+			fen = c.v.StartingFEN()
+		}
+		p, err := ParseVariantFEN(c.v, fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Perft(p, c.depth); got != c.want {
+			t.Errorf("%v %s: Perft(%d) = %d, want %d", c.v, fen, c.depth, got, c.want)
+		}
+		if got := p.String(); got != fen {
+			t.Errorf("%v: position changed to %q", c.v, got)
+		}
+	}
+}
+
+func TestParseVariant(t *testing.T) {
+	for v := Standard; v <= Horde; v++ {
+		got, err := ParseVariant(v.String())
+		if err != nil || got != v {
+			t.Errorf("ParseVariant(%q) = %v, %v", v.String(), got, err)
+		}
+	}
+	for s, want := range map[string]Variant{
+		"kingOfTheHill": KingOfTheHill,
+		"fromPosition":  Standard,
+		"3check":        -1,
+		"":              -1,
+	} {
+		got, err := ParseVariant(s)
+		if want < 0 {
+			if err == nil {
+				t.Errorf("ParseVariant(%q): want error", s)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("ParseVariant(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+}
+
+func TestParseVariantFEN(t *testing.T) {
+	cases := []struct {
+		v        Variant
+		fen, out string
+	}{
+		{Crazyhouse, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1", ""},
+		{Crazyhouse, "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKBN~R[Ppqp] w KQkq - 0 1", "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKBN~R[Pqpp] w KQkq - 0 1"},
+		{Crazyhouse, "4k3/8/8/8/8/8/8/4K3/Nn w - - 0 1", "4k3/8/8/8/8/8/8/4K3[Nn] w - - 0 1"},
+		{Antichess, "8/8/8/8/8/8/8/KK4kk w - - 0 1", ""},
+		{Horde, "4k3/8/8/8/8/8/8/PPPP4 w - - 0 1", ""},
+	}
+	for _, c := range cases {
+		p, err := ParseVariantFEN(c.v, c.fen)
+		if err != nil {
+			t.Errorf("%v %q: %v", c.v, c.fen, err)
+			continue
+		}
+		want := c.out
+		if want == "" {
+			want = c.fen
+		}
+		if got := p.String(); got != want {
+			t.Errorf("%v %q: String() = %q, want %q", c.v, c.fen, got, want)
+		}
+	}
+
+	for _, c := range []struct {
+		v   Variant
+		fen string
+	}{
+		{Standard, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1"},
+		{Crazyhouse, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[K] w KQkq - 0 1"},
+		{Crazyhouse, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPP~P/RNBQKBNR[] w KQkq - 0 1"},
+		{Antichess, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{Horde, StartingFEN},
+		{Standard, "4k3/8/8/8/8/8/8/PPPP4 w - - 0 1"},
+	} {
+		if _, err := ParseVariantFEN(c.v, c.fen); err == nil {
+			t.Errorf("%v %q: want error", c.v, c.fen)
+		}
+	}
+}
+
+// playVariant plays moves, in UCI notation, from fen in variant v.
+func playVariant(t *testing.T, v Variant, fen string, moves ...string) *Game {
+	t.Helper()
+	p, err := ParseVariantFEN(v, fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGameFromPosition(p)
+	for _, s := range moves {
+		if err := g.Play(mustParseMove(t, s)); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+	}
+	return g
+}
+
+func TestCrazyhouse(t *testing.T) {
+	// Promoted pieces keep their mark as they move, and go back to being
+	// pawns when captured.
+	g := playVariant(t, Crazyhouse, "4k3/1P6/8/8/8/8/8/R3K3[] w - - 0 1", "b7b8q", "e8d7", "b8b1", "d7c7")
+	p := g.Position()
+	if got, want := p.String(), "8/2k5/8/8/8/8/8/RQ~2K3[] w - - 3 3"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	g = playVariant(t, Crazyhouse, "4k3/1P6/8/8/8/8/8/4K3[] w - - 0 1", "b7b8q", "e8d7", "b8c7", "d7c7")
+	p = g.Position()
+	if got, want := p.String(), "8/2k5/8/8/8/8/8/4K3[p] w - - 0 3"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if p.Pocket(Black, Pawn) != 1 || p.Pocket(Black, Queen) != 0 {
+		t.Errorf("pocket = %d pawns, %d queens", p.Pocket(Black, Pawn), p.Pocket(Black, Queen))
+	}
+
+	// Drops may block a check, but pawns can't be dropped on the back ranks.
+	p, err := ParseVariantFEN(Crazyhouse, "4k3/8/8/8/8/8/8/r3K3[PN] w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var drops []string
+	for _, m := range p.LegalMoves() {
+		if m.Drop != NoPieceType {
+			drops = append(drops, FormatSAN(p, m))
+		}
+	}
+	if diff := cmp.Diff([]string{"N@b1", "N@c1", "N@d1"}, drops); diff != "" {
+		t.Errorf("drops mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := ParseSAN(p, "@b1"); err == nil {
+		t.Error("ParseSAN(@b1): want error")
+	}
+	m, err := ParseSAN(p, "N@d1")
+	if err != nil || m != (Move{From: D1, To: D1, Drop: Knight}) {
+		t.Errorf("ParseSAN(N@d1) = %v, %v", m, err)
+	}
+
+	h := p.Hash()
+	p.Apply(m)
+	p.Unapply()
+	if got := p.String(); got != "4k3/8/8/8/8/8/8/r3K3[NP] w - - 0 1" {
+		t.Errorf("after Unapply got %q", got)
+	}
+	if p.Hash() != h {
+		t.Error("hash changed after Unapply")
+	}
+	q, _ := ParseVariantFEN(Crazyhouse, "4k3/8/8/8/8/8/8/r3K3[N] w - - 0 1")
+	if q.Hash() == h {
+		t.Error("pockets don't change the hash")
+	}
+}
+
+func TestAtomic(t *testing.T) {
+	// Nxd7 explodes the knight, the queen on d7 and the bishop on c8, but not
+	// the pawns.
+	p, _ := ParseVariantFEN(Atomic, "rnb1kbnr/pppqpppp/5N2/8/8/8/PPPPPPPP/R1BQKBNR w KQkq - 0 1")
+	p.Apply(Move{From: F6, To: D7})
+	if got, want := p.String(), "rn3bnr/ppp1pppp/8/8/8/8/PPPPPPPP/R1BQKBNR b KQ - 0 1"; got != want {
+		t.Errorf("after Nxd7 got %q, want %q", got, want)
+	}
+	p.Unapply()
+	if got, want := p.String(), "rnb1kbnr/pppqpppp/5N2/8/8/8/PPPPPPPP/R1BQKBNR w KQkq - 0 1"; got != want {
+		t.Errorf("after Unapply got %q, want %q", got, want)
+	}
+
+	// Kings can't capture, and touching kings can't check each other.
+	p, _ = ParseVariantFEN(Atomic, "8/8/8/3k4/3q4/4K3/8/8 w - - 0 1")
+	if p.IsLegal(Move{From: E3, To: D4}) {
+		t.Error("the king captures")
+	}
+	if p.IsLegal(Move{From: E3, To: F4}) {
+		t.Error("Kf4 walks into check")
+	}
+	if !p.IsLegal(Move{From: E3, To: E4}) {
+		t.Error("Ke4 next to the other king is illegal")
+	}
+	p.Apply(Move{From: E3, To: E4})
+	if p.InCheck() {
+		t.Error("touching kings give check")
+	}
+
+	// Exploding the other king wins, even from check.
+	g := playVariant(t, Atomic, "3qk3/3p4/8/8/8/8/8/3QK2r w - - 0 1", "d1d7")
+	if g.Outcome() != WhiteWon || g.Method() != VariantEnd {
+		t.Errorf("got %v by %v, want 1-0 by variant end", g.Outcome(), g.Method())
+	}
+}
+
+func TestKingOfTheHill(t *testing.T) {
+	g := playVariant(t, KingOfTheHill, "4k3/8/8/8/8/4K3/8/8 w - - 0 1", "e3e4")
+	if g.Outcome() != WhiteWon || g.Method() != VariantEnd {
+		t.Errorf("got %v by %v, want 1-0 by variant end", g.Outcome(), g.Method())
+	}
+	if moves := g.Position().LegalMoves(); len(moves) != 0 {
+		t.Errorf("legal moves after the game ended: %v", moves)
+	}
+}
+
+func TestAntichess(t *testing.T) {
+	// Captures are compulsory, and pawns may promote to kings.
+	p, _ := ParseVariantFEN(Antichess, "8/1P6/8/8/8/8/4p3/3N4 b - - 0 1")
+	var got []string
+	for _, m := range p.LegalMoves() {
+		got = append(got, m.String())
+	}
+	if diff := cmp.Diff([]string{"e2d1q", "e2d1r", "e2d1b", "e2d1n", "e2d1k"}, got); diff != "" {
+		t.Errorf("moves mismatch (-want +got):\n%s", diff)
+	}
+
+	// Losing every piece wins.
+	g := playVariant(t, Antichess, "8/8/8/8/8/8/4p3/3N4 b - - 0 1", "e2d1k")
+	if g.Outcome() != WhiteWon || g.Method() != VariantEnd {
+		t.Errorf("got %v by %v, want 1-0 by variant end", g.Outcome(), g.Method())
+	}
+	g = playVariant(t, Antichess, "8/8/8/8/8/8/2k5/3N4 w - - 0 1", "d1c3", "c2c3")
+	if g.Outcome() != WhiteWon || g.Method() != VariantEnd {
+		t.Errorf("got %v by %v, want 1-0 by variant end", g.Outcome(), g.Method())
+	}
+
+	// So does being stalemated.
+	g = playVariant(t, Antichess, "8/8/8/8/8/p7/P7/8 w - - 0 1")
+	if g.Outcome() != WhiteWon || g.Method() != VariantEnd {
+		t.Errorf("got %v by %v, want 1-0 by variant end", g.Outcome(), g.Method())
+	}
+}
+
+func TestHorde(t *testing.T) {
+	// Pawns on the first rank may move two squares, but can't be taken en
+	// passant.
+	g := playVariant(t, Horde, "4k3/8/8/8/8/1p6/8/P7 w - - 0 1", "a1a3")
+	if ep := g.Position().EnPassant(); ep != NoSquare {
+		t.Errorf("EnPassant() = %v, want none", ep)
+	}
+
+	// Capturing the whole horde wins.
+	g = playVariant(t, Horde, "4k3/8/8/8/8/1p6/P1P5/8 b - - 0 1", "b3a2")
+	if g.Outcome() != NoOutcome {
+		t.Fatalf("game over early: %v", g.Outcome())
+	}
+	g = playVariant(t, Horde, "4k3/8/8/8/8/1p6/P7/8 b - - 0 1", "b3a2")
+	if g.Outcome() != BlackWon || g.Method() != VariantEnd {
+		t.Errorf("got %v by %v, want 0-1 by variant end", g.Outcome(), g.Method())
+	}
+}
@@ -0,0 +1,601 @@
+// Package xboard implements a client for the Chess Engine Communication
+// Protocol (CECP), also known as the xboard or WinBoard protocol.
+//
+// Client mirrors uci.Client, so that code can drive engines of either
+// protocol: positions are set with uci.PositionParams, searches are limited
+// with uci.Search, and search information is reported as uci.Info.
+//
+// AdaptCECP and AdaptUCI translate between the protocols, so that code written
+// for one can drive engines that speak the other.
+package xboard
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// featureTimeout is how long Handshake waits for an engine to report its
+// features, after which it is assumed to speak protocol version 1. It is
+// replaced in tests.
+var featureTimeout = 2 * time.Second
+
+var (
+	errHandshakeInProgress = errors.New("xboard: handshake already in progress")
+	errSearchInProgress    = errors.New("xboard: search already in progress")
+)
+
+// Client is a CECP client. It is safe for concurrent use by multiple
+// goroutines.
+//
+// A single goroutine reads everything the engine sends and routes each line to
+// the command waiting for it. Only one handshake and one search may be in
+// progress at a time.
+//
+// The client keeps the engine in force mode, where it only moves when told to
+// with Go.
+type Client struct {
+	r io.Reader
+	w io.Writer
+
+	wmu       sync.Mutex // Serializes writes to w.
+	startOnce sync.Once  // Starts the reader goroutine.
+
+	mu        sync.Mutex
+	err       error              // Set when the engine stops responding.
+	rejected  error              // The last move or command the engine rejected.
+	handshake *handshake         // The pending handshake, if any.
+	features  Features           // The features from the last handshake.
+	pings     map[int]chan error // Pending "ping" commands.
+	nextPing  int
+	search    *search         // The running search, if any.
+	startFEN  string          // The FEN of the starting position on the engine's board.
+	moves     []chess.Move    // The moves played from the starting position.
+	pos       *chess.Position // The position on the engine's board, or nil.
+}
+
+// NewClient returns a CECP client that reads from r and writes to w.
+func NewClient(r io.Reader, w io.Writer) *Client {
+	return &Client{r: r, w: w, features: defaultFeatures()}
+}
+
+// send writes a single command line to the engine.
+func (c *Client) send(format string, a ...any) error {
+	line := fmt.Sprintf(format, a...)
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := io.WriteString(c.w, line+"\n")
+	return err
+}
+
+// handshake is a pending feature negotiation.
+type handshake struct {
+	features Features
+	seen     bool // Whether the engine has reported any features.
+	extended bool // Whether the engine asked for more time with done=0.
+	err      error
+	done     chan struct{} // Closed when the negotiation ends.
+}
+
+// Handshake sends "xboard" and "protover 2", negotiates the features the
+// engine reports, and turns on thinking output with "post". An engine that
+// reports no features within two seconds is assumed to speak protocol
+// version 1, and gets the default features.
+//
+// If ctx is done first, the engine is assumed to be unresponsive and is sent
+// a "quit" command.
+func (c *Client) Handshake(ctx context.Context) (Features, error) {
+	h := &handshake{features: defaultFeatures(), done: make(chan struct{})}
+
+	c.mu.Lock()
+	var err error
+	switch {
+	case c.err != nil:
+		err = c.err
+	case c.handshake != nil:
+		err = errHandshakeInProgress
+	default:
+		c.handshake = h
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return Features{}, err
+	}
+	c.start()
+
+	if err := c.send("xboard\nprotover 2"); err != nil {
+		c.endHandshake(h)
+		return Features{}, err
+	}
+
+	timer := time.NewTimer(featureTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-h.done:
+			if h.err != nil {
+				return Features{}, h.err
+			}
+			return h.features, c.send("post")
+		case <-timer.C:
+			c.mu.Lock()
+			extended := h.extended
+			if !extended {
+				c.finishHandshake()
+			}
+			c.mu.Unlock()
+		case <-ctx.Done():
+			c.endHandshake(h)
+			c.Quit()
+			return Features{}, ctx.Err()
+		}
+	}
+}
+
+// finishHandshake ends the pending handshake successfully. The caller must
+// hold c.mu.
+func (c *Client) finishHandshake() {
+	h := c.handshake
+	if h == nil {
+		return
+	}
+	c.features = h.features
+	close(h.done)
+	c.handshake = nil
+}
+
+func (c *Client) endHandshake(h *handshake) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.handshake == h {
+		c.handshake = nil
+	}
+}
+
+// Features returns the features negotiated by the last handshake, or the
+// defaults if there hasn't been one.
+func (c *Client) Features() Features {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.features
+}
+
+// Ping sends a "ping" command and waits for the matching "pong", which the
+// engine sends once it has processed every earlier command. It returns an
+// error if the engine doesn't support "ping", or if it rejected a move or
+// command since the last call to Ping or Go.
+//
+// If ctx is done first, the engine is assumed to be unresponsive and is sent
+// a "quit" command.
+func (c *Client) Ping(ctx context.Context) error {
+	ch := make(chan error, 1)
+
+	c.mu.Lock()
+	if err := c.err; err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	if !c.features.Ping {
+		c.mu.Unlock()
+		return errors.New("xboard: engine doesn't support ping")
+	}
+	c.nextPing++
+	n := c.nextPing
+	if c.pings == nil {
+		c.pings = make(map[int]chan error)
+	}
+	c.pings[n] = ch
+	c.mu.Unlock()
+	c.start()
+
+	cancel := func() {
+		c.mu.Lock()
+		delete(c.pings, n)
+		c.mu.Unlock()
+	}
+	if err := c.send("ping %d", n); err != nil {
+		cancel()
+		return err
+	}
+
+	select {
+	case err := <-ch:
+		if err != nil {
+			return err
+		}
+		return c.takeRejected()
+	case <-ctx.Done():
+		cancel()
+		c.Quit()
+		return ctx.Err()
+	}
+}
+
+// takeRejected returns and clears the last rejection by the engine.
+func (c *Client) takeRejected() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.rejected
+	c.rejected = nil
+	return err
+}
+
+// SetOption sends an "option" command, which sets an option the engine
+// reported with the "option" feature. To press a button, use the empty
+// string.
+func (c *Client) SetOption(name, value string) error {
+	if value == "" {
+		return c.send("option %s", name)
+	}
+	return c.send("option %s=%s", name, value)
+}
+
+// Position sets up the position described by p on the engine's board. The
+// moves must be legal.
+//
+// If p continues the position that was set last, only the new moves are sent.
+// Otherwise, a new game is started with "new", and a FEN is sent with
+// "setboard", which the engine must support.
+func (c *Client) Position(p uci.PositionParams) error {
+	var (
+		pos *chess.Position
+		err error
+	)
+	if p.StartPos {
+		pos = chess.StartingPosition()
+	} else if pos, err = chess.ParseFEN(p.FEN); err != nil {
+		return fmt.Errorf("xboard: %w", err)
+	}
+	startFEN := pos.String()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.search != nil {
+		return errSearchInProgress
+	}
+
+	from := 0
+	if c.pos != nil && c.startFEN == startFEN && isPrefix(c.moves, p.Moves) {
+		from, pos = len(c.moves), c.pos.Clone()
+	} else {
+		if err := c.send("new\nforce"); err != nil {
+			return err
+		}
+		if startFEN != chess.StartingPosition().String() {
+			if !c.features.SetBoard {
+				return errors.New("xboard: engine doesn't support setboard")
+			}
+			if err := c.send("setboard %s", startFEN); err != nil {
+				return err
+			}
+		}
+		c.startFEN, c.moves, c.pos = startFEN, nil, pos.Clone()
+	}
+
+	for _, m := range p.Moves[from:] {
+		if !pos.IsLegal(m) {
+			return fmt.Errorf("xboard: illegal move %v in position %v", m, pos)
+		}
+		if err := c.sendMove(pos, m); err != nil {
+			return err
+		}
+		pos.Apply(m)
+		c.pos.Apply(m)
+		c.moves = append(c.moves, m)
+	}
+	return nil
+}
+
+// reset forgets the position on the engine's board, so that the next call to
+// Position starts a new game.
+func (c *Client) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pos = nil
+}
+
+func isPrefix(prefix, moves []chess.Move) bool {
+	if len(prefix) > len(moves) {
+		return false
+	}
+	for i, m := range prefix {
+		if moves[i] != m {
+			return false
+		}
+	}
+	return true
+}
+
+// sendMove sends m, a legal move in pos, in the format the engine asked for.
+func (c *Client) sendMove(pos *chess.Position, m chess.Move) error {
+	s := m.String()
+	if c.features.SAN {
+		s = chess.FormatSAN(pos, m)
+	}
+	if c.features.UserMove {
+		return c.send("usermove %s", s)
+	}
+	return c.send("%s", s)
+}
+
+// Reply is the engine's answer to Go.
+type Reply struct {
+	// Move is the engine's move, or the null move if it has none, such as
+	// when it resigned or claimed a result.
+	Move chess.Move
+
+	DrawOffer bool          // The engine offered a draw with its move.
+	Resign    bool          // The engine resigned.
+	Result    chess.Outcome // A result claimed by the engine, if any.
+	Comment   string        // The reason given for Result, such as "White mates".
+}
+
+// Go makes the engine move in the position set with Position, within the
+// limits in s:
+//
+//   - Depth is sent with "sd", and MoveTime with "st", in whole seconds.
+//   - Clock times are sent with "level", "time" and "otim".
+//   - Infinite starts analysis mode, which the engine must support. The
+//     reply's move is then the first move of the last principal variation.
+//
+// Nodes, Mate, SearchMoves and Ponder aren't supported.
+//
+// Search information is sent on the first channel as it arrives, and the
+// engine's reply is sent on the second channel when it moves. Both channels
+// are closed afterwards. If the engine stops responding, both channels are
+// closed without a reply. When ctx is done, the engine is told to move now
+// with "?", or to leave analysis mode with "exit".
+//
+// The engine's move is played on the client's copy of the board, so that
+// Position can continue from it. If the engine plays an illegal move, the
+// reply has the null move, and the next Go or Ping returns an error.
+func (c *Client) Go(ctx context.Context, s uci.Search) (<-chan uci.Info, <-chan Reply, error) {
+	if s.Ponder || s.Nodes != 0 || s.Mate != 0 || len(s.SearchMoves) > 0 {
+		return nil, nil, errors.New("xboard: unsupported search limits")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
+	switch {
+	case c.err != nil:
+		err = c.err
+	case c.search != nil:
+		err = errSearchInProgress
+	case c.pos == nil:
+		err = errors.New("xboard: no position")
+	case c.rejected != nil:
+		err, c.rejected = c.rejected, nil
+	case s.Infinite && !c.features.Analyze:
+		err = errors.New("xboard: engine doesn't support analysis")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sr := newSearch(s.Infinite)
+	c.search = sr
+	c.start()
+	if err := c.send("%s", c.goCommands(s)); err != nil {
+		c.search = nil
+		return nil, nil, err
+	}
+
+	go sr.Forward(ctx)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.stop(sr)
+		case <-sr.Done:
+		}
+	}()
+	return sr.Info, sr.Result, nil
+}
+
+// goCommands returns the commands that start a search with the limits in s.
+// The caller must hold c.mu.
+func (c *Client) goCommands(s uci.Search) string {
+	var cmds []string
+	if s.Infinite {
+		return "analyze"
+	}
+	if s.Depth > 0 {
+		cmds = append(cmds, fmt.Sprintf("sd %d", s.Depth))
+	}
+	if s.MoveTime > 0 {
+		secs := (s.MoveTime + time.Second - 1) / time.Second
+		cmds = append(cmds, fmt.Sprintf("st %d", secs))
+	}
+	if s.WhiteTime > 0 || s.BlackTime > 0 {
+		own, opp, inc := s.WhiteTime, s.BlackTime, s.WhiteIncrement
+		if c.pos.SideToMove() == chess.Black {
+			own, opp, inc = s.BlackTime, s.WhiteTime, s.BlackIncrement
+		}
+		cmds = append(cmds,
+			fmt.Sprintf("level %d %s %s", s.MovesToGo, formatBase(own), strconv.FormatFloat(inc.Seconds(), 'f', -1, 64)),
+			fmt.Sprintf("time %d", own/(10*time.Millisecond)),
+			fmt.Sprintf("otim %d", opp/(10*time.Millisecond)))
+	}
+	return strings.Join(append(cmds, "go"), "\n")
+}
+
+// formatBase formats the base time of a "level" command, in minutes and
+// seconds.
+func formatBase(d time.Duration) string {
+	secs := int(d / time.Second)
+	if secs%60 == 0 {
+		return strconv.Itoa(secs / 60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+// stop ends sr early: an analysis ends at once with the best move so far, and
+// a normal search is told to move now.
+func (c *Client) stop(sr *search) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.search != sr {
+		return
+	}
+	if !sr.analysis {
+		c.send("?")
+		return
+	}
+	c.send("exit")
+	var r Reply
+	if len(sr.lastPV) > 0 {
+		r.Move = sr.lastPV[0]
+	}
+	sr.Finish(&r)
+	c.search = nil
+}
+
+// Quit sends the "quit" command. It tells the engine to exit.
+func (c *Client) Quit() error {
+	return c.send("quit")
+}
+
+// start starts the reader goroutine, if it isn't already running.
+func (c *Client) start() {
+	c.startOnce.Do(func() { go c.read() })
+}
+
+// read reads lines from the engine until it stops responding, routing each line
+// to the command waiting for it.
+func (c *Client) read() {
+	s := bufio.NewScanner(c.r)
+	for s.Scan() {
+		c.dispatch(s.Text())
+	}
+
+	err := s.Err()
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+	if h := c.handshake; h != nil {
+		h.err = err
+		close(h.done)
+		c.handshake = nil
+	}
+	for n, ch := range c.pings {
+		ch <- err
+		delete(c.pings, n)
+	}
+	if c.search != nil {
+		c.search.Finish(nil)
+		c.search = nil
+	}
+}
+
+// dispatch routes a line from the engine.
+func (c *Client) dispatch(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch cmd, rest, _ := strings.Cut(line, " "); {
+	case cmd == "feature":
+		c.negotiate(rest)
+	case cmd == "pong":
+		n, err := strconv.Atoi(rest)
+		if ch, ok := c.pings[n]; ok && err == nil {
+			ch <- nil
+			delete(c.pings, n)
+		}
+	case cmd == "move", strings.HasPrefix(line, "My move is:"):
+		if i := strings.LastIndexAny(line, " :"); i >= 0 {
+			c.engineMove(strings.TrimSpace(line[i+1:]))
+		}
+	case cmd == "resign" || line == "tellics resign":
+		c.reply(Reply{Resign: true})
+	case line == "offer draw":
+		if c.search != nil {
+			c.search.drawOffer = true
+		}
+	case cmd == "1-0" || cmd == "0-1" || cmd == "1/2-1/2":
+		o := map[string]chess.Outcome{"1-0": chess.WhiteWon, "0-1": chess.BlackWon, "1/2-1/2": chess.Draw}[cmd]
+		c.reply(Reply{Result: o, Comment: strings.Trim(rest, "{} ")})
+	case strings.HasPrefix(line, "Illegal move"), strings.HasPrefix(line, "Error"):
+		c.rejected = fmt.Errorf("xboard: engine said %q", line)
+	case line[0] >= '0' && line[0] <= '9':
+		if c.search == nil {
+			return
+		}
+		if info, err := ParseThinking(c.pos, line); err == nil {
+			c.search.queue(info)
+		}
+	}
+}
+
+// negotiate accepts or rejects the features in the arguments of a "feature"
+// command. The caller must hold c.mu.
+func (c *Client) negotiate(args string) {
+	h := c.handshake
+	if h == nil {
+		return
+	}
+	features, err := parseFeatures(args)
+	if err != nil {
+		return
+	}
+	h.seen = true
+	for _, f := range features {
+		verdict := "accepted"
+		if !h.features.set(f.name, f.value) {
+			verdict = "rejected"
+		}
+		c.send("%s %s", verdict, f.name)
+		if f.name == "done" {
+			h.extended = f.value == "0"
+			if f.value == "1" {
+				c.finishHandshake()
+				return
+			}
+		}
+	}
+}
+
+// engineMove handles a move made by the engine. The caller must hold c.mu.
+func (c *Client) engineMove(s string) {
+	if c.search == nil || c.search.analysis {
+		return
+	}
+	m, err := parseMove(c.pos, s)
+	if err != nil {
+		c.rejected = fmt.Errorf("xboard: engine played an invalid move: %v", err)
+		c.reply(Reply{})
+		return
+	}
+	c.pos.Apply(m)
+	c.moves = append(c.moves, m)
+	// Stop the engine from thinking about the other side's move.
+	c.send("force")
+	c.reply(Reply{Move: m, DrawOffer: c.search.drawOffer})
+}
+
+// reply ends the running search with r. The caller must hold c.mu.
+func (c *Client) reply(r Reply) {
+	if c.search == nil {
+		return
+	}
+	c.search.Finish(&r)
+	c.search = nil
+}
@@ -0,0 +1,430 @@
+package xboard
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+func move(s string) chess.Move {
+	m, err := chess.ParseMove(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func moves(s ...string) []chess.Move {
+	var res []chess.Move
+	for _, m := range s {
+		res = append(res, move(m))
+	}
+	return res
+}
+
+// fakeEngine is a scripted engine. It records the commands it receives, and
+// answers each with the output of respond, if any.
+type fakeEngine struct {
+	mu   sync.Mutex
+	cmds []string
+	out  *io.PipeWriter
+}
+
+// newFakeEngine returns a client connected to a fake engine.
+func newFakeEngine(t *testing.T, respond func(cmd string) string) (*Client, *fakeEngine) {
+	t.Helper()
+	r, engineOut := io.Pipe()
+	engineIn, w := io.Pipe()
+	t.Cleanup(func() {
+		engineOut.Close()
+		w.Close()
+	})
+	e := &fakeEngine{out: engineOut}
+	go func() {
+		s := bufio.NewScanner(engineIn)
+		for s.Scan() {
+			e.mu.Lock()
+			e.cmds = append(e.cmds, s.Text())
+			e.mu.Unlock()
+			if out := respond(s.Text()); out != "" {
+				e.write(out)
+			}
+		}
+	}()
+	return NewClient(r, w), e
+}
+
+// write sends output from the engine.
+func (e *fakeEngine) write(s string) {
+	io.WriteString(e.out, s)
+}
+
+// commands returns the commands received so far, and forgets them.
+func (e *fakeEngine) commands() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	cmds := e.cmds
+	e.cmds = nil
+	return cmds
+}
+
+// waitCommands waits until the engine has received n commands, and returns
+// them.
+func (e *fakeEngine) waitCommands(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		e.mu.Lock()
+		got := len(e.cmds)
+		e.mu.Unlock()
+		if got >= n {
+			return e.commands()
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d commands, got %v", n, e.commands())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// connect performs a handshake in which the engine reports features.
+func connect(t *testing.T, features string, respond func(cmd string) string) (*Client, *fakeEngine) {
+	t.Helper()
+	c, e := newFakeEngine(t, func(cmd string) string {
+		if cmd == "protover 2" {
+			return "feature " + features + " done=1\n"
+		}
+		return respond(cmd)
+	})
+	if _, err := c.Handshake(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return c, e
+}
+
+func ignore(string) string { return "" }
+
+func TestClient_Handshake(t *testing.T) {
+	c, e := newFakeEngine(t, func(cmd string) string {
+		if cmd == "protover 2" {
+			return "feature ping=1 setboard=1 frob=1\n" +
+				"feature usermove=1 myname=\"Fake 1.0\" done=1\n"
+		}
+		return ""
+	})
+	got, err := c.Handshake(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := defaultFeatures()
+	want.Ping, want.SetBoard, want.UserMove, want.MyName = true, true, true, "Fake 1.0"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("features mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, c.Features()); diff != "" {
+		t.Errorf("Features mismatch (-want +got):\n%s", diff)
+	}
+
+	wantCmds := []string{
+		"xboard", "protover 2",
+		"accepted ping", "accepted setboard", "rejected frob",
+		"accepted usermove", "accepted myname", "accepted done",
+		"post",
+	}
+	if diff := cmp.Diff(wantCmds, e.waitCommands(t, len(wantCmds))); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Handshake_Version1(t *testing.T) {
+	defer func(d time.Duration) { featureTimeout = d }(featureTimeout)
+	featureTimeout = 10 * time.Millisecond
+
+	c, _ := newFakeEngine(t, ignore)
+	got, err := c.Handshake(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(defaultFeatures(), got); diff != "" {
+		t.Errorf("features mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Handshake_Extended(t *testing.T) {
+	defer func(d time.Duration) { featureTimeout = d }(featureTimeout)
+	featureTimeout = 10 * time.Millisecond
+
+	c, e := newFakeEngine(t, func(cmd string) string {
+		if cmd == "protover 2" {
+			return "feature done=0\n"
+		}
+		return ""
+	})
+	go func() {
+		time.Sleep(5 * featureTimeout)
+		e.write("feature ping=1 done=1\n")
+	}()
+	got, err := c.Handshake(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Ping {
+		t.Error("features reported after done=0 were ignored")
+	}
+}
+
+func TestClient_Handshake_Canceled(t *testing.T) {
+	c, e := newFakeEngine(t, func(cmd string) string {
+		if cmd == "protover 2" {
+			return "feature done=0\n"
+		}
+		return ""
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Handshake(ctx); err != context.DeadlineExceeded {
+		t.Errorf("want %v, got %v", context.DeadlineExceeded, err)
+	}
+	cmds := e.waitCommands(t, 4)
+	if last := cmds[len(cmds)-1]; last != "quit" {
+		t.Errorf("want quit, got %v", cmds)
+	}
+}
+
+func TestClient_Go(t *testing.T) {
+	c, e := connect(t, "usermove=1", func(cmd string) string {
+		if cmd == "go" {
+			return "3 25 10 1234 e7e5 Nf3\n" +
+				"move e7e5\n"
+		}
+		return ""
+	})
+	e.waitCommands(t, 5)
+
+	if err := c.Position(uci.PositionParams{StartPos: true, Moves: moves("e2e4")}); err != nil {
+		t.Fatal(err)
+	}
+	infoCh, replyCh, err := c.Go(context.Background(), uci.Search{Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infos []uci.Info
+	for info := range infoCh {
+		infos = append(infos, info)
+	}
+	reply, ok := <-replyCh
+	if !ok {
+		t.Fatal("no reply")
+	}
+	if want := (Reply{Move: move("e7e5")}); reply != want {
+		t.Errorf("reply: want %+v, got %+v", want, reply)
+	}
+	wantInfos := []uci.Info{{
+		Depth: 3, Score: uci.Score{CP: 25}, Time: 100 * time.Millisecond, Nodes: 1234,
+		PV: moves("e7e5", "g1f3"), String: "e7e5 Nf3",
+	}}
+	if diff := cmp.Diff(wantInfos, infos); diff != "" {
+		t.Errorf("infos mismatch (-want +got):\n%s", diff)
+	}
+
+	// The position continues from the engine's move.
+	if err := c.Position(uci.PositionParams{StartPos: true, Moves: moves("e2e4", "e7e5", "g1f3")}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"new", "force", "usermove e2e4", "sd 3", "go", "force", "usermove g1f3"}
+	if diff := cmp.Diff(want, e.waitCommands(t, len(want))); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Go_Clock(t *testing.T) {
+	c, e := connect(t, "san=1 setboard=1", func(cmd string) string {
+		if cmd == "go" {
+			return "offer draw\nmove Kd7\n"
+		}
+		return ""
+	})
+	e.waitCommands(t, 6)
+
+	fen := "4k3/8/8/8/8/8/8/4K2R w K - 0 1"
+	if err := c.Position(uci.PositionParams{FEN: fen, Moves: moves("e1g1")}); err != nil {
+		t.Fatal(err)
+	}
+	s := uci.Search{
+		WhiteTime: 5 * time.Minute, BlackTime: 90 * time.Second,
+		WhiteIncrement: time.Second, BlackIncrement: 1500 * time.Millisecond,
+		MovesToGo: 40,
+	}
+	infoCh, replyCh, err := c.Go(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range infoCh {
+	}
+	if want := (Reply{Move: move("e8d7"), DrawOffer: true}); <-replyCh != want {
+		t.Errorf("want reply %+v", want)
+	}
+	want := []string{
+		"new", "force", "setboard " + fen, "O-O",
+		"level 40 1:30 1.5", "time 9000", "otim 30000", "go", "force",
+	}
+	if diff := cmp.Diff(want, e.waitCommands(t, len(want))); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Go_Analyze(t *testing.T) {
+	c, e := connect(t, "", func(cmd string) string {
+		if cmd == "analyze" {
+			return "1 10 0 20 d2d4\n2 15 1 80 e2e4 e7e5\n"
+		}
+		return ""
+	})
+	if err := c.Position(uci.PositionParams{StartPos: true}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	infoCh, replyCh, err := c.Go(ctx, uci.Search{Infinite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < 2; n++ {
+		<-infoCh
+	}
+	cancel()
+	for range infoCh {
+	}
+	if reply := <-replyCh; reply.Move != move("e2e4") {
+		t.Errorf("want e2e4, got %v", reply.Move)
+	}
+	want := []string{"xboard", "protover 2", "accepted done", "post", "new", "force", "analyze", "exit"}
+	if diff := cmp.Diff(want, e.waitCommands(t, len(want))); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClient_Go_MoveNow(t *testing.T) {
+	c, _ := connect(t, "", func(cmd string) string {
+		if cmd == "?" {
+			return "move d2d4\n"
+		}
+		return ""
+	})
+	if err := c.Position(uci.PositionParams{StartPos: true}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	infoCh, replyCh, err := c.Go(ctx, uci.Search{MoveTime: 1500 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range infoCh {
+	}
+	if reply := <-replyCh; reply.Move != move("d2d4") {
+		t.Errorf("want d2d4, got %v", reply.Move)
+	}
+}
+
+func TestClient_Go_Results(t *testing.T) {
+	cases := []struct {
+		out  string
+		want Reply
+	}{
+		{"resign\n", Reply{Resign: true}},
+		{"0-1 {White resigns}\n", Reply{Result: chess.BlackWon, Comment: "White resigns"}},
+		{"1/2-1/2 {Draw by repetition}\n", Reply{Result: chess.Draw, Comment: "Draw by repetition"}},
+	}
+	for _, tc := range cases {
+		c, _ := connect(t, "", func(cmd string) string {
+			if cmd == "go" {
+				return tc.out
+			}
+			return ""
+		})
+		if err := c.Position(uci.PositionParams{StartPos: true}); err != nil {
+			t.Fatal(err)
+		}
+		infoCh, replyCh, err := c.Go(context.Background(), uci.Search{Depth: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range infoCh {
+		}
+		if got := <-replyCh; got != tc.want {
+			t.Errorf("%q: want %+v, got %+v", tc.out, tc.want, got)
+		}
+	}
+}
+
+func TestClient_Ping(t *testing.T) {
+	c, _ := connect(t, "ping=1", func(cmd string) string {
+		switch {
+		case strings.HasPrefix(cmd, "ping "):
+			return "pong " + strings.TrimPrefix(cmd, "ping ") + "\n"
+		case cmd == "e2e4":
+			return "Illegal move: e2e4\n"
+		}
+		return ""
+	})
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Position(uci.PositionParams{StartPos: true, Moves: moves("e2e4")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(context.Background()); err == nil {
+		t.Error("want error for the rejected move")
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("the rejection was reported twice: %v", err)
+	}
+}
+
+func TestClient_Disconnect(t *testing.T) {
+	c, e := connect(t, "", ignore)
+	if err := c.Position(uci.PositionParams{StartPos: true}); err != nil {
+		t.Fatal(err)
+	}
+	infoCh, replyCh, err := c.Go(context.Background(), uci.Search{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.out.Close()
+	for range infoCh {
+	}
+	if _, ok := <-replyCh; ok {
+		t.Error("got a reply from a disconnected engine")
+	}
+	if _, _, err := c.Go(context.Background(), uci.Search{Depth: 1}); err != io.ErrUnexpectedEOF {
+		t.Errorf("want %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	c, _ := connect(t, "", ignore)
+	if _, _, err := c.Go(context.Background(), uci.Search{Depth: 1}); err == nil {
+		t.Error("Go without a position: want error")
+	}
+	if err := c.Position(uci.PositionParams{FEN: "4k3/8/8/8/8/8/8/4K3 w - - 0 1"}); err == nil {
+		t.Error("Position with a FEN but no setboard: want error")
+	}
+	if err := c.Position(uci.PositionParams{StartPos: true, Moves: moves("e2e5")}); err == nil {
+		t.Error("Position with an illegal move: want error")
+	}
+	if err := c.Ping(context.Background()); err == nil {
+		t.Error("Ping without the ping feature: want error")
+	}
+	if _, _, err := c.Go(context.Background(), uci.Search{Nodes: 1000}); err == nil {
+		t.Error("Go with a node limit: want error")
+	}
+}
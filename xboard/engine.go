@@ -0,0 +1,129 @@
+package xboard
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/clfs/chess/uci"
+	"github.com/clfs/chess/uciengine"
+)
+
+// Engine presents a CECP engine as a UCI engine, translating positions,
+// searches and thinking output. It implements uciengine.Engine, so it can be
+// served over UCI with uciengine.Serve.
+//
+// Limits that CECP can't express, such as node counts and search moves, are
+// ignored. A ponder search is run as a normal search on the clock times it
+// was given.
+type Engine struct {
+	c *Client
+}
+
+// NewEngine returns an engine backed by c, which must have completed its
+// handshake.
+func NewEngine(c *Client) *Engine {
+	return &Engine{c: c}
+}
+
+// ID returns the name the engine reported with the "myname" feature. CECP
+// engines don't report an author.
+func (e *Engine) ID() (name, author string) {
+	return e.c.Features().MyName, ""
+}
+
+// Options returns the options the engine reported with the "option" feature.
+// Options that can't be parsed are left out.
+func (e *Engine) Options() []uci.Option {
+	var opts []uci.Option
+	for _, s := range e.c.Features().Options {
+		if o, err := ParseOption(s); err == nil {
+			opts = append(opts, o)
+		}
+	}
+	return opts
+}
+
+// SetOption sets an option with an "option" command.
+func (e *Engine) SetOption(name, value string) error {
+	for _, o := range e.Options() {
+		if _, ok := o.(uci.CheckOption); ok && strings.EqualFold(o.OptionName(), name) {
+			if value == "true" {
+				value = "1"
+			} else {
+				value = "0"
+			}
+		}
+	}
+	return e.c.SetOption(name, value)
+}
+
+// NewGame makes the next call to Position start a new game with "new".
+func (e *Engine) NewGame() {
+	e.c.reset()
+}
+
+// Position sets up the position on the engine's board.
+func (e *Engine) Position(p uci.PositionParams) error {
+	return e.c.Position(p)
+}
+
+// Search makes the engine move, or analyze if s is infinite.
+func (e *Engine) Search(s uci.Search, stop <-chan struct{}, info chan<- uci.Info) uci.BestMove {
+	s.Ponder, s.Nodes, s.Mate, s.SearchMoves = false, 0, 0, nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	infoCh, replyCh, err := e.c.Go(ctx, s)
+	if err != nil {
+		info <- uci.Info{String: err.Error()}
+		if s.Infinite {
+			<-stop
+		}
+		return uci.BestMove{}
+	}
+	var last uci.Info
+	for i := range infoCh {
+		if len(i.PV) > 0 {
+			// The variation as the engine wrote it is redundant.
+			i.String = ""
+			last = i
+		}
+		info <- i
+	}
+
+	r := <-replyCh
+	bm := uci.BestMove{Move: r.Move}
+	if len(last.PV) > 1 && last.PV[0] == r.Move {
+		bm.Ponder = last.PV[1]
+	}
+	return bm
+}
+
+// AdaptCECP returns a UCI client that drives the CECP engine c, which must
+// have completed its handshake. It lets code written for UCI engines, such as
+// the match package, play with CECP engines.
+//
+// The UCI client needs a handshake of its own. Quitting it doesn't quit c.
+func AdaptCECP(c *Client) *uci.Client {
+	cr, ew := io.Pipe()
+	er, cw := io.Pipe()
+	go func() {
+		err := uciengine.Serve(er, ew, NewEngine(c))
+		if err != nil {
+			err = fmt.Errorf("xboard: %w", err)
+		}
+		ew.CloseWithError(err)
+		er.CloseWithError(err)
+	}()
+	return uci.NewClient(cr, cw)
+}
@@ -0,0 +1,97 @@
+package xboard
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAdaptCECP(t *testing.T) {
+	c, e := connect(t, `myname="Fake 1.0" option="Hash -spin 16 1 64" option="Ponder -check 0"`, func(cmd string) string {
+		switch cmd {
+		case "go":
+			return "2 30 5 200 e7e5 Nf3\nmove e7e5\n"
+		case "analyze":
+			return "1 10 0 20 d2d4\n"
+		}
+		return ""
+	})
+	e.waitCommands(t, 7)
+
+	u := AdaptCECP(c)
+	name, author, opts, err := u.UCI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Fake 1.0" || author != "" {
+		t.Errorf("want name %q and no author, got %q and %q", "Fake 1.0", name, author)
+	}
+	wantOpts := []uci.Option{
+		uci.SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 64},
+		uci.CheckOption{Name: "Ponder"},
+	}
+	if diff := cmp.Diff(wantOpts, opts); diff != "" {
+		t.Errorf("options mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := u.SetOption("Ponder", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.PositionStartPos(moves("e2e4")); err != nil {
+		t.Fatal(err)
+	}
+	infoCh, bestCh, err := u.Go(uci.Search{Depth: 2, Nodes: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infos []uci.Info
+	for info := range infoCh {
+		infos = append(infos, info)
+	}
+	want := []uci.Info{{Depth: 2, Score: uci.Score{CP: 30}, Time: 50 * time.Millisecond, Nodes: 200, PV: moves("e7e5", "g1f3")}}
+	if diff := cmp.Diff(want, infos); diff != "" {
+		t.Errorf("infos mismatch (-want +got):\n%s", diff)
+	}
+	if bm := <-bestCh; bm != (uci.BestMove{Move: move("e7e5"), Ponder: move("g1f3")}) {
+		t.Errorf("want best move e7e5 pondering g1f3, got %v", bm)
+	}
+
+	// A new game starts over on the engine's board.
+	if err := u.UCINewGame(); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.PositionStartPos(nil); err != nil {
+		t.Fatal(err)
+	}
+	infoCh, bestCh, err = u.Go(uci.Search{Infinite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-infoCh
+	if err := u.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	for range infoCh {
+	}
+	if bm := <-bestCh; bm.Move != move("d2d4") {
+		t.Errorf("want best move d2d4, got %v", bm)
+	}
+
+	wantCmds := []string{
+		"option Ponder=1",
+		"new", "force", "e2e4", "sd 2", "go", "force",
+		"new", "force", "analyze", "exit",
+	}
+	var got []string
+	for _, cmd := range e.waitCommands(t, len(wantCmds)) {
+		if !strings.HasPrefix(cmd, "ping") {
+			got = append(got, cmd)
+		}
+	}
+	if diff := cmp.Diff(wantCmds, got); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
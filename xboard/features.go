@@ -0,0 +1,132 @@
+package xboard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Features are the features an engine reported during the handshake. Fields
+// have the protocol's defaults for features the engine didn't report.
+type Features struct {
+	MyName   string   // The engine's name.
+	Ping     bool     // The engine answers "ping" with "pong".
+	SetBoard bool     // The engine accepts "setboard".
+	UserMove bool     // Moves are sent as "usermove MOVE".
+	SAN      bool     // Moves are sent in SAN instead of coordinate notation.
+	Time     bool     // The engine accepts "time" and "otim".
+	Draw     bool     // The engine accepts draw offers.
+	Analyze  bool     // The engine supports analysis mode.
+	Colors   bool     // The engine expects "white" and "black" commands.
+	Reuse    bool     // The engine can play several games in a row.
+	Variants []string // The variants the engine plays.
+	Options  []string // Engine-defined options, in the protocol's format.
+
+	// Other holds the values of other features, such as "sigint" and "egt".
+	Other map[string]string
+}
+
+// defaultFeatures returns the features of an engine that reports none.
+func defaultFeatures() Features {
+	return Features{
+		Time:     true,
+		Draw:     true,
+		Analyze:  true,
+		Colors:   true,
+		Reuse:    true,
+		Variants: []string{"normal"},
+	}
+}
+
+// knownFeatures lists the features this package accepts.
+var knownFeatures = map[string]bool{
+	"ping": true, "setboard": true, "playother": true, "san": true,
+	"usermove": true, "time": true, "draw": true, "sigint": true,
+	"sigterm": true, "reuse": true, "analyze": true, "myname": true,
+	"variants": true, "colors": true, "ics": true, "name": true,
+	"pause": true, "nps": true, "debug": true, "memory": true, "smp": true,
+	"egt": true, "option": true, "done": true, "exclude": true,
+	"setscore": true, "highlight": true,
+}
+
+// set records a feature, and reports whether it is accepted.
+func (f *Features) set(name, value string) bool {
+	on := value == "1"
+	switch name {
+	case "myname":
+		f.MyName = value
+	case "ping":
+		f.Ping = on
+	case "setboard":
+		f.SetBoard = on
+	case "usermove":
+		f.UserMove = on
+	case "san":
+		f.SAN = on
+	case "time":
+		f.Time = on
+	case "draw":
+		f.Draw = on
+	case "analyze":
+		f.Analyze = on
+	case "colors":
+		f.Colors = on
+	case "reuse":
+		f.Reuse = on
+	case "variants":
+		f.Variants = strings.Split(value, ",")
+	case "option":
+		f.Options = append(f.Options, value)
+	case "done":
+		// Handled by the handshake.
+	default:
+		if !knownFeatures[name] {
+			return false
+		}
+		if f.Other == nil {
+			f.Other = make(map[string]string)
+		}
+		f.Other[name] = value
+	}
+	return true
+}
+
+// feature is a name and value pair from a "feature" command.
+type feature struct {
+	name, value string
+}
+
+// parseFeatures parses the arguments of a "feature" command, such as
+// `ping=1 myname="Engine 1.0"`. String values are unquoted.
+func parseFeatures(s string) ([]feature, error) {
+	var res []feature
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return res, nil
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("xboard: invalid feature %q", s)
+		}
+		f := feature{name: s[:eq]}
+		s = s[eq+1:]
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("xboard: unterminated feature %s", f.name)
+			}
+			f.value, s = s[1:end+1], s[end+2:]
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			f.value, s = s[:end], s[end:]
+			if _, err := strconv.Atoi(f.value); err != nil {
+				return nil, fmt.Errorf("xboard: invalid value for feature %s: %q", f.name, f.value)
+			}
+		}
+		res = append(res, f)
+	}
+}
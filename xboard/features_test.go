@@ -0,0 +1,63 @@
+package xboard
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFeatures(t *testing.T) {
+	got, err := parseFeatures(`ping=1  myname="Crafty 25.2" variants="normal,suicide" option="Hash -spin 64 1 1024" done=0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []feature{
+		{"ping", "1"},
+		{"myname", "Crafty 25.2"},
+		{"variants", "normal,suicide"},
+		{"option", "Hash -spin 64 1 1024"},
+		{"done", "0"},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(feature{})); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, s := range []string{`ping`, `=1`, `myname="Crafty`, `ping=yes`} {
+		if _, err := parseFeatures(s); err == nil {
+			t.Errorf("parseFeatures(%q): want error", s)
+		}
+	}
+}
+
+func TestFeatures_Set(t *testing.T) {
+	f := defaultFeatures()
+	for _, tc := range []struct {
+		name, value string
+		ok          bool
+	}{
+		{"myname", "Engine", true},
+		{"setboard", "1", true},
+		{"usermove", "1", true},
+		{"time", "0", true},
+		{"variants", "normal,atomic", true},
+		{"option", "Hash -spin 64 1 1024", true},
+		{"sigint", "0", true},
+		{"frobnicate", "1", false},
+	} {
+		if ok := f.set(tc.name, tc.value); ok != tc.ok {
+			t.Errorf("set(%q, %q) = %v, want %v", tc.name, tc.value, ok, tc.ok)
+		}
+	}
+
+	want := defaultFeatures()
+	want.MyName = "Engine"
+	want.SetBoard = true
+	want.UserMove = true
+	want.Time = false
+	want.Variants = []string{"normal", "atomic"}
+	want.Options = []string{"Hash -spin 64 1 1024"}
+	want.Other = map[string]string{"sigint": "0"}
+	if diff := cmp.Diff(want, f); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
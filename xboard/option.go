@@ -0,0 +1,94 @@
+package xboard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/clfs/chess/uci"
+)
+
+// ParseOption parses the value of an "option" feature, such as
+// "Hash -spin 64 1 4096", as the equivalent UCI option. Save and reset
+// buttons become button options, and file and path options become string
+// options.
+func ParseOption(s string) (uci.Option, error) {
+	i := strings.Index(s, " -")
+	if i <= 0 {
+		return nil, fmt.Errorf("xboard: invalid option %q", s)
+	}
+	name := strings.TrimSpace(s[:i])
+	typ, args, _ := strings.Cut(s[i+2:], " ")
+	args = strings.TrimSpace(args)
+
+	switch typ {
+	case "check":
+		switch args {
+		case "0", "1":
+			return uci.CheckOption{Name: name, Default: args == "1"}, nil
+		}
+	case "spin", "slider":
+		var n [3]int
+		f := strings.Fields(args)
+		if len(f) != len(n) {
+			break
+		}
+		for i := range n {
+			v, err := strconv.Atoi(f[i])
+			if err != nil {
+				return nil, fmt.Errorf("xboard: invalid option %q", s)
+			}
+			n[i] = v
+		}
+		return uci.SpinOption{Name: name, Default: n[0], Min: n[1], Max: n[2]}, nil
+	case "combo":
+		o := uci.ComboOption{Name: name}
+		for _, v := range strings.Split(args, "///") {
+			v = strings.TrimSpace(v)
+			if strings.HasPrefix(v, "*") {
+				v = v[1:]
+				o.Default = v
+			}
+			o.Vars = append(o.Vars, v)
+		}
+		if o.Default == "" && len(o.Vars) > 0 {
+			o.Default = o.Vars[0]
+		}
+		return o, nil
+	case "button", "save", "reset":
+		return uci.ButtonOption{Name: name}, nil
+	case "string", "file", "path":
+		return uci.StringOption{Name: name, Default: args}, nil
+	default:
+		return nil, fmt.Errorf("xboard: option %q has unknown type %q", name, typ)
+	}
+	return nil, fmt.Errorf("xboard: invalid option %q", s)
+}
+
+// FormatOption formats o as the value of an "option" feature.
+func FormatOption(o uci.Option) string {
+	switch o := o.(type) {
+	case uci.CheckOption:
+		v := 0
+		if o.Default {
+			v = 1
+		}
+		return fmt.Sprintf("%s -check %d", o.Name, v)
+	case uci.SpinOption:
+		return fmt.Sprintf("%s -spin %d %d %d", o.Name, o.Default, o.Min, o.Max)
+	case uci.ComboOption:
+		vars := make([]string, len(o.Vars))
+		for i, v := range o.Vars {
+			if v == o.Default {
+				v = "*" + v
+			}
+			vars[i] = v
+		}
+		return fmt.Sprintf("%s -combo %s", o.Name, strings.Join(vars, " /// "))
+	case uci.ButtonOption:
+		return o.Name + " -button"
+	case uci.StringOption:
+		return fmt.Sprintf("%s -string %s", o.Name, o.Default)
+	}
+	return o.OptionName() + " -string"
+}
@@ -0,0 +1,72 @@
+package xboard
+
+import (
+	"testing"
+
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseOption(t *testing.T) {
+	cases := []struct {
+		in   string
+		want uci.Option
+	}{
+		{"Ponder -check 1", uci.CheckOption{Name: "Ponder", Default: true}},
+		{"Hash -spin 64 1 4096", uci.SpinOption{Name: "Hash", Default: 64, Min: 1, Max: 4096}},
+		{"Contempt -slider 0 -100 100", uci.SpinOption{Name: "Contempt", Min: -100, Max: 100}},
+		{"Style -combo Solid /// *Normal /// Risky", uci.ComboOption{Name: "Style", Default: "Normal", Vars: []string{"Solid", "Normal", "Risky"}}},
+		{"Style -combo Solid /// Risky", uci.ComboOption{Name: "Style", Default: "Solid", Vars: []string{"Solid", "Risky"}}},
+		{"Clear Hash -button", uci.ButtonOption{Name: "Clear Hash"}},
+		{"Save -save", uci.ButtonOption{Name: "Save"}},
+		{"Book File -file book.bin", uci.StringOption{Name: "Book File", Default: "book.bin"}},
+		{"Log -string", uci.StringOption{Name: "Log"}},
+	}
+	for _, tc := range cases {
+		got, err := ParseOption(tc.in)
+		if err != nil {
+			t.Errorf("ParseOption(%q): %v", tc.in, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("ParseOption(%q) mismatch (-want +got):\n%s", tc.in, diff)
+		}
+	}
+}
+
+func TestParseOption_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"-check 1",
+		"Ponder -check yes",
+		"Hash -spin 64 1",
+		"Hash -spin 64 1 x",
+		"Hash -dial 3",
+	} {
+		if o, err := ParseOption(in); err == nil {
+			t.Errorf("ParseOption(%q) = %v, want error", in, o)
+		}
+	}
+}
+
+func TestFormatOption(t *testing.T) {
+	cases := []struct {
+		in   uci.Option
+		want string
+	}{
+		{uci.CheckOption{Name: "Ponder"}, "Ponder -check 0"},
+		{uci.SpinOption{Name: "Hash", Default: 16, Min: 1, Max: 1024}, "Hash -spin 16 1 1024"},
+		{uci.ComboOption{Name: "Style", Default: "Normal", Vars: []string{"Solid", "Normal"}}, "Style -combo Solid /// *Normal"},
+		{uci.ButtonOption{Name: "Clear Hash"}, "Clear Hash -button"},
+		{uci.StringOption{Name: "SyzygyPath", Default: "<empty>"}, "SyzygyPath -string <empty>"},
+	}
+	for _, tc := range cases {
+		got := FormatOption(tc.in)
+		if got != tc.want {
+			t.Errorf("FormatOption(%v): want %q, got %q", tc.in, tc.want, got)
+		}
+		if o, err := ParseOption(got); err != nil || !cmp.Equal(o, tc.in) {
+			t.Errorf("ParseOption(%q) = %v, %v; want %v", got, o, err, tc.in)
+		}
+	}
+}
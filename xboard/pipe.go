@@ -0,0 +1,70 @@
+package xboard
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// bufferedPipe is an in-memory pipe whose writes never block, like a pipe to a
+// process with an unlimited buffer. The client answers some commands from its
+// reader goroutine, which would deadlock with an engine that writes several
+// lines before reading again if writes blocked.
+type bufferedPipe struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    bytes.Buffer
+	err    error // Set when the pipe is closed.
+	closed bool
+}
+
+func newBufferedPipe() *bufferedPipe {
+	p := &bufferedPipe{}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// Read reads data written to the pipe, blocking until there is some or the
+// pipe is closed.
+func (p *bufferedPipe) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.buf.Len() == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if p.buf.Len() == 0 {
+		return 0, p.err
+	}
+	return p.buf.Read(b)
+}
+
+// Write writes data to the pipe without blocking.
+func (p *bufferedPipe) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, io.ErrClosedPipe
+	}
+	p.cond.Broadcast()
+	return p.buf.Write(b)
+}
+
+// CloseWithError closes the pipe. Reads return err after the buffered data,
+// or io.EOF if err is nil.
+func (p *bufferedPipe) CloseWithError(err error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		err = io.EOF
+	}
+	if !p.closed {
+		p.closed, p.err = true, err
+		p.cond.Broadcast()
+	}
+	return nil
+}
+
+// Close closes the pipe.
+func (p *bufferedPipe) Close() error {
+	return p.CloseWithError(nil)
+}
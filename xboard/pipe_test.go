@@ -0,0 +1,47 @@
+package xboard
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestBufferedPipe(t *testing.T) {
+	p := newBufferedPipe()
+	for _, s := range []string{"new\n", "force\n"} {
+		if _, err := io.WriteString(p, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Close()
+	if _, err := io.WriteString(p, "go\n"); err != io.ErrClosedPipe {
+		t.Errorf("write after close: want %v, got %v", io.ErrClosedPipe, err)
+	}
+	b, err := io.ReadAll(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != "new\nforce\n" {
+		t.Errorf("want %q, got %q", "new\nforce\n", got)
+	}
+}
+
+func TestBufferedPipe_Blocking(t *testing.T) {
+	p := newBufferedPipe()
+	done := make(chan string)
+	go func() {
+		b := make([]byte, 10)
+		n, _ := p.Read(b)
+		done <- string(b[:n])
+	}()
+	io.WriteString(p, "ping 1\n")
+	if got := <-done; got != "ping 1\n" {
+		t.Errorf("want %q, got %q", "ping 1\n", got)
+	}
+
+	errGone := errors.New("gone")
+	go p.CloseWithError(errGone)
+	if _, err := p.Read(make([]byte, 1)); err != errGone {
+		t.Errorf("want %v, got %v", errGone, err)
+	}
+}
@@ -0,0 +1,31 @@
+package xboard
+
+import (
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/internal/relay"
+	"github.com/clfs/chess/uci"
+)
+
+// search is a running "go" or "analyze" command. Information from the engine
+// is queued so that a slow consumer never blocks the reader.
+type search struct {
+	*relay.Relay[uci.Info, Reply]
+
+	// Guarded by Client.mu.
+	analysis  bool         // Whether the engine is in analysis mode.
+	drawOffer bool         // Whether the engine offered a draw.
+	lastPV    []chess.Move // The last principal variation reported.
+}
+
+func newSearch(analysis bool) *search {
+	return &search{Relay: relay.New[uci.Info, Reply](), analysis: analysis}
+}
+
+// queue adds info to the queue of search information. The caller must hold
+// Client.mu.
+func (s *search) queue(info uci.Info) {
+	if len(info.PV) > 0 {
+		s.lastPV = info.PV
+	}
+	s.Queue(info)
+}
@@ -0,0 +1,404 @@
+package xboard
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// server is the state of a single Serve call.
+type server struct {
+	c *uci.Client
+
+	wmu sync.Mutex // Serializes writes to w.
+	w   io.Writer
+
+	mu        sync.Mutex
+	start     *chess.Position // The starting position of the game.
+	pos       *chess.Position // The current position.
+	moves     []chess.Move    // The moves played from start.
+	force     bool            // Whether the engine only moves when told to.
+	engine    chess.Color     // The side the engine plays.
+	analyzing bool
+	post      bool // Whether to send thinking output.
+
+	mps       int           // Moves per time control, or 0 for the whole game.
+	base, inc time.Duration // The time control from "level".
+	moveTime  time.Duration // The time per move from "st".
+	depth     int           // The depth limit from "sd".
+	own, opp  time.Duration // The clocks from "time" and "otim".
+
+	search *serverSearch // The running search, if any.
+}
+
+// serverSearch is a search started by the server.
+type serverSearch struct {
+	cancel  context.CancelFunc
+	done    chan struct{} // Closed when the search has ended.
+	discard bool          // Whether to discard the best move. Guarded by server.mu.
+}
+
+// Serve presents the UCI engine c as a CECP engine, reading commands from r
+// and writing responses to w. It is the reverse of AdaptCECP: positions, time
+// controls and moves are translated to UCI, and search information is sent
+// as thinking output. c must have completed its handshake.
+//
+// Serve returns nil when it receives "quit" or r is exhausted, or the first
+// error writing to w or reading from r. It doesn't quit c.
+func Serve(r io.Reader, w io.Writer, c *uci.Client) error {
+	s := &server{c: c, w: w}
+	s.newGame()
+	defer s.stop(true)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		quit, err := s.handle(sc.Text())
+		if err != nil {
+			return err
+		}
+		if quit {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+func (s *server) println(format string, a ...any) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	_, err := fmt.Fprintf(s.w, format+"\n", a...)
+	return err
+}
+
+// handle handles a single command.
+func (s *server) handle(line string) (quit bool, err error) {
+	cmd, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	args = strings.TrimSpace(args)
+
+	switch cmd {
+	case "":
+	case "quit":
+		return true, nil
+	case "protover":
+		return false, s.features()
+	case "ping":
+		return false, s.println("pong %s", args)
+	case "?":
+		s.mu.Lock()
+		analyzing := s.analyzing
+		s.mu.Unlock()
+		if !analyzing {
+			s.stop(false)
+		}
+	case "post", "nopost":
+		s.mu.Lock()
+		s.post = cmd == "post"
+		s.mu.Unlock()
+	case "level", "st", "sd", "time", "otim":
+		if err := s.timeControl(cmd, args); err != nil {
+			return false, s.println("Error (%v): %s", err, line)
+		}
+	case "option":
+		return false, s.setOption(args)
+	case "xboard", "accepted", "rejected", "random", "computer", "name",
+		"rating", "ics", "hard", "easy", "draw", "white", "black", ".", "bk",
+		"hint":
+		// Ignored.
+	default:
+		s.stop(true)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return false, s.handleGame(cmd, args, line)
+	}
+	return false, nil
+}
+
+// handleGame handles a command that changes the game or the engine's mode.
+// The caller must hold s.mu, and must have stopped any running search.
+func (s *server) handleGame(cmd, args, line string) error {
+	switch cmd {
+	case "new":
+		s.c.UCINewGame()
+		s.newGame()
+	case "force", "result":
+		s.force = true
+	case "go":
+		s.force = false
+		s.engine = s.pos.SideToMove()
+	case "playother":
+		s.force = false
+		s.engine = s.pos.SideToMove().Other()
+		return nil
+	case "setboard":
+		pos, err := chess.ParseFEN(args)
+		if err != nil {
+			return s.println("tellusererror Illegal position")
+		}
+		s.start, s.pos, s.moves = pos, pos.Clone(), nil
+	case "analyze":
+		s.analyzing = true
+	case "exit":
+		s.analyzing = false
+		return nil
+	case "undo", "remove":
+		n := 1
+		if cmd == "remove" {
+			n = 2
+		}
+		for ; n > 0 && len(s.moves) > 0; n-- {
+			s.pos.Unapply()
+			s.moves = s.moves[:len(s.moves)-1]
+		}
+	case "usermove":
+		return s.userMove(args)
+	default:
+		// Protocol version 1 sends moves without "usermove".
+		if _, err := parseMove(s.pos, cmd); err == nil && args == "" {
+			return s.userMove(cmd)
+		}
+		return s.println("Error (unknown command): %s", line)
+	}
+	return s.think()
+}
+
+// userMove plays a move for the opponent. The caller must hold s.mu.
+func (s *server) userMove(arg string) error {
+	m, err := parseMove(s.pos, arg)
+	if err != nil {
+		return s.println("Illegal move: %s", arg)
+	}
+	s.pos.Apply(m)
+	s.moves = append(s.moves, m)
+	return s.think()
+}
+
+// newGame sets up a new game, with the engine playing Black. The caller must
+// hold s.mu, if Serve has started.
+func (s *server) newGame() {
+	s.start = chess.StartingPosition()
+	s.pos = s.start.Clone()
+	s.moves = nil
+	s.force = false
+	s.engine = chess.Black
+	s.depth = 0
+	s.own, s.opp = 0, 0
+	if s.base == 0 {
+		// The default time control of XBoard.
+		s.mps, s.base = 40, 5*time.Minute
+	}
+}
+
+// features sends the features of the engine.
+func (s *server) features() error {
+	name, _ := s.c.ID()
+	if err := s.println("feature myname=%q ping=1 setboard=1 usermove=1 playother=1 san=0 time=1 draw=0 sigint=0 sigterm=0 reuse=1 analyze=1 colors=0", name); err != nil {
+		return err
+	}
+	for _, o := range s.c.Options() {
+		if err := s.println("feature option=%q", FormatOption(o)); err != nil {
+			return err
+		}
+	}
+	return s.println("feature done=1")
+}
+
+// setOption handles an "option" command.
+func (s *server) setOption(args string) error {
+	name, value, _ := strings.Cut(args, "=")
+	for _, o := range s.c.Options() {
+		if _, ok := o.(uci.CheckOption); ok && o.OptionName() == name {
+			value = strconv.FormatBool(value == "1")
+		}
+	}
+	if err := s.c.SetOption(name, value); err != nil {
+		return s.println("Error (%v): option", err)
+	}
+	return nil
+}
+
+// timeControl handles a command that sets the time control.
+func (s *server) timeControl(cmd, args string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cmd == "level" {
+		f := strings.Fields(args)
+		if len(f) != 3 {
+			return fmt.Errorf("invalid arguments")
+		}
+		mps, err := strconv.Atoi(f[0])
+		if err != nil {
+			return err
+		}
+		min, sec, _ := strings.Cut(f[1], ":")
+		m, err := strconv.Atoi(min)
+		if err != nil {
+			return err
+		}
+		base := time.Duration(m) * time.Minute
+		if sec != "" {
+			n, err := strconv.Atoi(sec)
+			if err != nil {
+				return err
+			}
+			base += time.Duration(n) * time.Second
+		}
+		inc, err := strconv.ParseFloat(f[2], 64)
+		if err != nil {
+			return err
+		}
+		s.mps, s.base, s.inc, s.moveTime = mps, base, time.Duration(inc*float64(time.Second)), 0
+		s.own, s.opp = 0, 0
+		return nil
+	}
+
+	n, err := strconv.Atoi(args)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "st":
+		s.moveTime = time.Duration(n) * time.Second
+	case "sd":
+		s.depth = n
+	case "time":
+		s.own = time.Duration(n) * 10 * time.Millisecond
+	case "otim":
+		s.opp = time.Duration(n) * 10 * time.Millisecond
+	}
+	return nil
+}
+
+// think starts a search if the engine is analyzing, or if it is the engine's
+// turn to move. The caller must hold s.mu.
+func (s *server) think() error {
+	if !s.analyzing && (s.force || s.pos.SideToMove() != s.engine) {
+		return nil
+	}
+	if len(s.pos.LegalMoves()) == 0 {
+		return nil
+	}
+
+	p := uci.PositionParams{FEN: s.start.String(), Moves: s.moves}
+	if err := s.c.Position(p); err != nil {
+		return s.println("Error (%v): position", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	infoCh, bestCh, err := s.c.GoContext(ctx, s.limits())
+	if err != nil {
+		cancel()
+		return s.println("Error (%v): go", err)
+	}
+	sr := &serverSearch{cancel: cancel, done: make(chan struct{}), discard: s.analyzing}
+	s.search = sr
+	go s.run(sr, s.pos.Clone(), infoCh, bestCh)
+	return nil
+}
+
+// limits returns the limits of the next search. The caller must hold s.mu.
+func (s *server) limits() uci.Search {
+	if s.analyzing {
+		return uci.Search{Infinite: true}
+	}
+	l := uci.Search{Depth: s.depth, MoveTime: s.moveTime}
+	if s.moveTime > 0 {
+		return l
+	}
+	own, opp := s.own, s.opp
+	if own <= 0 {
+		own = s.base
+	}
+	if opp <= 0 {
+		opp = s.base
+	}
+	if s.engine == chess.White {
+		l.WhiteTime, l.BlackTime = own, opp
+	} else {
+		l.WhiteTime, l.BlackTime = opp, own
+	}
+	l.WhiteIncrement, l.BlackIncrement = s.inc, s.inc
+	if s.mps > 0 {
+		l.MovesToGo = s.mps - (s.pos.FullmoveNumber()-1)%s.mps
+	}
+	return l
+}
+
+// run reports the progress of sr, a search of pos, and plays its best move.
+func (s *server) run(sr *serverSearch, pos *chess.Position, infoCh <-chan uci.Info, bestCh <-chan uci.BestMove) {
+	defer close(sr.done)
+	defer sr.cancel()
+
+	for info := range infoCh {
+		s.mu.Lock()
+		post := s.post
+		s.mu.Unlock()
+		if post && len(info.PV) > 0 && info.MultiPV <= 1 {
+			s.println("%s", formatThinking(pos, info))
+		}
+	}
+	bm, ok := <-bestCh
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.search == sr {
+		s.search = nil
+	}
+	if !ok || sr.discard || !s.pos.IsLegal(bm.Move) {
+		return
+	}
+	s.pos.Apply(bm.Move)
+	s.moves = append(s.moves, bm.Move)
+	s.println("move %s", bm.Move)
+	if len(s.pos.LegalMoves()) == 0 {
+		switch {
+		case !s.pos.InCheck():
+			s.println("1/2-1/2 {Stalemate}")
+		case s.pos.SideToMove() == chess.Black:
+			s.println("1-0 {White mates}")
+		default:
+			s.println("0-1 {Black mates}")
+		}
+	}
+}
+
+// stop stops the running search, if any, and waits for it to end. If discard
+// is false, the engine plays the best move it has found.
+func (s *server) stop(discard bool) {
+	s.mu.Lock()
+	sr := s.search
+	if sr != nil && discard {
+		sr.discard = true
+	}
+	s.mu.Unlock()
+	if sr == nil {
+		return
+	}
+	sr.cancel()
+	<-sr.done
+}
+
+// AdaptUCI returns a CECP client that drives the UCI engine c, which must have
+// completed its handshake. It is the reverse of AdaptCECP.
+//
+// The CECP client needs a handshake of its own. Quitting it doesn't quit c.
+func AdaptUCI(c *uci.Client) *Client {
+	cr, ew := io.Pipe()
+	er := newBufferedPipe()
+	go func() {
+		err := Serve(er, ew, c)
+		if err != nil {
+			err = fmt.Errorf("xboard: %w", err)
+		}
+		ew.CloseWithError(err)
+		er.CloseWithError(err)
+	}()
+	return NewClient(cr, er)
+}
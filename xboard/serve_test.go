@@ -0,0 +1,300 @@
+package xboard
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/clfs/chess/uciengine"
+	"github.com/google/go-cmp/cmp"
+)
+
+// testEngine is a UCI engine that mates in one if it can, and otherwise plays
+// its first legal move in coordinate order.
+type testEngine struct {
+	mu       sync.Mutex
+	pos      *chess.Position
+	searches []uci.Search
+}
+
+func (e *testEngine) ID() (name, author string) { return "Test", "Tester" }
+
+func (e *testEngine) Options() []uci.Option {
+	return []uci.Option{uci.CheckOption{Name: "Ponder"}}
+}
+
+func (e *testEngine) SetOption(name, value string) error { return nil }
+
+func (e *testEngine) NewGame() {}
+
+func (e *testEngine) Position(p uci.PositionParams) error {
+	pos := chess.StartingPosition()
+	if !p.StartPos {
+		var err error
+		if pos, err = chess.ParseFEN(p.FEN); err != nil {
+			return err
+		}
+	}
+	for _, m := range p.Moves {
+		pos.Apply(m)
+	}
+	e.mu.Lock()
+	e.pos = pos
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *testEngine) Search(s uci.Search, stop <-chan struct{}, info chan<- uci.Info) uci.BestMove {
+	e.mu.Lock()
+	pos := e.pos.Clone()
+	e.searches = append(e.searches, s)
+	e.mu.Unlock()
+
+	legal := pos.LegalMoves()
+	sort.Slice(legal, func(i, j int) bool { return legal[i].String() < legal[j].String() })
+	best, score := legal[0], uci.Score{CP: 12}
+	for _, m := range legal {
+		pos.Apply(m)
+		if pos.InCheck() && len(pos.LegalMoves()) == 0 {
+			best, score = m, mate(1)
+		}
+		pos.Unapply()
+	}
+	info <- uci.Info{Depth: 1, Score: score, Nodes: 1, PV: []chess.Move{best}}
+	if s.Infinite {
+		<-stop
+	}
+	return uci.BestMove{Move: best}
+}
+
+// lastSearch returns the limits of the last search.
+func (e *testEngine) lastSearch() uci.Search {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.searches[len(e.searches)-1]
+}
+
+// newTestEngine returns a UCI client for a testEngine, after the handshake.
+func newTestEngine(t *testing.T) (*uci.Client, *testEngine) {
+	t.Helper()
+	e := &testEngine{}
+	cr, ew := io.Pipe()
+	er, cw := io.Pipe()
+	go uciengine.Serve(er, ew, e)
+	t.Cleanup(func() {
+		er.Close()
+		cr.Close()
+	})
+	c := uci.NewClient(cr, cw)
+	if _, _, _, err := c.UCI(); err != nil {
+		t.Fatal(err)
+	}
+	return c, e
+}
+
+// serveConn is a connection to Serve, as seen by a GUI.
+type serveConn struct {
+	t     *testing.T
+	w     io.Writer
+	lines chan string
+}
+
+func newServeConn(t *testing.T, c *uci.Client) *serveConn {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	go func() {
+		Serve(sr, sw, c)
+		sw.Close()
+	}()
+	t.Cleanup(func() { cw.Close() })
+
+	conn := &serveConn{t: t, w: cw, lines: make(chan string, 100)}
+	go func() {
+		defer close(conn.lines)
+		s := bufio.NewScanner(cr)
+		for s.Scan() {
+			conn.lines <- s.Text()
+		}
+	}()
+	return conn
+}
+
+func (c *serveConn) send(cmds ...string) {
+	for _, cmd := range cmds {
+		io.WriteString(c.w, cmd+"\n")
+	}
+}
+
+// expect checks the next lines sent by the engine.
+func (c *serveConn) expect(want ...string) {
+	c.t.Helper()
+	var got []string
+	for range want {
+		select {
+		case line := <-c.lines:
+			got = append(got, line)
+		case <-time.After(5 * time.Second):
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		c.t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestServe(t *testing.T) {
+	uc, _ := newTestEngine(t)
+	conn := newServeConn(t, uc)
+
+	conn.send("xboard", "protover 2")
+	conn.expect(
+		`feature myname="Test" ping=1 setboard=1 usermove=1 playother=1 san=0 time=1 draw=0 sigint=0 sigterm=0 reuse=1 analyze=1 colors=0`,
+		`feature option="Ponder -check 0"`,
+		`feature done=1`,
+	)
+
+	// The engine plays Black by default.
+	conn.send("accepted done", "new", "post", "level 40 5 2", "time 29000", "otim 28000", "usermove e2e4")
+	conn.expect("1 12 0 1 a5", "move a7a5")
+
+	conn.send("usermove e2e5", "ping 1")
+	conn.expect("Illegal move: e2e5", "pong 1")
+
+	// In force mode, the engine only moves when told to.
+	conn.send("nopost", "force", "usermove d2d4", "ping 2")
+	conn.expect("pong 2")
+	conn.send("remove", "remove", "go")
+	conn.expect("move a2a3")
+
+	conn.send("new", "setboard 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "sd 4", "go")
+	conn.expect("move a1a8", "1-0 {White mates}")
+
+	conn.send("new", "post", "analyze")
+	conn.expect("1 12 0 1 a3")
+	conn.send("usermove g2g3")
+	conn.expect("1 12 0 1 a5")
+	conn.send("exit", "ping 3")
+	conn.expect("pong 3")
+
+	conn.send("frobnicate", "quit")
+	conn.expect("Error (unknown command): frobnicate")
+	if _, ok := <-conn.lines; ok {
+		t.Error("Serve didn't return after quit")
+	}
+}
+
+func TestServer_Limits(t *testing.T) {
+	cases := []struct {
+		cmds []string
+		pos  string
+		want uci.Search
+	}{
+		{
+			cmds: nil,
+			pos:  "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			want: uci.Search{WhiteTime: 5 * time.Minute, BlackTime: 5 * time.Minute, MovesToGo: 40},
+		},
+		{
+			cmds: []string{"level 40 5 2", "time 29000", "otim 28000"},
+			pos:  "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			want: uci.Search{
+				WhiteTime: 280 * time.Second, BlackTime: 290 * time.Second,
+				WhiteIncrement: 2 * time.Second, BlackIncrement: 2 * time.Second,
+				MovesToGo: 40,
+			},
+		},
+		{
+			cmds: []string{"level 0 2:30 0.5", "sd 8"},
+			pos:  "4k3/8/8/8/8/8/8/4K3 b - - 0 70",
+			want: uci.Search{
+				WhiteTime: 150 * time.Second, BlackTime: 150 * time.Second,
+				WhiteIncrement: 500 * time.Millisecond, BlackIncrement: 500 * time.Millisecond,
+				Depth: 8,
+			},
+		},
+		{
+			cmds: []string{"level 40 5 0", "time 1000"},
+			pos:  "4k3/8/8/8/8/8/8/4K3 b - - 0 45",
+			want: uci.Search{WhiteTime: 5 * time.Minute, BlackTime: 10 * time.Second, MovesToGo: 36},
+		},
+		{
+			cmds: []string{"st 3"},
+			pos:  "4k3/8/8/8/8/8/8/4K3 b - - 0 1",
+			want: uci.Search{MoveTime: 3 * time.Second},
+		},
+	}
+	for _, tc := range cases {
+		s := &server{}
+		s.newGame()
+		for _, cmd := range tc.cmds {
+			name, args, _ := strings.Cut(cmd, " ")
+			if err := s.timeControl(name, args); err != nil {
+				t.Fatalf("%s: %v", cmd, err)
+			}
+		}
+		s.pos = mustParseFEN(t, tc.pos)
+		if diff := cmp.Diff(tc.want, s.limits()); diff != "" {
+			t.Errorf("%q: limits mismatch (-want +got):\n%s", tc.cmds, diff)
+		}
+	}
+
+	s := &server{}
+	for _, cmd := range []string{"level 40", "level x 5 0", "level 40 5:xx 0", "st soon"} {
+		name, args, _ := strings.Cut(cmd, " ")
+		if err := s.timeControl(name, args); err == nil {
+			t.Errorf("%s: want error", cmd)
+		}
+	}
+}
+
+func mustParseFEN(t *testing.T, fen string) *chess.Position {
+	t.Helper()
+	pos, err := chess.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pos
+}
+
+func TestAdaptUCI(t *testing.T) {
+	uc, _ := newTestEngine(t)
+	c := AdaptUCI(uc)
+	f, err := c.Handshake(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.MyName != "Test" || !f.Ping || !f.SetBoard || !f.UserMove {
+		t.Errorf("unexpected features %+v", f)
+	}
+	if diff := cmp.Diff([]string{"Ponder -check 0"}, f.Options); diff != "" {
+		t.Errorf("options mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := c.Position(uci.PositionParams{StartPos: true, Moves: moves("e2e4")}); err != nil {
+		t.Fatal(err)
+	}
+	infoCh, replyCh, err := c.Go(context.Background(), uci.Search{Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infos []uci.Info
+	for info := range infoCh {
+		infos = append(infos, info)
+	}
+	if reply := <-replyCh; reply != (Reply{Move: move("a7a5")}) {
+		t.Errorf("want a7a5, got %+v", reply)
+	}
+	wantInfos := []uci.Info{{Depth: 1, Score: uci.Score{CP: 12}, Nodes: 1, PV: moves("a7a5"), String: "a5"}}
+	if diff := cmp.Diff(wantInfos, infos); diff != "" {
+		t.Errorf("infos mismatch (-want +got):\n%s", diff)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,131 @@
+package xboard
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+)
+
+// mateScore is the score of a mate in 0 moves. A mate in N moves is reported
+// as mateScore+N, and being mated in N moves as -mateScore-N.
+const mateScore = 100000
+
+// ParseThinking parses a line of thinking output, which engines send while
+// searching after "post":
+//
+//	9 156 1084 48000 Nf3 Nc6 Nc3 Nf6
+//
+// The fields are the depth, the score in centipawns, the time in centiseconds,
+// the number of nodes and the principal variation. The variation is in SAN or
+// coordinate notation, and is parsed as moves played from pos, stopping at the
+// first move that can't be parsed. The variation as written is kept in the
+// String field of the result. pos may be nil to skip parsing the variation.
+func ParseThinking(pos *chess.Position, line string) (uci.Info, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return uci.Info{}, fmt.Errorf("xboard: invalid thinking output %q", line)
+	}
+	var n [4]int64
+	for i := range n {
+		// Some engines mark the depth with a suffix, such as "9." or "9&".
+		v, err := strconv.ParseInt(strings.TrimRight(fields[i], ".&"), 10, 64)
+		if err != nil {
+			return uci.Info{}, fmt.Errorf("xboard: invalid thinking output %q", line)
+		}
+		n[i] = v
+	}
+
+	info := uci.Info{
+		Depth: int(n[0]),
+		Time:  time.Duration(n[2]) * 10 * time.Millisecond,
+		Nodes: n[3],
+	}
+	switch score := int(n[1]); {
+	case score >= mateScore:
+		info.Score.Mate.Found = true
+		info.Score.Mate.MovesUntil = score - mateScore
+	case score <= -mateScore:
+		info.Score.Mate.Found = true
+		info.Score.Mate.MovesUntil = score + mateScore
+	default:
+		info.Score.CP = score
+	}
+
+	info.String = strings.Join(fields[4:], " ")
+	if pos != nil {
+		info.PV = parseLine(pos, fields[4:])
+	}
+	return info, nil
+}
+
+// formatThinking formats info, search information for pos, as a line of
+// thinking output. The principal variation is written in SAN.
+func formatThinking(pos *chess.Position, info uci.Info) string {
+	score := info.Score.CP
+	if m := info.Score.Mate; m.Found {
+		if m.MovesUntil > 0 {
+			score = mateScore + m.MovesUntil
+		} else {
+			score = -mateScore + m.MovesUntil
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %d %d %d", info.Depth, score, info.Time/(10*time.Millisecond), info.Nodes)
+	pos = pos.Clone()
+	for _, m := range info.PV {
+		if !pos.IsLegal(m) {
+			break
+		}
+		b.WriteString(" " + chess.FormatSAN(pos, m))
+		pos.Apply(m)
+	}
+	return b.String()
+}
+
+// parseLine parses moves played from pos, ignoring move numbers, and stopping
+// at the first move that can't be parsed.
+func parseLine(pos *chess.Position, words []string) []chess.Move {
+	pos = pos.Clone()
+	var line []chess.Move
+	for _, w := range words {
+		if isMoveNumber(w) {
+			continue
+		}
+		m, err := parseMove(pos, w)
+		if err != nil {
+			break
+		}
+		line = append(line, m)
+		pos.Apply(m)
+	}
+	return line
+}
+
+// isMoveNumber reports whether w is a move number, such as "12." or "12...".
+func isMoveNumber(w string) bool {
+	digits := strings.TrimRight(w, ".")
+	if digits == w || digits == "" {
+		return false
+	}
+	_, err := strconv.Atoi(digits)
+	return err == nil
+}
+
+// parseMove parses a legal move in pos in coordinate notation or SAN.
+func parseMove(pos *chess.Position, s string) (chess.Move, error) {
+	if m, err := chess.ParseMove(s); err == nil {
+		// Engines write castling as the king's move in coordinate
+		// notation, or as the king taking the rook in Chess960.
+		m = pos.ConvertCastling(m, pos.Chess960())
+		if pos.IsLegal(m) {
+			return m, nil
+		}
+		return chess.Move{}, errors.New("xboard: illegal move " + s)
+	}
+	return chess.ParseSAN(pos, s)
+}
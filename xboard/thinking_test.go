@@ -0,0 +1,92 @@
+package xboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clfs/chess"
+	"github.com/clfs/chess/uci"
+	"github.com/google/go-cmp/cmp"
+)
+
+func mate(n int) uci.Score {
+	var s uci.Score
+	s.Mate.Found = true
+	s.Mate.MovesUntil = n
+	return s
+}
+
+func TestParseThinking(t *testing.T) {
+	cases := []struct {
+		line string
+		want uci.Info
+	}{
+		{
+			line: "9 156 1084 48000 Nf3 Nc6 Nc3 Nf6",
+			want: uci.Info{
+				Depth: 9, Score: uci.Score{CP: 156}, Time: 10840 * time.Millisecond, Nodes: 48000,
+				PV: moves("g1f3", "b8c6", "b1c3", "g8f6"), String: "Nf3 Nc6 Nc3 Nf6",
+			},
+		},
+		{
+			line: "4. -20 3 250 1. e2e4 e7e5 2. Nf3",
+			want: uci.Info{
+				Depth: 4, Score: uci.Score{CP: -20}, Time: 30 * time.Millisecond, Nodes: 250,
+				PV: moves("e2e4", "e7e5", "g1f3"), String: "1. e2e4 e7e5 2. Nf3",
+			},
+		},
+		{
+			line: "12 100003 50 1000 e4 e5 Ke3 Nc6",
+			want: uci.Info{
+				Depth: 12, Score: mate(3), Time: 500 * time.Millisecond, Nodes: 1000,
+				PV: moves("e2e4", "e7e5"), String: "e4 e5 Ke3 Nc6",
+			},
+		},
+		{
+			line: "5 -100002 0 10",
+			want: uci.Info{Depth: 5, Score: mate(-2), Nodes: 10},
+		},
+	}
+	for _, tc := range cases {
+		got, err := ParseThinking(chess.StartingPosition(), tc.line)
+		if err != nil {
+			t.Errorf("ParseThinking(%q): %v", tc.line, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("ParseThinking(%q) mismatch (-want +got):\n%s", tc.line, diff)
+		}
+	}
+
+	for _, line := range []string{"", "9 156 1084", "x 1 2 3", "1 2 3 y"} {
+		if _, err := ParseThinking(nil, line); err == nil {
+			t.Errorf("ParseThinking(%q): want error", line)
+		}
+	}
+}
+
+func TestFormatThinking(t *testing.T) {
+	cases := []struct {
+		info uci.Info
+		want string
+	}{
+		{
+			info: uci.Info{Depth: 9, Score: uci.Score{CP: 156}, Time: 10840 * time.Millisecond, Nodes: 48000, PV: moves("g1f3", "b8c6")},
+			want: "9 156 1084 48000 Nf3 Nc6",
+		},
+		{
+			info: uci.Info{Depth: 12, Score: mate(3), PV: moves("e2e4", "e7e5", "e1e3")},
+			want: "12 100003 0 0 e4 e5",
+		},
+		{
+			info: uci.Info{Depth: 5, Score: mate(-2)},
+			want: "5 -100002 0 0",
+		},
+	}
+	for _, tc := range cases {
+		got := formatThinking(chess.StartingPosition(), tc.info)
+		if got != tc.want {
+			t.Errorf("formatThinking(%+v): want %q, got %q", tc.info, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package chess
+
+// Zobrist keys, generated from a fixed seed so that hashes are stable across
+// runs and versions.
+var (
+	zobristPieces    [13][64]uint64 // Indexed by Piece; NoPiece is unused.
+	zobristBlack     uint64         // Black to move.
+	zobristCastling  [4]uint64      // Indexed by castling right.
+	zobristEnPassant [8]uint64      // Indexed by file.
+	zobristPockets   [13][17]uint64 // Indexed by Piece and count, capped at 16.
+)
+
+func init() {
+	state := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 {
+		// SplitMix64.
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		return z ^ z>>31
+	}
+	for pc := WhitePawn; pc <= BlackKing; pc++ {
+		for sq := range zobristPieces[pc] {
+			zobristPieces[pc][sq] = next()
+		}
+	}
+	zobristBlack = next()
+	for i := range zobristCastling {
+		zobristCastling[i] = next()
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = next()
+	}
+	for pc := WhitePawn; pc <= BlackKing; pc++ {
+		for n := 1; n < len(zobristPockets[pc]); n++ {
+			zobristPockets[pc][n] = next()
+		}
+	}
+}
+
+// Hash returns a 64-bit Zobrist hash of the position, for use as a key in
+// transposition tables and caches. It covers the pieces, the side to move, the
+// castling rights and the en passant square, which only counts if a pawn of
+// the side to move stands ready to capture on it, as well as the pieces in
+// hand in Crazyhouse. The move counters don't count, so transposed positions
+// hash the same.
+//
+// Different positions rarely have the same hash, but they can.
+func (p *Position) Hash() uint64 {
+	var h uint64
+	for sq, pc := range p.board {
+		if pc != NoPiece {
+			h ^= zobristPieces[pc][sq]
+		}
+	}
+	if p.sideToMove == Black {
+		h ^= zobristBlack
+	}
+	for i := range zobristCastling {
+		if p.castling&(1<<i) != 0 {
+			h ^= zobristCastling[i]
+		}
+	}
+	if ep := p.enPassant; ep != NoSquare {
+		us := p.sideToMove
+		if pawnAttacks[us.Other()][ep]&p.Pieces(us, Pawn) != 0 {
+			h ^= zobristEnPassant[ep.File()]
+		}
+	}
+	for c, pocket := range p.pockets {
+		for pt, n := range pocket {
+			if n > 16 {
+				n = 16
+			}
+			if n > 0 {
+				h ^= zobristPockets[NewPiece(Color(c), PieceType(pt))][n]
+			}
+		}
+	}
+	return h
+}
@@ -0,0 +1,85 @@
+package chess
+
+import "testing"
+
+func TestPosition_Hash(t *testing.T) {
+	start := StartingPosition()
+
+	// Transpositions hash the same, whatever the move counters.
+	a := StartingPosition()
+	for _, m := range []Move{{From: G1, To: F3}, {From: G8, To: F6}, {From: B1, To: C3}} {
+		a.Apply(m)
+	}
+	b := StartingPosition()
+	for _, m := range []Move{{From: B1, To: C3}, {From: G8, To: F6}, {From: G1, To: F3}} {
+		b.Apply(m)
+	}
+	if a.Hash() != b.Hash() {
+		t.Error("transposed positions hash differently")
+	}
+	if a.Hash() == start.Hash() {
+		t.Error("different positions hash the same")
+	}
+
+	// Unapply restores the hash.
+	for len(a.history) > 0 {
+		a.Unapply()
+	}
+	if a.Hash() != start.Hash() {
+		t.Error("Unapply didn't restore the hash")
+	}
+
+	cases := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{
+			name: "side to move",
+			a:    "4k3/8/8/8/8/8/8/4K3 w - - 0 1",
+			b:    "4k3/8/8/8/8/8/8/4K3 b - - 0 1",
+		},
+		{
+			name: "castling rights",
+			a:    "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			b:    "r3k2r/8/8/8/8/8/8/R3K2R w KQk - 0 1",
+		},
+		{
+			name: "move counters",
+			a:    "4k3/8/8/8/8/8/8/4K3 w - - 0 1",
+			b:    "4k3/8/8/8/8/8/8/4K3 w - - 12 40",
+			same: true,
+		},
+		{
+			name: "en passant without a capturing pawn",
+			a:    "4k3/8/8/8/4P3/8/8/4K3 b - e3 0 1",
+			b:    "4k3/8/8/8/4P3/8/8/4K3 b - - 0 1",
+			same: true,
+		},
+		{
+			name: "en passant with a capturing pawn",
+			a:    "4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1",
+			b:    "4k3/8/8/8/3pP3/8/8/4K3 b - - 0 1",
+		},
+	}
+	for _, tc := range cases {
+		a, err := ParseFEN(tc.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseFEN(tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if same := a.Hash() == b.Hash(); same != tc.same {
+			t.Errorf("%s: same hash = %t, want %t", tc.name, same, tc.same)
+		}
+	}
+}
+
+func BenchmarkPosition_Hash(b *testing.B) {
+	p := StartingPosition()
+	for i := 0; i < b.N; i++ {
+		p.Hash()
+	}
+}